@@ -0,0 +1,247 @@
+// Package clientinfo derives privacy-preserving information about the callers of a GraphQL server,
+// to be used as telemetry tags, rate limiting keys, or audit log fields.
+//
+// The Middleware computes the information once per request, and stores it in the request context:
+//
+//	http.Handle("/query", clientinfo.Middleware(srv,
+//		clientinfo.TrustedProxies("10.0.0.0/8"),
+//		clientinfo.HMAC(secret),
+//	))
+//
+// Client IPs are never exposed as is: the client ID is the IP truncated to its network (/24 for IPv4 and
+// /48 for IPv6 by default), and optionally hashed with a secret key.
+//
+// Callers are classified by user agent (browser, mobile app or server), with a pluggable Classifier.
+//
+// Client apps identify themselves with the headers of Apollo clients, or with the headers set by ClientHeaders. The
+// extensions reporting client apps read them with AppOf.
+//
+// Callers may be located with a pluggable Locator, to tag telemetry with their country and region.
+// See the maxmind subpackage for an implementation based on MaxMind GeoIP2 databases.
+package clientinfo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opencensus.io/tag"
+)
+
+type infoKey struct{}
+
+const (
+	// DefaultNameHeader is the default header identifying the name of the client app
+	DefaultNameHeader = "apollographql-client-name"
+
+	// DefaultVersionHeader is the default header identifying the version of the client app
+	DefaultVersionHeader = "apollographql-client-version"
+)
+
+// Opencensus tags inserted in the request context with the Tagged option.
+// Add the tags to the keys of a view to aggregate metrics by client, country or region.
+var (
+	// TagClientID is the client ID
+	TagClientID = tag.MustNewKey("gql.client")
+
+	// TagCountry is the country of the caller
+	TagCountry = tag.MustNewKey("gql.client.country")
+
+	// TagRegion is the region of the caller
+	TagRegion = tag.MustNewKey("gql.client.region")
+
+	// TagClass is the class of the caller
+	TagClass = tag.MustNewKey("gql.client.class")
+)
+
+type (
+	// Info about the caller
+	Info struct {
+		// ClientID is the anonymized IP of the caller
+		ClientID string
+
+		// Class of the caller, from its user agent
+		Class Class
+
+		// App of the caller, from the client headers
+		App App
+
+		Location
+	}
+
+	// App is the client app identifying itself, e.g. "web" version "1.2.0"
+	App struct {
+		Name    string
+		Version string
+	}
+
+	// Location is the coarse geographical location of the caller
+	Location struct {
+		// Country is an ISO 3166-1 country code, e.g. "US"
+		Country string
+
+		// Region is an ISO 3166-2 subdivision code, e.g. "US-CA"
+		Region string
+	}
+
+	// Locator resolves the location of an IP. It yields false for unknown IPs.
+	Locator interface {
+		Locate(net.IP) (Location, bool)
+	}
+
+	// Attribute is a name/value pair describing the caller, for span attributes or log parameters
+	Attribute struct {
+		Key   string
+		Value string
+	}
+)
+
+// Middleware computes information about the caller, available with FromContext
+func Middleware(next http.Handler, opts ...Option) http.Handler {
+	cfg := defaultConfig()
+	for _, apply := range opts {
+		apply(&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := cfg.clientIP(r)
+		info := Info{
+			ClientID: cfg.clientID(ip),
+			Class:    cfg.classifier(r.UserAgent()),
+			App: App{
+				Name:    r.Header.Get(cfg.nameHeader),
+				Version: r.Header.Get(cfg.versionHeader),
+			},
+		}
+		if cfg.locator != nil && ip != nil {
+			info.Location, _ = cfg.locator.Locate(ip)
+		}
+
+		ctx := context.WithValue(r.Context(), infoKey{}, info)
+		if cfg.tagged {
+			ctx, _ = tag.New(ctx,
+				tag.Upsert(TagClientID, info.ClientID),
+				tag.Upsert(TagClass, valueOrUnknown(string(info.Class))),
+				tag.Upsert(TagCountry, valueOrUnknown(info.Country)),
+				tag.Upsert(TagRegion, valueOrUnknown(info.Region)),
+			)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// FromContext retrieves the information computed by the Middleware
+func FromContext(ctx context.Context) (Info, bool) {
+	info, ok := ctx.Value(infoKey{}).(Info)
+	return info, ok
+}
+
+// ClientID of the caller, or an empty string when the Middleware is not installed
+func ClientID(ctx context.Context) string {
+	info, _ := FromContext(ctx)
+	return info.ClientID
+}
+
+// AppOf yields the client app computed by the Middleware. Without the Middleware, the app is read from the default
+// headers of the request, e.g. the headers of the operation context of gqlgen.
+func AppOf(ctx context.Context, header http.Header) App {
+	if info, ok := FromContext(ctx); ok {
+		return info.App
+	}
+	return App{
+		Name:    header.Get(DefaultNameHeader),
+		Version: header.Get(DefaultVersionHeader),
+	}
+}
+
+// Attributes describing the caller, omitting unknown values
+func (i Info) Attributes() []Attribute {
+	attrs := make([]Attribute, 0, 4)
+	for _, attr := range []Attribute{
+		{Key: "client", Value: i.ClientID},
+		{Key: "class", Value: string(i.Class)},
+		{Key: "country", Value: i.Country},
+		{Key: "region", Value: i.Region},
+	} {
+		if attr.Value != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// Attributes describing the caller stored in the context, if any
+func Attributes(ctx context.Context) []Attribute {
+	info, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return info.Attributes()
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// clientIP is the remote address, or the rightmost untrusted address of the forwarded header when
+// the request comes from a trusted proxy
+func (c *config) clientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !c.trusted(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values(c.forwardedHeader), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !c.trusted(hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func (c *config) trusted(ip net.IP) bool {
+	for _, network := range c.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientID truncates the IP to its network, then hashes it when a key is configured
+func (c *config) clientID(ip net.IP) string {
+	if ip == nil {
+		return "unknown"
+	}
+
+	var id string
+	if v4 := ip.To4(); v4 != nil {
+		id = v4.Mask(net.CIDRMask(c.v4Bits, 32)).String()
+	} else {
+		id = ip.Mask(net.CIDRMask(c.v6Bits, 128)).String()
+	}
+
+	if len(c.hmacKey) == 0 {
+		return id
+	}
+	mac := hmac.New(sha256.New, c.hmacKey)
+	_, _ = mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
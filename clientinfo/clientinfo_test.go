@@ -0,0 +1,111 @@
+package clientinfo
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/tag"
+)
+
+func clientID(remoteAddr, forwarded string, opts ...Option) (id string, tagged string) {
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = ClientID(r.Context())
+		tagged, _ = tag.FromContext(r.Context()).Value(TagClientID)
+	}), opts...)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.RemoteAddr = remoteAddr
+	if forwarded != "" {
+		req.Header.Set("X-Forwarded-For", forwarded)
+	}
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	return
+}
+
+func TestClientID(t *testing.T) {
+	id, tagged := clientID("203.0.113.7:4242", "198.51.100.1")
+	assert.Equal(t, "203.0.113.0", id, "forwarded header from untrusted peers is ignored")
+	assert.Empty(t, tagged)
+
+	id, _ = clientID("[2001:db8:1:2::7]:4242", "")
+	assert.Equal(t, "2001:db8:1::", id)
+
+	proxies := TrustedProxies("10.0.0.0/8")
+	id, _ = clientID("10.0.0.1:4242", "198.51.100.1, 192.0.2.9, 10.1.1.1", proxies)
+	assert.Equal(t, "192.0.2.0", id, "the rightmost untrusted hop is the client")
+
+	id, _ = clientID("10.0.0.1:4242", "", proxies, Truncate(16, 32))
+	assert.Equal(t, "10.0.0.0", id)
+
+	id, tagged = clientID("203.0.113.7:4242", "", HMAC([]byte("secret")), Tagged())
+	other, _ := clientID("203.0.113.200:4242", "", HMAC([]byte("secret")))
+	assert.Len(t, id, 16)
+	assert.Equal(t, id, other)
+	assert.Equal(t, id, tagged)
+
+	id, _ = clientID("@", "")
+	assert.Equal(t, "unknown", id)
+}
+
+type locator map[string]Location
+
+func (l locator) Locate(ip net.IP) (Location, bool) {
+	loc, ok := l[ip.String()]
+	return loc, ok
+}
+
+func TestLocate(t *testing.T) {
+	var info Info
+	var country string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, _ = FromContext(r.Context())
+		country, _ = tag.FromContext(r.Context()).Value(TagCountry)
+	}), Locate(locator{"203.0.113.7": {Country: "FR", Region: "FR-IDF"}}), Tagged())
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.RemoteAddr = "203.0.113.7:4242"
+	req.Header.Set("User-Agent", "curl/7.68.0")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, Info{ClientID: "203.0.113.0", Class: ClassServer, Location: Location{Country: "FR", Region: "FR-IDF"}}, info)
+	assert.Equal(t, "FR", country)
+	assert.Equal(t, []Attribute{{"client", "203.0.113.0"}, {"class", "server"}, {"country", "FR"}, {"region", "FR-IDF"}}, info.Attributes())
+	assert.Nil(t, Attributes(context.Background()))
+}
+
+func TestClassifyUserAgent(t *testing.T) {
+	for ua, class := range map[string]Class{
+		"": ClassUnknown,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0 Safari/605.1.15": ClassBrowser,
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 14_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148":                             ClassBrowser,
+		"Todo/42 CFNetwork/1206 Darwin/20.1.0": ClassIOS,
+		"okhttp/4.9.0":                         ClassAndroid,
+		"Dalvik/2.1.0 (Linux; U; Android 11)":  ClassAndroid,
+		"Go-http-client/1.1":                   ClassServer,
+		"python-requests/2.25.1":               ClassServer,
+		"my-custom-agent":                      ClassUnknown,
+	} {
+		assert.Equal(t, class, ClassifyUserAgent(ua), ua)
+	}
+}
+
+func TestAppOf(t *testing.T) {
+	header := http.Header{}
+	header.Set(DefaultNameHeader, "web")
+	header.Set(DefaultVersionHeader, "1.2.0")
+	header.Set("X-Client", "ios")
+	assert.Equal(t, App{Name: "web", Version: "1.2.0"}, AppOf(context.Background(), header), "default headers without the middleware")
+
+	var app App
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		app = AppOf(r.Context(), r.Header)
+	}), ClientHeaders("X-Client", "X-Client-Version"))
+	req := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+	req.Header = header
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, App{Name: "ios"}, app)
+}
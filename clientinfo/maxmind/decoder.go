@@ -0,0 +1,208 @@
+package maxmind
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+const maxDepth = 32
+
+var errTruncated = errors.New("truncated data")
+
+// data types of the MaxMind DB format
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// decoder of the data section. Pointers are offsets from the start of the section.
+type decoder []byte
+
+// decode the value at some offset, yielding the offset of the next value
+func (d decoder) decode(offset uint, depth int) (interface{}, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, errors.New("data structure too deep")
+	}
+
+	ctrl, err := d.byteAt(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset++
+
+	typ := int(ctrl >> 5)
+	if typ == typeExtended {
+		ext, err := d.byteAt(offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		typ = 7 + int(ext)
+		offset++
+	}
+
+	if typ == typePointer {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var key, value interface{}
+			if key, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("invalid map key: %v", key)
+			}
+			m[k] = value
+		}
+		return m, offset, nil
+
+	case typeArray:
+		a := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var value interface{}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			a = append(a, value)
+		}
+		return a, offset, nil
+
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	b, err := d.slice(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += size
+
+	switch typ {
+	case typeString:
+		return string(b), offset, nil
+	case typeBytes:
+		return append([]byte(nil), b...), offset, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size: %d", size)
+		}
+		return math.Float64frombits(bigEndian(b)), offset, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size: %d", size)
+		}
+		return float64(math.Float32frombits(uint32(bigEndian(b)))), offset, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid integer size: %d", size)
+		}
+		return bigEndian(b), offset, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("invalid integer size: %d", size)
+		}
+		return int32(uint32(bigEndian(b))), offset, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(b), offset, nil
+	default:
+		return nil, 0, fmt.Errorf("unexpected data type: %d", typ)
+	}
+}
+
+func (d decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	n := uint(ctrl>>3&0x3) + 1
+	b, err := d.slice(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var pointer uint
+	switch n {
+	case 1:
+		pointer = uint(ctrl&0x7)<<8 | uint(b[0])
+	case 2:
+		pointer = (uint(ctrl&0x7)<<16 | uint(bigEndian(b))) + 2048
+	case 3:
+		pointer = (uint(ctrl&0x7)<<24 | uint(bigEndian(b))) + 526336
+	default:
+		pointer = uint(bigEndian(b))
+	}
+	return pointer, offset + n, nil
+}
+
+func (d decoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	n := size - 28
+	b, err := d.slice(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch n {
+	case 1:
+		size = 29 + uint(b[0])
+	case 2:
+		size = 285 + uint(bigEndian(b))
+	default:
+		size = 65821 + uint(bigEndian(b))
+	}
+	return size, offset + n, nil
+}
+
+func (d decoder) byteAt(offset uint) (byte, error) {
+	if offset >= uint(len(d)) {
+		return 0, errTruncated
+	}
+	return d[offset], nil
+}
+
+func (d decoder) slice(offset, size uint) ([]byte, error) {
+	if offset+size > uint(len(d)) {
+		return nil, errTruncated
+	}
+	return d[offset : offset+size], nil
+}
+
+func bigEndian(b []byte) uint64 {
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u
+}
@@ -0,0 +1,163 @@
+// Package maxmind locates callers with a MaxMind GeoIP2 or GeoLite2 country or city database.
+//
+// It reads databases in the MaxMind DB format (.mmdb) without any third-party dependency:
+//
+//	db, err := maxmind.Open("/var/lib/GeoIP/GeoLite2-City.mmdb")
+//	if err != nil {
+//		return err
+//	}
+//	http.Handle("/query", clientinfo.Middleware(srv, clientinfo.Locate(db)))
+//
+// See https://maxmind.github.io/MaxMind-DB/ for the specification of the format.
+package maxmind
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+)
+
+var _ clientinfo.Locator = &Reader{}
+
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Reader of a MaxMind database, held in memory. It is safe for concurrent use.
+type Reader struct {
+	tree       []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+}
+
+// Open a MaxMind database file
+func Open(path string) (*Reader, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes loads a MaxMind database from its content
+func FromBytes(buf []byte) (*Reader, error) {
+	start := bytes.LastIndex(buf, metadataMarker)
+	if start < 0 {
+		return nil, errors.New("maxmind: invalid database: metadata not found")
+	}
+	meta, _, err := decoder(buf[start+len(metadataMarker):]).decode(0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("maxmind: invalid metadata: %w", err)
+	}
+	metadata, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("maxmind: invalid metadata")
+	}
+
+	r := &Reader{
+		nodeCount:  uint(toUint(metadata["node_count"])),
+		recordSize: uint(toUint(metadata["record_size"])),
+		ipVersion:  uint(toUint(metadata["ip_version"])),
+	}
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, fmt.Errorf("maxmind: unsupported record size: %d", r.recordSize)
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+16 > uint(start) {
+		return nil, errors.New("maxmind: invalid database: truncated search tree")
+	}
+	r.tree = buf[:treeSize]
+	r.data = buf[treeSize+16 : start]
+
+	if r.ipVersion == 6 {
+		// IPv4 addresses are mapped to ::/96
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			r.ipv4Start = r.readNode(r.ipv4Start, 0)
+		}
+	}
+	return r, nil
+}
+
+// Lookup the record of an IP. It yields nil for unknown IPs, and an error for malformed IPs.
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+	node := uint(0)
+	bits := 128
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits = v4, 32
+		node = r.ipv4Start
+	} else if len(ip) != net.IPv6len {
+		return nil, fmt.Errorf("maxmind: invalid IP of %d bytes", len(ip))
+	} else if r.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < bits && node < r.nodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i&7))) & 1
+		node = r.readNode(node, bit)
+	}
+	if node <= r.nodeCount {
+		return nil, nil
+	}
+
+	value, _, err := decoder(r.data).decode(node-r.nodeCount-16, 0)
+	return value, err
+}
+
+// Locate an IP, with the country and first subdivision of its record
+func (r *Reader) Locate(ip net.IP) (clientinfo.Location, bool) {
+	record, err := r.Lookup(ip)
+	if err != nil || record == nil {
+		return clientinfo.Location{}, false
+	}
+
+	var loc clientinfo.Location
+	loc.Country, _ = path(record, "country", "iso_code").(string)
+	if loc.Country == "" {
+		loc.Country, _ = path(record, "registered_country", "iso_code").(string)
+	}
+	if subdivisions, ok := path(record, "subdivisions").([]interface{}); ok && len(subdivisions) > 0 && loc.Country != "" {
+		if code, ok := path(subdivisions[0], "iso_code").(string); ok && code != "" {
+			loc.Region = loc.Country + "-" + code
+		}
+	}
+	return loc, loc.Country != ""
+}
+
+func (r *Reader) readNode(node, bit uint) uint {
+	switch r.recordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		b := r.tree[node*8+bit*4:]
+		return uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3])
+	}
+}
+
+func path(value interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+func toUint(value interface{}) uint64 {
+	u, _ := value.(uint64)
+	return u
+}
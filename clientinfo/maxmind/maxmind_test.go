@@ -0,0 +1,100 @@
+package maxmind
+
+import (
+	"bytes"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+)
+
+// encode values in the MaxMind DB format (sizes < 29 only)
+func encode(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		buf.WriteByte(typeString<<5 | byte(len(v)))
+		buf.WriteString(v)
+	case uint16:
+		buf.Write([]byte{typeUint16<<5 | 2, byte(v >> 8), byte(v)})
+	case uint32:
+		buf.Write([]byte{typeUint32<<5 | 4, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+	case []interface{}:
+		buf.Write([]byte{byte(len(v)), typeArray - 7})
+		for _, item := range v {
+			encode(buf, item)
+		}
+	case map[string]interface{}:
+		buf.WriteByte(typeMap<<5 | byte(len(v)))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			encode(buf, key)
+			encode(buf, v[key])
+		}
+	}
+}
+
+// database mapping a single IPv4 network to a record, in an IPv6 tree with 24 bits records
+func database(network string, record interface{}) []byte {
+	_, ipnet, _ := net.ParseCIDR(network)
+	ones, _ := ipnet.Mask.Size()
+	prefix := make(net.IP, 16) // IPv4 networks are mapped to ::/96
+	copy(prefix[12:], ipnet.IP.To4())
+	bits := 96 + ones
+
+	nodeCount := uint32(bits)
+	var buf bytes.Buffer
+	for i := 0; i < bits; i++ {
+		next := uint32(i + 1)
+		if i == bits-1 {
+			next = nodeCount + 16 // data section offset 0
+		}
+		records := [2]uint32{nodeCount, nodeCount}
+		records[prefix[i>>3]>>(7-uint(i&7))&1] = next
+		for _, r := range records {
+			buf.Write([]byte{byte(r >> 16), byte(r >> 8), byte(r)})
+		}
+	}
+	buf.Write(make([]byte, 16))
+	encode(&buf, record)
+	buf.Write(metadataMarker)
+	encode(&buf, map[string]interface{}{
+		"node_count":  nodeCount,
+		"record_size": uint16(24),
+		"ip_version":  uint16(6),
+	})
+	return buf.Bytes()
+}
+
+func TestReader(t *testing.T) {
+	db, err := FromBytes(database("203.0.113.0/24", map[string]interface{}{
+		"country":      map[string]interface{}{"iso_code": "US"},
+		"subdivisions": []interface{}{map[string]interface{}{"iso_code": "CA"}},
+	}))
+	require.NoError(t, err)
+
+	loc, ok := db.Locate(net.ParseIP("203.0.113.42"))
+	assert.True(t, ok)
+	assert.Equal(t, clientinfo.Location{Country: "US", Region: "US-CA"}, loc)
+
+	_, ok = db.Locate(net.ParseIP("198.51.100.1"))
+	assert.False(t, ok)
+
+	_, ok = db.Locate(net.ParseIP("2001:db8::1"))
+	assert.False(t, ok)
+
+	_, err = db.Lookup(net.IP{1, 2, 3})
+	assert.Error(t, err, "malformed IPs are not looked up")
+	_, ok = db.Locate(nil)
+	assert.False(t, ok)
+
+	_, err = FromBytes([]byte("not a database"))
+	assert.Error(t, err)
+}
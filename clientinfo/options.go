@@ -0,0 +1,106 @@
+package clientinfo
+
+import (
+	"fmt"
+	"net"
+)
+
+// Option for the client information middleware
+type Option func(*config)
+
+type config struct {
+	trustedProxies  []*net.IPNet
+	forwardedHeader string
+	nameHeader      string
+	versionHeader   string
+	v4Bits          int
+	v6Bits          int
+	hmacKey         []byte
+	locator         Locator
+	classifier      Classifier
+	tagged          bool
+}
+
+func defaultConfig() config {
+	return config{
+		forwardedHeader: "X-Forwarded-For",
+		nameHeader:      DefaultNameHeader,
+		versionHeader:   DefaultVersionHeader,
+		v4Bits:          24,
+		v6Bits:          48,
+		classifier:      ClassifyUserAgent,
+	}
+}
+
+// TrustedProxies are the networks of the proxies in front of the server, in CIDR notation, e.g. "10.0.0.0/8".
+// The client IP is taken from the forwarded header only for requests coming from trusted proxies.
+// By default, no proxy is trusted, and the client IP is the remote address of the connection.
+//
+// It panics on invalid networks.
+func TrustedProxies(cidrs ...string) Option {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Errorf("clientinfo: invalid trusted proxy network: %w", err))
+		}
+		networks = append(networks, network)
+	}
+
+	return func(c *config) {
+		c.trustedProxies = append(c.trustedProxies, networks...)
+	}
+}
+
+// ForwardedHeader is the header listing forwarded client IPs. The default is "X-Forwarded-For".
+func ForwardedHeader(header string) Option {
+	return func(c *config) {
+		c.forwardedHeader = header
+	}
+}
+
+// ClientHeaders sets the HTTP headers identifying the name and version of the client app, shared by the extensions
+// reporting them. The defaults are DefaultNameHeader and DefaultVersionHeader.
+func ClientHeaders(name, version string) Option {
+	return func(c *config) {
+		c.nameHeader = name
+		c.versionHeader = version
+	}
+}
+
+// Truncate IPs to their network prefix. The defaults are 24 bits for IPv4, and 48 bits for IPv6.
+func Truncate(v4Bits, v6Bits int) Option {
+	return func(c *config) {
+		c.v4Bits = v4Bits
+		c.v6Bits = v6Bits
+	}
+}
+
+// HMAC hashes truncated IPs with HMAC-SHA256 and a secret key, so that client IDs can't be mapped back to networks
+func HMAC(key []byte) Option {
+	return func(c *config) {
+		c.hmacKey = key
+	}
+}
+
+// Locate callers with a Locator, e.g. a GeoIP database. Locations are resolved from the full IP, before truncation.
+func Locate(locator Locator) Option {
+	return func(c *config) {
+		c.locator = locator
+	}
+}
+
+// Classify callers with a custom user agent Classifier. The default is ClassifyUserAgent.
+func Classify(classifier Classifier) Option {
+	return func(c *config) {
+		c.classifier = classifier
+	}
+}
+
+// Tagged inserts the client ID, class and location in the opencensus tags of the request context,
+// as TagClientID, TagClass, TagCountry and TagRegion
+func Tagged() Option {
+	return func(c *config) {
+		c.tagged = true
+	}
+}
@@ -0,0 +1,51 @@
+package clientinfo
+
+import "strings"
+
+// Class of callers, with a low cardinality suitable for telemetry tags
+type Class string
+
+// Classes of callers
+const (
+	ClassUnknown Class = "unknown"
+	ClassBrowser Class = "browser"
+	ClassIOS     Class = "ios"
+	ClassAndroid Class = "android"
+	ClassServer  Class = "server"
+)
+
+// Classifier classifies callers from their user agent
+type Classifier func(userAgent string) Class
+
+var serverAgents = []string{
+	"curl/", "wget/", "go-http-client/", "python-requests/", "python-urllib/", "aiohttp/", "java/",
+	"apache-httpclient/", "node-fetch", "axios/", "undici", "ruby", "faraday", "guzzle", "libwww-perl/",
+	"graphql-client", "apollo-",
+}
+
+// ClassifyUserAgent is the default Classifier, recognizing user agents of browsers, native mobile apps
+// and common HTTP client libraries
+func ClassifyUserAgent(userAgent string) Class {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return ClassUnknown
+	}
+	browser := strings.HasPrefix(ua, "mozilla/") || strings.HasPrefix(ua, "opera/")
+
+	switch {
+	case !browser && (strings.Contains(ua, "cfnetwork") || strings.Contains(ua, "iphone") ||
+		strings.Contains(ua, "ipad") || strings.Contains(ua, "ios")):
+		return ClassIOS
+	case !browser && (strings.Contains(ua, "android") || strings.Contains(ua, "dalvik") || strings.Contains(ua, "okhttp")):
+		return ClassAndroid
+	case browser:
+		return ClassBrowser
+	}
+
+	for _, agent := range serverAgents {
+		if strings.Contains(ua, agent) {
+			return ClassServer
+		}
+	}
+	return ClassUnknown
+}
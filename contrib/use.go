@@ -0,0 +1,179 @@
+// Package contrib registers the extensions of gqlgen-contrib on a server in the right order:
+//
+//	err := contrib.Use(srv,
+//		metrics.New(),
+//		gqlopencensus.New(),
+//		gqlsyslog.New(w),
+//		gqlarmor.New(gqlarmor.Default()),
+//		extension.AutomaticPersistedQuery{Cache: cache},
+//	)
+//
+// registers the armor, then persisted queries, the tracer, metrics and the audit log, whatever the order of the
+// arguments.
+//
+// The first extension used on a gqlgen server intercepts operations first: guards go first, to reject operations
+// before any work is done on them; persisted queries are resolved before tracing, so that spans know the operation;
+// tracing goes before metrics and logging, so that they run within the span of the operation; caches go last, so that
+// cached responses are traced, counted and logged like the others.
+package contrib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+)
+
+// Stages of extensions, in the order they are used
+const (
+	StageGuard = iota
+	StagePersistedQuery
+	StageTracing
+	StageMetrics
+	StageLogging
+	StageCache
+	StageOther
+)
+
+// stages of the known extensions, by extension name: every extension of gqlgen-contrib has one. Other extensions come
+// last, in the order they are given.
+var stages = map[string]int{
+	"DocumentLimit":                 StageGuard,
+	"DepthLimit":                    StageGuard,
+	"AliasLimit":                    StageGuard,
+	"DirectiveLimit":                StageGuard,
+	"HideSuggestions":               StageGuard,
+	"Armor":                         StageGuard,
+	"Maintenance":                   StageGuard,
+	"LoadShedding":                  StageGuard,
+	"RateLimit":                     StageGuard,
+	"Quota":                         StageGuard,
+	"ConcurrencyLimit":              StageGuard,
+	"Timeout":                       StageGuard,
+	"IntrospectionGate":             StageGuard,
+	"OperationFilter":               StageGuard,
+	"Authorization":                 StageGuard,
+	"CircuitBreaker":                StageGuard,
+	"SubscriptionLimit":             StageGuard,
+	"CostLimit":                     StageGuard,
+	"ComplexityLimit":               StageGuard,
+	"AutomaticPersistedQuery":       StagePersistedQuery,
+	"RelayPersistedQueries":         StagePersistedQuery,
+	"PersistedOperationSafelist":    StagePersistedQuery,
+	"Opencensustracing":             StageTracing,
+	"Opentracing":                   StageTracing,
+	"ApolloTracing":                 StageTracing,
+	"ApolloFederatedTracing":        StageTracing,
+	"ApolloFederatedTracingGateway": StageTracing,
+	"Telemetry":                     StageTracing,
+	"WebsocketTracing":              StageTracing,
+	"PprofLabels":                   StageTracing,
+	"OpencensusMetrics":             StageMetrics,
+	"PrometheusMetrics":             StageMetrics,
+	"ApolloStudioUsageReporting":    StageMetrics,
+	"OperationStats":                StageMetrics,
+	"ServerTiming":                  StageMetrics,
+	"SchemaUsage":                   StageMetrics,
+	"BigQueryAnalytics":             StageMetrics,
+	"OperationEvents":               StageMetrics,
+	"CostAccounting":                StageMetrics,
+	"Debug":                         StageMetrics,
+	"ResolveTree":                   StageMetrics,
+	"SubscriptionMetrics":           StageMetrics,
+	"Health":                        StageMetrics,
+	"SyslogAudit":                   StageLogging,
+	"CacheControl":                  StageCache,
+	"ResponseCache":                 StageCache,
+	"FieldCache":                    StageCache,
+}
+
+// conflicts between extensions: the first extension already includes, or clashes with, the others
+var conflicts = []struct {
+	extension string
+	others    []string
+	reason    string
+}{
+	{"Armor", []string{"DocumentLimit", "DepthLimit", "AliasLimit", "DirectiveLimit", "HideSuggestions"}, "the armor already includes it"},
+	{"Telemetry", []string{"Opencensustracing", "OpencensusMetrics"}, "the telemetry already includes it"},
+	{"CostLimit", []string{"ComplexityLimit"}, "both limit the cost of operations, with different costs"},
+}
+
+// Bundle is implemented by extensions composing other extensions, e.g. the telemetry with its armor
+type Bundle interface {
+	Extensions() []graphql.HandlerExtension
+}
+
+// Use extensions on a server, in the order of their stage. Extensions of the same stage keep the order of arguments.
+//
+// Arguments are gqlgen extensions, or bundles of extensions. Nothing is used on the server when an argument is not an
+// extension, when an extension is used twice, or when extensions conflict.
+func Use(srv *handler.Server, exts ...interface{}) error {
+	extensions, err := Order(exts...)
+	if err != nil {
+		return err
+	}
+	for _, ext := range extensions {
+		srv.Use(ext)
+	}
+	return nil
+}
+
+// Order extensions as Use does, without using them
+func Order(exts ...interface{}) ([]graphql.HandlerExtension, error) {
+	extensions := make([]graphql.HandlerExtension, 0, len(exts))
+	for _, ext := range exts {
+		switch ext := ext.(type) {
+		case Bundle:
+			extensions = append(extensions, ext.Extensions()...)
+		case graphql.HandlerExtension:
+			extensions = append(extensions, ext)
+		default:
+			return nil, fmt.Errorf("contrib: %T is not a gqlgen extension", ext)
+		}
+	}
+
+	if err := validate(extensions); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(extensions, func(i, j int) bool {
+		return stage(extensions[i]) < stage(extensions[j])
+	})
+	return extensions, nil
+}
+
+func stage(ext graphql.HandlerExtension) int {
+	if s, ok := stages[ext.ExtensionName()]; ok {
+		return s
+	}
+	return StageOther
+}
+
+func validate(extensions []graphql.HandlerExtension) error {
+	names := make(map[string]bool, len(extensions))
+	for _, ext := range extensions {
+		name := ext.ExtensionName()
+		if names[name] {
+			return fmt.Errorf("contrib: %s is used twice", name)
+		}
+		names[name] = true
+	}
+
+	for _, c := range conflicts {
+		if !names[c.extension] {
+			continue
+		}
+		var clashing []string
+		for _, other := range c.others {
+			if names[other] {
+				clashing = append(clashing, other)
+			}
+		}
+		if len(clashing) > 0 {
+			return fmt.Errorf("contrib: %s conflicts with %s: %s", c.extension, strings.Join(clashing, ", "), c.reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package contrib
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqlarmor"
+	"github.com/99designs/gqlgen-contrib/gqlcachecontrol"
+	"github.com/99designs/gqlgen-contrib/gqlguard"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlsyslog"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+	"github.com/99designs/gqlgen-contrib/prometheus"
+	"github.com/99designs/gqlgen-contrib/telemetry"
+)
+
+func names(extensions []graphql.HandlerExtension) []string {
+	names := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		names = append(names, ext.ExtensionName())
+	}
+	return names
+}
+
+func TestOrder(t *testing.T) {
+	extensions, err := Order(
+		gqlcachecontrol.New(),
+		metrics.New(),
+		gqlsyslog.New(gqlsyslog.NewWriter(ioutil.Discard)),
+		gqlopencensus.New(),
+		extension.AutomaticPersistedQuery{Cache: graphql.MapCache{}},
+		gqlguard.DepthLimit{Max: 10},
+		gqlguard.AliasLimit{Max: 10},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DepthLimit",
+		"AliasLimit",
+		"AutomaticPersistedQuery",
+		"Opencensustracing",
+		"OpencensusMetrics",
+		"SyslogAudit",
+		"CacheControl",
+	}, names(extensions))
+
+	limits := gqlarmor.Default()
+	tel, err := telemetry.New(telemetry.Config{Limits: &limits})
+	require.NoError(t, err)
+	extensions, err = Order(prometheus.Metrics{}, tel)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Armor", "Telemetry", "PrometheusMetrics"}, names(extensions))
+}
+
+func TestOrderErrors(t *testing.T) {
+	limits := gqlarmor.Default()
+	tel, err := telemetry.New(telemetry.Config{Limits: &limits})
+	require.NoError(t, err)
+
+	for expected, exts := range map[string][]interface{}{
+		"contrib: string is not a gqlgen extension": {gqlopencensus.New(), "metrics"},
+		"contrib: Opencensustracing is used twice":  {gqlopencensus.New(), gqlopencensus.New()},
+		"contrib: Armor conflicts with DepthLimit, HideSuggestions: the armor already includes it": {
+			gqlguard.HideSuggestions{}, gqlarmor.New(limits), gqlguard.DepthLimit{},
+		},
+		"contrib: Telemetry conflicts with Opencensustracing: the telemetry already includes it": {
+			gqlopencensus.New(), tel,
+		},
+	} {
+		_, err := Order(exts...)
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func TestUse(t *testing.T) {
+	srv := handler.New(testschema.New(`type Query { todo: String }`, nil))
+	require.NoError(t, Use(srv, gqlopencensus.New(), gqlguard.DepthLimit{Max: 10}))
+	require.Error(t, Use(srv, 42))
+}
+
+func TestStages(t *testing.T) {
+	shipped := shippedExtensions(t, "..")
+	require.NotEmpty(t, shipped)
+	for _, name := range shipped {
+		_, ok := stages[name]
+		assert.True(t, ok, "extension %s has no stage", name)
+	}
+}
+
+// shippedExtensions walks the packages of the repository, and yields the names returned by the ExtensionName methods,
+// as string literals or string constants of their package
+func shippedExtensions(t *testing.T, root string) []string {
+	files := map[string][]*ast.File{}
+	fset := token.NewFileSet()
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		files[filepath.Dir(path)] = append(files[filepath.Dir(path)], f)
+		return nil
+	})
+	require.NoError(t, err)
+
+	var shipped []string
+	for dir, pkg := range files {
+		constants := map[string]string{}
+		for _, f := range pkg {
+			for _, decl := range f.Decls {
+				if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+					for _, spec := range gen.Specs {
+						spec := spec.(*ast.ValueSpec)
+						for i, value := range spec.Values {
+							if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+								constants[spec.Names[i].Name], _ = strconv.Unquote(lit.Value)
+							}
+						}
+					}
+				}
+			}
+		}
+		for _, f := range pkg {
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv == nil || fn.Name.Name != "ExtensionName" || fn.Body == nil {
+					continue
+				}
+				ret, ok := fn.Body.List[len(fn.Body.List)-1].(*ast.ReturnStmt)
+				require.True(t, ok && len(ret.Results) == 1, "%s: unexpected ExtensionName", dir)
+				switch result := ret.Results[0].(type) {
+				case *ast.BasicLit:
+					name, _ := strconv.Unquote(result.Value)
+					shipped = append(shipped, name)
+				case *ast.Ident:
+					name, ok := constants[result.Name]
+					require.True(t, ok, "%s: unknown constant %s", dir, result.Name)
+					shipped = append(shipped, name)
+				default:
+					t.Fatalf("%s: unexpected ExtensionName", dir)
+				}
+			}
+		}
+	}
+	return shipped
+}
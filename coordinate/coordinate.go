@@ -0,0 +1,122 @@
+// Package coordinate names the resolvers of a schema by their coordinates, e.g. "Query.user", for use as
+// low-cardinality metric tags and span names in custom instrumentation.
+//
+// Constants of the fields of a schema, and their typed Telemetry helpers, are generated by the telemetrygen plugin,
+// so that instrumentation does not build path strings at runtime, nor rely on string literals prone to typos:
+//
+//	ctx, span := telemetry.Query.User.StartSpan(ctx)
+//	defer span.End()
+//	telemetry.User.Orders.Record(ctx, lookups.M(1))
+package coordinate
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// Field is the coordinate of a field: its parent type and its name, e.g. "Query.user"
+type Field string
+
+// Of yields the coordinate of the field resolved in a context
+func Of(fc *graphql.FieldContext) Field {
+	return Field(fc.Object + "." + fc.Field.Name)
+}
+
+// Type yields the parent type of a field, e.g. "Query"
+func (f Field) Type() string {
+	if i := strings.IndexByte(string(f), '.'); i >= 0 {
+		return string(f[:i])
+	}
+	return ""
+}
+
+// Name yields the name of a field, e.g. "user"
+func (f Field) Name() string {
+	return string(f[strings.IndexByte(string(f), '.')+1:])
+}
+
+// String yields the coordinate, e.g. "Query.user"
+func (f Field) String() string {
+	return string(f)
+}
+
+// Is tells if a field context resolves this field
+func (f Field) Is(fc *graphql.FieldContext) bool {
+	return fc != nil && fc.Field.Field != nil && fc.Object == f.Type() && fc.Field.Name == f.Name()
+}
+
+// Tags yields the tag mutators of the field, with the type and field keys of the gqlopencensus-metrics views
+func (f Field) Tags() []tag.Mutator {
+	return []tag.Mutator{
+		tag.Upsert(metrics.TagType, f.Type()),
+		tag.Upsert(metrics.TagField, f.Name()),
+	}
+}
+
+// Tag the context with the field, see Tags
+func (f Field) Tag(ctx context.Context) (context.Context, error) {
+	return tag.New(ctx, f.Tags()...)
+}
+
+// StartSpan starts a span named after the field, with its type and field name as attributes
+func (f Field) StartSpan(ctx context.Context, opts ...trace.StartOption) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, f.String(), opts...)
+	span.AddAttributes(
+		trace.StringAttribute("type", f.Type()),
+		trace.StringAttribute("field", f.Name()),
+	)
+	return ctx, span
+}
+
+// Telemetry helpers of a field, with its tags and span attributes computed once
+type Telemetry struct {
+	Field
+	tags       []tag.Mutator
+	attributes []trace.Attribute
+}
+
+// NewTelemetry yields the helpers of a field
+func NewTelemetry(f Field) Telemetry {
+	return Telemetry{
+		Field: f,
+		tags:  f.Tags(),
+		attributes: []trace.Attribute{
+			trace.StringAttribute("type", f.Type()),
+			trace.StringAttribute("field", f.Name()),
+		},
+	}
+}
+
+// SpanName yields the name of the spans of the field: its coordinate
+func (t Telemetry) SpanName() string {
+	return string(t.Field)
+}
+
+// Tags yields the tag mutators of the field, see Field.Tags. They are shared: callers must not modify them.
+func (t Telemetry) Tags() []tag.Mutator {
+	return t.tags
+}
+
+// Tag the context with the field, see Tags
+func (t Telemetry) Tag(ctx context.Context) (context.Context, error) {
+	return tag.New(ctx, t.tags...)
+}
+
+// StartSpan starts a span named after the field, with its type and field name as attributes
+func (t Telemetry) StartSpan(ctx context.Context, opts ...trace.StartOption) (context.Context, *trace.Span) {
+	ctx, span := trace.StartSpan(ctx, t.SpanName(), opts...)
+	span.AddAttributes(t.attributes...)
+	return ctx, span
+}
+
+// Record measurements tagged with the field
+func (t Telemetry) Record(ctx context.Context, ms ...stats.Measurement) error {
+	return stats.RecordWithTags(ctx, t.tags, ms...)
+}
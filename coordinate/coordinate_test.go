@@ -0,0 +1,66 @@
+package coordinate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+)
+
+func TestField(t *testing.T) {
+	const userOrders Field = "User.orders"
+	assert.Equal(t, "User", userOrders.Type())
+	assert.Equal(t, "orders", userOrders.Name())
+	assert.Equal(t, "User.orders", userOrders.String())
+
+	fc := &graphql.FieldContext{Object: "User", Field: graphql.CollectedField{Field: &ast.Field{Name: "orders"}}}
+	assert.Equal(t, userOrders, Of(fc))
+	assert.True(t, userOrders.Is(fc))
+	assert.False(t, Field("Query.orders").Is(fc))
+	assert.False(t, userOrders.Is(nil))
+
+	ctx, err := userOrders.Tag(context.Background())
+	require.NoError(t, err)
+	typ, _ := tag.FromContext(ctx).Value(metrics.TagType)
+	field, _ := tag.FromContext(ctx).Value(metrics.TagField)
+	assert.Equal(t, "User", typ)
+	assert.Equal(t, "orders", field)
+}
+
+func TestTelemetry(t *testing.T) {
+	lookups := stats.Int64("test/lookups", "Lookups", stats.UnitDimensionless)
+	lookupsView := &view.View{
+		Name:        "test/lookups",
+		Measure:     lookups,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagType, metrics.TagField},
+	}
+	require.NoError(t, view.Register(lookupsView))
+	defer view.Unregister(lookupsView)
+
+	userOrders := NewTelemetry("User.orders")
+	assert.Equal(t, "User.orders", userOrders.SpanName())
+	assert.Equal(t, "orders", userOrders.Name())
+
+	ctx, span := userOrders.StartSpan(context.Background(), trace.WithSampler(trace.AlwaysSample()))
+	span.End()
+	assert.Equal(t, span, trace.FromContext(ctx))
+
+	require.NoError(t, userOrders.Record(ctx, lookups.M(1)))
+	metricstest.AssertCount(t, lookupsView, map[tag.Key]string{metrics.TagType: "User", metrics.TagField: "orders"}, 1)
+
+	ctx, err := userOrders.Tag(context.Background())
+	require.NoError(t, err)
+	field, _ := tag.FromContext(ctx).Value(metrics.TagField)
+	assert.Equal(t, "orders", field)
+}
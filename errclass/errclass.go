@@ -0,0 +1,191 @@
+// Package errclass classifies the errors of GraphQL operations, so metrics, spans and logs agree on what counts as
+// a server fault.
+//
+// A Classifier maps an error to a Classification: a class, a code and whether retrying may succeed. The Default
+// classifier knows context errors, the codes of gqlgen and the codes of the extensions of this repository, and may
+// be extended with Chain:
+//
+//	classifier := errclass.Chain(errclass.Codes(map[string]errclass.Classification{
+//		"PAYMENT_DECLINED": {Class: errclass.Client, Code: "PAYMENT_DECLINED"},
+//	}), errclass.Default())
+//	srv.Use(gqlopencensus.New(gqlopencensus.WithErrorClassifier(classifier)))
+//	srv.Use(metrics.New(metrics.ErrorClassifier(classifier)))
+package errclass
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Class of errors
+type Class string
+
+const (
+	// Client errors are caused by the request, e.g. validation errors or missing permissions
+	Client Class = "client"
+
+	// Server errors are faults of the server or of its dependencies
+	Server Class = "server"
+
+	// Timeout errors exceed a deadline
+	Timeout Class = "timeout"
+
+	// Canceled errors are canceled by the client
+	Canceled Class = "canceled"
+)
+
+// Fault tells if the errors of a class count as server faults, e.g. against an availability objective: server
+// errors and timeouts do
+func (c Class) Fault() bool {
+	return c == Server || c == Timeout
+}
+
+// Severity of the errors of a class, for logs: "error" for faults, "warn" otherwise
+func (c Class) Severity() string {
+	if c.Fault() {
+		return "error"
+	}
+	return "warn"
+}
+
+// severity orders classes, to pick the worst class of a response
+func (c Class) severity() int {
+	switch c {
+	case Server:
+		return 3
+	case Timeout:
+		return 2
+	case Canceled:
+		return 1
+	}
+	return 0
+}
+
+type (
+	// Classification of an error
+	Classification struct {
+		Class     Class
+		Code      string
+		Retryable bool
+	}
+
+	// Classifier classifies errors. Classify yields false when it doesn't know an error.
+	Classifier interface {
+		Classify(err error) (Classification, bool)
+	}
+
+	// ClassifierFunc is a function classifying errors
+	ClassifierFunc func(err error) (Classification, bool)
+
+	chain []Classifier
+)
+
+// Classify implements Classifier
+func (f ClassifierFunc) Classify(err error) (Classification, bool) {
+	return f(err)
+}
+
+// Chain classifiers: errors are classified by the first classifier knowing them
+func Chain(classifiers ...Classifier) Classifier {
+	return chain(classifiers)
+}
+
+func (c chain) Classify(err error) (Classification, bool) {
+	for _, classifier := range c {
+		if classification, ok := classifier.Classify(err); ok {
+			return classification, true
+		}
+	}
+	return Classification{}, false
+}
+
+// Context classifies context errors: context.DeadlineExceeded is a retryable timeout, and context.Canceled is
+// canceled
+func Context() Classifier {
+	return ClassifierFunc(func(err error) (Classification, bool) {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return Classification{Class: Timeout, Code: "TIMEOUT", Retryable: true}, true
+		case errors.Is(err, context.Canceled):
+			return Classification{Class: Canceled, Code: "CANCELED"}, true
+		}
+		return Classification{}, false
+	})
+}
+
+// Codes classifies errors by the code extension of GraphQL errors
+func Codes(codes map[string]Classification) Classifier {
+	return ClassifierFunc(func(err error) (Classification, bool) {
+		classification, ok := codes[Code(err)]
+		return classification, ok
+	})
+}
+
+// UserCodes classifies all the errors with a code extension as client errors: codes are set deliberately on
+// user-facing errors, while unexpected errors have none
+func UserCodes() Classifier {
+	return ClassifierFunc(func(err error) (Classification, bool) {
+		code := Code(err)
+		if code == "" {
+			return Classification{}, false
+		}
+		return Classification{Class: Client, Code: code}, true
+	})
+}
+
+// DefaultCodes classify the codes of the extensions of this repository which are not client errors, or which are
+// retryable
+var DefaultCodes = map[string]Classification{
+	"INTERNAL":            {Class: Server, Code: "INTERNAL"},
+	"TIMEOUT":             {Class: Timeout, Code: "TIMEOUT", Retryable: true},
+	"OVERLOADED":          {Class: Server, Code: "OVERLOADED", Retryable: true},
+	"CIRCUIT_OPEN":        {Class: Server, Code: "CIRCUIT_OPEN", Retryable: true},
+	"CONCURRENCY_LIMITED": {Class: Server, Code: "CONCURRENCY_LIMITED", Retryable: true},
+	"MAINTENANCE":         {Class: Server, Code: "MAINTENANCE", Retryable: true},
+	"RATE_LIMITED":        {Class: Client, Code: "RATE_LIMITED", Retryable: true},
+}
+
+var defaultClassifier = Default()
+
+// Default classifier: context errors, then DefaultCodes, then other codes as client errors. Errors without a code
+// are left to Classify, as server errors.
+func Default() Classifier {
+	return Chain(Context(), Codes(DefaultCodes), UserCodes())
+}
+
+// Classify an error, as a server error with an "INTERNAL" code when the classifier doesn't know it. A nil
+// classifier is the Default one.
+func Classify(c Classifier, err error) Classification {
+	if c == nil {
+		c = defaultClassifier
+	}
+	if classification, ok := c.Classify(err); ok {
+		return classification
+	}
+	return Classification{Class: Server, Code: "INTERNAL"}
+}
+
+// Worst classifies the errors of a response, yielding the classification of the worst error: server errors, then
+// timeouts, cancellations and client errors. It yields false when there are no errors.
+func Worst(c Classifier, errs gqlerror.List) (Classification, bool) {
+	var worst Classification
+	for i, err := range errs {
+		classification := Classify(c, err)
+		if i == 0 || classification.Class.severity() > worst.Class.severity() {
+			worst = classification
+		}
+	}
+	return worst, len(errs) > 0
+}
+
+// Code yields the code extension of a GraphQL error, or an empty string
+func Code(err error) string {
+	var gqlErr *gqlerror.Error
+	if !errors.As(err, &gqlErr) {
+		return ""
+	}
+	code, _ := gqlErr.Extensions["code"].(string)
+	return code
+}
@@ -0,0 +1,55 @@
+package errclass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func coded(code string) *gqlerror.Error {
+	err := gqlerror.Errorf("failed")
+	errcode.Set(err, code)
+	return err
+}
+
+func TestClassify(t *testing.T) {
+	for _, test := range []struct {
+		err      error
+		expected Classification
+	}{
+		{errors.New("boom"), Classification{Class: Server, Code: "INTERNAL"}},
+		{gqlerror.WrapPath(nil, context.DeadlineExceeded), Classification{Class: Timeout, Code: "TIMEOUT", Retryable: true}},
+		{fmt.Errorf("fetch: %w", context.Canceled), Classification{Class: Canceled, Code: "CANCELED"}},
+		{coded("OVERLOADED"), Classification{Class: Server, Code: "OVERLOADED", Retryable: true}},
+		{coded("RATE_LIMITED"), Classification{Class: Client, Code: "RATE_LIMITED", Retryable: true}},
+		{coded(errcode.ValidationFailed), Classification{Class: Client, Code: errcode.ValidationFailed}},
+		{coded("NOT_FOUND"), Classification{Class: Client, Code: "NOT_FOUND"}},
+	} {
+		assert.Equal(t, test.expected, Classify(nil, test.err), test.err.Error())
+	}
+
+	custom := Chain(Codes(map[string]Classification{"NOT_FOUND": {Class: Server, Code: "MISSING"}}), Default())
+	assert.Equal(t, Classification{Class: Server, Code: "MISSING"}, Classify(custom, coded("NOT_FOUND")))
+	assert.Equal(t, Classification{Class: Client, Code: "FORBIDDEN"}, Classify(custom, coded("FORBIDDEN")))
+}
+
+func TestWorst(t *testing.T) {
+	_, failed := Worst(nil, nil)
+	assert.False(t, failed)
+
+	worst, failed := Worst(nil, gqlerror.List{coded("NOT_FOUND"), gqlerror.WrapPath(nil, context.Canceled), coded("TIMEOUT")})
+	assert.True(t, failed)
+	assert.Equal(t, Timeout, worst.Class)
+
+	worst, _ = Worst(nil, gqlerror.List{coded("NOT_FOUND"), gqlerror.Errorf("boom")})
+	assert.Equal(t, Server, worst.Class)
+	assert.True(t, worst.Class.Fault())
+	assert.Equal(t, "error", worst.Class.Severity())
+	assert.False(t, Client.Fault())
+	assert.Equal(t, "warn", Canceled.Severity())
+}
@@ -0,0 +1,91 @@
+// Package observedtodo is a todo server wiring the observability extensions of gqlgen-contrib: opencensus tracing
+// and metrics, prometheus metrics, a syslog audit trail, and a response cache driven by cache policies.
+//
+// It serves as an integration test of the extensions, and as a starting point to copy:
+//
+//	srv, closeServer := observedtodo.New(observedtodo.Config{Traces: os.Stdout, Audit: os.Stderr})
+//	defer closeServer()
+//	log.Fatal(http.ListenAndServe(":8080", srv))
+package observedtodo
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqlcachecontrol"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlsyslog"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/internal/graph"
+	"github.com/99designs/gqlgen-contrib/prometheus"
+)
+
+// Config of the todo server
+type Config struct {
+	// Traces receives a line per ended span. All traces are sampled. By default traces are not exported.
+	Traces io.Writer
+
+	// Audit receives a syslog message per operation. By default the audit trail is discarded.
+	Audit io.Writer
+
+	// Registry of prometheus metrics, served at /metrics. By default this is a new registry.
+	Registry *prometheusclient.Registry
+
+	// CacheMaxAge of responses, in seconds. The default is 30.
+	CacheMaxAge int
+}
+
+// New todo server, serving GraphQL at /query and prometheus metrics at /metrics.
+//
+// The returned function unregisters the metrics and exporters of the server.
+func New(cfg Config) (http.Handler, func()) {
+	if cfg.Audit == nil {
+		cfg.Audit = ioutil.Discard
+	}
+	if cfg.Registry == nil {
+		cfg.Registry = prometheusclient.NewRegistry()
+	}
+	if cfg.CacheMaxAge == 0 {
+		cfg.CacheMaxAge = 30
+	}
+
+	var closers []func()
+	if cfg.Traces != nil {
+		printer := gqlopencensus.NewSpanPrinter(cfg.Traces)
+		trace.RegisterExporter(printer)
+		closers = append(closers, func() { trace.UnregisterExporter(printer) })
+	}
+
+	if err := metrics.Register(); err == nil {
+		closers = append(closers, metrics.Unregister)
+	}
+	prometheus.RegisterOn(cfg.Registry)
+	closers = append(closers, func() { prometheus.UnRegisterFrom(cfg.Registry) })
+
+	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	// sample every operation of this server, without changing the global sampler of the process
+	srv.Use(gqlopencensus.New(gqlopencensus.WithSampler(trace.AlwaysSample())))
+	srv.Use(metrics.New())
+	srv.Use(prometheus.Metrics{})
+	srv.Use(gqlsyslog.New(gqlsyslog.NewWriter(cfg.Audit)))
+	srv.Use(gqlcachecontrol.New(gqlcachecontrol.DefaultMaxAge(cfg.CacheMaxAge)))
+	srv.Use(gqlcache.New(gqlcache.NewMemoryStore(1000)))
+
+	mux := http.NewServeMux()
+	mux.Handle("/query", httpheader.Middleware(srv))
+	mux.Handle("/metrics", promhttp.HandlerFor(cfg.Registry, promhttp.HandlerOpts{}))
+
+	return mux, func() {
+		for _, close := range closers {
+			close()
+		}
+	}
+}
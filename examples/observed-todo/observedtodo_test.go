@@ -0,0 +1,49 @@
+package observedtodo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+)
+
+func TestServer(t *testing.T) {
+	var traces, audit bytes.Buffer
+	srv, closeServer := New(Config{Traces: &traces, Audit: &audit})
+	defer closeServer()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	query := `{"query": "query todos { todos { id text user { name } } }"}`
+	first := do(http.MethodPost, "/query", query)
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Contains(t, first.Body.String(), `"text":"Todo A"`)
+	assert.Equal(t, "max-age=30, public", first.Header().Get("Cache-Control"))
+
+	second := do(http.MethodPost, "/query", query)
+	assert.Contains(t, second.Body.String(), `"text":"Todo A"`)
+	assert.NotEmpty(t, second.Header().Get("Age"), "the second response should be served from the cache")
+
+	// cached responses skip the execution
+	metricstest.AssertCount(t, metrics.OperationCountView, map[tag.Key]string{metrics.TagOperation: "todos"}, 1)
+	assert.Contains(t, traces.String(), `name="todos"`)
+	assert.Contains(t, audit.String(), string(ast.Query))
+
+	exposition := do(http.MethodGet, "/metrics", "").Body.String()
+	assert.Contains(t, exposition, "graphql_request_duration_ms_count")
+}
@@ -0,0 +1,331 @@
+// Package fingerprint computes a normalized signature of GraphQL operations, and a stable hash of this signature.
+//
+// The normalization follows the Apollo usage reporting algorithm: unused definitions are dropped,
+// literals are hidden, aliases are removed, selections, arguments and directives are sorted,
+// and the result is printed with reduced whitespace.
+//
+// Operations which only differ by literals, aliases, formatting or the order of their selections share the same
+// fingerprint: extensions use it to agree on the identity of operations in metrics tags, span attributes, logs,
+// usage reports and allowlists.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// maxCached is the number of fingerprints kept in memory, before the cache is reset
+const maxCached = 1000
+
+// Fingerprint identifies an operation
+type Fingerprint struct {
+	// Signature is the normalized operation
+	Signature string
+
+	// Hash is the hex-encoded SHA-256 hash of the signature
+	Hash string
+}
+
+// Short yields the first 12 characters of the hash, e.g. to name anonymous operations
+func (f Fingerprint) Short() string {
+	if len(f.Hash) < 12 {
+		return f.Hash
+	}
+	return f.Hash[:12]
+}
+
+var cache = struct {
+	sync.Mutex
+	fingerprints map[string]Fingerprint
+}{fingerprints: map[string]Fingerprint{}}
+
+// Of yields the fingerprint of the operation of a request. It is empty if the operation is not known.
+//
+// Fingerprints are cached by raw query and operation name, so several extensions may call Of on each request.
+func Of(rc *graphql.OperationContext) Fingerprint {
+	if rc == nil || rc.Operation == nil {
+		return Fingerprint{}
+	}
+
+	key := rc.Operation.Name + "\n" + rc.RawQuery
+	cache.Lock()
+	f, ok := cache.fingerprints[key]
+	cache.Unlock()
+	if ok {
+		return f
+	}
+
+	signature := Signature(rc.Doc, rc.Operation.Name)
+	f = Fingerprint{Signature: signature, Hash: Hash(signature)}
+
+	cache.Lock()
+	if len(cache.fingerprints) >= maxCached {
+		cache.fingerprints = map[string]Fingerprint{}
+	}
+	cache.fingerprints[key] = f
+	cache.Unlock()
+	return f
+}
+
+// OperationName yields the name of the operation of a request: the name of the operation, or its type for anonymous
+// operations, e.g. "query", or the requested operation name when the operation is not known.
+//
+// Extensions name operations with it in metrics tags, span attributes and logs, to agree on their identity.
+func OperationName(rc *graphql.OperationContext) string {
+	if rc == nil {
+		return ""
+	}
+	if rc.Operation != nil {
+		if rc.Operation.Name != "" {
+			return rc.Operation.Name
+		}
+		return string(rc.Operation.Operation)
+	}
+	return rc.OperationName
+}
+
+// Hash yields the hex-encoded SHA-256 hash of a signature, or an empty string for an empty signature
+func Hash(signature string) string {
+	if signature == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(signature))
+	return hex.EncodeToString(sum[:])
+}
+
+// Signature of the named operation of a parsed document. It yields an empty string if the operation is not found.
+//
+// The document is not modified.
+func Signature(doc *ast.QueryDocument, operationName string) string {
+	if doc == nil {
+		return ""
+	}
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return ""
+	}
+
+	p := &printer{doc: doc, used: map[string]bool{}}
+	p.operation(op)
+
+	names := make([]string, 0, len(p.used))
+	for name := range p.used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fragments := &printer{doc: doc, used: map[string]bool{}}
+	for _, name := range names {
+		if fragment := doc.Fragments.ForName(name); fragment != nil {
+			fragments.fragment(fragment)
+		}
+	}
+
+	return p.String() + fragments.String()
+}
+
+type printer struct {
+	strings.Builder
+	doc  *ast.QueryDocument
+	used map[string]bool
+}
+
+func (p *printer) operation(op *ast.OperationDefinition) {
+	p.WriteString(string(op.Operation))
+	if op.Name != "" {
+		p.WriteByte(' ')
+		p.WriteString(op.Name)
+	}
+	if len(op.VariableDefinitions) > 0 {
+		defs := make([]*ast.VariableDefinition, len(op.VariableDefinitions))
+		copy(defs, op.VariableDefinitions)
+		sort.SliceStable(defs, func(i, j int) bool { return defs[i].Variable < defs[j].Variable })
+
+		p.WriteByte('(')
+		for i, def := range defs {
+			if i > 0 {
+				p.WriteByte(',')
+			}
+			p.WriteByte('$')
+			p.WriteString(def.Variable)
+			p.WriteByte(':')
+			p.WriteString(def.Type.String())
+			if def.DefaultValue != nil {
+				p.WriteByte('=')
+				p.value(def.DefaultValue)
+			}
+			p.directives(def.Directives)
+		}
+		p.WriteByte(')')
+	}
+	p.directives(op.Directives)
+	p.selectionSet(op.SelectionSet)
+}
+
+func (p *printer) fragment(fragment *ast.FragmentDefinition) {
+	p.WriteString("fragment ")
+	p.WriteString(fragment.Name)
+	p.WriteString(" on ")
+	p.WriteString(fragment.TypeCondition)
+	p.directives(fragment.Directives)
+	p.selectionSet(fragment.SelectionSet)
+}
+
+// selection kinds are sorted fields first, then fragment spreads, then inline fragments
+func selectionRank(selection ast.Selection) (int, string) {
+	switch sel := selection.(type) {
+	case *ast.Field:
+		return 0, sel.Name
+	case *ast.FragmentSpread:
+		return 1, sel.Name
+	case *ast.InlineFragment:
+		return 2, sel.TypeCondition
+	}
+	return 3, ""
+}
+
+func (p *printer) selectionSet(set ast.SelectionSet) {
+	if len(set) == 0 {
+		return
+	}
+	sorted := make(ast.SelectionSet, len(set))
+	copy(sorted, set)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, ni := selectionRank(sorted[i])
+		rj, nj := selectionRank(sorted[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return ni < nj
+	})
+
+	p.WriteByte('{')
+	for i, selection := range sorted {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if i > 0 && p.needsSpace() {
+				p.WriteByte(' ')
+			}
+			p.WriteString(sel.Name)
+			p.arguments(sel.Arguments)
+			p.directives(sel.Directives)
+			p.selectionSet(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			p.used[sel.Name] = true
+			p.markSpreads(sel.Name)
+			p.WriteString("...")
+			p.WriteString(sel.Name)
+			p.directives(sel.Directives)
+		case *ast.InlineFragment:
+			p.WriteString("...")
+			if sel.TypeCondition != "" {
+				p.WriteString("on ")
+				p.WriteString(sel.TypeCondition)
+			}
+			p.directives(sel.Directives)
+			p.selectionSet(sel.SelectionSet)
+		}
+	}
+	p.WriteByte('}')
+}
+
+// markSpreads records fragments transitively spread by a fragment
+func (p *printer) markSpreads(name string) {
+	fragment := p.doc.Fragments.ForName(name)
+	if fragment == nil {
+		return
+	}
+	var walk func(ast.SelectionSet)
+	walk = func(set ast.SelectionSet) {
+		for _, selection := range set {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				walk(sel.SelectionSet)
+			case *ast.InlineFragment:
+				walk(sel.SelectionSet)
+			case *ast.FragmentSpread:
+				if !p.used[sel.Name] {
+					p.used[sel.Name] = true
+					p.markSpreads(sel.Name)
+				}
+			}
+		}
+	}
+	walk(fragment.SelectionSet)
+}
+
+// needsSpace is true when the last printed character is part of a name
+func (p *printer) needsSpace() bool {
+	s := p.String()
+	if s == "" {
+		return false
+	}
+	c := s[len(s)-1]
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *printer) arguments(args ast.ArgumentList) {
+	if len(args) == 0 {
+		return
+	}
+	sorted := make(ast.ArgumentList, len(args))
+	copy(sorted, args)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	p.WriteByte('(')
+	for i, arg := range sorted {
+		if i > 0 {
+			p.WriteByte(',')
+		}
+		p.WriteString(arg.Name)
+		p.WriteByte(':')
+		p.value(arg.Value)
+	}
+	p.WriteByte(')')
+}
+
+func (p *printer) directives(directives ast.DirectiveList) {
+	if len(directives) == 0 {
+		return
+	}
+	sorted := make(ast.DirectiveList, len(directives))
+	copy(sorted, directives)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for _, directive := range sorted {
+		p.WriteByte('@')
+		p.WriteString(directive.Name)
+		p.arguments(directive.Arguments)
+	}
+}
+
+// value prints a value, hiding literals
+func (p *printer) value(v *ast.Value) {
+	if v == nil {
+		return
+	}
+	switch v.Kind {
+	case ast.Variable:
+		p.WriteByte('$')
+		p.WriteString(v.Raw)
+	case ast.IntValue, ast.FloatValue:
+		p.WriteByte('0')
+	case ast.StringValue, ast.BlockValue:
+		p.WriteString(`""`)
+	case ast.ListValue:
+		p.WriteString("[]")
+	case ast.ObjectValue:
+		p.WriteString("{}")
+	case ast.NullValue:
+		p.WriteString("null")
+	default:
+		// booleans and enums are not hidden
+		p.WriteString(v.Raw)
+	}
+}
@@ -0,0 +1,83 @@
+package fingerprint
+
+import (
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+)
+
+func TestSignature(t *testing.T) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: `
+		query Todos($b: Int = 10, $a: String) {
+			zz: todos(limit: 5, filter: {done: true}, text: "abc", after: $a) @include(if: true) {
+				...todoFields
+				user { name id }
+				... on Todo { done }
+			}
+		}
+
+		query Other {
+			other
+		}
+
+		fragment todoFields on Todo {
+			text
+			...more
+			id
+		}
+
+		fragment more on Todo {
+			owner: user(kind: ADMIN, tags: ["x"]) { id }
+		}
+
+		fragment unused on Todo {
+			id
+		}
+	`})
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`query Todos($a:String,$b:Int=0){todos(after:$a,filter:{},limit:0,text:"")@include(if:true){user{id name}...todoFields...on Todo{done}}}`+
+			`fragment more on Todo{user(kind:ADMIN,tags:[]){id}}`+
+			`fragment todoFields on Todo{id text...more}`,
+		Signature(doc, "Todos"))
+
+	assert.Equal(t, "query Other{other}", Signature(doc, "Other"))
+	assert.Equal(t, "", Signature(doc, "Missing"))
+}
+
+func TestOf(t *testing.T) {
+	a := Of(gqltesting.Operation(`{ todos(limit: 5) { text id } }`).Build())
+	b := Of(gqltesting.Operation(`{
+		all: todos(limit: 10) {
+			id
+			text
+		}
+	}`).Build())
+	c := Of(gqltesting.Operation(`{ todos(limit: 5) { text } }`).Build())
+
+	assert.Equal(t, "query{todos(limit:0){id text}}", a.Signature)
+	assert.Equal(t, Hash(a.Signature), a.Hash)
+	assert.Len(t, a.Hash, 64)
+	assert.Equal(t, a.Hash[:12], a.Short())
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a.Hash, c.Hash)
+
+	assert.Equal(t, Fingerprint{}, Of(nil))
+	assert.Equal(t, Fingerprint{}, Of(&graphql.OperationContext{}))
+	assert.Equal(t, "", Hash(""))
+}
+
+func TestOperationName(t *testing.T) {
+	assert.Equal(t, "Todos", OperationName(gqltesting.Operation(`query Todos { todos { id } }`).Build()))
+	assert.Equal(t, "query", OperationName(gqltesting.Operation(`{ todos { id } }`).Build()), "anonymous operations are named after their type")
+	assert.Equal(t, "Todos", OperationName(&graphql.OperationContext{OperationName: "Todos"}), "unknown operations keep the requested name")
+	assert.Empty(t, OperationName(nil))
+}
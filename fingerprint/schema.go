@@ -0,0 +1,20 @@
+package fingerprint
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// Schema yields the fingerprint of a schema: its signature is the schema printed in SDL, with sorted definitions.
+//
+// Schemas which only differ by formatting, comments or the order of their definitions share the same fingerprint.
+func Schema(schema *ast.Schema) Fingerprint {
+	if schema == nil {
+		return Fingerprint{}
+	}
+	var b strings.Builder
+	formatter.NewFormatter(&b).FormatSchema(schema)
+	return Fingerprint{Signature: b.String(), Hash: Hash(b.String())}
+}
@@ -0,0 +1,151 @@
+// Package gqlaccounting accounts the cost of GraphQL operations and the time spent in their resolvers per tenant and
+// client, for showback and chargeback reports:
+//
+//	gqlaccounting.RegisterViews()
+//	srv.Use(gqlcomplexity.New(1000))
+//	srv.Use(gqlaccounting.New(gqlaccounting.TenantBy(byAPIKey)))
+//
+// Views are cumulative sums: the consumption of an interval is the difference of the sums exported at its bounds, as
+// with any counter. The cardinality of views is bounded by MaxTenants and MaxClients.
+//
+// The cost of operations is computed by the gqlcomplexity extension (or by the gqlgen complexity limit), which must
+// be used before the accounting extension. Resolver time is the sum of the durations of resolvers, which exceeds the
+// latency of operations when resolvers run concurrently.
+package gqlaccounting
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+)
+
+const (
+	extensionName = "CostAccounting"
+
+	// TenantAnonymous is the tenant of operations of unidentified tenants
+	TenantAnonymous = "[anonymous]"
+
+	// TenantOther is the tenant, or client, of operations beyond the bounded cardinality of views
+	TenantOther = "[other]"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Accountant{}
+
+type (
+	// Accountant is a gqlgen extension accounting the consumption of operations per tenant and client
+	Accountant struct {
+		config
+		tenants *bounded
+		clients *bounded
+	}
+
+	// bounded set of tag values
+	bounded struct {
+		mu     sync.Mutex
+		values map[string]struct{}
+		max    int
+	}
+
+	usageKey struct{}
+
+	// usage of an operation: the nanoseconds spent in its resolvers
+	usage struct {
+		resolvers int64
+	}
+)
+
+// New cost accounting extension
+func New(opts ...Option) *Accountant {
+	a := &Accountant{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&a.config)
+	}
+	a.tenants = newBounded(a.maxTenants)
+	a.clients = newBounded(a.maxClients)
+	return a
+}
+
+// ExtensionName yields the extension name: "CostAccounting"
+func (a *Accountant) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (a *Accountant) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse records the consumption of an operation, once executed
+func (a *Accountant) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	rc := graphql.GetOperationContext(ctx)
+
+	u := &usage{}
+	resp := next(context.WithValue(ctx, usageKey{}, u))
+
+	tenant := a.tenant(ctx, rc)
+	if tenant == "" {
+		tenant = TenantAnonymous
+	}
+	mutators := []tag.Mutator{tag.Upsert(TagTenant, a.tenants.value(tenant))}
+	if client := clientinfo.AppOf(ctx, rc.Headers).Name; client != "" {
+		mutators = append(mutators, tag.Upsert(TagClient, a.clients.value(client)))
+	}
+
+	measurements := []stats.Measurement{
+		Operations.M(1),
+		ResolverTime.M(float64(atomic.LoadInt64(&u.resolvers)) / 1e6),
+	}
+	if cost, _, ok := gqlcomplexity.OperationCost(rc); ok {
+		measurements = append(measurements, Cost.M(int64(cost)))
+	}
+	_ = stats.RecordWithTags(ctx, mutators, measurements...)
+	return resp
+}
+
+// InterceptField measures the time spent in resolvers
+func (a *Accountant) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	u, ok := ctx.Value(usageKey{}).(*usage)
+	if !ok {
+		return next(ctx)
+	}
+	if fc := graphql.GetFieldContext(ctx); fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+
+	start := a.now()
+	res, err := next(ctx)
+	atomic.AddInt64(&u.resolvers, int64(a.now().Sub(start)))
+	return res, err
+}
+
+func newBounded(max int) *bounded {
+	return &bounded{values: make(map[string]struct{}), max: max}
+}
+
+// value yields a known value, or a new value while the set is not full, or else TenantOther
+func (b *bounded) value(v string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.values[v]; ok {
+		return v
+	}
+	if len(b.values) >= b.max {
+		return TenantOther
+	}
+	b.values[v] = struct{}{}
+	return v
+}
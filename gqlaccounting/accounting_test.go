@@ -0,0 +1,72 @@
+package gqlaccounting
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestAccountant(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	srv := handler.New(testschema.New(`type Query { todo: String, user: String }`, testschema.Resolvers{
+		"Query.todo": func(context.Context) (interface{}, error) {
+			clock.Advance(5 * time.Millisecond)
+			return "todo", nil
+		},
+		"Query.user": func(context.Context) (interface{}, error) {
+			clock.Advance(20 * time.Millisecond)
+			return "user", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(gqlcomplexity.New(100, gqlcomplexity.Weights(1, 1)))
+	srv.Use(New(
+		TenantBy(func(_ context.Context, rc *graphql.OperationContext) string {
+			return rc.Headers.Get("X-Tenant")
+		}),
+		MaxTenants(2),
+	))
+
+	do := func(tenant, client, query string) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Tenant", tenant)
+		req.Header.Set("apollographql-client-name", client)
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	do("acme", "web", "{ todo }")
+	do("acme", "web", "{ todo user }")
+	do("acme", "ios", "{ user }")
+	do("globex", "web", "{ todo }")
+	do("initech", "web", "{ todo }")
+	do("", "web", "{ todo }")
+
+	acmeWeb := map[tag.Key]string{TagTenant: "acme", TagClient: "web"}
+	metricstest.AssertCount(t, OperationCountView, acmeWeb, 2)
+	metricstest.AssertSum(t, CostView, acmeWeb, 3)
+	metricstest.AssertSum(t, ResolverTimeView, acmeWeb, 30)
+	metricstest.AssertSum(t, ResolverTimeView, map[tag.Key]string{TagTenant: "acme"}, 50)
+
+	metricstest.AssertCount(t, OperationCountView, map[tag.Key]string{TagTenant: "globex"}, 1)
+	metricstest.AssertCount(t, OperationCountView, map[tag.Key]string{TagTenant: TenantOther}, 2)
+}
@@ -0,0 +1,83 @@
+package gqlaccounting
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// RegisterViews registers the opencensus views of cost accounting.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(AccountingViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of cost accounting
+func UnregisterViews() {
+	view.Unregister(AccountingViews...)
+}
+
+var (
+	// AccountingViews contains all opencensus stats views declared by the cost accounting extension
+	AccountingViews = []*view.View{
+		OperationCountView,
+		CostView,
+		ResolverTimeView,
+	}
+
+	// measurements
+
+	// Operations tracks a count of operations
+	Operations = stats.Int64(
+		"gql/accounting/operation_count",
+		"Number of GraphQL operations, per tenant and client",
+		stats.UnitDimensionless)
+
+	// Cost tracks the cost of operations, computed by the gqlcomplexity extension or the gqlgen complexity limit
+	Cost = stats.Int64(
+		"gql/accounting/cost",
+		"Cost of GraphQL operations, per tenant and client",
+		stats.UnitDimensionless)
+
+	// ResolverTime tracks the time spent in the resolvers of operations, in milliseconds
+	ResolverTime = stats.Float64(
+		"gql/accounting/resolver_time",
+		"Time spent in the resolvers of GraphQL operations, per tenant and client",
+		stats.UnitMilliseconds)
+
+	// views
+
+	// OperationCountView reports a count of operations, by tenant and client
+	OperationCountView = &view.View{
+		Name:        "gql/accounting/operation_count",
+		Description: "Count of GraphQL operations, by tenant and client",
+		Measure:     Operations,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagTenant, TagClient},
+	}
+
+	// CostView reports the total cost of operations, by tenant and client
+	CostView = &view.View{
+		Name:        "gql/accounting/cost",
+		Description: "Total cost of GraphQL operations, by tenant and client",
+		Measure:     Cost,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagTenant, TagClient},
+	}
+
+	// ResolverTimeView reports the total time spent in resolvers, by tenant and client (in milliseconds)
+	ResolverTimeView = &view.View{
+		Name:        "gql/accounting/resolver_time",
+		Description: "Total time spent in the resolvers of GraphQL operations, by tenant and client",
+		Measure:     ResolverTime,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagTenant, TagClient},
+	}
+
+	// TagTenant is the tenant of an operation, TenantAnonymous, or TenantOther beyond MaxTenants
+	TagTenant = tag.MustNewKey("gql.tenant")
+
+	// TagClient is the name of the client of an operation, or TenantOther beyond MaxClients
+	TagClient = tag.MustNewKey("gql.client")
+)
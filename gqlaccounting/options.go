@@ -0,0 +1,62 @@
+package gqlaccounting
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlauthz"
+)
+
+// Option for the cost accounting extension
+type Option func(*config)
+
+// TenantFunc identifies the tenant of an operation. It yields an empty string for unidentified tenants.
+type TenantFunc func(context.Context, *graphql.OperationContext) string
+
+type config struct {
+	tenant     TenantFunc
+	maxTenants int
+	maxClients int
+	now        func() time.Time
+}
+
+func defaultConfig() config {
+	return config{
+		tenant:     byPrincipal,
+		maxTenants: 1000,
+		maxClients: 100,
+		now: func() time.Time {
+			return graphql.Now()
+		},
+	}
+}
+
+func byPrincipal(ctx context.Context, _ *graphql.OperationContext) string {
+	p, _ := gqlauthz.FromContext(ctx)
+	return p.ID
+}
+
+// TenantBy identifies tenants. The default is the ID of the principal stored with gqlauthz.WithPrincipal.
+func TenantBy(tenant TenantFunc) Option {
+	return func(c *config) {
+		c.tenant = tenant
+	}
+}
+
+// MaxTenants bounds the number of distinct tenants in views: operations of further tenants are accounted to
+// TenantOther. The default is 1000.
+func MaxTenants(n int) Option {
+	return func(c *config) {
+		c.maxTenants = n
+	}
+}
+
+// MaxClients bounds the number of distinct client names in views: operations of further clients are accounted to
+// TenantOther. The default is 100.
+func MaxClients(n int) Option {
+	return func(c *config) {
+		c.maxClients = n
+	}
+}
@@ -0,0 +1,53 @@
+package gqlapollostudio
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	histogramBuckets = 384
+	histogramBase    = 1.1
+)
+
+// durationHistogram is the Apollo duration histogram: bucket i counts durations up to 1.1^i microseconds
+type durationHistogram [histogramBuckets]int64
+
+func histogramBucket(d time.Duration) int {
+	micros := float64(d) / float64(time.Microsecond)
+	if micros <= 1 {
+		return 0
+	}
+	bucket := int(math.Ceil(math.Log(micros) / math.Log(histogramBase)))
+	if bucket >= histogramBuckets {
+		return histogramBuckets - 1
+	}
+	return bucket
+}
+
+func (h *durationHistogram) observe(d time.Duration) {
+	h[histogramBucket(d)]++
+}
+
+// encode the histogram in the sparse Apollo format: runs of empty buckets are encoded as
+// their negated length, and trailing empty buckets are dropped
+func (h *durationHistogram) encode() []int64 {
+	var out []int64
+	zeros := int64(0)
+	for _, count := range h {
+		if count == 0 {
+			zeros++
+			continue
+		}
+		switch zeros {
+		case 0:
+		case 1:
+			out = append(out, 0)
+		default:
+			out = append(out, -zeros)
+		}
+		zeros = 0
+		out = append(out, count)
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package gqlapollostudio
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Option for the Apollo Studio usage reporter
+type Option func(*config)
+
+type config struct {
+	endpoint       string
+	interval       time.Duration
+	maxEntries     int
+	maxRetries     int
+	minBackoff     time.Duration
+	client         *http.Client
+	hostname       string
+	serviceVersion string
+	errorHandler   func(error)
+}
+
+func defaultConfig() config {
+	host, _ := os.Hostname()
+	return config{
+		endpoint:   DefaultEndpoint,
+		interval:   20 * time.Second,
+		maxEntries: 5000,
+		maxRetries: 5,
+		minBackoff: 100 * time.Millisecond,
+		client:     &http.Client{Timeout: 30 * time.Second},
+		hostname:   host,
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+	}
+}
+
+// Endpoint overrides the usage reporting endpoint. The default is DefaultEndpoint.
+func Endpoint(url string) Option {
+	return func(c *config) {
+		c.endpoint = url
+	}
+}
+
+// Interval between two reports. The default is 20s.
+func Interval(interval time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+	}
+}
+
+// MaxEntries bounds the memory used by aggregation: this is the maximum number of distinct
+// (operation signature, client) pairs kept between two reports. The default is 5000.
+//
+// When the bound is reached, a report is sent early and new entries are dropped until it is sent.
+func MaxEntries(n int) Option {
+	return func(c *config) {
+		c.maxEntries = n
+	}
+}
+
+// Retries sets the maximum number of retries of a failed report, and the initial backoff between retries.
+// The defaults are 5 retries, with an initial backoff of 100ms.
+func Retries(maxRetries int, minBackoff time.Duration) Option {
+	return func(c *config) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+	}
+}
+
+// HTTPClient sets the client used to send reports
+func HTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// Hostname reported in the report header. By default this is the OS hostname
+func Hostname(hostname string) Option {
+	return func(c *config) {
+		c.hostname = hostname
+	}
+}
+
+// ServiceVersion reported in the report header, e.g. a git tag
+func ServiceVersion(version string) Option {
+	return func(c *config) {
+		c.serviceVersion = version
+	}
+}
+
+// ErrorHandler is called with errors occurring while sending reports in the background.
+// By default, errors are logged.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
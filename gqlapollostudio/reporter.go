@@ -0,0 +1,418 @@
+// Package gqlapollostudio reports usage statistics of a GraphQL server to Apollo Studio.
+//
+// Operations are aggregated in memory by signature and client, and shipped on an interval to the
+// Apollo usage reporting endpoint, as apollo-server does.
+package gqlapollostudio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/apollofederatedtracingv1/generated"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const (
+	extensionName = "ApolloStudioUsageReporting"
+	agentVersion  = "gqlgen-contrib"
+
+	// DefaultEndpoint is the Apollo Studio usage reporting endpoint
+	DefaultEndpoint = "https://usage-reporting.api.apollographql.com/api/ingress/traces"
+
+	parseFailureKey      = "## GraphQLParseFailure\n"
+	validationFailureKey = "## GraphQLValidationFailure\n"
+
+	// the number of cached signatures is bounded independently of the aggregated stats
+	maxCachedSignatures = 10000
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Reporter{}
+
+type (
+	// Reporter is a gqlgen extension aggregating usage statistics and shipping them to Apollo Studio
+	Reporter struct {
+		config
+		apiKey   string
+		graphRef string
+
+		mu             sync.Mutex
+		stats          map[string]map[statsContext]*queryStats
+		entries        int
+		operationCount uint64
+		signatures     map[string]string
+
+		flush    chan struct{}
+		done     chan struct{}
+		stopped  chan struct{}
+		stopOnce sync.Once
+	}
+
+	statsContext struct {
+		clientName    string
+		clientVersion string
+	}
+
+	queryStats struct {
+		latency            durationHistogram
+		requestCount       uint64
+		requestsWithErrors uint64
+		persistedHits      uint64
+		persistedMisses    uint64
+		rootErrors         pathErrors
+	}
+
+	pathErrors struct {
+		children           map[string]*pathErrors
+		errors             uint64
+		requestsWithErrors uint64
+	}
+)
+
+// New usage reporter for the given graph (e.g. "mygraph@current"), authenticated by an Apollo API key.
+//
+// The reporter ships reports in the background until Shutdown is called.
+func New(apiKey, graphRef string, opts ...Option) *Reporter {
+	r := &Reporter{
+		config:     defaultConfig(),
+		apiKey:     apiKey,
+		graphRef:   graphRef,
+		stats:      map[string]map[statsContext]*queryStats{},
+		signatures: map[string]string{},
+		flush:      make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	for _, apply := range opts {
+		apply(&r.config)
+	}
+
+	go r.run()
+	return r
+}
+
+// ExtensionName yields the extension name: "ApolloStudioUsageReporting"
+func (*Reporter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (*Reporter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor
+func (r *Reporter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+
+	oc := graphql.GetOperationContext(ctx)
+	if oc == nil || resp == nil {
+		return resp
+	}
+	if oc.Operation != nil && oc.Operation.Operation == ast.Subscription {
+		// subscription events are not operations
+		return resp
+	}
+
+	duration := graphql.Now().Sub(oc.Stats.OperationStart)
+	app := clientinfo.AppOf(ctx, oc.Headers)
+	sc := statsContext{
+		clientName:    app.Name,
+		clientVersion: app.Version,
+	}
+	apq := extension.GetApqStats(ctx)
+
+	r.record(r.statsKey(oc, resp.Errors), sc, duration, resp.Errors, apq)
+	return resp
+}
+
+// Flush sends the statistics aggregated so far
+func (r *Reporter) Flush(ctx context.Context) error {
+	report := r.swap()
+	if report == nil {
+		return nil
+	}
+	return r.send(ctx, report)
+}
+
+// Shutdown stops the background reporting, then sends the remaining statistics
+func (r *Reporter) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.done) })
+	select {
+	case <-r.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return r.Flush(ctx)
+}
+
+func (r *Reporter) run() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.flush:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+		if err := r.Flush(ctx); err != nil {
+			r.errorHandler(err)
+		}
+		cancel()
+	}
+}
+
+func (r *Reporter) statsKey(oc *graphql.OperationContext, errs gqlerror.List) string {
+	if oc.Operation == nil {
+		for _, err := range errs {
+			if code, _ := err.Extensions["code"].(string); code == errcode.ParseFailed {
+				return parseFailureKey
+			}
+		}
+		return validationFailureKey
+	}
+
+	cacheKey := oc.OperationName + "\x00" + oc.RawQuery
+	r.mu.Lock()
+	key, ok := r.signatures[cacheKey]
+	r.mu.Unlock()
+	if ok {
+		return key
+	}
+
+	name := oc.Operation.Name
+	if name == "" {
+		name = "-"
+	}
+	key = "# " + name + "\n" + fingerprint.Signature(oc.Doc, oc.OperationName)
+
+	r.mu.Lock()
+	if len(r.signatures) >= maxCachedSignatures {
+		r.signatures = map[string]string{}
+	}
+	r.signatures[cacheKey] = key
+	r.mu.Unlock()
+	return key
+}
+
+func (r *Reporter) record(key string, sc statsContext, duration time.Duration, errs gqlerror.List, apq *extension.ApqStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.operationCount++
+
+	contexts, ok := r.stats[key]
+	if !ok {
+		contexts = map[statsContext]*queryStats{}
+	}
+	qs, ok := contexts[sc]
+	if !ok {
+		if r.entries >= r.maxEntries {
+			// memory bound reached: drop this operation and ship what we have early
+			select {
+			case r.flush <- struct{}{}:
+			default:
+			}
+			return
+		}
+		qs = &queryStats{}
+		contexts[sc] = qs
+		r.stats[key] = contexts
+		r.entries++
+	}
+
+	qs.requestCount++
+	qs.latency.observe(duration)
+	if len(errs) > 0 {
+		qs.requestsWithErrors++
+		qs.rootErrors.add(errs)
+	}
+	if apq != nil {
+		if apq.SentQuery {
+			qs.persistedMisses++
+		} else {
+			qs.persistedHits++
+		}
+	}
+}
+
+// swap the aggregated statistics for empty ones, and build a report from them
+func (r *Reporter) swap() *generated.Report {
+	r.mu.Lock()
+	stats, count := r.stats, r.operationCount
+	r.stats = map[string]map[statsContext]*queryStats{}
+	r.entries = 0
+	r.operationCount = 0
+	r.mu.Unlock()
+
+	if count == 0 {
+		return nil
+	}
+
+	report := &generated.Report{
+		Header: &generated.ReportHeader{
+			GraphRef:       r.graphRef,
+			Hostname:       r.hostname,
+			AgentVersion:   agentVersion,
+			ServiceVersion: r.serviceVersion,
+			RuntimeVersion: runtime.Version(),
+		},
+		TracesPerQuery: make(map[string]*generated.TracesAndStats, len(stats)),
+		EndTime:        timestamppb.New(graphql.Now()),
+		OperationCount: count,
+	}
+	for key, contexts := range stats {
+		ts := &generated.TracesAndStats{}
+		for sc, qs := range contexts {
+			ts.StatsWithContext = append(ts.StatsWithContext, &generated.ContextualizedStats{
+				Context: &generated.StatsContext{
+					ClientName:    sc.clientName,
+					ClientVersion: sc.clientVersion,
+				},
+				QueryLatencyStats: &generated.QueryLatencyStats{
+					LatencyCount:                        qs.latency.encode(),
+					RequestCount:                        qs.requestCount,
+					PersistedQueryHits:                  qs.persistedHits,
+					PersistedQueryMisses:                qs.persistedMisses,
+					RootErrorStats:                      qs.rootErrors.proto(),
+					RequestsWithErrorsCount:             qs.requestsWithErrors,
+					RequestsWithoutFieldInstrumentation: qs.requestCount,
+				},
+			})
+		}
+		report.TracesPerQuery[key] = ts
+	}
+	return report
+}
+
+func (r *Reporter) send(ctx context.Context, report *generated.Report) error {
+	b, err := proto.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("gqlapollostudio: could not marshal report: %w", err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err = gz.Write(b); err != nil {
+		return fmt.Errorf("gqlapollostudio: could not compress report: %w", err)
+	}
+	if err = gz.Close(); err != nil {
+		return fmt.Errorf("gqlapollostudio: could not compress report: %w", err)
+	}
+	body := buf.Bytes()
+
+	backoff := r.minBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := r.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= r.maxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (r *Reporter) post(ctx context.Context, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("gqlapollostudio: could not create request: %w", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Api-Key", r.apiKey)
+	req.Header.Set("Content-Type", "application/protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("User-Agent", agentVersion)
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("gqlapollostudio: could not send report: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		_, _ = io.Copy(ioutil.Discard, res.Body)
+		return false, nil
+	}
+
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests,
+		fmt.Errorf("gqlapollostudio: report rejected with status %d: %s", res.StatusCode, bytes.TrimSpace(msg))
+}
+
+// add errors to the tree of errors by response path (list indices are not part of the tree)
+func (p *pathErrors) add(errs gqlerror.List) {
+	touched := map[*pathErrors]bool{}
+	for _, err := range errs {
+		node := p
+		for _, elem := range err.Path {
+			name, ok := elem.(ast.PathName)
+			if !ok {
+				continue
+			}
+			if node.children == nil {
+				node.children = map[string]*pathErrors{}
+			}
+			child, ok := node.children[string(name)]
+			if !ok {
+				child = &pathErrors{}
+				node.children[string(name)] = child
+			}
+			node = child
+		}
+		node.errors++
+		touched[node] = true
+	}
+	for node := range touched {
+		node.requestsWithErrors++
+	}
+}
+
+func (p *pathErrors) proto() *generated.PathErrorStats {
+	if p.errors == 0 && len(p.children) == 0 {
+		return nil
+	}
+	stats := &generated.PathErrorStats{
+		ErrorsCount:             p.errors,
+		RequestsWithErrorsCount: p.requestsWithErrors,
+	}
+	if len(p.children) > 0 {
+		stats.Children = make(map[string]*generated.PathErrorStats, len(p.children))
+		for name, child := range p.children {
+			stats.Children[name] = child.proto()
+		}
+	}
+	return stats
+}
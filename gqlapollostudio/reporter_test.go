@@ -0,0 +1,109 @@
+package gqlapollostudio
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/apollofederatedtracingv1/generated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHistogram(t *testing.T) {
+	var h durationHistogram
+	assert.Nil(t, h.encode())
+
+	h.observe(500 * time.Nanosecond)
+	h.observe(time.Millisecond)
+	h.observe(time.Millisecond)
+	h.observe(1100 * time.Nanosecond)
+	assert.Equal(t, 0, histogramBucket(time.Microsecond))
+	assert.Equal(t, 1, histogramBucket(1100*time.Nanosecond))
+	assert.Equal(t, 73, histogramBucket(time.Millisecond))
+	assert.Equal(t, histogramBuckets-1, histogramBucket(time.Duration(math.MaxInt64)))
+
+	assert.Equal(t, []int64{1, 1, -71, 2}, h.encode())
+}
+
+func TestReporter(t *testing.T) {
+	reports := make(chan *generated.Report, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "service:key", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		b, err := ioutil.ReadAll(gz)
+		require.NoError(t, err)
+
+		var report generated.Report
+		require.NoError(t, proto.Unmarshal(b, &report))
+		reports <- &report
+	}))
+	defer srv.Close()
+
+	ext := New("service:key", "graph@current", Endpoint(srv.URL), Interval(time.Hour), Hostname("pod-1"))
+	require.Equal(t, extensionName, ext.ExtensionName())
+
+	doc, err := parser.ParseQuery(&ast.Source{Input: `query Todos { todos(limit: 3) { id text } }`})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		opCtx := &graphql.OperationContext{
+			RawQuery:      `query Todos { todos(limit: 3) { id text } }`,
+			OperationName: "Todos",
+			Doc:           doc,
+			Operation:     doc.Operations[0],
+			Headers:       http.Header{"Apollographql-Client-Name": []string{"web"}},
+			Stats:         graphql.Stats{OperationStart: time.Now()},
+		}
+		ctx := graphql.WithOperationContext(context.Background(), opCtx)
+		ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			resp := &graphql.Response{}
+			if i == 0 {
+				resp.Errors = gqlerror.List{
+					{Message: "boom", Path: ast.Path{ast.PathName("todos"), ast.PathIndex(1), ast.PathName("text")}},
+					{Message: "boom", Path: ast.Path{ast.PathName("todos"), ast.PathIndex(2), ast.PathName("text")}},
+				}
+			}
+			return resp
+		})
+	}
+
+	require.NoError(t, ext.Shutdown(context.Background()))
+
+	var report *generated.Report
+	select {
+	case report = <-reports:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no report received")
+	}
+
+	assert.Equal(t, "graph@current", report.Header.GraphRef)
+	assert.Equal(t, "pod-1", report.Header.Hostname)
+	assert.Equal(t, uint64(3), report.OperationCount)
+
+	ts, ok := report.TracesPerQuery["# Todos\nquery Todos{todos(limit:0){id text}}"]
+	require.True(t, ok, "%v", report.TracesPerQuery)
+	require.Len(t, ts.StatsWithContext, 1)
+
+	stats := ts.StatsWithContext[0]
+	assert.Equal(t, "web", stats.Context.ClientName)
+	assert.Equal(t, uint64(3), stats.QueryLatencyStats.RequestCount)
+	assert.Equal(t, uint64(1), stats.QueryLatencyStats.RequestsWithErrorsCount)
+
+	text := stats.QueryLatencyStats.RootErrorStats.Children["todos"].Children["text"]
+	assert.Equal(t, uint64(2), text.ErrorsCount)
+	assert.Equal(t, uint64(1), text.RequestsWithErrorsCount)
+}
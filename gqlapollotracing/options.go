@@ -0,0 +1,40 @@
+package gqlapollotracing
+
+import (
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the Apollo tracing extension
+type Option func(*config)
+
+type config struct {
+	onlyMethods bool
+	header      string
+}
+
+func defaultConfig() config {
+	return config{}
+}
+
+func (c config) enabled(oc *graphql.OperationContext) bool {
+	if c.header == "" {
+		return true
+	}
+	return oc.Headers.Get(c.header) != ""
+}
+
+// OnlyMethods when enabled, records resolvers only for fields which correspond to a method of the resolver.
+// By default, all fields are recorded, as expected by Apollo tools.
+func OnlyMethods(enabled bool) Option {
+	return func(c *config) {
+		c.onlyMethods = enabled
+	}
+}
+
+// RequireHeader only traces operations sent with the given HTTP header, e.g. "X-Apollo-Tracing".
+// By default, all operations are traced.
+func RequireHeader(header string) Option {
+	return func(c *config) {
+		c.header = header
+	}
+}
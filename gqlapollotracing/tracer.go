@@ -0,0 +1,78 @@
+// Package gqlapollotracing emits the Apollo Tracing response extension (version 1 format)
+// for a GraphQL server.
+//
+// It extends the apollotracing extension of gqlgen with options, to trace on demand or to skip trivial fields.
+//
+// See https://github.com/apollographql/apollo-tracing
+package gqlapollotracing
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/apollotracing"
+)
+
+const responseKey = "tracing"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracer{}
+
+type (
+	// Tracer is a gqlgen extension adding the "tracing" extension to GraphQL responses
+	Tracer struct {
+		apollotracing.Tracer
+		config
+	}
+
+	// TracingExtension is the version 1 Apollo Tracing format
+	TracingExtension = apollotracing.TracingExtension
+
+	// Span is the timing of a phase of the operation, relative to the start of the operation
+	Span = apollotracing.Span
+
+	// ResolverExecution is the timing of a field resolver, relative to the start of the operation
+	ResolverExecution = apollotracing.ResolverExecution
+)
+
+// New Apollo tracing extension
+func New(opts ...Option) *Tracer {
+	tr := &Tracer{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&tr.config)
+	}
+	return tr
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor
+func (tr Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	if oc == nil || oc.Operation == nil || !tr.enabled(oc) {
+		return next(ctx)
+	}
+
+	return tr.Tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		if td := GetTracing(ctx); td != nil {
+			// Apollo tools expect a list of resolvers, even when none was recorded
+			td.Execution.Resolvers = []*ResolverExecution{}
+		}
+		return next(ctx)
+	})
+}
+
+// InterceptField implements graphql.FieldInterceptor
+func (tr Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if fc := graphql.GetFieldContext(ctx); tr.onlyMethods && !fc.IsMethod || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+	return tr.Tracer.InterceptField(ctx, next)
+}
+
+// GetTracing yields the tracing data collected for the current operation, if any
+func GetTracing(ctx context.Context) *TracingExtension {
+	td, _ := graphql.GetExtension(ctx, responseKey).(*TracingExtension)
+	return td
+}
@@ -0,0 +1,87 @@
+package gqlapollotracing
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestTracer(t *testing.T) {
+	now := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+	graphql.Now = func() time.Time {
+		now = now.Add(100 * time.Nanosecond)
+		return now
+	}
+	defer func() { graphql.Now = time.Now }()
+
+	ext := New()
+	require.Equal(t, "ApolloTracing", ext.ExtensionName())
+	require.Nil(t, ext.Validate(&graphql.ExecutableSchemaMock{}))
+
+	start := now
+	opCtx := &graphql.OperationContext{
+		RawQuery:  "query { todos { id } }",
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+		Stats: graphql.Stats{
+			OperationStart: start,
+			Parsing:        graphql.TraceTiming{Start: start.Add(100), End: start.Add(200)},
+			Validation:     graphql.TraceTiming{Start: start.Add(200), End: start.Add(400)},
+		},
+	}
+	ctx := graphql.WithOperationContext(context.Background(), opCtx)
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	h := func(ctx context.Context) *graphql.Response {
+		ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object: "Query",
+			Field: graphql.CollectedField{
+				Field: &ast.Field{
+					Name:       "todos",
+					Alias:      "todos",
+					Definition: &ast.FieldDefinition{Type: ast.NonNullListType(ast.NonNullNamedType("Todo", nil), nil)},
+				},
+			},
+			IsMethod: true,
+		})
+		_, err := ext.InterceptField(ctx, func(ctx context.Context) (interface{}, error) {
+			return []string{}, nil
+		})
+		require.NoError(t, err)
+
+		return &graphql.Response{
+			Data:       json.RawMessage(`{"todos": []}`),
+			Extensions: graphql.GetExtensions(ctx),
+		}
+	}
+
+	resp := ext.InterceptResponse(ctx, h)
+	require.NotNil(t, resp)
+
+	td := GetTracing(ctx)
+	require.NotNil(t, td)
+	assert.Equal(t, 1, td.Version)
+	assert.Equal(t, Span{StartOffset: 100, Duration: 100}, td.Parsing)
+	assert.Equal(t, Span{StartOffset: 200, Duration: 200}, td.Validation)
+	assert.Equal(t, time.Duration(300), td.Duration)
+
+	require.Len(t, td.Execution.Resolvers, 1)
+	assert.Equal(t, &ResolverExecution{
+		Path:        ast.Path{ast.PathName("todos")},
+		ParentType:  "Query",
+		FieldName:   "todos",
+		ReturnType:  "[Todo!]!",
+		StartOffset: 100,
+		Duration:    100,
+	}, td.Execution.Resolvers[0])
+
+	b, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"tracing":{"version":1,`)
+	assert.Contains(t, string(b), `"resolvers":[{"path":["todos"],"parentType":"Query","fieldName":"todos","returnType":"[Todo!]!","startOffset":100,"duration":100}]`)
+}
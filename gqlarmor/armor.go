@@ -0,0 +1,165 @@
+// Package gqlarmor hardens a GraphQL server with a single extension, composing the protections of
+// the gqlguard package with sane defaults:
+//
+//	srv.Use(gqlarmor.New(gqlarmor.Default()))
+//
+// Limits are tuned with the Config:
+//
+//	cfg := gqlarmor.Default()
+//	cfg.MaxDepth = 10
+//	srv.Use(gqlarmor.New(cfg))
+//
+// Batching is handled by a transport, which must be added separately, before transport.POST:
+//
+//	armor := gqlarmor.New(gqlarmor.Default())
+//	srv.AddTransport(armor.BatchTransport())
+//	srv.AddTransport(transport.POST{})
+//	srv.Use(armor)
+package gqlarmor
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqlguard"
+)
+
+const extensionName = "Armor"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+	graphql.OperationContextMutator
+	graphql.ResponseInterceptor
+} = &Armor{}
+
+type (
+	// Config of the protections. A limit of 0 disables the corresponding protection.
+	Config struct {
+		// MaxDepth of operations, see gqlguard.DepthLimit
+		MaxDepth int
+		// MaxIntrospectionDepth of introspection queries, see gqlguard.DepthLimit
+		MaxIntrospectionDepth int
+
+		// MaxAliases in an operation, see gqlguard.AliasLimit
+		MaxAliases int
+		// MaxAliasesPerSelection in a single selection set, see gqlguard.AliasLimit
+		MaxAliasesPerSelection int
+
+		// MaxDirectives in a document, see gqlguard.DirectiveLimit
+		MaxDirectives int
+		// MaxDirectivesPerLocation on a single field, fragment or operation, see gqlguard.DirectiveLimit
+		MaxDirectivesPerLocation int
+
+		// MaxBytes of a document, see gqlguard.DocumentLimit
+		MaxBytes int
+		// MaxTokens of a document, see gqlguard.DocumentLimit
+		MaxTokens int
+
+		// MaxBatchOperations in a batched request, see gqlguard.Batch
+		MaxBatchOperations int
+		// MaxBatchCost of a batched request, see gqlguard.Batch
+		MaxBatchCost int
+
+		// ShowSuggestions in validation errors. By default, suggestions are hidden: see gqlguard.HideSuggestions
+		ShowSuggestions bool
+		// AllowSuggestions for some callers, e.g. in development, when suggestions are hidden
+		AllowSuggestions func(context.Context) bool
+	}
+
+	// Armor is a gqlgen extension composing all protections
+	Armor struct {
+		config Config
+
+		document    gqlguard.DocumentLimit
+		depth       gqlguard.DepthLimit
+		alias       gqlguard.AliasLimit
+		directive   gqlguard.DirectiveLimit
+		suggestions gqlguard.HideSuggestions
+	}
+)
+
+// Default configuration, suitable for most public APIs
+func Default() Config {
+	return Config{
+		MaxDepth:                 6,
+		MaxIntrospectionDepth:    15,
+		MaxAliases:               15,
+		MaxAliasesPerSelection:   0,
+		MaxDirectives:            50,
+		MaxDirectivesPerLocation: 5,
+		MaxBytes:                 100 << 10,
+		MaxTokens:                1000,
+		MaxBatchOperations:       10,
+		MaxBatchCost:             0,
+	}
+}
+
+// New armor extension
+func New(cfg Config) *Armor {
+	return &Armor{
+		config: cfg,
+		document: gqlguard.DocumentLimit{
+			MaxBytes:  cfg.MaxBytes,
+			MaxTokens: cfg.MaxTokens,
+		},
+		depth: gqlguard.DepthLimit{
+			Max:              cfg.MaxDepth,
+			IntrospectionMax: cfg.MaxIntrospectionDepth,
+		},
+		alias: gqlguard.AliasLimit{
+			Max:             cfg.MaxAliases,
+			MaxPerSelection: cfg.MaxAliasesPerSelection,
+		},
+		directive: gqlguard.DirectiveLimit{
+			Max:            cfg.MaxDirectives,
+			MaxPerLocation: cfg.MaxDirectivesPerLocation,
+		},
+		suggestions: gqlguard.HideSuggestions{
+			Allow: cfg.AllowSuggestions,
+		},
+	}
+}
+
+// BatchTransport handling batched requests, with the batch limits of the configuration
+func (a *Armor) BatchTransport() gqlguard.Batch {
+	return gqlguard.Batch{
+		MaxOperations: a.config.MaxBatchOperations,
+		MaxCost:       a.config.MaxBatchCost,
+	}
+}
+
+// ExtensionName yields the extension name: "Armor"
+func (a *Armor) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (a *Armor) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationParameters rejects large documents, before they are parsed
+func (a *Armor) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	return a.document.MutateOperationParameters(ctx, rawParams)
+}
+
+// MutateOperationContext rejects abusive operations, before they are executed
+func (a *Armor) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	for _, guard := range []graphql.OperationContextMutator{a.depth, a.alias, a.directive} {
+		if err := guard.MutateOperationContext(ctx, rc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InterceptResponse hides suggestions from validation errors
+func (a *Armor) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if a.config.ShowSuggestions {
+		return next(ctx)
+	}
+	return a.suggestions.InterceptResponse(ctx, next)
+}
@@ -0,0 +1,65 @@
+package gqlarmor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = `
+type Query {
+	me: User
+}
+
+type User {
+	name: String
+	friend: User
+}
+`
+
+func TestArmor(t *testing.T) {
+	cfg := Default()
+	cfg.MaxDepth = 3
+	cfg.MaxBatchOperations = 2
+	armor := New(cfg)
+
+	srv := handler.New(testschema.New(schema, nil))
+	srv.AddTransport(armor.BatchTransport())
+	srv.AddTransport(transport.POST{})
+	srv.Use(armor)
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	res := do(`{"query": "{ me { friend { name } } }"}`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"me":{"friend":{"name":"name"}}}}`, res.Body.String())
+
+	for query, code := range map[string]string{
+		`{ me { friend { friend { name } } } }`:            "DEPTH_LIMIT_EXCEEDED",
+		strings.Repeat(`{ me { name } }`, 200):             "TOO_MANY_TOKENS",
+		`{ me { ` + strings.Repeat(`a: name `, 16) + `} }`: "ALIAS_LIMIT_EXCEEDED",
+		`{ me { nam } }`: "GRAPHQL_VALIDATION_FAILED",
+	} {
+		res = do(`{"query": "` + query + `"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, res.Code, query)
+		assert.Contains(t, res.Body.String(), `"code":"`+code+`"`, query)
+		assert.NotContains(t, res.Body.String(), "Did you mean")
+	}
+
+	res = do(`[{"query": "{ me { name } }"}, {"query": "{ me { name } }"}, {"query": "{ me { name } }"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":"BATCH_LIMIT_EXCEEDED"`)
+}
@@ -0,0 +1,229 @@
+// Package gqlauthz authorizes fields with the @hasRole and @scope schema directives.
+//
+// The directives must be declared in the schema (see Directive), and skipped at runtime in gqlgen.yml:
+//
+//	directives:
+//	  hasRole:
+//	    skip_runtime: true
+//	  scope:
+//	    skip_runtime: true
+//
+// A field requires any of the roles listed by @hasRole, and all of the scopes listed by @scope.
+// Directives on an object type apply to all its fields. Callers are retrieved from the request context,
+// by a pluggable PrincipalExtractor.
+//
+// By default, unauthorized fields resolve to null with an error, and the rest of the operation is executed.
+// With the Reject option, operations selecting unauthorized fields are rejected before execution.
+//
+// Some roles or principals may be read-only: their mutations are rejected before execution with a READ_ONLY error.
+package gqlauthz
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "Authorization"
+
+	// ErrForbiddenCode is the error code of unauthorized fields
+	ErrForbiddenCode = "FORBIDDEN"
+
+	// ErrReadOnlyCode is the error code of mutations of read-only principals
+	ErrReadOnlyCode = "READ_ONLY"
+
+	reasonDirective = "directive"
+	reasonReadOnly  = "read_only"
+
+	// Directive is the schema definition of the @hasRole and @scope directives
+	Directive = `directive @hasRole(roles: [String!]!) on FIELD_DEFINITION | OBJECT
+directive @scope(scopes: [String!]!) on FIELD_DEFINITION | OBJECT
+`
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrForbiddenCode, errcode.KindUser)
+	errcode.RegisterErrorType(ErrReadOnlyCode, errcode.KindUser)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+	graphql.FieldInterceptor
+} = &Authorizer{}
+
+// Authorizer is a gqlgen extension checking the @hasRole and @scope directives
+type Authorizer struct {
+	config
+	schema *ast.Schema
+}
+
+// New authorization extension
+func New(opts ...Option) *Authorizer {
+	a := &Authorizer{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&a.config)
+	}
+	return a
+}
+
+// ExtensionName yields the extension name: "Authorization"
+func (a *Authorizer) ExtensionName() string {
+	return extensionName
+}
+
+// Validate retains the schema, to resolve directives on types
+func (a *Authorizer) Validate(schema graphql.ExecutableSchema) error {
+	a.schema = schema.Schema()
+	return nil
+}
+
+// MutateOperationContext rejects mutations of read-only principals, and operations selecting unauthorized fields
+// with the Reject option
+func (a *Authorizer) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	principal, authenticated := a.extractor(ctx)
+	if rc.Operation.Operation == ast.Mutation && authenticated && a.readOnly(principal) {
+		a.deny(ctx, rc, principal, authenticated, "Mutation", reasonReadOnly)
+		err := gqlerror.ErrorPosf(rc.Operation.Position, "read-only access: mutations are not allowed")
+		errcode.Set(err, ErrReadOnlyCode)
+		return err
+	}
+
+	if !a.reject {
+		return nil
+	}
+	var check func(set ast.SelectionSet) *gqlerror.Error
+	check = func(set ast.SelectionSet) *gqlerror.Error {
+		for _, selection := range set {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				if sel.Definition != nil && sel.ObjectDefinition != nil {
+					if !a.authorized(principal, authenticated, sel.ObjectDefinition, sel.Definition) {
+						a.deny(ctx, rc, principal, authenticated, sel.ObjectDefinition.Name+"."+sel.Name, reasonDirective)
+						err := gqlerror.ErrorPosf(sel.Position, "not authorized to access %s.%s", sel.ObjectDefinition.Name, sel.Name)
+						errcode.Set(err, ErrForbiddenCode)
+						return err
+					}
+				}
+				if err := check(sel.SelectionSet); err != nil {
+					return err
+				}
+			case *ast.InlineFragment:
+				if err := check(sel.SelectionSet); err != nil {
+					return err
+				}
+			case *ast.FragmentSpread:
+				if sel.Definition != nil {
+					if err := check(sel.Definition.SelectionSet); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+	return check(rc.Operation.SelectionSet)
+}
+
+// InterceptField resolves unauthorized fields to null, with an error
+func (a *Authorizer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Field == nil || fc.Field.Definition == nil || a.schema == nil {
+		return next(ctx)
+	}
+
+	obj := a.schema.Types[fc.Object]
+	if obj == nil {
+		return next(ctx)
+	}
+
+	principal, authenticated := a.extractor(ctx)
+	if a.authorized(principal, authenticated, obj, fc.Field.Definition) {
+		return next(ctx)
+	}
+
+	a.deny(ctx, graphql.GetOperationContext(ctx), principal, authenticated, fc.Object+"."+fc.Field.Name, reasonDirective)
+	err := gqlerror.ErrorPathf(fc.Path(), "not authorized to access %s.%s", fc.Object, fc.Field.Name)
+	errcode.Set(err, ErrForbiddenCode)
+	return nil, err
+}
+
+// authorized checks the directives of the field definition, then of the object
+func (a *Authorizer) authorized(p Principal, authenticated bool, obj *ast.Definition, field *ast.FieldDefinition) bool {
+	for _, directives := range []ast.DirectiveList{field.Directives, obj.Directives} {
+		if d := directives.ForName(a.roleDirective); d != nil {
+			if !authenticated || !p.HasRole(stringList(d, "roles")...) {
+				return false
+			}
+		}
+		if d := directives.ForName(a.scopeDirective); d != nil {
+			if !authenticated || !p.HasScopes(stringList(d, "scopes")...) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (a *Authorizer) readOnly(p Principal) bool {
+	if p.HasRole(a.readOnlyRoles...) {
+		return true
+	}
+	for _, id := range a.readOnlyIDs {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// deny records and audits a denial
+func (a *Authorizer) deny(ctx context.Context, rc *graphql.OperationContext, p Principal, authenticated bool, coordinate, reason string) {
+	var opName string
+	if rc != nil {
+		opName = fingerprint.OperationName(rc)
+	}
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.TagField, coordinate), tag.Upsert(metrics.TagOperation, opName)},
+		Denials.M(1),
+	)
+
+	if a.audit != nil {
+		a.audit(ctx, Denial{
+			Principal:     p,
+			Authenticated: authenticated,
+			Operation:     opName,
+			Coordinate:    coordinate,
+			Reason:        reason,
+		})
+	}
+}
+
+func stringList(d *ast.Directive, name string) []string {
+	arg := d.Arguments.ForName(name)
+	if arg == nil || arg.Value == nil {
+		return nil
+	}
+	if len(arg.Value.Children) == 0 && arg.Value.Kind == ast.StringValue {
+		// a single value is coerced to a list
+		return []string{arg.Value.Raw}
+	}
+	values := make([]string, 0, len(arg.Value.Children))
+	for _, child := range arg.Value.Children {
+		values = append(values, child.Value.Raw)
+	}
+	return values
+}
@@ -0,0 +1,99 @@
+package gqlauthz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = Directive + `
+type Query {
+	me: User
+	billing: Billing @scope(scopes: ["billing:read"])
+}
+
+type User {
+	name: String
+	email: String @hasRole(roles: ["admin", "support"])
+}
+
+type Billing @hasRole(roles: ["admin"]) {
+	plan: String
+}
+`
+
+// as authenticates requests with a principal
+func as(p Principal) gqltesting.RequestOption {
+	return gqltesting.RequestContext(func(ctx context.Context) context.Context {
+		return WithPrincipal(ctx, p)
+	})
+}
+
+func TestAuthorizer(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	srv := gqltesting.NewServer(testschema.New(schema, nil), New())
+	admin := Principal{Roles: []string{"admin"}, Scopes: []string{"billing:read"}}
+	user := Principal{Roles: []string{"user"}, Scopes: []string{"billing:read"}}
+
+	assert.Equal(t, `{"data":{"billing":{"plan":"plan"},"me":{"email":"email","name":"name"}}}`, gqltesting.Post(srv, `{ me { name email } billing { plan } }`, as(admin)).Body.String())
+
+	assert.JSONEq(t, `{
+		"errors":[{"message":"not authorized to access User.email","path":["me","email"],"extensions":{"code":"FORBIDDEN"}}],
+		"data":{"me":{"name":"name","email":null}}
+	}`, gqltesting.Post(srv, `{ me { name email } }`, as(user)).Body.String())
+
+	assert.JSONEq(t, `{
+		"errors":[{"message":"not authorized to access Query.billing","path":["billing"],"extensions":{"code":"FORBIDDEN"}}],
+		"data":{"billing":null}
+	}`, gqltesting.Post(srv, `{ billing { plan } }`).Body.String())
+
+	rows, err := view.RetrieveData(DenialCountView.Name)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+}
+
+func TestReject(t *testing.T) {
+	srv := gqltesting.NewServer(testschema.New(schema, nil), New(Reject(), WithPrincipalExtractor(func(context.Context) (Principal, bool) {
+		return Principal{Roles: []string{"support"}}, true
+	})))
+
+	assert.Equal(t, `{"data":{"me":{"email":"email"}}}`, gqltesting.Post(srv, `{ me { email } }`).Body.String())
+	assert.JSONEq(t, `{
+		"errors":[{"message":"not authorized to access Query.billing","locations":[{"line":1,"column":15}],"extensions":{"code":"FORBIDDEN"}}],
+		"data":null
+	}`, gqltesting.Post(srv, `{ me { name } billing { plan } }`).Body.String())
+}
+
+func TestReadOnly(t *testing.T) {
+	var denials []Denial
+	srv := gqltesting.NewServer(testschema.New(schema+`type Mutation { save: String }`, nil), New(ReadOnlyRoles("auditor"), ReadOnlyPrincipals("key-ci"), Audit(func(_ context.Context, d Denial) {
+		denials = append(denials, d)
+	})))
+
+	assert.Equal(t, `{"data":{"save":"save"}}`, gqltesting.Post(srv, `mutation { save }`, as(Principal{ID: "alice", Roles: []string{"admin"}})).Body.String())
+	assert.Equal(t, `{"data":{"me":{"name":"name"}}}`, gqltesting.Post(srv, `{ me { name } }`, as(Principal{ID: "bob", Roles: []string{"auditor"}})).Body.String())
+
+	readOnly := `{
+		"errors":[{"message":"read-only access: mutations are not allowed","locations":[{"line":1,"column":1}],"extensions":{"code":"READ_ONLY"}}],
+		"data":null
+	}`
+	assert.JSONEq(t, readOnly, gqltesting.Post(srv, `mutation save { save }`, as(Principal{ID: "bob", Roles: []string{"auditor"}})).Body.String())
+	assert.JSONEq(t, readOnly, gqltesting.Post(srv, `mutation { save }`, as(Principal{ID: "key-ci"})).Body.String())
+
+	require.Len(t, denials, 2)
+	assert.Equal(t, Denial{
+		Principal:     Principal{ID: "bob", Roles: []string{"auditor"}},
+		Authenticated: true,
+		Operation:     "save",
+		Coordinate:    "Mutation",
+		Reason:        "read_only",
+	}, denials[0])
+}
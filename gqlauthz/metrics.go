@@ -0,0 +1,47 @@
+package gqlauthz
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of the authorization extension.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(AuthzViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the authorization extension
+func UnregisterViews() {
+	view.Unregister(AuthzViews...)
+}
+
+var (
+	// AuthzViews contains all opencensus stats views declared by the authorization extension
+	AuthzViews = []*view.View{
+		DenialCountView,
+	}
+
+	// measurements
+
+	// Denials tracks a count of unauthorized field accesses
+	Denials = stats.Int64(
+		"gql/authz/denied_count",
+		"Number of unauthorized GraphQL field accesses",
+		stats.UnitDimensionless)
+
+	// views
+
+	// DenialCountView reports a count of unauthorized field accesses, by field coordinate ("Type.field") and operation name
+	DenialCountView = &view.View{
+		Name:        "gql/authz/denied_count",
+		Description: "Count of unauthorized GraphQL field accesses by field and operation",
+		Measure:     Denials,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagField, metrics.TagOperation},
+	}
+)
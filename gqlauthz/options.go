@@ -0,0 +1,66 @@
+package gqlauthz
+
+// Option for the authorization extension
+type Option func(*config)
+
+type config struct {
+	extractor      PrincipalExtractor
+	reject         bool
+	roleDirective  string
+	scopeDirective string
+	readOnlyRoles  []string
+	readOnlyIDs    []string
+	audit          AuditFunc
+}
+
+func defaultConfig() config {
+	return config{
+		extractor:      FromContext,
+		roleDirective:  "hasRole",
+		scopeDirective: "scope",
+	}
+}
+
+// WithPrincipalExtractor retrieves callers with extract. By default, callers are stored in the context with WithPrincipal.
+func WithPrincipalExtractor(extract PrincipalExtractor) Option {
+	return func(c *config) {
+		c.extractor = extract
+	}
+}
+
+// Reject operations selecting any unauthorized field before execution, instead of resolving these fields to null.
+func Reject() Option {
+	return func(c *config) {
+		c.reject = true
+	}
+}
+
+// DirectiveNames overrides the names of the directives, e.g. when the schema already defines @scope.
+// The defaults are "hasRole" and "scope".
+func DirectiveNames(role, scope string) Option {
+	return func(c *config) {
+		c.roleDirective = role
+		c.scopeDirective = scope
+	}
+}
+
+// ReadOnlyRoles rejects mutations of principals with any of the roles
+func ReadOnlyRoles(roles ...string) Option {
+	return func(c *config) {
+		c.readOnlyRoles = append(c.readOnlyRoles, roles...)
+	}
+}
+
+// ReadOnlyPrincipals rejects mutations of principals with any of the IDs, e.g. the IDs of some API keys
+func ReadOnlyPrincipals(ids ...string) Option {
+	return func(c *config) {
+		c.readOnlyIDs = append(c.readOnlyIDs, ids...)
+	}
+}
+
+// Audit denials, e.g. to an audit log
+func Audit(audit AuditFunc) Option {
+	return func(c *config) {
+		c.audit = audit
+	}
+}
@@ -0,0 +1,72 @@
+package gqlauthz
+
+import "context"
+
+type principalKey struct{}
+
+type (
+	// Principal is the authenticated caller
+	Principal struct {
+		ID     string
+		Roles  []string
+		Scopes []string
+	}
+
+	// PrincipalExtractor retrieves the caller from the request context, e.g. from verified JWT claims.
+	// It yields false for anonymous callers.
+	PrincipalExtractor func(context.Context) (Principal, bool)
+
+	// Denial is an audit event of an unauthorized access
+	Denial struct {
+		Principal     Principal
+		Authenticated bool
+		Operation     string
+		// Coordinate of the unauthorized field, e.g. "User.email", or "Mutation" for read-only principals
+		Coordinate string
+		// Reason of the denial: "directive" or "read_only"
+		Reason string
+	}
+
+	// AuditFunc audits denials
+	AuditFunc func(context.Context, Denial)
+)
+
+// WithPrincipal stores the caller in the context, for the default principal extractor
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext retrieves the caller stored by WithPrincipal. It is the default principal extractor.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// HasRole tells if the principal has any of the roles
+func (p Principal) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		for _, r := range p.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasScopes tells if the principal has all of the scopes
+func (p Principal) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		found := false
+		for _, s := range p.Scopes {
+			if s == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
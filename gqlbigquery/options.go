@@ -0,0 +1,115 @@
+package gqlbigquery
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/errclass"
+)
+
+// Option for the BigQuery streamer
+type Option func(*config)
+
+type config struct {
+	endpoint     string
+	token        func(ctx context.Context) (string, error)
+	client       *http.Client
+	batchSize    int
+	interval     time.Duration
+	maxBuffered  int
+	maxRetries   int
+	minBackoff   time.Duration
+	host         string
+	classifier   errclass.Classifier
+	errorHandler func(error)
+}
+
+func defaultConfig() config {
+	host, _ := os.Hostname()
+	return config{
+		endpoint:    DefaultEndpoint,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		batchSize:   500,
+		interval:    5 * time.Second,
+		maxBuffered: 10000,
+		maxRetries:  5,
+		minBackoff:  100 * time.Millisecond,
+		host:        host,
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+	}
+}
+
+// Endpoint overrides the BigQuery API endpoint. The default is DefaultEndpoint.
+func Endpoint(url string) Option {
+	return func(c *config) {
+		c.endpoint = url
+	}
+}
+
+// Token yields the OAuth2 access tokens of requests, e.g. from a golang.org/x/oauth2 TokenSource. By default, tokens
+// are those of the default service account of the Google Cloud server.
+func Token(token func(ctx context.Context) (string, error)) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// HTTPClient sets the client used to send requests
+func HTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// Batch sets the maximum number of rows per request, and the maximum time rows are buffered before they are sent.
+// The defaults are 500 rows and 5s.
+func Batch(size int, interval time.Duration) Option {
+	return func(c *config) {
+		c.batchSize = size
+		c.interval = interval
+	}
+}
+
+// MaxBuffered bounds the memory used by rows waiting to be sent. Rows are dropped once the bound is reached, e.g. when
+// BigQuery is unavailable. The default is 10000.
+func MaxBuffered(n int) Option {
+	return func(c *config) {
+		c.maxBuffered = n
+	}
+}
+
+// Retries sets the maximum number of retries of a failed request, and the initial backoff between retries.
+// The defaults are 5 retries, with an initial backoff of 100ms.
+func Retries(maxRetries int, minBackoff time.Duration) Option {
+	return func(c *config) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+	}
+}
+
+// Host recorded in rows. By default this is the OS hostname
+func Host(host string) Option {
+	return func(c *config) {
+		c.host = host
+	}
+}
+
+// ErrorClassifier classifies the errors of operations in the error_class column. The default is errclass.Default.
+func ErrorClassifier(classifier errclass.Classifier) Option {
+	return func(c *config) {
+		c.classifier = classifier
+	}
+}
+
+// ErrorHandler is called with errors occurring while sending rows in the background.
+// By default, errors are logged.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
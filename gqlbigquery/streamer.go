@@ -0,0 +1,90 @@
+// Package gqlbigquery streams a row per GraphQL operation into a BigQuery table, for teams whose analytics stack is
+// BigQuery-native:
+//
+//	streamer := gqlbigquery.New("my-project", "graphql", "operations")
+//	srv.Use(streamer)
+//	defer streamer.Shutdown(ctx)
+//
+// The streamer is an emitter of gqlevents with a single Table sink: rows are buffered, and sent in batches with the
+// insertAll streaming API, with retries and exponential backoff. The table is created with Schema on the first batch,
+// partitioned by day, and missing columns are added to existing tables. Emitters with other sinks use the Table sink
+// directly.
+//
+// Requests are authenticated with the default service account of the Google Cloud server, unless the Token option
+// is set.
+package gqlbigquery
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlevents"
+)
+
+const (
+	extensionName = "BigQueryAnalytics"
+
+	// DefaultEndpoint is the endpoint of the BigQuery API
+	DefaultEndpoint = "https://bigquery.googleapis.com"
+
+	// SinkName is the name of the sink of the streamer, as the gqlevents.TagSink of the metrics of its delivery
+	SinkName = "bigquery"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Streamer{}
+
+type (
+	// Streamer is a gqlgen extension streaming a row per operation into a BigQuery table
+	Streamer struct {
+		*gqlevents.Emitter
+		table *Table
+	}
+
+	// Row of an operation, as inserted in the table. See Schema.
+	Row struct {
+		Timestamp     time.Time `json:"timestamp"`
+		Operation     string    `json:"operation,omitempty"`
+		OperationType string    `json:"operation_type,omitempty"`
+		Fingerprint   string    `json:"fingerprint,omitempty"`
+		ClientName    string    `json:"client_name,omitempty"`
+		ClientVersion string    `json:"client_version,omitempty"`
+		Host          string    `json:"host,omitempty"`
+		DurationMs    float64   `json:"duration_ms"`
+		Errors        int       `json:"errors"`
+		ErrorClass    string    `json:"error_class,omitempty"`
+
+		insertID string
+	}
+)
+
+// New BigQuery streamer, inserting rows in the table of a dataset of a project.
+//
+// The streamer sends rows in the background until Shutdown is called. Rows dropped once MaxBuffered is reached, or
+// which could not be sent, are counted by the views of gqlevents, with the SinkName tag.
+func New(project, dataset, table string, opts ...Option) *Streamer {
+	t := NewTable(project, dataset, table, opts...)
+	c := t.config
+	emitter := gqlevents.New(
+		gqlevents.To(t, gqlevents.RouteOptions{
+			Name:       SinkName,
+			BufferSize: c.maxBuffered,
+			BatchSize:  c.batchSize,
+			Interval:   c.interval,
+			// requests are retried by the table
+			MaxRetries: -1,
+		}),
+		gqlevents.Host(c.host),
+		gqlevents.ErrorClassifier(c.classifier),
+		gqlevents.ErrorHandler(c.errorHandler),
+	)
+	return &Streamer{Emitter: emitter, table: t}
+}
+
+// ExtensionName yields the extension name: "BigQueryAnalytics"
+func (*Streamer) ExtensionName() string {
+	return extensionName
+}
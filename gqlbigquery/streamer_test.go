@@ -0,0 +1,199 @@
+package gqlbigquery
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqlevents"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+// fakeBigQuery records the requests to the BigQuery API
+type fakeBigQuery struct {
+	mu       sync.Mutex
+	requests []string
+	table    string
+	rows     []map[string]interface{}
+	failures int
+}
+
+func (f *fakeBigQuery) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	body, _ := ioutil.ReadAll(r.Body)
+
+	const table = "/bigquery/v2/projects/p/datasets/d/tables"
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == table+"/t":
+		if f.table == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(f.table))
+	case r.Method == http.MethodPost && r.URL.Path == table, r.Method == http.MethodPatch:
+		f.table = string(body)
+	case r.Method == http.MethodPost && r.URL.Path == table+"/t/insertAll":
+		if f.failures > 0 {
+			f.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req struct {
+			Rows []struct {
+				InsertID string                 `json:"insertId"`
+				JSON     map[string]interface{} `json:"json"`
+			} `json:"rows"`
+		}
+		_ = json.Unmarshal(body, &req)
+		for _, row := range req.Rows {
+			f.rows = append(f.rows, row.JSON)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newStreamer(t *testing.T, fake *fakeBigQuery, opts ...Option) (*Streamer, func()) {
+	bq := httptest.NewServer(fake)
+	s := New("p", "d", "t", append([]Option{
+		Endpoint(bq.URL),
+		Token(func(context.Context) (string, error) { return "token", nil }),
+		Batch(2, time.Hour),
+		Retries(2, time.Millisecond),
+		Host("box"),
+	}, opts...)...)
+	return s, func() {
+		_ = s.Shutdown(context.Background())
+		bq.Close()
+	}
+}
+
+func TestStreamer(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	fake := &fakeBigQuery{failures: 1}
+	streamer, stop := newStreamer(t, fake, Batch(10, time.Hour))
+	defer stop()
+
+	srv := handler.New(testschema.New(`type Query { todo: String fail: String }`, testschema.Resolvers{
+		"Query.todo": func(context.Context) (interface{}, error) {
+			clock.Advance(5 * time.Millisecond)
+			return "todo", nil
+		},
+		"Query.fail": func(context.Context) (interface{}, error) {
+			return nil, gqlerror.Errorf("failed")
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(streamer)
+	for _, query := range []string{`query todos { todo }`, `{ fail }`, `{ todo }`} {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("apollographql-client-name", "web")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	require.NoError(t, streamer.Flush(context.Background()))
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, []string{
+		"GET /bigquery/v2/projects/p/datasets/d/tables/t",
+		"POST /bigquery/v2/projects/p/datasets/d/tables",
+		"POST /bigquery/v2/projects/p/datasets/d/tables/t/insertAll",
+		"POST /bigquery/v2/projects/p/datasets/d/tables/t/insertAll",
+	}, fake.requests, "the table is created once, and failed inserts are retried")
+	assert.Contains(t, fake.table, `"timePartitioning":{"type":"DAY","field":"timestamp"}`)
+
+	require.Len(t, fake.rows, 3)
+	assert.Equal(t, map[string]interface{}{
+		"timestamp":      "2020-01-01T00:00:00Z",
+		"operation":      "todos",
+		"operation_type": "query",
+		"fingerprint":    fake.rows[0]["fingerprint"],
+		"client_name":    "web",
+		"host":           "box",
+		"duration_ms":    5.0,
+		"errors":         0.0,
+	}, fake.rows[0])
+	assert.Equal(t, 1.0, fake.rows[1]["errors"])
+	assert.Equal(t, "server", fake.rows[1]["error_class"])
+}
+
+func TestSchemaMigration(t *testing.T) {
+	fake := &fakeBigQuery{table: `{"schema": {"fields": [{"name": "timestamp", "type": "TIMESTAMP", "mode": "REQUIRED"}, {"name": "legacy", "type": "STRING"}]}}`}
+	streamer, stop := newStreamer(t, fake)
+	defer stop()
+
+	require.NoError(t, streamer.table.ensureTable(context.Background()))
+	require.NoError(t, streamer.table.ensureTable(context.Background()))
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, []string{
+		"GET /bigquery/v2/projects/p/datasets/d/tables/t",
+		"PATCH /bigquery/v2/projects/p/datasets/d/tables/t",
+	}, fake.requests)
+	var patch struct {
+		Schema struct {
+			Fields []Field `json:"fields"`
+		} `json:"schema"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(fake.table), &patch))
+	require.Len(t, patch.Schema.Fields, len(Schema)+1)
+	assert.Equal(t, "legacy", patch.Schema.Fields[1].Name, "existing columns are kept")
+	assert.Equal(t, Field{Name: "operation", Type: "STRING", Mode: "NULLABLE", Description: "name of the operation"}, patch.Schema.Fields[2])
+}
+
+func TestMaxBuffered(t *testing.T) {
+	fake := &fakeBigQuery{}
+	streamer, stop := newStreamer(t, fake, MaxBuffered(1), Batch(10, time.Hour))
+	defer stop()
+
+	require.NoError(t, gqlevents.RegisterViews())
+	defer gqlevents.UnregisterViews()
+
+	streamer.Emit(gqlevents.Event{})
+	streamer.Emit(gqlevents.Event{})
+	metricstest.AssertSum(t, gqlevents.EventDroppedCountView, map[tag.Key]string{gqlevents.TagSink: SinkName}, 1)
+}
+
+func TestTable(t *testing.T) {
+	fake := &fakeBigQuery{}
+	bq := httptest.NewServer(fake)
+	defer bq.Close()
+	table := NewTable("p", "d", "t", Endpoint(bq.URL), Token(func(context.Context) (string, error) { return "token", nil }))
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var e gqlevents.Event
+	e.ID = "1"
+	e.Operation.Name = "todos"
+	e.Timings.Start = start
+	e.Timings.Total = 5
+	require.NoError(t, table.Send(context.Background(), []gqlevents.Event{e}))
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	require.Len(t, fake.rows, 1)
+	assert.Equal(t, "todos", fake.rows[0]["operation"])
+	assert.Equal(t, 5.0, fake.rows[0]["duration_ms"])
+}
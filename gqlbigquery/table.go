@@ -0,0 +1,301 @@
+package gqlbigquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqlevents"
+	"github.com/99designs/gqlgen-contrib/internal/gcpauth"
+)
+
+// Field of the schema of a table
+type Field struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Mode        string `json:"mode,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Schema of the table of operations, matching Row
+var Schema = []Field{
+	{Name: "timestamp", Type: "TIMESTAMP", Mode: "REQUIRED", Description: "start of the operation"},
+	{Name: "operation", Type: "STRING", Description: "name of the operation"},
+	{Name: "operation_type", Type: "STRING", Description: "query, mutation or subscription"},
+	{Name: "fingerprint", Type: "STRING", Description: "hash of the signature of the operation"},
+	{Name: "client_name", Type: "STRING"},
+	{Name: "client_version", Type: "STRING"},
+	{Name: "host", Type: "STRING", Description: "server of the operation"},
+	{Name: "duration_ms", Type: "FLOAT", Mode: "REQUIRED"},
+	{Name: "errors", Type: "INTEGER", Mode: "REQUIRED", Description: "number of errors in the response"},
+	{Name: "error_class", Type: "STRING", Description: "worst class of the errors, see errclass"},
+}
+
+type (
+	// Table is a gqlevents.Sink inserting events as rows of a BigQuery table, see Schema. It shares the pipeline of an
+	// emitter with other sinks:
+	//
+	//	emitter := gqlevents.New(
+	//		gqlevents.To(gqlbigquery.NewTable("my-project", "graphql", "operations"), gqlevents.RouteOptions{Name: "bigquery", MaxRetries: -1}),
+	//		gqlevents.To(gqlevents.WebhookSink(url, nil), gqlevents.RouteOptions{Name: "webhook"}),
+	//	)
+	//
+	// Failed requests are retried by the table, with Retries: retries of the route may be disabled.
+	Table struct {
+		config
+		project string
+		dataset string
+		table   string
+
+		mu    sync.Mutex
+		ready bool
+	}
+
+	tableResource struct {
+		TableReference   tableReference    `json:"tableReference"`
+		Schema           tableSchema       `json:"schema"`
+		TimePartitioning *timePartitioning `json:"timePartitioning,omitempty"`
+	}
+
+	tableReference struct {
+		ProjectID string `json:"projectId"`
+		DatasetID string `json:"datasetId"`
+		TableID   string `json:"tableId"`
+	}
+
+	tableSchema struct {
+		Fields []Field `json:"fields"`
+	}
+
+	timePartitioning struct {
+		Type  string `json:"type"`
+		Field string `json:"field"`
+	}
+
+	insertRequest struct {
+		Rows []insertRow `json:"rows"`
+	}
+
+	insertRow struct {
+		InsertID string `json:"insertId"`
+		JSON     Row    `json:"json"`
+	}
+
+	insertResponse struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+
+	// statusError is the status of a failed request
+	statusError struct {
+		status int
+		msg    string
+	}
+)
+
+var _ gqlevents.Sink = &Table{}
+
+// NewTable yields the sink of the table of a dataset of a project. Only the options of requests apply: Endpoint,
+// Token, HTTPClient and Retries.
+func NewTable(project, dataset, table string, opts ...Option) *Table {
+	t := &Table{config: defaultConfig(), project: project, dataset: dataset, table: table}
+	for _, apply := range opts {
+		apply(&t.config)
+	}
+	if t.token == nil {
+		t.token = gcpauth.MetadataToken(t.client, gcpauth.MetadataURL)
+	}
+	return t
+}
+
+// Send implements gqlevents.Sink: it creates or migrates the table on the first batch, then inserts the rows of the
+// events, deduplicated by event ID
+func (t *Table) Send(ctx context.Context, events []gqlevents.Event) error {
+	if err := t.ensureTable(ctx); err != nil {
+		return err
+	}
+	rows := make([]Row, len(events))
+	for i, e := range events {
+		rows[i] = rowOf(e)
+	}
+	return t.insert(ctx, rows)
+}
+
+// rowOf an event
+func rowOf(e gqlevents.Event) Row {
+	return Row{
+		Timestamp:     e.Timings.Start,
+		Operation:     e.Operation.Name,
+		OperationType: e.Operation.Type,
+		Fingerprint:   e.Operation.Fingerprint,
+		ClientName:    e.Client.Name,
+		ClientVersion: e.Client.Version,
+		Host:          e.Host,
+		DurationMs:    e.Timings.Total,
+		Errors:        e.Errors.Count,
+		ErrorClass:    e.Errors.Class,
+		insertID:      e.ID,
+	}
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("gqlbigquery: request failed with status %d: %s", e.status, e.msg)
+}
+
+func (t *Table) tableURL() string {
+	return strings.TrimSuffix(t.endpoint, "/") + "/bigquery/v2/projects/" + url.PathEscape(t.project) +
+		"/datasets/" + url.PathEscape(t.dataset) + "/tables"
+}
+
+// ensureTable creates the table, or adds the missing columns of Schema to it, once
+func (t *Table) ensureTable(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ready {
+		return nil
+	}
+
+	var table tableResource
+	err := t.do(ctx, http.MethodGet, t.tableURL()+"/"+url.PathEscape(t.table), nil, &table)
+	if se, ok := err.(*statusError); ok && se.status == http.StatusNotFound {
+		err = t.do(ctx, http.MethodPost, t.tableURL(), tableResource{
+			TableReference:   tableReference{ProjectID: t.project, DatasetID: t.dataset, TableID: t.table},
+			Schema:           tableSchema{Fields: Schema},
+			TimePartitioning: &timePartitioning{Type: "DAY", Field: "timestamp"},
+		}, nil)
+		if err != nil {
+			return err
+		}
+		t.ready = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(table.Schema.Fields))
+	for _, f := range table.Schema.Fields {
+		existing[f.Name] = true
+	}
+	fields := table.Schema.Fields
+	for _, f := range Schema {
+		if !existing[f.Name] {
+			// columns added to existing tables are nullable
+			f.Mode = "NULLABLE"
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) > len(table.Schema.Fields) {
+		patch := map[string]interface{}{"schema": tableSchema{Fields: fields}}
+		if err = t.do(ctx, http.MethodPatch, t.tableURL()+"/"+url.PathEscape(t.table), patch, nil); err != nil {
+			return err
+		}
+	}
+	t.ready = true
+	return nil
+}
+
+// insert a batch of rows with the streaming API
+func (t *Table) insert(ctx context.Context, batch []Row) error {
+	req := insertRequest{Rows: make([]insertRow, len(batch))}
+	for i, row := range batch {
+		req.Rows[i] = insertRow{InsertID: row.insertID, JSON: row}
+	}
+
+	var resp insertResponse
+	if err := t.do(ctx, http.MethodPost, t.tableURL()+"/"+url.PathEscape(t.table)+"/insertAll", req, &resp); err != nil {
+		return err
+	}
+	if len(resp.InsertErrors) > 0 {
+		first := resp.InsertErrors[0]
+		msg := "unknown error"
+		if len(first.Errors) > 0 {
+			msg = first.Errors[0].Reason + ": " + first.Errors[0].Message
+		}
+		return fmt.Errorf("gqlbigquery: %d rows rejected, e.g. row %d: %s", len(resp.InsertErrors), first.Index, msg)
+	}
+	return nil
+}
+
+// do a request, retrying transient failures with an exponential backoff
+func (t *Table) do(ctx context.Context, method, u string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("gqlbigquery: could not marshal request: %w", err)
+		}
+	}
+
+	backoff := t.minBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := t.request(ctx, method, u, payload, out)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= t.maxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (t *Table) request(ctx context.Context, method, u string, payload []byte, out interface{}) (retryable bool, err error) {
+	token, err := t.token(ctx)
+	if err != nil {
+		return true, fmt.Errorf("gqlbigquery: could not get a token: %w", err)
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return false, fmt.Errorf("gqlbigquery: could not create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("gqlbigquery: could not send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		if out == nil {
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			return false, nil
+		}
+		if err = json.NewDecoder(res.Body).Decode(out); err != nil {
+			return false, fmt.Errorf("gqlbigquery: could not decode response: %w", err)
+		}
+		return false, nil
+	}
+
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests,
+		&statusError{status: res.StatusCode, msg: string(bytes.TrimSpace(msg))}
+}
@@ -0,0 +1,147 @@
+// Package gqlbreaker provides circuit breakers for resolvers, protecting upstream services behind flaky resolvers.
+//
+// A circuit opens when the error rate of its resolvers exceeds a threshold. Open circuits short-circuit resolvers
+// with a CIRCUIT_OPEN error, or a fallback value, then probe the upstream service with a single call:
+//
+//	srv.Use(gqlbreaker.New(
+//		gqlbreaker.Group("reviews", "Product.reviews", "User.reviews"),
+//		gqlbreaker.Fallback("reviews", []*model.Review{}),
+//		gqlbreaker.SlowCall(2*time.Second),
+//	))
+package gqlbreaker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	extensionName = "CircuitBreaker"
+
+	// ErrCircuitOpenCode is the error code of resolvers short-circuited by an open circuit
+	ErrCircuitOpenCode = "CIRCUIT_OPEN"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &Breaker{}
+
+// Breaker is a gqlgen extension short-circuiting resolvers of failing fields
+type Breaker struct {
+	config
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// New circuit breaker extension
+func New(opts ...Option) *Breaker {
+	b := &Breaker{
+		config:   defaultConfig(),
+		circuits: make(map[string]*circuit),
+	}
+	for _, apply := range opts {
+		apply(&b.config)
+	}
+	return b
+}
+
+// ExtensionName yields the extension name: "CircuitBreaker"
+func (b *Breaker) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (b *Breaker) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField calls resolvers through their circuit
+func (b *Breaker) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	group := b.group(fc)
+	if group == "" {
+		return next(ctx)
+	}
+
+	c := b.circuit(group)
+	start := b.now()
+	allowed, probe, transition := c.allow(&b.config, start)
+	b.record(ctx, group, transition)
+	if !allowed {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagGroup, group)}, ShortCircuited.M(1))
+		if fallback, ok := b.fallbacks[group]; ok {
+			return fallback, nil
+		}
+		err := gqlerror.ErrorPathf(fc.Path(), "%s.%s is temporarily unavailable", fc.Object, fc.Field.Name)
+		errcode.Set(err, ErrCircuitOpenCode)
+		return nil, err
+	}
+
+	res, err := next(ctx)
+	if ctx.Err() == context.Canceled {
+		// cancelled by the client: the upstream service is not to blame
+		if probe {
+			c.abandon()
+		}
+		return res, err
+	}
+
+	now := b.now()
+	failed := err != nil || (b.slowCall > 0 && now.Sub(start) > b.slowCall)
+	b.record(ctx, group, c.done(&b.config, now, probe, failed))
+	return res, err
+}
+
+// State of a circuit
+func (b *Breaker) State(group string) State {
+	b.mu.Lock()
+	c, ok := b.circuits[group]
+	b.mu.Unlock()
+	if !ok {
+		return Closed
+	}
+	return c.current()
+}
+
+func (b *Breaker) group(fc *graphql.FieldContext) string {
+	coordinate := fc.Object + "." + fc.Field.Name
+	if group, ok := b.groups[coordinate]; ok {
+		return group
+	}
+	if b.groupBy != nil {
+		return b.groupBy(fc)
+	}
+	return coordinate
+}
+
+func (b *Breaker) circuit(group string) *circuit {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[group]
+	if !ok {
+		c = &circuit{since: b.now()}
+		b.circuits[group] = c
+	}
+	return c
+}
+
+func (b *Breaker) record(ctx context.Context, group string, transition *State) {
+	if transition == nil {
+		return
+	}
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(TagGroup, group),
+		tag.Upsert(TagState, transition.String()),
+	}, CircuitState.M(int64(*transition)))
+}
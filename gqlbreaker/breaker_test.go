@@ -0,0 +1,132 @@
+package gqlbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestBreaker(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	var (
+		failing = true
+		calls   int
+	)
+	flaky := func(ctx context.Context) (interface{}, error) {
+		calls++
+		if failing {
+			return nil, errors.New("upstream failure")
+		}
+		return "ok", nil
+	}
+	srv := handler.New(testschema.New(`type Query { reviews: String, ratings: String }`,
+		testschema.Resolvers{"Query.reviews": flaky, "Query.ratings": flaky}))
+	srv.AddTransport(transport.POST{})
+
+	now := time.Now()
+	b := New(MinCalls(2), ErrorRate(0.5), OpenFor(time.Minute), Fallback("Query.ratings", "n/a"))
+	b.now = func() time.Time { return now }
+	srv.Use(b)
+
+	gqltesting.Post(srv, `{ reviews }`)
+	assert.Equal(t, Closed, b.State("Query.reviews"))
+	gqltesting.Post(srv, `{ reviews }`)
+	assert.Equal(t, Open, b.State("Query.reviews"))
+
+	assert.JSONEq(t, `{
+		"errors":[{"message":"Query.reviews is temporarily unavailable","path":["reviews"],"extensions":{"code":"CIRCUIT_OPEN"}}],
+		"data":{"reviews":null}
+	}`, gqltesting.Post(srv, `{ reviews }`).Body.String())
+	assert.Equal(t, 2, calls)
+
+	gqltesting.Post(srv, `{ ratings }`)
+	gqltesting.Post(srv, `{ ratings }`)
+	assert.Equal(t, `{"data":{"ratings":"n/a"}}`, gqltesting.Post(srv, `{ ratings }`).Body.String())
+
+	// a failed probe reopens the circuit
+	now = now.Add(time.Minute)
+	gqltesting.Post(srv, `{ reviews }`)
+	assert.Equal(t, Open, b.State("Query.reviews"))
+
+	// a successful probe closes the circuit
+	failing = false
+	now = now.Add(time.Minute)
+	assert.Equal(t, `{"data":{"reviews":"ok"}}`, gqltesting.Post(srv, `{ reviews }`).Body.String())
+	assert.Equal(t, Closed, b.State("Query.reviews"))
+
+	rows, err := view.RetrieveData(StateView.Name)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+	rows, err = view.RetrieveData(ShortCircuitCountView.Name)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+}
+
+func TestSlowCall(t *testing.T) {
+	srv := handler.New(testschema.New(`type Query { slow: String }`, testschema.Resolvers{
+		"Query.slow": func(ctx context.Context) (interface{}, error) {
+			time.Sleep(5 * time.Millisecond)
+			return "slow", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	b := New(MinCalls(1), SlowCall(time.Millisecond), GroupBy(func(fc *graphql.FieldContext) string { return "upstream" }))
+	srv.Use(b)
+
+	assert.Equal(t, `{"data":{"slow":"slow"}}`, gqltesting.Post(srv, `{ slow }`).Body.String())
+	assert.Equal(t, Open, b.State("upstream"))
+}
+
+func TestCancelledProbe(t *testing.T) {
+	var (
+		failing = true
+		cancel  context.CancelFunc
+	)
+	srv := handler.New(testschema.New(`type Query { reviews: String }`, testschema.Resolvers{
+		"Query.reviews": func(ctx context.Context) (interface{}, error) {
+			if cancel != nil {
+				cancel()
+			}
+			if failing {
+				return nil, errors.New("upstream failure")
+			}
+			return "ok", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+
+	now := time.Now()
+	b := New(MinCalls(1), OpenFor(time.Minute))
+	b.now = func() time.Time { return now }
+	srv.Use(b)
+
+	gqltesting.Post(srv, `{ reviews }`)
+	require.Equal(t, Open, b.State("Query.reviews"))
+
+	// a probe cancelled by the client keeps the circuit half-open
+	now = now.Add(time.Minute)
+	gqltesting.Post(srv, `{ reviews }`, gqltesting.RequestContext(func(ctx context.Context) context.Context {
+		ctx, cancel = context.WithCancel(ctx)
+		return ctx
+	}))
+	cancel = nil
+	assert.Equal(t, HalfOpen, b.State("Query.reviews"))
+
+	// the next call probes again
+	failing = false
+	assert.Equal(t, `{"data":{"reviews":"ok"}}`, gqltesting.Post(srv, `{ reviews }`).Body.String())
+	assert.Equal(t, Closed, b.State("Query.reviews"))
+}
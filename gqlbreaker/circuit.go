@@ -0,0 +1,117 @@
+package gqlbreaker
+
+import (
+	"sync"
+	"time"
+)
+
+// State of a circuit
+type State int
+
+const (
+	// Closed circuits call resolvers
+	Closed State = iota
+
+	// HalfOpen circuits call a single resolver to probe the upstream service
+	HalfOpen
+
+	// Open circuits short-circuit resolvers
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// circuit tracks calls in fixed windows while closed
+type circuit struct {
+	mu       sync.Mutex
+	state    State
+	since    time.Time // start of the window, or time the circuit opened
+	calls    int
+	failures int
+	probing  bool
+}
+
+// allow a call, yielding whether this is a probe of a half-open circuit, and the new state on transitions
+func (c *circuit) allow(cfg *config, now time.Time) (allowed, probe bool, transition *State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case Open:
+		if now.Sub(c.since) < cfg.openFor {
+			return false, false, nil
+		}
+		c.state = HalfOpen
+		c.probing = true
+		return true, true, stateRef(HalfOpen)
+	case HalfOpen:
+		if c.probing {
+			return false, false, nil
+		}
+		c.probing = true
+		return true, true, nil
+	default:
+		if now.Sub(c.since) >= cfg.window {
+			c.since, c.calls, c.failures = now, 0, 0
+		}
+		return true, false, nil
+	}
+}
+
+// done records the outcome of an allowed call, yielding the new state on transitions
+func (c *circuit) done(cfg *config, now time.Time, probe, failed bool) *State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if probe {
+		c.probing = false
+		if c.state != HalfOpen {
+			return nil
+		}
+		if failed {
+			c.state, c.since = Open, now
+			return stateRef(Open)
+		}
+		c.state, c.since, c.calls, c.failures = Closed, now, 0, 0
+		return stateRef(Closed)
+	}
+
+	if c.state != Closed {
+		return nil
+	}
+	c.calls++
+	if failed {
+		c.failures++
+	}
+	if c.calls >= cfg.minCalls && float64(c.failures) >= cfg.errorRate*float64(c.calls) {
+		c.state, c.since = Open, now
+		return stateRef(Open)
+	}
+	return nil
+}
+
+// abandon a probe which was cancelled before its outcome was known: the circuit stays half-open, to probe again
+func (c *circuit) abandon() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+}
+
+func (c *circuit) current() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func stateRef(s State) *State {
+	return &s
+}
@@ -0,0 +1,77 @@
+package gqlbreaker
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// RegisterViews registers the opencensus views of circuit breakers.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(BreakerViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of circuit breakers
+func UnregisterViews() {
+	view.Unregister(BreakerViews...)
+}
+
+var (
+	// BreakerViews contains all opencensus stats views declared by the circuit breaker extension
+	BreakerViews = []*view.View{
+		StateView,
+		TransitionCountView,
+		ShortCircuitCountView,
+	}
+
+	// measurements
+
+	// CircuitState tracks the state of circuits: 0 when closed, 1 when half-open, 2 when open
+	CircuitState = stats.Int64(
+		"gql/breaker/state",
+		"State of circuit breakers: 0 when closed, 1 when half-open, 2 when open",
+		stats.UnitDimensionless)
+
+	// ShortCircuited tracks a count of resolver calls short-circuited by open circuits
+	ShortCircuited = stats.Int64(
+		"gql/breaker/short_circuit_count",
+		"Number of resolver calls short-circuited by open circuit breakers",
+		stats.UnitDimensionless)
+
+	// views
+
+	// StateView reports the last state of circuits, by group
+	StateView = &view.View{
+		Name:        "gql/breaker/state",
+		Description: "State of circuit breakers: 0 when closed, 1 when half-open, 2 when open, by group",
+		Measure:     CircuitState,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagGroup},
+	}
+
+	// TransitionCountView reports a count of state transitions of circuits, by group and new state
+	TransitionCountView = &view.View{
+		Name:        "gql/breaker/transition_count",
+		Description: "Count of state transitions of circuit breakers, by group and state",
+		Measure:     CircuitState,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagGroup, TagState},
+	}
+
+	// ShortCircuitCountView reports a count of short-circuited resolver calls, by group
+	ShortCircuitCountView = &view.View{
+		Name:        "gql/breaker/short_circuit_count",
+		Description: "Count of resolver calls short-circuited by open circuit breakers, by group",
+		Measure:     ShortCircuited,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagGroup},
+	}
+
+	// TagGroup is the group of a circuit
+	TagGroup = tag.MustNewKey("gql.breaker.group")
+
+	// TagState is the new state of a circuit: "closed", "half-open" or "open"
+	TagState = tag.MustNewKey("gql.breaker.state")
+)
@@ -0,0 +1,99 @@
+package gqlbreaker
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the circuit breaker extension
+type Option func(*config)
+
+// GroupFunc yields the circuit of a field, or "" to call its resolver unconditionally
+type GroupFunc func(fc *graphql.FieldContext) string
+
+type config struct {
+	groups    map[string]string
+	groupBy   GroupFunc
+	fallbacks map[string]interface{}
+	errorRate float64
+	slowCall  time.Duration
+	minCalls  int
+	window    time.Duration
+	openFor   time.Duration
+	now       func() time.Time
+}
+
+func defaultConfig() config {
+	return config{
+		groups:    make(map[string]string),
+		fallbacks: make(map[string]interface{}),
+		errorRate: 0.5,
+		minCalls:  20,
+		window:    10 * time.Second,
+		openFor:   30 * time.Second,
+		now:       time.Now,
+	}
+}
+
+// Group the circuits of some fields, e.g. all fields resolved by the same upstream service.
+// Fields are identified by their coordinate, e.g. "Query.reviews".
+func Group(name string, coordinates ...string) Option {
+	return func(c *config) {
+		for _, coordinate := range coordinates {
+			c.groups[coordinate] = name
+		}
+	}
+}
+
+// GroupBy yields the circuit of fields not listed in a Group.
+// By default, each field with a resolver has its own circuit, named after its coordinate.
+func GroupBy(fn GroupFunc) Option {
+	return func(c *config) {
+		c.groupBy = fn
+	}
+}
+
+// Fallback value of the fields of a circuit while it is open, instead of an error.
+//
+// The value must have the Go type returned by the resolvers of the fields.
+func Fallback(group string, value interface{}) Option {
+	return func(c *config) {
+		c.fallbacks[group] = value
+	}
+}
+
+// ErrorRate of the calls in a window above which a circuit opens, between 0 and 1. The default is 0.5.
+func ErrorRate(rate float64) Option {
+	return func(c *config) {
+		c.errorRate = rate
+	}
+}
+
+// SlowCall counts calls slower than latency as failures. By default, latency is ignored.
+func SlowCall(latency time.Duration) Option {
+	return func(c *config) {
+		c.slowCall = latency
+	}
+}
+
+// MinCalls in a window before a circuit may open. The default is 20.
+func MinCalls(n int) Option {
+	return func(c *config) {
+		c.minCalls = n
+	}
+}
+
+// Window in which calls are counted. The default is 10s.
+func Window(d time.Duration) Option {
+	return func(c *config) {
+		c.window = d
+	}
+}
+
+// OpenFor is the time before an open circuit probes the upstream service again. The default is 30s.
+func OpenFor(d time.Duration) Option {
+	return func(c *config) {
+		c.openFor = d
+	}
+}
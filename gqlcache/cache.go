@@ -0,0 +1,228 @@
+// Package gqlcache caches entire GraphQL query responses.
+//
+// Responses are keyed by the normalized query document, the operation name, the variables, and optional
+// vary-by-context values. Their TTL comes from the @cacheControl policy when the gqlcachecontrol extension
+// is used, and from the TTL option otherwise.
+//
+// As with the Apollo response cache, responses with a PRIVATE scope are only cached for identified sessions
+// (see the Session option), and responses with errors are never cached.
+package gqlcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcachecontrol"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+)
+
+const extensionName = "ResponseCache"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Cache{}
+
+type (
+	// Cache is a gqlgen extension caching query responses
+	Cache struct {
+		config
+		store Store
+	}
+
+	// Stats of the response cache for an operation
+	Stats struct {
+		// Hit is true when the response was served from the cache
+		Hit bool
+		// Key of the response in the store
+		Key string
+	}
+
+	entry struct {
+		Data   json.RawMessage       `json:"data"`
+		MaxAge int                   `json:"maxAge"`
+		Scope  gqlcachecontrol.Scope `json:"scope"`
+		Stored int64                 `json:"stored"`
+	}
+)
+
+// New response cache extension, keeping responses in the store
+func New(store Store, opts ...Option) *Cache {
+	c := &Cache{
+		config: defaultConfig(),
+		store:  store,
+	}
+	for _, apply := range opts {
+		apply(&c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "ResponseCache"
+func (*Cache) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (*Cache) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// GetStats returns the response cache stats of the current operation, if any
+func GetStats(ctx context.Context) *Stats {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	s, _ := graphql.GetOperationContext(ctx).Stats.GetExtension(extensionName).(*Stats)
+	return s
+}
+
+// InterceptOperation serves queries from the cache, or caches their response
+func (c *Cache) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc.Operation == nil || oc.Operation.Operation != ast.Query {
+		return next(ctx)
+	}
+
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.TagOperation, fingerprint.OperationName(oc)))
+	session := ""
+	if c.session != nil {
+		session = c.session(ctx)
+	}
+	base := c.keyBase(ctx, oc)
+	publicKey := c.key(base, "")
+	privateKey := ""
+	if session != "" {
+		privateKey = c.key(base, session)
+	}
+
+	for _, key := range []string{privateKey, publicKey} {
+		if key == "" {
+			continue
+		}
+		if resp := c.lookup(ctx, key); resp != nil {
+			oc.Stats.SetExtension(extensionName, &Stats{Hit: true, Key: key})
+			stats.Record(ctx, CacheHits.M(1))
+			return graphql.OneShot(resp)
+		}
+	}
+	stats.Record(ctx, CacheMisses.M(1))
+
+	cacheStats := &Stats{}
+	oc.Stats.SetExtension(extensionName, cacheStats)
+
+	responses := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := responses(ctx)
+		if resp == nil || len(resp.Errors) > 0 || len(resp.Data) == 0 {
+			return resp
+		}
+
+		ttl, scope := c.defaultTTL, gqlcachecontrol.ScopePublic
+		if policy, ok := gqlcachecontrol.GetPolicy(ctx); ok {
+			ttl, scope = policy.TTL(), policy.Scope
+		}
+		if c.maxTTL > 0 && ttl > c.maxTTL {
+			ttl = c.maxTTL
+		}
+		if ttl < time.Second {
+			return resp
+		}
+
+		key := publicKey
+		if scope == gqlcachecontrol.ScopePrivate {
+			if privateKey == "" {
+				return resp
+			}
+			key = privateKey
+		}
+
+		b, err := json.Marshal(entry{
+			Data:   resp.Data,
+			MaxAge: int(ttl / time.Second),
+			Scope:  scope,
+			Stored: graphql.Now().Unix(),
+		})
+		if err == nil {
+			err = c.store.Set(ctx, key, b, ttl)
+		}
+		if err != nil {
+			stats.Record(ctx, CacheErrors.M(1))
+			c.errorHandler(err)
+			return resp
+		}
+		cacheStats.Key = key
+		return resp
+	}
+}
+
+func (c *Cache) lookup(ctx context.Context, key string) *graphql.Response {
+	b, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		stats.Record(ctx, CacheErrors.M(1))
+		c.errorHandler(err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	var e entry
+	if err = json.Unmarshal(b, &e); err != nil {
+		stats.Record(ctx, CacheErrors.M(1))
+		c.errorHandler(err)
+		return nil
+	}
+
+	age := int(graphql.Now().Unix() - e.Stored)
+	if age < 0 {
+		age = 0
+	}
+	if age >= e.MaxAge {
+		return nil
+	}
+	httpheader.Set(ctx, "Age", strconv.Itoa(age))
+	httpheader.Set(ctx, "Cache-Control", gqlcachecontrol.Policy{MaxAge: e.MaxAge - age, Scope: e.Scope}.HeaderValue())
+
+	return &graphql.Response{Data: e.Data}
+}
+
+// keyBase of a response: the normalized document, the operation name, the variables and the vary-by values
+func (c *Cache) keyBase(ctx context.Context, oc *graphql.OperationContext) []byte {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatQueryDocument(oc.Doc)
+	buf.WriteByte(0)
+	buf.WriteString(oc.OperationName)
+	buf.WriteByte(0)
+	vars, _ := json.Marshal(oc.Variables) // map keys are sorted
+	buf.Write(vars)
+	for _, vary := range c.varyBy {
+		buf.WriteByte(0)
+		buf.WriteString(vary(ctx))
+	}
+	return buf.Bytes()
+}
+
+// key of a response: a hash of the key base, and of the session for private responses
+func (c *Cache) key(base []byte, session string) string {
+	h := sha256.New()
+	_, _ = h.Write(base)
+	if session != "" {
+		_, _ = h.Write([]byte{0, 1})
+		_, _ = h.Write([]byte(session))
+	}
+	return c.prefix + hex.EncodeToString(h.Sum(nil))
+}
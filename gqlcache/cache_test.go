@@ -0,0 +1,189 @@
+package gqlcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqlcachecontrol"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/internal/redis/redistest"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = gqlcachecontrol.Directive + `
+type Query {
+	public(id: Int): Int @cacheControl(maxAge: 60)
+	private: Int @cacheControl(maxAge: 60, scope: PRIVATE)
+	uncached: Int
+}
+`
+
+type sessionKey struct{}
+
+func TestCache(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	var calls int32
+	es := testschema.New(schema, testschema.Resolvers{
+		"Query.public": func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return graphql.GetFieldContext(ctx).Args["id"], nil
+		},
+		"Query.private": func(ctx context.Context) (interface{}, error) {
+			return atomic.AddInt32(&calls, 1), nil
+		},
+	})
+
+	stores := map[string]func() (Store, func()){
+		"memory": func() (Store, func()) {
+			return NewMemoryStore(100), func() {}
+		},
+		"redis": func() (Store, func()) {
+			srv := redistest.NewServer()
+			s := NewRedisStore(srv.Addr, RedisOptions{})
+			return s, func() {
+				_ = s.Close()
+				srv.Close()
+			}
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store, closeStore := newStore()
+			defer closeStore()
+			atomic.StoreInt32(&calls, 0)
+
+			srv := handler.New(es)
+			srv.AddTransport(transport.POST{})
+			srv.Use(gqlcachecontrol.New())
+			srv.Use(New(store, Session(func(ctx context.Context) string {
+				s, _ := ctx.Value(sessionKey{}).(string)
+				return s
+			})))
+
+			do := func(query, session string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+				req.Header.Set("Content-Type", "application/json")
+				if session != "" {
+					req = req.WithContext(context.WithValue(req.Context(), sessionKey{}, session))
+				}
+				w := httptest.NewRecorder()
+				httpheader.Middleware(srv).ServeHTTP(w, req)
+				return w
+			}
+
+			res := do(`{ public(id: 1) }`, "")
+			assert.JSONEq(t, `{"data":{"public":1},"extensions":{"cacheControl":{"version":1,"policy":{"maxAge":60,"scope":"PUBLIC"}}}}`, res.Body.String())
+			res = do(`{ public(id: 1) }`, "")
+			assert.JSONEq(t, `{"data":{"public":1}}`, res.Body.String())
+			assert.Equal(t, "max-age=60, public", res.Header().Get("Cache-Control"))
+			assert.Equal(t, "0", res.Header().Get("Age"))
+			assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+			// whitespace is normalized, variables are not
+			do(`query {  public(id: 1)  }`, "")
+			assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+			do(`{ public(id: 2) }`, "")
+			assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+			// private responses are cached by session only
+			do(`{ private }`, "")
+			do(`{ private }`, "")
+			assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+			do(`{ private }`, "alice")
+			res = do(`{ private }`, "alice")
+			assert.JSONEq(t, `{"data":{"private":5}}`, res.Body.String())
+			do(`{ private }`, "bob")
+			assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+
+			// uncacheable responses are not cached
+			do(`{ uncached }`, "")
+			res = do(`{ uncached }`, "")
+			assert.Contains(t, res.Body.String(), "cacheControl")
+		})
+	}
+
+	rows, err := view.RetrieveData(CacheHitCountView.Name)
+	require.NoError(t, err)
+	require.NotEmpty(t, rows)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(2)
+
+	require.NoError(t, s.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, s.Set(ctx, "b", []byte("2"), time.Minute))
+	_, ok, _ := s.Get(ctx, "a")
+	assert.True(t, ok)
+
+	require.NoError(t, s.Set(ctx, "c", []byte("3"), time.Minute))
+	assert.Equal(t, 2, s.Len())
+	_, ok, _ = s.Get(ctx, "b")
+	assert.False(t, ok, "least recently used entry is evicted")
+
+	require.NoError(t, s.Set(ctx, "d", []byte("4"), -time.Second))
+	_, ok, _ = s.Get(ctx, "d")
+	assert.False(t, ok, "expired entries are missing")
+}
+
+func TestMemoryStoreBudget(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(0, MaxBytes(16*(entryOverhead+10)), StoreName("test"))
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, s.Set(ctx, strconv.Itoa(1000+i), []byte("123456"), time.Minute))
+	}
+	assert.LessOrEqual(t, s.Bytes(), int64(16*(entryOverhead+10)))
+	assert.Equal(t, s.Bytes(), int64(s.Len()*(entryOverhead+10)))
+
+	require.NoError(t, s.Set(ctx, "big", make([]byte, 1024), time.Minute))
+	_, ok, _ := s.Get(ctx, "big")
+	assert.False(t, ok, "entries larger than a shard are not stored")
+
+	_, ok, _ = s.Get(ctx, "1099")
+	require.True(t, ok)
+	require.NoError(t, s.Set(ctx, "1099", make([]byte, 1024), time.Minute))
+	_, ok, _ = s.Get(ctx, "1099")
+	assert.False(t, ok, "entries replaced by values larger than a shard are removed")
+	assert.Equal(t, s.Bytes(), int64(s.Len()*(entryOverhead+10)))
+}
+
+func TestTieredStore(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := NewMemoryStore(10), NewMemoryStore(10)
+	s := NewTieredStore(l1, l2, time.Minute)
+
+	require.NoError(t, s.Set(ctx, "a", []byte("1"), time.Hour))
+	_, ok, _ := l1.Get(ctx, "a")
+	assert.True(t, ok)
+
+	require.NoError(t, l2.Set(ctx, "b", []byte("2"), time.Hour))
+	v, ok, err := s.Get(ctx, "b")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("2"), v)
+	_, ok, _ = l1.Get(ctx, "b")
+	assert.True(t, ok, "l2 hits are copied to l1")
+
+	apq := APQCache(s, time.Hour)
+	apq.Add(ctx, "hash", "{ me }")
+	q, ok := apq.Get(ctx, "hash")
+	assert.True(t, ok)
+	assert.Equal(t, "{ me }", q)
+}
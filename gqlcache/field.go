@@ -0,0 +1,325 @@
+package gqlcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	fieldExtensionName = "FieldCache"
+
+	// FieldDirective is the schema definition of the @cache directive
+	FieldDirective = `directive @cache(ttl: String!, key: CacheKey = PARENT) on FIELD_DEFINITION
+
+enum CacheKey {
+  PARENT
+  ARGS
+}
+`
+
+	// keyArgs keys cached fields by arguments only
+	keyArgs = "ARGS"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.FieldInterceptor
+} = &FieldCache{}
+
+type (
+	// FieldCache is a gqlgen extension caching the results of resolvers of fields annotated with @cache
+	FieldCache struct {
+		config
+		store Store
+
+		// parsed directives by field definition
+		directives sync.Map
+		// result types by field coordinate ("Type.field"), to decode cached results
+		types sync.Map
+	}
+
+	fieldDirective struct {
+		ttl    time.Duration
+		byArgs bool
+	}
+
+	// FieldStats collects the decisions of the field cache for an operation
+	FieldStats struct {
+		mu   sync.Mutex
+		hits map[string]bool
+	}
+
+	fieldStatsKey struct{}
+)
+
+// WithFieldStats collects the decisions of the field cache in the context, e.g. to debug an operation
+func WithFieldStats(ctx context.Context) (context.Context, *FieldStats) {
+	s := &FieldStats{hits: make(map[string]bool)}
+	return context.WithValue(ctx, fieldStatsKey{}, s), s
+}
+
+// Decisions yields the paths of the cached fields resolved so far: true when the field was served from the cache,
+// false when it was resolved and stored.
+func (s *FieldStats) Decisions() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	decisions := make(map[string]bool, len(s.hits))
+	for path, hit := range s.hits {
+		decisions[path] = hit
+	}
+	return decisions
+}
+
+func recordDecision(ctx context.Context, fc *graphql.FieldContext, hit bool) {
+	s, ok := ctx.Value(fieldStatsKey{}).(*FieldStats)
+	if !ok {
+		return
+	}
+	path := fc.Path().String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits[path] = hit
+}
+
+// NewFieldCache extension, keeping resolver results in the store.
+//
+// The field must be annotated with the directive (see FieldDirective), and the directive skipped at runtime
+// in gqlgen.yml. Results are keyed by field, arguments, and by default the id of the parent object:
+//
+//	type Query {
+//	  exchangeRate(currency: String!): Float! @cache(ttl: "30s", key: ARGS)
+//	}
+//	type Product {
+//	  reviews: [Review!]! @cache(ttl: "5m")
+//	}
+//
+// Results are stored as JSON, and decoded in the Go type returned by the resolver. Errors are not cached.
+//
+// The KeyPrefix, VaryBy, ErrorHandler, DirectiveName and ParentID options apply.
+func NewFieldCache(store Store, opts ...Option) *FieldCache {
+	c := &FieldCache{
+		config: defaultConfig(),
+		store:  store,
+	}
+	for _, apply := range opts {
+		apply(&c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "FieldCache"
+func (*FieldCache) ExtensionName() string {
+	return fieldExtensionName
+}
+
+// Validate the extension. This is a noop
+func (*FieldCache) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptField serves annotated fields from the cache
+func (c *FieldCache) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+	d := c.directive(fc.Field.Definition)
+	if d == nil {
+		return next(ctx)
+	}
+
+	coordinate := fc.Object + "." + fc.Field.Name
+	key, ok := c.fieldKey(ctx, fc, coordinate, d)
+	if !ok {
+		return next(ctx)
+	}
+	ctx, _ = tag.New(ctx, tag.Upsert(metrics.TagField, coordinate))
+
+	if res, hit := c.lookup(ctx, key, coordinate); hit {
+		stats.Record(ctx, FieldCacheHits.M(1))
+		recordDecision(ctx, fc, true)
+		return res, nil
+	}
+	stats.Record(ctx, FieldCacheMisses.M(1))
+	recordDecision(ctx, fc, false)
+
+	res, err := next(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	b, err := json.Marshal(res)
+	if err == nil {
+		if res != nil {
+			c.types.Store(coordinate, reflect.TypeOf(res))
+		}
+		err = c.store.Set(ctx, key, b, d.ttl)
+	}
+	if err != nil {
+		stats.Record(ctx, CacheErrors.M(1))
+		c.errorHandler(err)
+	}
+	return res, nil
+}
+
+func (c *FieldCache) lookup(ctx context.Context, key, coordinate string) (interface{}, bool) {
+	b, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		stats.Record(ctx, CacheErrors.M(1))
+		c.errorHandler(err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	if string(b) == "null" {
+		return nil, true
+	}
+	typ, known := c.types.Load(coordinate)
+	if !known {
+		// results can't be decoded until the resolver returned a value once
+		return nil, false
+	}
+	v := reflect.New(typ.(reflect.Type))
+	if err = json.Unmarshal(b, v.Interface()); err != nil {
+		stats.Record(ctx, CacheErrors.M(1))
+		c.errorHandler(fmt.Errorf("gqlcache: could not decode %s: %w", coordinate, err))
+		return nil, false
+	}
+	return v.Elem().Interface(), true
+}
+
+func (c *FieldCache) fieldKey(ctx context.Context, fc *graphql.FieldContext, coordinate string, d *fieldDirective) (string, bool) {
+	var buf bytes.Buffer
+	buf.WriteString(coordinate)
+	buf.WriteByte(0)
+
+	if !d.byArgs {
+		id, ok := c.parentID(parentObject(fc))
+		if !ok {
+			return "", false
+		}
+		buf.WriteString(id)
+		buf.WriteByte(0)
+	}
+
+	args, err := json.Marshal(fc.Args) // map keys are sorted
+	if err != nil {
+		return "", false
+	}
+	buf.Write(args)
+	for _, vary := range c.varyBy {
+		buf.WriteByte(0)
+		buf.WriteString(vary(ctx))
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	return c.prefix + "field:" + hex.EncodeToString(sum[:]), true
+}
+
+func (c *FieldCache) directive(def *ast.FieldDefinition) *fieldDirective {
+	if d, ok := c.directives.Load(def); ok {
+		return d.(*fieldDirective)
+	}
+
+	var d *fieldDirective
+	if dir := def.Directives.ForName(c.directiveName); dir != nil {
+		if arg := dir.Arguments.ForName("ttl"); arg != nil && arg.Value != nil {
+			ttl, err := time.ParseDuration(arg.Value.Raw)
+			if err != nil {
+				c.errorHandler(fmt.Errorf("gqlcache: invalid @%s ttl on %s: %w", c.directiveName, def.Name, err))
+			} else {
+				d = &fieldDirective{ttl: ttl}
+				if arg := dir.Arguments.ForName("key"); arg != nil && arg.Value != nil {
+					d.byArgs = arg.Value.Raw == keyArgs
+				}
+			}
+		}
+	}
+	c.directives.Store(def, d)
+	return d
+}
+
+// parentObject is the object holding the field, i.e. the result of the closest parent field that is not a list
+func parentObject(fc *graphql.FieldContext) interface{} {
+	for p := fc.Parent; p != nil; p = p.Parent {
+		if p.Result != nil {
+			return p.Result
+		}
+	}
+	return nil
+}
+
+// DefaultParentID identifies parent objects by an "ID" or "Id" struct field or method, or an "id" map key
+func DefaultParentID(obj interface{}) (string, bool) {
+	if obj == nil {
+		// root fields have no parent
+		return "", true
+	}
+	if m, ok := obj.(map[string]interface{}); ok {
+		id, ok := m["id"]
+		return fmt.Sprint(id), ok
+	}
+
+	v := reflect.ValueOf(obj)
+	for _, name := range []string{"ID", "Id"} {
+		if m := v.MethodByName(name); m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+			return stringify(m.Call(nil)[0])
+		}
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+	for _, name := range []string{"ID", "Id"} {
+		if f := v.FieldByName(name); f.IsValid() {
+			return stringify(f)
+		}
+	}
+	return "", false
+}
+
+func stringify(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "", false
+		}
+		return stringify(v.Elem())
+	default:
+		if !v.CanInterface() {
+			return "", false
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String(), true
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,105 @@
+package gqlcache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const fieldSchema = FieldDirective + `
+type Query {
+	rate(currency: String!): Float @cache(ttl: "1m", key: ARGS)
+	product(id: ID!): Product
+}
+
+type Product {
+	id: ID!
+	reviews: Int @cache(ttl: "1m")
+	orphan: Int @cache(ttl: "oops")
+}
+`
+
+type review struct {
+	Count int
+}
+
+func TestFieldCache(t *testing.T) {
+	var rates, reviews int32
+	es := testschema.New(fieldSchema, testschema.Resolvers{
+		"Query.rate": func(ctx context.Context) (interface{}, error) {
+			atomic.AddInt32(&rates, 1)
+			return 1.5, nil
+		},
+		"Query.product": func(ctx context.Context) (interface{}, error) {
+			return map[string]interface{}{"id": graphql.GetFieldContext(ctx).Args["id"]}, nil
+		},
+		"Product.reviews": func(ctx context.Context) (interface{}, error) {
+			return &review{Count: int(atomic.AddInt32(&reviews, 1))}, nil
+		},
+	})
+
+	var errs int32
+	srv := handler.New(es)
+	srv.AddTransport(transport.POST{})
+	srv.Use(NewFieldCache(NewMemoryStore(100), ErrorHandler(func(error) {
+		atomic.AddInt32(&errs, 1)
+	})))
+
+	do := func(query string) string {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	do(`{ rate(currency: \"EUR\") }`)
+	assert.Equal(t, `{"data":{"rate":1.5}}`, do(`{ rate(currency: \"EUR\") }`))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&rates))
+	do(`{ rate(currency: \"USD\") }`)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&rates))
+
+	assert.Equal(t, `{"data":{"product":{"reviews":{"Count":1}}}}`, do(`{ product(id: 1) { reviews } }`))
+	assert.Equal(t, `{"data":{"product":{"reviews":{"Count":1}}}}`, do(`{ product(id: 1) { reviews } }`))
+	assert.Equal(t, `{"data":{"product":{"reviews":{"Count":2}}}}`, do(`{ product(id: 2) { reviews } }`))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&reviews))
+
+	do(`{ product(id: 1) { orphan } }`)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&errs), "invalid directives are reported once")
+	do(`{ product(id: 1) { orphan } }`)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&errs))
+}
+
+func TestDefaultParentID(t *testing.T) {
+	type withField struct{ ID int }
+	type withPointer struct{ Id *string }
+
+	s := "abc"
+	for _, tc := range []struct {
+		obj interface{}
+		id  string
+		ok  bool
+	}{
+		{nil, "", true},
+		{withField{ID: 3}, "3", true},
+		{&withField{ID: 4}, "4", true},
+		{&withPointer{Id: &s}, "abc", true},
+		{&withPointer{}, "", false},
+		{map[string]interface{}{"id": "x"}, "x", true},
+		{struct{ Name string }{}, "", false},
+	} {
+		id, ok := DefaultParentID(tc.obj)
+		assert.Equal(t, tc.ok, ok, "%#v", tc.obj)
+		assert.Equal(t, tc.id, id, "%#v", tc.obj)
+	}
+}
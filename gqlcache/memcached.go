@@ -0,0 +1,378 @@
+package gqlcache
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// flagGzip marks values compressed with gzip, in the memcached item flags
+	flagGzip = 1
+
+	// maxRelativeExpiration is the largest expiration memcached interprets as relative to now (30 days)
+	maxRelativeExpiration = 30 * 24 * time.Hour
+)
+
+var (
+	errCacheMiss = errors.New("gqlcache: memcached miss")
+	errNotStored = errors.New("gqlcache: memcached item not stored")
+)
+
+type (
+	// MemcachedStore is a Store backed by memcached servers. Keys are distributed across servers
+	// with consistent hashing, so that adding or removing a server only moves a fraction of the keys.
+	MemcachedStore struct {
+		opts    MemcachedOptions
+		ring    []ringPoint
+		servers map[string]*memcachedServer
+	}
+
+	// MemcachedOptions configure the connections to memcached
+	MemcachedOptions struct {
+		// PoolSize is the maximum number of idle connections per server. The default is 10.
+		PoolSize int
+		// Timeout of a connection or command when the request context has no deadline. The default is 1s.
+		Timeout time.Duration
+		// CompressAbove compresses values larger than this many bytes with gzip. The default is 0: values are not compressed.
+		CompressAbove int
+		// VirtualNodes is the number of points of each server on the hash ring. The default is 160.
+		VirtualNodes int
+	}
+
+	ringPoint struct {
+		hash   uint32
+		server string
+	}
+
+	memcachedServer struct {
+		addr string
+		mu   sync.Mutex
+		idle []*memcachedConn
+	}
+
+	memcachedConn struct {
+		net.Conn
+		rw *bufio.ReadWriter
+	}
+)
+
+var _ Store = &MemcachedStore{}
+
+// NewMemcachedStore distributing keys across servers, e.g. "10.0.0.1:11211". Connections are established lazily.
+func NewMemcachedStore(servers []string, opts MemcachedOptions) *MemcachedStore {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	if opts.VirtualNodes <= 0 {
+		opts.VirtualNodes = 160
+	}
+
+	s := &MemcachedStore{
+		opts:    opts,
+		servers: make(map[string]*memcachedServer, len(servers)),
+	}
+	for _, addr := range servers {
+		s.servers[addr] = &memcachedServer{addr: addr}
+		for i := 0; i < opts.VirtualNodes; i++ {
+			s.ring = append(s.ring, ringPoint{
+				hash:   crc32.ChecksumIEEE([]byte(addr + "-" + strconv.Itoa(i))),
+				server: addr,
+			})
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool { return s.ring[i].hash < s.ring[j].hash })
+	return s
+}
+
+// Get implements Store
+func (s *MemcachedStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	key = memcachedKey(key)
+	var value []byte
+	err := s.do(ctx, key, func(rw *bufio.ReadWriter) error {
+		_, _ = rw.WriteString("get " + key + "\r\n")
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+
+		line, err := readMemcachedLine(rw.Reader)
+		if err != nil {
+			return err
+		}
+		if line == "END" {
+			return errCacheMiss
+		}
+
+		// VALUE <key> <flags> <bytes>
+		parts := strings.Fields(line)
+		if len(parts) != 4 || parts[0] != "VALUE" {
+			return fmt.Errorf("gqlcache: unexpected memcached reply %q", line)
+		}
+		flags, _ := strconv.Atoi(parts[2])
+		n, err := strconv.Atoi(parts[3])
+		if err != nil {
+			return fmt.Errorf("gqlcache: unexpected memcached reply %q", line)
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(rw, buf); err != nil {
+			return err
+		}
+		if line, err = readMemcachedLine(rw.Reader); err != nil {
+			return err
+		}
+		if line != "END" {
+			return fmt.Errorf("gqlcache: unexpected memcached reply %q", line)
+		}
+
+		value = buf[:n]
+		if flags&flagGzip != 0 {
+			return decompress(&value)
+		}
+		return nil
+	})
+	if err == errCacheMiss {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set implements Store
+func (s *MemcachedStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	key = memcachedKey(key)
+
+	flags := 0
+	if s.opts.CompressAbove > 0 && len(value) > s.opts.CompressAbove {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, _ = gz.Write(value)
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("gqlcache: could not compress value: %w", err)
+		}
+		value, flags = buf.Bytes(), flagGzip
+	}
+
+	return s.do(ctx, key, func(rw *bufio.ReadWriter) error {
+		return storeCommand(rw, "set", key, flags, ttl, value, "STORED")
+	})
+}
+
+// Incr increments a counter by delta, creating it with the given ttl when it doesn't exist.
+// It returns the new value of the counter.
+func (s *MemcachedStore) Incr(ctx context.Context, key string, delta uint64, ttl time.Duration) (uint64, error) {
+	key = memcachedKey(key)
+	var value uint64
+	err := s.do(ctx, key, func(rw *bufio.ReadWriter) error {
+		for attempt := 0; attempt < 2; attempt++ {
+			_, _ = rw.WriteString("incr " + key + " " + strconv.FormatUint(delta, 10) + "\r\n")
+			if err := rw.Flush(); err != nil {
+				return err
+			}
+			line, err := readMemcachedLine(rw.Reader)
+			if err != nil {
+				return err
+			}
+			if line != "NOT_FOUND" {
+				value, err = strconv.ParseUint(line, 10, 64)
+				if err != nil {
+					return fmt.Errorf("gqlcache: unexpected memcached reply %q", line)
+				}
+				return nil
+			}
+
+			// create the counter, unless another client just did
+			initial := []byte(strconv.FormatUint(delta, 10))
+			err = storeCommand(rw, "add", key, 0, ttl, initial, "STORED")
+			if err == nil {
+				value = delta
+				return nil
+			}
+			if err != errNotStored {
+				return err
+			}
+		}
+		return fmt.Errorf("gqlcache: could not increment %s", key)
+	})
+	return value, err
+}
+
+func storeCommand(rw *bufio.ReadWriter, cmd, key string, flags int, ttl time.Duration, value []byte, expected string) error {
+	_, _ = rw.WriteString(cmd + " " + key + " " + strconv.Itoa(flags) + " " + strconv.FormatInt(expiration(ttl), 10) + " " + strconv.Itoa(len(value)) + "\r\n")
+	_, _ = rw.Write(value)
+	_, _ = rw.WriteString("\r\n")
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+
+	line, err := readMemcachedLine(rw.Reader)
+	if err != nil {
+		return err
+	}
+	switch line {
+	case expected:
+		return nil
+	case "NOT_STORED":
+		return errNotStored
+	default:
+		return fmt.Errorf("gqlcache: unexpected memcached reply %q", line)
+	}
+}
+
+// Close the idle connections
+func (s *MemcachedStore) Close() error {
+	for _, srv := range s.servers {
+		srv.mu.Lock()
+		for _, cn := range srv.idle {
+			_ = cn.Close()
+		}
+		srv.idle = nil
+		srv.mu.Unlock()
+	}
+	return nil
+}
+
+// server owning the key on the hash ring
+func (s *MemcachedStore) server(key string) *memcachedServer {
+	if len(s.ring) == 0 {
+		return nil
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.servers[s.ring[i].server]
+}
+
+func (s *MemcachedStore) do(ctx context.Context, key string, fn func(rw *bufio.ReadWriter) error) error {
+	srv := s.server(key)
+	if srv == nil {
+		return errors.New("gqlcache: no memcached server")
+	}
+
+	cn, err := srv.get(ctx, s.opts.Timeout)
+	if err != nil {
+		return fmt.Errorf("gqlcache: could not connect to memcached: %w", err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(s.opts.Timeout)
+	}
+	if err = cn.SetDeadline(deadline); err != nil {
+		_ = cn.Close()
+		return err
+	}
+
+	err = fn(cn.rw)
+	if err != nil && err != errCacheMiss && err != errNotStored {
+		// the connection is in an unknown state
+		_ = cn.Close()
+		return fmt.Errorf("gqlcache: memcached: %w", err)
+	}
+	srv.put(cn, s.opts.PoolSize)
+	return err
+}
+
+func (srv *memcachedServer) get(ctx context.Context, timeout time.Duration) (*memcachedConn, error) {
+	srv.mu.Lock()
+	if n := len(srv.idle); n > 0 {
+		cn := srv.idle[n-1]
+		srv.idle = srv.idle[:n-1]
+		srv.mu.Unlock()
+		return cn, nil
+	}
+	srv.mu.Unlock()
+
+	d := net.Dialer{Timeout: timeout}
+	nc, err := d.DialContext(ctx, "tcp", srv.addr)
+	if err != nil {
+		return nil, err
+	}
+	return &memcachedConn{Conn: nc, rw: bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc))}, nil
+}
+
+func (srv *memcachedServer) put(cn *memcachedConn, poolSize int) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.idle) >= poolSize {
+		_ = cn.Close()
+		return
+	}
+	srv.idle = append(srv.idle, cn)
+}
+
+// memcachedKey hashes keys that are too long, or contain characters not allowed by the memcached text protocol
+func memcachedKey(key string) string {
+	valid := len(key) <= 250
+	for i := 0; valid && i < len(key); i++ {
+		valid = key[i] > ' ' && key[i] != 0x7f
+	}
+	if valid {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// expiration in the memcached format: seconds relative to now up to 30 days, an absolute unix time beyond
+func expiration(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > maxRelativeExpiration {
+		return time.Now().Add(ttl).Unix()
+	}
+	seconds := int64(ttl / time.Second)
+	if ttl%time.Second != 0 {
+		seconds++
+	}
+	return seconds
+}
+
+func readMemcachedLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+	if strings.HasPrefix(line, "SERVER_ERROR") || strings.HasPrefix(line, "CLIENT_ERROR") || line == "ERROR" {
+		return "", errors.New(line)
+	}
+	return line, nil
+}
+
+func decompress(value *[]byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(*value))
+	if err != nil {
+		return fmt.Errorf("gqlcache: could not decompress value: %w", err)
+	}
+	b, err := ioutil.ReadAll(gz)
+	if err != nil {
+		return fmt.Errorf("gqlcache: could not decompress value: %w", err)
+	}
+	*value = b
+	return nil
+}
@@ -0,0 +1,160 @@
+package gqlcache
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMemcached implements get, set, add and incr of the memcached text protocol
+type fakeMemcached struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	items map[string]fakeItem
+}
+
+type fakeItem struct {
+	flags string
+	value []byte
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	m := &fakeMemcached{ln: ln, items: map[string]fakeItem{}}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go m.serve(c)
+		}
+	}()
+	return m
+}
+
+func (m *fakeMemcached) serve(c net.Conn) {
+	defer c.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(c), bufio.NewWriter(c))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		m.mu.Lock()
+		switch args[0] {
+		case "get":
+			if item, ok := m.items[args[1]]; ok {
+				_, _ = rw.WriteString("VALUE " + args[1] + " " + item.flags + " " + strconv.Itoa(len(item.value)) + "\r\n")
+				_, _ = rw.Write(item.value)
+				_, _ = rw.WriteString("\r\n")
+			}
+			_, _ = rw.WriteString("END\r\n")
+		case "set", "add":
+			n, _ := strconv.Atoi(args[4])
+			value := make([]byte, n+2)
+			_, _ = io.ReadFull(rw, value)
+			if _, exists := m.items[args[1]]; exists && args[0] == "add" {
+				_, _ = rw.WriteString("NOT_STORED\r\n")
+				break
+			}
+			m.items[args[1]] = fakeItem{flags: args[2], value: value[:n]}
+			_, _ = rw.WriteString("STORED\r\n")
+		case "incr":
+			item, ok := m.items[args[1]]
+			if !ok {
+				_, _ = rw.WriteString("NOT_FOUND\r\n")
+				break
+			}
+			n, _ := strconv.ParseUint(string(item.value), 10, 64)
+			delta, _ := strconv.ParseUint(args[2], 10, 64)
+			item.value = []byte(strconv.FormatUint(n+delta, 10))
+			m.items[args[1]] = item
+			_, _ = rw.WriteString(string(item.value) + "\r\n")
+		default:
+			_, _ = rw.WriteString("ERROR\r\n")
+		}
+		m.mu.Unlock()
+		if rw.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (m *fakeMemcached) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+func TestMemcachedStore(t *testing.T) {
+	a, b := newFakeMemcached(t), newFakeMemcached(t)
+	defer a.ln.Close()
+	defer b.ln.Close()
+
+	ctx := context.Background()
+	s := NewMemcachedStore([]string{a.ln.Addr().String(), b.ln.Addr().String()}, MemcachedOptions{CompressAbove: 100})
+	defer s.Close()
+
+	_, ok, err := s.Get(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, s.Set(ctx, "key"+strconv.Itoa(i), []byte("value"), time.Minute))
+	}
+	assert.Equal(t, 50, a.len()+b.len())
+	assert.NotZero(t, a.len(), "keys are distributed across servers")
+	assert.NotZero(t, b.len(), "keys are distributed across servers")
+
+	v, ok, err := s.Get(ctx, "key7")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), v)
+
+	large := []byte(strings.Repeat("compressible ", 100))
+	require.NoError(t, s.Set(ctx, "large key with spaces", large, time.Minute))
+	v, ok, err = s.Get(ctx, "large key with spaces")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, large, v)
+
+	n, err := s.Incr(ctx, "counter", 2, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), n)
+	n, err = s.Incr(ctx, "counter", 3, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), n)
+}
+
+func TestConsistentHashing(t *testing.T) {
+	before := NewMemcachedStore([]string{"a:11211", "b:11211", "c:11211"}, MemcachedOptions{})
+	after := NewMemcachedStore([]string{"a:11211", "b:11211", "c:11211", "d:11211"}, MemcachedOptions{})
+
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := "key" + strconv.Itoa(i)
+		if before.server(key).addr != after.server(key).addr {
+			moved++
+		}
+	}
+	// about a quarter of the keys move to the new server
+	assert.InDelta(t, 250, moved, 100)
+}
+
+func TestExpiration(t *testing.T) {
+	assert.Equal(t, int64(1), expiration(10*time.Millisecond))
+	assert.Equal(t, int64(60), expiration(time.Minute))
+	assert.InDelta(t, time.Now().Add(60*24*time.Hour).Unix(), expiration(60*24*time.Hour), 2)
+}
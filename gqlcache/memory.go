@@ -0,0 +1,203 @@
+package gqlcache
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const (
+	// entryOverhead approximates the memory used by an entry besides its key and value
+	entryOverhead = 96
+
+	// stores holding at least this many entries are sharded to reduce lock contention
+	shardingThreshold = 1024
+	shardCount        = 16
+)
+
+type (
+	// MemoryStore is an in-process Store, evicting the least recently used entries beyond its capacity.
+	//
+	// The capacity is bounded by a number of entries, a memory budget, or both.
+	// Large stores are sharded, and the capacity is evenly split across shards.
+	MemoryStore struct {
+		bytes  int64 // accessed atomically, kept first for alignment
+		shards []*memoryShard
+		tagCtx context.Context
+	}
+
+	// MemoryOption configures a MemoryStore
+	MemoryOption func(*memoryConfig)
+
+	memoryConfig struct {
+		maxBytes int64
+		name     string
+	}
+
+	memoryShard struct {
+		mu         sync.Mutex
+		maxEntries int
+		maxBytes   int64
+		bytes      int64
+		ll         *list.List
+		entries    map[string]*list.Element
+	}
+
+	memoryEntry struct {
+		key     string
+		value   []byte
+		expires time.Time
+	}
+)
+
+var _ Store = &MemoryStore{}
+
+// MaxBytes bounds the approximate memory used by the keys and values of a MemoryStore
+func MaxBytes(n int64) MemoryOption {
+	return func(c *memoryConfig) {
+		c.maxBytes = n
+	}
+}
+
+// StoreName tags the metrics of a MemoryStore, to tell apart several stores. The default is "memory".
+func StoreName(name string) MemoryOption {
+	return func(c *memoryConfig) {
+		c.name = name
+	}
+}
+
+// NewMemoryStore holding at most maxEntries entries. A zero maxEntries leaves the number of entries unbounded,
+// which is only sensible along with MaxBytes.
+func NewMemoryStore(maxEntries int, opts ...MemoryOption) *MemoryStore {
+	cfg := memoryConfig{name: "memory"}
+	for _, apply := range opts {
+		apply(&cfg)
+	}
+
+	n := 1
+	if maxEntries == 0 || maxEntries >= shardingThreshold {
+		n = shardCount
+	}
+
+	s := &MemoryStore{shards: make([]*memoryShard, n)}
+	s.tagCtx, _ = tag.New(context.Background(), tag.Upsert(TagStore, cfg.name))
+	for i := range s.shards {
+		s.shards[i] = &memoryShard{
+			maxEntries: maxEntries / n,
+			maxBytes:   cfg.maxBytes / int64(n),
+			ll:         list.New(),
+			entries:    map[string]*list.Element{},
+		}
+	}
+	return s
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	el, ok := sh.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if !time.Now().Before(entry.expires) {
+		atomic.AddInt64(&s.bytes, -sh.remove(el))
+		stats.Record(s.tagCtx, MemoryEvictions.M(1))
+		return nil, false, nil
+	}
+	sh.ll.MoveToFront(el)
+	return entry.value, true, nil
+}
+
+// Set implements Store
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	size := entrySize(key, value)
+	sh := s.shard(key)
+	if sh.maxBytes > 0 && size > sh.maxBytes {
+		// would evict the whole shard, and still not fit: the previous value of the key must not be served anymore
+		sh.mu.Lock()
+		removed := int64(0)
+		if el, ok := sh.entries[key]; ok {
+			removed = sh.remove(el)
+		}
+		sh.mu.Unlock()
+		if removed > 0 {
+			stats.Record(s.tagCtx, MemoryBytes.M(atomic.AddInt64(&s.bytes, -removed)))
+		}
+		return nil
+	}
+
+	sh.mu.Lock()
+	delta := size
+	expires := time.Now().Add(ttl)
+	if el, ok := sh.entries[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		delta -= entrySize(key, entry.value)
+		entry.value, entry.expires = value, expires
+		sh.ll.MoveToFront(el)
+	} else {
+		sh.entries[key] = sh.ll.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+	}
+	sh.bytes += delta
+
+	evicted := int64(0)
+	for sh.ll.Len() > 1 && ((sh.maxEntries > 0 && sh.ll.Len() > sh.maxEntries) || (sh.maxBytes > 0 && sh.bytes > sh.maxBytes)) {
+		delta -= sh.remove(sh.ll.Back())
+		evicted++
+	}
+	sh.mu.Unlock()
+
+	if evicted > 0 {
+		stats.Record(s.tagCtx, MemoryEvictions.M(evicted))
+	}
+	stats.Record(s.tagCtx, MemoryBytes.M(atomic.AddInt64(&s.bytes, delta)))
+	return nil
+}
+
+// Len is the number of entries, including expired entries not yet evicted
+func (s *MemoryStore) Len() int {
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		n += sh.ll.Len()
+		sh.mu.Unlock()
+	}
+	return n
+}
+
+// Bytes is the approximate memory used by entries
+func (s *MemoryStore) Bytes() int64 {
+	return atomic.LoadInt64(&s.bytes)
+}
+
+func (s *MemoryStore) shard(key string) *memoryShard {
+	if len(s.shards) == 1 {
+		return s.shards[0]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// remove an entry, returning its size
+func (sh *memoryShard) remove(el *list.Element) int64 {
+	entry := el.Value.(*memoryEntry)
+	sh.ll.Remove(el)
+	delete(sh.entries, entry.key)
+	size := entrySize(entry.key, entry.value)
+	sh.bytes -= size
+	return size
+}
+
+func entrySize(key string, value []byte) int64 {
+	return int64(len(key) + len(value) + entryOverhead)
+}
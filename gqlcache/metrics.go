@@ -0,0 +1,146 @@
+package gqlcache
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of the response cache.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(CacheViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the response cache
+func UnregisterViews() {
+	view.Unregister(CacheViews...)
+}
+
+var (
+	// CacheViews contains all opencensus stats views declared by the response cache
+	CacheViews = []*view.View{
+		CacheHitCountView,
+		CacheMissCountView,
+		CacheErrorCountView,
+		MemoryEvictionCountView,
+		MemoryBytesView,
+		FieldCacheHitCountView,
+		FieldCacheMissCountView,
+	}
+
+	// measurements
+
+	// CacheHits tracks a count of operations served from the cache
+	CacheHits = stats.Int64(
+		"gql/cache/hit_count",
+		"Number of GraphQL operations served from the response cache",
+		stats.UnitDimensionless)
+
+	// CacheMisses tracks a count of cacheable operations not found in the cache
+	CacheMisses = stats.Int64(
+		"gql/cache/miss_count",
+		"Number of GraphQL operations missing from the response cache",
+		stats.UnitDimensionless)
+
+	// CacheErrors tracks a count of failed reads and writes to the cache store
+	CacheErrors = stats.Int64(
+		"gql/cache/error_count",
+		"Number of response cache store errors",
+		stats.UnitDimensionless)
+
+	// MemoryEvictions tracks a count of entries evicted from memory stores, because of their capacity or expiration
+	MemoryEvictions = stats.Int64(
+		"gql/cache/memory_evictions",
+		"Number of entries evicted from in-memory cache stores",
+		stats.UnitDimensionless)
+
+	// MemoryBytes tracks the approximate memory used by in-memory cache stores
+	MemoryBytes = stats.Int64(
+		"gql/cache/memory_bytes",
+		"Approximate memory used by in-memory cache stores",
+		stats.UnitBytes)
+
+	// FieldCacheHits tracks a count of field results served from the cache
+	FieldCacheHits = stats.Int64(
+		"gql/cache/field_hit_count",
+		"Number of GraphQL field results served from the field cache",
+		stats.UnitDimensionless)
+
+	// FieldCacheMisses tracks a count of cached field results not found in the cache
+	FieldCacheMisses = stats.Int64(
+		"gql/cache/field_miss_count",
+		"Number of GraphQL field results missing from the field cache",
+		stats.UnitDimensionless)
+
+	// views
+
+	// CacheHitCountView reports a count of cache hits by operation
+	CacheHitCountView = &view.View{
+		Name:        "gql/cache/hit_count",
+		Description: "Count of GraphQL operations served from the response cache by operation",
+		Measure:     CacheHits,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// CacheMissCountView reports a count of cache misses by operation
+	CacheMissCountView = &view.View{
+		Name:        "gql/cache/miss_count",
+		Description: "Count of GraphQL operations missing from the response cache by operation",
+		Measure:     CacheMisses,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// CacheErrorCountView reports a count of cache store errors by operation
+	CacheErrorCountView = &view.View{
+		Name:        "gql/cache/error_count",
+		Description: "Count of response cache store errors by operation",
+		Measure:     CacheErrors,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// MemoryEvictionCountView reports a count of evictions by in-memory store
+	MemoryEvictionCountView = &view.View{
+		Name:        "gql/cache/memory_evictions",
+		Description: "Count of entries evicted from in-memory cache stores by store",
+		Measure:     MemoryEvictions,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagStore},
+	}
+
+	// MemoryBytesView reports the approximate memory used by in-memory store
+	MemoryBytesView = &view.View{
+		Name:        "gql/cache/memory_bytes",
+		Description: "Approximate memory used by in-memory cache stores by store",
+		Measure:     MemoryBytes,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagStore},
+	}
+
+	// FieldCacheHitCountView reports a count of field cache hits by field
+	FieldCacheHitCountView = &view.View{
+		Name:        "gql/cache/field_hit_count",
+		Description: "Count of GraphQL field results served from the field cache by field",
+		Measure:     FieldCacheHits,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagField},
+	}
+
+	// FieldCacheMissCountView reports a count of field cache misses by field
+	FieldCacheMissCountView = &view.View{
+		Name:        "gql/cache/field_miss_count",
+		Description: "Count of GraphQL field results missing from the field cache by field",
+		Measure:     FieldCacheMisses,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagField},
+	}
+
+	// TagStore is the name of an in-memory cache store
+	TagStore = tag.MustNewKey("gql.cache.store")
+)
@@ -0,0 +1,92 @@
+package gqlcache
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Option for the response cache
+type Option func(*config)
+
+type config struct {
+	defaultTTL    time.Duration
+	maxTTL        time.Duration
+	prefix        string
+	session       func(context.Context) string
+	varyBy        []func(context.Context) string
+	errorHandler  func(error)
+	directiveName string
+	parentID      func(obj interface{}) (string, bool)
+}
+
+func defaultConfig() config {
+	return config{
+		prefix:        "gqlcache:",
+		directiveName: "cache",
+		parentID:      DefaultParentID,
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+	}
+}
+
+// TTL of responses when no cache policy is computed by the gqlcachecontrol extension.
+// The default is 0: responses are only cached according to @cacheControl hints.
+func TTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.defaultTTL = ttl
+	}
+}
+
+// MaxTTL caps the TTL of responses
+func MaxTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.maxTTL = ttl
+	}
+}
+
+// KeyPrefix of the keys in the store. The default is "gqlcache:".
+func KeyPrefix(prefix string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+	}
+}
+
+// Session identifies the user, so that responses with a PRIVATE scope may be cached per user.
+// Without a session, private responses are not cached.
+func Session(fn func(ctx context.Context) string) Option {
+	return func(c *config) {
+		c.session = fn
+	}
+}
+
+// VaryBy adds a value from the context to the cache key, e.g. an authorization scope or a locale
+func VaryBy(fn func(ctx context.Context) string) Option {
+	return func(c *config) {
+		c.varyBy = append(c.varyBy, fn)
+	}
+}
+
+// ErrorHandler is called with store errors. Store errors don't fail operations.
+// By default, errors are logged.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// DirectiveName of the field cache directive. The default is "cache".
+func DirectiveName(name string) Option {
+	return func(c *config) {
+		c.directiveName = name
+	}
+}
+
+// ParentID identifies the parent object of cached fields. Fields of parents without an id are not cached.
+// The default is DefaultParentID.
+func ParentID(fn func(obj interface{}) (string, bool)) Option {
+	return func(c *config) {
+		c.parentID = fn
+	}
+}
@@ -0,0 +1,158 @@
+package gqlcache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/internal/redis"
+)
+
+type (
+	// Store of cached responses
+	Store interface {
+		// Get a value. A missing or expired value is not an error.
+		Get(ctx context.Context, key string) ([]byte, bool, error)
+		// Set a value, expiring after ttl
+		Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	}
+
+	// RedisStore is a Store backed by Redis
+	RedisStore struct {
+		client *redis.Client
+	}
+
+	// RedisOptions configure the connection to Redis
+	RedisOptions struct {
+		Password string
+		DB       int
+		// PoolSize is the maximum number of idle connections. The default is 10.
+		PoolSize    int
+		DialTimeout time.Duration
+		// IOTimeout bounds commands when the request context has no deadline. The default is 3s.
+		IOTimeout time.Duration
+	}
+
+	// TieredStore reads from a fast L1 store (typically a MemoryStore) before a shared L2 store (typically Redis)
+	TieredStore struct {
+		l1    Store
+		l2    Store
+		l1TTL time.Duration
+	}
+
+	// apqCache adapts a Store to a graphql.Cache
+	apqCache struct {
+		store Store
+		ttl   time.Duration
+	}
+)
+
+var (
+	_ Store         = &RedisStore{}
+	_ Store         = &TieredStore{}
+	_ graphql.Cache = apqCache{}
+)
+
+// NewRedisStore connects to the Redis server at addr, e.g. "localhost:6379". Connections are established lazily.
+func NewRedisStore(addr string, opts RedisOptions) *RedisStore {
+	return &RedisStore{
+		client: redis.New(addr, redis.Options{
+			Password:    opts.Password,
+			DB:          opts.DB,
+			PoolSize:    opts.PoolSize,
+			DialTimeout: opts.DialTimeout,
+			IOTimeout:   opts.IOTimeout,
+		}),
+	}
+}
+
+// Get implements Store
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := s.client.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, fmt.Errorf("gqlcache: %w", err)
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("gqlcache: unexpected redis reply %T", reply)
+	}
+	return value, true, nil
+}
+
+// Set implements Store
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := int64(ttl / time.Millisecond)
+	if ms <= 0 {
+		return nil
+	}
+	if _, err := s.client.Do(ctx, "SET", key, value, "PX", ms); err != nil {
+		return fmt.Errorf("gqlcache: %w", err)
+	}
+	return nil
+}
+
+// Close the connections to Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+// NewTieredStore reads through l1 to l2, and writes to both.
+//
+// Values are kept in l1 for at most l1TTL, which bounds how stale l1 may be relative to l2.
+func NewTieredStore(l1, l2 Store, l1TTL time.Duration) *TieredStore {
+	return &TieredStore{l1: l1, l2: l2, l1TTL: l1TTL}
+}
+
+// Get implements Store
+func (s *TieredStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, ok, err := s.l1.Get(ctx, key); err == nil && ok {
+		return value, true, nil
+	}
+
+	value, ok, err := s.l2.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	_ = s.l1.Set(ctx, key, value, s.l1TTL)
+	return value, true, nil
+}
+
+// Set implements Store
+func (s *TieredStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l1TTL := ttl
+	if l1TTL > s.l1TTL {
+		l1TTL = s.l1TTL
+	}
+	_ = s.l1.Set(ctx, key, value, l1TTL)
+	return s.l2.Set(ctx, key, value, ttl)
+}
+
+// APQCache adapts a Store to the graphql.Cache used by the automatic persisted query extension:
+//
+//	srv.Use(extension.AutomaticPersistedQuery{Cache: gqlcache.APQCache(gqlcache.NewMemoryStore(0, gqlcache.MaxBytes(64<<20)), 24*time.Hour)})
+//
+// Store errors are treated as cache misses.
+func APQCache(store Store, ttl time.Duration) graphql.Cache {
+	return apqCache{store: store, ttl: ttl}
+}
+
+// Get implements graphql.Cache
+func (c apqCache) Get(ctx context.Context, key string) (interface{}, bool) {
+	value, ok, err := c.store.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false
+	}
+	return string(value), true
+}
+
+// Add implements graphql.Cache
+func (c apqCache) Add(ctx context.Context, key string, value interface{}) {
+	if s, ok := value.(string); ok {
+		_ = c.store.Set(ctx, key, []byte(s), c.ttl)
+	}
+}
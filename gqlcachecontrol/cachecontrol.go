@@ -0,0 +1,178 @@
+// Package gqlcachecontrol computes the cache policy of GraphQL responses from @cacheControl directives,
+// following the Apollo Server semantics.
+//
+// The directive must be declared in the schema (see Directive), and skipped at runtime in gqlgen.yml:
+//
+//	directives:
+//	  cacheControl:
+//	    skip_runtime: true
+//
+// The policy of a response is the lowest max age and the most restrictive scope among its fields.
+// Root fields, and fields returning objects, interfaces or unions, default to a max age of 0 unless they,
+// or their type, carry a hint. Scalar fields inherit the max age of their parent.
+//
+// The policy is available with GetPolicy, in the "cacheControl" response extension, and as a Cache-Control
+// header when the httpheader.Middleware is installed.
+package gqlcachecontrol
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/httpheader"
+)
+
+const (
+	extensionName = "CacheControl"
+	directiveName = "cacheControl"
+
+	// ResponseKey is the key of the cache policy in the response extensions
+	ResponseKey = "cacheControl"
+
+	// Directive is the schema definition of the @cacheControl directive
+	Directive = `enum CacheControlScope {
+  PUBLIC
+  PRIVATE
+}
+
+directive @cacheControl(
+  maxAge: Int
+  scope: CacheControlScope
+  inheritMaxAge: Boolean
+) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION
+`
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &CacheControl{}
+
+type (
+	// CacheControl is a gqlgen extension computing the cache policy of responses
+	CacheControl struct {
+		config
+		schema *ast.Schema
+	}
+
+	// responseExtension is the value of the "cacheControl" response extension
+	responseExtension struct {
+		Version int        `json:"version"`
+		Policy  Policy     `json:"policy"`
+		Hints   []pathHint `json:"hints,omitempty"`
+	}
+)
+
+// New cache control extension
+func New(opts ...Option) *CacheControl {
+	c := &CacheControl{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&c.config)
+	}
+	return c
+}
+
+// ExtensionName yields the extension name: "CacheControl"
+func (*CacheControl) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension, and retain the schema to look up type hints
+func (c *CacheControl) Validate(schema graphql.ExecutableSchema) error {
+	c.schema = schema.Schema()
+	return nil
+}
+
+// MutateOperationContext starts accumulating the cache policy of the operation
+func (c *CacheControl) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	rc.Stats.SetExtension(extensionName, &policy{})
+	return nil
+}
+
+// InterceptResponse exposes the cache policy once the response is complete
+func (c *CacheControl) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	p := getPolicy(ctx)
+	if p == nil {
+		return resp
+	}
+	policy := p.policy()
+
+	if c.header {
+		value := policy.HeaderValue()
+		if len(resp.Errors) > 0 {
+			value = Policy{}.HeaderValue()
+		}
+		httpheader.SetDefault(ctx, "Cache-Control", value)
+	}
+
+	if c.extension {
+		ext := responseExtension{Version: 1, Policy: policy}
+		if c.hints {
+			p.mu.Lock()
+			ext.Hints = p.hints
+			p.mu.Unlock()
+		}
+		if resp.Extensions == nil {
+			resp.Extensions = map[string]interface{}{}
+		}
+		resp.Extensions[ResponseKey] = ext
+	}
+	return resp
+}
+
+// InterceptField restricts the cache policy of the operation with the hint of the field
+func (c *CacheControl) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	p := getPolicy(ctx)
+	fc := graphql.GetFieldContext(ctx)
+	if p == nil || fc == nil || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+
+	def := fc.Field.Definition
+	fh := &fieldHint{}
+	inheritMaxAge := false
+
+	composite := false
+	if c.schema != nil {
+		if target := c.schema.Types[def.Type.Name()]; target != nil {
+			switch target.Kind {
+			case ast.Object, ast.Interface, ast.Union:
+				composite = true
+				typeHint := parseHint(target.Directives)
+				fh.hint.replace(typeHint)
+				inheritMaxAge = typeHint.inheritMaxAge
+			}
+		}
+	}
+
+	fieldHint := parseHint(def.Directives)
+	if fieldHint.inheritMaxAge && !fh.hint.hasMaxAge {
+		inheritMaxAge = true
+		fh.hint.replace(hint{scope: fieldHint.scope})
+	} else {
+		fh.hint.replace(fieldHint)
+	}
+
+	res, err := next(context.WithValue(ctx, fieldHintKey{}, fh))
+
+	path := fc.Path()
+	fh.mu.Lock()
+	h := fh.hint
+	fh.mu.Unlock()
+	if !h.hasMaxAge && ((composite && !inheritMaxAge) || len(path) == 1) {
+		h.restrict(hint{maxAge: c.defaultMaxAge, hasMaxAge: true})
+	}
+	p.restrict(path, h, c.hints)
+
+	return res, err
+}
@@ -0,0 +1,104 @@
+package gqlcachecontrol
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = Directive + `
+type Query {
+	todo: Todo @cacheControl(maxAge: 60)
+	me: User
+	cached: Int @cacheControl(maxAge: 30)
+	dynamic: Int @cacheControl(maxAge: 120)
+	uncached: Int
+}
+
+type Todo {
+	text: String
+	owner: User @cacheControl(inheritMaxAge: true)
+	count: Int @cacheControl(maxAge: 10)
+}
+
+type User @cacheControl(maxAge: 20, scope: PRIVATE) {
+	name: String
+}
+`
+
+func TestCacheControl(t *testing.T) {
+	es := testschema.New(schema, testschema.Resolvers{
+		"Query.dynamic": func(ctx context.Context) (interface{}, error) {
+			SetHint(ctx, Hint{MaxAge: 5, Scope: ScopePrivate})
+			return 1, nil
+		},
+	})
+
+	do := func(query string, opts ...Option) (*httptest.ResponseRecorder, Policy) {
+		var policy Policy
+		srv := handler.New(es)
+		srv.AddTransport(transport.POST{})
+		srv.Use(New(opts...))
+		srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+			resp := next(ctx)
+			policy, _ = GetPolicy(ctx)
+			return resp
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		httpheader.Middleware(srv).ServeHTTP(w, req)
+		return w, policy
+	}
+
+	t.Run("scalar and object hints", func(t *testing.T) {
+		w, policy := do(`{ todo { text count } cached }`)
+		assert.Equal(t, Policy{MaxAge: 10, Scope: ScopePublic}, policy)
+		assert.Equal(t, "max-age=10, public", w.Header().Get("Cache-Control"))
+		assert.Contains(t, w.Body.String(), `"cacheControl":{"version":1,"policy":{"maxAge":10,"scope":"PUBLIC"}}`)
+	})
+
+	t.Run("type hints and inherited max age", func(t *testing.T) {
+		_, policy := do(`{ todo { owner { name } } }`)
+		assert.Equal(t, Policy{MaxAge: 20, Scope: ScopePrivate}, policy)
+
+		w, policy := do(`{ me { name } }`)
+		assert.Equal(t, Policy{MaxAge: 20, Scope: ScopePrivate}, policy)
+		assert.Equal(t, "max-age=20, private", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("root fields default to uncacheable", func(t *testing.T) {
+		w, policy := do(`{ cached uncached }`)
+		assert.False(t, policy.Cacheable())
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+		_, policy = do(`{ cached uncached }`, DefaultMaxAge(15))
+		assert.Equal(t, Policy{MaxAge: 15, Scope: ScopePublic}, policy)
+	})
+
+	t.Run("dynamic hints", func(t *testing.T) {
+		_, policy := do(`{ dynamic cached }`)
+		assert.Equal(t, Policy{MaxAge: 5, Scope: ScopePrivate}, policy)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		w, _ := do(`{ todo { count } }`, WithHints())
+		assert.Contains(t, w.Body.String(), `"hints":[`)
+		assert.Contains(t, w.Body.String(), `{"path":"todo.count","maxAge":10}`)
+
+		w, _ = do(`{ todo { count } }`, WithoutHeader(), WithoutExtension())
+		assert.Empty(t, w.Header().Get("Cache-Control"))
+		assert.NotContains(t, w.Body.String(), "cacheControl")
+	})
+}
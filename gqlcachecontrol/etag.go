@@ -0,0 +1,112 @@
+package gqlcachecontrol
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/99designs/gqlgen-contrib/httpheader"
+)
+
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+// ETagMiddleware computes a strong ETag over cacheable responses, and answers conditional requests
+// with If-None-Match with a 304 Not Modified, saving bandwidth for polling clients.
+//
+// Responses are cacheable when the cache policy computed by the CacheControl extension is:
+// the middleware relies on the Cache-Control header set by the extension, and installs the httpheader.Middleware.
+//
+//	http.Handle("/query", gqlcachecontrol.ETagMiddleware(srv))
+//
+// Websocket, server-sent events and multipart requests are passed through.
+func ETagMiddleware(next http.Handler) http.Handler {
+	next = httpheader.Middleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !conditionable(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(bw, r)
+
+		if bw.status != http.StatusOK || !cacheable(w.Header().Get("Cache-Control")) {
+			bw.flush()
+			return
+		}
+
+		sum := sha256.Sum256(bw.buf.Bytes())
+		etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+		w.Header().Set("ETag", etag)
+
+		if etagMatch(r.Header.Get("If-None-Match"), etag) {
+			for _, h := range []string{"Content-Type", "Content-Length"} {
+				w.Header().Del(h)
+			}
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		bw.flush()
+	})
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// Write implements http.ResponseWriter
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.Header().Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+}
+
+func conditionable(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		return false
+	}
+	if r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	accept := r.Header.Get("Accept")
+	return !strings.Contains(accept, "text/event-stream") && !strings.Contains(accept, "multipart/mixed")
+}
+
+func cacheable(cacheControl string) bool {
+	cacheable := false
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		switch {
+		case directive == "no-store":
+			return false
+		case strings.HasPrefix(directive, "max-age="):
+			maxAge, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			cacheable = err == nil && maxAge > 0
+		}
+	}
+	return cacheable
+}
+
+// etagMatch uses the weak comparison of If-None-Match (RFC 7232 section 3.2)
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package gqlcachecontrol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	srv := handler.New(testschema.New(schema, nil))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(New())
+	h := ETagMiddleware(srv)
+
+	do := func(query, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	res := do(`{ cached }`, "")
+	require.Equal(t, http.StatusOK, res.Code)
+	etag := res.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Contains(t, res.Body.String(), `"cached"`)
+
+	res = do(`{ cached }`, etag)
+	assert.Equal(t, http.StatusNotModified, res.Code)
+	assert.Empty(t, res.Body.String())
+	assert.Equal(t, etag, res.Header().Get("ETag"))
+
+	res = do(`{ cached }`, `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, res.Code)
+
+	res = do(`{ cached }`, `"other"`)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	// uncacheable responses have no ETag
+	res = do(`{ uncached }`, "*")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Empty(t, res.Header().Get("ETag"))
+	assert.Contains(t, res.Body.String(), `"uncached"`)
+}
@@ -0,0 +1,191 @@
+package gqlcachecontrol
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Scope of a cache hint
+type Scope string
+
+const (
+	// ScopePublic responses may be cached by shared caches, e.g. a CDN
+	ScopePublic Scope = "PUBLIC"
+	// ScopePrivate responses may only be cached by the client
+	ScopePrivate Scope = "PRIVATE"
+)
+
+type (
+	// Hint is a cache hint. A negative MaxAge leaves the max age unset, and an empty Scope leaves the scope unset.
+	Hint struct {
+		MaxAge int
+		Scope  Scope
+	}
+
+	// Policy is the cache policy of a response
+	Policy struct {
+		// MaxAge in seconds. The response is not cacheable when MaxAge is 0.
+		MaxAge int   `json:"maxAge"`
+		Scope  Scope `json:"scope"`
+	}
+
+	// hint is a cache hint in the making, as parsed from directives
+	hint struct {
+		maxAge        int
+		hasMaxAge     bool
+		scope         Scope
+		inheritMaxAge bool
+	}
+
+	// fieldHint is the mutable hint of the field being resolved
+	fieldHint struct {
+		mu   sync.Mutex
+		hint hint
+	}
+
+	// policy accumulates the cache policy of an operation
+	policy struct {
+		mu    sync.Mutex
+		hint  hint
+		hints []pathHint
+	}
+
+	pathHint struct {
+		Path   string `json:"path"`
+		MaxAge *int   `json:"maxAge,omitempty"`
+		Scope  Scope  `json:"scope,omitempty"`
+	}
+)
+
+type fieldHintKey struct{}
+
+// SetHint replaces the cache hint of the field being resolved, e.g. from a resolver:
+//
+//	gqlcachecontrol.SetHint(ctx, gqlcachecontrol.Hint{MaxAge: 30, Scope: gqlcachecontrol.ScopePrivate})
+func SetHint(ctx context.Context, h Hint) {
+	fh, ok := ctx.Value(fieldHintKey{}).(*fieldHint)
+	if !ok {
+		return
+	}
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	fh.hint.replace(hint{maxAge: h.MaxAge, hasMaxAge: h.MaxAge >= 0, scope: h.Scope})
+}
+
+// GetPolicy returns the cache policy of the current operation.
+//
+// The policy is final once the response is complete, e.g. in a response interceptor after calling next.
+func GetPolicy(ctx context.Context) (Policy, bool) {
+	p := getPolicy(ctx)
+	if p == nil {
+		return Policy{}, false
+	}
+	return p.policy(), true
+}
+
+// Cacheable tells if the response may be cached
+func (p Policy) Cacheable() bool {
+	return p.MaxAge > 0
+}
+
+// TTL is the max age of the policy as a duration
+func (p Policy) TTL() time.Duration {
+	return time.Duration(p.MaxAge) * time.Second
+}
+
+// HeaderValue is the Cache-Control header value for the policy
+func (p Policy) HeaderValue() string {
+	if !p.Cacheable() {
+		return "no-store"
+	}
+	scope := "public"
+	if p.Scope == ScopePrivate {
+		scope = "private"
+	}
+	return "max-age=" + strconv.Itoa(p.MaxAge) + ", " + scope
+}
+
+func getPolicy(ctx context.Context) *policy {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	p, _ := graphql.GetOperationContext(ctx).Stats.GetExtension(extensionName).(*policy)
+	return p
+}
+
+func (p *policy) restrict(path ast.Path, h hint, record bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hint.restrict(h)
+
+	if record && (h.hasMaxAge || h.scope != "") {
+		ph := pathHint{Path: path.String(), Scope: h.scope}
+		if h.hasMaxAge {
+			maxAge := h.maxAge
+			ph.MaxAge = &maxAge
+		}
+		p.hints = append(p.hints, ph)
+	}
+}
+
+func (p *policy) policy() Policy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	scope := ScopePublic
+	if p.hint.scope == ScopePrivate {
+		scope = ScopePrivate
+	}
+	if !p.hint.hasMaxAge {
+		return Policy{Scope: scope}
+	}
+	return Policy{MaxAge: p.hint.maxAge, Scope: scope}
+}
+
+// replace the values set in other
+func (h *hint) replace(other hint) {
+	if other.hasMaxAge {
+		h.maxAge = other.maxAge
+		h.hasMaxAge = true
+	}
+	if other.scope != "" {
+		h.scope = other.scope
+	}
+}
+
+// restrict to the lowest max age and the most private scope
+func (h *hint) restrict(other hint) {
+	if other.hasMaxAge && (!h.hasMaxAge || other.maxAge < h.maxAge) {
+		h.maxAge = other.maxAge
+		h.hasMaxAge = true
+	}
+	if other.scope == ScopePrivate {
+		h.scope = ScopePrivate
+	}
+}
+
+// parseHint reads the @cacheControl directive, if any
+func parseHint(directives ast.DirectiveList) hint {
+	var h hint
+	d := directives.ForName(directiveName)
+	if d == nil {
+		return h
+	}
+	if arg := d.Arguments.ForName("maxAge"); arg != nil && arg.Value != nil {
+		if maxAge, err := strconv.Atoi(arg.Value.Raw); err == nil {
+			h.maxAge = maxAge
+			h.hasMaxAge = true
+		}
+	}
+	if arg := d.Arguments.ForName("scope"); arg != nil && arg.Value != nil {
+		h.scope = Scope(arg.Value.Raw)
+	}
+	if arg := d.Arguments.ForName("inheritMaxAge"); arg != nil && arg.Value != nil {
+		h.inheritMaxAge = arg.Value.Raw == "true"
+	}
+	return h
+}
@@ -0,0 +1,47 @@
+package gqlcachecontrol
+
+// Option for the cache control extension
+type Option func(*config)
+
+type config struct {
+	defaultMaxAge int
+	header        bool
+	extension     bool
+	hints         bool
+}
+
+func defaultConfig() config {
+	return config{
+		header:    true,
+		extension: true,
+	}
+}
+
+// DefaultMaxAge in seconds, for root fields and fields returning composite types without a hint. The default is 0.
+func DefaultMaxAge(seconds int) Option {
+	return func(c *config) {
+		c.defaultMaxAge = seconds
+	}
+}
+
+// WithoutHeader disables setting the Cache-Control response header
+func WithoutHeader() Option {
+	return func(c *config) {
+		c.header = false
+	}
+}
+
+// WithoutExtension disables the "cacheControl" response extension
+func WithoutExtension() Option {
+	return func(c *config) {
+		c.extension = false
+	}
+}
+
+// WithHints adds the hint of every field to the "cacheControl" response extension, as the legacy
+// Apollo cache control extension did. This is meant for debugging, as it grows with the response.
+func WithHints() Option {
+	return func(c *config) {
+		c.hints = true
+	}
+}
@@ -0,0 +1,157 @@
+// Package gqlcomplexity computes the cost of GraphQL operations from @cost directives declared
+// in the schema, and rejects operations above a limit before they are executed.
+//
+// The directive must be declared in the schema (see Directive), and skipped at runtime in gqlgen.yml:
+//
+//	directives:
+//	  cost:
+//	    skip_runtime: true
+//
+// The cost of a field is its weight, plus the cost of its selections times its multiplier:
+//
+//	cost = weight + multiplier * cost(selections)
+//
+// The weight is taken from the @cost directive on the field, then on its type. Without a directive,
+// fields returning objects, interfaces or unions weigh 1, and other fields weigh 0 (see Weights).
+//
+// The multiplier is the largest value among the multiplierArgs of the field, e.g. the page size of a
+// connection. Arguments holding lists count for their length. List fields without any multiplier
+// argument set use the default list size (see ListSize).
+//
+// The computed cost is available with GetStats, and is reported by the opencensus metrics and tracing extensions.
+package gqlcomplexity
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	extensionName = "CostLimit"
+	directiveName = "cost"
+
+	// ErrLimitCode is the error code when an operation exceeds the cost limit.
+	// It is the same code as the complexity limit extension of gqlgen.
+	ErrLimitCode = "COMPLEXITY_LIMIT_EXCEEDED"
+
+	// Directive is the schema definition of the @cost directive
+	Directive = `directive @cost(
+  weight: Int!
+  multiplierArgs: [String!]
+) on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | SCALAR | ENUM
+`
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrLimitCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Limit{}
+
+type (
+	// Limit is a gqlgen extension computing the cost of operations, and rejecting operations above a limit
+	Limit struct {
+		config
+		schema *ast.Schema
+	}
+
+	// Stats about the cost of an operation
+	Stats struct {
+		// Cost computed for this operation
+		Cost int
+
+		// Limit applied to this operation. 0 means unlimited.
+		Limit int
+	}
+)
+
+// New cost limit extension. Operations with a cost above limit are rejected.
+//
+// A limit of 0 only computes the cost.
+func New(limit int, opts ...Option) *Limit {
+	l := &Limit{config: defaultConfig()}
+	l.limit = func(context.Context, *graphql.OperationContext) int {
+		return limit
+	}
+	for _, apply := range opts {
+		apply(&l.config)
+	}
+	return l
+}
+
+// ExtensionName yields the extension name: "CostLimit"
+func (l *Limit) ExtensionName() string {
+	return extensionName
+}
+
+// Validate retains the schema, to resolve directives on types
+func (l *Limit) Validate(schema graphql.ExecutableSchema) error {
+	l.schema = schema.Schema()
+	return nil
+}
+
+// MutateOperationContext computes the cost of the operation, and rejects it when it exceeds the limit
+func (l *Limit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	w := walker{config: &l.config, schema: l.schema, vars: rc.Variables}
+	cost := w.selectionSet(rc.Operation.SelectionSet)
+	limit := l.limit(ctx, rc)
+
+	rc.Stats.SetExtension(extensionName, &Stats{
+		Cost:  cost,
+		Limit: limit,
+	})
+
+	if limit > 0 && cost > limit {
+		err := gqlerror.Errorf("operation has cost %d, which exceeds the limit of %d", cost, limit)
+		errcode.Set(err, ErrLimitCode)
+		err.Extensions["cost"] = cost
+		err.Extensions["limit"] = limit
+		return err
+	}
+
+	return nil
+}
+
+// GetStats returns the cost computed for the current operation, or nil when the extension is not installed
+func GetStats(ctx context.Context) *Stats {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	return GetOperationStats(graphql.GetOperationContext(ctx))
+}
+
+// GetOperationStats returns the cost computed for an operation, or nil when the extension is not installed
+func GetOperationStats(rc *graphql.OperationContext) *Stats {
+	if rc == nil {
+		return nil
+	}
+	s, _ := rc.Stats.GetExtension(extensionName).(*Stats)
+	return s
+}
+
+// OperationCost yields the cost of an operation and its limit, computed by this extension or, when it is not
+// installed, by the gqlgen complexity limit. ok is false when neither is installed.
+func OperationCost(rc *graphql.OperationContext) (cost, limit int, ok bool) {
+	if s := GetOperationStats(rc); s != nil {
+		return s.Cost, s.Limit, true
+	}
+	if rc == nil {
+		return 0, 0, false
+	}
+	if s, ok := rc.Stats.GetExtension("ComplexityLimit").(*extension.ComplexityStats); ok {
+		return s.Complexity, s.ComplexityLimit, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,138 @@
+package gqlcomplexity
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = Directive + `
+type Query {
+	me: User
+	users(first: Int = 10, ids: [ID!]): [User!]! @cost(weight: 2, multiplierArgs: ["first", "ids"])
+	search(text: String!): [Result!]!
+	expensive: Int @cost(weight: 50)
+}
+
+type User {
+	id: ID!
+	name: String
+	friends(first: Int): [User!]! @cost(weight: 1, multiplierArgs: ["first"])
+	avatar: Image
+}
+
+type Image @cost(weight: 5) {
+	url: String
+}
+
+union Result = User | Image
+`
+
+func TestCost(t *testing.T) {
+	s := gqlparser.MustLoadSchema(&ast.Source{Input: schema})
+
+	for _, tc := range []struct {
+		query string
+		vars  map[string]interface{}
+		opts  []Option
+		cost  int
+	}{
+		{query: `{ __typename me { id name } }`, cost: 1},
+		{query: `{ expensive }`, cost: 50},
+		{query: `{ me { avatar { url } } }`, cost: 6},
+		{query: `{ users { id } }`, cost: 2},
+		{query: `{ users { avatar { url } } }`, cost: 2 + 10*5},
+		{query: `{ users(first: 3) { friends(first: 4) { name } } }`, cost: 2 + 3*1},
+		{query: `{ users(first: 3) { friends(first: 4) { avatar { url } } } }`, cost: 2 + 3*(1+4*5)},
+		{query: `query($n: Int) { users(first: $n) { avatar { url } } }`, vars: map[string]interface{}{"n": 2}, cost: 2 + 2*5},
+		{query: `{ users(first: 1, ids: ["a", "b", "c"]) { avatar { url } } }`, cost: 2 + 3*5},
+		{query: `{ search(text: "x") { ... on User { id } ... on Image { url } } }`, cost: 1},
+		{query: `{ search(text: "x") { ...img } } fragment img on Image { url }`, opts: []Option{Weights(1, 1), ListSize(10)}, cost: 1 + 10*1},
+		{query: `{ users(first: 1000000) { friends(first: 1000000) { avatar { url } } } }`, cost: 2147483647},
+	} {
+		doc, errs := gqlparser.LoadQuery(s, tc.query)
+		require.Empty(t, errs, tc.query)
+
+		cfg := defaultConfig()
+		for _, apply := range tc.opts {
+			apply(&cfg)
+		}
+		w := walker{config: &cfg, schema: s, vars: tc.vars}
+		assert.Equal(t, tc.cost, w.selectionSet(doc.Operations[0].SelectionSet), tc.query)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	var stats *Stats
+	srv := handler.New(testschema.New(schema, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(20))
+	srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		stats = GetStats(ctx)
+		return next(ctx)
+	})
+
+	do := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	res := do(`{ me { avatar { url } } }`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"me":{"avatar":{"url":"url"}}}}`, res.Body.String())
+	require.NotNil(t, stats)
+	assert.Equal(t, Stats{Cost: 6, Limit: 20}, *stats)
+
+	res = do(`{ expensive }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"operation has cost 50, which exceeds the limit of 20","extensions":{"code":"COMPLEXITY_LIMIT_EXCEEDED","cost":50,"limit":20}}],"data":null}`, res.Body.String())
+}
+
+func TestOperationCost(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ext         graphql.HandlerExtension
+		cost, limit int
+		ok          bool
+	}{
+		"cost limit":       {ext: New(20), cost: 6, limit: 20, ok: true},
+		"complexity limit": {ext: extension.FixedComplexityLimit(30), cost: 3, limit: 30, ok: true},
+		"none":             {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var cost, limit int
+			var ok bool
+			srv := handler.New(testschema.New(schema, nil))
+			srv.AddTransport(transport.POST{})
+			if tc.ext != nil {
+				srv.Use(tc.ext)
+			}
+			srv.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+				cost, limit, ok = OperationCost(graphql.GetOperationContext(ctx))
+				return next(ctx)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ me { avatar { url } } }"}`))
+			req.Header.Set("Content-Type", "application/json")
+			srv.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.cost, cost)
+			assert.Equal(t, tc.limit, limit)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package gqlcomplexity
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// walker computes the cost of selection sets. Fragments are expanded in place, so that the cost of
+// an abstract selection is an upper bound over all its possible types.
+type walker struct {
+	*config
+	schema *ast.Schema
+	vars   map[string]interface{}
+}
+
+func (w walker) selectionSet(set ast.SelectionSet) int {
+	cost := 0
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			cost = add(cost, w.field(sel))
+		case *ast.InlineFragment:
+			cost = add(cost, w.selectionSet(sel.SelectionSet))
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				cost = add(cost, w.selectionSet(sel.Definition.SelectionSet))
+			}
+		}
+	}
+	return cost
+}
+
+func (w walker) field(field *ast.Field) int {
+	if field.Definition == nil || field.Definition.Type == nil {
+		// e.g. __typename
+		return 0
+	}
+
+	var typ *ast.Definition
+	if w.schema != nil {
+		typ = w.schema.Types[field.Definition.Type.Name()]
+	}
+
+	weight, hasWeight := w.fieldWeight(field.Definition, typ)
+	if !hasWeight {
+		weight = w.scalarWeight
+		if typ != nil && !typ.IsLeafType() {
+			weight = w.objectWeight
+		}
+	}
+
+	children := w.selectionSet(field.SelectionSet)
+	if children == 0 {
+		return weight
+	}
+	return add(weight, mul(w.multiplier(field), children))
+}
+
+// fieldWeight from the @cost directive on the field definition, then on its type
+func (w walker) fieldWeight(def *ast.FieldDefinition, typ *ast.Definition) (int, bool) {
+	if d := def.Directives.ForName(directiveName); d != nil {
+		if weight, ok := intArgument(d, "weight"); ok {
+			return weight, true
+		}
+	}
+	if typ != nil {
+		if d := typ.Directives.ForName(directiveName); d != nil {
+			if weight, ok := intArgument(d, "weight"); ok {
+				return weight, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (w walker) multiplier(field *ast.Field) int {
+	multiplier, found := 0, false
+	if d := field.Definition.Directives.ForName(directiveName); d != nil {
+		if arg := d.Arguments.ForName("multiplierArgs"); arg != nil && arg.Value != nil {
+			var args map[string]interface{}
+			for _, child := range arg.Value.Children {
+				if args == nil {
+					args = field.ArgumentMap(w.vars)
+				}
+				if n, ok := count(args[child.Value.Raw]); ok {
+					found = true
+					if n > multiplier {
+						multiplier = n
+					}
+				}
+			}
+		}
+	}
+
+	if !found && field.Definition.Type.Elem != nil {
+		return w.listSize
+	}
+	if !found {
+		return 1
+	}
+	return multiplier
+}
+
+func intArgument(d *ast.Directive, name string) (int, bool) {
+	arg := d.Arguments.ForName(name)
+	if arg == nil || arg.Value == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(arg.Value.Raw)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// count is the numeric value of an argument, or the length of a list argument
+func count(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case nil:
+		return 0, false
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return clamp(float64(v)), true
+	case float64:
+		return clamp(v), true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return clamp(f), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		return clamp(f), true
+	}
+
+	if rv := reflect.ValueOf(value); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		return rv.Len(), true
+	}
+	return 0, false
+}
+
+func clamp(f float64) int {
+	switch {
+	case f < 0:
+		return 0
+	case f > math.MaxInt32:
+		return math.MaxInt32
+	default:
+		return int(f)
+	}
+}
+
+// add and mul saturate, so that huge multipliers can't overflow into a negative cost
+func add(a, b int) int {
+	if a > math.MaxInt32-b {
+		return math.MaxInt32
+	}
+	return a + b
+}
+
+func mul(a, b int) int {
+	if a != 0 && b > math.MaxInt32/a {
+		return math.MaxInt32
+	}
+	return a * b
+}
@@ -0,0 +1,48 @@
+package gqlcomplexity
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the cost limit extension
+type Option func(*config)
+
+type config struct {
+	limit        func(context.Context, *graphql.OperationContext) int
+	objectWeight int
+	scalarWeight int
+	listSize     int
+}
+
+func defaultConfig() config {
+	return config{
+		objectWeight: 1,
+		scalarWeight: 0,
+		listSize:     1,
+	}
+}
+
+// LimitFunc determines the limit per operation, e.g. depending on the client. It overrides the limit passed to New.
+func LimitFunc(limit func(context.Context, *graphql.OperationContext) int) Option {
+	return func(c *config) {
+		c.limit = limit
+	}
+}
+
+// Weights of fields without a @cost directive, for fields returning objects, interfaces or unions,
+// and for fields returning scalars or enums. The defaults are 1 and 0.
+func Weights(object, scalar int) Option {
+	return func(c *config) {
+		c.objectWeight = object
+		c.scalarWeight = scalar
+	}
+}
+
+// ListSize is the multiplier of list fields without multiplier arguments. The default is 1.
+func ListSize(size int) Option {
+	return func(c *config) {
+		c.listSize = size
+	}
+}
@@ -0,0 +1,175 @@
+// Package gqlconcurrency bounds the number of concurrent executions of expensive GraphQL operations,
+// so that a single heavy operation can't monopolize the server.
+//
+// Operations are matched by name, or by the hash of their signature (see gqlfilter.SignatureHash).
+// Excess executions wait for a slot up to a timeout, then are rejected with a CONCURRENCY_LIMITED error, hinting to
+// retry after Limit.RetryAfter (see the throttle package):
+//
+//	srv.Use(gqlconcurrency.New(
+//		gqlconcurrency.Operation("salesReport", gqlconcurrency.Limit{Max: 2, Wait: 5 * time.Second}),
+//	))
+package gqlconcurrency
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlfilter"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/throttle"
+)
+
+const (
+	extensionName = "ConcurrencyLimit"
+
+	// ErrConcurrencyLimitedCode is the error code of operations exceeding their concurrency limit
+	ErrConcurrencyLimitedCode = "CONCURRENCY_LIMITED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrConcurrencyLimitedCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Limiter{}
+
+type (
+	// Limit of concurrent executions
+	Limit struct {
+		// Max concurrent executions
+		Max int
+
+		// Wait is the max time to wait for a slot. Excess executions are rejected immediately when 0.
+		Wait time.Duration
+
+		// RetryAfter is the delay hinted to clients of rejected executions. The default is 1s.
+		RetryAfter time.Duration
+	}
+
+	// Limiter is a gqlgen extension bounding concurrent executions of operations
+	Limiter struct {
+		config
+	}
+
+	semaphore struct {
+		Limit
+		slots chan struct{}
+	}
+)
+
+// New concurrency limiting extension
+func New(opts ...Option) *Limiter {
+	l := &Limiter{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&l.config)
+	}
+	return l
+}
+
+// ExtensionName yields the extension name: "ConcurrencyLimit"
+func (l *Limiter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (l *Limiter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse waits for a slot before executing limited operations. Subscriptions are not limited.
+func (l *Limiter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	rc := graphql.GetOperationContext(ctx)
+	if rc.Operation == nil || rc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	sem := l.semaphore(rc)
+	if sem == nil {
+		return next(ctx)
+	}
+
+	opName := fingerprint.OperationName(rc)
+	start := time.Now()
+	acquired, queued := sem.acquire(ctx)
+	wait := time.Since(start)
+	if !acquired {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.TagOperation, opName)}, Rejected.M(1))
+		if queued {
+			throttle.RecordWait(ctx, extensionName, opName, throttle.Limited, wait)
+		} else {
+			throttle.Record(ctx, extensionName, opName, throttle.Limited)
+		}
+		err := gqlerror.Errorf("too many concurrent executions of operation %s, retry later", opName)
+		errcode.Set(err, ErrConcurrencyLimitedCode)
+		throttle.RetryAfter(ctx, err, sem.RetryAfter)
+		return &graphql.Response{Errors: gqlerror.List{err}}
+	}
+	defer sem.release()
+
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.TagOperation, opName)},
+		WaitTime.M(float64(wait)/float64(time.Millisecond)),
+	)
+	if queued {
+		throttle.RecordWait(ctx, extensionName, opName, throttle.Queued, wait)
+	} else {
+		throttle.Record(ctx, extensionName, opName, throttle.Allowed)
+	}
+	return next(ctx)
+}
+
+// semaphore of the operation, matched by name then by signature
+func (l *Limiter) semaphore(rc *graphql.OperationContext) *semaphore {
+	if sem, ok := l.byName[rc.Operation.Name]; ok && rc.Operation.Name != "" {
+		return sem
+	}
+	if len(l.bySignature) > 0 {
+		if sem, ok := l.bySignature[gqlfilter.SignatureHash(rc)]; ok {
+			return sem
+		}
+	}
+	return l.fallback
+}
+
+func newSemaphore(limit Limit) *semaphore {
+	if limit.RetryAfter <= 0 {
+		limit.RetryAfter = time.Second
+	}
+	return &semaphore{Limit: limit, slots: make(chan struct{}, limit.Max)}
+}
+
+// acquire a slot, telling if the execution was queued, waiting for a slot
+func (s *semaphore) acquire(ctx context.Context) (acquired, queued bool) {
+	select {
+	case s.slots <- struct{}{}:
+		return true, false
+	default:
+	}
+	if s.Wait <= 0 {
+		return false, false
+	}
+
+	timer := time.NewTimer(s.Wait)
+	defer timer.Stop()
+	select {
+	case s.slots <- struct{}{}:
+		return true, true
+	case <-timer.C:
+		return false, true
+	case <-ctx.Done():
+		return false, true
+	}
+}
+
+func (s *semaphore) release() {
+	<-s.slots
+}
@@ -0,0 +1,69 @@
+package gqlconcurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+	"github.com/99designs/gqlgen-contrib/throttle"
+)
+
+func TestLimiter(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+	require.NoError(t, throttle.RegisterViews())
+	defer throttle.UnregisterViews()
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	srv := gqltesting.NewServer(testschema.New(`type Query { report: String }`, testschema.Resolvers{
+		"Query.report": func(ctx context.Context) (interface{}, error) {
+			started <- struct{}{}
+			<-unblock
+			return "report", nil
+		},
+	}), New(
+		Operation("report", Limit{Max: 1}),
+		Operation("queued", Limit{Max: 1, Wait: time.Minute}),
+	))
+
+	done := make(chan string)
+	go func() { done <- gqltesting.Post(srv, `query report { report }`).Body.String() }()
+	<-started
+
+	assert.JSONEq(t, `{
+		"errors":[{"message":"too many concurrent executions of operation report, retry later","extensions":{"code":"CONCURRENCY_LIMITED","retryAfter":1}}],
+		"data":null
+	}`, gqltesting.Post(srv, `query report { report }`).Body.String())
+
+	go func() { done <- gqltesting.Post(srv, `query queued { report }`).Body.String() }()
+	<-started
+	go func() { done <- gqltesting.Post(srv, `query queued { report }`).Body.String() }()
+
+	unblock <- struct{}{}
+	assert.Equal(t, `{"data":{"report":"report"}}`, <-done)
+	unblock <- struct{}{}
+	assert.Equal(t, `{"data":{"report":"report"}}`, <-done)
+	<-started
+	unblock <- struct{}{}
+	assert.Equal(t, `{"data":{"report":"report"}}`, <-done, "queued executions wait for a slot")
+
+	rows, err := view.RetrieveData(RejectedCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+
+	decision := func(opName, decision string) map[tag.Key]string {
+		return map[tag.Key]string{throttle.TagLimiter: extensionName, throttle.TagDecision: decision, metrics.TagOperation: opName}
+	}
+	metricstest.AssertCount(t, throttle.DecisionCountView, decision("report", throttle.Allowed), 1)
+	metricstest.AssertCount(t, throttle.DecisionCountView, decision("report", throttle.Limited), 1)
+}
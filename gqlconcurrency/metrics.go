@@ -0,0 +1,63 @@
+package gqlconcurrency
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of the concurrency limiter.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(ConcurrencyViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the concurrency limiter
+func UnregisterViews() {
+	view.Unregister(ConcurrencyViews...)
+}
+
+var (
+	// ConcurrencyViews contains all opencensus stats views declared by the concurrency limiter
+	ConcurrencyViews = []*view.View{
+		RejectedCountView,
+		WaitTimeView,
+	}
+
+	// measurements
+
+	// Rejected tracks a count of operations exceeding their concurrency limit
+	Rejected = stats.Int64(
+		"gql/concurrency/rejected_count",
+		"Number of GraphQL operations exceeding their concurrency limit",
+		stats.UnitDimensionless)
+
+	// WaitTime tracks the time spent by limited operations waiting for a slot, in milliseconds
+	WaitTime = stats.Float64(
+		"gql/concurrency/wait_time",
+		"Time spent waiting for an execution slot",
+		stats.UnitMilliseconds)
+
+	// views
+
+	// RejectedCountView reports a count of operations exceeding their concurrency limit, by operation name
+	RejectedCountView = &view.View{
+		Name:        "gql/concurrency/rejected_count",
+		Description: "Count of GraphQL operations exceeding their concurrency limit, by operation",
+		Measure:     Rejected,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+
+	// WaitTimeView reports a distribution of the time spent waiting for an execution slot, by operation name (in milliseconds)
+	WaitTimeView = &view.View{
+		Name:        "gql/concurrency/wait_time",
+		Description: "Distribution of the time spent by limited GraphQL operations waiting for an execution slot, by operation",
+		Measure:     WaitTime,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
@@ -0,0 +1,39 @@
+package gqlconcurrency
+
+// Option for the concurrency limiting extension
+type Option func(*config)
+
+type config struct {
+	byName      map[string]*semaphore
+	bySignature map[string]*semaphore
+	fallback    *semaphore
+}
+
+func defaultConfig() config {
+	return config{
+		byName:      make(map[string]*semaphore),
+		bySignature: make(map[string]*semaphore),
+	}
+}
+
+// Operation limits the concurrent executions of the operations with a name
+func Operation(name string, limit Limit) Option {
+	return func(c *config) {
+		c.byName[name] = newSemaphore(limit)
+	}
+}
+
+// Signature limits the concurrent executions of the operations with a signature hash, as computed by gqlfilter.SignatureHash
+func Signature(hash string, limit Limit) Option {
+	return func(c *config) {
+		c.bySignature[hash] = newSemaphore(limit)
+	}
+}
+
+// Others limits the concurrent executions of all other operations, as a whole.
+// By default, other operations are not limited.
+func Others(limit Limit) Option {
+	return func(c *config) {
+		c.fallback = newSemaphore(limit)
+	}
+}
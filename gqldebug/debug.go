@@ -0,0 +1,257 @@
+// Package gqldebug attaches a performance breakdown to the responses of GraphQL operations sent with a debug header,
+// so frontend developers get self-service insight into slow operations without access to the tracing backend:
+//
+//	srv.Use(gqldebug.New(gqldebug.Secret(key)))
+//	srv.Use(gqlcache.New(store))
+//
+// Requests enable the debug mode with a token in the X-GraphQL-Debug header, either allowlisted (see Allow) or
+// signed with a secret (see Sign). The breakdown is added to the "debug" response extension:
+//
+//	{
+//	  "data": {...},
+//	  "extensions": {
+//	    "debug": {
+//	      "timings": {"parseMs": 0.1, "validateMs": 0.2, "executeMs": 12.5, "totalMs": 12.8},
+//	      "cache": {"response": "miss", "policy": "max-age=60, public"},
+//	      "fields": [{"path": "product.reviews", "startMs": 1.2, "durationMs": 0.1, "cache": "hit"}]
+//	    }
+//	  }
+//	}
+//
+// Fields are reported when they call a resolver, with the decisions of the gqlcache field cache. The response cache
+// and cache policy are reported when the gqlcache and gqlcachecontrol extensions are used. The extension must be used
+// before the response cache to see its hits.
+//
+// The Recorder samples operations, and serves their resolver trees as JSON or HTML on a debug endpoint.
+package gqldebug
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqlcachecontrol"
+)
+
+const (
+	extensionName = "Debug"
+	responseKey   = "debug"
+
+	// DefaultHeader is the request header enabling the debug mode
+	DefaultHeader = "X-GraphQL-Debug"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.FieldInterceptor
+} = &Debugger{}
+
+type (
+	// Debugger is a gqlgen extension adding the "debug" extension to the responses of operations in debug mode
+	Debugger struct {
+		config
+		now func() time.Time
+	}
+
+	// Report is the performance breakdown of an operation
+	Report struct {
+		Timings Timings  `json:"timings"`
+		Cache   *Cache   `json:"cache,omitempty"`
+		Fields  []*Field `json:"fields"`
+		// Truncated is true when fields were left out of the report, see MaxFields
+		Truncated bool `json:"truncated,omitempty"`
+
+		mu    sync.Mutex
+		start time.Time
+		max   int
+	}
+
+	// Timings of the phases of an operation, in milliseconds. Execution is left out when the response is cached.
+	Timings struct {
+		Parsing    float64 `json:"parseMs"`
+		Validation float64 `json:"validateMs"`
+		Execution  float64 `json:"executeMs,omitempty"`
+		Total      float64 `json:"totalMs"`
+	}
+
+	// Cache decisions of an operation: "hit" or "miss" in the response cache, and the Cache-Control policy
+	Cache struct {
+		Response string `json:"response,omitempty"`
+		Policy   string `json:"policy,omitempty"`
+	}
+
+	// Field is the timing of a resolver relative to the start of the operation, in milliseconds, with the decision of
+	// the field cache: "hit" or "miss" for cached fields.
+	Field struct {
+		Path     string  `json:"path"`
+		Start    float64 `json:"startMs"`
+		Duration float64 `json:"durationMs"`
+		Cache    string  `json:"cache,omitempty"`
+	}
+
+	reportKey struct{}
+)
+
+// New debug extension. The debug mode is off until tokens are allowed with the Allow or Secret options.
+func New(opts ...Option) *Debugger {
+	d := &Debugger{
+		config: defaultConfig(),
+		now: func() time.Time {
+			return graphql.Now()
+		},
+	}
+	for _, apply := range opts {
+		apply(&d.config)
+	}
+	return d
+}
+
+// ExtensionName yields the extension name: "Debug"
+func (d *Debugger) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (d *Debugger) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation adds the report to the first response of operations in debug mode
+func (d *Debugger) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	rc := graphql.GetOperationContext(ctx)
+	if rc == nil || !d.authorized(rc.Headers.Get(d.header), d.now()) {
+		return next(ctx)
+	}
+
+	r := &Report{start: rc.Stats.OperationStart, max: d.maxFields}
+	ctx = context.WithValue(ctx, reportKey{}, r)
+	ctx, decisions := gqlcache.WithFieldStats(ctx)
+	responses := next(ctx)
+	first := true
+	return func(rctx context.Context) *graphql.Response {
+		resp := responses(rctx)
+		if resp == nil || !first {
+			return resp
+		}
+		first = false
+
+		// transports may call response handlers with another context: the operation context holds the stats
+		d.complete(ctx, rc, r, decisions.Decisions())
+		if resp.Extensions == nil {
+			resp.Extensions = make(map[string]interface{})
+		}
+		resp.Extensions[responseKey] = r
+		return resp
+	}
+}
+
+// InterceptField times the fields calling a resolver
+func (d *Debugger) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	r, ok := ctx.Value(reportKey{}).(*Report)
+	if !ok {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if !fc.IsMethod && !fc.IsResolver {
+		return next(ctx)
+	}
+
+	start := d.now()
+	defer func() {
+		r.add(&Field{
+			Path:     fc.Path().String(),
+			Start:    milliseconds(start.Sub(r.start)),
+			Duration: milliseconds(d.now().Sub(start)),
+		})
+	}()
+	return next(ctx)
+}
+
+// GetReport yields the report of the current operation in debug mode, if any, e.g. to add it to logs
+func GetReport(ctx context.Context) *Report {
+	r, _ := ctx.Value(reportKey{}).(*Report)
+	return r
+}
+
+func (r *Report) add(f *Field) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.Fields) >= r.max {
+		r.Truncated = true
+		return
+	}
+	r.Fields = append(r.Fields, f)
+}
+
+// complete the report with the timings and cache decisions of the operation
+func (d *Debugger) complete(ctx context.Context, rc *graphql.OperationContext, r *Report, decisions map[string]bool) {
+	end := d.now()
+	cs := gqlcache.GetStats(ctx)
+	hit := cs != nil && cs.Hit
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Timings = Timings{
+		Parsing:    milliseconds(rc.Stats.Parsing.End.Sub(rc.Stats.Parsing.Start)),
+		Validation: milliseconds(rc.Stats.Validation.End.Sub(rc.Stats.Validation.Start)),
+		Total:      milliseconds(end.Sub(rc.Stats.OperationStart)),
+	}
+	if !hit {
+		r.Timings.Execution = milliseconds(end.Sub(rc.Stats.Validation.End))
+	}
+
+	var c Cache
+	if cs != nil {
+		c.Response = decision(hit)
+	}
+	if policy, ok := gqlcachecontrol.GetPolicy(ctx); ok {
+		c.Policy = policy.HeaderValue()
+	}
+	if c != (Cache{}) {
+		r.Cache = &c
+	}
+
+	// fields served from the field cache may not reach the resolver middleware of the report
+	byPath := make(map[string]*Field, len(r.Fields))
+	for _, f := range r.Fields {
+		byPath[f.Path] = f
+	}
+	for path, hit := range decisions {
+		if f, ok := byPath[path]; ok {
+			f.Cache = decision(hit)
+		} else if len(r.Fields) < r.max {
+			r.Fields = append(r.Fields, &Field{Path: path, Cache: decision(hit)})
+		} else {
+			r.Truncated = true
+		}
+	}
+	if r.Fields == nil {
+		r.Fields = []*Field{}
+	}
+	sort.SliceStable(r.Fields, func(i, j int) bool {
+		if r.Fields[i].Start != r.Fields[j].Start {
+			return r.Fields[i].Start < r.Fields[j].Start
+		}
+		return r.Fields[i].Path < r.Fields[j].Path
+	})
+}
+
+func decision(hit bool) string {
+	if hit {
+		return "hit"
+	}
+	return "miss"
+}
+
+// milliseconds of a duration
+func milliseconds(d time.Duration) float64 {
+	if d < 0 {
+		d = 0
+	}
+	return float64(d) / float64(time.Millisecond)
+}
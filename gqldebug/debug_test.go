@@ -0,0 +1,100 @@
+package gqldebug
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = gqlcache.FieldDirective + `
+type Query {
+	rate(currency: String!): Float @cache(ttl: "1m", key: ARGS)
+	todo: String
+}
+`
+
+func TestDebugger(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	srv := handler.New(testschema.New(schema, testschema.Resolvers{
+		"Query.rate": func(context.Context) (interface{}, error) {
+			clock.Advance(3 * time.Millisecond)
+			return 1.5, nil
+		},
+		"Query.todo": func(context.Context) (interface{}, error) {
+			clock.Advance(2 * time.Millisecond)
+			return "todo", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(Allow("s3cr3t")))
+	srv.Use(gqlcache.NewFieldCache(gqlcache.NewMemoryStore(10)))
+
+	query := func(token string) string {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ todo rate(currency: \"EUR\") }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set(DefaultHeader, token)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	assert.JSONEq(t, `{"data":{"todo":"todo","rate":1.5}}`, query(""))
+	assert.JSONEq(t, `{"data":{"todo":"todo","rate":1.5}}`, query("guess"), "unknown tokens are ignored")
+	assert.JSONEq(t, `{"data":{"todo":"todo","rate":1.5},"extensions":{"debug":{
+		"timings": {"parseMs": 0, "validateMs": 0, "executeMs": 2, "totalMs": 2},
+		"fields": [
+			{"path": "todo", "startMs": 0, "durationMs": 2},
+			{"path": "rate", "startMs": 2, "durationMs": 0, "cache": "hit"}
+		]
+	}}}`, query("s3cr3t"))
+}
+
+func TestMaxFields(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	srv := handler.New(testschema.New(`type Query { a: String b: String }`, testschema.Resolvers{
+		"Query.a": func(context.Context) (interface{}, error) { return "a", nil },
+		"Query.b": func(context.Context) (interface{}, error) { return "b", nil },
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(Allow("s3cr3t"), MaxFields(1)))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ a b }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DefaultHeader, "s3cr3t")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	assert.Contains(t, w.Body.String(), `"fields":[{"path":"a","startMs":0,"durationMs":0}],"truncated":true`)
+}
+
+func TestSign(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := defaultConfig()
+	Secret([]byte("key"))(&c)
+
+	token := Sign([]byte("key"), now.Add(time.Hour))
+	assert.True(t, c.authorized(token, now))
+	assert.False(t, c.authorized(token, now.Add(time.Hour)), "expired")
+	assert.False(t, c.authorized(Sign([]byte("other"), now.Add(time.Hour)), now), "wrong key")
+	assert.False(t, c.authorized(strings.Replace(token, "157", "257", 1), now), "tampered expiry")
+	assert.False(t, c.authorized("", now))
+	assert.False(t, defaultConfig().authorized(token, now), "off by default")
+}
@@ -0,0 +1,67 @@
+package gqldebug
+
+// Option for the debug extension and the Recorder
+type Option func(*config)
+
+type config struct {
+	header    string
+	tokens    []string
+	secret    []byte
+	maxFields int
+	every     uint64
+	keep      int
+}
+
+func defaultConfig() config {
+	return config{
+		header:    DefaultHeader,
+		maxFields: 1000,
+		every:     100,
+		keep:      20,
+	}
+}
+
+// Header is the request header enabling the debug mode. The default is DefaultHeader.
+func Header(name string) Option {
+	return func(c *config) {
+		c.header = name
+	}
+}
+
+// Allow enables the debug mode for requests sending one of the tokens in the header
+func Allow(tokens ...string) Option {
+	return func(c *config) {
+		c.tokens = append(c.tokens, tokens...)
+	}
+}
+
+// Secret enables the debug mode for requests sending a token signed with the secret and not expired yet, see Sign
+func Secret(key []byte) Option {
+	return func(c *config) {
+		c.secret = key
+	}
+}
+
+// MaxFields is the maximum number of fields reported or recorded per operation. The default is 1000.
+func MaxFields(n int) Option {
+	return func(c *config) {
+		c.maxFields = n
+	}
+}
+
+// SampleEvery records one operation out of n with the Recorder. The default is 100.
+func SampleEvery(n int) Option {
+	return func(c *config) {
+		if n < 1 {
+			n = 1
+		}
+		c.every = uint64(n)
+	}
+}
+
+// Keep is the number of recent operations kept by the Recorder. The default is 20.
+func Keep(n int) Option {
+	return func(c *config) {
+		c.keep = n
+	}
+}
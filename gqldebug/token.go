@@ -0,0 +1,51 @@
+package gqldebug
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sign a debug token valid until it expires, for the Secret option. Tokens are "<expiry>.<signature>", where the
+// expiry is a unix time and the signature the hex encoded HMAC-SHA256 of the expiry, e.g. to hand out short-lived
+// tokens to frontend developers.
+func Sign(key []byte, expires time.Time) string {
+	expiry := strconv.FormatInt(expires.Unix(), 10)
+	return expiry + "." + signature(key, expiry)
+}
+
+func signature(key []byte, expiry string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(expiry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// authorized tells if a token enables the debug mode
+func (c config) authorized(token string, now time.Time) bool {
+	if token == "" {
+		return false
+	}
+	for _, allowed := range c.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return true
+		}
+	}
+	if len(c.secret) == 0 {
+		return false
+	}
+
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return false
+	}
+	expiry, sig := token[:i], token[i+1:]
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || !now.Before(time.Unix(unix, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(signature(c.secret, expiry)))
+}
@@ -0,0 +1,352 @@
+package gqldebug
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/internal/fieldpath"
+)
+
+const recorderExtensionName = "ResolveTree"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Recorder{}
+
+type (
+	// Recorder is a gqlgen extension recording the resolver tree of a sample of operations, and serving the recent
+	// ones, so that developers spot N+1 patterns without a tracing UI:
+	//
+	//	recorder := gqldebug.NewRecorder(gqldebug.SampleEvery(100))
+	//	srv.Use(recorder)
+	//	http.Handle("/debug/graphql/tree", recorder.Handler())
+	//
+	// The handler lists the recent operations, and renders the tree of an operation given by its id ("?id=42"), with
+	// the timing and the field cache decision of each node, and the number of calls of each resolver. Trees are
+	// rendered as HTML in browsers, or with "?format=html", and as JSON otherwise.
+	//
+	// The recorder must be used before the field cache to see its decisions. The handler exposes the structure of
+	// responses: it should only be served on an internal or authenticated endpoint.
+	Recorder struct {
+		config
+		now func() time.Time
+
+		count  uint64
+		mu     sync.Mutex
+		traces []*Trace
+		nextID int
+	}
+
+	// Trace is the resolver tree of an operation
+	Trace struct {
+		ID        int       `json:"id"`
+		Operation string    `json:"operation"`
+		Start     time.Time `json:"start"`
+		Duration  float64   `json:"durationMs"`
+		Errors    int       `json:"errors"`
+		Root      []*Node   `json:"root"`
+		// Resolvers are the calls of each field, the most called first: many calls of a nested field hint at N+1
+		// patterns
+		Resolvers []ResolverCalls `json:"resolvers"`
+		Truncated bool            `json:"truncated,omitempty"`
+
+		mu    sync.Mutex
+		nodes []*treeNode
+	}
+
+	// Node of a resolver tree: a field relative to its parent, e.g. "[0].reviews" under "products"
+	Node struct {
+		Name     string  `json:"name"`
+		Path     string  `json:"path"`
+		Field    string  `json:"field"`
+		Start    float64 `json:"startMs"`
+		Duration float64 `json:"durationMs"`
+		Cache    string  `json:"cache,omitempty"`
+		Children []*Node `json:"children,omitempty"`
+	}
+
+	// ResolverCalls is the number of calls of a field in an operation, and their total duration
+	ResolverCalls struct {
+		Field string  `json:"field"`
+		Calls int     `json:"calls"`
+		Total float64 `json:"totalMs"`
+	}
+
+	treeNode struct {
+		Node
+		parent string
+	}
+
+	traceKey struct{}
+)
+
+// NewRecorder of resolver trees. The SampleEvery, Keep and MaxFields options apply.
+func NewRecorder(opts ...Option) *Recorder {
+	r := &Recorder{
+		config: defaultConfig(),
+		now: func() time.Time {
+			return graphql.Now()
+		},
+	}
+	for _, apply := range opts {
+		apply(&r.config)
+	}
+	return r
+}
+
+// ExtensionName yields the extension name: "ResolveTree"
+func (r *Recorder) ExtensionName() string {
+	return recorderExtensionName
+}
+
+// Validate the extension. This is a noop
+func (r *Recorder) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse records the tree of sampled operations
+func (r *Recorder) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	if oc == nil || oc.Operation == nil || atomic.AddUint64(&r.count, 1)%r.every != 0 {
+		return next(ctx)
+	}
+
+	t := &Trace{Operation: fingerprint.OperationName(oc), Start: oc.Stats.OperationStart}
+	ctx = fieldpath.WithCache(context.WithValue(ctx, traceKey{}, t))
+	ctx, decisions := gqlcache.WithFieldStats(ctx)
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	t.Duration = milliseconds(r.now().Sub(t.Start))
+	t.Errors = len(resp.Errors)
+	t.build(decisions.Decisions())
+	r.keep(t)
+	return resp
+}
+
+// InterceptField records the fields of sampled operations
+func (r *Recorder) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	t, ok := ctx.Value(traceKey{}).(*Trace)
+	if !ok {
+		return next(ctx)
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	start := r.now()
+	defer func() {
+		n := &treeNode{Node: Node{
+			Path:     fieldpath.String(ctx, fc),
+			Field:    fc.Object + "." + fc.Field.Name,
+			Start:    milliseconds(start.Sub(t.Start)),
+			Duration: milliseconds(r.now().Sub(start)),
+		}}
+		for p := fc.Parent; p != nil; p = p.Parent {
+			if p.Index == nil && p.Field.Field != nil {
+				n.parent = fieldpath.String(ctx, p)
+				break
+			}
+		}
+		t.add(n, r.maxFields)
+	}()
+	return next(ctx)
+}
+
+// Traces yields the recent traces, the latest first
+func (r *Recorder) Traces() []*Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	traces := make([]*Trace, len(r.traces))
+	for i, t := range r.traces {
+		traces[len(traces)-1-i] = t
+	}
+	return traces
+}
+
+// Trace yields a recent trace by id, or nil
+func (r *Recorder) Trace(id int) *Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.traces {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) keep(t *Trace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	t.ID = r.nextID
+	r.traces = append(r.traces, t)
+	if len(r.traces) > r.config.keep {
+		r.traces = r.traces[len(r.traces)-r.config.keep:]
+	}
+}
+
+func (t *Trace) add(n *treeNode, max int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.nodes) >= max {
+		t.Truncated = true
+		return
+	}
+	t.nodes = append(t.nodes, n)
+}
+
+// build the tree of the recorded fields
+func (t *Trace) build(decisions map[string]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byPath := make(map[string]*Node, len(t.nodes))
+	calls := map[string]*ResolverCalls{}
+	for _, n := range t.nodes {
+		if hit, ok := decisions[n.Path]; ok {
+			n.Cache = decision(hit)
+		}
+		byPath[n.Path] = &n.Node
+
+		c, ok := calls[n.Field]
+		if !ok {
+			c = &ResolverCalls{Field: n.Field}
+			calls[n.Field] = c
+		}
+		c.Calls++
+		c.Total += n.Duration
+	}
+
+	t.Root = []*Node{}
+	for _, n := range t.nodes {
+		parent, ok := byPath[n.parent]
+		if !ok || n.parent == "" {
+			n.Name = n.Path
+			t.Root = append(t.Root, &n.Node)
+			continue
+		}
+		n.Name = strings.TrimPrefix(strings.TrimPrefix(n.Path, n.parent), ".")
+		parent.Children = append(parent.Children, &n.Node)
+	}
+	sortNodes(t.Root)
+	t.nodes = nil
+
+	t.Resolvers = make([]ResolverCalls, 0, len(calls))
+	for _, c := range calls {
+		t.Resolvers = append(t.Resolvers, *c)
+	}
+	sort.Slice(t.Resolvers, func(i, j int) bool {
+		a, b := t.Resolvers[i], t.Resolvers[j]
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.Field < b.Field
+	})
+}
+
+func sortNodes(nodes []*Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Start != nodes[j].Start {
+			return nodes[i].Start < nodes[j].Start
+		}
+		return nodes[i].Path < nodes[j].Path
+	})
+	for _, n := range nodes {
+		sortNodes(n.Children)
+	}
+}
+
+// Handler serves the recent traces, or the trace given by the "id" query parameter, as JSON or HTML
+func (r *Recorder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		html := req.URL.Query().Get("format") == "html" ||
+			req.URL.Query().Get("format") == "" && strings.Contains(req.Header.Get("Accept"), "text/html")
+
+		var data interface{}
+		tmpl := listTemplate
+		if param := req.URL.Query().Get("id"); param != "" {
+			id, err := strconv.Atoi(param)
+			t := r.Trace(id)
+			if err != nil || t == nil {
+				http.Error(w, "trace not found", http.StatusNotFound)
+				return
+			}
+			data, tmpl = t, traceTemplate
+		} else {
+			traces := r.Traces()
+			summaries := make([]map[string]interface{}, len(traces))
+			for i, t := range traces {
+				summaries[i] = map[string]interface{}{
+					"id":         t.ID,
+					"operation":  t.Operation,
+					"start":      t.Start,
+					"durationMs": t.Duration,
+					"errors":     t.Errors,
+				}
+			}
+			data = summaries
+		}
+
+		if html {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = tmpl.Execute(w, data)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(data)
+	})
+}
+
+const style = `<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 8px; text-align: left; }
+ul { list-style: none; padding-left: 20px; border-left: 1px solid #ddd; }
+.ms { color: #666; }
+.hit { color: #080; }
+.miss { color: #a60; }
+</style>`
+
+var (
+	listTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html><head><title>Resolver trees</title>` + style + `</head><body>
+<h1>Recent operations</h1>
+<table>
+<tr><th>Operation</th><th>Start</th><th>Duration</th><th>Errors</th></tr>
+{{range .}}<tr><td><a href="?id={{.id}}&amp;format=html">{{.operation}}</a></td><td>{{.start.Format "15:04:05.000"}}</td><td>{{printf "%.2f" .durationMs}} ms</td><td>{{.errors}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+	traceTemplate = template.Must(template.New("trace").Parse(`{{define "node"}}<li>{{.Name}} <span class="ms">{{.Field}} +{{printf "%.2f" .Start}} ms, {{printf "%.2f" .Duration}} ms</span>{{if .Cache}} <span class="{{.Cache}}">cache {{.Cache}}</span>{{end}}
+{{if .Children}}<ul>{{range .Children}}{{template "node" .}}{{end}}</ul>{{end}}</li>
+{{end}}<!DOCTYPE html>
+<html><head><title>{{.Operation}}</title>` + style + `</head><body>
+<h1>{{.Operation}}</h1>
+<p>{{.Start.Format "2006-01-02 15:04:05.000"}}, {{printf "%.2f" .Duration}} ms, {{.Errors}} errors{{if .Truncated}}, truncated{{end}}</p>
+<h2>Resolvers</h2>
+<table>
+<tr><th>Field</th><th>Calls</th><th>Total</th></tr>
+{{range .Resolvers}}<tr><td>{{.Field}}</td><td>{{.Calls}}</td><td>{{printf "%.2f" .Total}} ms</td></tr>
+{{end}}</table>
+<h2>Tree</h2>
+<ul>{{range .Root}}{{template "node" .}}{{end}}</ul>
+</body></html>
+`))
+)
@@ -0,0 +1,100 @@
+package gqldebug
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestRecorder(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	recorder := NewRecorder(SampleEvery(2), Keep(1))
+	srv := handler.New(testschema.New(`
+		type Query { product: Product other: Product }
+		type Product { name: String reviews: Int }
+	`, testschema.Resolvers{
+		"Product.reviews": func(context.Context) (interface{}, error) {
+			clock.Advance(2 * time.Millisecond)
+			return 3, nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(recorder)
+
+	query := func(q string) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+q+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	query(`query skipped { product { name } }`)
+	query(`query first { product { name } }`)
+	query(`query skipped { product { name } }`)
+	query(`query product { product { name reviews } other { reviews } }`)
+
+	traces := recorder.Traces()
+	require.Len(t, traces, 1, "one operation out of 2 is sampled, and only the last one is kept")
+	assert.Equal(t, 2, traces[0].ID)
+	assert.Nil(t, recorder.Trace(1))
+
+	serve := func(target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		recorder.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	// objects resolve before their fields, as in generated code
+	w := serve("/tree?id=2", "application/json")
+	require.Equal(t, http.StatusOK, w.Code)
+	var trace map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &trace))
+	delete(trace, "start")
+	b, _ := json.Marshal(trace)
+	assert.JSONEq(t, `{
+		"id": 2,
+		"operation": "product",
+		"durationMs": 4,
+		"errors": 0,
+		"root": [
+			{"name": "product", "path": "product", "field": "Query.product", "startMs": 0, "durationMs": 0, "children": [
+				{"name": "name", "path": "product.name", "field": "Product.name", "startMs": 0, "durationMs": 0},
+				{"name": "reviews", "path": "product.reviews", "field": "Product.reviews", "startMs": 0, "durationMs": 2}
+			]},
+			{"name": "other", "path": "other", "field": "Query.other", "startMs": 2, "durationMs": 0, "children": [
+				{"name": "reviews", "path": "other.reviews", "field": "Product.reviews", "startMs": 2, "durationMs": 2}
+			]}
+		],
+		"resolvers": [
+			{"field": "Product.reviews", "calls": 2, "totalMs": 4},
+			{"field": "Product.name", "calls": 1, "totalMs": 0},
+			{"field": "Query.other", "calls": 1, "totalMs": 0},
+			{"field": "Query.product", "calls": 1, "totalMs": 0}
+		]
+	}`, string(b))
+
+	w = serve("/tree", "text/html")
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `<a href="?id=2&amp;format=html">product</a>`)
+
+	w = serve("/tree?id=2&format=html", "")
+	assert.Contains(t, w.Body.String(), `<tr><td>Product.reviews</td><td>2</td><td>4.00 ms</td></tr>`)
+	assert.Contains(t, w.Body.String(), `<li>reviews <span class="ms">Product.reviews +2.00 ms, 2.00 ms</span>`)
+
+	assert.Equal(t, http.StatusNotFound, serve("/tree?id=1", "").Code)
+}
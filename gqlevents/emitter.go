@@ -0,0 +1,262 @@
+// Package gqlevents emits a versioned event per completed GraphQL operation, and delivers it to sinks through one
+// pipeline, so that file, webhook, message broker or data warehouse sinks share buffering, batching, retries and
+// drop policies:
+//
+//	emitter := gqlevents.New(
+//		gqlevents.To(gqlevents.WriterSink(w), gqlevents.RouteOptions{Name: "file"}),
+//		gqlevents.To(gqlevents.WebhookSink(url, nil), gqlevents.RouteOptions{Name: "webhook", Drop: gqlevents.DropOldest}),
+//	)
+//	srv.Use(emitter)
+//	defer emitter.Shutdown(ctx)
+//
+// Events (see Event) carry the signature, timings, errors, client, cost and cache decisions of operations. Each sink
+// has its own buffer, so that a slow sink does not hold back the others: events are dropped when its buffer is full.
+//
+// The emitter must be used before the response cache to see its hits.
+package gqlevents
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/errclass"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqlcachecontrol"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+)
+
+const extensionName = "OperationEvents"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Emitter{}
+
+type (
+	// Emitter is a gqlgen extension emitting an Event per completed operation to sinks
+	Emitter struct {
+		config
+		routes []*route
+	}
+
+	// Option for the emitter
+	Option func(*config)
+
+	config struct {
+		sinks        []Sink
+		routes       []RouteOptions
+		host         string
+		classifier   errclass.Classifier
+		errorHandler func(error)
+		now          func() time.Time
+	}
+)
+
+func defaultConfig() config {
+	host, _ := os.Hostname()
+	return config{
+		host: host,
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+		now: func() time.Time {
+			return graphql.Now()
+		},
+	}
+}
+
+// To delivers events to a sink, with its own buffer
+func To(sink Sink, opts RouteOptions) Option {
+	return func(c *config) {
+		if opts.Name == "" {
+			opts.Name = "sink-" + strconv.Itoa(len(c.sinks))
+		}
+		c.sinks = append(c.sinks, sink)
+		c.routes = append(c.routes, opts)
+	}
+}
+
+// Host recorded in events. By default this is the OS hostname
+func Host(host string) Option {
+	return func(c *config) {
+		c.host = host
+	}
+}
+
+// ErrorClassifier classifies the errors of operations. The default is errclass.Default.
+func ErrorClassifier(classifier errclass.Classifier) Option {
+	return func(c *config) {
+		c.classifier = classifier
+	}
+}
+
+// ErrorHandler is called with the errors of sinks, once retries are exhausted. By default, errors are logged.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// New emitter, delivering events in the background until Shutdown is called
+func New(opts ...Option) *Emitter {
+	e := &Emitter{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&e.config)
+	}
+	for i, sink := range e.sinks {
+		e.routes = append(e.routes, newRoute(sink, e.config.routes[i], e.errorHandler))
+	}
+	return e
+}
+
+// ExtensionName yields the extension name: "OperationEvents"
+func (e *Emitter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (e *Emitter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation emits the event of an operation once its first response is complete
+func (e *Emitter) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	rc := graphql.GetOperationContext(ctx)
+	if rc.Operation != nil && rc.Operation.Operation == ast.Subscription {
+		// subscription events are not operations
+		return next(ctx)
+	}
+
+	responses := next(ctx)
+	first := true
+	return func(rctx context.Context) *graphql.Response {
+		resp := responses(rctx)
+		if resp == nil || !first {
+			return resp
+		}
+		first = false
+		// transports may call response handlers with another context: the operation context holds the stats
+		e.Emit(e.event(ctx, rc, resp))
+		return resp
+	}
+}
+
+// Emit an event to all the sinks, e.g. an event built by another extension
+func (e *Emitter) Emit(event Event) {
+	for _, r := range e.routes {
+		r.push(event)
+	}
+}
+
+// Flush delivers the events buffered so far, without waiting for their batches to fill
+func (e *Emitter) Flush(ctx context.Context) error {
+	for _, r := range e.routes {
+		r.flush(ctx)
+	}
+	return ctx.Err()
+}
+
+// Shutdown stops the background delivery, then delivers the remaining events
+func (e *Emitter) Shutdown(ctx context.Context) error {
+	for _, r := range e.routes {
+		if err := r.close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// event of a completed operation
+func (e *Emitter) event(ctx context.Context, rc *graphql.OperationContext, resp *graphql.Response) Event {
+	end := e.now()
+	cs := gqlcache.GetStats(ctx)
+	hit := cs != nil && cs.Hit
+	cost, _, _ := gqlcomplexity.OperationCost(rc)
+
+	event := Event{
+		Version:   Version,
+		Type:      TypeOperationCompleted,
+		ID:        eventID(),
+		Timestamp: end,
+		Host:      e.host,
+		Operation: Operation{
+			Name:        fingerprint.OperationName(rc),
+			Fingerprint: fingerprint.Of(rc).Hash,
+		},
+		Timings: Timings{
+			Start:      rc.Stats.OperationStart,
+			Parsing:    milliseconds(rc.Stats.Parsing.End.Sub(rc.Stats.Parsing.Start)),
+			Validation: milliseconds(rc.Stats.Validation.End.Sub(rc.Stats.Validation.Start)),
+			Total:      milliseconds(end.Sub(rc.Stats.OperationStart)),
+		},
+		Errors: Errors{Count: len(resp.Errors)},
+		Cost:   cost,
+	}
+	if rc.Operation != nil {
+		event.Operation.Type = string(rc.Operation.Operation)
+	}
+	if !hit {
+		event.Timings.Execution = milliseconds(end.Sub(rc.Stats.Validation.End))
+	}
+
+	if classification, failed := errclass.Worst(e.classifier, resp.Errors); failed {
+		event.Errors.Class = string(classification.Class)
+		codes := map[string]bool{}
+		for _, err := range resp.Errors {
+			if code := errclass.Code(err); code != "" && !codes[code] {
+				codes[code] = true
+				event.Errors.Codes = append(event.Errors.Codes, code)
+			}
+		}
+		sort.Strings(event.Errors.Codes)
+	}
+
+	app := clientinfo.AppOf(ctx, rc.Headers)
+	event.Client.Name, event.Client.Version = app.Name, app.Version
+	if info, ok := clientinfo.FromContext(ctx); ok {
+		event.Client.ID = info.ClientID
+		event.Client.Class = string(info.Class)
+		event.Client.Country = info.Country
+	}
+
+	var c Cache
+	if cs != nil {
+		c.Response = "miss"
+		if hit {
+			c.Response = "hit"
+		}
+	}
+	if policy, ok := gqlcachecontrol.GetPolicy(ctx); ok {
+		c.Policy = policy.HeaderValue()
+	}
+	if c != (Cache{}) {
+		event.Cache = &c
+	}
+	return event
+}
+
+// eventID identifies an event, so that sinks may deduplicate retried deliveries
+func eventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// milliseconds of a duration
+func milliseconds(d time.Duration) float64 {
+	if d < 0 {
+		d = 0
+	}
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,66 @@
+package gqlevents
+
+import "time"
+
+// Version of the event schema. Fields may be added within a version; renaming or removing fields bumps it.
+const Version = 1
+
+// TypeOperationCompleted is the type of the events of completed operations
+const TypeOperationCompleted = "operation.completed"
+
+type (
+	// Event of a completed operation, as sent to sinks
+	Event struct {
+		Version   int       `json:"version"`
+		Type      string    `json:"type"`
+		ID        string    `json:"id"`
+		Timestamp time.Time `json:"timestamp"`
+		Host      string    `json:"host,omitempty"`
+		Operation Operation `json:"operation"`
+		Timings   Timings   `json:"timings"`
+		Errors    Errors    `json:"errors"`
+		Client    Client    `json:"client"`
+		// Cost of the operation, computed by gqlcomplexity or the gqlgen complexity limit
+		Cost  int    `json:"cost,omitempty"`
+		Cache *Cache `json:"cache,omitempty"`
+	}
+
+	// Operation of an event
+	Operation struct {
+		Name string `json:"name,omitempty"`
+		Type string `json:"type,omitempty"`
+		// Fingerprint is the hash of the signature of the operation, see the fingerprint package
+		Fingerprint string `json:"fingerprint,omitempty"`
+	}
+
+	// Timings of the phases of an operation, in milliseconds. Execution is 0 when the response is cached.
+	Timings struct {
+		Start      time.Time `json:"start"`
+		Parsing    float64   `json:"parseMs"`
+		Validation float64   `json:"validateMs"`
+		Execution  float64   `json:"executeMs"`
+		Total      float64   `json:"totalMs"`
+	}
+
+	// Errors of an operation: their count, their worst class (see errclass) and their distinct codes
+	Errors struct {
+		Count int      `json:"count"`
+		Class string   `json:"class,omitempty"`
+		Codes []string `json:"codes,omitempty"`
+	}
+
+	// Client of an operation: name and version from request headers, and the anonymized caller of clientinfo
+	Client struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version,omitempty"`
+		ID      string `json:"id,omitempty"`
+		Class   string `json:"class,omitempty"`
+		Country string `json:"country,omitempty"`
+	}
+
+	// Cache decisions of an operation: "hit" or "miss" in the response cache, and the Cache-Control policy
+	Cache struct {
+		Response string `json:"response,omitempty"`
+		Policy   string `json:"policy,omitempty"`
+	}
+)
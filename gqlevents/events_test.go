@@ -0,0 +1,260 @@
+package gqlevents
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]Event
+}
+
+func (r *recorder) Send(_ context.Context, events []Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, events)
+	return nil
+}
+
+func (r *recorder) events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []Event
+	for _, batch := range r.batches {
+		events = append(events, batch...)
+	}
+	return events
+}
+
+func TestEmitter(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	var buf bytes.Buffer
+	rec := &recorder{}
+	emitter := New(
+		To(rec, RouteOptions{Interval: time.Hour}),
+		To(WriterSink(&buf), RouteOptions{Name: "file", Interval: time.Hour}),
+		Host("host-1"),
+	)
+
+	srv := handler.New(testschema.New(`type Query { todo: String, fail: String }`, testschema.Resolvers{
+		"Query.todo": func(context.Context) (interface{}, error) {
+			clock.Advance(5 * time.Millisecond)
+			return "todo", nil
+		},
+		"Query.fail": func(context.Context) (interface{}, error) {
+			return nil, &gqlerror.Error{Message: "fail", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(emitter)
+	srv.Use(gqlcache.New(gqlcache.NewMemoryStore(10), gqlcache.TTL(time.Minute)))
+
+	query := func(body string) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("apollographql-client-name", "web")
+		req.Header.Set("apollographql-client-version", "1.2")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	query(`{"query": "query Todo { todo }"}`)
+	query(`{"query": "query Todo { todo }"}`)
+	query(`{"query": "query Fail { fail }"}`)
+	require.NoError(t, emitter.Shutdown(context.Background()))
+
+	events := rec.events()
+	require.Len(t, events, 3)
+	for _, e := range events {
+		assert.Equal(t, Version, e.Version)
+		assert.Equal(t, TypeOperationCompleted, e.Type)
+		assert.Len(t, e.ID, 32)
+		assert.Equal(t, "host-1", e.Host)
+		assert.Equal(t, Client{Name: "web", Version: "1.2"}, e.Client)
+	}
+
+	assert.Equal(t, "Todo", events[0].Operation.Name)
+	assert.Equal(t, "query", events[0].Operation.Type)
+	assert.NotEmpty(t, events[0].Operation.Fingerprint)
+	assert.Equal(t, 5.0, events[0].Timings.Execution)
+	assert.Equal(t, 5.0, events[0].Timings.Total)
+	assert.Equal(t, &Cache{Response: "miss"}, events[0].Cache)
+	assert.Equal(t, Errors{}, events[0].Errors)
+
+	assert.Equal(t, events[0].Operation.Fingerprint, events[1].Operation.Fingerprint)
+	assert.Equal(t, 0.0, events[1].Timings.Execution, "cached responses are not executed")
+	assert.Equal(t, &Cache{Response: "hit"}, events[1].Cache)
+
+	assert.Equal(t, Errors{Count: 1, Class: "client", Codes: []string{"NOT_FOUND"}}, events[2].Errors)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	var e Event
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &e))
+	assert.Equal(t, events[2].ID, e.ID)
+}
+
+func TestRouteBatches(t *testing.T) {
+	rec := &recorder{}
+	r := newRoute(rec, RouteOptions{BatchSize: 2, Interval: time.Hour}, func(err error) { t.Error(err) })
+	for i := 0; i < 5; i++ {
+		r.push(Event{ID: string(rune('a' + i))})
+	}
+	require.NoError(t, r.close(context.Background()))
+
+	var ids []string
+	for _, batch := range rec.batches {
+		assert.LessOrEqual(t, len(batch), 2)
+		for _, e := range batch {
+			ids = append(ids, e.ID)
+		}
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, ids)
+}
+
+func TestRouteDropPolicies(t *testing.T) {
+	ids := func(policy DropPolicy) []string {
+		rec := &recorder{}
+		r := newRoute(rec, RouteOptions{BufferSize: 2, BatchSize: 10, Interval: time.Hour, Drop: policy}, func(err error) { t.Error(err) })
+		for _, id := range []string{"a", "b", "c"} {
+			r.push(Event{ID: id})
+		}
+		require.NoError(t, r.close(context.Background()))
+
+		var ids []string
+		for _, e := range rec.events() {
+			ids = append(ids, e.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"a", "b"}, ids(DropNewest))
+	assert.Equal(t, []string{"b", "c"}, ids(DropOldest))
+}
+
+func TestRouteRing(t *testing.T) {
+	rec := &recorder{}
+	// batches never fill up: events are only flushed by the test
+	r := newRoute(rec, RouteOptions{BufferSize: 20, BatchSize: 21, Interval: time.Hour, Drop: DropOldest}, func(err error) { t.Error(err) })
+	var expected []string
+	for i := 0; i < 50; i++ {
+		id := strconv.Itoa(i)
+		r.push(Event{ID: id})
+		if i >= 30 {
+			expected = append(expected, id)
+		}
+		if i == 24 {
+			// wrap around the ring: the buffer keeps the 20 newest events
+			r.flush(context.Background())
+			expected = nil
+		}
+	}
+	r.mu.Lock()
+	assert.Len(t, r.buf, 20, "the buffer grows up to its size")
+	r.mu.Unlock()
+
+	rec.mu.Lock()
+	rec.batches = nil
+	rec.mu.Unlock()
+	require.NoError(t, r.close(context.Background()))
+	var ids []string
+	for _, e := range rec.events() {
+		ids = append(ids, e.ID)
+	}
+	assert.Equal(t, expected, ids)
+}
+
+func TestRouteRetries(t *testing.T) {
+	attempts := 0
+	failing := SinkFunc(func(context.Context, []Event) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	r := newRoute(failing, RouteOptions{Interval: time.Hour, MinBackoff: time.Millisecond}, func(err error) { t.Error(err) })
+	r.push(Event{ID: "a"})
+	require.NoError(t, r.close(context.Background()))
+	assert.Equal(t, 3, attempts)
+
+	var handled []error
+	r = newRoute(SinkFunc(func(context.Context, []Event) error {
+		return errors.New("unavailable")
+	}), RouteOptions{Name: "broken", MaxRetries: -1}, func(err error) { handled = append(handled, err) })
+	r.push(Event{ID: "a"})
+	require.NoError(t, r.close(context.Background()))
+	require.Len(t, handled, 1)
+	assert.EqualError(t, handled[0], "gqlevents: could not deliver 1 events to broken: unavailable")
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got []Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		b, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(b, &got))
+		if len(got) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	sink := WebhookSink(srv.URL, http.Header{"Authorization": {"Bearer token"}})
+	require.NoError(t, sink.Send(context.Background(), []Event{{ID: "a"}}))
+	assert.Equal(t, "a", got[0].ID)
+	assert.EqualError(t, sink.Send(context.Background(), []Event{{ID: "a"}, {ID: "b"}}), "gqlevents: could not post events: 503 Service Unavailable")
+}
+
+type memoryObjects map[string][]byte
+
+func (m memoryObjects) Put(_ context.Context, key string, body []byte, contentType string) error {
+	m[key+" "+contentType] = body
+	return nil
+}
+
+func TestObjectSink(t *testing.T) {
+	ts := time.Date(2020, 1, 31, 15, 45, 0, 0, time.UTC)
+	events := []Event{{ID: "3f2a9c0b1d4e", Timestamp: ts}, {ID: "b", Timestamp: ts}}
+
+	objects := memoryObjects{}
+	require.NoError(t, ObjectSink(objects, ObjectOptions{Prefix: "graphql/", Host: "box"}).Send(context.Background(), events))
+	body := objects["graphql/dt=2020-01-31/events-20200131T154500Z-box-3f2a9c0b.ndjson application/x-ndjson"]
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 2)
+	var e Event
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, "b", e.ID)
+
+	objects = memoryObjects{}
+	require.NoError(t, ObjectSink(objects, ObjectOptions{Host: "box", Gzip: true}).Send(context.Background(), events))
+	gz, err := gzip.NewReader(bytes.NewReader(objects["dt=2020-01-31/events-20200131T154500Z-box-3f2a9c0b.ndjson.gz application/x-ndjson"]))
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, string(body), string(b))
+}
@@ -0,0 +1,80 @@
+package gqlevents
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// RegisterViews registers the opencensus views of event delivery.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(EventViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of event delivery
+func UnregisterViews() {
+	view.Unregister(EventViews...)
+}
+
+var (
+	// EventViews contains all opencensus stats views declared by the event pipeline
+	EventViews = []*view.View{
+		EventSentCountView,
+		EventFailedCountView,
+		EventDroppedCountView,
+	}
+
+	// TagSink is the name of the route of a sink
+	TagSink = tag.MustNewKey("gql.events.sink")
+
+	// measurements
+
+	// EventsSent tracks a count of events delivered to sinks
+	EventsSent = stats.Int64(
+		"gql/events/sent_count",
+		"Number of operation events delivered to sinks",
+		stats.UnitDimensionless)
+
+	// EventsFailed tracks a count of events which could not be delivered, once retries are exhausted
+	EventsFailed = stats.Int64(
+		"gql/events/failed_count",
+		"Number of operation events which could not be delivered to sinks",
+		stats.UnitDimensionless)
+
+	// EventsDropped tracks a count of events dropped by full buffers
+	EventsDropped = stats.Int64(
+		"gql/events/dropped_count",
+		"Number of operation events dropped by full buffers",
+		stats.UnitDimensionless)
+
+	// views
+
+	// EventSentCountView reports a count of delivered events, by sink
+	EventSentCountView = &view.View{
+		Name:        "gql/events/sent_count",
+		Description: "Count of operation events delivered, by sink",
+		Measure:     EventsSent,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagSink},
+	}
+
+	// EventFailedCountView reports a count of undelivered events, by sink
+	EventFailedCountView = &view.View{
+		Name:        "gql/events/failed_count",
+		Description: "Count of operation events which could not be delivered, by sink",
+		Measure:     EventsFailed,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagSink},
+	}
+
+	// EventDroppedCountView reports a count of dropped events, by sink
+	EventDroppedCountView = &view.View{
+		Name:        "gql/events/dropped_count",
+		Description: "Count of operation events dropped by full buffers, by sink",
+		Measure:     EventsDropped,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{TagSink},
+	}
+)
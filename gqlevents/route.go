@@ -0,0 +1,226 @@
+package gqlevents
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// DropPolicy tells which events are dropped when the buffer of a sink is full
+type DropPolicy int
+
+const (
+	// DropNewest drops the events emitted while the buffer is full
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest buffered events to make room for new ones
+	DropOldest
+)
+
+type (
+	// RouteOptions configure the delivery of events to a sink
+	RouteOptions struct {
+		// Name of the sink, in errors and metrics (TagSink). The default is the index of the sink, e.g. "sink-0".
+		Name string
+		// BufferSize is the maximum number of events waiting to be delivered. The default is 1000.
+		BufferSize int
+		// BatchSize is the maximum number of events per batch. The default is 100.
+		BatchSize int
+		// Interval is the maximum time events wait for a batch to fill. The default is 1s.
+		Interval time.Duration
+		// Drop policy of a full buffer. The default is DropNewest.
+		Drop DropPolicy
+		// MaxRetries of a failed batch, with an exponential backoff from MinBackoff. The defaults are 3 retries,
+		// from 100ms. Negative values disable retries.
+		MaxRetries int
+		MinBackoff time.Duration
+	}
+
+	// route delivers events to a sink in the background, through its own buffer
+	route struct {
+		sink         Sink
+		opts         RouteOptions
+		errorHandler func(error)
+		ctx          context.Context
+
+		mu       sync.Mutex
+		buf      []Event // ring buffer, grown up to BufferSize
+		head     int     // index of the oldest event in buf
+		count    int     // number of events in buf
+		wake     chan struct{}
+		done     chan struct{}
+		closed   chan struct{}
+		stopOnce sync.Once
+	}
+)
+
+func newRoute(sink Sink, opts RouteOptions, errorHandler func(error)) *route {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 100 * time.Millisecond
+	}
+	ctx, _ := tag.New(context.Background(), tag.Upsert(TagSink, opts.Name))
+	r := &route{
+		sink:         sink,
+		opts:         opts,
+		errorHandler: errorHandler,
+		ctx:          ctx,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		closed:       make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// push an event into the buffer, applying the drop policy when it is full
+func (r *route) push(e Event) {
+	r.mu.Lock()
+	dropped := false
+	switch {
+	case r.count < r.opts.BufferSize:
+		if r.count == len(r.buf) {
+			r.grow()
+		}
+		r.buf[(r.head+r.count)%len(r.buf)] = e
+		r.count++
+	case r.opts.Drop == DropOldest:
+		// the buffer is full: the oldest event is replaced in place
+		r.buf[r.head] = e
+		r.head = (r.head + 1) % len(r.buf)
+		dropped = true
+	default:
+		dropped = true
+	}
+	full := r.count >= r.opts.BatchSize
+	r.mu.Unlock()
+
+	if dropped {
+		stats.Record(r.ctx, EventsDropped.M(1))
+	}
+	if full {
+		select {
+		case r.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// grow the ring buffer, up to BufferSize
+func (r *route) grow() {
+	size := 2 * len(r.buf)
+	if size < 16 {
+		size = 16
+	}
+	if size > r.opts.BufferSize {
+		size = r.opts.BufferSize
+	}
+	buf := make([]Event, size)
+	r.peek(buf[:r.count])
+	r.buf, r.head = buf, 0
+}
+
+// peek copies the oldest events of the ring buffer into events, in order
+func (r *route) peek(events []Event) {
+	end := r.head + len(events)
+	if end > len(r.buf) {
+		end = len(r.buf)
+	}
+	n := copy(events, r.buf[r.head:end])
+	copy(events[n:], r.buf)
+}
+
+func (r *route) run() {
+	defer close(r.closed)
+
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+		r.flush(context.Background())
+	}
+}
+
+// flush the buffer in batches
+func (r *route) flush(ctx context.Context) {
+	for {
+		r.mu.Lock()
+		n := r.count
+		if n > r.opts.BatchSize {
+			n = r.opts.BatchSize
+		}
+		if n == 0 {
+			r.mu.Unlock()
+			return
+		}
+		batch := make([]Event, n)
+		r.peek(batch)
+		for i := 0; i < n; i++ {
+			// release the events of the batch
+			r.buf[(r.head+i)%len(r.buf)] = Event{}
+		}
+		r.head = (r.head + n) % len(r.buf)
+		r.count -= n
+		r.mu.Unlock()
+
+		r.send(ctx, batch)
+	}
+}
+
+// send a batch, retrying failures with an exponential backoff
+func (r *route) send(ctx context.Context, batch []Event) {
+	backoff := r.opts.MinBackoff
+	for attempt := 0; ; attempt++ {
+		err := r.sink.Send(ctx, batch)
+		if err == nil {
+			stats.Record(r.ctx, EventsSent.M(int64(len(batch))))
+			return
+		}
+		if attempt >= r.opts.MaxRetries {
+			stats.Record(r.ctx, EventsFailed.M(int64(len(batch))))
+			r.errorHandler(fmt.Errorf("gqlevents: could not deliver %d events to %s: %w", len(batch), r.opts.Name, err))
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			stats.Record(r.ctx, EventsFailed.M(int64(len(batch))))
+			r.errorHandler(fmt.Errorf("gqlevents: could not deliver %d events to %s: %w", len(batch), r.opts.Name, err))
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// close stops the background delivery, then delivers the remaining events
+func (r *route) close(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.done) })
+	select {
+	case <-r.closed:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	r.flush(ctx)
+	return ctx.Err()
+}
@@ -0,0 +1,172 @@
+package gqlevents
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqlusage"
+)
+
+type (
+	// Sink delivers batches of events, e.g. to a file, a webhook, a message broker or a data warehouse
+	Sink interface {
+		Send(ctx context.Context, events []Event) error
+	}
+
+	// SinkFunc is a function used as a Sink
+	SinkFunc func(ctx context.Context, events []Event) error
+
+	writerSink struct {
+		mu  sync.Mutex
+		enc *json.Encoder
+	}
+
+	webhookSink struct {
+		url    string
+		header http.Header
+		client *http.Client
+	}
+
+	// ObjectOptions configure the ObjectSink
+	ObjectOptions struct {
+		// Prefix of the object keys, e.g. "graphql/events/"
+		Prefix string
+		// Host tells apart the objects of several servers. The default is the OS hostname.
+		Host string
+		// Gzip compresses objects, adding ".gz" to their key
+		Gzip bool
+	}
+
+	objectSink struct {
+		store gqlusage.ObjectStore
+		opts  ObjectOptions
+	}
+)
+
+// Send implements Sink
+func (f SinkFunc) Send(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// WriterSink writes events as JSON lines, e.g. to a rotatefile.Writer
+func WriterSink(w io.Writer) Sink {
+	return &writerSink{enc: json.NewEncoder(w)}
+}
+
+func (s *writerSink) Send(_ context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		if err := s.enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WebhookSink posts batches of events to a URL, as a JSON array, with the given headers, e.g. to authenticate requests
+func WebhookSink(url string, header http.Header) Sink {
+	return &webhookSink{url: url, header: header, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (s *webhookSink) Send(ctx context.Context, events []Event) error {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("gqlevents: could not marshal events: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("gqlevents: could not post events: %w", err)
+	}
+	for key, values := range s.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gqlevents: could not post events: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("gqlevents: could not post events: %s", resp.Status)
+	}
+	return nil
+}
+
+// ObjectSink writes each batch of events to an object of a store, as JSON lines partitioned by date, e.g. to the S3 or
+// Google Cloud Storage bucket of the usage reports of gqlusage:
+//
+//	gqlevents.To(gqlevents.ObjectSink(gqlusage.NewS3Store("events", "eu-west-1", creds, gqlusage.S3Options{}),
+//		gqlevents.ObjectOptions{Prefix: "graphql/events/", Gzip: true}), gqlevents.RouteOptions{Name: "s3"})
+//
+// Objects are keyed by the date and time of the first event of the batch, the host and the ID of the event, e.g.
+// "graphql/events/dt=2020-01-31/events-20200131T154500Z-host-3f2a9c0b.ndjson.gz".
+func ObjectSink(store gqlusage.ObjectStore, opts ObjectOptions) Sink {
+	if opts.Host == "" {
+		opts.Host, _ = os.Hostname()
+	}
+	return &objectSink{store: store, opts: opts}
+}
+
+// Key yields the key of the object of a batch of events
+func (o ObjectOptions) Key(events []Event) string {
+	first := events[0]
+	ts := first.Timestamp.UTC()
+	key := o.Prefix + "dt=" + ts.Format("2006-01-02") + "/events-" + ts.Format("20060102T150405Z")
+	if o.Host != "" {
+		key += "-" + o.Host
+	}
+	id := first.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	if id != "" {
+		key += "-" + id
+	}
+	key += ".ndjson"
+	if o.Gzip {
+		key += ".gz"
+	}
+	return key
+}
+
+func (s *objectSink) Send(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if s.opts.Gzip {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("gqlevents: could not encode events: %w", err)
+		}
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("gqlevents: could not compress events: %w", err)
+		}
+	}
+
+	key := s.opts.Key(events)
+	if err := s.store.Put(ctx, key, buf.Bytes(), "application/x-ndjson"); err != nil {
+		return fmt.Errorf("gqlevents: could not store %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// Package gqlfilter accepts or rejects operations by name or by normalized signature.
+//
+// Rules are either an allowlist or a denylist, and are reloaded in the background when the Refresh
+// option is set: this allows freezing the API surface, or blocking an abusive operation, during an incident.
+//
+// Signatures are normalized following the Apollo usage reporting algorithm: literals are hidden, aliases removed,
+// and selections sorted. Hashes of signatures are computed with SignatureHash, and appear in the rejection errors.
+package gqlfilter
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "OperationFilter"
+
+	// ErrNotAllowedCode is the error code when an operation is rejected by the rules
+	ErrNotAllowedCode = "OPERATION_NOT_ALLOWED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrNotAllowedCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Filter{}
+
+// Filter is a gqlgen extension accepting or rejecting operations according to rules
+type Filter struct {
+	config
+	load  Loader
+	rules atomic.Value
+
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// New operation filter. The rules are loaded once, and refreshed in the background when the Refresh option is set.
+func New(load Loader, opts ...Option) (*Filter, error) {
+	f := &Filter{
+		config:  defaultConfig(),
+		load:    load,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for _, apply := range opts {
+		apply(&f.config)
+	}
+
+	if err := f.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	if f.refresh > 0 {
+		go f.run()
+	} else {
+		close(f.stopped)
+	}
+	return f, nil
+}
+
+// ExtensionName yields the extension name: "OperationFilter"
+func (*Filter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (*Filter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Rules currently in use
+func (f *Filter) Rules() *Rules {
+	return f.rules.Load().(*Rules)
+}
+
+// Reload the rules now. On error, the current rules remain in use.
+func (f *Filter) Reload(ctx context.Context) error {
+	r, err := f.load(ctx)
+	if err != nil {
+		return err
+	}
+	f.rules.Store(r)
+	return nil
+}
+
+// Close stops refreshing the rules
+func (f *Filter) Close() error {
+	f.stopOnce.Do(func() { close(f.done) })
+	<-f.stopped
+	return nil
+}
+
+// MutateOperationContext rejects operations according to the rules
+func (f *Filter) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	rules := f.Rules()
+	hash := SignatureHash(rc)
+	matched, by := rules.Match(rc.Operation.Name, hash)
+	allowed := matched == (rules.Mode == ModeAllow)
+	if !matched {
+		by = "default"
+	}
+
+	decision := "allowed"
+	if !allowed {
+		decision = "denied"
+	}
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{
+			tag.Upsert(TagDecision, decision),
+			tag.Upsert(TagMatch, by),
+			tag.Upsert(metrics.TagOperation, fingerprint.OperationName(rc)),
+		},
+		Decisions.M(1),
+	)
+
+	if allowed {
+		return nil
+	}
+	err := gqlerror.Errorf("operation is not allowed")
+	errcode.Set(err, ErrNotAllowedCode)
+	err.Extensions["signature"] = hash
+	return err
+}
+
+// SignatureHash is the hex-encoded SHA-256 hash of the normalized signature of an operation, i.e. the hash of its
+// fingerprint.
+func SignatureHash(rc *graphql.OperationContext) string {
+	return fingerprint.Of(rc).Hash
+}
+
+func (f *Filter) run() {
+	defer close(f.stopped)
+
+	ticker := time.NewTicker(f.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), f.refresh)
+		if err := f.Reload(ctx); err != nil {
+			f.errorHandler(err)
+		}
+		cancel()
+	}
+}
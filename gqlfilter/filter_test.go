@@ -0,0 +1,102 @@
+package gqlfilter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestFilter(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	ctx := context.Background()
+	store := gqlcache.NewMemoryStore(10)
+	filter, err := New(FromStore(store, "rules"))
+	require.NoError(t, err)
+	defer filter.Close()
+
+	srv := handler.New(testschema.New(`type Query { name: String, find(id: Int): String }`, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(filter)
+
+	do := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	// no rules: everything is allowed
+	assert.Equal(t, http.StatusOK, do(`query AdminStuff { name }`).Code)
+
+	res := do(`query Find { find(id: 1) }`)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	require.NoError(t, store.Set(ctx, "rules", []byte(`{"mode": "deny", "names": ["Admin*"]}`), time.Hour))
+	require.NoError(t, filter.Reload(ctx))
+	res = do(`query AdminStuff { name }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	var body struct {
+		Errors []struct {
+			Extensions map[string]string
+		}
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+	require.Len(t, body.Errors, 1)
+	assert.Equal(t, ErrNotAllowedCode, body.Errors[0].Extensions["code"])
+	assert.Len(t, body.Errors[0].Extensions["signature"], 64)
+
+	// signatures ignore literals and aliases
+	rules, err := json.Marshal(Rules{Mode: ModeAllow, Signatures: []string{body.Errors[0].Extensions["signature"]}})
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, "rules", rules, time.Hour))
+	require.NoError(t, filter.Reload(ctx))
+	assert.Equal(t, http.StatusOK, do(`query AdminStuff { n: name }`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, do(`query Find { find(id: 2) }`).Code)
+
+	rows, err := view.RetrieveData(DecisionCountView.Name)
+	require.NoError(t, err)
+	decisions := map[string]int64{}
+	for _, row := range rows {
+		key := ""
+		for _, tg := range row.Tags {
+			if tg.Key != TagDecision && tg.Key != TagMatch {
+				continue
+			}
+			key += tg.Value + "/"
+		}
+		decisions[key] += row.Data.(*view.CountData).Value
+	}
+	assert.Equal(t, map[string]int64{"allowed/default/": 2, "denied/name/": 1, "allowed/signature/": 1, "denied/default/": 1}, decisions)
+}
+
+func TestParseRules(t *testing.T) {
+	r, err := ParseRules([]byte(`{"names": ["Export*"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, ModeDeny, r.Mode)
+	matched, by := r.Match("ExportAll", "")
+	assert.True(t, matched)
+	assert.Equal(t, "name", by)
+	matched, _ = r.Match("Import", "")
+	assert.False(t, matched)
+
+	_, err = ParseRules([]byte(`{"mode": "block"}`))
+	assert.Error(t, err)
+	_, err = ParseRules([]byte(`{"names": ["[a-"]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package gqlfilter
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of the operation filter.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(FilterViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the operation filter
+func UnregisterViews() {
+	view.Unregister(FilterViews...)
+}
+
+var (
+	// FilterViews contains all opencensus stats views declared by the operation filter
+	FilterViews = []*view.View{
+		DecisionCountView,
+	}
+
+	// measurements
+
+	// Decisions tracks a count of operations accepted or rejected by the filter
+	Decisions = stats.Int64(
+		"gql/filter/decision_count",
+		"Number of GraphQL operations accepted or rejected by the operation filter",
+		stats.UnitDimensionless)
+
+	// views
+
+	// DecisionCountView reports a count of decisions, by decision, match and operation name
+	DecisionCountView = &view.View{
+		Name:        "gql/filter/decision_count",
+		Description: "Count of GraphQL operations accepted or rejected by the operation filter, by decision and match",
+		Measure:     Decisions,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagDecision, TagMatch, metrics.TagOperation},
+	}
+
+	// TagDecision is the decision of the filter: "allowed" or "denied"
+	TagDecision = tag.MustNewKey("gql.filter.decision")
+
+	// TagMatch is how the operation matched the rules: "name", "signature", or "default" when it didn't match
+	TagMatch = tag.MustNewKey("gql.filter.match")
+)
@@ -0,0 +1,37 @@
+package gqlfilter
+
+import (
+	"log"
+	"time"
+)
+
+// Option for the operation filter
+type Option func(*config)
+
+type config struct {
+	refresh      time.Duration
+	errorHandler func(error)
+}
+
+func defaultConfig() config {
+	return config{
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+	}
+}
+
+// Refresh reloads the rules periodically. The default is to load them once.
+func Refresh(interval time.Duration) Option {
+	return func(c *config) {
+		c.refresh = interval
+	}
+}
+
+// ErrorHandler is called with errors occurring while refreshing the rules in the background.
+// By default, errors are logged.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
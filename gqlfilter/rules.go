@@ -0,0 +1,136 @@
+package gqlfilter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+)
+
+// Mode of the rules
+type Mode string
+
+const (
+	// ModeAllow only accepts the operations matching the rules
+	ModeAllow Mode = "allow"
+
+	// ModeDeny rejects the operations matching the rules
+	ModeDeny Mode = "deny"
+)
+
+type (
+	// Rules match operations by name or by signature.
+	//
+	// The JSON representation of the rules is:
+	//
+	//	{
+	//	  "mode": "deny",
+	//	  "names": ["Admin*", "ExportAll"],
+	//	  "signatures": ["9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"]
+	//	}
+	Rules struct {
+		// Mode of the rules. The default is ModeDeny.
+		Mode Mode `json:"mode"`
+
+		// Names are patterns of operation names, using the syntax of path.Match, e.g. "Admin*"
+		Names []string `json:"names"`
+
+		// Signatures are SHA-256 hashes of normalized operation signatures, as computed by SignatureHash
+		Signatures []string `json:"signatures"`
+
+		signatures map[string]struct{}
+	}
+
+	// Loader loads the rules, e.g. from a file or a store
+	Loader func(context.Context) (*Rules, error)
+)
+
+// ParseRules parses and validates JSON rules
+func ParseRules(b []byte) (*Rules, error) {
+	var r Rules
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("gqlfilter: invalid rules: %w", err)
+	}
+	if err := r.compile(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *Rules) compile() error {
+	switch r.Mode {
+	case "":
+		r.Mode = ModeDeny
+	case ModeAllow, ModeDeny:
+	default:
+		return fmt.Errorf("gqlfilter: invalid mode %q", r.Mode)
+	}
+
+	for _, pattern := range r.Names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("gqlfilter: invalid name pattern %q: %w", pattern, err)
+		}
+	}
+
+	r.signatures = make(map[string]struct{}, len(r.Signatures))
+	for _, sig := range r.Signatures {
+		r.signatures[sig] = struct{}{}
+	}
+	return nil
+}
+
+// Match tells if an operation matches the rules, by name or by signature hash
+func (r *Rules) Match(name, signatureHash string) (matched bool, by string) {
+	if name != "" {
+		for _, pattern := range r.Names {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true, "name"
+			}
+		}
+	}
+	if _, ok := r.signatures[signatureHash]; ok && signatureHash != "" {
+		return true, "signature"
+	}
+	return false, ""
+}
+
+// Static rules, e.g. defined in code
+func Static(rules Rules) Loader {
+	return func(context.Context) (*Rules, error) {
+		r := rules
+		if err := r.compile(); err != nil {
+			return nil, err
+		}
+		return &r, nil
+	}
+}
+
+// FromFile loads rules from a local JSON file
+func FromFile(path string) Loader {
+	return func(_ context.Context) (*Rules, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("gqlfilter: could not read rules: %w", err)
+		}
+		return ParseRules(b)
+	}
+}
+
+// FromStore loads JSON rules stored under key, e.g. in Redis or memcached.
+// A missing key yields empty rules in deny mode, accepting all operations.
+func FromStore(store gqlcache.Store, key string) Loader {
+	return func(ctx context.Context) (*Rules, error) {
+		b, ok, err := store.Get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("gqlfilter: could not read rules: %w", err)
+		}
+		if !ok {
+			r := &Rules{}
+			return r, r.compile()
+		}
+		return ParseRules(b)
+	}
+}
@@ -0,0 +1,216 @@
+package gqlftv1
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/apollofederatedtracingv1/generated"
+	"go.opencensus.io/trace"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const gatewayExtensionName = "ApolloFederatedTracingGateway"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Gateway{}
+
+type (
+	// Gateway is a gqlgen extension of federation gateways, collecting the federated traces returned by subgraphs.
+	//
+	// Resolvers of the gateway request traces with the "apollo-federation-include-trace: ftv1" header,
+	// then pass the "ftv1" extension of subgraph responses to Collect:
+	//
+	//	req.Header.Set(gqlftv1.IncludeTraceHeader, gqlftv1.ResponseKey)
+	//	sent := time.Now()
+	//	resp := fetch(req)
+	//	_ = gqlftv1.Collect(ctx, "reviews", sent, time.Now(), resp.Extensions[gqlftv1.ResponseKey])
+	//
+	// Subgraph traces are merged into a trace of the gateway operation, as fetch nodes of its query plan,
+	// and annotated on the current opencensus span.
+	Gateway struct {
+		gatewayConfig
+	}
+
+	// GatewayOption for the gateway extension
+	GatewayOption func(*gatewayConfig)
+
+	// TraceFunc handles the merged trace of a gateway operation, e.g. to re-export it
+	TraceFunc func(ctx context.Context, trace *generated.Trace)
+
+	gatewayConfig struct {
+		onTrace TraceFunc
+	}
+
+	// fetches collected during a gateway operation
+	fetches struct {
+		mu    sync.Mutex
+		start time.Time
+		nodes []*generated.Trace_QueryPlanNode_FetchNode
+	}
+
+	fetchesKey struct{}
+)
+
+// OnTrace handles the merged trace of gateway operations with fn
+func OnTrace(fn TraceFunc) GatewayOption {
+	return func(c *gatewayConfig) {
+		c.onTrace = fn
+	}
+}
+
+// NewGateway extension collecting subgraph traces
+func NewGateway(opts ...GatewayOption) *Gateway {
+	g := &Gateway{}
+	for _, apply := range opts {
+		apply(&g.gatewayConfig)
+	}
+	return g
+}
+
+// ExtensionName yields the extension name: "ApolloFederatedTracingGateway"
+func (g *Gateway) ExtensionName() string {
+	return gatewayExtensionName
+}
+
+// Validate the extension. This is a noop
+func (g *Gateway) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse collects the subgraph traces of the operation, then merges them
+func (g *Gateway) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	oc := graphql.GetOperationContext(ctx)
+	f := &fetches{start: oc.Stats.OperationStart}
+	if f.start.IsZero() {
+		f.start = graphql.Now()
+	}
+
+	resp := next(context.WithValue(ctx, fetchesKey{}, f))
+	end := graphql.Now()
+
+	f.mu.Lock()
+	nodes := f.nodes
+	f.mu.Unlock()
+	if len(nodes) == 0 {
+		return resp
+	}
+
+	if span := trace.FromContext(ctx); span != nil {
+		for _, node := range nodes {
+			span.Annotate(fetchAttributes(node), "subgraph trace")
+		}
+	}
+	if g.onTrace != nil {
+		g.onTrace(ctx, f.trace(nodes, end))
+	}
+	return resp
+}
+
+// Collect the federated trace returned by a subgraph in the "ftv1" response extension, during a gateway operation.
+// Fetches sent and received at some times are merged into the trace of the operation, even when their trace
+// is missing or fails to decode.
+func Collect(ctx context.Context, subgraph string, sent, received time.Time, extension interface{}) error {
+	f, ok := ctx.Value(fetchesKey{}).(*fetches)
+	if !ok {
+		return nil
+	}
+
+	node := &generated.Trace_QueryPlanNode_FetchNode{
+		ServiceName:  subgraph,
+		SentTime:     timestamppb.New(sent),
+		ReceivedTime: timestamppb.New(received),
+	}
+	if offset := sent.Sub(f.start); offset > 0 {
+		node.SentTimeOffset = uint64(offset)
+	}
+
+	var err error
+	if encoded, ok := extension.(string); !ok {
+		err = fmt.Errorf("gqlftv1: missing trace of subgraph %s", subgraph)
+	} else {
+		node.Trace, err = Decode(encoded)
+	}
+	node.TraceParsingFailed = err != nil
+
+	f.mu.Lock()
+	f.nodes = append(f.nodes, node)
+	f.mu.Unlock()
+	return err
+}
+
+// Decode a federated trace, as returned in the "ftv1" response extension
+func Decode(encoded string) (*generated.Trace, error) {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("gqlftv1: %w", err)
+	}
+	var t generated.Trace
+	if err := proto.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("gqlftv1: %w", err)
+	}
+	return &t, nil
+}
+
+// trace of the gateway operation, with fetches in parallel, in order of sending
+func (f *fetches) trace(nodes []*generated.Trace_QueryPlanNode_FetchNode, end time.Time) *generated.Trace {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].SentTimeOffset < nodes[j].SentTimeOffset
+	})
+
+	plan := make([]*generated.Trace_QueryPlanNode, 0, len(nodes))
+	for _, node := range nodes {
+		plan = append(plan, &generated.Trace_QueryPlanNode{Node: &generated.Trace_QueryPlanNode_Fetch{Fetch: node}})
+	}
+	queryPlan := plan[0]
+	if len(plan) > 1 {
+		queryPlan = &generated.Trace_QueryPlanNode{Node: &generated.Trace_QueryPlanNode_Parallel{
+			Parallel: &generated.Trace_QueryPlanNode_ParallelNode{Nodes: plan},
+		}}
+	}
+
+	var duration uint64
+	if d := end.Sub(f.start); d > 0 {
+		duration = uint64(d)
+	}
+	return &generated.Trace{
+		StartTime:  timestamppb.New(f.start),
+		EndTime:    timestamppb.New(end),
+		DurationNs: duration,
+		Root:       &generated.Trace_Node{},
+		QueryPlan:  queryPlan,
+	}
+}
+
+func fetchAttributes(node *generated.Trace_QueryPlanNode_FetchNode) []trace.Attribute {
+	attrs := []trace.Attribute{
+		trace.StringAttribute("subgraph", node.ServiceName),
+		trace.Int64Attribute("sent_offset_ns", int64(node.SentTimeOffset)),
+		trace.Int64Attribute("round_trip_ns", int64(node.ReceivedTime.AsTime().Sub(node.SentTime.AsTime()))),
+	}
+	if node.TraceParsingFailed {
+		return append(attrs, trace.BoolAttribute("trace_parsing_failed", true))
+	}
+	return append(attrs,
+		trace.Int64Attribute("duration_ns", int64(node.Trace.DurationNs)),
+		trace.Int64Attribute("errors", int64(countErrors(node.Trace.Root))),
+	)
+}
+
+func countErrors(node *generated.Trace_Node) int {
+	if node == nil {
+		return 0
+	}
+	n := len(node.Error)
+	for _, child := range node.Child {
+		n += countErrors(child)
+	}
+	return n
+}
@@ -0,0 +1,121 @@
+// Package gqlftv1 implements Apollo federated tracing (ftv1) for a GraphQL subgraph.
+//
+// When the gateway requests it with the "apollo-federation-include-trace: ftv1" header, the resolver
+// timings of the operation are returned as a base64-encoded protobuf trace in the "ftv1" response extension.
+//
+// The Tracer extends the apollofederatedtracingv1 extension of gqlgen, with the errors of the operation in the trace.
+// Federation gateways collect and merge the traces returned by their subgraphs with the Gateway extension.
+package gqlftv1
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/apollofederatedtracingv1"
+	"github.com/99designs/gqlgen/graphql/handler/apollofederatedtracingv1/generated"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// IncludeTraceHeader is the HTTP header sent by the gateway to request a federated trace
+	IncludeTraceHeader = "apollo-federation-include-trace"
+
+	// ResponseKey is the key of the response extension carrying the trace
+	ResponseKey = "ftv1"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Tracer{}
+
+// Tracer is a gqlgen extension producing federated traces
+type Tracer struct {
+	apollofederatedtracingv1.Tracer
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor, adding the errors of the response to its trace
+func (tr *Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := tr.Tracer.InterceptResponse(ctx, next)
+
+	encoded, ok := graphql.GetExtension(ctx, ResponseKey).(*string)
+	if !ok || resp == nil || len(resp.Errors) == 0 {
+		return resp
+	}
+	trace, err := Decode(*encoded)
+	if err != nil {
+		return resp
+	}
+	addErrors(trace, resp.Errors)
+	if b, err := proto.Marshal(trace); err == nil {
+		*encoded = base64.StdEncoding.EncodeToString(b)
+	}
+
+	return resp
+}
+
+// InterceptField implements graphql.FieldInterceptor, skipping the fields without a definition
+func (tr *Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if fc := graphql.GetFieldContext(ctx); fc == nil || fc.Field.Definition == nil {
+		return next(ctx)
+	}
+	return tr.Tracer.InterceptField(ctx, next)
+}
+
+// addErrors to the nodes of a trace at their path
+func addErrors(trace *generated.Trace, errs gqlerror.List) {
+	if trace.Root == nil {
+		trace.Root = &generated.Trace_Node{}
+	}
+
+	for _, err := range errs {
+		node := trace.Root
+		for _, elem := range err.Path {
+			node = child(node, elem)
+		}
+
+		traceErr := &generated.Trace_Error{Message: err.Message}
+		for _, location := range err.Locations {
+			traceErr.Location = append(traceErr.Location, &generated.Trace_Location{
+				Line:   uint32(location.Line),
+				Column: uint32(location.Column),
+			})
+		}
+		if b, jerr := json.Marshal(err); jerr == nil {
+			traceErr.Json = string(b)
+		}
+		node.Error = append(node.Error, traceErr)
+	}
+}
+
+// child yields the child of a node at a path element, creating it as needed
+func child(parent *generated.Trace_Node, elem ast.PathElement) *generated.Trace_Node {
+	for _, node := range parent.Child {
+		switch elem := elem.(type) {
+		case ast.PathIndex:
+			if id, ok := node.Id.(*generated.Trace_Node_Index); ok && id.Index == uint32(elem) {
+				return node
+			}
+		case ast.PathName:
+			if id, ok := node.Id.(*generated.Trace_Node_ResponseName); ok && id.ResponseName == string(elem) {
+				return node
+			}
+		}
+	}
+
+	node := &generated.Trace_Node{}
+	switch elem := elem.(type) {
+	case ast.PathIndex:
+		node.Id = &generated.Trace_Node_Index{Index: uint32(elem)}
+	case ast.PathName:
+		node.Id = &generated.Trace_Node_ResponseName{ResponseName: string(elem)}
+	}
+	parent.Child = append(parent.Child, node)
+	return node
+}
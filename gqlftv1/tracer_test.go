@@ -0,0 +1,159 @@
+package gqlftv1
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/apollofederatedtracingv1/generated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTracer(t *testing.T) {
+	start := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+	now := start
+	graphql.Now = func() time.Time {
+		now = now.Add(time.Microsecond)
+		return now
+	}
+	defer func() { graphql.Now = time.Now }()
+
+	ext := &Tracer{}
+	require.Equal(t, "ApolloFederatedTracingV1", ext.ExtensionName())
+
+	opCtx := &graphql.OperationContext{
+		Headers: http.Header{},
+		Stats:   graphql.Stats{OperationStart: start},
+	}
+	opCtx.Headers.Set(IncludeTraceHeader, "ftv1")
+	ctx := graphql.WithOperationContext(context.Background(), opCtx)
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	index := 1
+	h := func(ctx context.Context) *graphql.Response {
+		todos := &graphql.FieldContext{
+			Object: "Query",
+			Field: graphql.CollectedField{Field: &ast.Field{
+				Name: "todos", Alias: "todos",
+				Definition: &ast.FieldDefinition{Type: ast.ListType(ast.NamedType("Todo", nil), nil)},
+			}},
+		}
+		fctx := graphql.WithFieldContext(ctx, todos)
+		_, _ = ext.InterceptField(fctx, func(ctx context.Context) (interface{}, error) { return nil, nil })
+		fctx = graphql.WithFieldContext(fctx, &graphql.FieldContext{Index: &index})
+		fctx = graphql.WithFieldContext(fctx, &graphql.FieldContext{
+			Object: "Todo",
+			Field: graphql.CollectedField{Field: &ast.Field{
+				Name: "text", Alias: "text",
+				Definition: &ast.FieldDefinition{Type: ast.NonNullNamedType("String", nil)},
+			}},
+		})
+		_, _ = ext.InterceptField(fctx, func(ctx context.Context) (interface{}, error) { return "abc", nil })
+
+		return &graphql.Response{
+			Errors: gqlerror.List{{
+				Message:   "boom",
+				Path:      ast.Path{ast.PathName("todos"), ast.PathIndex(0)},
+				Locations: []gqlerror.Location{{Line: 1, Column: 3}},
+			}},
+		}
+	}
+
+	resp := dispatch(ctx, ext, h)
+	require.NotNil(t, resp)
+
+	encoded, ok := graphql.GetExtension(ctx, ResponseKey).(*string)
+	require.True(t, ok)
+	trace, err := Decode(*encoded)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5*time.Microsecond), trace.DurationNs)
+
+	require.Len(t, trace.Root.Child, 1)
+	list := trace.Root.Child[0]
+	assert.Equal(t, "todos", list.GetResponseName())
+	assert.Equal(t, "[Todo]", list.Type)
+	assert.Equal(t, uint64(1*time.Microsecond), list.StartTime)
+
+	require.Len(t, list.Child, 2)
+	item := list.Child[0]
+	assert.Equal(t, uint32(1), item.GetIndex())
+	require.Len(t, item.Child, 1)
+	assert.Equal(t, "text", item.Child[0].GetResponseName())
+	assert.Equal(t, "Todo", item.Child[0].ParentType)
+	assert.Equal(t, uint64(3*time.Microsecond), item.Child[0].StartTime)
+
+	failed := list.Child[1]
+	assert.Equal(t, uint32(0), failed.GetIndex())
+	require.Len(t, failed.Error, 1)
+	assert.Equal(t, "boom", failed.Error[0].Message)
+	assert.Equal(t, uint32(3), failed.Error[0].Location[0].Column)
+}
+
+func TestTracerNotRequested(t *testing.T) {
+	opCtx := &graphql.OperationContext{Headers: http.Header{}}
+	ctx := graphql.WithOperationContext(context.Background(), opCtx)
+	ctx = graphql.WithResponseContext(ctx, graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	resp := dispatch(ctx, &Tracer{}, func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+	require.NotNil(t, resp)
+	assert.Nil(t, graphql.GetExtension(ctx, ResponseKey))
+}
+
+// dispatch an operation through the interceptors of the tracer, as the executor of gqlgen does
+func dispatch(ctx context.Context, ext *Tracer, next graphql.ResponseHandler) *graphql.Response {
+	var opCtx context.Context
+	responses := ext.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+		opCtx = ctx
+		return func(ctx context.Context) *graphql.Response {
+			return ext.InterceptResponse(ctx, next)
+		}
+	})
+	return responses(opCtx)
+}
+
+func TestGateway(t *testing.T) {
+	start := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+	subgraph := &generated.Trace{DurationNs: uint64(time.Millisecond)}
+	addErrors(subgraph, gqlerror.List{{Message: "boom", Path: ast.Path{ast.PathName("reviews")}}})
+	b, err := proto.Marshal(subgraph)
+	require.NoError(t, err)
+	encoded := base64.StdEncoding.EncodeToString(b)
+
+	var merged *generated.Trace
+	ext := NewGateway(OnTrace(func(ctx context.Context, trace *generated.Trace) {
+		merged = trace
+	}))
+	require.Equal(t, gatewayExtensionName, ext.ExtensionName())
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Stats: graphql.Stats{OperationStart: start},
+	})
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		assert.NoError(t, Collect(ctx, "reviews", start.Add(time.Millisecond), start.Add(3*time.Millisecond), encoded))
+		assert.Error(t, Collect(ctx, "products", start, start.Add(time.Millisecond), nil))
+		return &graphql.Response{}
+	})
+
+	require.NotNil(t, merged)
+	nodes := merged.QueryPlan.GetParallel().GetNodes()
+	require.Len(t, nodes, 2)
+
+	products := nodes[0].GetFetch()
+	assert.Equal(t, "products", products.ServiceName)
+	assert.True(t, products.TraceParsingFailed)
+
+	reviews := nodes[1].GetFetch()
+	assert.Equal(t, "reviews", reviews.ServiceName)
+	assert.Equal(t, uint64(time.Millisecond), reviews.SentTimeOffset)
+	assert.Equal(t, uint64(time.Millisecond), reviews.Trace.DurationNs)
+	assert.Equal(t, "boom", reviews.Trace.Root.Child[0].Error[0].Message)
+}
@@ -0,0 +1,96 @@
+package gqlguard
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const (
+	// ErrAliasLimitCode is the error code when an operation uses too many aliases
+	ErrAliasLimitCode = "ALIAS_LIMIT_EXCEEDED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrAliasLimitCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = AliasLimit{}
+
+// AliasLimit is a gqlgen extension rejecting operations with many aliases.
+//
+// Aliases allow resolving the same expensive field many times in a single request, e.g.
+//
+//	{ a1: user(id: 1) { ... } a2: user(id: 1) { ... } ... }
+//
+// Fragments are expanded: aliases in a fragment count every time the fragment is spread.
+//
+// Aliases are counted once the document is parsed and validated: this limit bounds the execution of operations, not
+// the cost of parsing them. Use DocumentLimit to reject large documents before they are parsed.
+type AliasLimit struct {
+	// Max number of aliases in an operation. 0 means unlimited.
+	Max int
+
+	// MaxPerSelection is the max number of aliases in a single selection set. 0 means unlimited.
+	MaxPerSelection int
+}
+
+// ExtensionName yields the extension name: "AliasLimit"
+func (AliasLimit) ExtensionName() string {
+	return "AliasLimit"
+}
+
+// Validate this extension. This is a noop
+func (AliasLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext rejects the operation when it uses too many aliases
+func (a AliasLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil || (a.Max <= 0 && a.MaxPerSelection <= 0) {
+		return nil
+	}
+
+	total := 0
+	var check func(fields []*ast.Field, path ast.Path) *gqlerror.Error
+	check = func(fields []*ast.Field, path ast.Path) *gqlerror.Error {
+		count := 0
+		for _, field := range fields {
+			if field.Alias == "" || field.Alias == field.Name {
+				continue
+			}
+			count++
+			total++
+
+			if a.MaxPerSelection > 0 && count > a.MaxPerSelection {
+				err := gqlerror.ErrorPosf(field.Position, "selection set uses more than %d aliases", a.MaxPerSelection)
+				err.Path = path
+				errcode.Set(err, ErrAliasLimitCode)
+				return reject(ctx, fingerprint.OperationName(rc), a.ExtensionName(), "selection_set", err)
+			}
+			if a.Max > 0 && total > a.Max {
+				err := gqlerror.ErrorPosf(field.Position, "operation uses more than %d aliases", a.Max)
+				errcode.Set(err, ErrAliasLimitCode)
+				return reject(ctx, fingerprint.OperationName(rc), a.ExtensionName(), "total", err)
+			}
+		}
+
+		for _, field := range fields {
+			childPath := append(path[:len(path):len(path)], ast.PathName(field.Alias))
+			if err := check(collectFields(field.SelectionSet), childPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return check(collectFields(rc.Operation.SelectionSet), nil)
+}
@@ -0,0 +1,44 @@
+package gqlguard
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestAliasLimit(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	srv := gqltesting.NewServer(testschema.New(schema, nil), AliasLimit{Max: 3, MaxPerSelection: 2})
+
+	res := gqltesting.Post(srv, `{ a: me { name } b: me { n: name } me { name } }`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"a":{"name":"name"},"b":{"n":"name"},"me":{"name":"name"}}}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `{ me { a: name b: name c: name } }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"selection set uses more than 2 aliases","locations":[{"line":1,"column":24}],"path":["me"],"extensions":{"code":"ALIAS_LIMIT_EXCEEDED"}}],"data":null}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `query Q { me { ...f } m: me { ...f } } fragment f on User { a: name b: name }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Contains(t, res.Body.String(), "operation uses more than 3 aliases")
+
+	rows, err := view.RetrieveData(RejectionCountView.Name)
+	require.NoError(t, err)
+	reasons := map[string]int64{}
+	for _, row := range rows {
+		for _, tg := range row.Tags {
+			if tg.Key == TagReason {
+				reasons[tg.Value] = row.Data.(*view.CountData).Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"selection_set": 1, "total": 1}, reasons)
+}
@@ -0,0 +1,141 @@
+package gqlguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+)
+
+const (
+	// ErrBatchLimitCode is the error code when a batched request is rejected
+	ErrBatchLimitCode = "BATCH_LIMIT_EXCEEDED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrBatchLimitCode, errcode.KindProtocol)
+}
+
+var _ graphql.Transport = Batch{}
+
+// Batch is a gqlgen transport for batched requests: POST requests with a JSON array of operations,
+// answered with a JSON array of responses. The size of batches is limited.
+//
+// All operations of a batch are parsed and validated before any of them is executed: oversized batches
+// are rejected as a whole, with a single error and a 422 status.
+//
+// The transport must be added before transport.POST:
+//
+//	srv.AddTransport(gqlguard.Batch{MaxOperations: 10})
+//	srv.AddTransport(transport.POST{})
+type Batch struct {
+	// MaxOperations is the max number of operations in a batch. 0 means unlimited.
+	MaxOperations int
+
+	// MaxCost is the max cumulative cost of the operations in a batch, as computed by the gqlcomplexity
+	// extension, or by the gqlgen complexity limit extension. 0 means unlimited.
+	MaxCost int
+}
+
+// Supports POST requests with a JSON array body
+func (b Batch) Supports(r *http.Request) bool {
+	if r.Method != http.MethodPost || r.Header.Get("Upgrade") != "" || r.Body == nil {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return false
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	_ = r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return err == nil && bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+}
+
+// Do executes the operations of a batch, in order
+func (b Batch) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	start := graphql.Now()
+
+	var batch []*graphql.RawParams
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, exec.DispatchError(ctx, gqlerror.List{gqlerror.Errorf("json request body could not be decoded: %+v", err)}))
+		return
+	}
+
+	if b.MaxOperations > 0 && len(batch) > b.MaxOperations {
+		err := gqlerror.Errorf("batch exceeds the maximum of %d operations", b.MaxOperations)
+		errcode.Set(err, ErrBatchLimitCode)
+		b.reject(w, r, exec, reject(ctx, "", "Batch", "operations", err))
+		return
+	}
+
+	type operation struct {
+		rc   *graphql.OperationContext
+		errs gqlerror.List
+	}
+	operations := make([]operation, len(batch))
+	cost := 0
+	for i, params := range batch {
+		if params == nil {
+			params = &graphql.RawParams{}
+		}
+		params.Headers = r.Header
+		params.ReadTime = graphql.TraceTiming{
+			Start: start,
+			End:   graphql.Now(),
+		}
+
+		rc, errs := exec.CreateOperationContext(ctx, params)
+		operations[i] = operation{rc: rc, errs: errs}
+		if errs == nil {
+			c, _, _ := gqlcomplexity.OperationCost(rc)
+			cost += c
+		}
+	}
+
+	if b.MaxCost > 0 && cost > b.MaxCost {
+		err := gqlerror.Errorf("batch has cost %d, which exceeds the limit of %d", cost, b.MaxCost)
+		errcode.Set(err, ErrBatchLimitCode)
+		err.Extensions["cost"] = cost
+		err.Extensions["limit"] = b.MaxCost
+		b.reject(w, r, exec, reject(ctx, "", "Batch", "cost", err))
+		return
+	}
+
+	responses := make([]*graphql.Response, len(operations))
+	for i, op := range operations {
+		if op.errs != nil {
+			responses[i] = exec.DispatchError(graphql.WithOperationContext(ctx, op.rc), op.errs)
+			continue
+		}
+		handler, opCtx := exec.DispatchOperation(ctx, op.rc)
+		responses[i] = handler(opCtx)
+	}
+	writeJSON(w, responses)
+}
+
+func (b Batch) reject(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor, err *gqlerror.Error) {
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	writeJSON(w, exec.DispatchError(r.Context(), gqlerror.List{err}))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	_, _ = w.Write(b)
+}
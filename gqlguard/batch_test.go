@@ -0,0 +1,50 @@
+package gqlguard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestBatch(t *testing.T) {
+	srv := handler.New(testschema.New(schema, nil))
+	srv.AddTransport(Batch{MaxOperations: 3, MaxCost: 2})
+	srv.AddTransport(transport.POST{})
+	srv.Use(gqlcomplexity.New(0))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	res := post(`{"query": "{ me { name } }"}`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"me":{"name":"name"}}}`, res.Body.String())
+
+	res = post(` [{"query": "{ me { name } }"}, {"query": "{ me { n: name } }"}, {"query": "{ oops }"}]`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `[
+		{"data":{"me":{"name":"name"}}},
+		{"data":{"me":{"n":"name"}}},
+		{"errors":[{"message":"Cannot query field \"oops\" on type \"Query\".","locations":[{"line":1,"column":3}],"extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}],"data":null}
+	]`, res.Body.String())
+
+	res = post(`[{"query": "{ me { name } }"}, {"query": "{ me { name } }"}, {"query": "{ me { name } }"}, {"query": "{ me { name } }"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"batch exceeds the maximum of 3 operations","extensions":{"code":"BATCH_LIMIT_EXCEEDED"}}],"data":null}`, res.Body.String())
+
+	res = post(`[{"query": "{ me { name } }"}, {"query": "{ me { name } }"}, {"query": "{ me { friend { name } } }"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"batch has cost 4, which exceeds the limit of 2","extensions":{"code":"BATCH_LIMIT_EXCEEDED","cost":4,"limit":2}}],"data":null}`, res.Body.String())
+}
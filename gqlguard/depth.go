@@ -0,0 +1,114 @@
+// Package gqlguard protects GraphQL servers against abusive documents: deeply nested selections,
+// alias or directive floods, oversized documents and batches.
+//
+// Each protection is a separate gqlgen extension, configured by its exported fields:
+//
+//	srv.Use(gqlguard.DepthLimit{Max: 10})
+//
+// Documents are checked after parsing and validation, before any resolver runs, except for DocumentLimit
+// which runs before parsing.
+package gqlguard
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const (
+	// ErrDepthLimitCode is the error code when an operation exceeds the maximum depth
+	ErrDepthLimitCode = "DEPTH_LIMIT_EXCEEDED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrDepthLimitCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = DepthLimit{}
+
+// DepthLimit is a gqlgen extension rejecting operations with deeply nested selections.
+//
+// Root fields have a depth of 1. Fragments are expanded, and don't count as a level.
+type DepthLimit struct {
+	// Max depth of operations. 0 means unlimited.
+	Max int
+
+	// IntrospectionMax is the max depth of introspection queries, under the __schema and __type fields,
+	// which are usually deeper than regular operations. 0 means that Max applies.
+	IntrospectionMax int
+}
+
+// ExtensionName yields the extension name: "DepthLimit"
+func (DepthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate this extension. This is a noop
+func (DepthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext rejects the operation when it is too deep
+func (d DepthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Operation == nil {
+		return nil
+	}
+
+	for _, field := range collectFields(rc.Operation.SelectionSet) {
+		max, reason := d.Max, "depth"
+		if d.IntrospectionMax > 0 && (field.Name == "__schema" || field.Name == "__type") {
+			max, reason = d.IntrospectionMax, "introspection_depth"
+		}
+		if max <= 0 {
+			continue
+		}
+
+		if path, deepest := tooDeep(field, ast.Path{ast.PathName(field.Alias)}, 1, max); path != nil {
+			err := gqlerror.ErrorPosf(deepest.Position, "operation exceeds the maximum depth of %d at %s", max, path.String())
+			err.Path = path
+			errcode.Set(err, ErrDepthLimitCode)
+			return reject(ctx, fingerprint.OperationName(rc), d.ExtensionName(), reason, err)
+		}
+	}
+	return nil
+}
+
+// tooDeep returns the path of the first field deeper than max, if any
+func tooDeep(field *ast.Field, path ast.Path, depth, max int) (ast.Path, *ast.Field) {
+	if depth > max {
+		return path, field
+	}
+	for _, child := range collectFields(field.SelectionSet) {
+		childPath := append(path[:len(path):len(path)], ast.PathName(child.Alias))
+		if p, f := tooDeep(child, childPath, depth+1, max); p != nil {
+			return p, f
+		}
+	}
+	return nil, nil
+}
+
+// collectFields of a selection set, expanding fragments
+func collectFields(set ast.SelectionSet) []*ast.Field {
+	var fields []*ast.Field
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			fields = append(fields, sel)
+		case *ast.InlineFragment:
+			fields = append(fields, collectFields(sel.SelectionSet)...)
+		case *ast.FragmentSpread:
+			if sel.Definition != nil {
+				fields = append(fields, collectFields(sel.Definition.SelectionSet)...)
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,42 @@
+package gqlguard
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = `
+type Query {
+	me: User
+}
+
+type User {
+	name: String
+	friend: User
+}
+`
+
+func TestDepthLimit(t *testing.T) {
+	srv := gqltesting.NewServer(testschema.New(schema, nil), DepthLimit{Max: 3, IntrospectionMax: 5})
+
+	res := gqltesting.Post(srv, `{ me { friend { name } } }`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"me":{"friend":{"name":"name"}}}}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `{ me { friend { f: friend { name } } } }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"operation exceeds the maximum depth of 3 at me.friend.f.name","locations":[{"line":1,"column":29}],"path":["me","friend","f","name"],"extensions":{"code":"DEPTH_LIMIT_EXCEEDED"}}],"data":null}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `{ me { ...deep } } fragment deep on User { friend { ... on User { friend { name } } } }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Contains(t, res.Body.String(), `"path":["me","friend","friend","name"]`)
+
+	res = gqltesting.Post(srv, `{ __schema { types { fields { type { ofType { name } } } } } }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Contains(t, res.Body.String(), "maximum depth of 5")
+}
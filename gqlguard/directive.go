@@ -0,0 +1,185 @@
+package gqlguard
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/lexer"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const (
+	// ErrDirectiveLimitCode is the error code when a document uses too many directives
+	ErrDirectiveLimitCode = "DIRECTIVE_LIMIT_EXCEEDED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrDirectiveLimitCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+	graphql.OperationContextMutator
+} = DirectiveLimit{}
+
+// DirectiveLimit is a gqlgen extension rejecting documents with many directives.
+//
+// Directives are counted over the whole document: all operations, fields, fragments and fragment spreads.
+// They are counted with the GraphQL lexer before the document is parsed, as directive floods are expensive to parse
+// and validate, then again once it is parsed, for documents which are not sent as raw queries, e.g. persisted queries.
+type DirectiveLimit struct {
+	// Max number of directives in a document. 0 means unlimited.
+	Max int
+
+	// MaxPerLocation is the max number of directives on a single operation, field, fragment or
+	// fragment spread. 0 means unlimited.
+	MaxPerLocation int
+}
+
+// ExtensionName yields the extension name: "DirectiveLimit"
+func (DirectiveLimit) ExtensionName() string {
+	return "DirectiveLimit"
+}
+
+// Validate this extension. This is a noop
+func (DirectiveLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationParameters rejects the document when it uses too many directives, before it is parsed
+func (d DirectiveLimit) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	if rawParams.Query == "" || (d.Max <= 0 && d.MaxPerLocation <= 0) {
+		return nil
+	}
+
+	lex := lexer.New(&ast.Source{Input: rawParams.Query})
+	var (
+		total, atLocation int
+		name, args, ended bool // reading the name or the arguments of a directive, or right after a directive
+		depth             int
+	)
+	for {
+		tok, err := lex.ReadToken()
+		if err != nil || tok.Kind == lexer.EOF {
+			// syntax errors are left to the parser
+			return nil
+		}
+		if tok.Kind == lexer.Comment {
+			continue
+		}
+
+		switch {
+		case depth > 0:
+			if tok.Kind == lexer.ParenL {
+				depth++
+			} else if tok.Kind == lexer.ParenR {
+				depth--
+				ended = depth == 0
+			}
+			continue
+		case name:
+			name, args = false, true
+			total++
+			if d.MaxPerLocation > 0 && atLocation > d.MaxPerLocation {
+				err := gqlerror.ErrorPosf(&tok.Pos, "more than %d directives are used at the same location", d.MaxPerLocation)
+				errcode.Set(err, ErrDirectiveLimitCode)
+				return reject(ctx, rawParams.OperationName, d.ExtensionName(), "location", err)
+			}
+			if d.Max > 0 && total > d.Max {
+				err := gqlerror.ErrorPosf(&tok.Pos, "document uses more than %d directives", d.Max)
+				errcode.Set(err, ErrDirectiveLimitCode)
+				return reject(ctx, rawParams.OperationName, d.ExtensionName(), "total", err)
+			}
+			continue
+		case args:
+			args = false
+			if tok.Kind == lexer.ParenL {
+				depth = 1
+				continue
+			}
+			ended = true
+		}
+
+		if tok.Kind == lexer.At {
+			if !ended {
+				atLocation = 0
+			}
+			atLocation++
+			name = true
+		}
+		ended = false
+	}
+}
+
+// MutateOperationContext rejects the operation when its document uses too many directives
+func (d DirectiveLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if rc.Doc == nil || (d.Max <= 0 && d.MaxPerLocation <= 0) {
+		return nil
+	}
+
+	total := 0
+	count := func(directives ast.DirectiveList) *gqlerror.Error {
+		total += len(directives)
+		if d.MaxPerLocation > 0 && len(directives) > d.MaxPerLocation {
+			err := gqlerror.ErrorPosf(directives[d.MaxPerLocation].Position, "more than %d directives are used at the same location", d.MaxPerLocation)
+			errcode.Set(err, ErrDirectiveLimitCode)
+			return reject(ctx, fingerprint.OperationName(rc), d.ExtensionName(), "location", err)
+		}
+		if d.Max > 0 && total > d.Max {
+			err := gqlerror.ErrorPosf(directives[len(directives)-(total-d.Max)].Position, "document uses more than %d directives", d.Max)
+			errcode.Set(err, ErrDirectiveLimitCode)
+			return reject(ctx, fingerprint.OperationName(rc), d.ExtensionName(), "total", err)
+		}
+		return nil
+	}
+
+	var selectionSet func(set ast.SelectionSet) *gqlerror.Error
+	selectionSet = func(set ast.SelectionSet) *gqlerror.Error {
+		for _, selection := range set {
+			switch sel := selection.(type) {
+			case *ast.Field:
+				if err := count(sel.Directives); err != nil {
+					return err
+				}
+				if err := selectionSet(sel.SelectionSet); err != nil {
+					return err
+				}
+			case *ast.InlineFragment:
+				if err := count(sel.Directives); err != nil {
+					return err
+				}
+				if err := selectionSet(sel.SelectionSet); err != nil {
+					return err
+				}
+			case *ast.FragmentSpread:
+				if err := count(sel.Directives); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, op := range rc.Doc.Operations {
+		if err := count(op.Directives); err != nil {
+			return err
+		}
+		if err := selectionSet(op.SelectionSet); err != nil {
+			return err
+		}
+	}
+	for _, fragment := range rc.Doc.Fragments {
+		if err := count(fragment.Directives); err != nil {
+			return err
+		}
+		if err := selectionSet(fragment.SelectionSet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
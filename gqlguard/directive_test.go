@@ -0,0 +1,54 @@
+package gqlguard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestDirectiveLimit(t *testing.T) {
+	srv := gqltesting.NewServer(testschema.New(schema, nil), DirectiveLimit{Max: 3, MaxPerLocation: 1})
+
+	res := gqltesting.Post(srv, `{ me @include(if: true) { name @skip(if: false) } }`)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"me":{"name":"name"}}}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `{ me { name @include(if: true) @skip(if: false) } }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"more than 1 directives are used at the same location","locations":[{"line":1,"column":33}],"extensions":{"code":"DIRECTIVE_LIMIT_EXCEEDED"}}],"data":null}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `{ me { ...f @include(if: true) } } fragment f on User { name @skip(if: false) a: name @skip(if: false) b: name @skip(if: false) }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Contains(t, res.Body.String(), "document uses more than 3 directives")
+}
+
+func TestDirectiveLimitBeforeParsing(t *testing.T) {
+	d := DirectiveLimit{Max: 3, MaxPerLocation: 2}
+	check := func(query string) error {
+		if err := d.MutateOperationParameters(context.Background(), &graphql.RawParams{Query: query}); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	assert.NoError(t, check(`{ me @include(if: true) @skip(if: false) { name @a } }`))
+	assert.NoError(t, check(`{ me(text: "@a @b @c @d") { name } } # @a @b @c @d`), "strings and comments are not directives")
+	assert.NoError(t, check(`{ me @a { name @b(x: 1) @c } `), "syntax errors are left to the parser")
+
+	err := check(`{ me { name @a @b(x: [1]) @c } }`)
+	require.Error(t, err)
+	assert.Equal(t, `input:1: more than 2 directives are used at the same location`, err.Error())
+	assert.Equal(t, ErrDirectiveLimitCode, err.(*gqlerror.Error).Extensions["code"])
+
+	err = check(`{ me @a { name @b(x: 1) a: name @c } b: me @d { name } }`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "document uses more than 3 directives")
+}
@@ -0,0 +1,127 @@
+package gqlguard
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/lexer"
+)
+
+const (
+	// ErrDocumentTooLargeCode is the error code when a document, or a request body, exceeds the maximum size
+	ErrDocumentTooLargeCode = "DOCUMENT_TOO_LARGE"
+
+	// ErrTooManyTokensCode is the error code when a document exceeds the maximum number of tokens
+	ErrTooManyTokensCode = "TOO_MANY_TOKENS"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrDocumentTooLargeCode, errcode.KindProtocol)
+	errcode.RegisterErrorType(ErrTooManyTokensCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = DocumentLimit{}
+
+// DocumentLimit is a gqlgen extension rejecting large documents before they are parsed.
+//
+// Tokens are counted with the GraphQL lexer, without allocating the document: this is much cheaper than parsing,
+// and stops documents crafted to be expensive to parse and validate. Comments are not counted as tokens.
+//
+// The transport has already read the request at this stage: use MaxBodySize to limit the size of request bodies.
+type DocumentLimit struct {
+	// MaxBytes is the max size of the document. 0 means unlimited.
+	MaxBytes int
+
+	// MaxTokens is the max number of tokens in the document. 0 means unlimited.
+	MaxTokens int
+}
+
+// ExtensionName yields the extension name: "DocumentLimit"
+func (DocumentLimit) ExtensionName() string {
+	return "DocumentLimit"
+}
+
+// Validate this extension. This is a noop
+func (DocumentLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationParameters rejects the document when it is too large
+func (d DocumentLimit) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	if d.MaxBytes > 0 && len(rawParams.Query) > d.MaxBytes {
+		err := gqlerror.Errorf("document exceeds the maximum size of %d bytes", d.MaxBytes)
+		errcode.Set(err, ErrDocumentTooLargeCode)
+		return reject(ctx, rawParams.OperationName, d.ExtensionName(), "bytes", err)
+	}
+
+	if d.MaxTokens > 0 && countTokens(rawParams.Query, d.MaxTokens) > d.MaxTokens {
+		err := gqlerror.Errorf("document exceeds the maximum of %d tokens", d.MaxTokens)
+		errcode.Set(err, ErrTooManyTokensCode)
+		return reject(ctx, rawParams.OperationName, d.ExtensionName(), "tokens", err)
+	}
+	return nil
+}
+
+// countTokens of a document, stopping after max+1. Syntax errors stop counting, and are left to the parser.
+func countTokens(query string, max int) int {
+	lex := lexer.New(&ast.Source{Input: query})
+	count := 0
+	for count <= max {
+		tok, err := lex.ReadToken()
+		if err != nil || tok.Kind == lexer.EOF {
+			break
+		}
+		if tok.Kind != lexer.Comment {
+			count++
+		}
+	}
+	return count
+}
+
+// MaxBodySize is an HTTP middleware rejecting request bodies larger than max bytes with a 413 Payload Too Large,
+// before the transport decodes them.
+//
+//	http.Handle("/query", gqlguard.MaxBodySize(1<<20, srv))
+func MaxBodySize(max int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tooLarge := r.ContentLength > max
+		if !tooLarge {
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, max+1))
+			_ = r.Body.Close()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			tooLarge = int64(len(body)) > max
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		if !tooLarge {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err := gqlerror.Errorf("request body exceeds the maximum size of %d bytes", max)
+		errcode.Set(err, ErrDocumentTooLargeCode)
+		_ = reject(r.Context(), "", "MaxBodySize", "body_bytes", err)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		writeJSON(w, &graphql.Response{Errors: gqlerror.List{err}})
+	})
+}
@@ -0,0 +1,48 @@
+package gqlguard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestDocumentLimit(t *testing.T) {
+	srv := gqltesting.NewServer(testschema.New(schema, nil), DocumentLimit{MaxBytes: 100, MaxTokens: 10})
+
+	res := gqltesting.Post(srv, "# a comment is not a token\n{ me { name } }")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `{"data":{"me":{"name":"name"}}}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `{ me { name a: name b: name } }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"document exceeds the maximum of 10 tokens","extensions":{"code":"TOO_MANY_TOKENS"}}],"data":null}`, res.Body.String())
+
+	res = gqltesting.Post(srv, `{ me { name } }`+strings.Repeat(" ", 100))
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"document exceeds the maximum size of 100 bytes","extensions":{"code":"DOCUMENT_TOO_LARGE"}}],"data":null}`, res.Body.String())
+}
+
+func TestMaxBodySize(t *testing.T) {
+	h := MaxBodySize(64, gqltesting.NewServer(testschema.New(schema, nil), DocumentLimit{}))
+
+	res := gqltesting.Post(h, `{ me { name } }`)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	res = gqltesting.Post(h, `{ me { name } }`+strings.Repeat(" ", 64))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"request body exceeds the maximum size of 64 bytes","extensions":{"code":"DOCUMENT_TOO_LARGE"}}],"data":null}`, res.Body.String())
+
+	// chunked bodies, without content length
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ me { name } }`+strings.Repeat(" ", 64)+`"}`))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -0,0 +1,65 @@
+package gqlguard
+
+import (
+	"context"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of the guards.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(GuardViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the guards
+func UnregisterViews() {
+	view.Unregister(GuardViews...)
+}
+
+var (
+	// GuardViews contains all opencensus stats views declared by the guards
+	GuardViews = []*view.View{
+		RejectionCountView,
+	}
+
+	// measurements
+
+	// Rejections tracks a count of operations rejected by a guard
+	Rejections = stats.Int64(
+		"gql/guard/rejection_count",
+		"Number of GraphQL operations rejected by guards",
+		stats.UnitDimensionless)
+
+	// views
+
+	// RejectionCountView reports a count of rejected operations, by guard, reason and operation name
+	RejectionCountView = &view.View{
+		Name:        "gql/guard/rejection_count",
+		Description: "Count of GraphQL operations rejected by guards, by guard and reason",
+		Measure:     Rejections,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagGuard, TagReason, metrics.TagOperation},
+	}
+
+	// TagGuard is the extension name of the guard rejecting an operation, e.g. "AliasLimit"
+	TagGuard = tag.MustNewKey("gql.guard")
+
+	// TagReason is the reason of a rejection, e.g. "total" or "selection_set"
+	TagReason = tag.MustNewKey("gql.guard.reason")
+)
+
+// reject records the rejection of the operation, and returns err
+func reject(ctx context.Context, opName, guard, reason string, err *gqlerror.Error) *gqlerror.Error {
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(TagGuard, guard), tag.Upsert(TagReason, reason), tag.Upsert(metrics.TagOperation, opName)},
+		Rejections.M(1),
+	)
+	return err
+}
@@ -0,0 +1,54 @@
+package gqlguard
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+)
+
+var suggestion = regexp.MustCompile(`\s*Did you mean [^?]*\?$`)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = HideSuggestions{}
+
+// HideSuggestions is a gqlgen extension removing "Did you mean ...?" suggestions from validation errors.
+//
+// Suggestions leak the names of types, fields and arguments: they allow mapping the schema even when
+// introspection is disabled.
+type HideSuggestions struct {
+	// Allow tells if the caller still gets suggestions, e.g. in development. By default, no caller gets suggestions.
+	Allow func(context.Context) bool
+}
+
+// ExtensionName yields the extension name: "HideSuggestions"
+func (HideSuggestions) ExtensionName() string {
+	return "HideSuggestions"
+}
+
+// Validate this extension. This is a noop
+func (HideSuggestions) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse removes suggestions from validation errors
+func (h HideSuggestions) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil || len(resp.Errors) == 0 || (h.Allow != nil && h.Allow(ctx)) {
+		return resp
+	}
+
+	for i, err := range resp.Errors {
+		if err == nil || err.Extensions["code"] != errcode.ValidationFailed || !suggestion.MatchString(err.Message) {
+			continue
+		}
+		// errors may be shared: rewrite a copy
+		stripped := *err
+		stripped.Message = suggestion.ReplaceAllString(err.Message, "")
+		resp.Errors[i] = &stripped
+	}
+	return resp
+}
@@ -0,0 +1,24 @@
+package gqlguard
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestHideSuggestions(t *testing.T) {
+	srv := gqltesting.NewServer(testschema.New(schema, nil), HideSuggestions{})
+
+	res := gqltesting.Post(srv, `{ me { nam } }`)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"Cannot query field \"nam\" on type \"User\".","locations":[{"line":1,"column":8}],"extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}],"data":null}`, res.Body.String())
+
+	srv = gqltesting.NewServer(testschema.New(schema, nil), HideSuggestions{Allow: func(context.Context) bool { return true }})
+	res = gqltesting.Post(srv, `{ me { nam } }`)
+	assert.Contains(t, res.Body.String(), `Did you mean \"name\"?`)
+}
@@ -0,0 +1,45 @@
+package gqlhealth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+)
+
+// StoreCheck checks that a cache store, e.g. the store of the response cache or of APQ, is operational: it writes
+// then reads back a short-lived probe key.
+func StoreCheck(store gqlcache.Store) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		value := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+		if err := store.Set(ctx, probeKey, value, time.Minute); err != nil {
+			return err
+		}
+		// replicas sharing the store may overwrite the value in between: only its presence matters
+		_, ok, err := store.Get(ctx, probeKey)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("probe key not found")
+		}
+		return nil
+	}
+}
+
+// DialCheck checks that a TCP address accepts connections, e.g. the agent or collector of a metrics or trace exporter
+func DialCheck(addr string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+const probeKey = "gqlhealth:probe"
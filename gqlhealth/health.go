@@ -0,0 +1,193 @@
+// Package gqlhealth serves the liveness and readiness of a GraphQL server, so that orchestrators only send traffic to
+// servers with an operational GraphQL stack:
+//
+//	health := gqlhealth.New(
+//		gqlhealth.Check("cache", gqlhealth.StoreCheck(store)),
+//		gqlhealth.Check("agent", gqlhealth.DialCheck("localhost:55678")),
+//	)
+//	srv.Use(health)
+//	http.Handle("/healthz", health.Liveness())
+//	http.Handle("/readyz", health)
+//
+// Reports are JSON documents with the schema hash, the extensions used, the number of operations served and the
+// result of each check:
+//
+//	{"status": "ok", "schema": "3f2a9c81d0e4", "extensions": ["Opencensustracing"], "operations": 42, "checks": {"cache": {"status": "ok", "durationMs": 0.4}}}
+//
+// The server is live once the schema is loaded, i.e. the extension is used on a server. It is ready when it is live
+// and all checks pass. Failing reports have a 503 status.
+package gqlhealth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const extensionName = "Health"
+
+// Statuses of reports and checks
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	http.Handler
+} = &Health{}
+
+type (
+	// Health is a gqlgen extension serving the readiness of the server
+	Health struct {
+		config
+		schema     atomic.Value
+		operations int64
+	}
+
+	// Report on the health of the server
+	Report struct {
+		Status     string                 `json:"status"`
+		Schema     string                 `json:"schema,omitempty"`
+		Extensions []string               `json:"extensions,omitempty"`
+		Operations int64                  `json:"operations"`
+		Checks     map[string]CheckResult `json:"checks,omitempty"`
+	}
+
+	// CheckResult is the outcome of a readiness check
+	CheckResult struct {
+		Status   string  `json:"status"`
+		Duration float64 `json:"durationMs"`
+		Error    string  `json:"error,omitempty"`
+	}
+)
+
+// New health extension and readiness handler
+func New(opts ...Option) *Health {
+	h := &Health{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&h.config)
+	}
+	return h
+}
+
+// ExtensionName yields the extension name: "Health"
+func (h *Health) ExtensionName() string {
+	return extensionName
+}
+
+// Validate records the hash of the schema: the server is live from then on
+func (h *Health) Validate(schema graphql.ExecutableSchema) error {
+	h.schema.Store(fingerprint.Schema(schema.Schema()).Short())
+	return nil
+}
+
+// InterceptResponse counts the operations served
+func (h *Health) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	atomic.AddInt64(&h.operations, 1)
+	return next(ctx)
+}
+
+// Liveness is the handler of the liveness of the server, without running checks
+func (h *Health) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, h.report())
+	})
+}
+
+// ServeHTTP serves the readiness of the server
+func (h *Health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.report()
+	if report.Status == StatusOK {
+		report.Checks = h.Check(r.Context())
+		for _, result := range report.Checks {
+			if result.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}
+	}
+	write(w, report)
+}
+
+// Check runs the readiness checks concurrently, each within the timeout
+func (h *Health) Check(ctx context.Context) map[string]CheckResult {
+	if len(h.checks) == 0 {
+		return nil
+	}
+
+	results := make(map[string]CheckResult, len(h.checks))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			result := run(ctx, c, h.timeout)
+			mu.Lock()
+			results[c.name] = result
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	return results
+}
+
+// report on the liveness of the server
+func (h *Health) report() Report {
+	schema, _ := h.schema.Load().(string)
+	report := Report{
+		Status:     StatusOK,
+		Schema:     schema,
+		Extensions: h.extensions,
+		Operations: atomic.LoadInt64(&h.operations),
+	}
+	if schema == "" {
+		report.Status = StatusUnavailable
+	}
+	return report
+}
+
+func run(ctx context.Context, c check, timeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		// checks ignoring their context are abandoned
+		err = ctx.Err()
+	}
+
+	result := CheckResult{Status: StatusOK, Duration: float64(time.Since(start)) / float64(time.Millisecond)}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func write(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
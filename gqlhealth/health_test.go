@@ -0,0 +1,84 @@
+package gqlhealth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqlservertiming"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func serve(h http.Handler) (int, string) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	return w.Code, w.Body.String()
+}
+
+func TestHealth(t *testing.T) {
+	failing := errors.New("connection refused")
+	health := New(
+		Extensions(gqlservertiming.New()),
+		Check("cache", StoreCheck(gqlcache.NewMemoryStore(10))),
+		Check("exporter", func(ctx context.Context) error { return failing }),
+	)
+
+	code, body := serve(health.Liveness())
+	assert.Equal(t, http.StatusServiceUnavailable, code, "the schema is not loaded yet")
+	assert.JSONEq(t, `{"status": "unavailable", "extensions": ["ServerTiming"], "operations": 0}`, body)
+
+	es := testschema.New(`type Query { todo: String }`, nil)
+	srv := handler.New(es)
+	srv.AddTransport(transport.POST{})
+	srv.Use(health)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ todo }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+	schema := fingerprint.Schema(es.Schema()).Short()
+
+	code, body = serve(health.Liveness())
+	assert.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"status": "ok", "schema": "`+schema+`", "extensions": ["ServerTiming"], "operations": 1}`, body)
+
+	code, _ = serve(health)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	checks := health.Check(context.Background())
+	assert.Equal(t, StatusOK, checks["cache"].Status)
+	assert.Equal(t, CheckResult{Status: StatusUnavailable, Duration: checks["exporter"].Duration, Error: "connection refused"}, checks["exporter"])
+
+	failing = nil
+	code, _ = serve(health)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestTimeout(t *testing.T) {
+	health := New(Timeout(10*time.Millisecond), Check("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+	result := health.Check(context.Background())["stuck"]
+	assert.Equal(t, StatusUnavailable, result.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), result.Error)
+}
+
+func TestDialCheck(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+
+	assert.NoError(t, DialCheck(addr)(context.Background()))
+	require.NoError(t, l.Close())
+	assert.Error(t, DialCheck(addr)(context.Background()))
+}
@@ -0,0 +1,56 @@
+package gqlhealth
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the health handler
+type Option func(*config)
+
+type (
+	config struct {
+		checks     []check
+		extensions []string
+		timeout    time.Duration
+	}
+
+	check struct {
+		name string
+		fn   func(ctx context.Context) error
+	}
+)
+
+func defaultConfig() config {
+	return config{
+		timeout: 2 * time.Second,
+	}
+}
+
+// Check adds a named readiness check, e.g. StoreCheck or DialCheck. The server is not ready while a check fails.
+func Check(name string, fn func(ctx context.Context) error) Option {
+	return func(c *config) {
+		c.checks = append(c.checks, check{name: name, fn: fn})
+	}
+}
+
+// Extensions lists the extensions used on the server in the report, e.g. as ordered by contrib.Order:
+//
+//	exts, err := contrib.Order(metrics.New(), gqlopencensus.New())
+//	health := gqlhealth.New(gqlhealth.Extensions(exts...))
+func Extensions(exts ...graphql.HandlerExtension) Option {
+	return func(c *config) {
+		for _, ext := range exts {
+			c.extensions = append(c.extensions, ext.ExtensionName())
+		}
+	}
+}
+
+// Timeout bounds the duration of each check. The default is 2s.
+func Timeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
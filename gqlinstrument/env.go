@@ -0,0 +1,46 @@
+package gqlinstrument
+
+import (
+	"github.com/99designs/gqlgen-contrib/internal/env"
+)
+
+// Environment variables of the shared options
+const (
+	// EnvHost sets the Host, e.g. GQL_HOST=checkout
+	EnvHost = "GQL_HOST"
+	// EnvOnlyMethods sets OnlyMethods, e.g. GQL_ONLY_METHODS=false
+	EnvOnlyMethods = "GQL_ONLY_METHODS"
+	// EnvSkipIntrospection sets SkipIntrospection, e.g. GQL_SKIP_INTROSPECTION=true
+	EnvSkipIntrospection = "GQL_SKIP_INTROSPECTION"
+	// EnvSchemaHash sets SchemaHash, e.g. GQL_SCHEMA_HASH=true
+	EnvSchemaHash = "GQL_SCHEMA_HASH"
+	// EnvFieldSample sets a ProbabilitySampler of fields, e.g. GQL_FIELD_SAMPLE=0.1
+	EnvFieldSample = "GQL_FIELD_SAMPLE"
+)
+
+// FromEnv yields the shared options set by environment variables. Unset variables leave the defaults unchanged.
+func FromEnv() ([]Option, error) {
+	return fromEnv(env.New())
+}
+
+func fromEnv(r *env.Reader) ([]Option, error) {
+	var opts []Option
+	r.String(EnvHost, func(host string) {
+		opts = append(opts, Host(host))
+	})
+	r.Bool(EnvOnlyMethods, func(enabled bool) {
+		opts = append(opts, OnlyMethods(enabled))
+	})
+	r.Bool(EnvSkipIntrospection, func(skip bool) {
+		opts = append(opts, func(c *Config) { c.SkipIntrospection = skip })
+	})
+	r.Bool(EnvSchemaHash, func(enabled bool) {
+		if enabled {
+			opts = append(opts, SchemaHash())
+		}
+	})
+	r.Fraction(EnvFieldSample, func(fraction float64) {
+		opts = append(opts, WithSampler(ProbabilitySampler(fraction)))
+	})
+	return opts, r.Err()
+}
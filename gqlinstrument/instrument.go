@@ -0,0 +1,179 @@
+// Package gqlinstrument holds the options shared by the instrumentation extensions, so the same options apply to the
+// opencensus tracer and metrics collector alike:
+//
+//	common := []gqlinstrument.Option{
+//		gqlinstrument.Host("checkout"),
+//		gqlinstrument.SkipIntrospection(),
+//		gqlinstrument.Tags(gqlinstrument.Tag{Key: clientinfo.TagClientID, Value: clientinfo.ClientID}),
+//	}
+//	srv.Use(gqlopencensus.New(gqlopencensus.Common(common...)))
+//	srv.Use(metrics.New(metrics.Common(common...)))
+package gqlinstrument
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/tag"
+)
+
+type (
+	// Option shared by instrumentation extensions
+	Option func(*Config)
+
+	// Config shared by instrumentation extensions
+	Config struct {
+		// Host names the GraphQL server in spans and metrics
+		Host string
+
+		// OnlyMethods instruments only the fields which correspond to a method of the resolver. This is the default.
+		OnlyMethods bool
+
+		// SkipIntrospection leaves the fields of introspection queries out of the instrumentation
+		SkipIntrospection bool
+
+		// Sampler decides which operations get their fields instrumented. By default, all operations do.
+		Sampler Sampler
+
+		// Tags extracted from the context of requests
+		Tags []Tag
+
+		// schema holds the hash of the schema, with the SchemaHash option
+		schema *schemaHash
+	}
+
+	// Sampler decides whether to instrument the fields of an operation
+	Sampler func(*graphql.OperationContext) bool
+
+	// Tag extracts the value of a tag from the context of a request, e.g. the client name. Empty values are skipped.
+	Tag struct {
+		Key   tag.Key
+		Value func(context.Context) string
+	}
+
+	// TagValue is the value of a Tag, extracted from a request
+	TagValue struct {
+		Key   tag.Key
+		Value string
+	}
+
+	sampledKey struct{}
+)
+
+// Default configuration
+func Default() Config {
+	return Config{OnlyMethods: true}
+}
+
+// Host determines the name of the GraphQL server in spans and metrics
+func Host(hostname string) Option {
+	return func(c *Config) {
+		c.Host = hostname
+	}
+}
+
+// OnlyMethods when enabled, instruments only the fields which correspond to a method of the resolver. This is the
+// default. When set to false, all fields are instrumented.
+func OnlyMethods(enabled bool) Option {
+	return func(c *Config) {
+		c.OnlyMethods = enabled
+	}
+}
+
+// SkipIntrospection leaves the fields of introspection queries out of the instrumentation
+func SkipIntrospection() Option {
+	return func(c *Config) {
+		c.SkipIntrospection = true
+	}
+}
+
+// WithSampler instruments the fields of the operations selected by a sampler. Operations are instrumented regardless.
+func WithSampler(sampler Sampler) Option {
+	return func(c *Config) {
+		c.Sampler = sampler
+	}
+}
+
+// Tags adds tags extracted from the context of requests: they become span attributes and metrics tags.
+//
+// Metrics views must be registered with the keys of these tags, e.g. with metrics.RegisterWithTagKeys.
+func Tags(tags ...Tag) Option {
+	return func(c *Config) {
+		c.Tags = append(c.Tags, tags...)
+	}
+}
+
+// ProbabilitySampler samples a fraction of operations, e.g. 0.1
+func ProbabilitySampler(fraction float64) Sampler {
+	return probabilitySampler(func() float64 {
+		return fraction
+	})
+}
+
+func probabilitySampler(fraction func() float64) Sampler {
+	var mu sync.Mutex
+	rnd := rand.New(rand.NewSource(rand.Int63()))
+	return func(*graphql.OperationContext) bool {
+		f := fraction()
+		mu.Lock()
+		defer mu.Unlock()
+		return rnd.Float64() < f
+	}
+}
+
+// Sample decides whether the fields of an operation are instrumented, and keeps the decision in the context:
+// extensions sampling the same operation agree with the first one.
+func (c Config) Sample(ctx context.Context, rc *graphql.OperationContext) (context.Context, bool) {
+	if sampled, ok := ctx.Value(sampledKey{}).(bool); ok {
+		return ctx, sampled
+	}
+	if c.Sampler == nil {
+		return ctx, true
+	}
+	sampled := c.Sampler(rc)
+	return context.WithValue(ctx, sampledKey{}, sampled), sampled
+}
+
+// Sampled reports whether the fields of the operation are instrumented. This is true when no decision was made.
+func Sampled(ctx context.Context) bool {
+	sampled, ok := ctx.Value(sampledKey{}).(bool)
+	return sampled || !ok
+}
+
+// SkipField reports whether a field is left out of the instrumentation
+func (c Config) SkipField(ctx context.Context, fc *graphql.FieldContext) bool {
+	if c.OnlyMethods && !fc.IsMethod {
+		return true
+	}
+	if c.SkipIntrospection && Introspection(fc) {
+		return true
+	}
+	return !Sampled(ctx)
+}
+
+// TagValues extracts the non-empty values of tags from a request
+func (c Config) TagValues(ctx context.Context) []TagValue {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+	values := make([]TagValue, 0, len(c.Tags))
+	for _, t := range c.Tags {
+		if value := t.Value(ctx); value != "" {
+			values = append(values, TagValue{Key: t.Key, Value: value})
+		}
+	}
+	return values
+}
+
+// Introspection reports whether a field belongs to an introspection query, e.g. "__schema.types.name"
+func Introspection(fc *graphql.FieldContext) bool {
+	for ; fc != nil; fc = fc.Parent {
+		if fc.Field.Field != nil && strings.HasPrefix(fc.Field.Name, "__") {
+			return true
+		}
+	}
+	return false
+}
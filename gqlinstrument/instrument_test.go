@@ -0,0 +1,117 @@
+package gqlinstrument
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/env"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestSample(t *testing.T) {
+	rc := gqltesting.Operation(`{ todos { text } }`).Build()
+	ctx := context.Background()
+	assert.True(t, Sampled(ctx))
+
+	calls := 0
+	never := Default()
+	WithSampler(func(*graphql.OperationContext) bool { calls++; return false })(&never)
+	always := Default()
+
+	ctx, sampled := never.Sample(ctx, rc)
+	assert.False(t, sampled)
+	assert.False(t, Sampled(ctx))
+
+	// later extensions agree with the first decision
+	_, sampled = always.Sample(ctx, rc)
+	assert.False(t, sampled)
+	_, _ = never.Sample(ctx, rc)
+	assert.Equal(t, 1, calls)
+
+	_, sampled = always.Sample(context.Background(), rc)
+	assert.True(t, sampled)
+
+	assert.True(t, ProbabilitySampler(1)(rc))
+	assert.False(t, ProbabilitySampler(0)(rc))
+}
+
+func TestSkipField(t *testing.T) {
+	c := Default()
+	SkipIntrospection()(&c)
+	ctx := context.Background()
+
+	assert.False(t, c.SkipField(ctx, gqltesting.Field("todos").Resolver().Build()))
+	assert.True(t, c.SkipField(ctx, gqltesting.Field("todos", 0, "text").Build()))
+	assert.True(t, c.SkipField(ctx, gqltesting.Field("__schema", "types").Method().Build()))
+
+	OnlyMethods(false)(&c)
+	assert.False(t, c.SkipField(ctx, gqltesting.Field("todos", 0, "text").Build()))
+
+	unsampled := context.WithValue(ctx, sampledKey{}, false)
+	assert.True(t, c.SkipField(unsampled, gqltesting.Field("todos").Resolver().Build()))
+}
+
+func TestTagValues(t *testing.T) {
+	client := tag.MustNewKey("gql.client")
+	c := Default()
+	Tags(
+		Tag{Key: client, Value: func(context.Context) string { return "web" }},
+		Tag{Key: tag.MustNewKey("gql.empty"), Value: func(context.Context) string { return "" }},
+	)(&c)
+
+	assert.Equal(t, []TagValue{{Key: client, Value: "web"}}, c.TagValues(context.Background()))
+	assert.Nil(t, Default().TagValues(context.Background()))
+}
+
+func TestFromEnv(t *testing.T) {
+	vars := map[string]string{EnvHost: "checkout", EnvSkipIntrospection: "true", EnvFieldSample: "0"}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+	opts, err := fromEnv(env.NewWithLookup(lookup))
+	assert.NoError(t, err)
+
+	c := Default()
+	for _, apply := range opts {
+		apply(&c)
+	}
+	assert.Equal(t, "checkout", c.Host)
+	assert.True(t, c.OnlyMethods)
+	assert.True(t, c.SkipIntrospection)
+	_, sampled := c.Sample(context.Background(), gqltesting.Operation(`{ todos { text } }`).Build())
+	assert.False(t, sampled)
+
+	vars[EnvOnlyMethods] = "maybe"
+	_, err = fromEnv(env.NewWithLookup(lookup))
+	assert.EqualError(t, err, `GQL_ONLY_METHODS: invalid boolean "maybe"`)
+}
+
+func TestSchemaHash(t *testing.T) {
+	c := Default()
+	SchemaHash()(&c)
+	ctx := context.Background()
+	assert.Empty(t, c.TagValues(ctx))
+
+	schema := testschema.New(`type Query { todos: [Todo] } type Todo { text: String }`, nil)
+	assert.NoError(t, c.Validate(schema))
+	hash := fingerprint.Schema(schema.Schema()).Short()
+	assert.Len(t, hash, 12)
+	assert.Equal(t, []TagValue{{Key: TagSchema, Value: hash}}, c.TagValues(ctx))
+
+	reordered := testschema.New(`
+		type Todo { text: String }
+		type Query { todos: [Todo] }
+	`, nil)
+	assert.Equal(t, hash, fingerprint.Schema(reordered.Schema()).Short())
+	changed := testschema.New(`type Query { todos: [Todo] } type Todo { text: String done: Boolean }`, nil)
+	assert.NotEqual(t, hash, fingerprint.Schema(changed.Schema()).Short())
+
+	assert.NoError(t, Default().Validate(schema))
+}
@@ -0,0 +1,45 @@
+package gqlinstrument
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+// TagSchema is the short hash of the executable schema, with the SchemaHash option
+var TagSchema = tag.MustNewKey("gql.schema")
+
+// schemaHash holds the hash of the schema, computed when extensions are validated
+type schemaHash struct {
+	hash atomic.Value
+}
+
+func (h *schemaHash) value(context.Context) string {
+	hash, _ := h.hash.Load().(string)
+	return hash
+}
+
+// SchemaHash tags spans, metrics and log lines with a short hash of the executable schema (TagSchema), computed
+// when the extensions are validated, e.g. to correlate shifts of latency or errors with schema deployments.
+//
+// Metrics views must be registered with TagSchema, e.g. with metrics.RegisterWithTagKeys.
+func SchemaHash() Option {
+	h := &schemaHash{}
+	return func(c *Config) {
+		c.schema = h
+		c.Tags = append(c.Tags, Tag{Key: TagSchema, Value: h.value})
+	}
+}
+
+// Validate computes the hash of the schema, with the SchemaHash option. Extensions call it from their own Validate.
+func (c Config) Validate(schema graphql.ExecutableSchema) error {
+	if c.schema == nil || schema == nil {
+		return nil
+	}
+	c.schema.hash.Store(fingerprint.Schema(schema.Schema()).Short())
+	return nil
+}
@@ -0,0 +1,183 @@
+package gqlinstrument
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/trace"
+)
+
+type (
+	// Settings are tuned at runtime, without a deploy, and read by extensions on every request
+	Settings struct {
+		mu     sync.Mutex // serializes updates
+		values atomic.Value
+	}
+
+	// Values of the runtime settings
+	Values struct {
+		// TraceSample is the fraction of traced operations, with TraceSampler
+		TraceSample float64 `json:"trace_sample"`
+
+		// FieldSample is the fraction of operations with instrumented fields, with FieldSampler
+		FieldSample float64 `json:"field_sample"`
+
+		// SlowQuery is the latency above which operations are slow, e.g. "2s". 0 disables slow operations.
+		SlowQuery Duration `json:"slow_query"`
+
+		// ComplexityLimit is the maximum cost of operations, with ComplexityLimit. 0 means unlimited.
+		ComplexityLimit int `json:"complexity_limit"`
+	}
+
+	// Duration is a time.Duration, as a string in JSON, e.g. "2s"
+	Duration time.Duration
+)
+
+// DefaultValues of the runtime settings: traces follow the default sampling of opencensus, all fields are
+// instrumented, and operations are neither slow nor limited
+func DefaultValues() Values {
+	return Values{
+		TraceSample: 1e-4,
+		FieldSample: 1,
+	}
+}
+
+// NewSettings with initial values
+func NewSettings(values Values) (*Settings, error) {
+	if err := values.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Settings{}
+	s.values.Store(values)
+	return s, nil
+}
+
+// Load the current values
+func (s *Settings) Load() Values {
+	return s.values.Load().(Values)
+}
+
+// Store new values, unless they are invalid
+func (s *Settings) Store(values Values) error {
+	if err := values.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values.Store(values)
+	return nil
+}
+
+// Update some of the values, e.g. from a JSON patch, unless they become invalid
+func (s *Settings) Update(update func(*Values) error) (Values, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := s.Load()
+	if err := update(&values); err != nil {
+		return Values{}, err
+	}
+	if err := values.Validate(); err != nil {
+		return Values{}, err
+	}
+	s.values.Store(values)
+	return values, nil
+}
+
+// Validate values
+func (v Values) Validate() error {
+	if v.TraceSample < 0 || v.TraceSample > 1 {
+		return fmt.Errorf("trace_sample: %v is not between 0 and 1", v.TraceSample)
+	}
+	if v.FieldSample < 0 || v.FieldSample > 1 {
+		return fmt.Errorf("field_sample: %v is not between 0 and 1", v.FieldSample)
+	}
+	if v.SlowQuery < 0 {
+		return fmt.Errorf("slow_query: %s is negative", time.Duration(v.SlowQuery))
+	}
+	if v.ComplexityLimit < 0 {
+		return fmt.Errorf("complexity_limit: %d is negative, expecting 0 for unlimited", v.ComplexityLimit)
+	}
+	return nil
+}
+
+// TraceSampler samples traces at the current TraceSample, e.g. with gqlopencensus.WithSampler
+func (s *Settings) TraceSampler() trace.Sampler {
+	return func(p trace.SamplingParameters) trace.SamplingDecision {
+		return trace.ProbabilitySampler(s.Load().TraceSample)(p)
+	}
+}
+
+// FieldSampler samples the fields of operations at the current FieldSample, e.g. with WithSampler
+func (s *Settings) FieldSampler() Sampler {
+	return probabilitySampler(func() float64 {
+		return s.Load().FieldSample
+	})
+}
+
+// Slow reports whether an operation of some latency is slow
+func (s *Settings) Slow(latency time.Duration) bool {
+	threshold := time.Duration(s.Load().SlowQuery)
+	return threshold > 0 && latency > threshold
+}
+
+// ComplexityLimit yields the current ComplexityLimit, e.g. with gqlcomplexity.LimitFunc
+func (s *Settings) ComplexityLimit(context.Context, *graphql.OperationContext) int {
+	return s.Load().ComplexityLimit
+}
+
+// Handler exposes the settings, e.g. to operators. It must be protected by the caller.
+//
+//	GET    yields the current values, in JSON
+//	PATCH  updates the values present in a JSON body, e.g. {"trace_sample": 0.5}, and yields the new values
+func (s *Settings) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var values Values
+		switch r.Method {
+		case http.MethodGet:
+			values = s.Load()
+		case http.MethodPatch:
+			var err error
+			values, err = s.Update(func(v *Values) error {
+				dec := json.NewDecoder(r.Body)
+				dec.DisallowUnknownFields()
+				return dec.Decode(v)
+			})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PATCH")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(values)
+	})
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration %s, expecting e.g. \"2s\"", data)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, expecting e.g. \"2s\"", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,77 @@
+package gqlinstrument
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+)
+
+func TestSettings(t *testing.T) {
+	settings, err := NewSettings(DefaultValues())
+	require.NoError(t, err)
+	rc := gqltesting.Operation(`{ todos { text } }`).Build()
+
+	fields := settings.FieldSampler()
+	traces := settings.TraceSampler()
+	assert.True(t, fields(rc))
+	assert.False(t, settings.Slow(time.Hour))
+	assert.Equal(t, 0, settings.ComplexityLimit(context.Background(), rc))
+
+	require.NoError(t, settings.Store(Values{TraceSample: 1, SlowQuery: Duration(time.Second), ComplexityLimit: 100}))
+	assert.False(t, fields(rc))
+	assert.True(t, traces(trace.SamplingParameters{}).Sample)
+	assert.True(t, settings.Slow(2*time.Second))
+	assert.False(t, settings.Slow(time.Second))
+	assert.Equal(t, 100, settings.ComplexityLimit(context.Background(), rc))
+
+	assert.EqualError(t, settings.Store(Values{TraceSample: 2}), "trace_sample: 2 is not between 0 and 1")
+	assert.Equal(t, 1.0, settings.Load().TraceSample)
+
+	_, err = NewSettings(Values{ComplexityLimit: -1})
+	assert.EqualError(t, err, "complexity_limit: -1 is negative, expecting 0 for unlimited")
+}
+
+func TestSettingsHandler(t *testing.T) {
+	settings, err := NewSettings(DefaultValues())
+	require.NoError(t, err)
+	handler := settings.Handler()
+	do := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, "/settings", strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"trace_sample": 0.0001, "field_sample": 1, "slow_query": "0s", "complexity_limit": 0}`, w.Body.String())
+
+	w = do(http.MethodPatch, `{"trace_sample": 0.5, "slow_query": "2s"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"trace_sample": 0.5, "field_sample": 1, "slow_query": "2s", "complexity_limit": 0}`, w.Body.String())
+	assert.Equal(t, Duration(2*time.Second), settings.Load().SlowQuery)
+
+	for body, expected := range map[string]string{
+		`{"trace_sample": 5}`:      "trace_sample: 5 is not between 0 and 1\n",
+		`{"slow_query": "later"}`:  "invalid duration \"later\", expecting e.g. \"2s\"\n",
+		`{"sample": 0.1}`:          "json: unknown field \"sample\"\n",
+		`{"complexity_limit": -1}`: "complexity_limit: -1 is negative, expecting 0 for unlimited\n",
+	} {
+		w = do(http.MethodPatch, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Equal(t, expected, w.Body.String(), body)
+	}
+	assert.Equal(t, 0.5, settings.Load().TraceSample, "invalid patches are discarded")
+
+	w = do(http.MethodPut, `{}`)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, PATCH", w.Header().Get("Allow"))
+}
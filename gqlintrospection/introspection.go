@@ -0,0 +1,119 @@
+// Package gqlintrospection gates GraphQL introspection: globally, by environment, or by caller role.
+//
+// The extension replaces the gqlgen Introspection extension: introspection is enabled for the operations
+// it allows, and other introspection queries are rejected with an error.
+//
+//	srv.Use(gqlintrospection.New(
+//		gqlintrospection.EnabledInEnvironments("APP_ENV", "dev", "staging"),
+//		gqlintrospection.AllowRoles(rolesFromContext, "admin"),
+//	))
+//
+// Introspection is allowed when all conditions are met. The __typename field is always allowed.
+package gqlintrospection
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "IntrospectionGate"
+
+	// ErrDisabledCode is the error code of the default error when introspection is blocked
+	ErrDisabledCode = "INTROSPECTION_DISABLED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrDisabledCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Gate{}
+
+// Gate is a gqlgen extension enabling introspection for allowed callers only
+type Gate struct {
+	config
+}
+
+// New introspection gate. Without options, introspection is enabled for all callers.
+func New(opts ...Option) *Gate {
+	g := &Gate{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&g.config)
+	}
+	return g
+}
+
+// ExtensionName yields the extension name: "IntrospectionGate"
+func (g *Gate) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (g *Gate) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext enables introspection for allowed callers, and rejects introspection queries from others
+func (g *Gate) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if g.Allowed(ctx) {
+		rc.DisableIntrospection = false
+		return nil
+	}
+
+	rc.DisableIntrospection = true
+	if rc.Operation == nil || !isIntrospection(rc.Operation.SelectionSet) {
+		return nil
+	}
+
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(metrics.TagOperation, fingerprint.OperationName(rc))},
+		BlockedCount.M(1),
+	)
+	return g.errorFunc(ctx)
+}
+
+// Allowed tells if introspection is allowed for the caller
+func (g *Gate) Allowed(ctx context.Context) bool {
+	if !g.enabled {
+		return false
+	}
+	for _, allow := range g.rules {
+		if !allow(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// isIntrospection tells if root fields include __schema or __type, expanding fragments
+func isIntrospection(set ast.SelectionSet) bool {
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			if sel.Name == "__schema" || sel.Name == "__type" {
+				return true
+			}
+		case *ast.InlineFragment:
+			if isIntrospection(sel.SelectionSet) {
+				return true
+			}
+		case *ast.FragmentSpread:
+			if sel.Definition != nil && isIntrospection(sel.Definition.SelectionSet) {
+				return true
+			}
+		}
+	}
+	return false
+}
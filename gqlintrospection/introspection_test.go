@@ -0,0 +1,74 @@
+package gqlintrospection
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+type rolesKey struct{}
+
+func roles(ctx context.Context) []string {
+	r, _ := ctx.Value(rolesKey{}).([]string)
+	return r
+}
+
+func TestGate(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	es := testschema.New(`type Query { introspection: Boolean }`, testschema.Resolvers{
+		"Query.introspection": func(ctx context.Context) (interface{}, error) {
+			return !graphql.GetOperationContext(ctx).DisableIntrospection, nil
+		},
+	})
+	srv := handler.New(es)
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(AllowRoles(roles, "admin")))
+
+	do := func(query string, r ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(context.WithValue(req.Context(), rolesKey{}, r))
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, `{"data":{"introspection":true}}`, do(`{ introspection }`, "admin").Body.String())
+	assert.Equal(t, `{"data":{"__typename":"Query","introspection":false}}`, do(`{ __typename introspection }`, "user").Body.String())
+
+	res := do(`query Schema { ...f } fragment f on Query { __schema { queryType { name } } }`, "user")
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.JSONEq(t, `{"errors":[{"message":"introspection is disabled","extensions":{"code":"INTROSPECTION_DISABLED"}}],"data":null}`, res.Body.String())
+
+	rows, err := view.RetrieveData(BlockedCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Schema", rows[0].Tags[0].Value)
+	assert.Equal(t, int64(1), rows[0].Data.(*view.CountData).Value)
+}
+
+func TestAllowed(t *testing.T) {
+	ctx := context.Background()
+	assert.True(t, New().Allowed(ctx))
+	assert.False(t, New(Disabled()).Allowed(ctx))
+	assert.False(t, New(AllowFunc(func(context.Context) bool { return false })).Allowed(ctx))
+
+	os.Setenv("GQLINTROSPECTION_TEST_ENV", "dev")
+	defer os.Unsetenv("GQLINTROSPECTION_TEST_ENV")
+	assert.True(t, New(EnabledInEnvironments("GQLINTROSPECTION_TEST_ENV", "dev", "staging")).Allowed(ctx))
+	assert.False(t, New(EnabledInEnvironments("GQLINTROSPECTION_TEST_ENV", "staging")).Allowed(ctx))
+}
@@ -0,0 +1,47 @@
+package gqlintrospection
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of the introspection gate.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(IntrospectionViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the introspection gate
+func UnregisterViews() {
+	view.Unregister(IntrospectionViews...)
+}
+
+var (
+	// IntrospectionViews contains all opencensus stats views declared by the introspection gate
+	IntrospectionViews = []*view.View{
+		BlockedCountView,
+	}
+
+	// measurements
+
+	// BlockedCount tracks a count of blocked introspection queries
+	BlockedCount = stats.Int64(
+		"gql/introspection/blocked_count",
+		"Number of blocked GraphQL introspection queries",
+		stats.UnitDimensionless)
+
+	// views
+
+	// BlockedCountView reports a count of blocked introspection queries, by operation name
+	BlockedCountView = &view.View{
+		Name:        "gql/introspection/blocked_count",
+		Description: "Count of blocked GraphQL introspection queries by operation",
+		Measure:     BlockedCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
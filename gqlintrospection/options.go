@@ -0,0 +1,87 @@
+package gqlintrospection
+
+import (
+	"context"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Option for the introspection gate
+type Option func(*config)
+
+// RoleExtractor retrieves the roles or claims of the caller from the request context
+type RoleExtractor func(context.Context) []string
+
+type config struct {
+	enabled   bool
+	rules     []func(context.Context) bool
+	errorFunc func(context.Context) *gqlerror.Error
+}
+
+func defaultConfig() config {
+	return config{
+		enabled: true,
+		errorFunc: func(context.Context) *gqlerror.Error {
+			err := gqlerror.Errorf("introspection is disabled")
+			errcode.Set(err, ErrDisabledCode)
+			return err
+		},
+	}
+}
+
+// Disabled disables introspection for all callers
+func Disabled() Option {
+	return func(c *config) {
+		c.enabled = false
+	}
+}
+
+// EnabledInEnvironments enables introspection only when the environment variable is set to one of environments,
+// e.g. EnabledInEnvironments("APP_ENV", "dev", "staging"). The variable is read once, when the extension is created.
+func EnabledInEnvironments(variable string, environments ...string) Option {
+	return func(c *config) {
+		current := os.Getenv(variable)
+		for _, env := range environments {
+			if env == current {
+				return
+			}
+		}
+		c.enabled = false
+	}
+}
+
+// AllowRoles enables introspection only for callers with at least one of the roles or claims, as retrieved by extract
+func AllowRoles(extract RoleExtractor, roles ...string) Option {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(c *config) {
+		c.rules = append(c.rules, func(ctx context.Context) bool {
+			for _, role := range extract(ctx) {
+				if _, ok := allowed[role]; ok {
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
+
+// AllowFunc enables introspection only for callers accepted by allow
+func AllowFunc(allow func(context.Context) bool) Option {
+	return func(c *config) {
+		c.rules = append(c.rules, allow)
+	}
+}
+
+// ErrorFunc builds the error returned when introspection is blocked.
+// The default is "introspection is disabled", with the code INTROSPECTION_DISABLED.
+func ErrorFunc(errorFunc func(context.Context) *gqlerror.Error) Option {
+	return func(c *config) {
+		c.errorFunc = errorFunc
+	}
+}
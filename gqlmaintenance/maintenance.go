@@ -0,0 +1,127 @@
+// Package gqlmaintenance puts the write path of a GraphQL server in read-only mode, e.g. during migrations.
+//
+// In maintenance, mutations (and optionally subscriptions) are rejected with a MAINTENANCE error, while queries
+// are still served. Maintenance is toggled at runtime, with Enable and Disable, or with the admin handler:
+//
+//	maintenance := gqlmaintenance.New()
+//	srv.Use(maintenance)
+//	http.Handle("/admin/maintenance", maintenance.Handler())
+package gqlmaintenance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	extensionName = "Maintenance"
+
+	// ErrMaintenanceCode is the error code of operations rejected during maintenance
+	ErrMaintenanceCode = "MAINTENANCE"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrMaintenanceCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Mode{}
+
+// Mode is a gqlgen extension rejecting write operations during maintenance
+type Mode struct {
+	config
+	enabled int32
+}
+
+// New maintenance extension, disabled by default
+func New(opts ...Option) *Mode {
+	m := &Mode{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&m.config)
+	}
+	if m.enabledOnStart {
+		m.Enable()
+	}
+	return m
+}
+
+// ExtensionName yields the extension name: "Maintenance"
+func (m *Mode) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (m *Mode) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Enable maintenance
+func (m *Mode) Enable() {
+	atomic.StoreInt32(&m.enabled, 1)
+}
+
+// Disable maintenance
+func (m *Mode) Disable() {
+	atomic.StoreInt32(&m.enabled, 0)
+}
+
+// Enabled tells if maintenance is enabled
+func (m *Mode) Enabled() bool {
+	return atomic.LoadInt32(&m.enabled) == 1
+}
+
+// MutateOperationContext rejects mutations, and optionally subscriptions, during maintenance
+func (m *Mode) MutateOperationContext(_ context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if !m.Enabled() || rc.Operation == nil {
+		return nil
+	}
+
+	switch rc.Operation.Operation {
+	case ast.Mutation:
+	case ast.Subscription:
+		if !m.subscriptions {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	err := gqlerror.ErrorPosf(rc.Operation.Position, "%s", m.message)
+	errcode.Set(err, ErrMaintenanceCode)
+	return err
+}
+
+// Handler toggles maintenance. It must be protected by the caller.
+//
+//	GET     yields the state of maintenance, in JSON
+//	PUT     enables maintenance
+//	DELETE  disables maintenance
+func (m *Mode) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			m.Enable()
+		case http.MethodDelete:
+			m.Disable()
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Enabled bool `json:"enabled"`
+		}{Enabled: m.Enabled()})
+	})
+}
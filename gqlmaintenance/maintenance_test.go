@@ -0,0 +1,41 @@
+package gqlmaintenance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = `
+type Query { hello: String }
+type Mutation { save: String }
+`
+
+func TestMode(t *testing.T) {
+	m := New()
+	srv := gqltesting.NewServer(testschema.New(schema, nil), m)
+
+	assert.Equal(t, `{"data":{"save":"save"}}`, gqltesting.Post(srv, `mutation { save }`).Body.String())
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", nil))
+	assert.JSONEq(t, `{"enabled":true}`, w.Body.String())
+
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, `{ hello }`).Body.String())
+	assert.JSONEq(t, `{
+		"errors":[{
+			"message":"the service is under maintenance: only read operations are available",
+			"locations":[{"line":1,"column":1}],
+			"extensions":{"code":"MAINTENANCE"}
+		}],
+		"data":null
+	}`, gqltesting.Post(srv, `mutation { save }`).Body.String())
+
+	m.Disable()
+	assert.Equal(t, `{"data":{"save":"save"}}`, gqltesting.Post(srv, `mutation { save }`).Body.String())
+}
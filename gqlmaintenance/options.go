@@ -0,0 +1,37 @@
+package gqlmaintenance
+
+// Option for the maintenance extension
+type Option func(*config)
+
+type config struct {
+	enabledOnStart bool
+	subscriptions  bool
+	message        string
+}
+
+func defaultConfig() config {
+	return config{
+		message: "the service is under maintenance: only read operations are available",
+	}
+}
+
+// EnabledOnStart enables maintenance as soon as the server starts
+func EnabledOnStart() Option {
+	return func(c *config) {
+		c.enabledOnStart = true
+	}
+}
+
+// IncludeSubscriptions rejects new subscriptions during maintenance. Running subscriptions are not interrupted.
+func IncludeSubscriptions() Option {
+	return func(c *config) {
+		c.subscriptions = true
+	}
+}
+
+// Message of the errors of operations rejected during maintenance
+func Message(message string) Option {
+	return func(c *config) {
+		c.message = message
+	}
+}
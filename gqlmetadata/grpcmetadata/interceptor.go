@@ -0,0 +1,43 @@
+// Package grpcmetadata provides the gRPC client interceptors of gqlmetadata, appending the GraphQL context of
+// resolvers to the outgoing metadata of calls:
+//
+//	propagator := gqlmetadata.New(gqlmetadata.TraceContext())
+//
+//	conn, err := grpc.Dial(target,
+//		grpc.WithUnaryInterceptor(grpcmetadata.UnaryClientInterceptor(propagator)),
+//		grpc.WithStreamInterceptor(grpcmetadata.StreamClientInterceptor(propagator)),
+//	)
+//
+// It lives apart from gqlmetadata, so that the propagator itself does not depend on grpc.
+package grpcmetadata
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetadata"
+)
+
+// UnaryClientInterceptor appends the metadata of a propagator to unary calls
+func UnaryClientInterceptor(p *gqlmetadata.Propagator) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx, p), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor appends the metadata of a propagator to streaming calls
+func StreamClientInterceptor(p *gqlmetadata.Propagator) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx, p), desc, cc, method, opts...)
+	}
+}
+
+func outgoing(ctx context.Context, p *gqlmetadata.Propagator) context.Context {
+	pairs := p.Pairs(ctx)
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
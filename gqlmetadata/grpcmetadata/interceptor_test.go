@@ -0,0 +1,50 @@
+package grpcmetadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/99designs/gqlgen-contrib/gqlmetadata"
+)
+
+func TestInterceptors(t *testing.T) {
+	propagator := gqlmetadata.New()
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "Orders", Operation: ast.Query},
+	})
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "token")
+	expected := metadata.Pairs("authorization", "token", gqlmetadata.KeyOperation, "Orders")
+
+	var sent metadata.MD
+	err := UnaryClientInterceptor(propagator)(ctx, "/orders.Orders/Count", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			sent, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, expected, sent)
+
+	sent = nil
+	_, err = StreamClientInterceptor(propagator)(ctx, &grpc.StreamDesc{}, nil, "/orders.Orders/Watch",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			sent, _ = metadata.FromOutgoingContext(ctx)
+			return nil, nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, expected, sent)
+
+	sent = nil
+	require.NoError(t, UnaryClientInterceptor(propagator)(context.Background(), "/orders.Orders/Count", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			sent, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		}))
+	assert.Nil(t, sent, "out of GraphQL operations")
+}
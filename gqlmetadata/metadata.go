@@ -0,0 +1,129 @@
+// Package gqlmetadata propagates the GraphQL context of resolvers to downstream services, as gRPC metadata, so that
+// their logs reference the originating operation and field.
+//
+// The package does not depend on grpc: the client interceptors of the grpcmetadata subpackage append the pairs of a
+// Propagator to the outgoing metadata:
+//
+//	propagator := gqlmetadata.New(gqlmetadata.TraceContext())
+//
+//	conn, err := grpc.Dial(target,
+//		grpc.WithUnaryInterceptor(grpcmetadata.UnaryClientInterceptor(propagator)),
+//		grpc.WithStreamInterceptor(grpcmetadata.StreamClientInterceptor(propagator)),
+//	)
+//
+// Downstream services read the operation name, request ID, field coordinate and path from the incoming metadata
+// keys of this package.
+package gqlmetadata
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/trace"
+	"go.opencensus.io/trace/propagation"
+
+	"github.com/99designs/gqlgen-contrib/coordinate"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/internal/fieldpath"
+)
+
+const (
+	// KeyOperation is the metadata key of the name of the GraphQL operation
+	KeyOperation = "x-graphql-operation"
+	// KeyRequestID is the metadata key of the ID of the request, from the request ID header
+	KeyRequestID = "x-request-id"
+	// KeyField is the metadata key of the coordinate of the field being resolved, e.g. "Query.user"
+	KeyField = "x-graphql-field"
+	// KeyPath is the metadata key of the path of the field being resolved, e.g. "users[1].orders"
+	KeyPath = "x-graphql-path"
+	// KeyTraceContext is the metadata key of the binary trace context, as propagated by ocgrpc
+	KeyTraceContext = "grpc-trace-bin"
+)
+
+type (
+	// Propagator yields the metadata of the GraphQL context of resolvers
+	Propagator struct {
+		config
+	}
+
+	// Option for the propagator
+	Option func(*config)
+
+	config struct {
+		requestIDHeader string
+		traceContext    bool
+	}
+)
+
+func defaultConfig() config {
+	return config{
+		requestIDHeader: "X-Request-ID",
+	}
+}
+
+// RequestIDHeader sets the header of GraphQL requests holding the request ID. The default is "X-Request-ID".
+func RequestIDHeader(name string) Option {
+	return func(c *config) {
+		c.requestIDHeader = name
+	}
+}
+
+// TraceContext adds the binary trace context of the span of the context to the metadata, for clients without ocgrpc
+func TraceContext() Option {
+	return func(c *config) {
+		c.traceContext = true
+	}
+}
+
+// New propagator
+func New(opts ...Option) *Propagator {
+	p := &Propagator{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&p.config)
+	}
+	return p
+}
+
+// Pairs yields the metadata of a context, as key and value pairs, e.g. for metadata.AppendToOutgoingContext. Pairs
+// are omitted out of GraphQL operations, or when their value is empty.
+func (p *Propagator) Pairs(ctx context.Context) []string {
+	var pairs []string
+	add := func(key, value string) {
+		if value != "" {
+			pairs = append(pairs, key, value)
+		}
+	}
+
+	if graphql.HasOperationContext(ctx) {
+		oc := graphql.GetOperationContext(ctx)
+		add(KeyOperation, fingerprint.OperationName(oc))
+		add(KeyRequestID, oc.Headers.Get(p.requestIDHeader))
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	for fc != nil && fc.Field.Field == nil {
+		// list elements resolve within the field of the list
+		fc = fc.Parent
+	}
+	if fc != nil {
+		add(KeyField, coordinate.Of(fc).String())
+		add(KeyPath, fieldpath.String(ctx, fc))
+	}
+
+	if p.traceContext {
+		if span := trace.FromContext(ctx); span != nil {
+			add(KeyTraceContext, string(propagation.Binary(span.SpanContext())))
+		}
+	}
+	return pairs
+}
+
+// Map yields the metadata of a context as a map, see Pairs
+func (p *Propagator) Map(ctx context.Context) map[string]string {
+	pairs := p.Pairs(ctx)
+	m := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		m[pairs[i]] = pairs[i+1]
+	}
+	return m
+}
@@ -0,0 +1,57 @@
+package gqlmetadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+	"go.opencensus.io/trace/propagation"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestPairs(t *testing.T) {
+	propagator := New(TraceContext())
+
+	var metadata map[string]string
+	var span trace.SpanContext
+	srv := handler.New(testschema.New(`type Query { user: User } type User { orders: Int }`, testschema.Resolvers{
+		"Query.user": func(context.Context) (interface{}, error) {
+			return map[string]interface{}{}, nil
+		},
+		"User.orders": func(ctx context.Context) (interface{}, error) {
+			ctx, s := trace.StartSpan(ctx, "orders.Count", trace.WithSampler(trace.AlwaysSample()))
+			defer s.End()
+			span = s.SpanContext()
+			metadata = propagator.Map(ctx)
+			return 3, nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query Orders { user { orders } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "req-1")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	traceContext, ok := propagation.FromBinary([]byte(metadata[KeyTraceContext]))
+	require.True(t, ok)
+	assert.Equal(t, span, traceContext)
+
+	delete(metadata, KeyTraceContext)
+	assert.Equal(t, map[string]string{
+		KeyOperation: "Orders",
+		KeyRequestID: "req-1",
+		KeyField:     "User.orders",
+		KeyPath:      "user.orders",
+	}, metadata)
+
+	assert.Empty(t, propagator.Pairs(context.Background()), "out of GraphQL operations")
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+type (
+	// fieldAggregate accumulates the measurements of the fields of a request with LiteFields, to record them in one
+	// flush per field when the response is complete.
+	//
+	// Lite fields are tagged by name and parent type only, so that the items of lists share their tags. Fields tagged
+	// with their path are recorded right away instead: paths are unique within a response, with nothing to batch.
+	fieldAggregate struct {
+		mu           sync.Mutex
+		measurements map[aggregated][]stats.Measurement
+	}
+
+	// aggregated identifies the measurements recorded together: the tags of a field, and the tags carried by the
+	// context of its resolver
+	aggregated struct {
+		field fieldKey
+		tags  *tag.Map
+	}
+
+	aggregateKey struct{}
+)
+
+func withFieldAggregate(ctx context.Context) (context.Context, *fieldAggregate) {
+	a := &fieldAggregate{measurements: make(map[aggregated][]stats.Measurement)}
+	return context.WithValue(ctx, aggregateKey{}, a), a
+}
+
+func getFieldAggregate(ctx context.Context) *fieldAggregate {
+	a, _ := ctx.Value(aggregateKey{}).(*fieldAggregate)
+	return a
+}
+
+// add measurements of a field, resolved with ctx. It yields false when the aggregate is already flushed, e.g. for a
+// field resolved in the background after the response: such measurements should be recorded right away.
+func (a *fieldAggregate) add(ctx context.Context, key fieldKey, measurements ...stats.Measurement) bool {
+	k := aggregated{field: key, tags: tag.FromContext(ctx)}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.measurements == nil {
+		return false
+	}
+	a.measurements[k] = append(a.measurements[k], measurements...)
+	return true
+}
+
+// flush records the accumulated measurements, with a single recording per field and tags of the resolver context
+func (a *fieldAggregate) flush(ctx context.Context, tagger func(fieldKey) []tag.Mutator) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for key, measurements := range a.measurements {
+		_ = stats.RecordWithTags(tag.NewContext(ctx, key.tags), tagger(key.field), measurements...)
+	}
+	a.measurements = nil
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+	"github.com/99designs/gqlgen-contrib/internal/env"
+)
+
+// Environment variables of the collector, besides the shared variables of gqlinstrument.FromEnv
+const (
+	// EnvFields sets FieldsEnabled, e.g. GQL_METRICS_FIELDS=false
+	EnvFields = "GQL_METRICS_FIELDS"
+	// EnvFieldCounts sets FieldCountsEnabled, e.g. GQL_METRICS_FIELD_COUNTS=true
+	EnvFieldCounts = "GQL_METRICS_FIELD_COUNTS"
+	// EnvFieldLatency sets FieldLatencyEnabled, e.g. GQL_METRICS_FIELD_LATENCY=false
+	EnvFieldLatency = "GQL_METRICS_FIELD_LATENCY"
+	// EnvLiteFields enables LiteFields, e.g. GQL_METRICS_LITE_FIELDS=true
+	EnvLiteFields = "GQL_METRICS_LITE_FIELDS"
+	// EnvFlushInterval sets FlushInterval, e.g. GQL_METRICS_FLUSH_INTERVAL=5s
+	EnvFlushInterval = "GQL_METRICS_FLUSH_INTERVAL"
+)
+
+// NewFromEnv builds a collector with options, tuned by environment variables: environment variables take precedence.
+//
+// Example, to collect operation metrics only for the checkout service:
+//
+//	GQL_HOST=checkout GQL_METRICS_FIELDS=false
+func NewFromEnv(opts ...Option) (*Collector, error) {
+	common, err := gqlinstrument.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, Common(common...))
+
+	r := env.New()
+	r.Bool(EnvFields, func(enabled bool) {
+		opts = append(opts, FieldsEnabled(enabled))
+	})
+	r.Bool(EnvFieldCounts, func(enabled bool) {
+		opts = append(opts, FieldCountsEnabled(enabled))
+	})
+	r.Bool(EnvFieldLatency, func(enabled bool) {
+		opts = append(opts, FieldLatencyEnabled(enabled))
+	})
+	r.Bool(EnvLiteFields, func(enabled bool) {
+		opts = append(opts, func(c *config) { c.liteFields = enabled })
+	})
+	r.Duration(EnvFlushInterval, func(interval time.Duration) {
+		opts = append(opts, FlushInterval(interval))
+	})
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return New(opts...), nil
+}
@@ -6,8 +6,16 @@ import (
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/metric/metricproducer"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/errclass"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/internal/fieldpath"
+	"github.com/99designs/gqlgen-contrib/internal/toggle"
 )
 
 const extensionName = "OpencensusMetrics"
@@ -16,14 +24,19 @@ var _ interface {
 	graphql.HandlerExtension
 	graphql.ResponseInterceptor
 	graphql.FieldInterceptor
+	metricproducer.Producer
 } = &Collector{}
 
 type (
 	// Collector is a gqlgen extension to collect opencensus metrics on all GraphQL executions
 	Collector struct {
 		*config
+		tags        *tagger
+		periodic    *periodic
+		toggle      *toggle.Toggle
+		fields      *toggle.Toggle
 		opTagger    func(string) []tag.Mutator
-		fieldTagger func(string, string) []tag.Mutator
+		fieldTagger func(fieldKey) []tag.Mutator
 	}
 )
 
@@ -34,100 +47,265 @@ func New(opts ...Option) *Collector {
 		apply(m.config)
 	}
 
-	if m.config.host == "" {
-		m.config.host = "-"
+	if m.config.Host == "" {
+		m.config.Host = "-"
 	}
 
-	m.opTagger = func(opName string) []tag.Mutator {
-		return []tag.Mutator{tag.Upsert(TagHost, m.config.host), tag.Upsert(TagOperation, opName)}
+	m.toggle = &toggle.Toggle{}
+	m.fields = &toggle.Toggle{}
+	if !m.config.fieldsEnabled() {
+		// fields are switched off, until SetFieldsEnabled switches all field metrics on
+		m.config.fieldCounts, m.config.fieldLatency = true, true
+		m.fields.Set(false)
 	}
-	if m.config.fieldsEnabled {
-		m.fieldTagger = func(fieldName, pth string) []tag.Mutator {
-			return []tag.Mutator{tag.Upsert(TagHost, m.config.host), tag.Upsert(TagField, fieldName), tag.Upsert(TagPath, pth)}
-		}
+	m.tags = newTagger(m.config.Host)
+	m.opTagger = m.tags.operation
+	m.fieldTagger = m.tags.field
+	if m.config.flushInterval > 0 {
+		m.periodic = newPeriodic(m.config)
 	}
 	return m
 }
 
+// Close flushes the measurements accumulated with FlushInterval, and stops flushing. It is a noop otherwise.
+func (m *Collector) Close() error {
+	if m.periodic != nil {
+		m.periodic.close()
+	}
+	return nil
+}
+
+// Read implements the metricproducer.Producer: with FlushInterval, it yields the metrics of the registered views, as
+// of the last flush. It yields nil otherwise, since measurements are recorded in views.
+func (m *Collector) Read() []*metricdata.Metric {
+	if m.periodic == nil {
+		return nil
+	}
+	return m.periodic.read()
+}
+
+// SetEnabled switches the collection of metrics on or off at runtime. Collectors are enabled by default.
+func (m *Collector) SetEnabled(enabled bool) {
+	m.toggle.Set(enabled)
+}
+
+// SetFieldsEnabled switches field metrics on or off at runtime, e.g. to collect expensive field metrics during an
+// incident only. Field metrics are on, unless they are disabled by options.
+//
+// Switching fields on restores the field metrics selected by FieldCountsEnabled and FieldLatencyEnabled, or all of
+// them when fields were disabled altogether.
+func (m *Collector) SetFieldsEnabled(enabled bool) {
+	m.fields.Set(enabled)
+}
+
+// enabled reports whether metrics are collected: when views are registered and the collector is not switched off
+func (m Collector) enabled() bool {
+	return m.toggle.Enabled() && viewsRegistered()
+}
+
 // ExtensionName yields the extension name: "OpencensusMetrics"
 func (Collector) ExtensionName() string {
 	return extensionName
 }
 
-// Validate this collector. This is a noop
-func (Collector) Validate(schema graphql.ExecutableSchema) error {
-	return nil
+// Validate this collector. It computes the hash of the schema, with the gqlinstrument.SchemaHash option.
+func (m Collector) Validate(schema graphql.ExecutableSchema) error {
+	return m.config.Validate(schema)
 }
 
 // InterceptField implements the gqlgen field interceptor
 func (m Collector) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
-	if !m.config.fieldsEnabled {
+	if !m.fields.Enabled() || !m.enabled() {
 		return next(ctx)
 	}
 
 	fc := graphql.GetFieldContext(ctx)
-	if !fc.IsMethod {
-		// only capture fields which correspond to a resolver method
+	if m.config.SkipField(ctx, fc) {
+		// only capture sampled fields which correspond to a resolver method
 		return next(ctx)
 	}
 
-	start := graphql.Now()
+	start := m.config.now()
 
 	defer func() {
-		end := graphql.Now()
-		_ = stats.RecordWithTags(ctx,
-			m.fieldTagger(fieldTags(fc)),
-			ServerFieldCount.M(1),
-			ServerFieldLatency.M(float64(end.Sub(start))/float64(time.Millisecond)),
-		)
+		end := m.config.now()
+		key := m.fieldTags(ctx, fc)
+		ms := float64(end.Sub(start)) / float64(time.Millisecond)
+
+		if m.periodic != nil {
+			m.periodic.field(ctx, key, ms)
+			return
+		}
+
+		measurements := m.fieldMeasurements(ms)
+		if a := getFieldAggregate(ctx); a != nil && a.add(ctx, key, measurements...) {
+			// recorded when the response is complete
+			return
+		}
+		_ = stats.RecordWithTags(ctx, m.fieldTagger(key), measurements...)
 	}()
 
 	return next(ctx)
 }
 
+// fieldMeasurements yields the enabled measurements of a field
+func (m Collector) fieldMeasurements(latency float64) []stats.Measurement {
+	measurements := make([]stats.Measurement, 0, 2)
+	if m.config.fieldCounts {
+		measurements = append(measurements, ServerFieldCount.M(1))
+	}
+	if m.config.fieldLatency {
+		measurements = append(measurements, ServerFieldLatency.M(latency))
+	}
+	return measurements
+}
+
 // InterceptResponse implements the gqlgen response interceptor
 func (m Collector) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !m.enabled() {
+		return next(ctx)
+	}
+	if !graphql.HasOperationContext(ctx) {
+		// requests rejected before their operation is created, e.g. with an undecodable body
+		resp := next(ctx)
+		if resp != nil {
+			reportStatus(ctx, []tag.Mutator{m.tags.host}, outcome(resp))
+		}
+		return resp
+	}
+
 	rc := graphql.GetOperationContext(ctx)
-	opName := operationName(rc)
+	opName := fingerprint.OperationName(rc)
+
+	ctx = m.tagContext(ctx)
+	ctx, _ = m.config.Sample(ctx, rc)
+	operationTags, hash := m.opTagger(opName), ""
+	if m.config.fingerprint {
+		if hash = fingerprint.Of(rc).Hash; hash != "" {
+			// the cached tags of the operation are shared: copy them
+			operationTags = append(append(make([]tag.Mutator, 0, len(operationTags)+1), operationTags...),
+				tag.Upsert(TagFingerprint, hash))
+		}
+	}
+	nctx := context.WithValue(fieldpath.WithCache(ctx), scopeKey{}, &scope{tags: operationTags, collector: m})
+	var fields *fieldAggregate
+	if m.config.liteFields && m.fields.Enabled() && m.periodic == nil {
+		nctx, fields = withFieldAggregate(nctx)
+	}
+
+	resp := next(nctx)
+	end := m.config.now()
+	if fields != nil {
+		fields.flush(ctx, m.fieldTagger)
+	}
 
-	resp := next(ctx)
-	end := graphql.Now()
+	parsing := float64(rc.Stats.Validation.End.Sub(rc.Stats.Parsing.Start)) / float64(time.Millisecond)
+	latency := float64(end.Sub(rc.Stats.Validation.End)) / float64(time.Millisecond)
+	cost, limit, costed := gqlcomplexity.OperationCost(rc)
+	var classification errclass.Classification
+	failed := false
+	if resp != nil {
+		classification, failed = errclass.Worst(m.config.classifier, resp.Errors)
+		reportStatus(ctx, operationTags, outcome(resp))
+	}
 
-	_ = stats.RecordWithTags(ctx,
-		m.opTagger(opName),
+	if m.periodic != nil {
+		sample := operationSample{latency: latency, parsing: parsing, cost: cost, limit: limit, costed: costed}
+		if failed {
+			sample.errorClass = string(classification.Class)
+		}
+		if resp != nil {
+			sample.outcome = outcome(resp)
+		}
+		m.periodic.operation(ctx, opName, hash, sample)
+		if measurements := m.config.userMeasurements(ctx, nil); len(measurements) > 0 {
+			_ = stats.RecordWithTags(ctx, operationTags, measurements...)
+		}
+		return resp
+	}
+
+	if hash != "" {
+		if tagged, err := tag.New(ctx, operationTags...); err == nil {
+			ctx = tagged
+		}
+	} else {
+		ctx = m.tags.operationContext(ctx, opName)
+	}
+	measurements := make([]stats.Measurement, 0, 5+len(m.config.measures))
+	measurements = append(measurements,
 		ServerRequestCount.M(1),
-		ServerParsing.M(float64(rc.Stats.Validation.End.Sub(rc.Stats.Parsing.Start))/float64(time.Millisecond)),
-		ServerLatency.M(float64(end.Sub(rc.Stats.Validation.End))/float64(time.Millisecond)),
+		ServerParsing.M(parsing),
+		ServerLatency.M(latency),
 	)
 
-	if resp == nil {
-		return nil
+	if costed {
+		measurements = append(measurements, ServerCost.M(int64(cost)))
+		if limit > 0 {
+			measurements = append(measurements, ServerCostLimit.M(int64(limit)), ServerCostHeadroom.M(headroom(cost, limit)))
+		}
 	}
-	if err := resp.Errors.Error(); err != "" {
-		_ = stats.RecordWithTags(ctx, m.opTagger(opName), ServerErrorCount.M(1))
+
+	if failed {
+		// views without TagErrorClass, e.g. the count of operations, drop the class
+		if tagged, err := tag.New(ctx, tag.Upsert(TagErrorClass, string(classification.Class))); err == nil {
+			ctx = tagged
+		}
+		measurements = append(measurements, ServerErrorCount.M(1))
+	}
+	if resp != nil {
+		if tagged, err := tag.New(ctx, tag.Upsert(TagOutcome, outcome(resp))); err == nil {
+			ctx = tagged
+		}
+		measurements = append(measurements, ServerResponseCount.M(1))
 	}
+	stats.Record(ctx, m.config.userMeasurements(ctx, measurements)...)
 	return resp
 }
 
-func operationName(ctx *graphql.OperationContext) (opName string) {
-	if ctx.Operation != nil {
-		opName = ctx.Operation.Name
+// tagContext tags the context of a request with the tags extracted by the Tags option
+func (m Collector) tagContext(ctx context.Context) context.Context {
+	values := m.config.TagValues(ctx)
+	if len(values) == 0 {
+		return ctx
 	}
-	if opName == "" && ctx.Operation != nil {
-		//parent response case
-		opName = string(ctx.Operation.Operation)
+	mutators := make([]tag.Mutator, 0, len(values))
+	for _, t := range values {
+		mutators = append(mutators, tag.Upsert(t.Key, t.Value))
 	}
-	if opName == "" {
-		opName = ctx.OperationName
+	if tagged, err := tag.New(ctx, mutators...); err == nil {
+		return tagged
 	}
-	return
+	return ctx
 }
 
-func fieldTags(ctx *graphql.FieldContext) (string, string) {
-	pth := ctx.Path().String()
+// headroom of an operation: the fraction of its cost limit left, e.g. 0.25 when its cost is 75% of the limit, and
+// negative beyond the limit
+func headroom(cost, limit int) float64 {
+	return float64(limit-cost) / float64(limit)
+}
+
+// outcome of a response: success without errors, partial with data and errors, or failure without data
+func outcome(resp *graphql.Response) string {
+	switch {
+	case len(resp.Errors) == 0:
+		return OutcomeSuccess
+	case len(resp.Data) > 0 && string(resp.Data) != "null":
+		return OutcomePartial
+	default:
+		return OutcomeFailure
+	}
+}
+
+func (m Collector) fieldTags(ctx context.Context, fc *graphql.FieldContext) fieldKey {
+	if m.config.liteFields {
+		// introspection types are few: no need to collapse them
+		return fieldKey{field: fc.Field.Name, typ: fc.Object}
+	}
+
+	pth := fieldpath.String(ctx, fc)
 	if strings.HasPrefix(pth, "__schema") {
 		// collapse all schema introspection under one single tag
-		return "[introspection]", "__schema"
+		return fieldKey{field: "[introspection]", path: "__schema"}
 	}
-	return ctx.Field.Name, pth
+	return fieldKey{field: fc.Field.Name, path: pth}
 }
@@ -3,32 +3,210 @@
 package metrics
 
 import (
+	"sync/atomic"
+	"time"
+
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 )
 
+var (
+	// registration holds the time views were registered
+	registration atomic.Value
+
+	// registeredViews holds the views registered last, which may be renamed
+	registeredViews atomic.Value
+
+	// viewsFound caches whether views are registered, as of viewsCheckedAt (in unix nanoseconds)
+	viewsFound     int32
+	viewsCheckedAt int64
+)
+
+// viewsCheckInterval is the time views are assumed to stay registered, or not, between two lookups
+const viewsCheckInterval = time.Second
+
+func setRegistered(views []*view.View) {
+	registeredViews.Store(views)
+	registration.Store(time.Now())
+	atomic.StoreInt64(&viewsCheckedAt, 0)
+}
+
+// viewsRegistered reports whether GraphQL views are registered, with Register, RegisterViews or view.Register
+// directly: collectors skip all work otherwise.
+//
+// Views are looked up at most once per viewsCheckInterval, as it runs on every request.
+func viewsRegistered() bool {
+	now := time.Now().UnixNano()
+	if now-atomic.LoadInt64(&viewsCheckedAt) < int64(viewsCheckInterval) {
+		return atomic.LoadInt32(&viewsFound) == 1
+	}
+
+	found := int32(0)
+	for _, views := range [][]*view.View{registeredGQLViews(), GQLViews} {
+		for _, v := range views {
+			if view.Find(v.Name) != nil {
+				found = 1
+				break
+			}
+		}
+	}
+	atomic.StoreInt32(&viewsFound, found)
+	atomic.StoreInt64(&viewsCheckedAt, now)
+	return found == 1
+}
+
+// registeredGQLViews yields the views registered last, or GQLViews
+func registeredGQLViews() []*view.View {
+	if views, _ := registeredViews.Load().([]*view.View); views != nil {
+		return views
+	}
+	return GQLViews
+}
+
+func registeredAt() time.Time {
+	at, _ := registration.Load().(time.Time)
+	return at
+}
+
 // Register views.
 //
-// Views must be registered before using the extension.
+// Views must be registered before using the extension: collectors skip all work until then.
 func Register() error {
-	return view.Register(GQLViews...)
+	if err := view.Register(GQLViews...); err != nil {
+		return err
+	}
+	setRegistered(GQLViews)
+	return nil
 }
 
 // Unregister views
 func Unregister() {
-	view.Unregister(GQLViews...)
+	view.Unregister(registeredGQLViews()...)
+	atomic.StoreInt64(&viewsCheckedAt, 0)
+}
+
+// SetReportingPeriod sets the interval between the exports of views, e.g. to export more frequently in short batch
+// jobs. The default opencensus reporting period is 10s.
+func SetReportingPeriod(d time.Duration) {
+	view.SetReportingPeriod(d)
+}
+
+// ForceFlush exports the current data of the registered GraphQL views to exporters, without waiting for the next
+// reporting period. All the measurements recorded before the call are aggregated in the exported data.
+//
+// This is useful in tests, and at the end of batch jobs.
+func ForceFlush(exporters ...view.Exporter) error {
+	now := time.Now()
+	for _, v := range registeredGQLViews() {
+		registered := view.Find(v.Name)
+		if registered == nil {
+			continue
+		}
+		rows, err := view.RetrieveData(v.Name)
+		if err != nil {
+			return err
+		}
+		data := &view.Data{View: registered, Start: registeredAt(), End: now, Rows: rows}
+		for _, e := range exporters {
+			e.ExportView(data)
+		}
+	}
+	return nil
+}
+
+// RegisterWithTagKeys registers views with some extra tag keys, inserted in the request context upstream.
+//
+// Example, to aggregate metrics by country:
+//
+//	metrics.RegisterWithTagKeys(clientinfo.TagCountry)
+func RegisterWithTagKeys(keys ...tag.Key) error {
+	return RegisterViews(GQLViews, keys...)
+}
+
+// RegisterViews registers some of the GQLViews only, with some extra tag keys. Measurements of the views left out
+// are not aggregated.
+func RegisterViews(views []*view.View, keys ...tag.Key) error {
+	extended := make([]*view.View, 0, len(views))
+	for _, v := range views {
+		e := *v
+		e.TagKeys = append(append([]tag.Key{}, v.TagKeys...), keys...)
+		extended = append(extended, &e)
+	}
+	if err := view.Register(extended...); err != nil {
+		return err
+	}
+	setRegistered(extended)
+	return nil
+}
+
+// WithViewNames yields copies of views, renamed after a map of the default names to the exported names, e.g. to
+// follow the naming conventions of a metrics backend. Views left out of the map keep their default name. The views
+// are the GQLViews when none are given, e.g. LiteViews otherwise.
+//
+//	metrics.RegisterViews(metrics.WithViewNames(map[string]string{
+//		"gql/server/latency":         "graphql_operation_latency_ms",
+//		"gql/server/operation_count": "graphql_operations_total",
+//	}))
+//
+// Names of unknown views are ignored.
+func WithViewNames(names map[string]string, views ...*view.View) []*view.View {
+	if len(views) == 0 {
+		views = GQLViews
+	}
+	renamed := make([]*view.View, 0, len(views))
+	for _, v := range views {
+		r := *v
+		if name, ok := names[v.Name]; ok && name != "" {
+			r.Name = name
+		}
+		renamed = append(renamed, &r)
+	}
+	return renamed
 }
 
+// LiteViews yields copies of views, with the field views tagged by host, field name and parent type, as fields are
+// recorded with LiteFields. Other views are left as is.
+//
+//	metrics.RegisterViews(metrics.LiteViews(metrics.GQLViews))
+func LiteViews(views []*view.View) []*view.View {
+	lite := make([]*view.View, 0, len(views))
+	for _, v := range views {
+		if v.Measure == ServerFieldCount || v.Measure == ServerFieldLatency {
+			l := *v
+			l.TagKeys = []tag.Key{TagHost, TagField, TagType}
+			v = &l
+		}
+		lite = append(lite, v)
+	}
+	return lite
+}
+
+// Outcomes of responses, as TagOutcome values
+const (
+	// OutcomeSuccess is the outcome of responses without errors
+	OutcomeSuccess = "success"
+	// OutcomePartial is the outcome of responses with both data and errors: GraphQL resolves the fields that did not
+	// fail, and users see a degraded result
+	OutcomePartial = "partial"
+	// OutcomeFailure is the outcome of responses with errors and no data, e.g. rejected or failed operations
+	OutcomeFailure = "failure"
+)
+
 var (
 	// GQLViews contains all opencensus stats views declared by the GraphQL stats collector
 	GQLViews = []*view.View{
 		OperationCountView,
 		FieldCountView,
 		OperationErrorsView,
+		OperationOutcomeView,
+		OperationStatusView,
 		OperationLatencyView,
 		FieldLatencyView,
 		OperationParsingView,
+		OperationCostView,
+		OperationCostLimitView,
+		OperationHeadroomView,
 	}
 
 	// measurements
@@ -47,8 +225,20 @@ var (
 
 	// ServerErrorCount tracks a count of request errors
 	ServerErrorCount = stats.Int64(
-		"gql/server/request_count",
-		"Number of GraphQL requests started",
+		"gql/server/error_count",
+		"Number of GraphQL requests returning an error",
+		stats.UnitDimensionless)
+
+	// ServerResponseCount tracks a count of responses, by outcome (TagOutcome)
+	ServerResponseCount = stats.Int64(
+		"gql/server/response_count",
+		"Number of GraphQL responses, by outcome: success, partial or failure",
+		stats.UnitDimensionless)
+
+	// ServerHTTPResponseCount tracks a count of HTTP responses, by status class, with the StatusMiddleware
+	ServerHTTPResponseCount = stats.Int64(
+		"gql/server/http_response_count",
+		"Number of HTTP responses, by status class and GraphQL outcome",
 		stats.UnitDimensionless)
 
 	// ServerLatency tracks the execution time of requests (excluding parsing and validation time), in milliseconds
@@ -69,6 +259,26 @@ var (
 		"Parsing & validation latency",
 		stats.UnitMilliseconds)
 
+	// ServerCost tracks the cost of operations computed by the gqlcomplexity extension, or the gqlgen complexity limit
+	ServerCost = stats.Int64(
+		"gql/server/operation_cost",
+		"Operation cost",
+		stats.UnitDimensionless)
+
+	// ServerCostLimit tracks the cost limit applied to operations, by the gqlcomplexity extension or the gqlgen
+	// complexity limit
+	ServerCostLimit = stats.Int64(
+		"gql/server/operation_cost_limit",
+		"Operation cost limit",
+		stats.UnitDimensionless)
+
+	// ServerCostHeadroom tracks the fraction of their cost limit left to operations: 0 at the limit, and negative
+	// beyond it
+	ServerCostHeadroom = stats.Float64(
+		"gql/server/operation_cost_headroom",
+		"Fraction of the cost limit left to operations",
+		stats.UnitDimensionless)
+
 	// views
 
 	// OperationCountView reports a count of operations tagged by host and operation name
@@ -89,13 +299,33 @@ var (
 		TagKeys:     []tag.Key{TagHost, TagField, TagPath},
 	}
 
-	// OperationErrorsView reports a count of errors tagged by host and operation name
+	// OperationErrorsView reports a count of errors tagged by host, operation name and error class
 	OperationErrorsView = &view.View{
 		Name:        "gql/server/error_count",
-		Description: "Count of GraphQL requests returning an error by operation",
+		Description: "Count of GraphQL requests returning an error by operation and error class",
 		Measure:     ServerErrorCount,
 		Aggregation: view.Count(),
-		TagKeys:     []tag.Key{TagHost, TagOperation},
+		TagKeys:     []tag.Key{TagHost, TagOperation, TagErrorClass},
+	}
+
+	// OperationOutcomeView reports a count of responses tagged by host, operation name and outcome: successful
+	// responses, partial responses with data and errors, and failures without data
+	OperationOutcomeView = &view.View{
+		Name:        "gql/server/response_count",
+		Description: "Count of GraphQL responses by operation and outcome: success, partial or failure",
+		Measure:     ServerResponseCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagHost, TagOperation, TagOutcome},
+	}
+
+	// OperationStatusView reports a count of HTTP responses tagged by host, operation name, HTTP status class and
+	// GraphQL outcome, with the StatusMiddleware
+	OperationStatusView = &view.View{
+		Name:        "gql/server/http_response_count",
+		Description: "Count of HTTP responses by operation, status class and GraphQL outcome",
+		Measure:     ServerHTTPResponseCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagHost, TagOperation, TagStatusClass, TagOutcome},
 	}
 
 	// OperationLatencyView reports a distribution of execution time of GraphQL operations, by host and operation (in milliseconds)
@@ -125,18 +355,70 @@ var (
 		TagKeys:     []tag.Key{TagHost, TagOperation},
 	}
 
+	// OperationCostView reports a distribution of the cost of GraphQL operations, by host and operation
+	OperationCostView = &view.View{
+		Name:        "gql/server/operation_cost",
+		Description: "Cost distribution of GraphQL requests by operation, as computed by the gqlcomplexity extension",
+		Measure:     ServerCost,
+		Aggregation: DefaultCostDistribution,
+		TagKeys:     []tag.Key{TagHost, TagOperation},
+	}
+
+	// OperationCostLimitView reports the last cost limit applied to GraphQL operations, by host and operation
+	OperationCostLimitView = &view.View{
+		Name:        "gql/server/operation_cost_limit",
+		Description: "Last cost limit applied to GraphQL requests by operation",
+		Measure:     ServerCostLimit,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagHost, TagOperation},
+	}
+
+	// OperationHeadroomView reports a distribution of the fraction of their cost limit left to GraphQL operations, by
+	// host and operation, to spot operations creeping toward their limit before they are rejected
+	OperationHeadroomView = &view.View{
+		Name:        "gql/server/operation_cost_headroom",
+		Description: "Distribution of the fraction of their cost limit left to GraphQL requests by operation",
+		Measure:     ServerCostHeadroom,
+		Aggregation: DefaultHeadroomDistribution,
+		TagKeys:     []tag.Key{TagHost, TagOperation},
+	}
+
 	// TagHost is the name of the graphQL server
 	TagHost = tag.MustNewKey("gql.host")
 
 	// TagOperation is the query operation name
 	TagOperation = tag.MustNewKey("gql.operation")
 
+	// TagFingerprint is the hash of the fingerprint of an operation, with the Fingerprint option. It is not a key of
+	// the default views.
+	TagFingerprint = tag.MustNewKey("gql.fingerprint")
+
+	// TagErrorClass is the class of the worst error of an operation, as classified by the errclass package:
+	// "client", "server", "timeout" or "canceled"
+	TagErrorClass = tag.MustNewKey("gql.error_class")
+
+	// TagOutcome is the outcome of a response: OutcomeSuccess, OutcomePartial or OutcomeFailure
+	TagOutcome = tag.MustNewKey("gql.outcome")
+
+	// TagStatusClass is the class of the HTTP status of a response, e.g. "2xx" or "4xx", see StatusMiddleware
+	TagStatusClass = tag.MustNewKey("gql.http_status_class")
+
 	// TagField is an individual GraphQL field requested
 	TagField = tag.MustNewKey("gql.field")
 
+	// TagType is the parent type of an individual GraphQL field requested
+	TagType = tag.MustNewKey("gql.type")
+
 	// TagPath is an individual GraphQL path to a field requested
 	TagPath = tag.MustNewKey("gql.path")
 
 	// DefaultLatencyDistribution constructs buckets for latency distributions in views
 	DefaultLatencyDistribution = view.Distribution(1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30, 40, 50, 65, 80, 100, 130, 160, 200, 250, 300, 400, 500, 650, 800, 1000, 2000, 5000, 10000, 20000, 50000, 100000)
+
+	// DefaultHeadroomDistribution constructs buckets for headroom distributions in views: operations beyond their limit
+	// fall in the first bucket
+	DefaultHeadroomDistribution = view.Distribution(0, 0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1)
+
+	// DefaultCostDistribution constructs buckets for operation cost distributions in views
+	DefaultCostDistribution = view.Distribution(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 20000, 50000, 100000)
 )
@@ -3,16 +3,35 @@ package metrics
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
 )
 
 func TestMetrics(t *testing.T) {
-	view.RegisterExporter(testExporter{t: t})
 	err := Register()
 	require.NoError(t, err)
 
@@ -21,8 +40,8 @@ func TestMetrics(t *testing.T) {
 	oTags := ext.opTagger("test")
 	require.Len(t, oTags, 2)
 
-	fTags := ext.fieldTagger("aField", "q/path")
-	require.Len(t, fTags, 3)
+	fTags := ext.fieldTagger(fieldKey{field: "aField", typ: "Query", path: "q/path"})
+	require.Len(t, fTags, 4)
 
 	require.Equal(t, extensionName, ext.ExtensionName())
 	require.Nil(t, ext.Validate(&graphql.ExecutableSchemaMock{}))
@@ -43,11 +62,705 @@ func TestMetrics(t *testing.T) {
 	bbb, err := json.Marshal(resp)
 	require.NoError(t, err)
 	t.Logf("resp: %v", string(bbb))
-	time.Sleep(11 * time.Second)
+
+	exporter := metricstest.NewExporter()
+	require.NoError(t, ForceFlush(exporter))
+	require.NotNil(t, exporter.Data(OperationCountView.Name))
+	metricstest.AssertCount(t, OperationCountView, map[tag.Key]string{TagOperation: "test"}, 1)
+}
+
+func BenchmarkCollector(b *testing.B) {
+	require.NoError(b, Register())
+	defer Unregister()
+
+	ext := New(Host("bench"))
+	opCtx := &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "todos", Operation: ast.Query},
+	}
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+
+	b.Run("operation", func(b *testing.B) {
+		b.ReportAllocs()
+		ctx := graphql.WithOperationContext(context.Background(), opCtx)
+		for i := 0; i < b.N; i++ {
+			ext.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+				return &graphql.Response{}
+			})
+		}
+	})
+
+	b.Run("field", func(b *testing.B) {
+		b.ReportAllocs()
+		ctx := todoFields(graphql.WithOperationContext(context.Background(), opCtx), 1)[0]
+		for i := 0; i < b.N; i++ {
+			_, _ = ext.InterceptField(ctx, resolver)
+		}
+	})
+
+	b.Run("request", func(b *testing.B) {
+		b.ReportAllocs()
+		ctx := graphql.WithOperationContext(context.Background(), opCtx)
+		for i := 0; i < b.N; i++ {
+			ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+				for _, fctx := range todoFields(ctx, 500) {
+					_, _ = ext.InterceptField(fctx, resolver)
+				}
+				return &graphql.Response{}
+			})
+		}
+	})
+}
+
+func BenchmarkCollectorParallel(b *testing.B) {
+	require.NoError(b, Register())
+	defer Unregister()
+
+	opCtx := &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "todos", Operation: ast.Query},
+	}
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "direct"},
+		{name: "periodic", opts: []Option{FlushInterval(time.Second)}},
+	} {
+		ext := New(append(bench.opts, Host("bench"))...)
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				ctx := graphql.WithOperationContext(context.Background(), opCtx)
+				fields := todoFields(ctx, 10)
+				for pb.Next() {
+					ext.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+						for _, fctx := range fields {
+							_, _ = ext.InterceptField(fctx, resolver)
+						}
+						return &graphql.Response{}
+					})
+				}
+			})
+		})
+		require.NoError(b, ext.Close())
+	}
+}
+
+func BenchmarkFieldModes(b *testing.B) {
+	require.NoError(b, Register())
+	defer Unregister()
+
+	opCtx := &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "todos", Operation: ast.Query},
+	}
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "full"},
+		{name: "lite", opts: []Option{LiteFields()}},
+		{name: "counts", opts: []Option{LiteFields(), FieldLatencyEnabled(false)}},
+	} {
+		ext := New(append(bench.opts, Host("bench"))...)
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			ctx := graphql.WithOperationContext(context.Background(), opCtx)
+			for i := 0; i < b.N; i++ {
+				ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+					for _, fctx := range todoFields(ctx, 500) {
+						_, _ = ext.InterceptField(fctx, resolver)
+					}
+					return &graphql.Response{}
+				})
+			}
+		})
+	}
+}
+
+// todoFields yields contexts resolving the fields "todos[i].text", for n todos
+func todoFields(ctx context.Context, n int) []context.Context {
+	ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Field: graphql.CollectedField{Field: &ast.Field{Name: "todos", Alias: "todos"}},
+	})
+	fields := make([]context.Context, 0, n)
+	for i := 0; i < n; i++ {
+		index := i
+		item := graphql.WithFieldContext(ctx, &graphql.FieldContext{Index: &index})
+		fields = append(fields, graphql.WithFieldContext(item, &graphql.FieldContext{
+			Object:   "Todo",
+			IsMethod: true,
+			Field:    graphql.CollectedField{Field: &ast.Field{Name: "text", Alias: "text"}},
+		}))
+	}
+	return fields
+}
+
+func TestFieldAggregate(t *testing.T) {
+	user := tag.MustNewKey("test.user")
+	Unregister()
+	require.NoError(t, RegisterViews(LiteViews(GQLViews), user))
+	defer Unregister()
+
+	ext := New(Host("aggregate"), LiteFields())
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+	opCtx := &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "todos", Operation: ast.Query},
+	}
+
+	host := map[tag.Key]string{TagHost: "aggregate"}
+	ctx := graphql.WithOperationContext(context.Background(), opCtx)
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		for i, fctx := range todoFields(ctx, 3) {
+			// tags of resolver contexts are kept
+			tagged, err := tag.New(fctx, tag.Upsert(user, []string{"a", "a", "b"}[i]))
+			require.NoError(t, err)
+			_, _ = ext.InterceptField(tagged, resolver)
+		}
+		require.Empty(t, metricstest.Rows(t, FieldCountView, host), "field measurements should be recorded with the response")
+		return &graphql.Response{}
+	})
+
+	metricstest.AssertCount(t, FieldCountView, map[tag.Key]string{TagHost: "aggregate", user: "a"}, 2)
+	metricstest.AssertCount(t, FieldCountView, map[tag.Key]string{TagHost: "aggregate", user: "b"}, 1)
+	metricstest.AssertCount(t, FieldLatencyView, host, 3)
+}
+
+func TestOperationContext(t *testing.T) {
+	tags := newTagger("h")
+
+	ctx := tags.operationContext(context.Background(), "op")
+	require.Same(t, tag.FromContext(ctx), tag.FromContext(tags.operationContext(context.Background(), "op")))
+	value, _ := tag.FromContext(ctx).Value(TagOperation)
+	require.Equal(t, "op", value)
+
+	user := tag.MustNewKey("user")
+	ctx, err := tag.New(context.Background(), tag.Upsert(user, "u"))
+	require.NoError(t, err)
+	ctx = tags.operationContext(ctx, "op")
+	value, _ = tag.FromContext(ctx).Value(user)
+	require.Equal(t, "u", value)
+	value, _ = tag.FromContext(ctx).Value(TagHost)
+	require.Equal(t, "h", value)
+}
+
+func TestFlushInterval(t *testing.T) {
+	user := tag.MustNewKey("test.user")
+	Unregister()
+	require.NoError(t, RegisterWithTagKeys(TagFingerprint, user))
+	defer Unregister()
+
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	ext := New(Host("periodic"), FlushInterval(time.Hour), Fingerprint(), Clock(clock.Now))
+	for i, err := range []error{nil, nil, errors.New("boom")} {
+		err := err
+		ctx, tagErr := tag.New(context.Background(), tag.Upsert(user, []string{"a", "a", "b"}[i]))
+		require.NoError(t, tagErr)
+		ctx = gqltesting.Operation(`query todos { todos { text } }`).Timings(clock.Now(), 0, 0, 0).Context(ctx)
+		ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			for _, fctx := range todoFields(ctx, 2) {
+				_, _ = ext.InterceptField(fctx, func(context.Context) (interface{}, error) {
+					clock.Advance(1500 * time.Microsecond)
+					return "abc", nil
+				})
+			}
+			if err != nil {
+				return &graphql.Response{Errors: gqlerror.List{gqlerror.WrapPath(nil, err)}}
+			}
+			return &graphql.Response{}
+		})
+	}
+	require.Empty(t, ext.Read(), "measurements should be exported on flush")
+
+	require.NoError(t, ext.Close())
+	source := metricstest.FromProducer(ext)
+	host := map[tag.Key]string{TagHost: "periodic"}
+	hash := fingerprint.Hash("query todos{todos{text}}")
+	source.AssertCount(t, OperationCountView, map[tag.Key]string{TagHost: "periodic", TagFingerprint: hash}, 3)
+	source.AssertCount(t, OperationCountView, map[tag.Key]string{TagHost: "periodic", user: "a"}, 2)
+	source.AssertDistributionSum(t, OperationLatencyView, host, 3*3)
+	source.AssertCount(t, OperationErrorsView, map[tag.Key]string{TagHost: "periodic", TagErrorClass: "server", user: "b"}, 1)
+	source.AssertCount(t, OperationOutcomeView, map[tag.Key]string{TagHost: "periodic", TagOutcome: OutcomeFailure}, 1)
+	source.AssertDistributionSum(t, FieldLatencyView, host, 6*1.5)
+	for _, row := range source.WaitForRows(t, FieldCountView, map[tag.Key]string{TagHost: "periodic", user: "a"}, 2) {
+		require.Equal(t, int64(2), row.Data.(*view.CountData).Value)
+	}
+	require.Empty(t, metricstest.Rows(t, OperationCountView, host), "measurements should not be recorded in views")
+}
+
+func TestFlushIntervalSeries(t *testing.T) {
+	require.NoError(t, Register())
+
+	ext := New(Host("periodic-series"), FlushInterval(time.Hour))
+	defer ext.Close()
+	ext.periodic.operations.max = 1
+	for _, name := range []string{"todos", "users", "posts"} {
+		ctx := gqltesting.Operation(`query ` + name + ` { todos { text } }`).Context(context.Background())
+		ext.InterceptResponse(ctx, func(context.Context) *graphql.Response { return nil })
+	}
+	ext.periodic.flush()
+
+	source := metricstest.FromProducer(ext)
+	source.AssertCount(t, OperationCountView, map[tag.Key]string{TagOperation: "todos"}, 1)
+	source.AssertCount(t, OperationCountView, map[tag.Key]string{TagOperation: otherSeries}, 2)
+
+	// series without samples expire
+	ext.periodic.mu.Lock()
+	ext.periodic.operations.series.Range(func(_, value interface{}) bool {
+		seriesOf(value).seen = time.Now().Add(-seriesExpiry - time.Second)
+		return true
+	})
+	ext.periodic.mu.Unlock()
+	ext.periodic.flush()
+	require.Empty(t, ext.Read())
+	require.Zero(t, ext.periodic.operations.count)
+}
+
+func TestEnabled(t *testing.T) {
+	ext := New(Host("enabled"))
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "todos", Operation: ast.Query},
+	})
+	collected := func() (ok bool) {
+		ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			ok = scopeFromContext(ctx) != nil
+			return &graphql.Response{}
+		})
+		return ok
+	}
+
+	Unregister()
+	require.False(t, collected(), "metrics should not be collected without views")
+
+	// views registered directly, once the views are looked up again
+	require.NoError(t, view.Register(GQLViews...))
+	atomic.StoreInt64(&viewsCheckedAt, 0)
+	require.True(t, collected())
+	metricstest.AssertCount(t, OperationCountView, map[tag.Key]string{TagHost: "enabled"}, 1)
+	Unregister()
+	require.NoError(t, Register())
+
+	ext.SetEnabled(false)
+	require.False(t, collected(), "metrics should not be collected when disabled")
+
+	ext.SetEnabled(true)
+	require.True(t, collected())
+}
+
+func TestFieldToggles(t *testing.T) {
+	require.NoError(t, Register())
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "todos", Operation: ast.Query},
+	})
+
+	for _, host := range []string{"counts", "latency"} {
+		ext := New(Host(host), FieldCountsEnabled(host == "counts"), FieldLatencyEnabled(host == "latency"))
+		ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			_, _ = ext.InterceptField(todoFields(ctx, 1)[0], resolver)
+			return &graphql.Response{}
+		})
+	}
+
+	counts, latency := map[tag.Key]string{TagHost: "counts"}, map[tag.Key]string{TagHost: "latency"}
+	metricstest.AssertCount(t, FieldCountView, counts, 1)
+	metricstest.AssertCount(t, FieldLatencyView, latency, 1)
+	require.Empty(t, metricstest.Rows(t, FieldLatencyView, counts))
+	require.Empty(t, metricstest.Rows(t, FieldCountView, latency))
+}
+
+func TestCommon(t *testing.T) {
+	require.NoError(t, Register())
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+
+	ext := New(Common(
+		gqlinstrument.Host("common"),
+		gqlinstrument.WithSampler(func(*graphql.OperationContext) bool { return false }),
+	))
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		_, _ = ext.InterceptField(todoFields(ctx, 1)[0], resolver)
+		return &graphql.Response{}
+	})
+
+	host := map[tag.Key]string{TagHost: "common"}
+	metricstest.AssertCount(t, OperationCountView, host, 1)
+	require.Empty(t, metricstest.Rows(t, FieldCountView, host))
+}
+
+func TestNewFromEnv(t *testing.T) {
+	require.NoError(t, Register())
+	for name, value := range map[string]string{
+		gqlinstrument.EnvHost: "env",
+		EnvFields:             "false",
+	} {
+		require.NoError(t, os.Setenv(name, value))
+		defer os.Unsetenv(name)
+	}
+
+	ext, err := NewFromEnv(Host("code"), LiteFields())
+	require.NoError(t, err)
+	require.True(t, ext.config.liteFields)
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		_, _ = ext.InterceptField(todoFields(ctx, 1)[0], func(context.Context) (interface{}, error) { return "abc", nil })
+		return &graphql.Response{}
+	})
+
+	host := map[tag.Key]string{TagHost: "env"}
+	metricstest.AssertCount(t, OperationCountView, host, 1)
+	require.Empty(t, metricstest.Rows(t, FieldCountView, host))
+
+	require.NoError(t, os.Setenv(EnvFlushInterval, "soon"))
+	_, err = NewFromEnv()
+	require.EqualError(t, err, `GQL_METRICS_FLUSH_INTERVAL: invalid duration "soon", expecting e.g. 5s`)
+}
+
+func TestLiteFields(t *testing.T) {
+	lite := LiteViews(GQLViews)
+	require.ElementsMatch(t, []tag.Key{TagHost, TagField, TagPath}, FieldCountView.TagKeys, "default views should be left as is")
+	require.ElementsMatch(t, []tag.Key{TagHost, TagField, TagType}, lite[1].TagKeys)
+	require.Same(t, OperationCountView, lite[0])
+
+	Unregister()
+	require.NoError(t, RegisterViews(lite))
+	defer Unregister()
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "todos", Operation: ast.Query},
+	})
+
+	ext := New(Host("lite"), LiteFields())
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		for _, fctx := range todoFields(ctx, 3) {
+			_, _ = ext.InterceptField(fctx, resolver)
+		}
+		return &graphql.Response{}
+	})
+
+	rows := metricstest.WaitForRows(t, FieldCountView, map[tag.Key]string{TagHost: "lite"}, 1)
+	require.Len(t, rows, 1)
+	require.Equal(t, int64(3), rows[0].Data.(*view.CountData).Value)
+	require.Equal(t, []tag.Tag{
+		{Key: TagField, Value: "text"},
+		{Key: TagHost, Value: "lite"},
+		{Key: TagType, Value: "Todo"},
+	}, rows[0].Tags)
+}
+
+func TestClock(t *testing.T) {
+	require.NoError(t, Register())
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	ext := New(Host("clock"), Clock(clock.Now))
+
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).
+		Timings(clock.Now(), 0, time.Millisecond, time.Millisecond).
+		Context(context.Background())
+	clock.Advance(2 * time.Millisecond)
+
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		for i := 0; i < 2; i++ {
+			fctx := gqltesting.Field("todos", i, "text").Object("Todo").Method().Context(ctx)
+			_, _ = ext.InterceptField(fctx, func(context.Context) (interface{}, error) {
+				clock.Advance(30 * time.Millisecond)
+				return "abc", nil
+			})
+		}
+		return &graphql.Response{}
+	})
+
+	host := map[tag.Key]string{TagHost: "clock"}
+	metricstest.AssertDistributionSum(t, OperationParsingView, host, 2)
+	metricstest.AssertDistributionSum(t, OperationLatencyView, host, 60)
+	metricstest.AssertDistributionSum(t, FieldLatencyView, host, 60)
+}
+
+func TestSetFieldsEnabled(t *testing.T) {
+	require.NoError(t, Register())
+	resolver := func(context.Context) (interface{}, error) { return "abc", nil }
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+
+	for _, host := range []string{"switched-off", "switched-on"} {
+		ext := New(Host(host), FieldsEnabled(host == "switched-off"))
+		ext.SetFieldsEnabled(host == "switched-on")
+		ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			_, _ = ext.InterceptField(todoFields(ctx, 1)[0], resolver)
+			return &graphql.Response{}
+		})
+	}
+
+	on, off := map[tag.Key]string{TagHost: "switched-on"}, map[tag.Key]string{TagHost: "switched-off"}
+	metricstest.AssertCount(t, FieldCountView, on, 1)
+	metricstest.AssertCount(t, FieldLatencyView, on, 1)
+	metricstest.AssertCount(t, OperationCountView, off, 1)
+	require.Empty(t, metricstest.Rows(t, FieldCountView, off))
+}
+
+func TestFingerprint(t *testing.T) {
+	Unregister()
+	require.NoError(t, RegisterWithTagKeys(TagFingerprint))
+	defer Unregister()
+
+	ext := New(Host("fingerprint"), Fingerprint())
+	for _, query := range []string{`{ todos { text } }`, `{ all: todos { text } }`, `{ todos { id } }`} {
+		ext.InterceptResponse(gqltesting.Operation(query).Context(context.Background()), func(context.Context) *graphql.Response {
+			return &graphql.Response{}
+		})
+	}
+
+	hash := fingerprint.Hash("query{todos{text}}")
+	metricstest.AssertCount(t, OperationCountView, map[tag.Key]string{TagHost: "fingerprint", TagFingerprint: hash}, 2)
+	metricstest.AssertCount(t, OperationCountView, map[tag.Key]string{TagHost: "fingerprint", TagOperation: "query"}, 3)
+}
+
+func TestTagsFromContext(t *testing.T) {
+	require.NoError(t, Register())
+	require.Nil(t, TagsFromContext(context.Background()))
+
+	var tags *tag.Map
+	ext := New(Host("custom"))
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		tagged, err := tag.New(context.Background(), TagsFromContext(ctx)...)
+		require.NoError(t, err)
+		tags = tag.FromContext(tagged)
+		return &graphql.Response{}
+	})
+
+	require.NotNil(t, tags)
+	host, _ := tags.Value(TagHost)
+	operation, _ := tags.Value(TagOperation)
+	require.Equal(t, "custom", host)
+	require.Equal(t, "todos", operation)
+}
+
+func TestRecord(t *testing.T) {
+	require.NoError(t, Register())
+	rows := stats.Int64("test/rows_scanned", "Rows scanned", stats.UnitDimensionless)
+	rowsView := &view.View{
+		Name:        "test/rows_scanned",
+		Measure:     rows,
+		Aggregation: view.Distribution(10, 100),
+		TagKeys:     []tag.Key{TagHost, TagOperation, TagField, TagPath},
+	}
+	require.NoError(t, view.Register(rowsView))
+	defer view.Unregister(rowsView)
+
+	ext := New(Host("record"))
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		Record(ctx, rows, 5)
+		Record(todoFields(ctx, 1)[0], rows, 7.9)
+		return &graphql.Response{}
+	})
+	Record(context.Background(), rows, 1)
+
+	metricstest.AssertDistributionSum(t, rowsView, map[tag.Key]string{TagHost: "record", TagOperation: "todos"}, 12)
+	metricstest.AssertDistributionSum(t, rowsView, map[tag.Key]string{
+		TagHost: "record", TagOperation: "todos", TagField: "text", TagPath: "todos[0].text",
+	}, 7)
+	metricstest.AssertCount(t, rowsView, nil, 3)
+}
+
+func TestMeasures(t *testing.T) {
+	require.NoError(t, Register())
+	queries := stats.Int64("test/db_queries", "Database queries", stats.UnitDimensionless)
+	queriesView := &view.View{
+		Name:        "test/db_queries",
+		Measure:     queries,
+		Aggregation: view.Distribution(1, 10),
+		TagKeys:     []tag.Key{TagHost, TagOperation},
+	}
+	require.NoError(t, view.Register(queriesView))
+	defer view.Unregister(queriesView)
+
+	type countKey struct{}
+	ext := New(Host("measures"), Measures(Measure{
+		Measure: queries,
+		Value: func(ctx context.Context) (float64, bool) {
+			count, ok := ctx.Value(countKey{}).(*int)
+			if !ok {
+				return 0, false
+			}
+			return float64(*count), true
+		},
+	}))
+	for _, n := range []int{3, 4} {
+		count := 0
+		ctx := context.WithValue(context.Background(), countKey{}, &count)
+		ctx = gqltesting.Operation(`query todos { todos { text } }`).Context(ctx)
+		ext.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+			count = n
+			return &graphql.Response{}
+		})
+	}
+	ext.InterceptResponse(gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background()),
+		func(context.Context) *graphql.Response { return &graphql.Response{} })
+
+	tags := map[tag.Key]string{TagHost: "measures", TagOperation: "todos"}
+	metricstest.AssertDistributionSum(t, queriesView, tags, 7)
+	metricstest.AssertCount(t, queriesView, tags, 2)
+	metricstest.AssertCount(t, OperationCountView, tags, 3)
 }
 
-type testExporter struct{ t testing.TB }
+func TestWithViewNames(t *testing.T) {
+	Unregister()
+	views := WithViewNames(map[string]string{
+		"gql/server/operation_count": "graphql_operations_total",
+		"gql/server/unknown":         "ignored",
+	})
+	require.Len(t, views, len(GQLViews))
+	require.NoError(t, RegisterViews(views))
+	require.Equal(t, "gql/server/operation_count", OperationCountView.Name)
 
-func (x testExporter) ExportView(viewData *view.Data) {
-	x.t.Logf("viewData: %#v", viewData)
+	ext := New(Host("renamed"))
+	ext.InterceptResponse(gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background()),
+		func(context.Context) *graphql.Response { return &graphql.Response{} })
+
+	exporter := metricstest.NewExporter()
+	require.NoError(t, ForceFlush(exporter))
+	require.NotNil(t, exporter.Data("graphql_operations_total"))
+	require.Nil(t, exporter.Data(OperationCountView.Name))
+	require.NotNil(t, exporter.Data(OperationLatencyView.Name))
+
+	Unregister()
+	require.Nil(t, view.Find("graphql_operations_total"))
+	require.Nil(t, view.Find(OperationLatencyView.Name))
+
+	lite := WithViewNames(map[string]string{"gql/server/field_count": "graphql_fields_total"}, LiteViews(GQLViews)...)
+	require.Len(t, lite, len(GQLViews))
+	for _, v := range lite {
+		if v.Measure == ServerFieldCount {
+			require.Equal(t, "graphql_fields_total", v.Name)
+			require.Equal(t, []tag.Key{TagHost, TagField, TagType}, v.TagKeys)
+		}
+	}
+}
+
+func TestErrorClassifier(t *testing.T) {
+	require.NoError(t, Register())
+
+	ext := New(Host("classified"))
+	for _, err := range []error{context.Canceled, errors.New("boom"), errors.New("boom")} {
+		err := err
+		ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+		ext.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+			return &graphql.Response{Errors: gqlerror.List{gqlerror.WrapPath(nil, err)}}
+		})
+	}
+
+	host := map[tag.Key]string{TagHost: "classified"}
+	metricstest.AssertCount(t, OperationErrorsView, map[tag.Key]string{TagHost: "classified", TagErrorClass: "canceled"}, 1)
+	metricstest.AssertCount(t, OperationErrorsView, map[tag.Key]string{TagHost: "classified", TagErrorClass: "server"}, 2)
+	require.Empty(t, metricstest.Rows(t, OperationLatencyView, map[tag.Key]string{TagErrorClass: "server"}))
+	metricstest.AssertCount(t, OperationLatencyView, host, 3)
+}
+
+func TestOutcomes(t *testing.T) {
+	require.NoError(t, Register())
+
+	responses := []*graphql.Response{
+		{Data: []byte(`{"todos": []}`)},
+		{Data: []byte(`{"todos": null, "user": {}}`), Errors: gqlerror.List{gqlerror.Errorf("boom")}},
+		{Data: []byte(`null`), Errors: gqlerror.List{gqlerror.Errorf("boom")}},
+		{Errors: gqlerror.List{gqlerror.Errorf("rejected")}},
+	}
+	for _, tc := range []struct {
+		host string
+		opts []Option
+	}{
+		{host: "outcomes"},
+		{host: "periodic-outcomes", opts: []Option{FlushInterval(time.Hour)}},
+	} {
+		ext := New(append(tc.opts, Host(tc.host))...)
+		for _, resp := range responses {
+			resp := resp
+			ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+			ext.InterceptResponse(ctx, func(context.Context) *graphql.Response { return resp })
+		}
+		require.NoError(t, ext.Close())
+
+		source := metricstest.Views
+		if ext.periodic != nil {
+			source = metricstest.FromProducer(ext)
+		}
+		outcome := func(outcome string) map[tag.Key]string {
+			return map[tag.Key]string{TagHost: tc.host, TagOperation: "todos", TagOutcome: outcome}
+		}
+		source.AssertCount(t, OperationOutcomeView, outcome(OutcomeSuccess), 1)
+		source.AssertCount(t, OperationOutcomeView, outcome(OutcomePartial), 1)
+		source.AssertCount(t, OperationOutcomeView, outcome(OutcomeFailure), 2)
+	}
+}
+
+func TestStatusMiddleware(t *testing.T) {
+	require.NoError(t, Register())
+
+	srv := handler.New(testschema.New(`type Query { todo: String, fail: String }`, testschema.Resolvers{
+		"Query.todo": func(context.Context) (interface{}, error) { return "todo", nil },
+		"Query.fail": func(context.Context) (interface{}, error) { return nil, errors.New("boom") },
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(Host("status")))
+	h := StatusMiddleware(srv)
+
+	for _, body := range []string{
+		`{"query": "query todo { todo }"}`,
+		`{"query": "query fail { todo fail }"}`,
+		`{"query": "query invalid { unknown }"}`,
+		`{"query": `,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	metricstest.AssertCount(t, OperationStatusView, map[tag.Key]string{TagHost: "status", TagOperation: "todo", TagStatusClass: "2xx", TagOutcome: OutcomeSuccess}, 1)
+	metricstest.AssertCount(t, OperationStatusView, map[tag.Key]string{TagHost: "status", TagOperation: "fail", TagStatusClass: "2xx", TagOutcome: OutcomePartial}, 1)
+	// invalid and undecodable operations
+	metricstest.AssertCount(t, OperationStatusView, map[tag.Key]string{TagHost: "status", TagStatusClass: "4xx", TagOutcome: OutcomeFailure}, 2)
+	require.Empty(t, metricstest.Rows(t, OperationStatusView, map[tag.Key]string{TagHost: "status", TagStatusClass: "5xx"}))
+}
+
+func TestHeadroom(t *testing.T) {
+	require.NoError(t, Register())
+
+	for _, tc := range []struct {
+		host string
+		opts []Option
+	}{
+		{host: "headroom"},
+		{host: "periodic-headroom", opts: []Option{FlushInterval(time.Hour)}},
+	} {
+		ext := New(append(tc.opts, Host(tc.host))...)
+		for _, stats := range []interface{}{
+			&gqlcomplexity.Stats{Cost: 75, Limit: 100},
+			&gqlcomplexity.Stats{Cost: 120, Limit: 100},
+			&extension.ComplexityStats{Complexity: 50, ComplexityLimit: 100},
+			&gqlcomplexity.Stats{Cost: 10},
+		} {
+			rc := gqltesting.Operation(`query todos { todos { text } }`).Build()
+			if s, ok := stats.(*gqlcomplexity.Stats); ok {
+				rc.Stats.SetExtension("CostLimit", s)
+			} else {
+				rc.Stats.SetExtension("ComplexityLimit", stats)
+			}
+			ext.InterceptResponse(graphql.WithOperationContext(context.Background(), rc),
+				func(context.Context) *graphql.Response { return &graphql.Response{} })
+		}
+		require.NoError(t, ext.Close())
+
+		source := metricstest.Views
+		if ext.periodic != nil {
+			source = metricstest.FromProducer(ext)
+		}
+		op := map[tag.Key]string{TagHost: tc.host, TagOperation: "todos"}
+		source.AssertCount(t, OperationCostView, op, 4)
+		source.AssertCount(t, OperationHeadroomView, op, 3)
+		source.AssertDistributionSum(t, OperationHeadroomView, op, 0.25-0.2+0.5)
+		rows := source.WaitForRows(t, OperationCostLimitView, op, 1)
+		require.Equal(t, 100.0, rows[0].Data.(*view.LastValueData).Value)
+	}
 }
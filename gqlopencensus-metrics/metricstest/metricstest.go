@@ -0,0 +1,291 @@
+// Package metricstest helps verifying opencensus metrics in tests, without sleeping until views are exported.
+//
+// Assertions retrieve the data of views directly, and wait for recordings made by other goroutines:
+//
+//	metricstest.AssertCount(t, metrics.OperationCountView, map[tag.Key]string{metrics.TagOperation: "todos"}, 1)
+//
+// The same assertions apply to the metrics read from a producer, e.g. a metrics.Collector with FlushInterval, which
+// yields metrics named after views rather than recording measurements in them:
+//
+//	metricstest.FromProducer(collector).AssertCount(t, metrics.OperationCountView, tags, 1)
+package metricstest
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/metric/metricproducer"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Timeout of assertions, waiting for the expected data
+var Timeout = time.Second
+
+var _ view.Exporter = &Exporter{}
+
+// Exporter is an in-memory view exporter, retaining the last data exported for each view.
+//
+// It may be registered with view.RegisterExporter, or given to metrics.ForceFlush.
+type Exporter struct {
+	mu   sync.Mutex
+	data map[string]*view.Data
+}
+
+// NewExporter yields an empty in-memory exporter
+func NewExporter() *Exporter {
+	return &Exporter{data: make(map[string]*view.Data)}
+}
+
+// ExportView implements view.Exporter
+func (e *Exporter) ExportView(data *view.Data) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.data[data.View.Name] = data
+}
+
+// Data last exported for a view, or nil
+func (e *Exporter) Data(name string) *view.Data {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.data[name]
+}
+
+// Source of the data of views
+type Source struct {
+	retrieve func(v *view.View) ([]*view.Row, error)
+}
+
+// Views is the source of the data aggregated by views, read by the functions of this package
+var Views = &Source{retrieve: func(v *view.View) ([]*view.Row, error) {
+	return view.RetrieveData(v.Name)
+}}
+
+// FromProducer yields a source of the data of views, read from the metrics of a producer named after the views
+func FromProducer(p metricproducer.Producer) *Source {
+	return &Source{retrieve: func(v *view.View) ([]*view.Row, error) {
+		var rows []*view.Row
+		for _, metric := range p.Read() {
+			if metric.Descriptor.Name != v.Name {
+				continue
+			}
+			for _, ts := range metric.TimeSeries {
+				if row := rowOf(v, metric.Descriptor.LabelKeys, ts); row != nil {
+					rows = append(rows, row)
+				}
+			}
+		}
+		return rows, nil
+	}}
+}
+
+// rowOf converts the last point of a time series to a row of a view
+func rowOf(v *view.View, keys []metricdata.LabelKey, ts *metricdata.TimeSeries) *view.Row {
+	if len(ts.Points) == 0 {
+		return nil
+	}
+	row := &view.Row{}
+	for i, value := range ts.LabelValues {
+		if value.Present && i < len(keys) {
+			row.Tags = append(row.Tags, tag.Tag{Key: tag.MustNewKey(keys[i].Key), Value: value.Value})
+		}
+	}
+
+	switch value := ts.Points[len(ts.Points)-1].Value.(type) {
+	case int64:
+		row.Data = dataOf(v, float64(value))
+	case float64:
+		row.Data = dataOf(v, value)
+	case *metricdata.Distribution:
+		d := &view.DistributionData{Count: value.Count, SumOfSquaredDev: value.SumOfSquaredDeviation}
+		if value.Count > 0 {
+			d.Mean = value.Sum / float64(value.Count)
+		}
+		for _, b := range value.Buckets {
+			d.CountPerBucket = append(d.CountPerBucket, b.Count)
+		}
+		row.Data = d
+	}
+	return row
+}
+
+// dataOf a scalar value, after the aggregation of a view
+func dataOf(v *view.View, value float64) view.AggregationData {
+	switch v.Aggregation.Type {
+	case view.AggTypeCount:
+		return &view.CountData{Value: int64(value)}
+	case view.AggTypeLastValue:
+		return &view.LastValueData{Value: value}
+	}
+	return &view.SumData{Value: value}
+}
+
+// Rows of a view matching some tags: rows may carry more tags than the given ones
+func Rows(t testing.TB, v *view.View, tags map[tag.Key]string) []*view.Row {
+	t.Helper()
+	return Views.Rows(t, v, tags)
+}
+
+// WaitForRows waits until a view holds n rows matching some tags, and yields them. It fails the test on timeout.
+func WaitForRows(t testing.TB, v *view.View, tags map[tag.Key]string, n int) []*view.Row {
+	t.Helper()
+	return Views.WaitForRows(t, v, tags, n)
+}
+
+// AssertCount asserts the count of measurements of a view, over the rows matching some tags.
+//
+// This applies to count and distribution views. It waits until the expected count is reached.
+func AssertCount(t testing.TB, v *view.View, tags map[tag.Key]string, n int64) bool {
+	t.Helper()
+	return Views.AssertCount(t, v, tags, n)
+}
+
+// AssertDistributionSum asserts the sum of the measurements of a distribution view, over the rows matching some
+// tags. It waits until the expected sum is reached.
+func AssertDistributionSum(t testing.TB, v *view.View, tags map[tag.Key]string, sum float64) bool {
+	t.Helper()
+	return Views.AssertDistributionSum(t, v, tags, sum)
+}
+
+// AssertSum asserts the sum of a sum view, over the rows matching some tags. It waits until the expected sum is
+// reached.
+func AssertSum(t testing.TB, v *view.View, tags map[tag.Key]string, sum float64) bool {
+	t.Helper()
+	return Views.AssertSum(t, v, tags, sum)
+}
+
+// Rows of a view matching some tags, from this source
+func (s *Source) Rows(t testing.TB, v *view.View, tags map[tag.Key]string) []*view.Row {
+	t.Helper()
+	rows, err := s.retrieve(v)
+	if err != nil {
+		t.Fatalf("retrieving view %s: %v", v.Name, err)
+	}
+
+	var matching []*view.Row
+	for _, row := range rows {
+		if matches(row, tags) {
+			matching = append(matching, row)
+		}
+	}
+	return matching
+}
+
+// WaitForRows waits until a view holds n rows matching some tags in this source, see WaitForRows
+func (s *Source) WaitForRows(t testing.TB, v *view.View, tags map[tag.Key]string, n int) []*view.Row {
+	t.Helper()
+	var rows []*view.Row
+	if !wait(func() bool {
+		rows = s.Rows(t, v, tags)
+		return len(rows) >= n
+	}) {
+		t.Fatalf("view %s: expected %d rows matching %v, got %d", v.Name, n, tags, len(rows))
+	}
+	return rows
+}
+
+// AssertCount asserts the count of measurements of a view in this source, see AssertCount
+func (s *Source) AssertCount(t testing.TB, v *view.View, tags map[tag.Key]string, n int64) bool {
+	t.Helper()
+	var count int64
+	if wait(func() bool {
+		count = 0
+		for _, row := range s.Rows(t, v, tags) {
+			switch data := row.Data.(type) {
+			case *view.CountData:
+				count += data.Value
+			case *view.DistributionData:
+				count += data.Count
+			}
+		}
+		return count == n
+	}) {
+		return true
+	}
+	t.Errorf("view %s: expected a count of %d over the rows matching %v, got %d", v.Name, n, tags, count)
+	return false
+}
+
+// AssertDistributionSum asserts the sum of a distribution view in this source, see AssertDistributionSum
+func (s *Source) AssertDistributionSum(t testing.TB, v *view.View, tags map[tag.Key]string, sum float64) bool {
+	t.Helper()
+	var actual float64
+	if wait(func() bool {
+		actual = 0
+		for _, row := range s.Rows(t, v, tags) {
+			if data, ok := row.Data.(*view.DistributionData); ok {
+				actual += data.Sum()
+			}
+		}
+		return almostEqual(actual, sum)
+	}) {
+		return true
+	}
+	t.Errorf("view %s: expected a sum of %v over the rows matching %v, got %v", v.Name, sum, tags, actual)
+	return false
+}
+
+// AssertSum asserts the sum of a sum view in this source, see AssertSum
+func (s *Source) AssertSum(t testing.TB, v *view.View, tags map[tag.Key]string, sum float64) bool {
+	t.Helper()
+	var actual float64
+	if wait(func() bool {
+		actual = 0
+		for _, row := range s.Rows(t, v, tags) {
+			if data, ok := row.Data.(*view.SumData); ok {
+				actual += data.Value
+			}
+		}
+		return almostEqual(actual, sum)
+	}) {
+		return true
+	}
+	t.Errorf("view %s: expected a sum of %v over the rows matching %v, got %v", v.Name, sum, tags, actual)
+	return false
+}
+
+// Tags formats the tags of a row as a map, e.g. to compare them
+func Tags(row *view.Row) map[tag.Key]string {
+	tags := make(map[tag.Key]string, len(row.Tags))
+	for _, tg := range row.Tags {
+		tags[tg.Key] = tg.Value
+	}
+	return tags
+}
+
+func matches(row *view.Row, tags map[tag.Key]string) bool {
+	for key, value := range tags {
+		found := false
+		for _, tg := range row.Tags {
+			if tg.Key == key {
+				found = tg.Value == value
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// wait until a condition is met, or the timeout
+func wait(condition func() bool) bool {
+	deadline := time.Now().Add(Timeout)
+	for {
+		if condition() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*(1+math.Abs(b))
+}
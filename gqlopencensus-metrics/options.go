@@ -1,39 +1,196 @@
 package metrics
 
 import (
+	"context"
 	"os"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+
+	"github.com/99designs/gqlgen-contrib/errclass"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
 )
 
 type (
-	// Option for this metrics collector (none implemented at the moment)
+	// Option for this metrics collector
 	Option func(*config)
 
+	// Measure is a custom measure of operations, recorded along the measurements of the Collector, e.g. the number
+	// of database queries of an operation counted by a middleware
+	Measure struct {
+		Measure stats.Measure
+
+		// Value extracts the measurement from the context of the request, when the response is complete. Measurements
+		// are skipped when ok is false. Int64 measurements are truncated.
+		Value func(ctx context.Context) (value float64, ok bool)
+	}
+
 	config struct {
-		host          string
-		fieldsEnabled bool
+		gqlinstrument.Config
+		fieldCounts   bool
+		fieldLatency  bool
+		liteFields    bool
+		fingerprint   bool
+		measures      []Measure
+		classifier    errclass.Classifier
+		flushInterval time.Duration
+		now           func() time.Time
 	}
 )
 
 func defaultCollector() *Collector {
 	host, _ := os.Hostname()
-	return &Collector{
+	m := &Collector{
 		config: &config{
-			host:          host,
-			fieldsEnabled: true,
+			Config:       gqlinstrument.Default(),
+			fieldCounts:  true,
+			fieldLatency: true,
+			now: func() time.Time {
+				return graphql.Now()
+			},
 		},
 	}
+	m.config.Host = host
+	return m
 }
 
 // Host determines the host tag. By default this is the OS hostname
 func Host(hostname string) Option {
 	return func(c *config) {
-		c.host = hostname
+		c.Host = hostname
+	}
+}
+
+// Common applies options shared with other instrumentation extensions, e.g. the host, sampler and tags.
+//
+// Views must be registered with the keys of the tags, with RegisterWithTagKeys.
+func Common(opts ...gqlinstrument.Option) Option {
+	return func(c *config) {
+		for _, apply := range opts {
+			apply(&c.Config)
+		}
+	}
+}
+
+// Fingerprint tags operation metrics with the hash of the fingerprint of operations (TagFingerprint), e.g. to tell
+// apart anonymous operations. This is disabled by default.
+//
+// Views must be registered with this extra key:
+//
+//	metrics.RegisterWithTagKeys(metrics.TagFingerprint)
+func Fingerprint() Option {
+	return func(c *config) {
+		c.fingerprint = true
 	}
 }
 
 // FieldsEnabled controls whether metrics at the field level are enabled (this is enabled by default)
 func FieldsEnabled(enabled bool) Option {
 	return func(c *config) {
-		c.fieldsEnabled = enabled
+		c.fieldCounts = enabled
+		c.fieldLatency = enabled
+	}
+}
+
+// FieldCountsEnabled controls whether field counts are recorded (this is enabled by default)
+func FieldCountsEnabled(enabled bool) Option {
+	return func(c *config) {
+		c.fieldCounts = enabled
+	}
+}
+
+// FieldLatencyEnabled controls whether field latency distributions are recorded (this is enabled by default).
+//
+// Latency distributions are the most expensive field metrics to export: disable them to keep cheap field counts only.
+func FieldLatencyEnabled(enabled bool) Option {
+	return func(c *config) {
+		c.fieldLatency = enabled
+	}
+}
+
+// LiteFields tags fields with their name and parent type only, omitting their path. This lite mode avoids building
+// the path of every field, and bounds the number of tag values with the schema, rather than with the response size.
+//
+// The measurements of lite fields are batched per request, and recorded when the response is complete.
+//
+// Field views must be registered with the keys of lite fields, with LiteViews.
+func LiteFields() Option {
+	return func(c *config) {
+		c.liteFields = true
+	}
+}
+
+// Clock measures latencies with a given clock, e.g. a fake clock in tests. By default this is graphql.Now.
+func Clock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
+
+// ErrorClassifier classifies the errors of operations, to tag error counts with the class of the worst error of
+// each operation (TagErrorClass). The default is errclass.Default().
+func ErrorClassifier(classifier errclass.Classifier) Option {
+	return func(c *config) {
+		c.classifier = classifier
+	}
+}
+
+// Measures records some custom measures of operations, in the same batch as the measurements of the Collector and
+// with the same tags. Example:
+//
+//	queries := stats.Int64("app/db_queries", "Database queries per operation", stats.UnitDimensionless)
+//	metrics.New(metrics.Measures(metrics.Measure{
+//		Measure: queries,
+//		Value: func(ctx context.Context) (float64, bool) {
+//			if counter := db.CounterFromContext(ctx); counter != nil {
+//				return float64(counter.Count()), true
+//			}
+//			return 0, false
+//		},
+//	}))
+//
+// Views of custom measures are registered by the caller, e.g. with TagHost and TagOperation keys.
+func Measures(measures ...Measure) Option {
+	return func(c *config) {
+		c.measures = append(c.measures, measures...)
+	}
+}
+
+// userMeasurements appends the measurements of the custom measures of an operation
+func (c *config) userMeasurements(ctx context.Context, measurements []stats.Measurement) []stats.Measurement {
+	for _, m := range c.measures {
+		value, ok := m.Value(ctx)
+		if !ok {
+			continue
+		}
+		if measurement, ok := measurementOf(m.Measure, value); ok {
+			measurements = append(measurements, measurement)
+		}
+	}
+	return measurements
+}
+
+func (c *config) fieldsEnabled() bool {
+	return c.fieldCounts || c.fieldLatency
+}
+
+// FlushInterval enables a high-throughput mode, where measurements are accumulated in counters sharded per CPU, and
+// merged at every interval, e.g. 5s. Metrics are stale until the next flush, but requests no longer contend on
+// recordings.
+//
+// In this mode, the Collector yields its metrics itself, as a metric producer, rather than recording measurements in
+// views: its metrics are named after the registered views, with their tag keys and buckets. Register the Collector
+// with the producers read by exporters, e.g. the Prometheus and Stackdriver exporters:
+//
+//	collector := metrics.New(metrics.FlushInterval(5 * time.Second))
+//	metricproducer.GlobalManager().AddProducer(collector)
+//	defer metricproducer.GlobalManager().DeleteProducer(collector)
+//
+// Series without samples for 10 minutes are dropped, and the operations and fields beyond the bounds of series are
+// counted under "[other]". Close the Collector to flush the last measurements.
+func FlushInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.flushInterval = interval
 	}
 }
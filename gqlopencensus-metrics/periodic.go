@@ -0,0 +1,690 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+const (
+	// maxOperationSeries bounds the number of series of operations, since operation names are chosen by clients. An
+	// operation holds a few series: one for its measures, and one per outcome and error class.
+	maxOperationSeries = 8 * maxCachedTags
+
+	// maxFieldSeries bounds the number of series of fields, e.g. one per path
+	maxFieldSeries = 10 * maxCachedTags
+
+	// seriesExpiry is the time after which series without samples are dropped
+	seriesExpiry = 10 * time.Minute
+
+	// otherSeries is the operation or field name of the samples beyond the bounds of series
+	otherSeries = "[other]"
+)
+
+// ownKeys are the tag keys set by the Collector, rather than carried by the context of requests
+var ownKeys = map[tag.Key]bool{
+	TagHost:        true,
+	TagOperation:   true,
+	TagFingerprint: true,
+	TagErrorClass:  true,
+	TagOutcome:     true,
+	TagStatusClass: true,
+	TagField:       true,
+	TagType:        true,
+	TagPath:        true,
+}
+
+type (
+	// periodic accumulates measurements in atomics sharded per P, merges them into cumulative totals on a ticker,
+	// and yields the totals as the metrics of the registered views
+	periodic struct {
+		*config
+		host   string
+		shards int
+		next   uint32
+		tokens sync.Pool // *int: shard indexes, cached per P
+
+		operations *seriesSet
+		fields     *seriesSet
+		bounds     sync.Map     // measure name -> []float64
+		keys       atomic.Value // *viewKeys
+
+		// mu guards the totals of accumulators, and the expiry of series
+		mu      sync.Mutex
+		flushed time.Time
+
+		done     chan struct{}
+		stopped  chan struct{}
+		stopOnce sync.Once
+	}
+
+	// viewKeys are the tag keys of the registered views which are not set by the Collector: samples are accumulated
+	// in distinct series per value of these keys
+	viewKeys struct {
+		views       []*view.View
+		context     []tag.Key
+		fingerprint bool
+	}
+
+	// seriesSet holds series of one kind, e.g. fields, up to a maximum number
+	seriesSet struct {
+		series sync.Map // key -> *series
+		count  int64
+		max    int64
+	}
+
+	// series accumulates the samples of some measures, with the same tags
+	series struct {
+		tags     map[tag.Key]string
+		measures map[string]*accumulator // measure name -> accumulator: read-only once created
+		start    time.Time
+		counted  bool
+
+		// seen is the time of the last flush with samples, guarded by periodic.mu
+		seen time.Time
+	}
+
+	// operationSeries holds the accumulators of the measures of an operation
+	operationSeries struct {
+		*series
+		requests, latency, parsing, cost, headroom, limit *accumulator
+	}
+
+	// counterSeries holds a counter of an operation, e.g. of its errors of a class
+	counterSeries struct {
+		*series
+		counter *accumulator
+	}
+
+	// fieldSeries holds the latencies of a field, which count the field too
+	fieldSeries struct {
+		*series
+		latency *accumulator
+	}
+
+	operationKey struct {
+		name, fingerprint, context string
+		errorClass, outcome        string
+	}
+
+	fieldSeriesKey struct {
+		fieldKey
+		context string
+	}
+
+	// accumulator accumulates the samples of a measure in shards, merged into cumulative totals on flush. A sample
+	// racing with a flush may be split across two flushes, e.g. its count in the first and its sum in the second.
+	//
+	// Counters accumulate samples of 1, and gauges keep their last sample too.
+	accumulator struct {
+		bounds  []float64
+		shards  []accumulatorShard
+		counter bool
+		gauge   bool
+		last    uint64 // bits of the last sample of a gauge
+
+		// total is guarded by periodic.mu
+		total cumulative
+	}
+
+	accumulatorShard struct {
+		count   int64
+		sum     uint64 // bits of a float64
+		sumSq   uint64 // bits of a float64
+		buckets []int64
+
+		// keeps shards on distinct cache lines
+		_ [64]byte
+	}
+
+	cumulative struct {
+		count      int64
+		sum, sumSq float64
+		buckets    []int64
+		last       float64
+	}
+
+	// operationSample holds the measurements of an operation
+	operationSample struct {
+		latency, parsing float64
+		cost, limit      int
+		costed           bool
+		errorClass       string
+		outcome          string
+	}
+)
+
+func newPeriodic(c *config) *periodic {
+	p := &periodic{
+		config:     c,
+		host:       c.Host,
+		shards:     runtime.GOMAXPROCS(0),
+		operations: &seriesSet{max: maxOperationSeries},
+		fields:     &seriesSet{max: maxFieldSeries},
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	p.tokens.New = func() interface{} {
+		// tokens dropped by the pool are handed out again in turn, so that Ps keep spreading over shards
+		i := int(atomic.AddUint32(&p.next, 1)-1) % p.shards
+		return &i
+	}
+	go p.run()
+	return p
+}
+
+func (p *periodic) run() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			p.flush()
+			return
+		case <-ticker.C:
+			p.flush()
+		}
+	}
+}
+
+func (p *periodic) close() {
+	p.stopOnce.Do(func() { close(p.done) })
+	<-p.stopped
+}
+
+// operation accumulates the measurements of an operation
+func (p *periodic) operation(ctx context.Context, opName, fingerprint string, sample operationSample) {
+	keys := p.viewKeys()
+	key := operationKey{name: opName, context: keys.contextKey(ctx)}
+	if keys.fingerprint {
+		key.fingerprint = fingerprint
+	}
+
+	token := p.tokens.Get().(*int)
+	defer p.tokens.Put(token)
+	shard := *token
+
+	s := p.operationSeries(ctx, key)
+	s.requests.add(shard, 1)
+	s.latency.add(shard, sample.latency)
+	s.parsing.add(shard, sample.parsing)
+	if sample.costed {
+		s.cost.add(shard, float64(sample.cost))
+		if sample.limit > 0 {
+			s.headroom.add(shard, headroom(sample.cost, sample.limit))
+			s.limit.add(shard, float64(sample.limit))
+		}
+	}
+
+	if sample.errorClass != "" {
+		errKey := key
+		errKey.errorClass = sample.errorClass
+		p.counter(ctx, errKey, ServerErrorCount).add(shard, 1)
+	}
+	if sample.outcome != "" {
+		outcomeKey := key
+		outcomeKey.outcome = sample.outcome
+		p.counter(ctx, outcomeKey, ServerResponseCount).add(shard, 1)
+	}
+}
+
+// field accumulates the latency of a field
+func (p *periodic) field(ctx context.Context, key fieldKey, latency float64) {
+	token := p.tokens.Get().(*int)
+	defer p.tokens.Put(token)
+
+	p.fieldSeries(ctx, fieldSeriesKey{fieldKey: key, context: p.viewKeys().contextKey(ctx)}).latency.add(*token, latency)
+}
+
+func (p *periodic) operationSeries(ctx context.Context, key operationKey) *operationSeries {
+	s := p.operations.get(key, func(counted bool) interface{} {
+		if !counted {
+			key = operationKey{name: otherSeries}
+		}
+		s := &operationSeries{
+			requests: p.counterOf(ServerRequestCount),
+			latency:  p.accumulator(ServerLatency, DefaultLatencyDistribution.Buckets),
+			parsing:  p.accumulator(ServerParsing, DefaultLatencyDistribution.Buckets),
+			cost:     p.accumulator(ServerCost, DefaultCostDistribution.Buckets),
+			headroom: p.accumulator(ServerCostHeadroom, DefaultHeadroomDistribution.Buckets),
+			limit:    p.accumulator(ServerCostLimit, nil),
+		}
+		s.limit.gauge = true
+		s.series = p.newSeries(ctx, key.tags(p.host), counted, map[string]*accumulator{
+			ServerRequestCount.Name(): s.requests,
+			ServerLatency.Name():      s.latency,
+			ServerParsing.Name():      s.parsing,
+			ServerCost.Name():         s.cost,
+			ServerCostHeadroom.Name(): s.headroom,
+			ServerCostLimit.Name():    s.limit,
+		})
+		return s
+	}, operationKey{name: otherSeries})
+	return s.(*operationSeries)
+}
+
+// counter of an operation, e.g. of its errors of a class
+func (p *periodic) counter(ctx context.Context, key operationKey, measure stats.Measure) *accumulator {
+	s := p.operations.get(key, func(counted bool) interface{} {
+		if !counted {
+			key = operationKey{name: otherSeries, errorClass: key.errorClass, outcome: key.outcome}
+		}
+		s := &counterSeries{counter: p.counterOf(measure)}
+		s.series = p.newSeries(ctx, key.tags(p.host), counted, map[string]*accumulator{measure.Name(): s.counter})
+		return s
+	}, operationKey{name: otherSeries, errorClass: key.errorClass, outcome: key.outcome})
+	return s.(*counterSeries).counter
+}
+
+func (p *periodic) fieldSeries(ctx context.Context, key fieldSeriesKey) *fieldSeries {
+	s := p.fields.get(key, func(counted bool) interface{} {
+		if !counted {
+			key = fieldSeriesKey{fieldKey: fieldKey{field: otherSeries}}
+		}
+		s := &fieldSeries{latency: p.accumulator(ServerFieldLatency, DefaultLatencyDistribution.Buckets)}
+		measures := make(map[string]*accumulator, 2)
+		if p.fieldCounts {
+			measures[ServerFieldCount.Name()] = s.latency
+		}
+		if p.fieldLatency {
+			measures[ServerFieldLatency.Name()] = s.latency
+		}
+		tags := map[tag.Key]string{TagHost: p.host, TagField: key.field}
+		if key.typ != "" {
+			tags[TagType] = key.typ
+		}
+		if key.path != "" {
+			tags[TagPath] = key.path
+		}
+		s.series = p.newSeries(ctx, tags, counted, measures)
+		return s
+	}, fieldSeriesKey{fieldKey: fieldKey{field: otherSeries}})
+	return s.(*fieldSeries)
+}
+
+// newSeries with some tags, completed with the tags of the registered views carried by ctx
+func (p *periodic) newSeries(ctx context.Context, tags map[tag.Key]string, counted bool, measures map[string]*accumulator) *series {
+	if m := tag.FromContext(ctx); m != nil && counted {
+		for _, k := range p.viewKeys().context {
+			if value, ok := m.Value(k); ok {
+				tags[k] = value
+			}
+		}
+	}
+	now := time.Now()
+	return &series{tags: tags, measures: measures, start: now, seen: now, counted: counted}
+}
+
+func (k operationKey) tags(host string) map[tag.Key]string {
+	tags := map[tag.Key]string{TagHost: host, TagOperation: k.name}
+	if k.fingerprint != "" {
+		tags[TagFingerprint] = k.fingerprint
+	}
+	if k.errorClass != "" {
+		tags[TagErrorClass] = k.errorClass
+	}
+	if k.outcome != "" {
+		tags[TagOutcome] = k.outcome
+	}
+	return tags
+}
+
+// get the series of a key, created by create when missing. Beyond the maximum number of series, samples are
+// accumulated in the series of the other key, which is not counted.
+func (set *seriesSet) get(key interface{}, create func(counted bool) interface{}, other interface{}) interface{} {
+	if s, ok := set.series.Load(key); ok {
+		return s
+	}
+	if atomic.AddInt64(&set.count, 1) > set.max {
+		atomic.AddInt64(&set.count, -1)
+		if s, ok := set.series.Load(other); ok {
+			return s
+		}
+		s, _ := set.series.LoadOrStore(other, create(false))
+		return s
+	}
+	s, loaded := set.series.LoadOrStore(key, create(true))
+	if loaded {
+		atomic.AddInt64(&set.count, -1)
+	}
+	return s
+}
+
+// viewKeys yields the tag keys of the registered views
+func (p *periodic) viewKeys() *viewKeys {
+	views := registeredGQLViews()
+	if keys, _ := p.keys.Load().(*viewKeys); keys != nil && sameViews(keys.views, views) {
+		return keys
+	}
+
+	keys := &viewKeys{views: views}
+	seen := make(map[tag.Key]bool)
+	for _, v := range views {
+		for _, k := range v.TagKeys {
+			if k == TagFingerprint {
+				keys.fingerprint = p.fingerprint
+			}
+			if !ownKeys[k] && !seen[k] {
+				seen[k] = true
+				keys.context = append(keys.context, k)
+			}
+		}
+	}
+	p.keys.Store(keys)
+	return keys
+}
+
+func sameViews(a, b []*view.View) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// contextKey joins the values of the view keys carried by ctx, or yields "" without such keys
+func (k *viewKeys) contextKey(ctx context.Context) string {
+	if len(k.context) == 0 {
+		return ""
+	}
+	m := tag.FromContext(ctx)
+	if m == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, key := range k.context {
+		if value, ok := m.Value(key); ok {
+			b.WriteString(value)
+		}
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// accumulator of a measure, with the buckets of its registered distribution view, or the default buckets
+func (p *periodic) accumulator(measure stats.Measure, buckets []float64) *accumulator {
+	if bounds, ok := p.bounds.Load(measure.Name()); ok {
+		buckets = bounds.([]float64)
+	} else {
+		for _, v := range registeredGQLViews() {
+			if registered := view.Find(v.Name); registered != nil && registered.Measure.Name() == measure.Name() &&
+				registered.Aggregation.Type == view.AggTypeDistribution {
+				buckets = registered.Aggregation.Buckets
+				break
+			}
+		}
+		p.bounds.Store(measure.Name(), buckets)
+	}
+
+	a := &accumulator{bounds: buckets, shards: make([]accumulatorShard, p.shards)}
+	for i := range a.shards {
+		a.shards[i].buckets = make([]int64, len(buckets)+1)
+	}
+	a.total.buckets = make([]int64, len(buckets)+1)
+	return a
+}
+
+// counterOf a measure
+func (p *periodic) counterOf(measure stats.Measure) *accumulator {
+	a := p.accumulator(measure, nil)
+	a.counter = true
+	return a
+}
+
+// add a sample to a shard
+func (a *accumulator) add(shard int, value float64) {
+	s := &a.shards[shard]
+	if !a.counter {
+		if a.gauge {
+			atomic.StoreUint64(&a.last, math.Float64bits(value))
+		}
+		if len(a.bounds) > 0 {
+			bucket := sort.SearchFloat64s(a.bounds, value)
+			if bucket < len(a.bounds) && a.bounds[bucket] == value {
+				// bounds are the lower bounds of the next buckets
+				bucket++
+			}
+			atomic.AddInt64(&s.buckets[bucket], 1)
+		}
+		addFloat(&s.sum, value)
+		addFloat(&s.sumSq, value*value)
+	}
+	atomic.AddInt64(&s.count, 1)
+}
+
+// merge the samples of the shards into the totals. It reports whether there were any samples.
+func (a *accumulator) merge() bool {
+	merged := false
+	for i := range a.shards {
+		s := &a.shards[i]
+		count := atomic.SwapInt64(&s.count, 0)
+		if count == 0 {
+			continue
+		}
+		merged = true
+		a.total.count += count
+		if len(a.bounds) > 0 {
+			for bucket := range s.buckets {
+				a.total.buckets[bucket] += atomic.SwapInt64(&s.buckets[bucket], 0)
+			}
+		} else {
+			a.total.buckets[0] += count
+		}
+		if a.counter {
+			// samples of 1 are implicit
+			a.total.sum += float64(count)
+			a.total.sumSq += float64(count)
+			continue
+		}
+		a.total.sum += swapFloat(&s.sum)
+		a.total.sumSq += swapFloat(&s.sumSq)
+	}
+	if merged && a.gauge {
+		a.total.last = math.Float64frombits(atomic.LoadUint64(&a.last))
+	}
+	return merged
+}
+
+// flush merges the accumulated samples into the totals, and drops the series without samples for long
+func (p *periodic) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.flushed = now
+	for _, set := range []*seriesSet{p.operations, p.fields} {
+		set.series.Range(func(key, value interface{}) bool {
+			s := seriesOf(value)
+			merged := false
+			for _, a := range s.measures {
+				if a.merge() {
+					merged = true
+				}
+			}
+			switch {
+			case merged:
+				s.seen = now
+			case now.Sub(s.seen) > seriesExpiry:
+				// a sample racing with the expiry of its series is lost
+				set.series.Delete(key)
+				if s.counted {
+					atomic.AddInt64(&set.count, -1)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// seriesOf the values of series sets
+func seriesOf(value interface{}) *series {
+	return value.(interface{ base() *series }).base()
+}
+
+func (s *series) base() *series {
+	return s
+}
+
+// read the metrics of the registered views of the accumulated measures, as of the last flush
+func (p *periodic) read() []*metricdata.Metric {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.flushed.IsZero() {
+		return nil
+	}
+	var metrics []*metricdata.Metric
+	for _, v := range registeredGQLViews() {
+		registered := view.Find(v.Name)
+		if registered == nil {
+			continue
+		}
+		if metric := p.metric(registered); metric != nil {
+			metrics = append(metrics, metric)
+		}
+	}
+	return metrics
+}
+
+// metric of a view: the totals of the series of its measure, summed by the tag keys of the view
+func (p *periodic) metric(v *view.View) *metricdata.Metric {
+	type group struct {
+		values []metricdata.LabelValue
+		start  time.Time
+		seen   time.Time
+		total  cumulative
+		bounds []float64
+	}
+	groups := make(map[string]*group)
+	var order []string
+
+	for _, set := range []*seriesSet{p.operations, p.fields} {
+		set.series.Range(func(_, value interface{}) bool {
+			s := seriesOf(value)
+			a := s.measures[v.Measure.Name()]
+			if a == nil || a.total.count == 0 {
+				return true
+			}
+
+			values := make([]metricdata.LabelValue, 0, len(v.TagKeys))
+			var key strings.Builder
+			for _, k := range v.TagKeys {
+				if value, ok := s.tags[k]; ok {
+					values = append(values, metricdata.NewLabelValue(value))
+					key.WriteString(value)
+				} else {
+					values = append(values, metricdata.LabelValue{})
+					key.WriteByte(1)
+				}
+				key.WriteByte(0)
+			}
+
+			g, ok := groups[key.String()]
+			if !ok {
+				g = &group{values: values, start: s.start, seen: s.seen, bounds: a.bounds}
+				g.total.buckets = make([]int64, len(a.total.buckets))
+				groups[key.String()] = g
+				order = append(order, key.String())
+			}
+			if s.start.Before(g.start) {
+				g.start = s.start
+			}
+			if !s.seen.Before(g.seen) {
+				g.seen, g.total.last = s.seen, a.total.last
+			}
+			g.total.count += a.total.count
+			g.total.sum += a.total.sum
+			g.total.sumSq += a.total.sumSq
+			for i := range g.total.buckets {
+				g.total.buckets[i] += a.total.buckets[i]
+			}
+			return true
+		})
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	_, isInt := v.Measure.(*stats.Int64Measure)
+	metric := &metricdata.Metric{
+		Descriptor: metricdata.Descriptor{
+			Name:        v.Name,
+			Description: v.Description,
+			Unit:        metricdata.Unit(v.Measure.Unit()),
+		},
+	}
+	for _, k := range v.TagKeys {
+		metric.Descriptor.LabelKeys = append(metric.Descriptor.LabelKeys, metricdata.LabelKey{Key: k.Name()})
+	}
+
+	for _, key := range order {
+		g := groups[key]
+		ts := &metricdata.TimeSeries{LabelValues: g.values, StartTime: g.start}
+		switch v.Aggregation.Type {
+		case view.AggTypeCount:
+			metric.Descriptor.Type, metric.Descriptor.Unit = metricdata.TypeCumulativeInt64, metricdata.UnitDimensionless
+			ts.Points = []metricdata.Point{metricdata.NewInt64Point(p.flushed, g.total.count)}
+		case view.AggTypeSum:
+			metric.Descriptor.Type = metricdata.TypeCumulativeFloat64
+			ts.Points = []metricdata.Point{metricdata.NewFloat64Point(p.flushed, g.total.sum)}
+			if isInt {
+				metric.Descriptor.Type = metricdata.TypeCumulativeInt64
+				ts.Points = []metricdata.Point{metricdata.NewInt64Point(p.flushed, int64(g.total.sum))}
+			}
+		case view.AggTypeLastValue:
+			metric.Descriptor.Type = metricdata.TypeGaugeFloat64
+			ts.StartTime = time.Time{}
+			ts.Points = []metricdata.Point{metricdata.NewFloat64Point(p.flushed, g.total.last)}
+			if isInt {
+				metric.Descriptor.Type = metricdata.TypeGaugeInt64
+				ts.Points = []metricdata.Point{metricdata.NewInt64Point(p.flushed, int64(g.total.last))}
+			}
+		case view.AggTypeDistribution:
+			metric.Descriptor.Type = metricdata.TypeCumulativeDistribution
+			ts.Points = []metricdata.Point{metricdata.NewDistributionPoint(p.flushed, g.total.distribution(g.bounds))}
+		default:
+			return nil
+		}
+		metric.TimeSeries = append(metric.TimeSeries, ts)
+	}
+	return metric
+}
+
+// distribution of the samples accumulated with some bounds
+func (c cumulative) distribution(bounds []float64) *metricdata.Distribution {
+	d := &metricdata.Distribution{
+		Count:         c.count,
+		Sum:           c.sum,
+		BucketOptions: &metricdata.BucketOptions{Bounds: bounds},
+		Buckets:       make([]metricdata.Bucket, 0, len(c.buckets)),
+	}
+	if c.count > 0 {
+		d.SumOfSquaredDeviation = math.Max(0, c.sumSq-c.sum*c.sum/float64(c.count))
+	}
+	for _, count := range c.buckets {
+		d.Buckets = append(d.Buckets, metricdata.Bucket{Count: count})
+	}
+	return d
+}
+
+func addFloat(addr *uint64, value float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if atomic.CompareAndSwapUint64(addr, old, math.Float64bits(math.Float64frombits(old)+value)) {
+			return
+		}
+	}
+}
+
+func swapFloat(addr *uint64) float64 {
+	return math.Float64frombits(atomic.SwapUint64(addr, 0))
+}
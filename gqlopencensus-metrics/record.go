@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+type scopeKey struct{}
+
+// scope of the operation measured by a Collector
+type scope struct {
+	tags      []tag.Mutator
+	collector Collector
+}
+
+func scopeFromContext(ctx context.Context) *scope {
+	s, _ := ctx.Value(scopeKey{}).(*scope)
+	return s
+}
+
+// TagsFromContext yields the tags of the operation being measured by a Collector: host and operation name, e.g. to
+// record custom measurements from a resolver with the same tags as the operation:
+//
+//	stats.RecordWithTags(ctx, metrics.TagsFromContext(ctx), itemsLoaded.M(int64(len(items))))
+//
+// The fingerprint of the operation is included with the Fingerprint option, while the tags of the Tags option are
+// carried by the context already. It yields nil outside of the operations measured by a Collector.
+func TagsFromContext(ctx context.Context) []tag.Mutator {
+	s := scopeFromContext(ctx)
+	if s == nil {
+		return nil
+	}
+	return append([]tag.Mutator{}, s.tags...)
+}
+
+// Record a measurement of a custom measure from a resolver, with the tags of the operation (see TagsFromContext)
+// and of the field being resolved: field name, parent type and path, as for the field views. Measurements of
+// Int64 measures are truncated.
+//
+// Example, to slice the rows scanned by resolvers along the GraphQL dimensions:
+//
+//	rowsScanned := stats.Int64("app/rows_scanned", "Rows scanned", stats.UnitDimensionless)
+//	view.Register(&view.View{
+//		Name:        "app/rows_scanned",
+//		Measure:     rowsScanned,
+//		Aggregation: view.Sum(),
+//		TagKeys:     []tag.Key{metrics.TagHost, metrics.TagOperation, metrics.TagField, metrics.TagPath},
+//	})
+//
+//	metrics.Record(ctx, rowsScanned, float64(len(rows)))
+//
+// Outside of the operations measured by a Collector, the measurement is recorded with the tags of the context only.
+func Record(ctx context.Context, measure stats.Measure, value float64) {
+	measurement, ok := measurementOf(measure, value)
+	if !ok {
+		return
+	}
+
+	s := scopeFromContext(ctx)
+	if s == nil {
+		stats.Record(ctx, measurement)
+		return
+	}
+	mutators := s.tags
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		field := s.collector.fieldTagger(s.collector.fieldTags(ctx, fc))
+		mutators = append(append(make([]tag.Mutator, 0, len(mutators)+len(field)), mutators...), field...)
+	}
+	_ = stats.RecordWithTags(ctx, mutators, measurement)
+}
+
+// measurementOf yields a measurement of an Int64 or Float64 measure
+func measurementOf(measure stats.Measure, value float64) (stats.Measurement, bool) {
+	switch m := measure.(type) {
+	case *stats.Int64Measure:
+		return m.M(int64(value)), true
+	case *stats.Float64Measure:
+		return m.M(value), true
+	}
+	return stats.Measurement{}, false
+}
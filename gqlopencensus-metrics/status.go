@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+type (
+	statusKey struct{}
+
+	// status holds the tags of the first operation of a request, for the StatusMiddleware
+	status struct {
+		mu      sync.Mutex
+		tags    []tag.Mutator
+		outcome string
+	}
+
+	// statusWriter captures the HTTP status of a response
+	statusWriter struct {
+		http.ResponseWriter
+		status int
+	}
+)
+
+// StatusMiddleware counts HTTP responses by status class ("2xx", "4xx", "5xx"), along with the operation and outcome
+// of their GraphQL response (OperationStatusView), so that load balancer metrics reconcile with GraphQL metrics, e.g.
+// 200 responses with errors:
+//
+//	http.Handle("/query", metrics.StatusMiddleware(srv))
+//
+// Responses are tagged by the Collector: requests which never reach it have no operation nor outcome tags, and invalid
+// operations have no operation tag. Batched requests are tagged with their first operation.
+func StatusMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !viewsRegistered() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s := &status{}
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), statusKey{}, s)))
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+
+		s.mu.Lock()
+		mutators := append(append(make([]tag.Mutator, 0, len(s.tags)+2), s.tags...), tag.Upsert(TagStatusClass, statusClass(sw.status)))
+		if s.outcome != "" {
+			mutators = append(mutators, tag.Upsert(TagOutcome, s.outcome))
+		}
+		s.mu.Unlock()
+		_ = stats.RecordWithTags(r.Context(), mutators, ServerHTTPResponseCount.M(1))
+	})
+}
+
+// reportStatus reports the tags and outcome of an operation to the StatusMiddleware, if installed
+func reportStatus(ctx context.Context, tags []tag.Mutator, outcome string) {
+	s, ok := ctx.Value(statusKey{}).(*status)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.outcome == "" {
+		s.tags = tags
+		s.outcome = outcome
+	}
+}
+
+// statusClass of an HTTP status, e.g. "4xx"
+func statusClass(code int) string {
+	return strconv.Itoa(code/100) + "xx"
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, for incremental transports
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, for websockets
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("metrics: the response writer does not support hijacking")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap yields the wrapped response writer, for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opencensus.io/tag"
+)
+
+// maxCachedTags bounds the number of operations and fields with cached mutators, since operation names are
+// chosen by clients
+const maxCachedTags = 1000
+
+// tagger precomputes the tag mutators of the Collector, for its host and for known operations and fields.
+//
+// Cached slices are shared: callers must not modify them.
+type tagger struct {
+	host tag.Mutator
+
+	operations sync.Map // operation name -> *operationTags
+	fields     sync.Map // field name -> tag.Mutator
+	types      sync.Map // type name -> tag.Mutator
+	cached     int64
+}
+
+func newTagger(host string) *tagger {
+	return &tagger{host: tag.Upsert(TagHost, host)}
+}
+
+// operationTags are the mutators of an operation, and the tag map they build from an empty context
+type operationTags struct {
+	mutators []tag.Mutator
+	tags     *tag.Map
+}
+
+func (t *tagger) operationTags(opName string) *operationTags {
+	if cached, ok := t.operations.Load(opName); ok {
+		return cached.(*operationTags)
+	}
+
+	o := &operationTags{mutators: []tag.Mutator{t.host, tag.Upsert(TagOperation, opName)}}
+	if ctx, err := tag.New(context.Background(), o.mutators...); err == nil {
+		o.tags = tag.FromContext(ctx)
+	}
+	t.cache(&t.operations, opName, o)
+	return o
+}
+
+// operation yields the mutators of an operation: host and operation name
+func (t *tagger) operation(opName string) []tag.Mutator {
+	return t.operationTags(opName).mutators
+}
+
+// operationContext yields a context tagged with the host and operation name, to record all the measurements of
+// an operation.
+//
+// When ctx carries no tags, the tag map of the operation is reused instead of being built again.
+func (t *tagger) operationContext(ctx context.Context, opName string) context.Context {
+	o := t.operationTags(opName)
+	if o.tags != nil && tag.FromContext(ctx) == nil {
+		return tag.NewContext(ctx, o.tags)
+	}
+	if tagged, err := tag.New(ctx, o.mutators...); err == nil {
+		return tagged
+	}
+	return ctx
+}
+
+// fieldKey holds the tags of a field
+type fieldKey struct {
+	field, typ, path string
+}
+
+// field yields the mutators of a field: host, field name, then parent type and path unless they are omitted
+func (t *tagger) field(key fieldKey) []tag.Mutator {
+	mutators := make([]tag.Mutator, 0, 4)
+	mutators = append(mutators, t.host, t.mutator(&t.fields, TagField, key.field))
+	if key.typ != "" {
+		mutators = append(mutators, t.mutator(&t.types, TagType, key.typ))
+	}
+	if key.path != "" {
+		mutators = append(mutators, tag.Upsert(TagPath, key.path))
+	}
+	return mutators
+}
+
+// mutator yields a cached mutator upserting a tag value
+func (t *tagger) mutator(m *sync.Map, key tag.Key, value string) tag.Mutator {
+	if cached, ok := m.Load(value); ok {
+		return cached.(tag.Mutator)
+	}
+	mutator := tag.Upsert(key, value)
+	t.cache(m, value, mutator)
+	return mutator
+}
+
+func (t *tagger) cache(m *sync.Map, key string, value interface{}) {
+	if atomic.LoadInt64(&t.cached) >= maxCachedTags {
+		return
+	}
+	if _, loaded := m.LoadOrStore(key, value); !loaded {
+		atomic.AddInt64(&t.cached, 1)
+	}
+}
@@ -0,0 +1,52 @@
+package gqlopencensus
+
+import (
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+	"github.com/99designs/gqlgen-contrib/internal/env"
+)
+
+// Environment variables of the tracer, besides the shared variables of gqlinstrument.FromEnv
+const (
+	// EnvSample sets the sampling rate of operations, e.g. GQL_TRACE_SAMPLE=0.1
+	EnvSample = "GQL_TRACE_SAMPLE"
+	// EnvRawQuery enables WithRawQuery, e.g. GQL_TRACE_RAW_QUERY=true
+	EnvRawQuery = "GQL_TRACE_RAW_QUERY"
+	// EnvVariables enables WithVariables, e.g. GQL_TRACE_VARIABLES=true
+	EnvVariables = "GQL_TRACE_VARIABLES"
+	// EnvArgs enables WithArgs, e.g. GQL_TRACE_ARGS=true
+	EnvArgs = "GQL_TRACE_ARGS"
+)
+
+// NewFromEnv builds a tracer with options, tuned by environment variables: environment variables take precedence.
+//
+// Example, to trace 10% of operations of the checkout service:
+//
+//	GQL_HOST=checkout GQL_TRACE_SAMPLE=0.1
+func NewFromEnv(opts ...Option) (*Tracer, error) {
+	common, err := gqlinstrument.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, Common(common...))
+
+	r := env.New()
+	r.Fraction(EnvSample, func(fraction float64) {
+		opts = append(opts, WithSampler(trace.ProbabilitySampler(fraction)))
+	})
+	enable := func(opt Option) func(bool) {
+		return func(enabled bool) {
+			if enabled {
+				opts = append(opts, opt)
+			}
+		}
+	}
+	r.Bool(EnvRawQuery, enable(WithRawQuery()))
+	r.Bool(EnvVariables, enable(WithVariables()))
+	r.Bool(EnvArgs, enable(WithArgs()))
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return New(opts...), nil
+}
@@ -2,9 +2,15 @@ package gqlopencensus
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/errclass"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
 )
 
 // Option for an opencensus tracer. At this moment, it is possible to configure span attributes retrieved from the GraphQL contexts.
@@ -45,7 +51,11 @@ func OperationAttribute(key, value string) OperationAttributer {
 type config struct {
 	fieldAttributers     []FieldAttributer
 	operationAttributers []OperationAttributer
-	onlyMethods          bool
+	sampler              trace.Sampler
+	fingerprint          bool
+	classifier           errclass.Classifier
+	now                  func() time.Time
+	gqlinstrument.Config
 }
 
 func (c config) fieldAttributes(ctx *graphql.FieldContext) []trace.Attribute {
@@ -75,13 +85,18 @@ func defaultTracer() *Tracer {
 			},
 			},
 			operationAttributers: []OperationAttributer{func(oc *graphql.OperationContext) []trace.Attribute {
-				return []trace.Attribute{
+				attrs := []trace.Attribute{
 					trace.StringAttribute("server", "gqlgen"),
-					trace.StringAttribute("operation", operationName(oc)),
+					trace.StringAttribute("operation", fingerprint.OperationName(oc)),
 				}
+				if cs := gqlcomplexity.GetOperationStats(oc); cs != nil {
+					attrs = append(attrs, trace.Int64Attribute("cost", int64(cs.Cost)))
+				}
+				return attrs
 			},
 			},
-			onlyMethods: true,
+			now:    graphql.Now,
+			Config: gqlinstrument.Default(),
 		},
 	}
 }
@@ -106,7 +121,7 @@ func WithDataDog() Option {
 	return func(c *config) {
 		c.operationAttributers = append(c.operationAttributers, func(oc *graphql.OperationContext) []trace.Attribute {
 			return []trace.Attribute{
-				trace.StringAttribute("resource.name", operationName(oc)),
+				trace.StringAttribute("resource.name", fingerprint.OperationName(oc)),
 			}
 		})
 	}
@@ -123,6 +138,23 @@ func WithRawQuery() Option {
 	}
 }
 
+// WithFingerprint adds the hash of the fingerprint of an operation to its span, and names the spans of anonymous
+// operations after their fingerprint, e.g. "query 3f2a9c0b1d4e" rather than "query". This is disabled by default.
+func WithFingerprint() Option {
+	return func(c *config) {
+		c.fingerprint = true
+		c.operationAttributers = append(c.operationAttributers, func(oc *graphql.OperationContext) []trace.Attribute {
+			hash := fingerprint.Of(oc).Hash
+			if hash == "" {
+				return nil
+			}
+			return []trace.Attribute{
+				trace.StringAttribute("fingerprint", hash),
+			}
+		})
+	}
+}
+
 // WithVariables adds the values of all variables attached to the GraphL query to the trace span of an operation. This is disabled by default.
 func WithVariables() Option {
 	return func(c *config) {
@@ -151,20 +183,49 @@ func WithArgs() Option {
 // When set to false, all fields produce a span.
 func OnlyMethods(enabled bool) Option {
 	return func(c *config) {
-		c.onlyMethods = enabled
+		c.OnlyMethods = enabled
+	}
+}
+
+// WithSampler samples operation spans with a given sampler, e.g. trace.ProbabilitySampler(0.1), rather than with
+// the default sampler of opencensus
+func WithSampler(sampler trace.Sampler) Option {
+	return func(c *config) {
+		c.sampler = sampler
 	}
 }
 
-func operationName(ctx *graphql.OperationContext) (opName string) {
-	if ctx.Operation != nil {
-		opName = ctx.Operation.Name
+// WithErrorClassifier classifies the errors of operations, to set the status of their spans: the class of the worst
+// error maps to the status code, e.g. DEADLINE_EXCEEDED for timeouts. The default is errclass.Default().
+func WithErrorClassifier(classifier errclass.Classifier) Option {
+	return func(c *config) {
+		c.classifier = classifier
 	}
-	if opName == "" && ctx.Operation != nil {
-		//parent response case
-		opName = string(ctx.Operation.Operation)
+}
+
+// WithClock times operations with a given clock, e.g. a fake clock in tests, for the "latency_ms" attribute of
+// their spans. opencensus stamps spans with the system clock. By default this is graphql.Now.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
 	}
-	if opName == "" {
-		opName = ctx.OperationName
+}
+
+// Common applies options shared with other instrumentation extensions, e.g. the host, sampler and tags
+func Common(opts ...gqlinstrument.Option) Option {
+	return func(c *config) {
+		for _, apply := range opts {
+			apply(&c.Config)
+		}
+	}
+}
+
+// spanName yields the name of the span of an operation
+func (c config) spanName(oc *graphql.OperationContext) string {
+	if c.fingerprint && oc.Operation != nil && oc.Operation.Name == "" {
+		if f := fingerprint.Of(oc); f.Hash != "" {
+			return string(oc.Operation.Operation) + " " + f.Short()
+		}
 	}
-	return
+	return fingerprint.OperationName(oc)
 }
@@ -0,0 +1,30 @@
+package gqlopencensus
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"go.opencensus.io/trace"
+)
+
+var _ trace.Exporter = &SpanPrinter{}
+
+// SpanPrinter is a trace exporter writing a line per ended span, e.g. to the standard output
+type SpanPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewSpanPrinter writes spans to w
+func NewSpanPrinter(w io.Writer) *SpanPrinter {
+	return &SpanPrinter{w: w}
+}
+
+// ExportSpan implements trace.Exporter
+func (p *SpanPrinter) ExportSpan(s *trace.SpanData) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = fmt.Fprintf(p.w, "trace=%s span=%s parent=%s name=%q duration=%s status=%d\n",
+		s.TraceID, s.SpanID, s.ParentSpanID, s.Name, s.EndTime.Sub(s.StartTime), s.Code)
+}
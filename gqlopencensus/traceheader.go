@@ -0,0 +1,82 @@
+package gqlopencensus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"go.opencensus.io/trace"
+)
+
+// TraceParentHeader is the W3C trace context header echoed by TraceHeader by default
+const TraceParentHeader = "traceparent"
+
+type traceHeaderKey struct{}
+
+type traceHeader struct {
+	mu        sync.Mutex
+	header    http.Header
+	name      string
+	traceID   bool
+	operation bool
+}
+
+// TraceHeaderOption configures the TraceHeader middleware
+type TraceHeaderOption func(*traceHeader)
+
+// TraceIDHeader echoes the bare trace ID, in hex, under the given header (e.g. "X-Trace-Id") rather than a W3C
+// traceparent.
+func TraceIDHeader(name string) TraceHeaderOption {
+	return func(h *traceHeader) {
+		h.name = name
+		h.traceID = true
+	}
+}
+
+// TraceHeader is an HTTP middleware echoing the trace context of the server on the response, so that clients may
+// correlate their logs with server traces, even when they did not start the trace:
+//
+//	http.Handle("/query", gqlopencensus.TraceHeader(srv))
+//
+// The header carries the span of the first operation traced by a Tracer, or else the span found in the request
+// context (e.g. started by an ochttp.Handler wrapping the middleware). By default, it is a W3C traceparent header.
+func TraceHeader(next http.Handler, opts ...TraceHeaderOption) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := &traceHeader{header: w.Header(), name: TraceParentHeader}
+		for _, apply := range opts {
+			apply(h)
+		}
+		if span := trace.FromContext(r.Context()); span != nil {
+			h.set(span.SpanContext(), false)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), traceHeaderKey{}, h)))
+	})
+}
+
+// echoTraceContext sets the trace header of the response to the span of an operation
+func echoTraceContext(ctx context.Context, sc trace.SpanContext) {
+	if h, ok := ctx.Value(traceHeaderKey{}).(*traceHeader); ok {
+		h.set(sc, true)
+	}
+}
+
+func (h *traceHeader) set(sc trace.SpanContext, operation bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.operation {
+		// keep the span of the first operation of a batch
+		return
+	}
+	h.operation = operation
+	if h.traceID {
+		h.header.Set(h.name, sc.TraceID.String())
+		return
+	}
+	h.header.Set(h.name, traceParent(sc))
+}
+
+// traceParent formats a span context as a W3C traceparent header value
+func traceParent(sc trace.SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, uint32(sc.TraceOptions)&1)
+}
@@ -0,0 +1,67 @@
+package gqlopencensus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlopencensus/tracetest"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestTraceHeader(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	srv := handler.New(testschema.New(`type Query { todo: String }`, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New())
+
+	serve := func(h http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query named { todo }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("traceparent of the operation", func(t *testing.T) {
+		w := serve(TraceHeader(srv))
+
+		span := tracetest.Span(t, rec.WaitForSpans(t, 1), "named")
+		sc := span.SpanContext
+		assert.Equal(t, "00-"+sc.TraceID.String()+"-"+sc.SpanID.String()+"-01", w.Header().Get("traceparent"))
+		rec.Reset()
+	})
+
+	t.Run("trace id", func(t *testing.T) {
+		w := serve(TraceHeader(srv, TraceIDHeader("X-Trace-Id")))
+
+		span := tracetest.Span(t, rec.WaitForSpans(t, 1), "named")
+		assert.Equal(t, span.SpanContext.TraceID.String(), w.Header().Get("X-Trace-Id"))
+		assert.Empty(t, w.Header().Get("traceparent"))
+		rec.Reset()
+	})
+
+	t.Run("span of the request without tracer", func(t *testing.T) {
+		bare := handler.New(testschema.New(`type Query { todo: String }`, nil))
+		bare.AddTransport(transport.POST{})
+		w := serve(&ochttp.Handler{
+			Handler:      TraceHeader(bare),
+			StartOptions: trace.StartOptions{Sampler: trace.AlwaysSample()},
+		})
+
+		spans := rec.WaitForSpans(t, 1)
+		require.Len(t, spans, 1)
+		sc := spans[0].SpanContext
+		assert.Equal(t, "00-"+sc.TraceID.String()+"-"+sc.SpanID.String()+"-01", w.Header().Get("traceparent"))
+	})
+}
@@ -2,14 +2,41 @@ package gqlopencensus
 
 import (
 	"context"
+	"time"
 
 	"github.com/99designs/gqlgen/graphql"
 	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/errclass"
+	"github.com/99designs/gqlgen-contrib/internal/fieldpath"
+	"github.com/99designs/gqlgen-contrib/internal/toggle"
 )
 
+type operationSpanKey struct{}
+
+// SpanFromContext yields the current span of a traced operation, e.g. to add attributes or annotations from a
+// resolver: the span of the field being resolved, or the span of the operation when fields are not traced.
+//
+// It yields nil outside of the operations traced by a Tracer.
+func SpanFromContext(ctx context.Context) *trace.Span {
+	if OperationSpanFromContext(ctx) == nil {
+		return nil
+	}
+	return trace.FromContext(ctx)
+}
+
+// OperationSpanFromContext yields the span of the operation traced by a Tracer, or nil
+func OperationSpanFromContext(ctx context.Context) *trace.Span {
+	span, _ := ctx.Value(operationSpanKey{}).(*trace.Span)
+	return span
+}
+
 // Tracer enables opencensus tracing on gqlgen
 type Tracer struct {
 	config
+	toggle     *toggle.Toggle
+	fieldSpans *toggle.Toggle
 }
 
 var _ interface {
@@ -25,28 +52,47 @@ func New(opts ...Option) *Tracer {
 	for _, apply := range opts {
 		apply(&tr.config)
 	}
+	tr.toggle = &toggle.Toggle{}
+	tr.fieldSpans = &toggle.Toggle{}
 	return tr
 }
 
+// SetEnabled switches tracing on or off at runtime. Tracers are enabled by default.
+func (tr *Tracer) SetEnabled(enabled bool) {
+	tr.toggle.Set(enabled)
+}
+
+// SetFieldSpans switches the spans of fields on or off at runtime, keeping the spans of operations, e.g. to trace
+// fields during an incident only. Field spans are on by default.
+func (tr *Tracer) SetFieldSpans(enabled bool) {
+	tr.fieldSpans.Set(enabled)
+}
+
 // ExtensionName implements the graphql.HandlerExtension
 func (Tracer) ExtensionName() string {
 	return "Opencensustracing"
 }
 
-// Validate implements the graphql.HandlerExtension
-func (Tracer) Validate(schema graphql.ExecutableSchema) error {
-	return nil
+// Validate implements the graphql.HandlerExtension. It computes the hash of the schema, with the
+// gqlinstrument.SchemaHash option.
+func (tr Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return tr.Config.Validate(schema)
 }
 
 // InterceptField implements graphql.FieldInterceptor
 func (tr Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+	if parent := trace.FromContext(ctx); parent != nil && !parent.IsRecordingEvents() || !tr.toggle.Enabled() || !tr.fieldSpans.Enabled() {
+		// the operation is not sampled, or tracing is off: skip all the work on fields
+		return next(ctx)
+	}
+
 	fc := graphql.GetFieldContext(ctx)
-	if tr.onlyMethods && !fc.IsMethod {
-		// only capture fields which correspond to a resolver method
+	if tr.SkipField(ctx, fc) {
+		// only capture sampled fields which correspond to a resolver method
 		return next(ctx)
 	}
 	ctx, span := trace.StartSpan(ctx,
-		fc.Path().String(),
+		fieldpath.String(ctx, fc),
 		trace.WithSpanKind(trace.SpanKindServer),
 	)
 	span.AddAttributes(tr.config.fieldAttributes(fc)...)
@@ -57,26 +103,60 @@ func (tr Tracer) InterceptField(ctx context.Context, next graphql.Resolver) (res
 
 // InterceptResponse implements graphql.OperationInterceptor
 func (tr Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !tr.toggle.Enabled() {
+		return next(ctx)
+	}
+
 	oc := graphql.GetOperationContext(ctx)
-	ctx, span := trace.StartSpan(ctx,
-		operationName(oc),
-		trace.WithSpanKind(trace.SpanKindServer),
-	)
+	ctx = fieldpath.WithCache(ctx)
+	ctx, _ = tr.Sample(ctx, oc)
+	startOptions := []trace.StartOption{trace.WithSpanKind(trace.SpanKindServer)}
+	if tr.sampler != nil {
+		startOptions = append(startOptions, trace.WithSampler(tr.sampler))
+	}
+	ctx, span := trace.StartSpan(ctx, tr.spanName(oc), startOptions...)
 	defer span.End()
+	ctx = context.WithValue(ctx, operationSpanKey{}, span)
+	echoTraceContext(ctx, span.SpanContext())
 
 	span.AddAttributes(tr.config.operationAttributes(oc)...)
+	if tr.Host != "" {
+		span.AddAttributes(trace.StringAttribute("host", tr.Host))
+	}
+	for _, t := range tr.TagValues(ctx) {
+		span.AddAttributes(trace.StringAttribute(t.Key.Name(), t.Value))
+	}
+	for _, attr := range clientinfo.Attributes(ctx) {
+		span.AddAttributes(trace.StringAttribute(attr.Key, attr.Value))
+	}
 
+	start := tr.now()
 	resp := next(ctx)
 	if resp == nil {
 		return nil
 	}
+	span.AddAttributes(trace.Float64Attribute("latency_ms", float64(tr.now().Sub(start))/float64(time.Millisecond)))
 
-	if errs := resp.Errors; len(errs) > 0 {
+	if classification, failed := errclass.Worst(tr.classifier, resp.Errors); failed {
+		span.AddAttributes(trace.StringAttribute("error.class", string(classification.Class)))
 		span.SetStatus(trace.Status{
-			Code:    trace.StatusCodeUnknown,
-			Message: errs.Error(),
+			Code:    statusCode(classification.Class),
+			Message: resp.Errors.Error(),
 		})
 	}
 
 	return resp
 }
+
+// statusCode maps a class of errors to the code of a span status
+func statusCode(class errclass.Class) int32 {
+	switch class {
+	case errclass.Client:
+		return trace.StatusCodeInvalidArgument
+	case errclass.Timeout:
+		return trace.StatusCodeDeadlineExceeded
+	case errclass.Canceled:
+		return trace.StatusCodeCancelled
+	}
+	return trace.StatusCodeInternal
+}
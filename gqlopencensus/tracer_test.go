@@ -0,0 +1,249 @@
+package gqlopencensus
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus/tracetest"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestTracer(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	srv := handler.New(testschema.New(`
+		type Query { todo: Todo }
+		type Todo { text: String done: Boolean }
+	`, testschema.Resolvers{
+		"Todo.done": func(context.Context) (interface{}, error) { return nil, errors.New("boom") },
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(OnlyMethods(false)))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query todos { todo { text done } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.WaitForSpans(t, 4)
+	tracetest.AssertTree(t, spans, `
+		todos
+		  todo
+		  todo.text
+		  todo.done
+	`)
+
+	op := tracetest.Span(t, spans, "todos")
+	tracetest.AssertAttributes(t, op, map[string]interface{}{"server": "gqlgen", "operation": "todos"})
+	tracetest.AssertStatus(t, op, trace.StatusCodeInternal)
+	tracetest.AssertParent(t, tracetest.Span(t, spans, "todo.done"), op)
+	tracetest.AssertAttributes(t, tracetest.Span(t, spans, "todo.done"), map[string]interface{}{"field": "done"})
+}
+
+func TestCommon(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	srv := handler.New(testschema.New(`
+		type Query { todo: Todo }
+		type Todo { text: String }
+	`, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(Common(
+		gqlinstrument.Host("checkout"),
+		gqlinstrument.OnlyMethods(false),
+		gqlinstrument.SkipIntrospection(),
+		gqlinstrument.Tags(gqlinstrument.Tag{
+			Key:   tag.MustNewKey("gql.client"),
+			Value: func(context.Context) string { return "web" },
+		}),
+	)))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query todos { todo { text __typename } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.WaitForSpans(t, 3)
+	tracetest.AssertTree(t, spans, `
+		todos
+		  todo
+		  todo.text
+	`)
+	tracetest.AssertAttributes(t, tracetest.Span(t, spans, "todos"), map[string]interface{}{
+		"host":       "checkout",
+		"gql.client": "web",
+	})
+}
+
+func TestNewFromEnv(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	for name, value := range map[string]string{
+		gqlinstrument.EnvHost: "checkout",
+		EnvSample:             "1",
+		EnvRawQuery:           "true",
+	} {
+		require.NoError(t, os.Setenv(name, value))
+		defer os.Unsetenv(name)
+	}
+
+	tr, err := NewFromEnv()
+	require.NoError(t, err)
+	srv := handler.New(testschema.New(`type Query { todo: String }`, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tr)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query q { todo }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.WaitForSpans(t, 1)
+	tracetest.AssertAttributes(t, tracetest.Span(t, spans, "q"), map[string]interface{}{
+		"host":  "checkout",
+		"query": "query q { todo }",
+	})
+
+	require.NoError(t, os.Setenv(EnvSample, "often"))
+	_, err = NewFromEnv()
+	assert.EqualError(t, err, `GQL_TRACE_SAMPLE: invalid fraction "often", expecting a number between 0 and 1`)
+}
+
+func TestSetFieldSpans(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	tr := New(OnlyMethods(false))
+	srv := handler.New(testschema.New(`type Query { todo: String }`, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(tr)
+	query := func(name string) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query `+name+` { todo }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tr.SetFieldSpans(false)
+	query("off")
+	tr.SetFieldSpans(true)
+	query("on")
+
+	spans := rec.WaitForSpans(t, 3)
+	tracetest.AssertTree(t, spans, `
+		off
+		on
+		  todo
+	`)
+}
+
+func TestWithFingerprint(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	srv := handler.New(testschema.New(`type Query { todo: String }`, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(WithFingerprint()))
+
+	for _, query := range []string{`{ todo }`, `query named { todo }`} {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	hash := fingerprint.Hash("query{todo}")
+	spans := rec.WaitForSpans(t, 2)
+	tracetest.AssertTree(t, spans, `
+		query `+hash[:12]+`
+		named
+	`)
+	tracetest.AssertAttributes(t, tracetest.Span(t, spans, "query "+hash[:12]), map[string]interface{}{
+		"operation":   "query",
+		"fingerprint": hash,
+	})
+	tracetest.AssertAttributes(t, tracetest.Span(t, spans, "named"), map[string]interface{}{
+		"fingerprint": fingerprint.Hash("query named{todo}"),
+	})
+}
+
+func TestSpanFromContext(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	assert.Nil(t, SpanFromContext(context.Background()))
+	assert.Nil(t, OperationSpanFromContext(context.Background()))
+
+	srv := handler.New(testschema.New(`type Query { todo: String }`, testschema.Resolvers{
+		"Query.todo": func(ctx context.Context) (interface{}, error) {
+			SpanFromContext(ctx).AddAttributes(trace.StringAttribute("cache", "hit"))
+			OperationSpanFromContext(ctx).AddAttributes(trace.StringAttribute("tenant", "acme"))
+			return "todo", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(OnlyMethods(false)))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query q { todo }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.WaitForSpans(t, 2)
+	tracetest.AssertAttributes(t, tracetest.Span(t, spans, "todo"), map[string]interface{}{"cache": "hit"})
+	tracetest.AssertAttributes(t, tracetest.Span(t, spans, "q"), map[string]interface{}{"tenant": "acme"})
+}
+
+func TestErrorClassifier(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	srv := handler.New(testschema.New(`type Query { todo: String }`, testschema.Resolvers{
+		"Query.todo": func(ctx context.Context) (interface{}, error) { return nil, context.DeadlineExceeded },
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New())
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query slow { todo }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	op := tracetest.Span(t, rec.WaitForSpans(t, 1), "slow")
+	tracetest.AssertStatus(t, op, trace.StatusCodeDeadlineExceeded)
+	tracetest.AssertAttributes(t, op, map[string]interface{}{"error.class": "timeout"})
+}
+
+func TestWithClock(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	srv := handler.New(testschema.New(`type Query { todo: String }`, testschema.Resolvers{
+		"Query.todo": func(ctx context.Context) (interface{}, error) {
+			clock.Advance(5 * time.Millisecond)
+			return "todo", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(WithClock(clock.Now)))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query q { todo }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	op := tracetest.Span(t, rec.WaitForSpans(t, 1), "q")
+	tracetest.AssertAttributes(t, op, map[string]interface{}{"latency_ms": 5.0})
+}
@@ -0,0 +1,211 @@
+// Package tracetest helps verifying the shape of opencensus traces in tests: span hierarchy, names, attributes and
+// status.
+//
+// Example:
+//
+//	rec := tracetest.Record()
+//	defer rec.Stop()
+//
+//	// run a query
+//
+//	tracetest.AssertTree(t, rec.WaitForSpans(t, 3), `
+//	todos
+//	  todos
+//	  todos[0].text
+//	`)
+package tracetest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// Timeout of assertions, waiting for spans to end
+var Timeout = time.Second
+
+var _ trace.Exporter = &Recorder{}
+
+// Recorder is an in-memory span exporter
+type Recorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+// Record registers a new Recorder, and samples all spans until it is stopped
+func Record() *Recorder {
+	r := &Recorder{}
+	trace.RegisterExporter(r)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	return r
+}
+
+// Stop recording, and restore the default sampler of opencensus
+func (r *Recorder) Stop() {
+	trace.UnregisterExporter(r)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(1e-4)})
+}
+
+// ExportSpan implements trace.Exporter
+func (r *Recorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+// Spans ended so far, in the order they ended
+func (r *Recorder) Spans() []*trace.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*trace.SpanData(nil), r.spans...)
+}
+
+// Reset forgets the spans recorded so far
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+// WaitForSpans waits until n spans have ended, and yields them. It fails the test on timeout.
+func (r *Recorder) WaitForSpans(t testing.TB, n int) []*trace.SpanData {
+	t.Helper()
+	deadline := time.Now().Add(Timeout)
+	for {
+		spans := r.Spans()
+		if len(spans) >= n {
+			return spans
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d spans, got %d", n, len(spans))
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Span named name, among spans. It fails the test when there is no such span.
+func Span(t testing.TB, spans []*trace.SpanData, name string) *trace.SpanData {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span named %q among %d spans", name, len(spans))
+	return nil
+}
+
+// Tree formats the hierarchy of spans, with a span name per line, children indented by two spaces under their
+// parent, and siblings ordered by start time then name
+func Tree(spans []*trace.SpanData) string {
+	known := make(map[trace.SpanID]bool, len(spans))
+	for _, s := range spans {
+		known[s.SpanID] = true
+	}
+	children := make(map[trace.SpanID][]*trace.SpanData)
+	var roots []*trace.SpanData
+	for _, s := range spans {
+		if known[s.ParentSpanID] {
+			children[s.ParentSpanID] = append(children[s.ParentSpanID], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+
+	var b strings.Builder
+	var write func(level int, spans []*trace.SpanData)
+	write = func(level int, spans []*trace.SpanData) {
+		sort.SliceStable(spans, func(i, j int) bool {
+			if !spans[i].StartTime.Equal(spans[j].StartTime) {
+				return spans[i].StartTime.Before(spans[j].StartTime)
+			}
+			return spans[i].Name < spans[j].Name
+		})
+		for _, s := range spans {
+			b.WriteString(strings.Repeat("  ", level) + s.Name + "\n")
+			write(level+1, children[s.SpanID])
+		}
+	}
+	write(0, roots)
+	return b.String()
+}
+
+// AssertTree asserts the hierarchy of spans, as formatted by Tree. Leading and trailing blank lines of the expected
+// tree are ignored, as well as its common indentation.
+func AssertTree(t testing.TB, spans []*trace.SpanData, expected string) bool {
+	t.Helper()
+	expected = dedent(expected)
+	if actual := Tree(spans); actual != expected {
+		t.Errorf("unexpected span tree:\n%s\nexpected:\n%s", actual, expected)
+		return false
+	}
+	return true
+}
+
+// AssertParent asserts that a span is the child of another one
+func AssertParent(t testing.TB, child, parent *trace.SpanData) bool {
+	t.Helper()
+	if child.ParentSpanID != parent.SpanID || child.TraceID != parent.TraceID {
+		t.Errorf("span %q is not a child of span %q", child.Name, parent.Name)
+		return false
+	}
+	return true
+}
+
+// AssertAttributes asserts the attributes of a span. The span may carry more attributes than the expected ones.
+func AssertAttributes(t testing.TB, span *trace.SpanData, expected map[string]interface{}) bool {
+	t.Helper()
+	ok := true
+	for key, value := range expected {
+		actual, found := span.Attributes[key]
+		if !found {
+			t.Errorf("span %q: missing attribute %q", span.Name, key)
+			ok = false
+			continue
+		}
+		if actual != value {
+			t.Errorf("span %q: attribute %q is %#v, expected %#v", span.Name, key, actual, value)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertStatus asserts the status code of a span, e.g. trace.StatusCodeOK
+func AssertStatus(t testing.TB, span *trace.SpanData, code int32) bool {
+	t.Helper()
+	if span.Code != code {
+		t.Errorf("span %q: status code is %d (%s), expected %d", span.Name, span.Code, span.Message, code)
+		return false
+	}
+	return true
+}
+
+// dedent trims the blank lines around a text, and the indentation common to its lines
+func dedent(text string) string {
+	lines := strings.Split(strings.Trim(text, "\n"), "\n")
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		b.WriteString(strings.TrimRight(line[indent:], " \t") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package gqlopencensus
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/internal/fieldpath"
+)
+
+var _ http.RoundTripper = &Transport{}
+
+// Transport is an ochttp.Transport for HTTP clients of resolvers. The spans and metrics of downstream calls are
+// tagged with the GraphQL operation and field resolved in the request context:
+//
+//	client := &http.Client{Transport: &gqlopencensus.Transport{}}
+//
+// Add metrics.TagOperation, metrics.TagField or metrics.TagPath to the keys of the ochttp client views to aggregate
+// downstream calls by resolver.
+type Transport struct {
+	ochttp.Transport
+}
+
+type annotator struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !graphql.HasOperationContext(ctx) {
+		return t.Transport.RoundTrip(req)
+	}
+
+	mutators := []tag.Mutator{tag.Upsert(metrics.TagOperation, fingerprint.OperationName(graphql.GetOperationContext(ctx)))}
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		mutators = append(mutators, tag.Upsert(metrics.TagField, fc.Field.Name), tag.Upsert(metrics.TagPath, fieldpath.String(ctx, fc)))
+	}
+	if tagged, err := tag.New(ctx, mutators...); err == nil {
+		ctx = tagged
+	}
+
+	base := t.Transport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	inner := t.Transport
+	inner.Base = annotator{base: base}
+	return inner.RoundTrip(req.WithContext(ctx))
+}
+
+// RoundTrip adds the GraphQL attributes to the client span started by ochttp
+func (a annotator) RoundTrip(req *http.Request) (*http.Response, error) {
+	if span := trace.FromContext(req.Context()); span != nil {
+		span.AddAttributes(downstreamAttributes(req.Context())...)
+	}
+	return a.base.RoundTrip(req)
+}
+
+func downstreamAttributes(ctx context.Context) []trace.Attribute {
+	attrs := []trace.Attribute{trace.StringAttribute("operation", fingerprint.OperationName(graphql.GetOperationContext(ctx)))}
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		attrs = append(attrs, trace.StringAttribute("field", fc.Field.Name), trace.StringAttribute("path", fieldpath.String(ctx, fc)))
+	}
+	return attrs
+}
@@ -0,0 +1,64 @@
+package gqlopencensus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus/tracetest"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer downstream.Close()
+
+	var tags *tag.Map
+	client := &http.Client{Transport: &Transport{Transport: ochttp.Transport{
+		StartOptions: trace.StartOptions{Sampler: trace.AlwaysSample()},
+		Base: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			tags = tag.FromContext(r.Context())
+			return http.DefaultTransport.RoundTrip(r)
+		}),
+	}}}
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "product", Operation: ast.Query},
+	})
+	ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Field: graphql.CollectedField{Field: &ast.Field{Name: "reviews", Alias: "reviews"}},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downstream.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	operation, _ := tags.Value(metrics.TagOperation)
+	assert.Equal(t, "product", operation)
+	path, _ := tags.Value(metrics.TagPath)
+	assert.Equal(t, "reviews", path)
+
+	spans := rec.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind)
+	tracetest.AssertAttributes(t, spans[0], map[string]interface{}{"operation": "product", "path": "reviews"})
+}
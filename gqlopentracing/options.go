@@ -0,0 +1,18 @@
+package gqlopentracing
+
+import "time"
+
+// Option for an opentracing tracer
+type Option func(*config)
+
+type config struct {
+	now func() time.Time
+}
+
+// WithClock stamps the start and finish of spans with a given clock, e.g. a fake clock in tests. By default the
+// opentracing tracer decides.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
@@ -7,9 +7,18 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/internal/fieldpath"
 )
 
-type OpenTracingTracer struct{}
+// OpenTracingTracer is a gqlgen extension tracing operations and fields with opentracing. The zero value traces with
+// the default options.
+type OpenTracingTracer struct {
+	config
+}
 
 var _ interface {
 	graphql.HandlerExtension
@@ -17,6 +26,15 @@ var _ interface {
 	graphql.FieldInterceptor
 } = OpenTracingTracer{}
 
+// New opentracing tracer for gqlgen
+func New(opts ...Option) OpenTracingTracer {
+	var t OpenTracingTracer
+	for _, apply := range opts {
+		apply(&t.config)
+	}
+	return t
+}
+
 func (OpenTracingTracer) ExtensionName() string {
 	return "Opentracing"
 }
@@ -25,33 +43,29 @@ func (OpenTracingTracer) Validate(schema graphql.ExecutableSchema) error {
 	return nil
 }
 
-func (OpenTracingTracer) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+func (t OpenTracingTracer) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
 	fieldCtx := graphql.GetFieldContext(ctx)
-	span, ctx := opentracing.StartSpanFromContext(ctx, fieldCtx.Path().String())
-	defer span.Finish()
+	span, ctx := opentracing.StartSpanFromContext(ctx, fieldpath.String(ctx, fieldCtx), t.startOptions()...)
+	defer t.finish(span)
 	ext.SpanKind.Set(span, "server")
 	ext.Component.Set(span, "gqlgen")
 
 	return next(ctx)
 }
 
-func (OpenTracingTracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+func (t OpenTracingTracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
 	opCtx := graphql.GetOperationContext(ctx)
-	opName := ""
-	if opCtx.Operation != nil {
-		opName = opCtx.Operation.Name
-	}
-	if opName == "" && opCtx.Operation != nil {
-		//parent response case
-		opName = string(opCtx.Operation.Operation)
-	}
-	if opName == "" {
-		opName = opCtx.OperationName
-	}
-	span, ctx := opentracing.StartSpanFromContext(ctx, opName)
-	defer span.Finish()
+	opName := fingerprint.OperationName(opCtx)
+	span, ctx := opentracing.StartSpanFromContext(fieldpath.WithCache(ctx), opName, t.startOptions()...)
+	defer t.finish(span)
 	ext.SpanKind.Set(span, "server")
 	ext.Component.Set(span, "gqlgen")
+	if cs := gqlcomplexity.GetOperationStats(opCtx); cs != nil {
+		span.SetTag("cost", cs.Cost)
+	}
+	for _, attr := range clientinfo.Attributes(ctx) {
+		span.SetTag(attr.Key, attr.Value)
+	}
 
 	resp := next(ctx)
 	if resp == nil {
@@ -65,3 +79,18 @@ func (OpenTracingTracer) InterceptResponse(ctx context.Context, next graphql.Res
 
 	return resp
 }
+
+func (t OpenTracingTracer) startOptions() []opentracing.StartSpanOption {
+	if t.now == nil {
+		return nil
+	}
+	return []opentracing.StartSpanOption{opentracing.StartTime(t.now())}
+}
+
+func (t OpenTracingTracer) finish(span opentracing.Span) {
+	if t.now == nil {
+		span.Finish()
+		return
+	}
+	span.FinishWithOptions(opentracing.FinishOptions{FinishTime: t.now()})
+}
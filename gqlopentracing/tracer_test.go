@@ -0,0 +1,50 @@
+package gqlopentracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestWithClock(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := gqltesting.NewClock(start)
+	srv := handler.New(testschema.New(`type Query { todo: String }`, testschema.Resolvers{
+		"Query.todo": func(context.Context) (interface{}, error) {
+			clock.Advance(5 * time.Millisecond)
+			return "todo", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(WithClock(clock.Now)))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query todos { todo }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := tracer.FinishedSpans()
+	require.Len(t, spans, 2)
+	field, op := spans[0], spans[1]
+	assert.Equal(t, "todo", field.OperationName)
+	assert.Equal(t, "todos", op.OperationName)
+	assert.Equal(t, start, op.StartTime)
+	assert.Equal(t, start.Add(5*time.Millisecond), op.FinishTime)
+	assert.Equal(t, op.SpanContext.SpanID, field.ParentID)
+}
@@ -0,0 +1,16 @@
+package gqlpprof
+
+// Option for the profiling labels extension
+type Option func(*config)
+
+type config struct {
+	fields bool
+}
+
+// Fields labels the resolvers of top-level fields with the name of their field, e.g. to tell apart the root
+// fields of a query in profiles. This is disabled by default.
+func Fields() Option {
+	return func(c *config) {
+		c.fields = true
+	}
+}
@@ -0,0 +1,94 @@
+// Package gqlpprof labels the execution of GraphQL operations for the profiler, so CPU and heap profiles taken in
+// production can be filtered to a single operation:
+//
+//	srv.Use(gqlpprof.New())
+//
+// Operations are executed with the pprof labels "graphql.operation" (the name of the operation, or its type when it
+// is anonymous) and "graphql.type" ("query", "mutation" or "subscription"), inherited by the goroutines resolving
+// fields. Profiles are then filtered with pprof tags, e.g.:
+//
+//	go tool pprof -tagfocus graphql.operation=todos http://localhost:6060/debug/pprof/profile
+package gqlpprof
+
+import (
+	"context"
+	"runtime/pprof"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const (
+	extensionName = "PprofLabels"
+
+	// LabelOperation is the label of the operation name
+	LabelOperation = "graphql.operation"
+
+	// LabelType is the label of the operation type
+	LabelType = "graphql.type"
+
+	// LabelField is the label of top-level fields, with the Fields option
+	LabelField = "graphql.field"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Labeler{}
+
+// Labeler is a gqlgen extension executing operations with pprof labels
+type Labeler struct {
+	config
+}
+
+// New profiling labels extension
+func New(opts ...Option) *Labeler {
+	l := &Labeler{}
+	for _, apply := range opts {
+		apply(&l.config)
+	}
+	return l
+}
+
+// ExtensionName yields the extension name: "PprofLabels"
+func (l *Labeler) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (l *Labeler) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse executes the operation with the labels of the operation
+func (l *Labeler) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	rc := graphql.GetOperationContext(ctx)
+	var typ string
+	if rc.Operation != nil {
+		typ = string(rc.Operation.Operation)
+	}
+
+	var resp *graphql.Response
+	pprof.Do(ctx, pprof.Labels(LabelOperation, fingerprint.OperationName(rc), LabelType, typ), func(ctx context.Context) {
+		resp = next(ctx)
+	})
+	return resp
+}
+
+// InterceptField resolves top-level fields with the label of their field, with the Fields option
+func (l *Labeler) InterceptField(ctx context.Context, next graphql.Resolver) (res interface{}, err error) {
+	if !l.fields {
+		return next(ctx)
+	}
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || fc.Parent != nil {
+		return next(ctx)
+	}
+
+	pprof.Do(ctx, pprof.Labels(LabelField, fc.Field.Name), func(ctx context.Context) {
+		res, err = next(ctx)
+	})
+	return res, err
+}
@@ -0,0 +1,81 @@
+package gqlpprof
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestLabeler(t *testing.T) {
+	var mu sync.Mutex
+	labels := map[string]map[string]string{}
+	record := func(field string) func(context.Context) (interface{}, error) {
+		return func(ctx context.Context) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			labels[field] = map[string]string{}
+			pprof.ForLabels(ctx, func(key, value string) bool {
+				labels[field][key] = value
+				return true
+			})
+			return "ok", nil
+		}
+	}
+
+	for _, test := range []struct {
+		name     string
+		opts     []Option
+		query    string
+		expected map[string]map[string]string
+	}{
+		{
+			name:  "operation",
+			query: `query todos { todo { text } }`,
+			expected: map[string]map[string]string{
+				"todo": {LabelOperation: "todos", LabelType: "query"},
+				"text": {LabelOperation: "todos", LabelType: "query"},
+			},
+		},
+		{
+			name:  "fields",
+			opts:  []Option{Fields()},
+			query: `{ todo { text } }`,
+			expected: map[string]map[string]string{
+				"todo": {LabelOperation: "query", LabelType: "query", LabelField: "todo"},
+				"text": {LabelOperation: "query", LabelType: "query"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			labels = map[string]map[string]string{}
+			srv := handler.New(testschema.New(`
+				type Query { todo: Todo }
+				type Todo { text: String }
+			`, testschema.Resolvers{
+				"Query.todo": func(ctx context.Context) (interface{}, error) {
+					_, _ = record("todo")(ctx)
+					return map[string]interface{}{}, nil
+				},
+				"Todo.text": record("text"),
+			}))
+			srv.AddTransport(transport.POST{})
+			srv.Use(New(test.opts...))
+
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+test.query+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			srv.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.expected, labels)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package gqlquota
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminHandler exposes the quotas of tenants, e.g. to support teams. It must be protected by the caller.
+//
+//	GET ?tenant=acme     yields the usage of the quotas of the tenant, in JSON
+//	DELETE ?tenant=acme  resets the quotas of the tenant
+func (t *Tracker) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.URL.Query().Get("tenant")
+		if tenant == "" {
+			http.Error(w, "missing tenant", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			usage, err := t.Usage(r.Context(), tenant)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(struct {
+				Tenant string  `json:"tenant"`
+				Quotas []Usage `json:"quotas"`
+			}{Tenant: tenant, Quotas: usage})
+		case http.MethodDelete:
+			if err := t.Reset(r.Context(), tenant); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
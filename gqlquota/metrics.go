@@ -0,0 +1,66 @@
+package gqlquota
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of quotas.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(QuotaViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of quotas
+func UnregisterViews() {
+	view.Unregister(QuotaViews...)
+}
+
+var (
+	// QuotaViews contains all opencensus stats views declared by the quota extension
+	QuotaViews = []*view.View{
+		WarningCountView,
+		RejectedCountView,
+	}
+
+	// measurements
+
+	// Warnings tracks a count of operations exceeding a soft limit
+	Warnings = stats.Int64(
+		"gql/quota/warning_count",
+		"Number of GraphQL operations exceeding the soft limit of a quota",
+		stats.UnitDimensionless)
+
+	// Rejected tracks a count of operations rejected by a hard limit
+	Rejected = stats.Int64(
+		"gql/quota/rejected_count",
+		"Number of GraphQL operations exceeding the hard limit of a quota",
+		stats.UnitDimensionless)
+
+	// views
+
+	// WarningCountView reports a count of operations exceeding a soft limit, by quota window and operation name
+	WarningCountView = &view.View{
+		Name:        "gql/quota/warning_count",
+		Description: "Count of GraphQL operations exceeding the soft limit of a quota, by window and operation",
+		Measure:     Warnings,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagWindow, metrics.TagOperation},
+	}
+
+	// RejectedCountView reports a count of operations rejected by a hard limit, by quota window and operation name
+	RejectedCountView = &view.View{
+		Name:        "gql/quota/rejected_count",
+		Description: "Count of GraphQL operations exceeding the hard limit of a quota, by window and operation",
+		Measure:     Rejected,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagWindow, metrics.TagOperation},
+	}
+
+	// TagWindow is the window of a quota, e.g. "hourly" or "daily"
+	TagWindow = tag.MustNewKey("gql.quota.window")
+)
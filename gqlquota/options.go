@@ -0,0 +1,71 @@
+package gqlquota
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlauthz"
+)
+
+// Option for the quota extension
+type Option func(*config)
+
+// TenantFunc identifies the tenant of an operation. It yields an empty string for unidentified tenants.
+type TenantFunc func(context.Context, *graphql.OperationContext) string
+
+type config struct {
+	store        Store
+	tenant       TenantFunc
+	keyPrefix    string
+	errorHandler func(error)
+	now          func() time.Time
+}
+
+func defaultConfig() config {
+	return config{
+		store:     NewMemoryStore(),
+		tenant:    byPrincipal,
+		keyPrefix: "gqlquota:",
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+		now: time.Now,
+	}
+}
+
+func byPrincipal(ctx context.Context, _ *graphql.OperationContext) string {
+	p, _ := gqlauthz.FromContext(ctx)
+	return p.ID
+}
+
+// WithStore holds counters in a custom store, e.g. a RedisStore. The default is a MemoryStore.
+func WithStore(store Store) Option {
+	return func(c *config) {
+		c.store = store
+	}
+}
+
+// TenantBy identifies tenants. The default is the ID of the principal stored with gqlauthz.WithPrincipal.
+func TenantBy(tenant TenantFunc) Option {
+	return func(c *config) {
+		c.tenant = tenant
+	}
+}
+
+// KeyPrefix of counters in the store. The default is "gqlquota:".
+func KeyPrefix(prefix string) Option {
+	return func(c *config) {
+		c.keyPrefix = prefix
+	}
+}
+
+// ErrorHandler handles errors of the store. Operations are allowed when the store fails.
+// The default logs errors.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
@@ -0,0 +1,228 @@
+// Package gqlquota tracks the cumulative cost of GraphQL operations per tenant, in fixed time windows.
+//
+// Quotas have a soft limit and a hard limit. Beyond the soft limit, operations are executed with a warning:
+// the X-Quota-Warning response header is set when the httpheader middleware is installed, and a metric is recorded.
+// Operations exceeding the hard limit are rejected before execution with a QUOTA_EXCEEDED error, hinting to retry
+// once the window resets (see the throttle package).
+//
+//	quotas := gqlquota.New([]gqlquota.Quota{
+//		gqlquota.Hourly(10000, 20000),
+//		gqlquota.Daily(100000, 150000),
+//	}, gqlquota.WithStore(gqlquota.NewRedisStore("localhost:6379", gqlquota.RedisOptions{})))
+//	srv.Use(quotas)
+//	http.Handle("/admin/quota", quotas.AdminHandler())
+//
+// The cost of operations is computed by the gqlcomplexity extension (or by the gqlgen complexity limit),
+// which must be used before the quota extension. Operations without cost count for 1.
+package gqlquota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/throttle"
+)
+
+const (
+	extensionName = "Quota"
+
+	// ErrQuotaExceededCode is the error code of operations exceeding the hard limit of a quota
+	ErrQuotaExceededCode = "QUOTA_EXCEEDED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrQuotaExceededCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Tracker{}
+
+type (
+	// Quota of cost per tenant, over a time window aligned on UTC, e.g. a clock hour
+	Quota struct {
+		Window time.Duration
+
+		// Soft limit, beyond which operations are executed with a warning, or 0 when disabled
+		Soft int
+
+		// Hard limit, beyond which operations are rejected, or 0 when disabled
+		Hard int
+	}
+
+	// Usage of a quota by a tenant, in the current window
+	Usage struct {
+		Window  string    `json:"window"`
+		Soft    int       `json:"soft"`
+		Hard    int       `json:"hard"`
+		Used    int       `json:"used"`
+		ResetAt time.Time `json:"resetAt"`
+	}
+
+	// Tracker is a gqlgen extension enforcing quotas
+	Tracker struct {
+		config
+		quotas []Quota
+	}
+)
+
+// Hourly quota
+func Hourly(soft, hard int) Quota {
+	return Quota{Window: time.Hour, Soft: soft, Hard: hard}
+}
+
+// Daily quota
+func Daily(soft, hard int) Quota {
+	return Quota{Window: 24 * time.Hour, Soft: soft, Hard: hard}
+}
+
+// New quota extension
+func New(quotas []Quota, opts ...Option) *Tracker {
+	t := &Tracker{
+		config: defaultConfig(),
+		quotas: quotas,
+	}
+	for _, apply := range opts {
+		apply(&t.config)
+	}
+	return t
+}
+
+// ExtensionName yields the extension name: "Quota"
+func (t *Tracker) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (t *Tracker) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext consumes the cost of the operation from the quotas of the tenant.
+//
+// Operations of unidentified tenants are not accounted. Operations are allowed when the store fails.
+func (t *Tracker) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	tenant := t.tenant(ctx, rc)
+	if tenant == "" || len(t.quotas) == 0 {
+		return nil
+	}
+
+	cost, _, _ := gqlcomplexity.OperationCost(rc)
+	if cost < 1 {
+		cost = 1
+	}
+
+	now := t.now()
+	counters := make([]Counter, 0, len(t.quotas))
+	for _, q := range t.quotas {
+		start := now.Truncate(q.Window)
+		counters = append(counters, Counter{
+			Key:  t.key(tenant, q, start),
+			Hard: q.Hard,
+			TTL:  start.Add(q.Window).Sub(now),
+		})
+	}
+
+	usage, allowed, err := t.store.Consume(ctx, cost, counters)
+	if err != nil {
+		t.errorHandler(err)
+		return nil
+	}
+
+	opName := fingerprint.OperationName(rc)
+	if !allowed {
+		for i, q := range t.quotas {
+			if q.Hard > 0 && usage[i]+cost > q.Hard {
+				record(ctx, opName, q, Rejected)
+				resetIn := int(counters[i].TTL.Round(time.Second) / time.Second)
+				gqlErr := gqlerror.Errorf("%s quota of %d exceeded, resets in %s", windowName(q.Window), q.Hard, time.Duration(resetIn)*time.Second)
+				errcode.Set(gqlErr, ErrQuotaExceededCode)
+				gqlErr.Extensions["window"] = windowName(q.Window)
+				gqlErr.Extensions["used"] = usage[i]
+				gqlErr.Extensions["limit"] = q.Hard
+				gqlErr.Extensions["resetIn"] = resetIn
+				throttle.Record(ctx, extensionName, opName, throttle.Limited)
+				throttle.RetryAfter(ctx, gqlErr, counters[i].TTL)
+				return gqlErr
+			}
+		}
+		return nil
+	}
+
+	for i, q := range t.quotas {
+		if q.Soft > 0 && usage[i] > q.Soft {
+			record(ctx, opName, q, Warnings)
+			httpheader.Add(ctx, "X-Quota-Warning", fmt.Sprintf("%s; used=%d; limit=%d", windowName(q.Window), usage[i], q.Soft))
+		}
+	}
+	throttle.Record(ctx, extensionName, opName, throttle.Allowed)
+	return nil
+}
+
+// Usage of all quotas by a tenant, in the current windows
+func (t *Tracker) Usage(ctx context.Context, tenant string) ([]Usage, error) {
+	now := t.now()
+	usages := make([]Usage, 0, len(t.quotas))
+	for _, q := range t.quotas {
+		start := now.Truncate(q.Window)
+		used, err := t.store.Usage(ctx, t.key(tenant, q, start))
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, Usage{
+			Window:  windowName(q.Window),
+			Soft:    q.Soft,
+			Hard:    q.Hard,
+			Used:    used,
+			ResetAt: start.Add(q.Window).UTC(),
+		})
+	}
+	return usages, nil
+}
+
+// Reset all quotas of a tenant, in the current windows
+func (t *Tracker) Reset(ctx context.Context, tenant string) error {
+	now := t.now()
+	for _, q := range t.quotas {
+		if err := t.store.Reset(ctx, t.key(tenant, q, now.Truncate(q.Window))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tracker) key(tenant string, q Quota, start time.Time) string {
+	return t.keyPrefix + tenant + ":" + windowName(q.Window) + ":" + strconv.FormatInt(start.Unix(), 10)
+}
+
+func record(ctx context.Context, opName string, q Quota, measure *stats.Int64Measure) {
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{tag.Upsert(TagWindow, windowName(q.Window)), tag.Upsert(metrics.TagOperation, opName)},
+		measure.M(1),
+	)
+}
+
+// windowName is "hourly", "daily", or the duration of the window
+func windowName(window time.Duration) string {
+	switch window {
+	case time.Hour:
+		return "hourly"
+	case 24 * time.Hour:
+		return "daily"
+	default:
+		return window.String()
+	}
+}
@@ -0,0 +1,119 @@
+package gqlquota
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/internal/redis/redistest"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const hi = `query hi { hello }`
+
+// tenant identifies the tenant of a request
+func tenant(name string) gqltesting.RequestOption {
+	return gqltesting.Header("X-Tenant", name)
+}
+
+// emulateConsume emulates consumeScript
+func emulateConsume(call func(args ...string) (interface{}, error), keys, args []string) interface{} {
+	cost, _ := strconv.ParseInt(args[0], 10, 64)
+	result := []interface{}{int64(1)}
+	for i, key := range keys {
+		reply, _ := call("GET", key)
+		b, _ := reply.([]byte)
+		usage, _ := strconv.ParseInt(string(b), 10, 64)
+		if hard, _ := strconv.ParseInt(args[2*i+1], 10, 64); hard > 0 && usage+cost > hard {
+			result[0] = int64(0)
+		}
+		result = append(result, usage)
+	}
+	if result[0] == int64(1) {
+		for i, key := range keys {
+			result[i+1], _ = call("INCRBY", key, args[0])
+			if ttl, _ := call("PTTL", key); ttl.(int64) < 0 {
+				_, _ = call("PEXPIRE", key, args[2*i+2])
+			}
+		}
+	}
+	return result
+}
+
+func TestTracker(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	redisServer := redistest.NewServer()
+	defer redisServer.Close()
+	redisServer.HandleScript(consumeScript, emulateConsume)
+	redisStore := NewRedisStore(redisServer.Addr, RedisOptions{})
+	defer redisStore.Close()
+
+	for name, store := range map[string]Store{"memory": NewMemoryStore(), "redis": redisStore} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Date(2020, 5, 1, 10, 45, 0, 0, time.UTC)
+			tracker := New([]Quota{Hourly(2, 3), Daily(0, 10)}, WithStore(store),
+				TenantBy(func(_ context.Context, rc *graphql.OperationContext) string {
+					return rc.Headers.Get("X-Tenant")
+				}),
+			)
+			tracker.now = func() time.Time { return now }
+			srv := httpheader.Middleware(gqltesting.NewServer(testschema.New(`type Query { hello: String }`, nil), tracker))
+
+			for i := 0; i < 2; i++ {
+				w := gqltesting.Post(srv, hi, tenant("acme"))
+				assert.Equal(t, `{"data":{"hello":"hello"}}`, w.Body.String())
+				assert.Empty(t, w.Header().Get("X-Quota-Warning"))
+			}
+			w := gqltesting.Post(srv, hi, tenant("acme"))
+			assert.Equal(t, `{"data":{"hello":"hello"}}`, w.Body.String())
+			assert.Equal(t, "hourly; used=3; limit=2", w.Header().Get("X-Quota-Warning"))
+
+			w = gqltesting.Post(srv, hi, tenant("acme"))
+			assert.JSONEq(t, `{
+				"errors":[{
+					"message":"hourly quota of 3 exceeded, resets in 15m0s",
+					"extensions":{"code":"QUOTA_EXCEEDED","window":"hourly","used":3,"limit":3,"resetIn":900,"retryAfter":900}
+				}],
+				"data":null
+			}`, w.Body.String())
+			assert.Equal(t, "900", w.Header().Get("Retry-After"))
+			assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, hi, tenant("other")).Body.String())
+			assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, hi, tenant("")).Body.String(), "unidentified tenants are not accounted")
+
+			admin := tracker.AdminHandler()
+			w = httptest.NewRecorder()
+			admin.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?tenant=acme", nil))
+			assert.JSONEq(t, `{"tenant":"acme","quotas":[
+				{"window":"hourly","soft":2,"hard":3,"used":3,"resetAt":"2020-05-01T11:00:00Z"},
+				{"window":"daily","soft":0,"hard":10,"used":3,"resetAt":"2020-05-02T00:00:00Z"}
+			]}`, w.Body.String())
+
+			w = httptest.NewRecorder()
+			admin.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/?tenant=acme", nil))
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, hi, tenant("acme")).Body.String())
+
+			now = now.Add(time.Hour)
+			usage, err := tracker.Usage(context.Background(), "acme")
+			require.NoError(t, err)
+			assert.Equal(t, 0, usage[0].Used, "a new hourly window is started")
+			assert.Equal(t, 1, usage[1].Used)
+		})
+	}
+
+	rows, err := view.RetrieveData(RejectedCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+}
@@ -0,0 +1,233 @@
+package gqlquota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/internal/redis"
+)
+
+var (
+	_ Store = &MemoryStore{}
+	_ Store = &RedisStore{}
+)
+
+type (
+	// Counter of the cost consumed by a tenant in a quota window
+	Counter struct {
+		Key string
+
+		// Hard limit of the counter, or 0 when unlimited
+		Hard int
+
+		// TTL of the counter, until the end of the window
+		TTL time.Duration
+	}
+
+	// Store holds counters
+	Store interface {
+		// Consume adds cost to all the counters atomically, unless it would exceed the hard limit of any counter.
+		// It yields the usage of the counters, after consumption when allowed.
+		Consume(ctx context.Context, cost int, counters []Counter) (usage []int, allowed bool, err error)
+
+		// Usage of a counter
+		Usage(ctx context.Context, key string) (int, error)
+
+		// Reset a counter
+		Reset(ctx context.Context, key string) error
+	}
+
+	// MemoryStore holds counters in memory, for a single server
+	MemoryStore struct {
+		mu       sync.Mutex
+		counters map[string]*counter
+		consumed int
+		now      func() time.Time
+	}
+
+	counter struct {
+		usage   int
+		expires time.Time
+	}
+
+	// RedisStore holds counters in Redis, for quotas shared by several servers
+	RedisStore struct {
+		client *redis.Client
+	}
+
+	// RedisOptions configure the connection to Redis
+	RedisOptions = gqlcache.RedisOptions
+)
+
+// NewMemoryStore holds counters in memory
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*counter),
+		now:      time.Now,
+	}
+}
+
+// Consume implements Store
+func (s *MemoryStore) Consume(_ context.Context, cost int, counters []Counter) ([]int, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.consumed++
+	if s.consumed%1000 == 0 {
+		for key, c := range s.counters {
+			if !now.Before(c.expires) {
+				delete(s.counters, key)
+			}
+		}
+	}
+
+	usage := make([]int, len(counters))
+	allowed := true
+	for i, c := range counters {
+		usage[i] = s.usage(c.Key, now)
+		if c.Hard > 0 && usage[i]+cost > c.Hard {
+			allowed = false
+		}
+	}
+	if !allowed {
+		return usage, false, nil
+	}
+
+	for i, c := range counters {
+		stored, ok := s.counters[c.Key]
+		if !ok || !now.Before(stored.expires) {
+			stored = &counter{expires: now.Add(c.TTL)}
+			s.counters[c.Key] = stored
+		}
+		stored.usage += cost
+		usage[i] = stored.usage
+	}
+	return usage, true, nil
+}
+
+// Usage implements Store
+func (s *MemoryStore) Usage(_ context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage(key, s.now()), nil
+}
+
+// Reset implements Store
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counters, key)
+	return nil
+}
+
+func (s *MemoryStore) usage(key string, now time.Time) int {
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expires) {
+		return 0
+	}
+	return c.usage
+}
+
+// consumeScript checks the hard limits of all counters, then increments them atomically.
+//
+// ARGV holds the cost, then the hard limit and TTL (in milliseconds) of each key.
+// It returns the allowed flag (0 or 1), then the usage of each key.
+const consumeScript = `
+local cost = tonumber(ARGV[1])
+local result = {1}
+for i, key in ipairs(KEYS) do
+	local usage = tonumber(redis.call('GET', key) or '0')
+	local hard = tonumber(ARGV[2 * i])
+	if hard > 0 and usage + cost > hard then
+		result[1] = 0
+	end
+	result[i + 1] = usage
+end
+if result[1] == 1 then
+	for i, key in ipairs(KEYS) do
+		result[i + 1] = redis.call('INCRBY', key, cost)
+		if redis.call('PTTL', key) < 0 then
+			redis.call('PEXPIRE', key, ARGV[2 * i + 1])
+		end
+	end
+end
+return result
+`
+
+var consume = redis.NewScript(consumeScript)
+
+// NewRedisStore connects to the Redis server at addr, e.g. "localhost:6379". Connections are established lazily.
+func NewRedisStore(addr string, opts RedisOptions) *RedisStore {
+	return &RedisStore{
+		client: redis.New(addr, redis.Options{
+			Password:    opts.Password,
+			DB:          opts.DB,
+			PoolSize:    opts.PoolSize,
+			DialTimeout: opts.DialTimeout,
+			IOTimeout:   opts.IOTimeout,
+		}),
+	}
+}
+
+// Consume implements Store, with a Lua script
+func (s *RedisStore) Consume(ctx context.Context, cost int, counters []Counter) ([]int, bool, error) {
+	keys := make([]string, 0, len(counters))
+	args := make([]interface{}, 0, 1+2*len(counters))
+	args = append(args, cost)
+	for _, c := range counters {
+		keys = append(keys, c.Key)
+		args = append(args, c.Hard, int64(c.TTL/time.Millisecond))
+	}
+
+	reply, err := consume.Run(ctx, s.client, keys, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("gqlquota: %w", err)
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != len(counters)+1 {
+		return nil, false, fmt.Errorf("gqlquota: unexpected reply: %v", reply)
+	}
+
+	usage := make([]int, len(counters))
+	for i := range usage {
+		n, _ := values[i+1].(int64)
+		usage[i] = int(n)
+	}
+	allowed, _ := values[0].(int64)
+	return usage, allowed == 1, nil
+}
+
+// Usage implements Store
+func (s *RedisStore) Usage(ctx context.Context, key string) (int, error) {
+	reply, err := s.client.Do(ctx, "GET", key)
+	if err != nil {
+		return 0, fmt.Errorf("gqlquota: %w", err)
+	}
+	if reply == nil {
+		return 0, nil
+	}
+	value, _ := reply.([]byte)
+	usage, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("gqlquota: invalid usage: %w", err)
+	}
+	return usage, nil
+}
+
+// Reset implements Store
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	if _, err := s.client.Do(ctx, "DEL", key); err != nil {
+		return fmt.Errorf("gqlquota: %w", err)
+	}
+	return nil
+}
+
+// Close the connections to Redis
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
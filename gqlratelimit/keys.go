@@ -0,0 +1,43 @@
+package gqlratelimit
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/gqlauthz"
+)
+
+// KeyFunc identifies the client of an operation. It yields an empty key when it can't identify the client.
+type KeyFunc func(context.Context, *graphql.OperationContext) string
+
+// ByAPIKey identifies clients by an API key, sent in a request header
+func ByAPIKey(header string) KeyFunc {
+	return func(_ context.Context, rc *graphql.OperationContext) string {
+		if key := rc.Headers.Get(header); key != "" {
+			return "key:" + key
+		}
+		return ""
+	}
+}
+
+// ByPrincipal identifies clients by the ID of the authenticated principal, stored with gqlauthz.WithPrincipal
+func ByPrincipal() KeyFunc {
+	return func(ctx context.Context, _ *graphql.OperationContext) string {
+		if p, ok := gqlauthz.FromContext(ctx); ok && p.ID != "" {
+			return "user:" + p.ID
+		}
+		return ""
+	}
+}
+
+// ByClientIP identifies clients by their anonymized IP, computed by the clientinfo middleware
+func ByClientIP() KeyFunc {
+	return func(ctx context.Context, _ *graphql.OperationContext) string {
+		if id := clientinfo.ClientID(ctx); id != "" {
+			return "ip:" + id
+		}
+		return ""
+	}
+}
@@ -0,0 +1,47 @@
+package gqlratelimit
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of the rate limiter.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(RateLimitViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the rate limiter
+func UnregisterViews() {
+	view.Unregister(RateLimitViews...)
+}
+
+var (
+	// RateLimitViews contains all opencensus stats views declared by the rate limiter
+	RateLimitViews = []*view.View{
+		ThrottledCountView,
+	}
+
+	// measurements
+
+	// Throttled tracks a count of operations rejected by the rate limiter
+	Throttled = stats.Int64(
+		"gql/ratelimit/throttled_count",
+		"Number of GraphQL operations exceeding the rate limit",
+		stats.UnitDimensionless)
+
+	// views
+
+	// ThrottledCountView reports a count of operations exceeding the rate limit, by operation name
+	ThrottledCountView = &view.View{
+		Name:        "gql/ratelimit/throttled_count",
+		Description: "Count of GraphQL operations exceeding the rate limit, by operation",
+		Measure:     Throttled,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
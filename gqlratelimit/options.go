@@ -0,0 +1,62 @@
+package gqlratelimit
+
+import "log"
+
+// Option for the rate limiting extension
+type Option func(*config)
+
+type config struct {
+	store        Store
+	keyFuncs     []KeyFunc
+	errorHandler func(error)
+	costWeighted bool
+	exposeBudget bool
+}
+
+func defaultConfig() config {
+	return config{
+		store:    NewMemoryStore(100000),
+		keyFuncs: []KeyFunc{ByClientIP()},
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+	}
+}
+
+// WithStore holds token buckets in a custom store. The default is a MemoryStore holding up to 100000 clients.
+func WithStore(store Store) Option {
+	return func(c *config) {
+		c.store = store
+	}
+}
+
+// KeyBy identifies clients with the first key function yielding a non-empty key.
+// Unidentified clients share the same bucket. The default is ByClientIP.
+func KeyBy(keyFuncs ...KeyFunc) Option {
+	return func(c *config) {
+		c.keyFuncs = keyFuncs
+	}
+}
+
+// ErrorHandler handles errors of the store. Operations are allowed when the store fails.
+// The default logs errors.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// CostWeighted takes as many tokens as the cost of operations, instead of one token per operation.
+// Complexity extensions must be used before the rate limiter.
+func CostWeighted() Option {
+	return func(c *config) {
+		c.costWeighted = true
+	}
+}
+
+// ExposeBudget exposes the remaining budget of clients in response extensions and headers
+func ExposeBudget() Option {
+	return func(c *config) {
+		c.exposeBudget = true
+	}
+}
@@ -0,0 +1,197 @@
+// Package gqlratelimit limits the rate of GraphQL operations per client, with token buckets.
+//
+// Clients are identified by a pluggable KeyFunc, e.g. their API key, user ID or anonymized IP.
+// Operations are evaluated before execution: operations exceeding the limit are rejected with a RATE_LIMITED
+// error, carrying the number of seconds to wait before retrying in its "retryAfter" extension and in the Retry-After
+// header (see the throttle package).
+//
+//	srv.Use(gqlratelimit.New(gqlratelimit.PerMinute(600),
+//		gqlratelimit.KeyBy(gqlratelimit.ByAPIKey("X-Api-Key"), gqlratelimit.ByClientIP()),
+//	))
+//
+// With the CostWeighted option, operations take as many tokens as their cost, as computed by the gqlcomplexity
+// extension (or by the gqlgen complexity limit), so that expensive operations can't sneak under the limit.
+// Complexity extensions must be used before the rate limiter.
+//
+// With the ExposeBudget option, the remaining budget of the client is exposed in the "rateLimit" response extension,
+// and in the X-RateLimit-Limit and X-RateLimit-Remaining headers when the httpheader middleware is installed.
+//
+// Buckets are held in memory by default. Use a shared Store to limit the rate of clients across several servers.
+package gqlratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/throttle"
+)
+
+const (
+	extensionName = "RateLimit"
+
+	// ErrRateLimitedCode is the error code of operations exceeding the rate limit
+	ErrRateLimitedCode = "RATE_LIMITED"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrRateLimitedCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+	graphql.ResponseInterceptor
+} = &Limiter{}
+
+type (
+	// Limiter is a gqlgen extension limiting the rate of operations per client
+	Limiter struct {
+		config
+		limit Limit
+	}
+
+	// Budget of the client after an operation
+	Budget struct {
+		// Cost is the number of tokens taken by the operation
+		Cost int `json:"cost"`
+
+		// Limit is the capacity of the bucket of the client
+		Limit int `json:"limit"`
+
+		// Remaining is the number of tokens left in the bucket of the client
+		Remaining int `json:"remaining"`
+
+		// RetryAfter is the number of seconds to wait before retrying a rejected operation
+		RetryAfter int `json:"retryAfter,omitempty"`
+	}
+)
+
+// New rate limiting extension
+func New(limit Limit, opts ...Option) *Limiter {
+	l := &Limiter{
+		config: defaultConfig(),
+		limit:  limit,
+	}
+	for _, apply := range opts {
+		apply(&l.config)
+	}
+	return l
+}
+
+// ExtensionName yields the extension name: "RateLimit"
+func (l *Limiter) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (l *Limiter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// MutateOperationContext takes tokens from the bucket of the client, and rejects the operation when empty.
+//
+// Operations are allowed when the store fails.
+func (l *Limiter) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	cost := 1
+	if l.costWeighted {
+		if c, _, _ := gqlcomplexity.OperationCost(rc); c > cost {
+			cost = c
+		}
+	}
+
+	res, err := l.store.Take(ctx, l.key(ctx, rc), l.limit, cost)
+	if err != nil {
+		l.errorHandler(err)
+		return nil
+	}
+
+	budget := &Budget{
+		Cost:      cost,
+		Limit:     l.limit.Burst,
+		Remaining: res.Remaining,
+	}
+	if !res.Allowed {
+		budget.RetryAfter = int(math.Ceil(res.RetryAfter.Seconds()))
+	}
+	rc.Stats.SetExtension(extensionName, budget)
+	if l.exposeBudget {
+		httpheader.Set(ctx, "X-RateLimit-Limit", strconv.Itoa(budget.Limit))
+		httpheader.Set(ctx, "X-RateLimit-Remaining", strconv.Itoa(budget.Remaining))
+	}
+	opName := fingerprint.OperationName(rc)
+	if res.Allowed {
+		throttle.Record(ctx, extensionName, opName, throttle.Allowed)
+		return nil
+	}
+
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.TagOperation, opName)}, Throttled.M(1))
+	throttle.Record(ctx, extensionName, opName, throttle.Limited)
+
+	var gqlErr *gqlerror.Error
+	if cost > l.limit.Burst {
+		gqlErr = gqlerror.Errorf("operation has cost %d, which exceeds the rate limit of %d", cost, l.limit.Burst)
+	} else {
+		gqlErr = gqlerror.Errorf("rate limit exceeded, retry in %s", time.Duration(budget.RetryAfter)*time.Second)
+	}
+	errcode.Set(gqlErr, ErrRateLimitedCode)
+	throttle.RetryAfter(ctx, gqlErr, time.Duration(budget.RetryAfter)*time.Second)
+	if l.costWeighted {
+		gqlErr.Extensions["cost"] = cost
+	}
+	return gqlErr
+}
+
+// InterceptResponse exposes the budget of the client in the "rateLimit" response extension, with the ExposeBudget option
+func (l *Limiter) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil || !l.exposeBudget {
+		return resp
+	}
+
+	if budget := GetOperationBudget(graphql.GetOperationContext(ctx)); budget != nil {
+		if resp.Extensions == nil {
+			resp.Extensions = make(map[string]interface{}, 1)
+		}
+		resp.Extensions["rateLimit"] = budget
+	}
+	return resp
+}
+
+// GetBudget retrieves the budget of the client from the request context, after the rate limit is evaluated
+func GetBudget(ctx context.Context) *Budget {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	return GetOperationBudget(graphql.GetOperationContext(ctx))
+}
+
+// GetOperationBudget retrieves the budget of the client from the operation context
+func GetOperationBudget(rc *graphql.OperationContext) *Budget {
+	if rc == nil {
+		return nil
+	}
+	b, _ := rc.Stats.GetExtension(extensionName).(*Budget)
+	return b
+}
+
+// key of the client, from the first key function yielding a non-empty key
+func (l *Limiter) key(ctx context.Context, rc *graphql.OperationContext) string {
+	for _, keyFunc := range l.keyFuncs {
+		if key := keyFunc(ctx, rc); key != "" {
+			return key
+		}
+	}
+	return "anonymous"
+}
@@ -0,0 +1,101 @@
+package gqlratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const (
+	schema = `type Query { hello: String }`
+	hi     = `query hi { hello }`
+)
+
+// apiKey identifies the client of a request
+func apiKey(key string) gqltesting.RequestOption {
+	return gqltesting.Header("X-Api-Key", key)
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit, int) (Result, error) {
+	return Result{}, errors.New("unavailable")
+}
+
+func TestLimiter(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	srv := gqltesting.NewServer(testschema.New(schema, nil), New(PerMinute(2), KeyBy(ByAPIKey("X-Api-Key"))))
+
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, hi, apiKey("a")).Body.String())
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, hi, apiKey("a")).Body.String())
+	assert.JSONEq(t, `{
+		"errors":[{"message":"rate limit exceeded, retry in 30s","extensions":{"code":"RATE_LIMITED","retryAfter":30}}],
+		"data":null
+	}`, gqltesting.Post(srv, hi, apiKey("a")).Body.String())
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, hi, apiKey("b")).Body.String(), "clients have their own bucket")
+
+	rows, err := view.RetrieveData(ThrottledCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "hi", rows[0].Tags[0].Value)
+
+	var failures int
+	srv = gqltesting.NewServer(testschema.New(schema, nil), New(PerMinute(0), WithStore(failingStore{}), ErrorHandler(func(error) { failures++ })))
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, hi, apiKey("a")).Body.String(), "operations are allowed when the store fails")
+	assert.Equal(t, 1, failures)
+}
+
+func TestCostWeighted(t *testing.T) {
+	srv := gqltesting.NewServer(testschema.New(gqlcomplexity.Directive+`type Query { hello: String @cost(weight: 4) }`, nil), gqlcomplexity.New(100), New(Limit{Rate: 1, Burst: 10}, CostWeighted(), ExposeBudget()))
+	h := httpheader.Middleware(srv)
+
+	assert.JSONEq(t, `{"data":{"hello":"hello"},"extensions":{"rateLimit":{"cost":4,"limit":10,"remaining":6}}}`, gqltesting.Post(h, hi, apiKey("a")).Body.String())
+	assert.JSONEq(t, `{"data":{"hello":"hello"},"extensions":{"rateLimit":{"cost":4,"limit":10,"remaining":2}}}`, gqltesting.Post(h, hi, apiKey("a")).Body.String())
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ hello }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.JSONEq(t, `{
+		"errors":[{"message":"rate limit exceeded, retry in 2s","extensions":{"code":"RATE_LIMITED","retryAfter":2,"cost":4}}],
+		"extensions":{"rateLimit":{"cost":4,"limit":10,"remaining":2,"retryAfter":2}},
+		"data":null
+	}`, w.Body.String())
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore(2)
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 3}
+
+	res, _ := store.Take(context.Background(), "a", limit, 2)
+	assert.Equal(t, Result{Allowed: true, Remaining: 1}, res)
+	res, _ = store.Take(context.Background(), "a", limit, 3)
+	assert.Equal(t, Result{Remaining: 1, RetryAfter: 2 * time.Second}, res)
+
+	now = now.Add(time.Second)
+	res, _ = store.Take(context.Background(), "a", limit, 2)
+	assert.Equal(t, Result{Allowed: true}, res, "tokens are refilled over time")
+
+	_, _ = store.Take(context.Background(), "b", limit, 1)
+	_, _ = store.Take(context.Background(), "c", limit, 1)
+	assert.Len(t, store.buckets, 2)
+}
@@ -0,0 +1,126 @@
+package gqlratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+var _ Store = &MemoryStore{}
+
+type (
+	// Limit of a token bucket: tokens are refilled at Rate per second, up to Burst tokens
+	Limit struct {
+		Rate  float64
+		Burst int
+	}
+
+	// Result of taking tokens from a bucket
+	Result struct {
+		Allowed bool
+
+		// Remaining tokens in the bucket
+		Remaining int
+
+		// RetryAfter is the time until enough tokens are available, when not allowed
+		RetryAfter time.Duration
+	}
+
+	// Store holds the token buckets of clients.
+	// Stores backed by a shared database enable rate limiting across several servers.
+	Store interface {
+		// Take n tokens from the bucket of a client
+		Take(ctx context.Context, key string, limit Limit, n int) (Result, error)
+	}
+
+	// MemoryStore holds token buckets in memory, for a single server
+	MemoryStore struct {
+		mu      sync.Mutex
+		buckets map[string]*bucket
+		maxKeys int
+		now     func() time.Time
+	}
+
+	bucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// PerSecond allows n operations per second, with bursts of n operations
+func PerSecond(n int) Limit {
+	return Limit{Rate: float64(n), Burst: n}
+}
+
+// PerMinute allows n operations per minute, with bursts of n operations
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// PerHour allows n operations per hour, with bursts of n operations
+func PerHour(n int) Limit {
+	return Limit{Rate: float64(n) / 3600, Burst: n}
+}
+
+// NewMemoryStore holds up to maxKeys buckets in memory.
+//
+// When full, buckets unused for an hour are dropped first, then arbitrary buckets.
+func NewMemoryStore(maxKeys int) *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket, maxKeys),
+		maxKeys: maxKeys,
+		now:     time.Now,
+	}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, n int) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		s.evict(now)
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	b.refill(limit, now)
+
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+	}
+
+	res := Result{Remaining: int(b.tokens)}
+	if limit.Rate > 0 {
+		res.RetryAfter = time.Duration(math.Ceil((float64(n) - b.tokens) / limit.Rate * float64(time.Second)))
+	}
+	return res, nil
+}
+
+func (s *MemoryStore) evict(now time.Time) {
+	if len(s.buckets) < s.maxKeys {
+		return
+	}
+	for key, b := range s.buckets {
+		// buckets unused for an hour are most likely refilled
+		if now.Sub(b.last) > time.Hour {
+			delete(s.buckets, key)
+		}
+	}
+	for key := range s.buckets {
+		if len(s.buckets) < s.maxKeys {
+			break
+		}
+		delete(s.buckets, key)
+	}
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed.Seconds()*limit.Rate)
+		b.last = now
+	}
+}
@@ -0,0 +1,52 @@
+package gqlrecover
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+var _ graphql.ErrorPresenterFunc = (&Recoverer{}).Present
+
+// Present is a gqlgen ErrorPresenterFunc: errors with an allowed code reach clients unchanged, as well as the errors
+// of recovered panics, while other errors are logged with a new incident reference and replaced by a masked INTERNAL
+// error with this reference.
+func (r *Recoverer) Present(ctx context.Context, err error) *gqlerror.Error {
+	presented := graphql.DefaultErrorPresenter(ctx, err)
+	code, _ := presented.Extensions["code"].(string)
+	if r.allowedCodes[code] {
+		return presented
+	}
+	if _, ok := presented.Extensions["incident"]; ok && code == ErrInternalCode {
+		// the errors of recovered panics are masked already
+		return presented
+	}
+
+	incident := Incident{
+		ID:    newID(),
+		Time:  time.Now(),
+		Path:  presented.Path.String(),
+		Error: err.Error(),
+	}
+	if graphql.HasOperationContext(ctx) {
+		incident.Operation = fingerprint.OperationName(graphql.GetOperationContext(ctx))
+	}
+
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.TagOperation, incident.Operation)}, MaskedErrors.M(1))
+	r.logIncident(incident)
+	for _, fn := range r.onError {
+		fn(ctx, incident)
+	}
+
+	masked := r.masked(incident.ID)
+	masked.Path = presented.Path
+	masked.Locations = presented.Locations
+	return masked
+}
@@ -0,0 +1,63 @@
+package gqlrecover
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of panics.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(RecoverViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of panics
+func UnregisterViews() {
+	view.Unregister(RecoverViews...)
+}
+
+var (
+	// RecoverViews contains all opencensus stats views declared by the panic recovery
+	RecoverViews = []*view.View{
+		PanicCountView,
+		MaskedErrorCountView,
+	}
+
+	// measurements
+
+	// Panics tracks a count of panics recovered while executing operations
+	Panics = stats.Int64(
+		"gql/recover/panic_count",
+		"Number of panics recovered while executing GraphQL operations",
+		stats.UnitDimensionless)
+
+	// MaskedErrors tracks a count of errors masked by the error presenter
+	MaskedErrors = stats.Int64(
+		"gql/recover/masked_error_count",
+		"Number of GraphQL errors masked as internal errors",
+		stats.UnitDimensionless)
+
+	// views
+
+	// PanicCountView reports a count of recovered panics, by operation name and field
+	PanicCountView = &view.View{
+		Name:        "gql/recover/panic_count",
+		Description: "Count of panics recovered while executing GraphQL operations, by operation and field",
+		Measure:     Panics,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, metrics.TagField},
+	}
+
+	// MaskedErrorCountView reports a count of masked errors, by operation name
+	MaskedErrorCountView = &view.View{
+		Name:        "gql/recover/masked_error_count",
+		Description: "Count of GraphQL errors masked as internal errors, by operation",
+		Measure:     MaskedErrors,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation},
+	}
+)
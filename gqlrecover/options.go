@@ -0,0 +1,70 @@
+package gqlrecover
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql/errcode"
+)
+
+// Option for the panic recovery
+type Option func(*config)
+
+type config struct {
+	log          io.Writer
+	message      string
+	onPanic      []func(context.Context, Incident)
+	onError      []func(context.Context, Incident)
+	allowedCodes map[string]bool
+}
+
+func defaultConfig() config {
+	return config{
+		log:     os.Stderr,
+		message: "internal server error",
+		allowedCodes: map[string]bool{
+			errcode.ParseFailed:      true,
+			errcode.ValidationFailed: true,
+		},
+	}
+}
+
+// Log writes a JSON line per incident, with the stack of the panic. The default is os.Stderr, and nil disables logs.
+func Log(w io.Writer) Option {
+	return func(c *config) {
+		c.log = w
+	}
+}
+
+// Message of the errors returned to clients, completed by the incident reference, for panics and masked errors.
+// The default is "internal server error".
+func Message(message string) Option {
+	return func(c *config) {
+		c.message = message
+	}
+}
+
+// OnPanic calls a function on every incident, e.g. to report it to an error tracker
+func OnPanic(fn func(context.Context, Incident)) Option {
+	return func(c *config) {
+		c.onPanic = append(c.onPanic, fn)
+	}
+}
+
+// OnError calls a function on every masked error, e.g. to report it to an error tracker
+func OnError(fn func(context.Context, Incident)) Option {
+	return func(c *config) {
+		c.onError = append(c.onError, fn)
+	}
+}
+
+// AllowCodes lets the errors with some codes reach clients unmasked, as user-facing errors, e.g. "NOT_FOUND". The
+// parsing and validation errors of gqlgen are allowed by default.
+func AllowCodes(codes ...string) Option {
+	return func(c *config) {
+		for _, code := range codes {
+			c.allowedCodes[code] = true
+		}
+	}
+}
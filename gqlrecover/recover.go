@@ -0,0 +1,164 @@
+// Package gqlrecover recovers from the panics of resolvers, and masks internal errors, with a single, coherent
+// report of the incident.
+//
+// Every recovered panic is given an incident reference, is counted, is recorded on the current span and on the
+// span of the operation, and is logged with its stack, while clients get a masked INTERNAL error with the reference
+// of the incident only:
+//
+//	recoverer := gqlrecover.New(gqlrecover.Log(os.Stderr))
+//	srv.SetRecoverFunc(recoverer.Recover)
+//
+// Errors are masked alike with an error presenter, unless their code is allowed as user-facing:
+//
+//	recoverer := gqlrecover.New(gqlrecover.AllowCodes("NOT_FOUND", "FORBIDDEN"))
+//	srv.SetErrorPresenter(recoverer.Present)
+//
+// Client errors look like:
+//
+//	{"message": "internal server error (incident 3f2a9c0b1d4e5f60)", "extensions": {"code": "INTERNAL", "incident": "3f2a9c0b1d4e5f60"}}
+package gqlrecover
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// ErrInternalCode is the error code of the errors of recovered panics
+const ErrInternalCode = "INTERNAL"
+
+func init() {
+	errcode.RegisterErrorType(ErrInternalCode, errcode.KindUser)
+}
+
+var _ graphql.RecoverFunc = (&Recoverer{}).Recover
+
+type (
+	// Recoverer recovers from panics, see Recover
+	Recoverer struct {
+		config
+		mu sync.Mutex
+	}
+
+	// Incident describes a recovered panic
+	Incident struct {
+		ID        string    `json:"incident"`
+		Time      time.Time `json:"time"`
+		Operation string    `json:"operation,omitempty"`
+		Path      string    `json:"path,omitempty"`
+		Panic     string    `json:"panic,omitempty"`
+		Stack     string    `json:"stack,omitempty"`
+
+		// Error is the original message of a masked error
+		Error string `json:"error,omitempty"`
+	}
+)
+
+// New panic recovery
+func New(opts ...Option) *Recoverer {
+	r := &Recoverer{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&r.config)
+	}
+	return r
+}
+
+// Recover is a gqlgen RecoverFunc: it reports the incident of a panic, and yields a masked INTERNAL error with the
+// reference of the incident
+func (r *Recoverer) Recover(ctx context.Context, p interface{}) error {
+	incident := Incident{
+		ID:    newID(),
+		Time:  time.Now(),
+		Panic: fmt.Sprint(p),
+		Stack: string(debug.Stack()),
+	}
+	var field string
+	if graphql.HasOperationContext(ctx) {
+		incident.Operation = fingerprint.OperationName(graphql.GetOperationContext(ctx))
+	}
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		incident.Path = fc.Path().String()
+		field = fc.Field.Name
+	}
+
+	r.record(ctx, incident, field)
+	r.trace(ctx, incident)
+	r.logIncident(incident)
+	for _, fn := range r.onPanic {
+		fn(ctx, incident)
+	}
+
+	return r.masked(incident.ID)
+}
+
+// masked yields an INTERNAL error referencing an incident
+func (r *Recoverer) masked(id string) *gqlerror.Error {
+	err := gqlerror.Errorf("%s (incident %s)", r.message, id)
+	errcode.Set(err, ErrInternalCode)
+	err.Extensions["incident"] = id
+	return err
+}
+
+// record counts the panic
+func (r *Recoverer) record(ctx context.Context, incident Incident, field string) {
+	mutators := []tag.Mutator{tag.Upsert(metrics.TagOperation, incident.Operation)}
+	if field != "" {
+		mutators = append(mutators, tag.Upsert(metrics.TagField, field))
+	}
+	_ = stats.RecordWithTags(ctx, mutators, Panics.M(1))
+}
+
+// trace annotates the current span and the span of the operation with the incident
+func (r *Recoverer) trace(ctx context.Context, incident Incident) {
+	status := trace.Status{Code: trace.StatusCodeInternal, Message: "panic: incident " + incident.ID}
+	attributes := []trace.Attribute{
+		trace.StringAttribute("incident", incident.ID),
+		trace.StringAttribute("panic", incident.Panic),
+	}
+	span := trace.FromContext(ctx)
+	if span != nil {
+		span.Annotate(attributes, "panic")
+		span.SetStatus(status)
+	}
+	if operation := gqlopencensus.OperationSpanFromContext(ctx); operation != nil && operation != span {
+		operation.Annotate(attributes, "panic")
+		operation.SetStatus(status)
+	}
+}
+
+// logIncident writes the incident as a JSON line
+func (r *Recoverer) logIncident(incident Incident) {
+	if r.log == nil {
+		return
+	}
+	line, err := json.Marshal(incident)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.log.Write(append(line, '\n'))
+}
+
+// newID yields a random incident reference
+func newID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
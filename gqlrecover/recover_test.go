@@ -0,0 +1,123 @@
+package gqlrecover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus/tracetest"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+)
+
+func TestRecover(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	var log bytes.Buffer
+	var reported []Incident
+	r := New(Log(&log), OnPanic(func(_ context.Context, incident Incident) {
+		reported = append(reported, incident)
+	}))
+
+	var err error
+	tracer := gqlopencensus.New(gqlopencensus.OnlyMethods(false))
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+	tracer.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fctx := gqltesting.Field("todos", 0, "text").Object("Todo").Context(ctx)
+		_, _ = tracer.InterceptField(fctx, func(ctx context.Context) (interface{}, error) {
+			err = r.Recover(ctx, "boom")
+			return nil, err
+		})
+		return &graphql.Response{}
+	})
+
+	require.Len(t, reported, 1)
+	incident := reported[0]
+	assert.Len(t, incident.ID, 16)
+	assert.Equal(t, "todos", incident.Operation)
+	assert.Equal(t, "todos[0].text", incident.Path)
+	assert.Equal(t, "boom", incident.Panic)
+	assert.Contains(t, incident.Stack, "gqlrecover.(*Recoverer).Recover")
+
+	var gqlErr *gqlerror.Error
+	require.ErrorAs(t, err, &gqlErr)
+	assert.Equal(t, "internal server error (incident "+incident.ID+")", gqlErr.Message)
+	assert.Equal(t, map[string]interface{}{"code": ErrInternalCode, "incident": incident.ID}, gqlErr.Extensions)
+
+	var logged Incident
+	require.NoError(t, json.Unmarshal(log.Bytes(), &logged))
+	assert.Equal(t, incident.ID, logged.ID)
+	assert.Equal(t, incident.Stack, logged.Stack)
+
+	metricstest.AssertCount(t, PanicCountView, map[tag.Key]string{metrics.TagOperation: "todos", metrics.TagField: "text"}, 1)
+
+	spans := rec.WaitForSpans(t, 2)
+	for _, name := range []string{"todos", "todos[0].text"} {
+		span := tracetest.Span(t, spans, name)
+		tracetest.AssertStatus(t, span, trace.StatusCodeInternal)
+		require.Len(t, span.Annotations, 1, name)
+		assert.Equal(t, "panic", span.Annotations[0].Message)
+	}
+}
+
+func TestRecoverOutsideOperations(t *testing.T) {
+	err := New(Log(nil), Message("oops")).Recover(context.Background(), "boom")
+
+	var gqlErr *gqlerror.Error
+	require.ErrorAs(t, err, &gqlErr)
+	assert.Contains(t, gqlErr.Message, "oops (incident ")
+}
+
+func TestPresent(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	var log bytes.Buffer
+	var reported []Incident
+	r := New(Log(&log), AllowCodes("NOT_FOUND"), OnError(func(_ context.Context, incident Incident) {
+		reported = append(reported, incident)
+	}))
+
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+	ctx = gqltesting.Field("todos").Context(ctx)
+
+	notFound := gqlerror.Errorf("todo not found")
+	errcode.Set(notFound, "NOT_FOUND")
+	assert.Equal(t, notFound, r.Present(ctx, notFound))
+
+	panicked := r.Recover(ctx, "boom")
+	assert.Equal(t, panicked, r.Present(ctx, panicked))
+	log.Reset()
+
+	masked := r.Present(ctx, errors.New("pq: relation \"todos\" does not exist"))
+	require.Len(t, reported, 1)
+	incident := reported[0]
+	assert.Equal(t, "internal server error (incident "+incident.ID+")", masked.Message)
+	assert.Equal(t, map[string]interface{}{"code": ErrInternalCode, "incident": incident.ID}, masked.Extensions)
+	assert.Equal(t, "todos", masked.Path.String())
+	assert.Equal(t, "todos", incident.Operation)
+	assert.Equal(t, `pq: relation "todos" does not exist`, incident.Error)
+
+	var logged map[string]interface{}
+	require.NoError(t, json.Unmarshal(log.Bytes(), &logged))
+	assert.Equal(t, incident.ID, logged["incident"])
+	assert.Equal(t, incident.Error, logged["error"])
+	assert.NotContains(t, logged, "stack")
+
+	metricstest.AssertCount(t, MaskedErrorCountView, map[tag.Key]string{metrics.TagOperation: "todos"}, 1)
+}
@@ -0,0 +1,208 @@
+// Package gqlrelay supports Relay persisted queries.
+//
+// Relay clients with persisted queries enabled send a document ID instead of the query text, as
+// `doc_id` (or `query_id`, `id` for older relay versions) in the request body or URL query.
+//
+// PersistedQueries is both a transport and an extension: the transport accepts the Relay request shape,
+// and the extension resolves documents from a Store. Register both, before the default transports:
+//
+//	pq := gqlrelay.New(gqlrelay.MapStore(queries))
+//	srv := handler.New(es)
+//	srv.AddTransport(pq)
+//	srv.AddTransport(transport.GET{})
+//	srv.AddTransport(transport.POST{})
+//	srv.Use(pq)
+package gqlrelay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	extensionName = "RelayPersistedQueries"
+
+	// docIDExtension carries the document ID from the transport to the extension
+	docIDExtension = "relayDocID"
+
+	// ErrNotFoundCode is the error code when the requested document ID is unknown
+	ErrNotFoundCode = "PERSISTED_QUERY_NOT_FOUND"
+)
+
+// idParams are the request fields holding a document ID, by order of precedence
+var idParams = []string{"doc_id", "query_id", "id"}
+
+type (
+	// Store of persisted documents, by ID
+	Store interface {
+		Get(ctx context.Context, id string) (query string, ok bool)
+	}
+
+	// MapStore is a static Store, e.g. loaded from the persisted_queries.json file generated by the relay compiler
+	MapStore map[string]string
+
+	// StoreFunc adapts a function to a Store
+	StoreFunc func(ctx context.Context, id string) (string, bool)
+)
+
+// Get implements Store
+func (m MapStore) Get(_ context.Context, id string) (string, bool) {
+	query, ok := m[id]
+	return query, ok
+}
+
+// Get implements Store
+func (f StoreFunc) Get(ctx context.Context, id string) (string, bool) {
+	return f(ctx, id)
+}
+
+var (
+	_ graphql.Transport = &PersistedQueries{}
+	_ interface {
+		graphql.HandlerExtension
+		graphql.OperationParameterMutator
+	} = &PersistedQueries{}
+)
+
+// PersistedQueries is a gqlgen transport and extension serving Relay persisted queries
+type PersistedQueries struct {
+	store Store
+	post  transport.POST
+	get   transport.GET
+}
+
+// New Relay persisted queries transport and extension, resolving documents from the store
+func New(store Store) *PersistedQueries {
+	return &PersistedQueries{store: store}
+}
+
+// ExtensionName yields the extension name: "RelayPersistedQueries"
+func (*PersistedQueries) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (*PersistedQueries) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Supports implements graphql.Transport. Only requests carrying a document ID are supported:
+// other requests are left to the default transports.
+func (p *PersistedQueries) Supports(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" {
+		return false
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		return query.Get("query") == "" && firstID(func(k string) string { return query.Get(k) }) != ""
+	case http.MethodPost:
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		return err == nil && mediaType == "application/json"
+	default:
+		return false
+	}
+}
+
+// Do implements graphql.Transport. The document ID is moved to the request extensions,
+// then the request is handled by the default GET or POST transport.
+func (p *PersistedQueries) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	if r.Method == http.MethodGet {
+		p.get.Do(w, rewriteGet(r), exec)
+		return
+	}
+	p.post.Do(w, rewritePost(r), exec)
+}
+
+// MutateOperationParameters implements graphql.OperationParameterMutator
+func (p *PersistedQueries) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	id, _ := rawParams.Extensions[docIDExtension].(string)
+	if id == "" {
+		return nil
+	}
+	delete(rawParams.Extensions, docIDExtension)
+
+	query, ok := p.store.Get(ctx, id)
+	if !ok {
+		err := gqlerror.Errorf("persisted query %s not found", id)
+		errcode.Set(err, ErrNotFoundCode)
+		return err
+	}
+	rawParams.Query = query
+	return nil
+}
+
+func firstID(get func(string) string) string {
+	for _, k := range idParams {
+		if id := get(k); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func rewriteGet(r *http.Request) *http.Request {
+	query := r.URL.Query()
+	id := firstID(func(k string) string { return query.Get(k) })
+
+	extensions := map[string]interface{}{}
+	if raw := query.Get("extensions"); raw != "" {
+		_ = json.Unmarshal([]byte(raw), &extensions)
+	}
+	extensions[docIDExtension] = id
+	b, _ := json.Marshal(extensions)
+	query.Set("extensions", string(b))
+
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	r2 := r.Clone(r.Context())
+	r2.URL = &u
+	return r2
+}
+
+// rewritePost moves the document ID to the extensions. When the body can't be rewritten, it is left
+// untouched so that the POST transport reports the error.
+func rewritePost(r *http.Request) *http.Request {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return r
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var params map[string]json.RawMessage
+	if json.Unmarshal(body, &params) != nil {
+		return r
+	}
+	id := firstID(func(k string) string {
+		var s string
+		_ = json.Unmarshal(params[k], &s)
+		return s
+	})
+	if id == "" {
+		return r
+	}
+
+	extensions := map[string]interface{}{}
+	if raw, ok := params["extensions"]; ok {
+		_ = json.Unmarshal(raw, &extensions)
+	}
+	extensions[docIDExtension] = id
+	params["extensions"], _ = json.Marshal(extensions)
+
+	body, err = json.Marshal(params)
+	if err != nil {
+		return r
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return r
+}
@@ -0,0 +1,65 @@
+package gqlrelay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler/testserver"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistedQueries(t *testing.T) {
+	pq := New(MapStore{"abc": "{ name }"})
+
+	srv := testserver.New()
+	srv.AddTransport(pq)
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(pq)
+
+	do := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		return w
+	}
+	post := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("doc_id", func(t *testing.T) {
+		res := do(post(`{"doc_id": "abc", "variables": {}}`))
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.JSONEq(t, `{"data":{"name":"test"}}`, res.Body.String())
+	})
+
+	t.Run("query_id", func(t *testing.T) {
+		res := do(post(`{"query_id": "abc", "extensions": {"other": true}}`))
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.JSONEq(t, `{"data":{"name":"test"}}`, res.Body.String())
+	})
+
+	t.Run("GET", func(t *testing.T) {
+		res := do(httptest.NewRequest(http.MethodGet, "/graphql?doc_id=abc", nil))
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.JSONEq(t, `{"data":{"name":"test"}}`, res.Body.String())
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		res := do(post(`{"doc_id": "def"}`))
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Contains(t, res.Body.String(), ErrNotFoundCode)
+	})
+
+	t.Run("plain queries", func(t *testing.T) {
+		res := do(post(`{"query": "{ name }"}`))
+		assert.JSONEq(t, `{"data":{"name":"test"}}`, res.Body.String())
+
+		res = do(httptest.NewRequest(http.MethodGet, "/graphql?query={name}", nil))
+		assert.JSONEq(t, `{"data":{"name":"test"}}`, res.Body.String())
+	})
+}
@@ -0,0 +1,139 @@
+package gqlsafelist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const apolloManifestFormat = "apollo-persisted-query-manifest"
+
+type (
+	// Manifest is an immutable set of persisted operations, indexed by ID
+	Manifest struct {
+		byID    map[string]string
+		queries map[string]struct{}
+	}
+
+	// Loader yields a manifest, e.g. from a file or from a URL
+	Loader func(ctx context.Context) (*Manifest, error)
+
+	apolloManifest struct {
+		Format     string `json:"format"`
+		Version    int    `json:"version"`
+		Operations []struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		} `json:"operations"`
+	}
+)
+
+// NewManifest builds a manifest from a map of operation IDs to documents
+func NewManifest(operations map[string]string) *Manifest {
+	m := &Manifest{
+		byID:    make(map[string]string, len(operations)),
+		queries: make(map[string]struct{}, len(operations)),
+	}
+	for id, query := range operations {
+		m.byID[id] = query
+		m.queries[query] = struct{}{}
+	}
+	return m
+}
+
+// ParseManifest parses a JSON manifest.
+//
+// Both the Apollo persisted query manifest format, and the plain JSON object mapping IDs to documents
+// produced by GraphQL Code Generator or the relay compiler are supported.
+func ParseManifest(b []byte) (*Manifest, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("gqlsafelist: invalid manifest: %w", err)
+	}
+
+	if _, isApollo := raw["format"]; isApollo {
+		var manifest apolloManifest
+		if err := json.Unmarshal(b, &manifest); err != nil {
+			return nil, fmt.Errorf("gqlsafelist: invalid manifest: %w", err)
+		}
+		if manifest.Format != apolloManifestFormat || manifest.Version != 1 {
+			return nil, fmt.Errorf("gqlsafelist: unsupported manifest format %q, version %d", manifest.Format, manifest.Version)
+		}
+		operations := make(map[string]string, len(manifest.Operations))
+		for _, op := range manifest.Operations {
+			operations[op.ID] = op.Body
+		}
+		return NewManifest(operations), nil
+	}
+
+	operations := make(map[string]string, len(raw))
+	for id, query := range raw {
+		var s string
+		if err := json.Unmarshal(query, &s); err != nil {
+			return nil, fmt.Errorf("gqlsafelist: invalid manifest entry %q: %w", id, err)
+		}
+		operations[id] = s
+	}
+	return NewManifest(operations), nil
+}
+
+// Query returns the document of the operation with the given ID
+func (m *Manifest) Query(id string) (string, bool) {
+	query, ok := m.byID[id]
+	return query, ok
+}
+
+// Contains tells if the document is one of the manifest's operations
+func (m *Manifest) Contains(query string) bool {
+	_, ok := m.queries[query]
+	return ok
+}
+
+// Len is the number of operations in the manifest
+func (m *Manifest) Len() int {
+	return len(m.byID)
+}
+
+// FromFile loads a manifest from a local file
+func FromFile(path string) Loader {
+	return func(_ context.Context) (*Manifest, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("gqlsafelist: could not read manifest: %w", err)
+		}
+		return ParseManifest(b)
+	}
+}
+
+// FromURL loads a manifest over HTTP. If client is nil, http.DefaultClient is used.
+func FromURL(url string, client *http.Client) Loader {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) (*Manifest, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("gqlsafelist: could not create request: %w", err)
+		}
+
+		res, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("gqlsafelist: could not fetch manifest: %w", err)
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			_, _ = io.Copy(ioutil.Discard, res.Body)
+			return nil, fmt.Errorf("gqlsafelist: could not fetch manifest: status %d", res.StatusCode)
+		}
+
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("gqlsafelist: could not fetch manifest: %w", err)
+		}
+		return ParseManifest(b)
+	}
+}
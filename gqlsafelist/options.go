@@ -0,0 +1,76 @@
+package gqlsafelist
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the safelist extension
+type Option func(*config)
+
+type config struct {
+	enforce       bool
+	persistedOnly bool
+	internal      func(context.Context) bool
+	cache         graphql.Cache
+	refresh       time.Duration
+	errorHandler  func(error)
+}
+
+func defaultConfig() config {
+	return config{
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+	}
+}
+
+// Enforce rejects any operation that is not in the manifest, including full documents sent by clients.
+//
+// By default, operations sent in full are executed, and only IDs are resolved from the manifest.
+func Enforce() Option {
+	return func(c *config) {
+		c.enforce = true
+	}
+}
+
+// PersistedOnly rejects any query text, even when the document is in the manifest: clients must send operation IDs.
+func PersistedOnly() Option {
+	return func(c *config) {
+		c.persistedOnly = true
+	}
+}
+
+// AllowInternal exempts internal callers from Enforce and PersistedOnly: they may send documents in full,
+// and register automatic persisted queries in the APQCache.
+func AllowInternal(internal func(context.Context) bool) Option {
+	return func(c *config) {
+		c.internal = internal
+	}
+}
+
+// APQCache resolves IDs missing from the manifest from the automatic persisted query cache.
+// Only internal callers may register queries in the cache.
+func APQCache(cache graphql.Cache) Option {
+	return func(c *config) {
+		c.cache = cache
+	}
+}
+
+// Refresh reloads the manifest periodically, e.g. when it is served from a URL. The default is to load it once.
+func Refresh(interval time.Duration) Option {
+	return func(c *config) {
+		c.refresh = interval
+	}
+}
+
+// ErrorHandler is called with errors occurring while refreshing the manifest in the background.
+// By default, errors are logged.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
@@ -0,0 +1,211 @@
+// Package gqlsafelist serves persisted operations from a manifest (safelist) generated at build time
+// by client tooling, such as the Apollo persisted query manifest or the GraphQL Code Generator client preset.
+//
+// Clients send the operation ID as `extensions.persistedQuery.sha256Hash`, and the server resolves
+// the document from the manifest. Optionally, operations missing from the manifest are rejected.
+//
+// The manifest replaces automatic persisted queries: the AutomaticPersistedQuery extension should not be used alongside.
+//
+// For public production APIs, the PersistedOnly option rejects any query text: only operation IDs are accepted.
+// Internal callers, allowed with AllowInternal, may still send documents, and register automatic persisted
+// queries in the APQCache.
+package gqlsafelist
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/mitchellh/mapstructure"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	extensionName = "PersistedOperationSafelist"
+
+	// ErrNotFoundCode is the error code when the requested operation ID is not in the manifest
+	ErrNotFoundCode = "PERSISTED_QUERY_NOT_FOUND"
+
+	// ErrNotInListCode is the error code when a document is rejected because it is not in the manifest
+	ErrNotInListCode = "PERSISTED_QUERY_NOT_IN_LIST"
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrNotInListCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = &Safelist{}
+
+// Safelist is a gqlgen extension serving operations from a persisted operation manifest
+type Safelist struct {
+	config
+	load     Loader
+	manifest atomic.Value
+
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// New safelist extension. The manifest is loaded once, and refreshed in the background when the Refresh option is set.
+func New(load Loader, opts ...Option) (*Safelist, error) {
+	s := &Safelist{
+		config:  defaultConfig(),
+		load:    load,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	for _, apply := range opts {
+		apply(&s.config)
+	}
+
+	if err := s.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	if s.refresh > 0 {
+		go s.run()
+	} else {
+		close(s.stopped)
+	}
+	return s, nil
+}
+
+// ExtensionName yields the extension name: "PersistedOperationSafelist"
+func (*Safelist) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (*Safelist) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Manifest currently in use
+func (s *Safelist) Manifest() *Manifest {
+	return s.manifest.Load().(*Manifest)
+}
+
+// Reload the manifest now. On error, the current manifest remains in use.
+func (s *Safelist) Reload(ctx context.Context) error {
+	m, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	s.manifest.Store(m)
+	return nil
+}
+
+// Close stops refreshing the manifest
+func (s *Safelist) Close() error {
+	s.stopOnce.Do(func() { close(s.done) })
+	<-s.stopped
+	return nil
+}
+
+// MutateOperationParameters implements graphql.OperationParameterMutator
+func (s *Safelist) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	manifest := s.Manifest()
+	internal := s.internal != nil && s.internal(ctx)
+
+	id, err := persistedID(rawParams)
+	if err != nil {
+		return err
+	}
+
+	if id == "" {
+		if internal {
+			return nil
+		}
+		if s.persistedOnly {
+			err := gqlerror.Errorf("only persisted operations are accepted")
+			errcode.Set(err, ErrNotInListCode)
+			return err
+		}
+		if s.enforce && !manifest.Contains(rawParams.Query) {
+			err := gqlerror.Errorf("operation is not in the persisted operation list")
+			errcode.Set(err, ErrNotInListCode)
+			return err
+		}
+		return nil
+	}
+
+	query, ok := manifest.Query(id)
+	if !ok && s.cache != nil {
+		if cached, found := s.cache.Get(ctx, id); found {
+			query, ok = cached.(string)
+		}
+	}
+	if !ok && internal && s.cache != nil && rawParams.Query != "" {
+		// internal callers register automatic persisted queries
+		if computeHash(rawParams.Query) != id {
+			return gqlerror.Errorf("provided APQ hash does not match query")
+		}
+		s.cache.Add(ctx, id, rawParams.Query)
+		query, ok = rawParams.Query, true
+	}
+	if !ok {
+		err := gqlerror.Errorf("PersistedQueryNotFound")
+		errcode.Set(err, ErrNotFoundCode)
+		return err
+	}
+	if rawParams.Query != "" && rawParams.Query != query {
+		return gqlerror.Errorf("provided query does not match the persisted operation %s", id)
+	}
+
+	rawParams.Query = query
+	delete(rawParams.Extensions, "persistedQuery")
+	return nil
+}
+
+func (s *Safelist) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.refresh)
+		if err := s.Reload(ctx); err != nil {
+			s.errorHandler(err)
+		}
+		cancel()
+	}
+}
+
+func computeHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func persistedID(rawParams *graphql.RawParams) (string, *gqlerror.Error) {
+	if rawParams.Extensions["persistedQuery"] == nil {
+		return "", nil
+	}
+
+	var extension struct {
+		Sha256  string `mapstructure:"sha256Hash"`
+		Version int64  `mapstructure:"version"`
+	}
+	if err := mapstructure.Decode(rawParams.Extensions["persistedQuery"], &extension); err != nil {
+		return "", gqlerror.Errorf("invalid persisted query extension data")
+	}
+	if extension.Version != 1 {
+		return "", gqlerror.Errorf("unsupported persisted query version")
+	}
+	return extension.Sha256, nil
+}
@@ -0,0 +1,143 @@
+package gqlsafelist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest([]byte(`{
+		"format": "apollo-persisted-query-manifest",
+		"version": 1,
+		"operations": [{"id": "abc", "name": "Todos", "type": "query", "body": "query Todos { todos { id } }"}]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, m.Len())
+	query, ok := m.Query("abc")
+	assert.True(t, ok)
+	assert.Equal(t, "query Todos { todos { id } }", query)
+	assert.True(t, m.Contains("query Todos { todos { id } }"))
+
+	m, err = ParseManifest([]byte(`{"abc": "{ todos { id } }", "def": "{ me { id } }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, m.Len())
+	query, _ = m.Query("def")
+	assert.Equal(t, "{ me { id } }", query)
+
+	_, err = ParseManifest([]byte(`{"format": "other", "version": 1}`))
+	assert.Error(t, err)
+	_, err = ParseManifest([]byte(`{"abc": 1}`))
+	assert.Error(t, err)
+}
+
+func TestSafelist(t *testing.T) {
+	static := func(ops map[string]string) Loader {
+		return func(context.Context) (*Manifest, error) {
+			return NewManifest(ops), nil
+		}
+	}
+	persisted := func(id string) map[string]interface{} {
+		return map[string]interface{}{
+			"persistedQuery": map[string]interface{}{"sha256Hash": id, "version": 1},
+		}
+	}
+
+	t.Run("resolves IDs", func(t *testing.T) {
+		s, err := New(static(map[string]string{"abc": "{ todos { id } }"}))
+		require.NoError(t, err)
+		defer s.Close()
+
+		params := &graphql.RawParams{Extensions: persisted("abc")}
+		require.Nil(t, s.MutateOperationParameters(context.Background(), params))
+		assert.Equal(t, "{ todos { id } }", params.Query)
+		assert.Nil(t, params.Extensions["persistedQuery"])
+
+		gqlErr := s.MutateOperationParameters(context.Background(), &graphql.RawParams{Extensions: persisted("def")})
+		require.NotNil(t, gqlErr)
+		assert.Equal(t, ErrNotFoundCode, gqlErr.Extensions["code"])
+
+		gqlErr = s.MutateOperationParameters(context.Background(), &graphql.RawParams{Query: "{ me { id } }", Extensions: persisted("abc")})
+		assert.NotNil(t, gqlErr)
+
+		assert.Nil(t, s.MutateOperationParameters(context.Background(), &graphql.RawParams{Query: "{ me { id } }"}))
+	})
+
+	t.Run("enforces the list", func(t *testing.T) {
+		s, err := New(static(map[string]string{"abc": "{ todos { id } }"}), Enforce())
+		require.NoError(t, err)
+		defer s.Close()
+
+		assert.Nil(t, s.MutateOperationParameters(context.Background(), &graphql.RawParams{Query: "{ todos { id } }"}))
+
+		gqlErr := s.MutateOperationParameters(context.Background(), &graphql.RawParams{Query: "{ me { id } }"})
+		require.NotNil(t, gqlErr)
+		assert.Equal(t, ErrNotInListCode, gqlErr.Extensions["code"])
+	})
+
+	t.Run("accepts persisted operations only", func(t *testing.T) {
+		type internalKey struct{}
+		cache := graphql.MapCache{}
+		s, err := New(static(map[string]string{"abc": "{ todos { id } }"}),
+			PersistedOnly(),
+			APQCache(cache),
+			AllowInternal(func(ctx context.Context) bool { return ctx.Value(internalKey{}) != nil }),
+		)
+		require.NoError(t, err)
+		defer s.Close()
+
+		ctx := context.Background()
+		internal := context.WithValue(ctx, internalKey{}, true)
+
+		gqlErr := s.MutateOperationParameters(ctx, &graphql.RawParams{Query: "{ todos { id } }"})
+		require.NotNil(t, gqlErr)
+		assert.Equal(t, ErrNotInListCode, gqlErr.Extensions["code"])
+		assert.Nil(t, s.MutateOperationParameters(ctx, &graphql.RawParams{Extensions: persisted("abc")}))
+		assert.Nil(t, s.MutateOperationParameters(internal, &graphql.RawParams{Query: "{ me { id } }"}))
+
+		// only internal callers register automatic persisted queries
+		query := "{ me { name } }"
+		id := computeHash(query)
+		gqlErr = s.MutateOperationParameters(ctx, &graphql.RawParams{Query: query, Extensions: persisted(id)})
+		require.NotNil(t, gqlErr)
+		assert.Equal(t, ErrNotFoundCode, gqlErr.Extensions["code"])
+		assert.NotNil(t, s.MutateOperationParameters(internal, &graphql.RawParams{Query: query, Extensions: persisted("bad")}))
+		assert.Nil(t, s.MutateOperationParameters(internal, &graphql.RawParams{Query: query, Extensions: persisted(id)}))
+
+		params := &graphql.RawParams{Extensions: persisted(id)}
+		assert.Nil(t, s.MutateOperationParameters(ctx, params))
+		assert.Equal(t, query, params.Query)
+	})
+
+	t.Run("refreshes from a URL", func(t *testing.T) {
+		var version int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&version, 1) == 1 {
+				_, _ = w.Write([]byte(`{"abc": "{ todos { id } }"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"abc": "{ todos { id } }", "def": "{ me { id } }"}`))
+		}))
+		defer srv.Close()
+
+		s, err := New(FromURL(srv.URL, nil), Refresh(10*time.Millisecond))
+		require.NoError(t, err)
+		defer s.Close()
+
+		require.Eventually(t, func() bool {
+			return s.Manifest().Len() == 2
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("fails on initial load", func(t *testing.T) {
+		_, err := New(FromFile("does-not-exist.json"))
+		assert.Error(t, err)
+	})
+}
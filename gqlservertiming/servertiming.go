@@ -0,0 +1,112 @@
+// Package gqlservertiming writes a Server-Timing response header with the phases of GraphQL operations, so browser
+// devtools and CDNs show the breakdown of an operation without opening the tracing backend:
+//
+//	srv.Use(gqlservertiming.New())
+//	http.Handle("/query", httpheader.Middleware(srv))
+//
+// The header lists the durations of parsing, validation, execution and the total, in milliseconds, and whether the
+// response was served from the gqlcache response cache:
+//
+//	Server-Timing: parse;dur=0.12, validate;dur=0.3, execute;dur=12.5, total;dur=13.1, cache;desc=hit
+//
+// The extension must be used before the response cache to see its hits. Headers are set with the httpheader
+// middleware, for the first response of an operation only.
+package gqlservertiming
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+)
+
+const (
+	extensionName = "ServerTiming"
+
+	// Header is the name of the response header
+	Header = "Server-Timing"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &ServerTiming{}
+
+// ServerTiming is a gqlgen extension writing a Server-Timing header
+type ServerTiming struct {
+	now func() time.Time
+}
+
+// New Server-Timing extension
+func New() *ServerTiming {
+	return &ServerTiming{now: func() time.Time {
+		return graphql.Now()
+	}}
+}
+
+// ExtensionName yields the extension name: "ServerTiming"
+func (s *ServerTiming) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (s *ServerTiming) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation sets the header when the first response of the operation is complete
+func (s *ServerTiming) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if !httpheader.Installed(ctx) {
+		return next(ctx)
+	}
+
+	rc := graphql.GetOperationContext(ctx)
+	responses := next(ctx)
+	first := true
+	return func(rctx context.Context) *graphql.Response {
+		resp := responses(rctx)
+		if first {
+			first = false
+			// transports may call response handlers with another context: the operation context holds the headers
+			httpheader.Add(ctx, Header, s.header(ctx, rc))
+		}
+		return resp
+	}
+}
+
+// header yields the value of the Server-Timing header of an operation
+func (s *ServerTiming) header(ctx context.Context, rc *graphql.OperationContext) string {
+	end := s.now()
+	cs := gqlcache.GetStats(ctx)
+	hit := cs != nil && cs.Hit
+
+	metrics := []string{
+		metric("parse", rc.Stats.Parsing.End.Sub(rc.Stats.Parsing.Start)),
+		metric("validate", rc.Stats.Validation.End.Sub(rc.Stats.Validation.Start)),
+	}
+	if !hit {
+		metrics = append(metrics, metric("execute", end.Sub(rc.Stats.Validation.End)))
+	}
+	metrics = append(metrics, metric("total", end.Sub(rc.Stats.OperationStart)))
+	if cs != nil {
+		desc := "miss"
+		if hit {
+			desc = "hit"
+		}
+		metrics = append(metrics, "cache;desc="+desc)
+	}
+	return strings.Join(metrics, ", ")
+}
+
+// metric formats a duration in milliseconds
+func metric(name string, d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return name + ";dur=" + strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
+}
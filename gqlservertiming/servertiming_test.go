@@ -0,0 +1,47 @@
+package gqlservertiming
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/gqlcache"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestServerTiming(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	srv := handler.New(testschema.New(`type Query { todo: String }`, testschema.Resolvers{
+		"Query.todo": func(context.Context) (interface{}, error) {
+			clock.Advance(5 * time.Millisecond)
+			return "todo", nil
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New())
+	srv.Use(gqlcache.New(gqlcache.NewMemoryStore(10), gqlcache.TTL(time.Minute)))
+
+	query := func(h http.Handler) string {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ todo }"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Header().Get(Header)
+	}
+
+	assert.Equal(t, "parse;dur=0, validate;dur=0, execute;dur=5, total;dur=5, cache;desc=miss", query(httpheader.Middleware(srv)))
+	assert.Equal(t, "parse;dur=0, validate;dur=0, total;dur=0, cache;desc=hit", query(httpheader.Middleware(srv)))
+	assert.Empty(t, query(srv), "the header is only set with the middleware")
+}
@@ -0,0 +1,53 @@
+package gqlshed
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of load shedding.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(ShedViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of load shedding
+func UnregisterViews() {
+	view.Unregister(ShedViews...)
+}
+
+var (
+	// ShedViews contains all opencensus stats views declared by the load shedding extension
+	ShedViews = []*view.View{
+		ShedCountView,
+	}
+
+	// measurements
+
+	// Shed tracks a count of operations shed while the server is overloaded
+	Shed = stats.Int64(
+		"gql/shed/shed_count",
+		"Number of GraphQL operations shed while the server is overloaded",
+		stats.UnitDimensionless)
+
+	// views
+
+	// ShedCountView reports a count of shed operations, by priority, action and operation name
+	ShedCountView = &view.View{
+		Name:        "gql/shed/shed_count",
+		Description: "Count of GraphQL operations shed while the server is overloaded, by priority, action and operation",
+		Measure:     Shed,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagPriority, TagAction, metrics.TagOperation},
+	}
+
+	// TagPriority is the priority of a shed operation: "low" or "normal"
+	TagPriority = tag.MustNewKey("gql.shed.priority")
+
+	// TagAction is the action taken on a shed operation: "reject" or "degrade"
+	TagAction = tag.MustNewKey("gql.shed.action")
+)
@@ -0,0 +1,79 @@
+package gqlshed
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the load shedding extension
+type Option func(*config)
+
+type config struct {
+	maxInFlight   int
+	targetLatency time.Duration
+	smoothing     float64
+	classify      Classifier
+	degrade       bool
+	latencyFrom   func() time.Duration
+	now           func() time.Time
+}
+
+func defaultConfig() config {
+	return config{
+		smoothing: 0.1,
+		classify: func(context.Context, *graphql.OperationContext) Priority {
+			return PriorityNormal
+		},
+		now: time.Now,
+	}
+}
+
+// MaxInFlight is the number of concurrent operations beyond which the server is overloaded.
+// The default is unlimited.
+func MaxInFlight(max int) Option {
+	return func(c *config) {
+		c.maxInFlight = max
+	}
+}
+
+// TargetLatency is the recent latency of operations beyond which the server is overloaded.
+// The default is unlimited.
+func TargetLatency(target time.Duration) Option {
+	return func(c *config) {
+		c.targetLatency = target
+	}
+}
+
+// Smoothing factor of the moving average of latency, between 0 and 1: higher values react faster.
+// The default is 0.1.
+func Smoothing(factor float64) Option {
+	return func(c *config) {
+		c.smoothing = factor
+	}
+}
+
+// ClassifyBy classifies the priority of operations. By default, all operations have a normal priority.
+func ClassifyBy(classifier Classifier) Option {
+	return func(c *config) {
+		c.classify = classifier
+	}
+}
+
+// LatencyFrom measures the recent latency of operations with a function, e.g. a percentile of a gqlstats.Aggregator,
+// instead of a moving average:
+//
+//	gqlshed.LatencyFrom(stats.Percentile(0.95, time.Second))
+func LatencyFrom(latency func() time.Duration) Option {
+	return func(c *config) {
+		c.latencyFrom = latency
+	}
+}
+
+// Degrade executes shed operations in degraded mode, instead of rejecting them
+func Degrade() Option {
+	return func(c *config) {
+		c.degrade = true
+	}
+}
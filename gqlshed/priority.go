@@ -0,0 +1,54 @@
+package gqlshed
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+// Priority of operations
+type Priority int
+
+// Priorities
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// Classifier yields the priority of an operation
+type Classifier func(context.Context, *graphql.OperationContext) Priority
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+// ByOperationName classifies operations by name, with a default priority for other operations
+func ByOperationName(priorities map[string]Priority, defaultPriority Priority) Classifier {
+	return func(_ context.Context, rc *graphql.OperationContext) Priority {
+		if p, ok := priorities[fingerprint.OperationName(rc)]; ok {
+			return p
+		}
+		return defaultPriority
+	}
+}
+
+// MutationsFirst gives a high priority to mutations, and a normal priority to queries
+func MutationsFirst() Classifier {
+	return func(_ context.Context, rc *graphql.OperationContext) Priority {
+		if rc.Operation != nil && rc.Operation.Operation == ast.Mutation {
+			return PriorityHigh
+		}
+		return PriorityNormal
+	}
+}
@@ -0,0 +1,175 @@
+// Package gqlshed sheds low-priority GraphQL operations when the server is overloaded.
+//
+// The server is overloaded when too many operations are executing, or when the recent latency of operations
+// exceeds a target. Operations are classified by priority: when overloaded, low priority operations are shed,
+// and when severely overloaded (twice the thresholds), normal priority operations are shed too.
+// High priority operations are never shed.
+//
+// Shed operations are rejected with an OVERLOADED error, or executed in degraded mode with the Degrade option:
+// resolvers may then skip optional work, checking Degraded.
+//
+//	srv.Use(gqlshed.New(
+//		gqlshed.MaxInFlight(200),
+//		gqlshed.TargetLatency(500*time.Millisecond),
+//		gqlshed.ClassifyBy(gqlshed.ByOperationName(map[string]gqlshed.Priority{
+//			"checkout": gqlshed.PriorityHigh,
+//			"recommendations": gqlshed.PriorityLow,
+//		}, gqlshed.PriorityNormal)),
+//	))
+package gqlshed
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "LoadShedding"
+
+	// ErrOverloadedCode is the error code of operations shed while the server is overloaded
+	ErrOverloadedCode = "OVERLOADED"
+
+	// latencyHalfLife is the half-life of the latency estimate, when no operation completes
+	latencyHalfLife = time.Second
+)
+
+func init() {
+	errcode.RegisterErrorType(ErrOverloadedCode, errcode.KindProtocol)
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Shedder{}
+
+type degradedKey struct{}
+
+// Shedder is a gqlgen extension shedding low-priority operations when the server is overloaded
+type Shedder struct {
+	config
+
+	inFlight int64
+
+	mu         sync.Mutex
+	latency    float64 // exponentially weighted moving average, in seconds
+	lastUpdate time.Time
+}
+
+// New load shedding extension
+func New(opts ...Option) *Shedder {
+	s := &Shedder{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&s.config)
+	}
+	return s
+}
+
+// ExtensionName yields the extension name: "LoadShedding"
+func (s *Shedder) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (s *Shedder) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Degraded tells if the operation is executed in degraded mode
+func Degraded(ctx context.Context) bool {
+	degraded, _ := ctx.Value(degradedKey{}).(bool)
+	return degraded
+}
+
+// InterceptResponse sheds operations when overloaded, and tracks the load of the server.
+// Subscriptions are not shed.
+func (s *Shedder) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	rc := graphql.GetOperationContext(ctx)
+	if rc.Operation == nil || rc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	priority := s.classify(ctx, rc)
+	if int(priority) < s.Level() {
+		action := "reject"
+		if s.degrade {
+			action = "degrade"
+		}
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{
+			tag.Upsert(TagPriority, priority.String()),
+			tag.Upsert(TagAction, action),
+			tag.Upsert(metrics.TagOperation, fingerprint.OperationName(rc)),
+		}, Shed.M(1))
+
+		if !s.degrade {
+			err := gqlerror.Errorf("server is overloaded, retry later")
+			errcode.Set(err, ErrOverloadedCode)
+			return &graphql.Response{Errors: gqlerror.List{err}}
+		}
+		ctx = context.WithValue(ctx, degradedKey{}, true)
+	}
+
+	atomic.AddInt64(&s.inFlight, 1)
+	start := s.now()
+	defer func() {
+		atomic.AddInt64(&s.inFlight, -1)
+		s.observe(s.now().Sub(start))
+	}()
+	return next(ctx)
+}
+
+// Level of overload: 0 when not overloaded, 1 when overloaded, 2 when severely overloaded
+func (s *Shedder) Level() int {
+	inFlight := atomic.LoadInt64(&s.inFlight)
+	latency := s.Latency()
+
+	level := 0
+	for i := int64(1); i <= 2; i++ {
+		if (s.maxInFlight > 0 && inFlight >= i*int64(s.maxInFlight)) ||
+			(s.targetLatency > 0 && latency >= time.Duration(i)*s.targetLatency) {
+			level = int(i)
+		}
+	}
+	return level
+}
+
+// Latency is the recent latency of operations. It decays when no operation completes, unless it is measured by
+// LatencyFrom.
+func (s *Shedder) Latency() time.Duration {
+	if s.latencyFrom != nil {
+		return s.latencyFrom()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastUpdate.IsZero() {
+		return 0
+	}
+	decay := math.Pow(0.5, float64(s.now().Sub(s.lastUpdate))/float64(latencyHalfLife))
+	return time.Duration(s.latency * decay * float64(time.Second))
+}
+
+func (s *Shedder) observe(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastUpdate.IsZero() {
+		s.latency = latency.Seconds()
+	} else {
+		s.latency = s.smoothing*latency.Seconds() + (1-s.smoothing)*s.latency
+	}
+	s.lastUpdate = s.now()
+}
@@ -0,0 +1,87 @@
+package gqlshed
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func newServer(s *Shedder) http.Handler {
+	return gqltesting.NewServer(testschema.New(`type Query { hello: String }`, testschema.Resolvers{
+		"Query.hello": func(ctx context.Context) (interface{}, error) {
+			if Degraded(ctx) {
+				return "degraded", nil
+			}
+			return "hello", nil
+		},
+	}), s)
+}
+
+func newShedder(latency time.Duration, opts ...Option) (*Shedder, *time.Time) {
+	now := time.Now()
+	s := New(append([]Option{
+		TargetLatency(100 * time.Millisecond),
+		ClassifyBy(ByOperationName(map[string]Priority{"low": PriorityLow, "high": PriorityHigh}, PriorityNormal)),
+	}, opts...)...)
+	s.now = func() time.Time { return now }
+	s.observe(latency)
+	return s, &now
+}
+
+func TestShedder(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	overloaded := `{"errors":[{"message":"server is overloaded, retry later","extensions":{"code":"OVERLOADED"}}],"data":null}`
+
+	s, _ := newShedder(50 * time.Millisecond)
+	srv := newServer(s)
+	assert.Equal(t, 0, s.Level())
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, `query low { hello }`).Body.String())
+
+	s, now := newShedder(150 * time.Millisecond)
+	srv = newServer(s)
+	assert.Equal(t, 1, s.Level())
+	assert.JSONEq(t, overloaded, gqltesting.Post(srv, `query low { hello }`).Body.String())
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, `query normal { hello }`).Body.String())
+
+	*now = now.Add(2 * time.Second)
+	assert.Equal(t, 0, s.Level(), "latency decays when no operation completes")
+
+	s, _ = newShedder(250 * time.Millisecond)
+	srv = newServer(s)
+	assert.Equal(t, 2, s.Level())
+	assert.JSONEq(t, overloaded, gqltesting.Post(srv, `query normal { hello }`).Body.String())
+	assert.Equal(t, `{"data":{"hello":"hello"}}`, gqltesting.Post(srv, `query high { hello }`).Body.String())
+
+	s, _ = newShedder(150*time.Millisecond, Degrade())
+	assert.Equal(t, `{"data":{"hello":"degraded"}}`, gqltesting.Post(newServer(s), `query low { hello }`).Body.String())
+
+	rows, err := view.RetrieveData(ShedCountView.Name)
+	require.NoError(t, err)
+	assert.Len(t, rows, 3)
+}
+
+func TestMaxInFlight(t *testing.T) {
+	s := New(MaxInFlight(1))
+	s.inFlight = 1
+	assert.Equal(t, 1, s.Level())
+	s.inFlight = 2
+	assert.Equal(t, 2, s.Level())
+}
+
+func TestLatencyFrom(t *testing.T) {
+	p95 := 150 * time.Millisecond
+	s := New(TargetLatency(100*time.Millisecond), LatencyFrom(func() time.Duration { return p95 }))
+	s.observe(time.Millisecond)
+	assert.Equal(t, p95, s.Latency())
+	assert.Equal(t, 1, s.Level())
+}
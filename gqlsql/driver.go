@@ -0,0 +1,249 @@
+package gqlsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+)
+
+var (
+	_ driver.Driver             = wrappedDriver{}
+	_ driver.DriverContext      = wrappedDriver{}
+	_ driver.Connector          = wrappedConnector{}
+	_ driver.Conn               = wrappedConn{}
+	_ driver.ConnPrepareContext = wrappedConn{}
+	_ driver.ConnBeginTx        = wrappedConn{}
+	_ driver.ExecerContext      = wrappedConn{}
+	_ driver.QueryerContext     = wrappedConn{}
+	_ driver.Pinger             = wrappedConn{}
+	_ driver.SessionResetter    = wrappedConn{}
+	_ driver.NamedValueChecker  = wrappedConn{}
+	_ driver.StmtExecContext    = wrappedStmt{}
+	_ driver.StmtQueryContext   = wrappedStmt{}
+	_ driver.NamedValueChecker  = wrappedStmt{}
+)
+
+type (
+	wrappedDriver struct {
+		parent driver.Driver
+	}
+
+	wrappedConnector struct {
+		parent driver.Connector
+		driver driver.Driver
+	}
+
+	wrappedConn struct {
+		parent driver.Conn
+	}
+
+	wrappedStmt struct {
+		parent driver.Stmt
+		conn   driver.Conn
+	}
+
+	// dsnConnector opens connections of drivers which are not a driver.DriverContext
+	dsnConnector struct {
+		dsn    string
+		driver driver.Driver
+	}
+)
+
+// Wrap a database/sql driver, annotating the spans of its queries, statements and transactions with the GraphQL
+// context, see Annotate
+func Wrap(d driver.Driver) driver.Driver {
+	return wrappedDriver{parent: d}
+}
+
+// WrapConnector wraps a database/sql connector, as Wrap does with drivers, e.g. for sql.OpenDB
+func WrapConnector(c driver.Connector) driver.Connector {
+	return wrappedConnector{parent: c, driver: Wrap(c.Driver())}
+}
+
+func (d wrappedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return wrappedConn{parent: conn}, nil
+}
+
+func (d wrappedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.parent.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return wrappedConnector{parent: c, driver: d}, nil
+	}
+	return wrappedConnector{parent: dsnConnector{dsn: name, driver: d.parent}, driver: d}, nil
+}
+
+func (c wrappedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.parent.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return wrappedConn{parent: conn}, nil
+}
+
+func (c wrappedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c wrappedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.parent.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return wrappedStmt{parent: stmt, conn: c.parent}, nil
+}
+
+func (c wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	Annotate(ctx)
+	pc, ok := c.parent.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	stmt, err := pc.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return wrappedStmt{parent: stmt, conn: c.parent}, nil
+}
+
+func (c wrappedConn) Close() error {
+	return c.parent.Close()
+}
+
+func (c wrappedConn) Begin() (driver.Tx, error) {
+	return c.parent.Begin()
+}
+
+func (c wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	Annotate(ctx)
+	if bt, ok := c.parent.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	// as database/sql does for drivers without BeginTx
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	return c.Begin()
+}
+
+func (c wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.parent.(driver.ExecerContext)
+	if !ok {
+		// database/sql prepares a statement instead
+		return nil, driver.ErrSkip
+	}
+	Annotate(ctx)
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.parent.(driver.QueryerContext)
+	if !ok {
+		// database/sql prepares a statement instead
+		return nil, driver.ErrSkip
+	}
+	Annotate(ctx)
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c wrappedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.parent.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c wrappedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.parent.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (s wrappedStmt) Close() error {
+	return s.parent.Close()
+}
+
+func (s wrappedStmt) NumInput() int {
+	return s.parent.NumInput()
+}
+
+func (s wrappedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.parent.Exec(args)
+}
+
+func (s wrappedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.parent.Query(args)
+}
+
+func (s wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	Annotate(ctx)
+	if execer, ok := s.parent.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Exec(values)
+}
+
+func (s wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	Annotate(ctx)
+	if queryer, ok := s.parent.(driver.StmtQueryContext); ok {
+		return queryer.QueryContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Query(values)
+}
+
+// CheckNamedValue delegates to the statement, then to the connection, as database/sql does
+func (s wrappedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.parent.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues converts the arguments of drivers without named parameters
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("sql: driver does not support the use of Named Parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,70 @@
+// Package gqlsql attaches the GraphQL context of resolvers to database spans, so that slow SQL is attributed to the
+// operations and fields of the schema.
+//
+// Wrap the database/sql driver before ocsql, so that the spans started by ocsql are annotated:
+//
+//	sql.Register("postgres-traced", ocsql.Wrap(gqlsql.Wrap(&pq.Driver{})))
+//	db, err := sql.Open("postgres-traced", dsn)
+//
+// or, with connectors:
+//
+//	db := sql.OpenDB(ocsql.WrapConnector(gqlsql.WrapConnector(connector)))
+//
+// Database spans then carry the operation name, the coordinate (e.g. "Query.user") and the path of the field being
+// resolved, as attributes. Other database/sql wrappers may call Annotate, or add Attributes to their own spans.
+package gqlsql
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/coordinate"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/internal/fieldpath"
+)
+
+const (
+	// AttributeOperation is the attribute of the name of the GraphQL operation
+	AttributeOperation = "graphql.operation"
+	// AttributeField is the attribute of the coordinate of the field being resolved, e.g. "Query.user"
+	AttributeField = "graphql.field"
+	// AttributePath is the attribute of the path of the field being resolved, e.g. "users[1].orders"
+	AttributePath = "graphql.path"
+)
+
+// Attributes yields the attributes of the GraphQL operation and field being resolved in a context, if any
+func Attributes(ctx context.Context) []trace.Attribute {
+	var attrs []trace.Attribute
+	if graphql.HasOperationContext(ctx) {
+		if name := fingerprint.OperationName(graphql.GetOperationContext(ctx)); name != "" {
+			attrs = append(attrs, trace.StringAttribute(AttributeOperation, name))
+		}
+	}
+
+	fc := graphql.GetFieldContext(ctx)
+	for fc != nil && fc.Field.Field == nil {
+		// list elements resolve within the field of the list
+		fc = fc.Parent
+	}
+	if fc != nil {
+		attrs = append(attrs,
+			trace.StringAttribute(AttributeField, coordinate.Of(fc).String()),
+			trace.StringAttribute(AttributePath, fieldpath.String(ctx, fc)),
+		)
+	}
+	return attrs
+}
+
+// Annotate the span of a context with the GraphQL attributes of the context. It is a noop when the span is not
+// sampled, or out of a GraphQL operation.
+func Annotate(ctx context.Context) {
+	span := trace.FromContext(ctx)
+	if !span.IsRecordingEvents() {
+		return
+	}
+	if attrs := Attributes(ctx); len(attrs) > 0 {
+		span.AddAttributes(attrs...)
+	}
+}
@@ -0,0 +1,100 @@
+package gqlsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlopencensus/tracetest"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+type (
+	// fakeDriver only supports prepared statements, without contexts
+	fakeDriver struct{}
+	fakeConn   struct{}
+	fakeStmt   struct{}
+	fakeRows   struct{ done bool }
+)
+
+func (fakeDriver) Open(string) (driver.Conn, error)         { return fakeConn{}, nil }
+func (fakeConn) Prepare(string) (driver.Stmt, error)        { return fakeStmt{}, nil }
+func (fakeConn) Close() error                               { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                  { return nil, driver.ErrBadConn }
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return 1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+func (*fakeRows) Columns() []string                         { return []string{"total"} }
+func (*fakeRows) Close() error                              { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(42)
+	return nil
+}
+
+func init() {
+	sql.Register("gqlsql-fake", Wrap(fakeDriver{}))
+}
+
+func TestWrap(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	db, err := sql.Open("gqlsql-fake", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	srv := handler.New(testschema.New(`type Query { user: User } type User { total: Int }`, testschema.Resolvers{
+		"Query.user": func(context.Context) (interface{}, error) {
+			return map[string]interface{}{}, nil
+		},
+		"User.total": func(ctx context.Context) (interface{}, error) {
+			// as ocsql would, around the wrapped driver
+			ctx, span := trace.StartSpan(ctx, "sql:query")
+			defer span.End()
+
+			var total int
+			err := db.QueryRowContext(ctx, "SELECT total FROM orders WHERE user = ?", 1).Scan(&total)
+			return total, err
+		},
+	}))
+	srv.AddTransport(transport.POST{})
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "query User { user { total } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
+	assert.JSONEq(t, `{"data": {"user": {"total": 42}}}`, w.Body.String())
+
+	tracetest.AssertAttributes(t, tracetest.Span(t, rec.WaitForSpans(t, 1), "sql:query"), map[string]interface{}{
+		AttributeOperation: "User",
+		AttributeField:     "User.total",
+		AttributePath:      "user.total",
+	})
+}
+
+func TestAnnotate(t *testing.T) {
+	rec := tracetest.Record()
+	defer rec.Stop()
+
+	ctx, span := trace.StartSpan(context.Background(), "sql:exec")
+	Annotate(ctx)
+	span.End()
+	assert.Empty(t, tracetest.Span(t, rec.WaitForSpans(t, 1), "sql:exec").Attributes, "out of GraphQL operations")
+	assert.Empty(t, Attributes(context.Background()))
+}
@@ -0,0 +1,153 @@
+// Package gqlstats aggregates the latency and errors of GraphQL operations in process, over a rolling window, e.g. to
+// triage slow operations on a box where dashboards are unavailable:
+//
+//	stats := gqlstats.New()
+//	srv.Use(stats)
+//	http.Handle("/debug/graphql/top", stats.TopHandler())
+//
+// Aggregation is opt-in: it costs a lock and a few histograms per operation.
+package gqlstats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const (
+	extensionName = "OperationStats"
+
+	// OtherOperations aggregates the operations beyond MaxOperations
+	OtherOperations = "[other]"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Aggregator{}
+
+type (
+	// Aggregator is a gqlgen extension aggregating the latency and errors of operations over a rolling window
+	Aggregator struct {
+		config
+
+		mu    sync.Mutex
+		slots []slot
+	}
+
+	// slot aggregates operations over a fraction of the window
+	slot struct {
+		start      time.Time
+		operations map[string]*operation
+	}
+
+	operation struct {
+		count   int64
+		errors  int64
+		latency histogram
+	}
+)
+
+// New aggregator
+func New(opts ...Option) *Aggregator {
+	a := &Aggregator{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&a.config)
+	}
+	a.slots = make([]slot, a.config.slots)
+	return a
+}
+
+// ExtensionName yields the extension name: "OperationStats"
+func (a *Aggregator) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. This is a noop
+func (a *Aggregator) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse aggregates the latency and errors of operations
+func (a *Aggregator) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+
+	rc := graphql.GetOperationContext(ctx)
+	end := a.config.now()
+	ms := float64(end.Sub(rc.Stats.OperationStart)) / float64(time.Millisecond)
+	failed := resp != nil && len(resp.Errors) > 0
+	a.record(end, fingerprint.OperationName(rc), ms, failed)
+	return resp
+}
+
+func (a *Aggregator) record(at time.Time, name string, ms float64, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.slot(at)
+	op, ok := s.operations[name]
+	if !ok {
+		if len(s.operations) >= a.config.maxOperations {
+			name = OtherOperations
+			op = s.operations[name]
+		}
+		if op == nil {
+			op = &operation{}
+			s.operations[name] = op
+		}
+	}
+	op.count++
+	if failed {
+		op.errors++
+	}
+	op.latency.add(ms)
+}
+
+// slot of a time, reset when it held an older fraction of the window
+func (a *Aggregator) slot(at time.Time) *slot {
+	width := a.slotWidth()
+	start := at.Truncate(width)
+	s := &a.slots[int(start.UnixNano()/int64(width))%len(a.slots)]
+	if !s.start.Equal(start) {
+		s.start = start
+		s.operations = make(map[string]*operation)
+	}
+	return s
+}
+
+func (a *Aggregator) slotWidth() time.Duration {
+	width := a.config.window / time.Duration(len(a.slots))
+	if width <= 0 {
+		width = time.Nanosecond
+	}
+	return width
+}
+
+// aggregate the operations of the slots within the window
+func (a *Aggregator) aggregate() map[string]*operation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	oldest := a.config.now().Add(-a.config.window)
+	operations := make(map[string]*operation)
+	for _, s := range a.slots {
+		if s.operations == nil || !s.start.After(oldest.Add(-a.slotWidth())) {
+			continue
+		}
+		for name, op := range s.operations {
+			agg, ok := operations[name]
+			if !ok {
+				agg = &operation{}
+				operations[name] = agg
+			}
+			agg.count += op.count
+			agg.errors += op.errors
+			agg.latency.merge(&op.latency)
+		}
+	}
+	return operations
+}
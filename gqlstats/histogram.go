@@ -0,0 +1,67 @@
+package gqlstats
+
+import "math"
+
+const (
+	// histograms have log-linear buckets: each bucket is 5% wider than the previous one, from 10µs to about 17min,
+	// so that percentiles are accurate within 5%
+	bucketGrowth  = 1.05
+	bucketMin     = 0.01 // ms
+	bucketCount   = 380
+	bucketOverMax = bucketCount - 1
+)
+
+var logGrowth = math.Log(bucketGrowth)
+
+// histogram of latencies, in milliseconds
+type histogram struct {
+	counts [bucketCount]uint32
+	total  uint64
+}
+
+func bucket(ms float64) int {
+	if ms <= bucketMin {
+		return 0
+	}
+	b := int(math.Log(ms/bucketMin)/logGrowth) + 1
+	if b > bucketOverMax {
+		return bucketOverMax
+	}
+	return b
+}
+
+// upper bound of a bucket, in milliseconds
+func bucketUpper(b int) float64 {
+	return bucketMin * math.Pow(bucketGrowth, float64(b))
+}
+
+func (h *histogram) add(ms float64) {
+	h.counts[bucket(ms)]++
+	h.total++
+}
+
+func (h *histogram) merge(other *histogram) {
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+}
+
+// quantile q, e.g. 0.95, as the upper bound of the bucket holding it
+func (h *histogram) quantile(q float64) float64 {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += uint64(c)
+		if seen >= rank {
+			return bucketUpper(i)
+		}
+	}
+	return bucketUpper(bucketOverMax)
+}
@@ -0,0 +1,52 @@
+package gqlstats
+
+import (
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the aggregator
+type Option func(*config)
+
+type config struct {
+	window        time.Duration
+	slots         int
+	maxOperations int
+	now           func() time.Time
+}
+
+func defaultConfig() config {
+	return config{
+		window:        5 * time.Minute,
+		slots:         10,
+		maxOperations: 200,
+		now: func() time.Time {
+			return graphql.Now()
+		},
+	}
+}
+
+// Window over which operations are aggregated. The default is 5 minutes.
+//
+// The window rolls by tenths: the oldest tenth of the window is dropped at once.
+func Window(window time.Duration) Option {
+	return func(c *config) {
+		c.window = window
+	}
+}
+
+// MaxOperations is the number of distinct operations aggregated over a window. Further operations are aggregated
+// together, under the name "[other]". The default is 200.
+func MaxOperations(max int) Option {
+	return func(c *config) {
+		c.maxOperations = max
+	}
+}
+
+// Clock measures latencies and windows with a given clock, e.g. a fake clock in tests. By default this is graphql.Now.
+func Clock(now func() time.Time) Option {
+	return func(c *config) {
+		c.now = now
+	}
+}
@@ -0,0 +1,94 @@
+package gqlstats
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Snapshot of the operations aggregated over the window
+	Snapshot struct {
+		Window time.Duration
+
+		// All operations together
+		All OperationSnapshot
+
+		// Operations by name
+		Operations map[string]OperationSnapshot
+	}
+
+	// OperationSnapshot aggregates the executions of an operation over the window. Percentiles are accurate within 5%.
+	OperationSnapshot struct {
+		Count     int64
+		Errors    int64
+		ErrorRate float64
+		P50       time.Duration
+		P95       time.Duration
+		P99       time.Duration
+	}
+)
+
+// Snapshot of the latency percentiles and error rates of operations over the rolling window, e.g. for a service to
+// adapt its behavior to its own latency:
+//
+//	if stats.Snapshot().Operations["checkout"].P99 > time.Second {
+//		// skip optional work
+//	}
+func (a *Aggregator) Snapshot() Snapshot {
+	operations := a.aggregate()
+	snapshot := Snapshot{
+		Window:     a.config.window,
+		Operations: make(map[string]OperationSnapshot, len(operations)),
+	}
+	all := &operation{}
+	for name, op := range operations {
+		snapshot.Operations[name] = op.snapshot()
+		all.count += op.count
+		all.errors += op.errors
+		all.latency.merge(&op.latency)
+	}
+	snapshot.All = all.snapshot()
+	return snapshot
+}
+
+func (op *operation) snapshot() OperationSnapshot {
+	s := OperationSnapshot{
+		Count:  op.count,
+		Errors: op.errors,
+		P50:    milliseconds(op.latency.quantile(0.50)),
+		P95:    milliseconds(op.latency.quantile(0.95)),
+		P99:    milliseconds(op.latency.quantile(0.99)),
+	}
+	if op.count > 0 {
+		s.ErrorRate = float64(op.errors) / float64(op.count)
+	}
+	return s
+}
+
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// Percentile yields a function returning the q-th percentile of the latency of all operations over the window, e.g.
+// 0.95, to feed gqlshed.LatencyFrom. The percentile is computed at most once every refresh interval, so that the
+// function is cheap enough to call on every request.
+func (a *Aggregator) Percentile(q float64, refresh time.Duration) func() time.Duration {
+	var (
+		mu         sync.Mutex
+		value      time.Duration
+		computedAt time.Time
+	)
+	return func() time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		now := a.config.now()
+		if computedAt.IsZero() || now.Sub(computedAt) >= refresh {
+			all := &operation{}
+			for _, op := range a.aggregate() {
+				all.latency.merge(&op.latency)
+			}
+			value, computedAt = milliseconds(all.latency.quantile(q)), now
+		}
+		return value
+	}
+}
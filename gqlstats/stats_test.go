@@ -0,0 +1,146 @@
+package gqlstats
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+)
+
+func TestHistogram(t *testing.T) {
+	var h histogram
+	assert.Equal(t, 0.0, h.quantile(0.5))
+	for i := 1; i <= 1000; i++ {
+		h.add(float64(i))
+	}
+	assert.InEpsilon(t, 500, h.quantile(0.5), 0.05)
+	assert.InEpsilon(t, 950, h.quantile(0.95), 0.05)
+	assert.InEpsilon(t, 990, h.quantile(0.99), 0.05)
+
+	h.add(1e9)
+	assert.Equal(t, bucketUpper(bucketOverMax), h.quantile(1))
+}
+
+// query executes an operation taking some latency, failing or not
+func query(a *Aggregator, clock *gqltesting.Clock, name string, latency time.Duration, failed bool) {
+	ctx := gqltesting.Operation(`query `+name+` { todos { text } }`).
+		Timings(clock.Now(), 0, 0, 0).
+		Context(context.Background())
+	a.InterceptResponse(ctx, func(context.Context) *graphql.Response {
+		clock.Advance(latency)
+		resp := &graphql.Response{}
+		if failed {
+			resp.Errors = gqlerror.List{gqlerror.Errorf("boom")}
+		}
+		return resp
+	})
+}
+
+func TestTop(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	a := New(Window(time.Minute), MaxOperations(3), Clock(clock.Now))
+
+	for i := 0; i < 10; i++ {
+		query(a, clock, "fast", time.Millisecond, false)
+		query(a, clock, "slow", 100*time.Millisecond, false)
+		query(a, clock, "failing", 10*time.Millisecond, i%2 == 0)
+	}
+	query(a, clock, "extra", time.Second, false)
+
+	top := a.Top(10, ByLatency)
+	assert.Equal(t, "1m0s", top.Window)
+	require.Len(t, top.Operations, 4)
+	assert.Equal(t, OtherOperations, top.Operations[0].Operation)
+	assert.Equal(t, "slow", top.Operations[1].Operation)
+	assert.InEpsilon(t, 100, top.Operations[1].P95, 0.05)
+	assert.Equal(t, int64(10), top.Operations[1].Count)
+
+	top = a.Top(1, ByErrorRate)
+	require.Len(t, top.Operations, 1)
+	assert.Equal(t, "failing", top.Operations[0].Operation)
+	assert.Equal(t, int64(5), top.Operations[0].Errors)
+	assert.Equal(t, 0.5, top.Operations[0].ErrorRate)
+
+	// the window rolls
+	clock.Advance(40 * time.Second)
+	query(a, clock, "recent", time.Millisecond, false)
+	assert.Len(t, a.Top(10, ByLatency).Operations, 5)
+	clock.Advance(40 * time.Second)
+	top = a.Top(10, ByLatency)
+	require.Len(t, top.Operations, 1)
+	assert.Equal(t, "recent", top.Operations[0].Operation)
+}
+
+func TestSnapshot(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	a := New(Clock(clock.Now))
+	assert.Equal(t, Snapshot{Window: 5 * time.Minute, Operations: map[string]OperationSnapshot{}}, a.Snapshot())
+
+	for i := 1; i <= 100; i++ {
+		query(a, clock, "todos", time.Duration(i)*time.Millisecond, i > 90)
+	}
+	query(a, clock, "users", time.Second, false)
+
+	snapshot := a.Snapshot()
+	todos := snapshot.Operations["todos"]
+	assert.Equal(t, int64(100), todos.Count)
+	assert.Equal(t, 0.1, todos.ErrorRate)
+	assert.InEpsilon(t, float64(50*time.Millisecond), float64(todos.P50), 0.05)
+	assert.InEpsilon(t, float64(95*time.Millisecond), float64(todos.P95), 0.05)
+	assert.InEpsilon(t, float64(99*time.Millisecond), float64(todos.P99), 0.05)
+
+	assert.Equal(t, int64(101), snapshot.All.Count)
+	assert.Equal(t, int64(10), snapshot.All.Errors)
+	assert.InEpsilon(t, float64(100*time.Millisecond), float64(snapshot.All.P99), 0.05)
+}
+
+func TestPercentile(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	a := New(Clock(clock.Now))
+	p95 := a.Percentile(0.95, time.Second)
+	assert.Equal(t, time.Duration(0), p95())
+
+	query(a, clock, "todos", 100*time.Millisecond, false)
+	assert.Equal(t, time.Duration(0), p95(), "the percentile is refreshed every second")
+	clock.Advance(time.Second)
+	assert.InEpsilon(t, float64(100*time.Millisecond), float64(p95()), 0.05)
+}
+
+func TestTopHandler(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	a := New(Clock(clock.Now))
+	query(a, clock, "todos", 10*time.Millisecond, true)
+	query(a, clock, "users", 20*time.Millisecond, false)
+
+	w := httptest.NewRecorder()
+	a.TopHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/top?n=1&by=error_rate", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var top Top
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &top))
+	require.Len(t, top.Operations, 1)
+	assert.Equal(t, "todos", top.Operations[0].Operation)
+	assert.Equal(t, 1.0, top.Operations[0].ErrorRate)
+
+	for target, expected := range map[string]string{
+		"/top?n=none": "invalid n, expecting a positive number\n",
+		"/top?by=p99": "invalid by, expecting p95 or error_rate\n",
+	} {
+		w = httptest.NewRecorder()
+		a.TopHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, expected, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	a.TopHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/top", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,101 @@
+package gqlstats
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Sort orders of the top operations
+const (
+	ByLatency   = "p95"
+	ByErrorRate = "error_rate"
+)
+
+type (
+	// Top operations over the window
+	Top struct {
+		Window     string         `json:"window"`
+		Operations []OperationTop `json:"operations"`
+	}
+
+	// OperationTop aggregates an operation over the window, with latency percentiles in milliseconds
+	OperationTop struct {
+		Operation string  `json:"operation"`
+		Count     int64   `json:"count"`
+		Errors    int64   `json:"errors"`
+		ErrorRate float64 `json:"error_rate"`
+		P50       float64 `json:"p50_ms"`
+		P95       float64 `json:"p95_ms"`
+		P99       float64 `json:"p99_ms"`
+	}
+)
+
+// Top n operations over the window, by p95 latency or by error rate
+func (a *Aggregator) Top(n int, by string) Top {
+	snapshot := a.Snapshot()
+	top := Top{Window: snapshot.Window.String(), Operations: make([]OperationTop, 0, len(snapshot.Operations))}
+	for name, op := range snapshot.Operations {
+		top.Operations = append(top.Operations, OperationTop{
+			Operation: name,
+			Count:     op.Count,
+			Errors:    op.Errors,
+			ErrorRate: op.ErrorRate,
+			P50:       float64(op.P50) / float64(time.Millisecond),
+			P95:       float64(op.P95) / float64(time.Millisecond),
+			P99:       float64(op.P99) / float64(time.Millisecond),
+		})
+	}
+
+	sort.Slice(top.Operations, func(i, j int) bool {
+		oi, oj := top.Operations[i], top.Operations[j]
+		if by == ByErrorRate && oi.ErrorRate != oj.ErrorRate {
+			return oi.ErrorRate > oj.ErrorRate
+		}
+		if oi.P95 != oj.P95 {
+			return oi.P95 > oj.P95
+		}
+		return oi.Operation < oj.Operation
+	})
+	if n > 0 && len(top.Operations) > n {
+		top.Operations = top.Operations[:n]
+	}
+	return top
+}
+
+// TopHandler serves the top operations over the window, in JSON. It must be protected by the caller.
+//
+//	GET ?n=10&by=p95         yields the 10 operations with the highest p95 latency (the defaults)
+//	GET ?n=5&by=error_rate   yields the 5 operations with the highest error rate
+func (a *Aggregator) TopHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		n := 10
+		if param := r.URL.Query().Get("n"); param != "" {
+			var err error
+			if n, err = strconv.Atoi(param); err != nil || n <= 0 {
+				http.Error(w, "invalid n, expecting a positive number", http.StatusBadRequest)
+				return
+			}
+		}
+		by := r.URL.Query().Get("by")
+		switch by {
+		case "":
+			by = ByLatency
+		case ByLatency, ByErrorRate:
+		default:
+			http.Error(w, "invalid by, expecting p95 or error_rate", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(a.Top(n, by))
+	})
+}
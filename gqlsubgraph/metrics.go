@@ -0,0 +1,95 @@
+package gqlsubgraph
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of subgraph fetches.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(SubgraphViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of subgraph fetches
+func UnregisterViews() {
+	view.Unregister(SubgraphViews...)
+}
+
+var (
+	// SubgraphViews contains all opencensus stats views declared for subgraph fetches
+	SubgraphViews = []*view.View{
+		FetchCountView,
+		FetchLatencyView,
+		FetchErrorCountView,
+		FetchRequestSizeView,
+	}
+
+	// measurements
+
+	// FetchLatency tracks the latency of fetches from subgraphs, in milliseconds
+	FetchLatency = stats.Float64(
+		"gql/subgraph/latency",
+		"Latency of fetches from subgraphs",
+		stats.UnitMilliseconds)
+
+	// FetchErrors tracks a count of failed fetches from subgraphs
+	FetchErrors = stats.Int64(
+		"gql/subgraph/error_count",
+		"Number of failed fetches from subgraphs",
+		stats.UnitDimensionless)
+
+	// FetchRequestSize tracks the size of the requests to subgraphs, in bytes
+	FetchRequestSize = stats.Int64(
+		"gql/subgraph/request_size",
+		"Size of the requests to subgraphs",
+		stats.UnitBytes)
+
+	// views
+
+	// FetchCountView reports a count of fetches from subgraphs, by subgraph and parent operation
+	FetchCountView = &view.View{
+		Name:        "gql/subgraph/fetch_count",
+		Description: "Count of fetches from subgraphs, by subgraph and parent operation",
+		Measure:     FetchLatency,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagSubgraph, metrics.TagOperation},
+	}
+
+	// FetchLatencyView reports a distribution of the latency of fetches from subgraphs, by subgraph and parent operation
+	FetchLatencyView = &view.View{
+		Name:        "gql/subgraph/latency",
+		Description: "Distribution of the latency of fetches from subgraphs, by subgraph and parent operation",
+		Measure:     FetchLatency,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{TagSubgraph, metrics.TagOperation},
+	}
+
+	// FetchErrorCountView reports a count of failed fetches from subgraphs, by subgraph and parent operation
+	FetchErrorCountView = &view.View{
+		Name:        "gql/subgraph/error_count",
+		Description: "Count of failed fetches from subgraphs, by subgraph and parent operation",
+		Measure:     FetchErrors,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagSubgraph, metrics.TagOperation},
+	}
+
+	// FetchRequestSizeView reports a distribution of the size of requests to subgraphs, by subgraph and parent operation
+	FetchRequestSizeView = &view.View{
+		Name:        "gql/subgraph/request_size",
+		Description: "Distribution of the size of requests to subgraphs, by subgraph and parent operation",
+		Measure:     FetchRequestSize,
+		Aggregation: DefaultSizeDistribution,
+		TagKeys:     []tag.Key{TagSubgraph, metrics.TagOperation},
+	}
+
+	// TagSubgraph is the name of a subgraph
+	TagSubgraph = tag.MustNewKey("gql.subgraph")
+
+	// DefaultSizeDistribution constructs buckets for size distributions in views, in bytes
+	DefaultSizeDistribution = view.Distribution(256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216)
+)
@@ -0,0 +1,95 @@
+// Package gqlsubgraph instruments the fetches of a federation gateway from its subgraphs with opencensus metrics.
+//
+// Latency, errors and request sizes are recorded by subgraph and by the parent operation of the gateway:
+//
+//	client := &http.Client{Transport: gqlsubgraph.Transport("reviews", http.DefaultTransport)}
+//
+// Fetches with other clients are instrumented with Fetch.
+package gqlsubgraph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+var _ http.RoundTripper = &roundTripper{}
+
+type roundTripper struct {
+	subgraph string
+	base     http.RoundTripper
+}
+
+// Fetch instruments a fetch from a subgraph, with a request of requestSize bytes.
+//
+// The context of fetch is tagged with the subgraph and the parent operation, for the metrics of downstream clients.
+func Fetch(ctx context.Context, subgraph string, requestSize int64, fetch func(context.Context) error) error {
+	ctx, err := tag.New(ctx,
+		tag.Upsert(TagSubgraph, subgraph),
+		tag.Upsert(metrics.TagOperation, parentOperation(ctx)),
+	)
+	if err != nil {
+		return fetch(ctx)
+	}
+
+	start := time.Now()
+	err = fetch(ctx)
+	measurements := []stats.Measurement{
+		FetchLatency.M(float64(time.Since(start)) / float64(time.Millisecond)),
+		FetchRequestSize.M(requestSize),
+	}
+	if err != nil {
+		measurements = append(measurements, FetchErrors.M(1))
+	}
+	stats.Record(ctx, measurements...)
+	return err
+}
+
+// Transport instruments the HTTP requests to a subgraph, sent with base (or http.DefaultTransport when nil).
+// Transport errors and responses with an error status are recorded as errors.
+func Transport(subgraph string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &roundTripper{subgraph: subgraph, base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	size := req.ContentLength
+	if size < 0 {
+		size = 0
+	}
+
+	_ = Fetch(req.Context(), t.subgraph, size, func(ctx context.Context) error {
+		resp, err = t.base.RoundTrip(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("gqlsubgraph: %s", resp.Status)
+		}
+		return nil
+	})
+	return resp, err
+}
+
+// parentOperation yields the name of the operation of the gateway fetching from subgraphs
+func parentOperation(ctx context.Context) string {
+	if !graphql.HasOperationContext(ctx) {
+		return "unknown"
+	}
+	return fingerprint.OperationName(graphql.GetOperationContext(ctx))
+}
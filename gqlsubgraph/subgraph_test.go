@@ -0,0 +1,75 @@
+package gqlsubgraph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+func TestTransport(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	var tags *tag.Map
+	subgraph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer subgraph.Close()
+
+	client := &http.Client{Transport: Transport("reviews", roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		tags = tag.FromContext(r.Context())
+		return http.DefaultTransport.RoundTrip(r)
+	}))}
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		Operation: &ast.OperationDefinition{Name: "product", Operation: ast.Query},
+	})
+
+	for _, path := range []string{"/", "/down"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, subgraph.URL+path, strings.NewReader(`{"query":"{ reviews }"}`))
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	subgraphName, _ := tags.Value(TagSubgraph)
+	assert.Equal(t, "reviews", subgraphName)
+
+	expected := []tag.Tag{{Key: metrics.TagOperation, Value: "product"}, {Key: TagSubgraph, Value: "reviews"}}
+	rows, err := view.RetrieveData(FetchCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.ElementsMatch(t, expected, rows[0].Tags)
+	assert.Equal(t, int64(2), rows[0].Data.(*view.CountData).Value)
+
+	rows, err = view.RetrieveData(FetchErrorCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(1), rows[0].Data.(*view.CountData).Value)
+
+	rows, err = view.RetrieveData(FetchRequestSizeView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(23), rows[0].Data.(*view.DistributionData).Mean)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,160 @@
+package gqlsyslog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const extensionName = "SyslogAudit"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = &Auditor{}
+
+// Auditor is a gqlgen extension writing one syslog message per GraphQL operation
+type Auditor struct {
+	config
+	w *Writer
+}
+
+// New syslog audit extension sending messages to the given writer
+func New(w *Writer, opts ...Option) *Auditor {
+	a := &Auditor{
+		config: defaultConfig(),
+		w:      w,
+	}
+	for _, apply := range opts {
+		apply(&a.config)
+	}
+	return a
+}
+
+// ExtensionName yields the extension name: "SyslogAudit"
+func (Auditor) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (Auditor) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse implements graphql.ResponseInterceptor
+func (a Auditor) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		return nil
+	}
+
+	oc := graphql.GetOperationContext(ctx)
+	if !a.audited(oc) {
+		return resp
+	}
+
+	severity := a.severity
+	if len(resp.Errors) > 0 {
+		severity = a.errorSeverity
+	}
+
+	element := SDElement{ID: a.sdID}
+	for _, apply := range a.mappers {
+		element.Params = append(element.Params, apply(oc, resp)...)
+	}
+	for _, apply := range a.ctxMappers {
+		element.Params = append(element.Params, apply(ctx)...)
+	}
+
+	_ = a.w.WriteMessage(severity, a.msgID, []SDElement{element}, message(oc, resp))
+
+	return resp
+}
+
+func (a Auditor) audited(oc *graphql.OperationContext) bool {
+	if len(a.operationTypes) == 0 {
+		return true
+	}
+	if oc.Operation == nil {
+		// operations rejected before parsing are always audited
+		return true
+	}
+	for _, typ := range a.operationTypes {
+		if oc.Operation.Operation == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func message(oc *graphql.OperationContext, resp *graphql.Response) string {
+	typ := "operation"
+	if oc.Operation != nil {
+		typ = string(oc.Operation.Operation)
+	}
+	name := fingerprint.OperationName(oc)
+	if name == "" {
+		name = "[anonymous]"
+	}
+	if n := len(resp.Errors); n > 0 {
+		return fmt.Sprintf("%s %s completed with %d error(s)", typ, name, n)
+	}
+	return fmt.Sprintf("%s %s completed", typ, name)
+}
+
+// defaultParams map the operation name, type, root fields, error count and duration
+func defaultParams(oc *graphql.OperationContext, resp *graphql.Response) []SDParam {
+	params := []SDParam{
+		{Name: "operation", Value: fingerprint.OperationName(oc)},
+	}
+	if oc.Operation != nil {
+		params = append(params,
+			SDParam{Name: "type", Value: string(oc.Operation.Operation)},
+			SDParam{Name: "fields", Value: strings.Join(rootFields(oc.Operation.SelectionSet, oc.Doc), ",")},
+		)
+	}
+	params = append(params, SDParam{Name: "errors", Value: strconv.Itoa(len(resp.Errors))})
+	if !oc.Stats.OperationStart.IsZero() {
+		duration := graphql.Now().Sub(oc.Stats.OperationStart)
+		params = append(params, SDParam{Name: "duration_ms", Value: strconv.FormatFloat(float64(duration)/float64(time.Millisecond), 'f', 3, 64)})
+	}
+	return params
+}
+
+func rawQueryParam(oc *graphql.OperationContext, _ *graphql.Response) []SDParam {
+	return []SDParam{{Name: "query", Value: oc.RawQuery}}
+}
+
+func variablesParam(oc *graphql.OperationContext, _ *graphql.Response) []SDParam {
+	variables, _ := json.Marshal(oc.Variables)
+	return []SDParam{{Name: "variables", Value: string(variables)}}
+}
+
+// rootFields lists the top-level fields selected by an operation, resolving fragments
+func rootFields(set ast.SelectionSet, doc *ast.QueryDocument) []string {
+	fields := make([]string, 0, len(set))
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			fields = append(fields, sel.Name)
+		case *ast.InlineFragment:
+			fields = append(fields, rootFields(sel.SelectionSet, doc)...)
+		case *ast.FragmentSpread:
+			if doc == nil {
+				continue
+			}
+			if fragment := doc.Fragments.ForName(sel.Name); fragment != nil {
+				fields = append(fields, rootFields(fragment.SelectionSet, doc)...)
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,126 @@
+package gqlsyslog
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+)
+
+// Option for the syslog audit extension
+type Option func(*config)
+
+// ParamMapper is a functor producing structured data parameters from a completed GraphQL operation
+type ParamMapper func(*graphql.OperationContext, *graphql.Response) []SDParam
+
+// Param is a simple ParamMapper that just adds a constant name/value parameter to every message.
+//
+// Example:
+//
+//	New(w, WithParams(Param("env", "production")))
+func Param(name, value string) ParamMapper {
+	return func(_ *graphql.OperationContext, _ *graphql.Response) []SDParam {
+		return []SDParam{{Name: name, Value: value}}
+	}
+}
+
+// ContextParamMapper is a functor producing structured data parameters from the request context,
+// e.g. with the caller identity stored by some HTTP middleware
+type ContextParamMapper func(context.Context) []SDParam
+
+type config struct {
+	sdID           string
+	msgID          string
+	severity       Severity
+	errorSeverity  Severity
+	mappers        []ParamMapper
+	ctxMappers     []ContextParamMapper
+	operationTypes []ast.Operation
+}
+
+func defaultConfig() config {
+	return config{
+		sdID:          DefaultSDID,
+		msgID:         "graphql",
+		severity:      SeverityInfo,
+		errorSeverity: SeverityWarning,
+		mappers:       []ParamMapper{defaultParams},
+	}
+}
+
+// WithSDID sets the ID of the structured data element carrying the GraphQL parameters.
+// The default is DefaultSDID.
+func WithSDID(id string) Option {
+	return func(c *config) {
+		c.sdID = id
+	}
+}
+
+// WithMsgID sets the MSGID header field of audit messages. The default is "graphql".
+func WithMsgID(msgID string) Option {
+	return func(c *config) {
+		c.msgID = msgID
+	}
+}
+
+// WithSeverity sets the severity of successful and failed operations.
+// The defaults are SeverityInfo and SeverityWarning.
+func WithSeverity(success, failure Severity) Option {
+	return func(c *config) {
+		c.severity = success
+		c.errorSeverity = failure
+	}
+}
+
+// WithParams adds some extra structured data parameters to audit messages
+func WithParams(mappers ...ParamMapper) Option {
+	return func(c *config) {
+		c.mappers = append(c.mappers, mappers...)
+	}
+}
+
+// WithContextParams adds some extra structured data parameters, retrieved from the request context, to audit messages.
+//
+// Example:
+//
+//	New(w, WithContextParams(func(ctx context.Context) []SDParam {
+//		return []SDParam{{Name: "client", Value: clientinfo.ClientID(ctx)}}
+//	}))
+func WithContextParams(mappers ...ContextParamMapper) Option {
+	return func(c *config) {
+		c.ctxMappers = append(c.ctxMappers, mappers...)
+	}
+}
+
+// WithClientInfo adds the anonymized client ID and location of the caller to audit messages,
+// when the clientinfo middleware is installed
+func WithClientInfo() Option {
+	return WithContextParams(func(ctx context.Context) []SDParam {
+		attrs := clientinfo.Attributes(ctx)
+		params := make([]SDParam, 0, len(attrs))
+		for _, attr := range attrs {
+			params = append(params, SDParam{Name: attr.Key, Value: attr.Value})
+		}
+		return params
+	})
+}
+
+// WithRawQuery adds the GraphQL query to audit messages. This is disabled by default.
+func WithRawQuery() Option {
+	return WithParams(rawQueryParam)
+}
+
+// WithVariables adds the values of all variables attached to the GraphQL query to audit messages. This is disabled by default.
+func WithVariables() Option {
+	return WithParams(variablesParam)
+}
+
+// OperationTypes restricts the audit trail to some operation types, e.g. only mutations.
+// By default, all operations are audited.
+func OperationTypes(types ...ast.Operation) Option {
+	return func(c *config) {
+		c.operationTypes = types
+	}
+}
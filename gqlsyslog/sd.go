@@ -0,0 +1,53 @@
+package gqlsyslog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// DefaultSDID is the structured data ID used for GraphQL audit parameters.
+//
+// 32473 is the private enterprise number reserved for documentation: you should use your own when
+// your collector expects it (see WithSDID).
+const DefaultSDID = "gql@32473"
+
+// SDParam is an RFC5424 structured data parameter
+type SDParam struct {
+	Name  string
+	Value string
+}
+
+// SDElement is an RFC5424 structured data element
+type SDElement struct {
+	ID     string
+	Params []SDParam
+}
+
+func (e SDElement) writeTo(buf *bytes.Buffer) {
+	buf.WriteByte('[')
+	buf.WriteString(sdName(e.ID))
+	for _, param := range e.Params {
+		buf.WriteByte(' ')
+		buf.WriteString(sdName(param.Name))
+		buf.WriteString(`="`)
+		sdEscaper.WriteString(buf, param.Value) //nolint:errcheck
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+}
+
+// sdEscaper escapes the characters not allowed in a PARAM-VALUE (RFC5424 section 6.3.3)
+var sdEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// sdName sanitizes an SD-ID or PARAM-NAME: printable US-ASCII, excluding '=', ' ', ']' and '"', at most 32 characters
+func sdName(name string) string {
+	b := make([]byte, 0, len(name))
+	for i := 0; i < len(name) && len(b) < 32; i++ {
+		c := name[i]
+		if c <= 32 || c >= 127 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
@@ -0,0 +1,131 @@
+package gqlsyslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, WithFacility(FacilityAudit), WithAppName("my app"), WithHostname("pod-1"), WithProcID("42"))
+
+	ts := time.Date(2020, 5, 12, 10, 11, 12, 123456000, time.UTC)
+	err := w.writeMessage(ts, SeverityWarning, "graphql", []SDElement{{
+		ID:     DefaultSDID,
+		Params: []SDParam{{Name: "operation", Value: `say "hi" [x]\`}},
+	}}, "done")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`<108>1 2020-05-12T10:11:12.123456Z pod-1 myapp 42 graphql [gql@32473 operation="say \"hi\" [x\]\\"] done`+"\n",
+		buf.String())
+
+	buf.Reset()
+	require.NoError(t, w.writeMessage(ts, SeverityInfo, "", nil, ""))
+	assert.Equal(t, "<110>1 2020-05-12T10:11:12.123456Z pod-1 myapp 42 - -\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, w.writeMessage(ts, SeverityInfo, "", []SDElement{{
+		ID:     DefaultSDID,
+		Params: []SDParam{{Name: "query", Value: "{\r\n  todos\n}"}},
+	}}, "a\nb"))
+	assert.Equal(t, `<110>1 2020-05-12T10:11:12.123456Z pod-1 myapp 42 - [gql@32473 query="{    todos }"] a b`+"\n", buf.String(),
+		"line breaks are collapsed, one message per line")
+}
+
+func TestDial(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gqlsyslog")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := net.Listen("unix", filepath.Join(dir, "syslog.sock"))
+	require.NoError(t, err)
+	defer l.Close()
+
+	w, err := Dial("unix", l.Addr().String(), WithHostname("pod-1"), WithAppName("app"), WithProcID("42"))
+	require.NoError(t, err)
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ts := time.Date(2020, 5, 12, 10, 11, 12, 0, time.UTC)
+	require.NoError(t, w.writeMessage(ts, SeverityInfo, "", nil, "a"))
+	require.NoError(t, w.Close())
+	b, err := ioutil.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "53 <134>1 2020-05-12T10:11:12.000000Z pod-1 app 42 - - a", string(b), "unix streams are octet-counted")
+
+	assert.Equal(t, framingNone, framingOf("udp"))
+	assert.Equal(t, framingNone, framingOf("unixgram"))
+	assert.Equal(t, framingOctetCounting, framingOf("tcp"))
+}
+
+func TestAuditor(t *testing.T) {
+	var buf bytes.Buffer
+	ext := New(NewWriter(&buf, WithHostname("pod-1")), WithParams(Param("env", "test")), OperationTypes(ast.Mutation))
+
+	require.Equal(t, extensionName, ext.ExtensionName())
+	require.Nil(t, ext.Validate(&graphql.ExecutableSchemaMock{}))
+
+	doc := &ast.QueryDocument{
+		Operations: ast.OperationList{
+			{
+				Name:      "createTodo",
+				Operation: ast.Mutation,
+				SelectionSet: ast.SelectionSet{
+					&ast.Field{Name: "createTodo"},
+					&ast.FragmentSpread{Name: "more"},
+				},
+			},
+			{Name: "todos", Operation: ast.Query},
+		},
+		Fragments: ast.FragmentDefinitionList{
+			{Name: "more", SelectionSet: ast.SelectionSet{&ast.Field{Name: "deleteTodo"}}},
+		},
+	}
+	h := func(_ context.Context) *graphql.Response {
+		return &graphql.Response{
+			Data:   json.RawMessage(`{"createTodo": null}`),
+			Errors: gqlerror.List{gqlerror.Errorf("boom")},
+		}
+	}
+
+	opCtx := &graphql.OperationContext{
+		RawQuery:      "mutation createTodo {}",
+		OperationName: "createTodo",
+		Doc:           doc,
+		Operation:     doc.Operations[0],
+	}
+	resp := ext.InterceptResponse(graphql.WithOperationContext(context.Background(), opCtx), h)
+	require.NotNil(t, resp)
+
+	line := buf.String()
+	assert.True(t, strings.HasPrefix(line, "<132>1 "), line)
+	assert.Contains(t, line, ` graphql [gql@32473 operation="createTodo" type="mutation" fields="createTodo,deleteTodo" errors="1" env="test"]`)
+	assert.True(t, strings.HasSuffix(line, " mutation createTodo completed with 1 error(s)\n"), line)
+
+	buf.Reset()
+	opCtx = &graphql.OperationContext{
+		RawQuery:      "query todos {}",
+		OperationName: "todos",
+		Doc:           doc,
+		Operation:     doc.Operations[1],
+	}
+	resp = ext.InterceptResponse(graphql.WithOperationContext(context.Background(), opCtx), h)
+	require.NotNil(t, resp)
+	assert.Empty(t, buf.String(), "queries are not audited")
+}
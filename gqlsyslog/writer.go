@@ -0,0 +1,240 @@
+// Package gqlsyslog writes an audit trail of GraphQL operations
+// as RFC5424 syslog messages.
+package gqlsyslog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Severity of a syslog message, as defined by RFC5424 section 6.2.1
+type Severity int
+
+// Syslog severities
+const (
+	SeverityEmergency Severity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// Facility of a syslog message, as defined by RFC5424 section 6.2.1
+type Facility int
+
+// Syslog facilities commonly used for audit trails
+const (
+	FacilityKern     Facility = 0
+	FacilityUser     Facility = 1
+	FacilityDaemon   Facility = 3
+	FacilityAuth     Facility = 4
+	FacilityAuthPriv Facility = 10
+	FacilityAudit    Facility = 13
+	FacilityLocal0   Facility = 16
+	FacilityLocal1   Facility = 17
+	FacilityLocal2   Facility = 18
+	FacilityLocal3   Facility = 19
+	FacilityLocal4   Facility = 20
+	FacilityLocal5   Facility = 21
+	FacilityLocal6   Facility = 22
+	FacilityLocal7   Facility = 23
+)
+
+const (
+	nilValue        = "-"
+	timestampFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+type framing int
+
+const (
+	// one message per line, used for files and streams that are not syslog collectors
+	framingNewline framing = iota
+	// one message per datagram
+	framingNone
+	// RFC6587 octet counting, used for stream transports to syslog collectors
+	framingOctetCounting
+)
+
+// WriterOption configures the RFC5424 header fields produced by a Writer
+type WriterOption func(*Writer)
+
+// WithFacility sets the syslog facility. The default is FacilityLocal0.
+func WithFacility(facility Facility) WriterOption {
+	return func(w *Writer) {
+		w.facility = facility
+	}
+}
+
+// WithAppName sets the APP-NAME header field. The default is the name of the running program.
+func WithAppName(appName string) WriterOption {
+	return func(w *Writer) {
+		w.appName = headerField(appName, 48)
+	}
+}
+
+// WithHostname sets the HOSTNAME header field. The default is the OS hostname.
+func WithHostname(hostname string) WriterOption {
+	return func(w *Writer) {
+		w.hostname = headerField(hostname, 255)
+	}
+}
+
+// WithProcID sets the PROCID header field. The default is the process ID.
+func WithProcID(procID string) WriterOption {
+	return func(w *Writer) {
+		w.procID = headerField(procID, 128)
+	}
+}
+
+// Writer formats and sends RFC5424 messages. It is safe for concurrent use.
+type Writer struct {
+	mu       sync.Mutex
+	out      io.Writer
+	framing  framing
+	facility Facility
+	hostname string
+	appName  string
+	procID   string
+}
+
+// NewWriter produces a Writer emitting one newline-terminated message per line to w,
+// e.g. a file or the standard output of a container collected by a log shipper.
+//
+// Line breaks within messages, e.g. in multi-line queries, are collapsed into spaces to keep one message per line.
+func NewWriter(w io.Writer, opts ...WriterOption) *Writer {
+	return newWriter(w, framingNewline, opts...)
+}
+
+// Dial connects to a syslog collector. Supported networks are "udp", "tcp" and "unix".
+//
+// Stream connections ("tcp", "unix") use the RFC6587 octet-counting framing, datagrams ("udp", "unixgram") are
+// not framed.
+func Dial(network, raddr string, opts ...WriterOption) (*Writer, error) {
+	conn, err := net.Dial(network, raddr)
+	if err != nil {
+		return nil, fmt.Errorf("gqlsyslog: could not connect to syslog collector: %w", err)
+	}
+	return newWriter(conn, framingOf(network), opts...), nil
+}
+
+// framingOf messages sent over a network: datagrams carry a single message each
+func framingOf(network string) framing {
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return framingNone
+	}
+	return framingOctetCounting
+}
+
+func newWriter(out io.Writer, f framing, opts ...WriterOption) *Writer {
+	hostname, _ := os.Hostname()
+	w := &Writer{
+		out:      out,
+		framing:  f,
+		facility: FacilityLocal0,
+		hostname: headerField(hostname, 255),
+		appName:  headerField(appName(), 48),
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+	for _, apply := range opts {
+		apply(w)
+	}
+	return w
+}
+
+// WriteMessage sends a single message with the given severity, MSGID and structured data
+func (w *Writer) WriteMessage(severity Severity, msgID string, data []SDElement, msg string) error {
+	return w.writeMessage(time.Now(), severity, msgID, data, msg)
+}
+
+func (w *Writer) writeMessage(ts time.Time, severity Severity, msgID string, data []SDElement, msg string) error {
+	var buf bytes.Buffer
+	buf.Grow(256 + len(msg))
+
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %s %s ",
+		int(w.facility)*8+int(severity),
+		ts.UTC().Format(timestampFormat),
+		w.hostname,
+		w.appName,
+		w.procID,
+		headerField(msgID, 32),
+	)
+	if len(data) == 0 {
+		buf.WriteString(nilValue)
+	}
+	for _, element := range data {
+		element.writeTo(&buf)
+	}
+	if msg != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(msg)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	switch w.framing {
+	case framingOctetCounting:
+		_, err = fmt.Fprintf(w.out, "%d %s", buf.Len(), buf.Bytes())
+	case framingNewline:
+		b := buf.Bytes()
+		for i, c := range b {
+			if c == '\n' || c == '\r' {
+				b[i] = ' '
+			}
+		}
+		_, err = w.out.Write(append(b, '\n'))
+	default:
+		_, err = w.out.Write(buf.Bytes())
+	}
+	return err
+}
+
+// Close the underlying connection or writer, if it may be closed
+func (w *Writer) Close() error {
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// headerField sanitizes an RFC5424 header field: printable US-ASCII only, no spaces, bounded length
+func headerField(value string, maxLen int) string {
+	if value == "" {
+		return nilValue
+	}
+	b := make([]byte, 0, len(value))
+	for i := 0; i < len(value) && len(b) < maxLen; i++ {
+		if c := value[i]; c > 32 && c < 127 {
+			b = append(b, c)
+		}
+	}
+	if len(b) == 0 {
+		return nilValue
+	}
+	return string(b)
+}
+
+func appName() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	name := os.Args[0]
+	for i := len(name) - 1; i >= 0; i-- {
+		if os.IsPathSeparator(name[i]) {
+			return name[i+1:]
+		}
+	}
+	return name
+}
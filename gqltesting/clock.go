@@ -0,0 +1,57 @@
+// Package gqltesting helps testing gqlgen extensions and their use: operation and field contexts without running a
+// full server, a fake clock, and servers queried with POST requests.
+package gqltesting
+
+import (
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Clock is a fake clock, controlled by tests, so that latencies are exact.
+//
+// Its Now method may be injected in extensions supporting a clock option, or replace graphql.Now with OverrideNow.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewClock yields a fake clock, starting at a given time
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now yields the time of the clock. It only changes with Advance and Set.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance the clock
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set the time of the clock
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// OverrideNow replaces graphql.Now with the clock, until the returned function restores it:
+//
+//	defer clock.OverrideNow()()
+//
+// graphql.Now is global: tests overriding it must not run in parallel.
+func (c *Clock) OverrideNow() (restore func()) {
+	previous := graphql.Now
+	graphql.Now = c.Now
+	return func() {
+		graphql.Now = previous
+	}
+}
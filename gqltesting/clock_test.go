@@ -0,0 +1,22 @@
+package gqltesting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+
+	restore := clock.OverrideNow()
+	assert.Equal(t, start, graphql.Now())
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), graphql.Now())
+
+	restore()
+	assert.NotEqual(t, start.Add(time.Second), graphql.Now())
+}
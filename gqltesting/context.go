@@ -0,0 +1,207 @@
+package gqltesting
+
+import (
+	"context"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+type (
+	// OperationBuilder builds operation contexts, to test interceptors without an executable schema.
+	//
+	// Builders panic on invalid queries, as tests should not go on with them.
+	OperationBuilder struct {
+		query     string
+		name      string
+		schema    *ast.Schema
+		variables map[string]interface{}
+		start     time.Time
+		timings   [3]time.Duration
+	}
+
+	// FieldBuilder builds field contexts, with the field contexts of their parents
+	FieldBuilder struct {
+		path       []interface{}
+		object     string
+		args       map[string]interface{}
+		isMethod   bool
+		isResolver bool
+		definition *ast.FieldDefinition
+	}
+)
+
+// Operation builds the context of an operation of a query, e.g. "query todos { todos { text } }"
+func Operation(query string) *OperationBuilder {
+	return &OperationBuilder{query: query}
+}
+
+// Name of the operation to execute, among the operations of the query
+func (b *OperationBuilder) Name(name string) *OperationBuilder {
+	b.name = name
+	return b
+}
+
+// Schema validates the query against a schema, which also sets the definitions of its fields
+func (b *OperationBuilder) Schema(schema *ast.Schema) *OperationBuilder {
+	b.schema = schema
+	return b
+}
+
+// Variables of the operation
+func (b *OperationBuilder) Variables(variables map[string]interface{}) *OperationBuilder {
+	b.variables = variables
+	return b
+}
+
+// Timings of the operation: it starts at start, then it is read, parsed and validated in the given durations.
+// By default the operation starts at graphql.Now() and takes no time to read, parse or validate.
+func (b *OperationBuilder) Timings(start time.Time, read, parsing, validation time.Duration) *OperationBuilder {
+	b.start = start
+	b.timings = [3]time.Duration{read, parsing, validation}
+	return b
+}
+
+// Build the operation context
+func (b *OperationBuilder) Build() *graphql.OperationContext {
+	var doc *ast.QueryDocument
+	if b.schema != nil {
+		var errs error
+		doc, errs = loadQuery(b.schema, b.query)
+		if errs != nil {
+			panic(errs)
+		}
+	} else {
+		var err error
+		doc, err = parser.ParseQuery(&ast.Source{Input: b.query})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	op := doc.Operations.ForName(b.name)
+	if op == nil {
+		panic("gqltesting: no operation named " + b.name)
+	}
+
+	rc := &graphql.OperationContext{
+		RawQuery:           b.query,
+		Variables:          b.variables,
+		OperationName:      b.name,
+		Doc:                doc,
+		Operation:          op,
+		ResolverMiddleware: func(ctx context.Context, next graphql.Resolver) (interface{}, error) { return next(ctx) },
+		RootResolverMiddleware: func(ctx context.Context, next graphql.RootResolver) graphql.Marshaler {
+			return next(ctx)
+		},
+	}
+	if rc.Variables == nil {
+		rc.Variables = map[string]interface{}{}
+	}
+
+	start := b.start
+	if start.IsZero() {
+		start = graphql.Now()
+	}
+	rc.Stats.OperationStart = start
+	rc.Stats.Read = graphql.TraceTiming{Start: start, End: start.Add(b.timings[0])}
+	rc.Stats.Parsing = graphql.TraceTiming{Start: rc.Stats.Read.End, End: rc.Stats.Read.End.Add(b.timings[1])}
+	rc.Stats.Validation = graphql.TraceTiming{Start: rc.Stats.Parsing.End, End: rc.Stats.Parsing.End.Add(b.timings[2])}
+	return rc
+}
+
+// Context with the operation context
+func (b *OperationBuilder) Context(ctx context.Context) context.Context {
+	return graphql.WithOperationContext(ctx, b.Build())
+}
+
+// loadQuery parses and validates a query, yielding an error rather than a list of errors
+func loadQuery(schema *ast.Schema, query string) (*ast.QueryDocument, error) {
+	doc, errs := gqlparser.LoadQuery(schema, query)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return doc, nil
+}
+
+// Field builds the context of a field from its path: field names or aliases, and list indexes.
+// The last element is the name of the field, e.g. Field("todos", 0, "text").
+func Field(path ...interface{}) *FieldBuilder {
+	if len(path) == 0 {
+		panic("gqltesting: empty field path")
+	}
+	if _, ok := path[len(path)-1].(string); !ok {
+		panic("gqltesting: a field path must end with a field name")
+	}
+	return &FieldBuilder{path: path}
+}
+
+// Object is the parent type of the field, e.g. "Todo"
+func (b *FieldBuilder) Object(object string) *FieldBuilder {
+	b.object = object
+	return b
+}
+
+// Args of the field
+func (b *FieldBuilder) Args(args map[string]interface{}) *FieldBuilder {
+	b.args = args
+	return b
+}
+
+// Method flags the field as resolved by a method
+func (b *FieldBuilder) Method() *FieldBuilder {
+	b.isMethod = true
+	return b
+}
+
+// Resolver flags the field as resolved by a resolver method
+func (b *FieldBuilder) Resolver() *FieldBuilder {
+	b.isMethod = true
+	b.isResolver = true
+	return b
+}
+
+// Definition of the field in the schema
+func (b *FieldBuilder) Definition(definition *ast.FieldDefinition) *FieldBuilder {
+	b.definition = definition
+	return b
+}
+
+// Build the field context, linked to the field contexts of its parents
+func (b *FieldBuilder) Build() *graphql.FieldContext {
+	return graphql.GetFieldContext(b.Context(context.Background()))
+}
+
+// Context with the field context, nested in the contexts of its parents
+func (b *FieldBuilder) Context(ctx context.Context) context.Context {
+	last := len(b.path) - 1
+	for i, element := range b.path {
+		switch element := element.(type) {
+		case int:
+			index := element
+			ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{Index: &index})
+		case string:
+			fc := &graphql.FieldContext{
+				Field: graphql.CollectedField{Field: &ast.Field{Name: element, Alias: element}},
+			}
+			if i == last {
+				fc.Object = b.object
+				fc.Args = b.args
+				fc.IsMethod = b.isMethod
+				fc.IsResolver = b.isResolver
+				fc.Field.Definition = b.definition
+				fc.Field.Field.Definition = b.definition
+				if b.definition != nil {
+					fc.Field.Name = b.definition.Name
+				}
+			}
+			ctx = graphql.WithFieldContext(ctx, fc)
+		default:
+			panic("gqltesting: field paths hold strings and ints only")
+		}
+	}
+	return ctx
+}
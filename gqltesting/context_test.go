@@ -0,0 +1,73 @@
+package gqltesting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestOperation(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	rc := Operation(`query todos { todos { text } } mutation create { createTodo { id } }`).
+		Name("create").
+		Variables(map[string]interface{}{"text": "abc"}).
+		Timings(start, time.Millisecond, 2*time.Millisecond, 3*time.Millisecond).
+		Build()
+
+	require.NotNil(t, rc.Operation)
+	assert.Equal(t, "create", rc.Operation.Name)
+	assert.Equal(t, ast.Mutation, rc.Operation.Operation)
+	assert.Equal(t, "abc", rc.Variables["text"])
+	assert.Equal(t, start, rc.Stats.OperationStart)
+	assert.Equal(t, start.Add(time.Millisecond), rc.Stats.Parsing.Start)
+	assert.Equal(t, 2*time.Millisecond, rc.Stats.Parsing.End.Sub(rc.Stats.Parsing.Start))
+	assert.Equal(t, start.Add(6*time.Millisecond), rc.Stats.Validation.End)
+
+	ctx := Operation(`{ todos { text } }`).Context(context.Background())
+	assert.Equal(t, ast.Query, graphql.GetOperationContext(ctx).Operation.Operation)
+
+	assert.Panics(t, func() { Operation(`{ todos`).Build() })
+	assert.Panics(t, func() { Operation(`query todos { todos { text } }`).Name("other").Build() })
+}
+
+func TestOperationSchema(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query { todos: [Todo!]! }
+		type Todo { text: String! }
+	`})
+
+	rc := Operation(`{ todos { text } }`).Schema(schema).Build()
+	field := rc.Operation.SelectionSet[0].(*ast.Field)
+	require.NotNil(t, field.Definition)
+	assert.Equal(t, "[Todo!]!", field.Definition.Type.String())
+
+	assert.Panics(t, func() { Operation(`{ users { name } }`).Schema(schema).Build() })
+}
+
+func TestField(t *testing.T) {
+	ctx := Operation(`{ todos { text } }`).Context(context.Background())
+	ctx = Field("todos", 1, "text").
+		Object("Todo").
+		Resolver().
+		Args(map[string]interface{}{"upper": true}).
+		Context(ctx)
+
+	fc := graphql.GetFieldContext(ctx)
+	assert.Equal(t, "text", fc.Field.Name)
+	assert.Equal(t, "Todo", fc.Object)
+	assert.True(t, fc.IsMethod)
+	assert.True(t, fc.IsResolver)
+	assert.Equal(t, true, fc.Args["upper"])
+	assert.Equal(t, "todos[1].text", fc.Path().String())
+	assert.NotNil(t, graphql.GetOperationContext(ctx))
+
+	assert.Equal(t, "todos", Field("todos").Build().Path().String())
+	assert.Panics(t, func() { Field("todos", 0) })
+	assert.Panics(t, func() { Field("todos", 1.5, "text").Build() })
+}
@@ -0,0 +1,54 @@
+package gqltesting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// RequestOption customizes the requests sent with Post
+type RequestOption func(*http.Request) *http.Request
+
+// NewServer yields a server of an executable schema with the POST transport, using some extensions
+func NewServer(schema graphql.ExecutableSchema, extensions ...graphql.HandlerExtension) *handler.Server {
+	srv := handler.New(schema)
+	srv.AddTransport(transport.POST{})
+	for _, ext := range extensions {
+		srv.Use(ext)
+	}
+	return srv
+}
+
+// Post sends a query to a handler in a JSON POST request, and yields the recorded response
+func Post(h http.Handler, query string, opts ...RequestOption) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	for _, apply := range opts {
+		req = apply(req)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// Header sets a header of the request
+func Header(key, value string) RequestOption {
+	return func(r *http.Request) *http.Request {
+		r.Header.Set(key, value)
+		return r
+	}
+}
+
+// RequestContext derives the context of the request, e.g. to store the principal set by an authentication middleware
+func RequestContext(derive func(ctx context.Context) context.Context) RequestOption {
+	return func(r *http.Request) *http.Request {
+		return r.WithContext(derive(r.Context()))
+	}
+}
@@ -0,0 +1,35 @@
+package gqltesting
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+type (
+	headerEcho struct{}
+	userKey    struct{}
+)
+
+func (headerEcho) ExtensionName() string                          { return "HeaderEcho" }
+func (headerEcho) Validate(schema graphql.ExecutableSchema) error { return nil }
+func (headerEcho) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	graphql.RegisterExtension(ctx, "tenant", graphql.GetOperationContext(ctx).Headers.Get("X-Tenant"))
+	graphql.RegisterExtension(ctx, "user", ctx.Value(userKey{}))
+	return next(ctx)
+}
+
+func TestPost(t *testing.T) {
+	srv := NewServer(testschema.New(`type Query { hello(name: String): String }`, nil), headerEcho{})
+
+	res := Post(srv, "query hi {\n hello(name: \"world\") }", Header("X-Tenant", "acme"), RequestContext(func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, userKey{}, "alice")
+	}))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"data":{"hello":"hello"},"extensions":{"tenant":"acme","user":"alice"}}`, res.Body.String())
+}
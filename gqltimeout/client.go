@@ -0,0 +1,171 @@
+package gqltimeout
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Deadline sources, as tagged on timeouts
+const (
+	// SourceServer is the source of deadlines configured on the server
+	SourceServer = "server"
+
+	// SourceClient is the source of deadlines requested by clients
+	SourceClient = "client"
+)
+
+// Default headers of client deadlines
+const (
+	// HeaderRequestTimeout holds a timeout as a number of seconds, e.g. "2.5", or a duration, e.g. "2500ms"
+	HeaderRequestTimeout = "X-Request-Timeout"
+
+	// HeaderGRPCTimeout holds a timeout in the gRPC format, e.g. "2500m"
+	HeaderGRPCTimeout = "Grpc-Timeout"
+)
+
+type (
+	clientDeadline struct {
+		at      time.Time
+		timeout time.Duration
+	}
+
+	clientDeadlineKey struct{}
+)
+
+// ClientOption for the client deadline middleware
+type ClientOption func(*clientConfig)
+
+type clientConfig struct {
+	headers    []string
+	minTimeout time.Duration
+	maxTimeout time.Duration
+}
+
+func defaultClientConfig() clientConfig {
+	return clientConfig{
+		headers:    []string{HeaderRequestTimeout, HeaderGRPCTimeout},
+		maxTimeout: time.Minute,
+	}
+}
+
+// Headers of client deadlines, by order of precedence. The default is X-Request-Timeout, then Grpc-Timeout.
+func Headers(headers ...string) ClientOption {
+	return func(c *clientConfig) {
+		c.headers = headers
+	}
+}
+
+// ClampTimeout clamps the timeouts requested by clients to [min, max]. The default is [0, 1m].
+func ClampTimeout(min, max time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.minTimeout, c.maxTimeout = min, max
+	}
+}
+
+// ClientDeadline is a middleware applying the deadline requested by clients in a header to the request context.
+//
+// Timeouts are clamped to the limits of the server. Invalid timeouts are ignored.
+// With the Timeout extension, operations get the earliest of the client and server deadlines, and timeouts
+// are tagged with the deadline that fired.
+func ClientDeadline(next http.Handler, opts ...ClientOption) http.Handler {
+	cfg := defaultClientConfig()
+	for _, apply := range opts {
+		apply(&cfg)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, ok := cfg.timeout(r.Header)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		deadline := clientDeadline{at: time.Now().Add(timeout), timeout: timeout}
+		ctx, cancel := context.WithDeadline(context.WithValue(r.Context(), clientDeadlineKey{}, deadline), deadline.at)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ClientDeadlineFromContext yields the deadline requested by the client, if any
+func ClientDeadlineFromContext(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(clientDeadlineKey{}).(clientDeadline)
+	return deadline.at, ok
+}
+
+func (c clientConfig) timeout(header http.Header) (time.Duration, bool) {
+	for _, name := range c.headers {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+
+		var (
+			timeout time.Duration
+			ok      bool
+		)
+		if strings.EqualFold(name, HeaderGRPCTimeout) {
+			timeout, ok = parseGRPCTimeout(value)
+		} else {
+			timeout, ok = parseTimeout(value)
+		}
+		if !ok || timeout <= 0 {
+			return 0, false
+		}
+
+		if timeout < c.minTimeout {
+			timeout = c.minTimeout
+		}
+		if c.maxTimeout > 0 && timeout > c.maxTimeout {
+			timeout = c.maxTimeout
+		}
+		return timeout, true
+	}
+	return 0, false
+}
+
+// parseTimeout parses a number of seconds, or a duration
+func parseTimeout(value string) (time.Duration, bool) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds > float64(time.Duration(1<<63-1)/time.Second) {
+			return 0, false
+		}
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	timeout, err := time.ParseDuration(value)
+	return timeout, err == nil
+}
+
+// parseGRPCTimeout parses a timeout in the gRPC format: up to 8 digits, then a unit
+func parseGRPCTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch value[len(value)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
@@ -0,0 +1,50 @@
+package gqltimeout
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+// RegisterViews registers the opencensus views of timeouts.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(TimeoutViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of timeouts
+func UnregisterViews() {
+	view.Unregister(TimeoutViews...)
+}
+
+var (
+	// TimeoutViews contains all opencensus stats views declared by the timeout extension
+	TimeoutViews = []*view.View{
+		TimeoutCountView,
+	}
+
+	// measurements
+
+	// Timeouts tracks a count of operations exceeding their deadline
+	Timeouts = stats.Int64(
+		"gql/timeout/timeout_count",
+		"Number of GraphQL operations exceeding their deadline",
+		stats.UnitDimensionless)
+
+	// views
+
+	// TimeoutCountView reports a count of operations exceeding their deadline, by operation name and deadline source
+	TimeoutCountView = &view.View{
+		Name:        "gql/timeout/timeout_count",
+		Description: "Count of GraphQL operations exceeding their deadline, by operation and deadline source",
+		Measure:     Timeouts,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{metrics.TagOperation, TagSource},
+	}
+
+	// TagSource is the source of the deadline that fired: "server" or "client"
+	TagSource = tag.MustNewKey("gql.timeout.source")
+)
@@ -0,0 +1,44 @@
+package gqltimeout
+
+import (
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Option for the timeout extension
+type Option func(*config)
+
+type config struct {
+	byName   map[string]time.Duration
+	byType   map[ast.Operation]time.Duration
+	fallback time.Duration
+}
+
+func defaultConfig() config {
+	return config{
+		byName: make(map[string]time.Duration),
+		byType: make(map[ast.Operation]time.Duration),
+	}
+}
+
+// Operation sets the timeout of the operations with a name
+func Operation(name string, timeout time.Duration) Option {
+	return func(c *config) {
+		c.byName[name] = timeout
+	}
+}
+
+// OperationType sets the timeout of the operations of a type, e.g. ast.Mutation
+func OperationType(typ ast.Operation, timeout time.Duration) Option {
+	return func(c *config) {
+		c.byType[typ] = timeout
+	}
+}
+
+// Default sets the timeout of all other operations. By default, other operations have no deadline.
+func Default(timeout time.Duration) Option {
+	return func(c *config) {
+		c.fallback = timeout
+	}
+}
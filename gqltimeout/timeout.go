@@ -0,0 +1,146 @@
+// Package gqltimeout applies deadlines to GraphQL operations, configured by operation name or type.
+//
+// Resolvers failing with context.DeadlineExceeded after the deadline of the operation yield a consistent
+// TIMEOUT error, and timeouts are counted per operation and deadline source:
+//
+//	srv.Use(gqltimeout.New(
+//		gqltimeout.Default(5*time.Second),
+//		gqltimeout.OperationType(ast.Mutation, 10*time.Second),
+//		gqltimeout.Operation("salesReport", 30*time.Second),
+//	))
+//
+// Clients may request shorter deadlines with a header, see ClientDeadline.
+package gqltimeout
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const (
+	extensionName = "Timeout"
+
+	// ErrTimeoutCode is the error code of resolvers failing after the deadline of the operation
+	ErrTimeoutCode = "TIMEOUT"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Timeout{}
+
+type (
+	// Timeout is a gqlgen extension applying deadlines to operations
+	Timeout struct {
+		config
+	}
+
+	deadline struct {
+		timeout time.Duration
+		source  string
+		fired   int32
+	}
+
+	deadlineKey struct{}
+)
+
+// New timeout extension
+func New(opts ...Option) *Timeout {
+	t := &Timeout{config: defaultConfig()}
+	for _, apply := range opts {
+		apply(&t.config)
+	}
+	return t
+}
+
+// ExtensionName yields the extension name: "Timeout"
+func (t *Timeout) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (t *Timeout) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse applies the deadline of the operation. Subscriptions have no deadline.
+func (t *Timeout) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	rc := graphql.GetOperationContext(ctx)
+	if rc.Operation == nil || rc.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	d := &deadline{timeout: t.timeout(rc), source: SourceServer}
+	if client, ok := ctx.Value(clientDeadlineKey{}).(clientDeadline); ok {
+		// the client deadline is already applied to the context
+		if d.timeout <= 0 || client.at.Before(time.Now().Add(d.timeout)) {
+			d.timeout, d.source = client.timeout, SourceClient
+		}
+	}
+	if d.timeout <= 0 {
+		return next(ctx)
+	}
+
+	ctx = context.WithValue(ctx, deadlineKey{}, d)
+	if d.source == SourceServer {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	resp := next(ctx)
+	if ctx.Err() == context.DeadlineExceeded || atomic.LoadInt32(&d.fired) == 1 {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{
+			tag.Upsert(metrics.TagOperation, fingerprint.OperationName(rc)),
+			tag.Upsert(TagSource, d.source),
+		}, Timeouts.M(1))
+	}
+	return resp
+}
+
+// InterceptField converts deadline exceeded errors of resolvers into TIMEOUT errors
+func (t *Timeout) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	res, err := next(ctx)
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		return res, err
+	}
+
+	d, ok := ctx.Value(deadlineKey{}).(*deadline)
+	if !ok || ctx.Err() != context.DeadlineExceeded {
+		// not the deadline of the operation
+		return res, err
+	}
+	atomic.StoreInt32(&d.fired, 1)
+
+	var path ast.Path
+	if fc := graphql.GetFieldContext(ctx); fc != nil {
+		path = fc.Path()
+	}
+	gqlErr := gqlerror.ErrorPathf(path, "operation timed out after %s", d.timeout)
+	errcode.Set(gqlErr, ErrTimeoutCode)
+	return res, gqlErr
+}
+
+// timeout of the operation, by name, then by type
+func (t *Timeout) timeout(rc *graphql.OperationContext) time.Duration {
+	if timeout, ok := t.byName[rc.Operation.Name]; ok && rc.Operation.Name != "" {
+		return timeout
+	}
+	if timeout, ok := t.byType[rc.Operation.Operation]; ok {
+		return timeout
+	}
+	return t.fallback
+}
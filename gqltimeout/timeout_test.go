@@ -0,0 +1,110 @@
+package gqltimeout
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestTimeout(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	var deadlines []time.Duration
+	slow := func(ctx context.Context) (interface{}, error) {
+		dl, _ := ctx.Deadline()
+		deadlines = append(deadlines, time.Until(dl).Round(time.Second))
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	srv := handler.New(testschema.New(`
+		type Query { slow: String, fast: String }
+		type Mutation { slow: String }
+	`, testschema.Resolvers{"Query.slow": slow, "Mutation.slow": slow}))
+	srv.AddTransport(transport.POST{})
+	srv.Use(New(
+		Default(10*time.Millisecond),
+		OperationType(ast.Mutation, 20*time.Millisecond),
+		Operation("report", time.Minute),
+	))
+
+	assert.JSONEq(t, `{
+		"errors":[{"message":"operation timed out after 10ms","path":["slow"],"extensions":{"code":"TIMEOUT"}}],
+		"data":{"slow":null,"fast":"fast"}
+	}`, gqltesting.Post(srv, `query list { fast slow }`).Body.String())
+	assert.JSONEq(t, `{
+		"errors":[{"message":"operation timed out after 20ms","path":["slow"],"extensions":{"code":"TIMEOUT"}}],
+		"data":{"slow":null}
+	}`, gqltesting.Post(srv, `mutation { slow }`).Body.String())
+	assert.Equal(t, `{"data":{"fast":"fast"}}`, gqltesting.Post(srv, `query report { fast }`).Body.String())
+
+	rows, err := view.RetrieveData(TimeoutCountView.Name)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+}
+
+func TestClientDeadline(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	srv := gqltesting.NewServer(testschema.New(`type Query { slow: String }`, testschema.Resolvers{
+		"Query.slow": func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}), New(Default(time.Minute)))
+	h := ClientDeadline(srv, ClampTimeout(10*time.Millisecond, time.Second))
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ slow }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderGRPCTimeout, "1n")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.JSONEq(t, `{
+		"errors":[{"message":"operation timed out after 10ms","path":["slow"],"extensions":{"code":"TIMEOUT"}}],
+		"data":{"slow":null}
+	}`, w.Body.String())
+
+	rows, err := view.RetrieveData(TimeoutCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Contains(t, rows[0].Tags, tag.Tag{Key: TagSource, Value: SourceClient})
+}
+
+func TestClientTimeout(t *testing.T) {
+	cfg := defaultClientConfig()
+	for value, expected := range map[string]time.Duration{
+		"2.5":   2500 * time.Millisecond,
+		"250ms": 250 * time.Millisecond,
+		"1h":    time.Minute,
+		"-1":    0,
+		"soon":  0,
+		"":      0,
+	} {
+		timeout, _ := cfg.timeout(http.Header{HeaderRequestTimeout: []string{value}})
+		assert.Equal(t, expected, timeout, value)
+	}
+	for value, expected := range map[string]time.Duration{
+		"100m":       100 * time.Millisecond,
+		"2S":         2 * time.Second,
+		"123456789S": 0,
+		"10x":        0,
+	} {
+		timeout, _ := cfg.timeout(http.Header{HeaderGRPCTimeout: []string{value}})
+		assert.Equal(t, expected, timeout, value)
+	}
+}
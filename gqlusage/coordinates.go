@@ -0,0 +1,92 @@
+package gqlusage
+
+import (
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Kinds of schema coordinates
+const (
+	KindType     = "type"
+	KindField    = "field"
+	KindArgument = "argument"
+)
+
+// defaultDeprecationReason is the reason of @deprecated directives without one, as defined by the spec
+const defaultDeprecationReason = "No longer supported"
+
+// coordinate of a schema element, e.g. "Query", "Query.product" or "Query.product(id:)"
+type coordinate struct {
+	name string
+	kind string
+	// deprecation is the reason of the @deprecated directive of a field or argument, if any
+	deprecation string
+}
+
+// coordinates requested by an operation, once each, in order. Introspection fields are left out.
+func coordinates(op *ast.OperationDefinition) []coordinate {
+	seen := map[coordinate]struct{}{}
+	visitedFragments := map[string]struct{}{}
+	add := func(c coordinate) {
+		seen[c] = struct{}{}
+	}
+	var walk func(ast.SelectionSet)
+	walk = func(set ast.SelectionSet) {
+		for _, sel := range set {
+			switch sel := sel.(type) {
+			case *ast.Field:
+				if sel.Definition == nil || sel.ObjectDefinition == nil || isIntrospection(sel.Name) {
+					continue
+				}
+				parent := sel.ObjectDefinition.Name
+				field := parent + "." + sel.Name
+				add(coordinate{name: parent, kind: KindType})
+				add(coordinate{name: field, kind: KindField, deprecation: deprecation(sel.Definition.Directives)})
+				add(coordinate{name: sel.Definition.Type.Name(), kind: KindType})
+				for _, arg := range sel.Arguments {
+					c := coordinate{name: field + "(" + arg.Name + ":)", kind: KindArgument}
+					if def := sel.Definition.Arguments.ForName(arg.Name); def != nil {
+						c.deprecation = deprecation(def.Directives)
+					}
+					add(c)
+				}
+				walk(sel.SelectionSet)
+			case *ast.InlineFragment:
+				walk(sel.SelectionSet)
+			case *ast.FragmentSpread:
+				if _, ok := visitedFragments[sel.Name]; ok || sel.Definition == nil {
+					continue
+				}
+				visitedFragments[sel.Name] = struct{}{}
+				walk(sel.Definition.SelectionSet)
+			}
+		}
+	}
+	walk(op.SelectionSet)
+
+	coords := make([]coordinate, 0, len(seen))
+	for c := range seen {
+		coords = append(coords, c)
+	}
+	sort.Slice(coords, func(i, j int) bool {
+		return coords[i].name < coords[j].name
+	})
+	return coords
+}
+
+func isIntrospection(name string) bool {
+	return len(name) > 1 && name[0] == '_' && name[1] == '_'
+}
+
+// deprecation yields the reason of a @deprecated directive, or an empty string
+func deprecation(directives ast.DirectiveList) string {
+	d := directives.ForName("deprecated")
+	if d == nil {
+		return ""
+	}
+	if arg := d.Arguments.ForName("reason"); arg != nil && arg.Value != nil && arg.Value.Raw != "" {
+		return arg.Value.Raw
+	}
+	return defaultDeprecationReason
+}
@@ -0,0 +1,172 @@
+package gqlusage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var _ Sink = &Coverage{}
+
+type (
+	// Coverage accumulates when each schema coordinate was last requested, from the reports of a Collector, to find
+	// the fields that are never requested:
+	//
+	//	coverage := gqlusage.NewCoverage()
+	//	usage := gqlusage.New(gqlusage.Sinks(sink, coverage))
+	//	http.Handle("/admin/graphql/coverage", coverage.Handler(es.Schema(), 30*24*time.Hour))
+	//
+	// The memory of a Coverage is bounded by the size of the schema. Save and Load keep it across restarts.
+	Coverage struct {
+		mu       sync.Mutex
+		since    time.Time
+		lastSeen map[string]time.Time
+	}
+
+	// CoverageReport lists the types, fields and arguments of a schema which were not requested since the threshold
+	CoverageReport struct {
+		// Since is the start of the collection of usage: coordinates are never requested since then
+		Since time.Time `json:"since"`
+		// Threshold is the time since which unused coordinates were not requested
+		Threshold time.Time `json:"threshold"`
+		// Fields is the number of fields of the schema, Used the number of fields requested since the threshold
+		Fields int      `json:"fields"`
+		Used   int      `json:"used"`
+		Unused []Unused `json:"unused"`
+	}
+
+	// Unused schema coordinate. LastSeen is nil when it was never requested.
+	Unused struct {
+		Coordinate  string     `json:"coordinate"`
+		Kind        string     `json:"kind"`
+		Deprecation string     `json:"deprecation,omitempty"`
+		LastSeen    *time.Time `json:"lastSeen,omitempty"`
+	}
+
+	savedCoverage struct {
+		Since    time.Time            `json:"since"`
+		LastSeen map[string]time.Time `json:"lastSeen"`
+	}
+)
+
+// NewCoverage accumulating usage from now on
+func NewCoverage() *Coverage {
+	return &Coverage{since: graphql.Now(), lastSeen: map[string]time.Time{}}
+}
+
+// Send implements Sink: coordinates of the report were last seen at its end
+func (c *Coverage) Send(_ context.Context, report *Report) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, u := range report.Usage {
+		if report.End.After(c.lastSeen[u.Coordinate]) {
+			c.lastSeen[u.Coordinate] = report.End
+		}
+	}
+	return nil
+}
+
+// Report the coordinates of the schema not requested for longer than the threshold, e.g. 30 days, or never
+// requested with a threshold of 0. Object, interface and union types, their fields and the arguments of their fields
+// are covered: input types, enums and scalars are not.
+func (c *Coverage) Report(schema *ast.Schema, threshold time.Duration) *CoverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &CoverageReport{Since: c.since, Threshold: graphql.Now().Add(-threshold), Unused: []Unused{}}
+	if threshold == 0 {
+		report.Threshold = c.since
+	}
+	unused := func(name, kind, deprecation string) bool {
+		seen, ok := c.lastSeen[name]
+		if ok && (threshold == 0 || seen.After(report.Threshold)) {
+			return false
+		}
+		u := Unused{Coordinate: name, Kind: kind, Deprecation: deprecation}
+		if ok {
+			u.LastSeen = &seen
+		}
+		report.Unused = append(report.Unused, u)
+		return true
+	}
+
+	for _, def := range schema.Types {
+		if def.BuiltIn || isIntrospection(def.Name) {
+			continue
+		}
+		switch def.Kind {
+		case ast.Object, ast.Interface, ast.Union:
+		default:
+			continue
+		}
+		unused(def.Name, KindType, "")
+		for _, field := range def.Fields {
+			if isIntrospection(field.Name) {
+				continue
+			}
+			name := def.Name + "." + field.Name
+			report.Fields++
+			if !unused(name, KindField, deprecation(field.Directives)) {
+				report.Used++
+			}
+			for _, arg := range field.Arguments {
+				unused(name+"("+arg.Name+":)", KindArgument, deprecation(arg.Directives))
+			}
+		}
+	}
+	sort.Slice(report.Unused, func(i, j int) bool {
+		return report.Unused[i].Coordinate < report.Unused[j].Coordinate
+	})
+	return report
+}
+
+// Handler serves the coverage report of the schema as JSON, e.g. on an admin endpoint. The threshold may be
+// overridden by a "threshold" query parameter, e.g. "?threshold=168h".
+func (c *Coverage) Handler(schema *ast.Schema, threshold time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := threshold
+		if param := r.URL.Query().Get("threshold"); param != "" {
+			var err error
+			if t, err = time.ParseDuration(param); err != nil {
+				http.Error(w, "invalid threshold: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.Report(schema, t))
+	})
+}
+
+// Save the accumulated usage as JSON, e.g. to a file, to Load it after a restart
+func (c *Coverage) Save(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return json.NewEncoder(w).Encode(savedCoverage{Since: c.since, LastSeen: c.lastSeen})
+}
+
+// Load usage saved by Save, merging it with the usage accumulated so far
+func (c *Coverage) Load(r io.Reader) error {
+	var saved savedCoverage
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if saved.Since.Before(c.since) {
+		c.since = saved.Since
+	}
+	for name, seen := range saved.LastSeen {
+		if seen.After(c.lastSeen[name]) {
+			c.lastSeen[name] = seen
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package gqlusage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+)
+
+func TestCoverage(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := gqltesting.NewClock(start)
+	restore := clock.OverrideNow()
+	defer restore()
+
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: deprecatedSchema})
+	coverage := NewCoverage()
+	require.NoError(t, coverage.Send(context.Background(), &Report{
+		End: start.Add(time.Hour),
+		Usage: []Usage{
+			{Coordinate: "Query", Kind: KindType},
+			{Coordinate: "Query.product", Kind: KindField},
+			{Coordinate: "Query.product(id:)", Kind: KindArgument},
+			{Coordinate: "Product", Kind: KindType},
+		},
+	}))
+	clock.Advance(24 * time.Hour)
+	require.NoError(t, coverage.Send(context.Background(), &Report{
+		End:   start.Add(24 * time.Hour),
+		Usage: []Usage{{Coordinate: "Query", Kind: KindType}, {Coordinate: "Query.product", Kind: KindField}},
+	}))
+
+	lastHour := start.Add(time.Hour)
+	assert.Equal(t, &CoverageReport{
+		Since:     start,
+		Threshold: start,
+		Fields:    3,
+		Used:      1,
+		Unused: []Unused{
+			{Coordinate: "Product.name", Kind: KindField},
+			{Coordinate: "Query.legacy", Kind: KindField, Deprecation: "No longer supported"},
+			{Coordinate: "Query.product(sku:)", Kind: KindArgument, Deprecation: "use id"},
+		},
+	}, coverage.Report(schema, 0), "never requested")
+
+	report := coverage.Report(schema, 12*time.Hour)
+	assert.Equal(t, start.Add(12*time.Hour), report.Threshold)
+	assert.Equal(t, []Unused{
+		{Coordinate: "Product", Kind: KindType, LastSeen: &lastHour},
+		{Coordinate: "Product.name", Kind: KindField},
+		{Coordinate: "Query.legacy", Kind: KindField, Deprecation: "No longer supported"},
+		{Coordinate: "Query.product(id:)", Kind: KindArgument, LastSeen: &lastHour},
+		{Coordinate: "Query.product(sku:)", Kind: KindArgument, Deprecation: "use id"},
+	}, report.Unused, "not requested for 12h")
+
+	var saved bytes.Buffer
+	require.NoError(t, coverage.Save(&saved))
+	restored := NewCoverage()
+	require.NoError(t, restored.Load(&saved))
+	assert.Equal(t, coverage.Report(schema, 12*time.Hour), restored.Report(schema, 12*time.Hour))
+
+	w := httptest.NewRecorder()
+	coverage.Handler(schema, 0).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/coverage?threshold=12h", nil))
+	var served CoverageReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &served))
+	assert.Len(t, served.Unused, 5)
+
+	w = httptest.NewRecorder()
+	coverage.Handler(schema, 0).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/coverage?threshold=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,191 @@
+package gqlusage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// DeprecationSummary lists the deprecated fields and arguments still requested during a report period
+	DeprecationSummary struct {
+		Start        time.Time     `json:"start"`
+		End          time.Time     `json:"end"`
+		Deprecations []Deprecation `json:"deprecations"`
+	}
+
+	// Deprecation is the usage of a deprecated field or argument, by client
+	Deprecation struct {
+		Coordinate string        `json:"coordinate"`
+		Kind       string        `json:"kind"`
+		Reason     string        `json:"reason"`
+		Count      int64         `json:"count"`
+		Clients    []ClientCount `json:"clients"`
+	}
+
+	// ClientCount is the number of operations of a client
+	ClientCount struct {
+		Client Client `json:"client"`
+		Count  int64  `json:"count"`
+	}
+
+	// WebhookOption configures the DeprecationWebhook sink
+	WebhookOption func(*webhook)
+
+	webhook struct {
+		url    string
+		client *http.Client
+		header http.Header
+		slack  bool
+	}
+)
+
+// Deprecations summarizes the usage of deprecated fields and arguments in a report, the most requested first. It
+// yields nil when no deprecated field or argument was requested.
+func Deprecations(report *Report) *DeprecationSummary {
+	byCoordinate := map[string]*Deprecation{}
+	for _, u := range report.Usage {
+		if u.Deprecation == "" {
+			continue
+		}
+		d, ok := byCoordinate[u.Coordinate]
+		if !ok {
+			d = &Deprecation{Coordinate: u.Coordinate, Kind: u.Kind, Reason: u.Deprecation}
+			byCoordinate[u.Coordinate] = d
+		}
+		d.Count += u.Count
+		d.Clients = append(d.Clients, ClientCount{Client: u.Client, Count: u.Count})
+	}
+	if len(byCoordinate) == 0 {
+		return nil
+	}
+
+	summary := &DeprecationSummary{Start: report.Start, End: report.End}
+	for _, d := range byCoordinate {
+		sort.SliceStable(d.Clients, func(i, j int) bool {
+			return d.Clients[i].Count > d.Clients[j].Count
+		})
+		summary.Deprecations = append(summary.Deprecations, *d)
+	}
+	sort.Slice(summary.Deprecations, func(i, j int) bool {
+		a, b := summary.Deprecations[i], summary.Deprecations[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Coordinate < b.Coordinate
+	})
+	return summary
+}
+
+// DeprecationWebhook is a Sink posting the DeprecationSummary of each report to a webhook, so that the owners of
+// deprecated fields know which clients still request them before they are removed:
+//
+//	usage := gqlusage.New(gqlusage.DeprecationWebhook(url, gqlusage.Slack()), gqlusage.Interval(24*time.Hour))
+//
+// Nothing is posted when no deprecated field or argument was requested. Summaries are posted as JSON, or as Slack
+// messages with the Slack option.
+func DeprecationWebhook(url string, opts ...WebhookOption) Sink {
+	w := &webhook{
+		url:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+		header: http.Header{},
+	}
+	for _, apply := range opts {
+		apply(w)
+	}
+	return w
+}
+
+// Slack posts summaries as Slack messages, for Slack incoming webhooks and compatible services
+func Slack() WebhookOption {
+	return func(w *webhook) {
+		w.slack = true
+	}
+}
+
+// WebhookClient sets the client used to post summaries
+func WebhookClient(client *http.Client) WebhookOption {
+	return func(w *webhook) {
+		w.client = client
+	}
+}
+
+// WebhookHeader adds a header to the requests of the webhook, e.g. to authenticate them
+func WebhookHeader(key, value string) WebhookOption {
+	return func(w *webhook) {
+		w.header.Add(key, value)
+	}
+}
+
+// Send implements Sink
+func (w *webhook) Send(ctx context.Context, report *Report) error {
+	summary := Deprecations(report)
+	if summary == nil {
+		return nil
+	}
+
+	var payload interface{} = summary
+	if w.slack {
+		payload = map[string]string{"text": slackText(summary)}
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("gqlusage: could not marshal deprecations: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("gqlusage: could not post deprecations: %w", err)
+	}
+	for key, values := range w.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gqlusage: could not post deprecations: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("gqlusage: could not post deprecations: %s", resp.Status)
+	}
+	return nil
+}
+
+// slackText formats a summary as a Slack message
+func slackText(summary *DeprecationSummary) string {
+	var b strings.Builder
+	b.WriteString("*Deprecated schema elements still requested* from ")
+	b.WriteString(summary.Start.UTC().Format(time.RFC3339))
+	b.WriteString(" to ")
+	b.WriteString(summary.End.UTC().Format(time.RFC3339))
+	for _, d := range summary.Deprecations {
+		b.WriteString("\n• `")
+		b.WriteString(d.Coordinate)
+		b.WriteString("` (")
+		b.WriteString(d.Reason)
+		b.WriteString("): ")
+		b.WriteString(strconv.FormatInt(d.Count, 10))
+		b.WriteString(" operations, by ")
+		for i, c := range d.Clients {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(c.Client.String())
+			b.WriteString(" (")
+			b.WriteString(strconv.FormatInt(c.Count, 10))
+			b.WriteString(")")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package gqlusage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const deprecatedSchema = `
+type Query {
+	product(id: ID!, sku: String @deprecated(reason: "use id")): Product
+	legacy: String @deprecated
+}
+
+type Product {
+	name: String
+}
+`
+
+func TestDeprecationWebhook(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	var bodies []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer hook.Close()
+
+	usage := New(Sinks(
+		DeprecationWebhook(hook.URL, WebhookHeader("Authorization", "Bearer token")),
+		DeprecationWebhook(hook.URL, WebhookHeader("Authorization", "Bearer token"), Slack()),
+	), Interval(time.Hour))
+	defer func() {
+		_ = usage.Shutdown(context.Background())
+	}()
+
+	srv := handler.New(testschema.New(deprecatedSchema, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(usage)
+	query := func(client, version, body string) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("apollographql-client-name", client)
+		req.Header.Set("apollographql-client-version", version)
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	query("web", "", `{"query": "{ product(id: 1) { name } }"}`)
+	clock.Advance(time.Minute)
+	require.NoError(t, usage.Flush(context.Background()))
+	assert.Empty(t, bodies, "nothing is posted without deprecations")
+
+	query("web", "", `{"query": "{ legacy }"}`)
+	query("ios", "1.2", `{"query": "{ legacy product(id: 1, sku: \"a\") { name } }"}`)
+	query("ios", "1.2", `{"query": "{ legacy }"}`)
+	clock.Advance(time.Minute)
+	require.NoError(t, usage.Flush(context.Background()))
+
+	require.Len(t, bodies, 2)
+	assert.JSONEq(t, `{
+		"start": "2020-01-01T00:01:00Z",
+		"end": "2020-01-01T00:02:00Z",
+		"deprecations": [
+			{"coordinate": "Query.legacy", "kind": "field", "reason": "No longer supported", "count": 3, "clients": [
+				{"client": {"name": "ios", "version": "1.2"}, "count": 2},
+				{"client": {"name": "web"}, "count": 1}
+			]},
+			{"coordinate": "Query.product(sku:)", "kind": "argument", "reason": "use id", "count": 1, "clients": [
+				{"client": {"name": "ios", "version": "1.2"}, "count": 1}
+			]}
+		]
+	}`, bodies[0])
+	assert.JSONEq(t, `{"text": "*Deprecated schema elements still requested* from 2020-01-01T00:01:00Z to 2020-01-01T00:02:00Z\n`+
+		"• `Query.legacy` (No longer supported): 3 operations, by ios 1.2 (2), web (1)\\n"+
+		"• `Query.product(sku:)` (use id): 1 operations, by ios 1.2 (1)"+`"}`, bodies[1])
+}
+
+func TestDeprecationWebhookError(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer hook.Close()
+
+	report := &Report{Usage: []Usage{{Coordinate: "Query.legacy", Kind: KindField, Deprecation: "gone", Count: 1}}}
+	err := DeprecationWebhook(hook.URL).Send(context.Background(), report)
+	assert.EqualError(t, err, "gqlusage: could not post deprecations: 403 Forbidden")
+
+	failing := errors.New("failing")
+	err = Sinks(SinkFunc(func(context.Context, *Report) error { return failing }), DeprecationWebhook(hook.URL)).Send(context.Background(), report)
+	assert.Equal(t, failing, err, "the first error is returned")
+}
@@ -0,0 +1,137 @@
+package gqlusage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+type (
+	// ObjectStore stores objects, e.g. in a bucket of S3 (see S3Store) or Google Cloud Storage (see GCSStore)
+	ObjectStore interface {
+		Put(ctx context.Context, key string, body []byte, contentType string) error
+	}
+
+	// Encoder writes a report to an object, e.g. as newline-delimited JSON (see NDJSON)
+	Encoder func(w io.Writer, report *Report) error
+
+	// ObjectOptions configure the ObjectSink
+	ObjectOptions struct {
+		// Prefix of the object keys, e.g. "graphql/usage/"
+		Prefix string
+		// Host tells apart the objects of several servers. The default is the OS hostname.
+		Host string
+		// Encoder of the reports. The default is NDJSON.
+		Encoder Encoder
+		// Extension and ContentType of the objects written by the Encoder. The defaults are "ndjson" and
+		// "application/x-ndjson".
+		Extension   string
+		ContentType string
+		// Gzip compresses objects, adding ".gz" to their key
+		Gzip bool
+	}
+
+	objectSink struct {
+		store ObjectStore
+		opts  ObjectOptions
+	}
+
+	// Row is the usage of a schema coordinate by a client, as written by NDJSON. Rows are flat, for data warehouses.
+	Row struct {
+		Start         time.Time `json:"start"`
+		End           time.Time `json:"end"`
+		Coordinate    string    `json:"coordinate"`
+		Kind          string    `json:"kind"`
+		Deprecation   string    `json:"deprecation,omitempty"`
+		ClientName    string    `json:"client_name,omitempty"`
+		ClientVersion string    `json:"client_version,omitempty"`
+		Count         int64     `json:"count"`
+	}
+)
+
+// ObjectSink writes each report to an object of the store, partitioned by date, so that data platforms ingest usage
+// from a bucket:
+//
+//	gqlusage.New(gqlusage.ObjectSink(gqlusage.NewS3Store("usage", "eu-west-1", creds, gqlusage.S3Options{}),
+//		gqlusage.ObjectOptions{Prefix: "graphql/", Gzip: true}))
+//
+// Objects are keyed by the date and time of the start of the report, and the host, e.g.
+// "graphql/dt=2020-01-31/usage-20200131T154500Z-host.ndjson.gz". Reports are written as NDJSON by default: other
+// formats, e.g. Parquet, may be written by an Encoder.
+func ObjectSink(store ObjectStore, opts ObjectOptions) Sink {
+	if opts.Host == "" {
+		opts.Host, _ = os.Hostname()
+	}
+	if opts.Encoder == nil {
+		opts.Encoder = NDJSON
+		opts.Extension = "ndjson"
+		opts.ContentType = "application/x-ndjson"
+	}
+	return &objectSink{store: store, opts: opts}
+}
+
+// NDJSON encodes the usage of a report as newline-delimited JSON Rows
+func NDJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	for _, u := range report.Usage {
+		row := Row{
+			Start:         report.Start,
+			End:           report.End,
+			Coordinate:    u.Coordinate,
+			Kind:          u.Kind,
+			Deprecation:   u.Deprecation,
+			ClientName:    u.Client.Name,
+			ClientVersion: u.Client.Version,
+			Count:         u.Count,
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Key yields the key of the object of a report
+func (o ObjectOptions) Key(report *Report) string {
+	start := report.Start.UTC()
+	key := o.Prefix + "dt=" + start.Format("2006-01-02") + "/usage-" + start.Format("20060102T150405Z")
+	if o.Host != "" {
+		key += "-" + o.Host
+	}
+	if o.Extension != "" {
+		key += "." + o.Extension
+	}
+	if o.Gzip {
+		key += ".gz"
+	}
+	return key
+}
+
+func (s *objectSink) Send(ctx context.Context, report *Report) error {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if s.opts.Gzip {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	if err := s.opts.Encoder(w, report); err != nil {
+		return fmt.Errorf("gqlusage: could not encode report: %w", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("gqlusage: could not compress report: %w", err)
+		}
+	}
+
+	key := s.opts.Key(report)
+	if err := s.store.Put(ctx, key, buf.Bytes(), s.opts.ContentType); err != nil {
+		return fmt.Errorf("gqlusage: could not store %s: %w", key, err)
+	}
+	return nil
+}
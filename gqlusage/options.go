@@ -0,0 +1,56 @@
+package gqlusage
+
+import (
+	"log"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// Option for the usage collector
+type Option func(*config)
+
+type config struct {
+	interval     time.Duration
+	maxEntries   int
+	errorHandler func(error)
+	now          func() time.Time
+}
+
+func defaultConfig() config {
+	return config{
+		interval:   time.Minute,
+		maxEntries: 10000,
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+		now: func() time.Time {
+			return graphql.Now()
+		},
+	}
+}
+
+// Interval between two reports. The default is 1m.
+func Interval(interval time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+	}
+}
+
+// MaxEntries bounds the memory used by aggregation: this is the maximum number of distinct (schema coordinate,
+// client) pairs kept between two reports. The default is 10000.
+//
+// When the bound is reached, a report is sent early and new entries are dropped until it is sent.
+func MaxEntries(n int) Option {
+	return func(c *config) {
+		c.maxEntries = n
+	}
+}
+
+// ErrorHandler is called with errors occurring while sending reports in the background.
+// By default, errors are logged.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
@@ -0,0 +1,164 @@
+package gqlusage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// S3Store is an ObjectStore writing to an S3 bucket, or to an S3 compatible service (e.g. MinIO, or Google Cloud
+	// Storage with HMAC keys). Requests are signed with AWS Signature Version 4.
+	S3Store struct {
+		bucket string
+		region string
+		creds  S3Credentials
+		opts   S3Options
+	}
+
+	// S3Credentials authenticate requests to S3
+	S3Credentials struct {
+		AccessKeyID     string
+		SecretAccessKey string
+		// SessionToken of temporary credentials, if any
+		SessionToken string
+	}
+
+	// S3Options configure the S3Store
+	S3Options struct {
+		// Endpoint of an S3 compatible service, e.g. "https://storage.googleapis.com". Buckets are addressed by path
+		// on custom endpoints. The default is the regional AWS endpoint, with virtual-hosted buckets.
+		Endpoint string
+		// Client sending the requests. The default has a 30s timeout.
+		Client *http.Client
+	}
+)
+
+var _ ObjectStore = &S3Store{}
+
+// NewS3Store writing to a bucket of a region, e.g. "eu-west-1"
+func NewS3Store(bucket, region string, creds S3Credentials, opts S3Options) *S3Store {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3Store{bucket: bucket, region: region, creds: creds, opts: opts}
+}
+
+// Put an object in the bucket
+func (s *S3Store) Put(ctx context.Context, key string, body []byte, contentType string) error {
+	url := "https://" + s.bucket + ".s3." + s.region + ".amazonaws.com/" + uriEncode(key, false)
+	if s.opts.Endpoint != "" {
+		url = strings.TrimSuffix(s.opts.Endpoint, "/") + "/" + s.bucket + "/" + uriEncode(key, false)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	signV4(req, body, s.creds, s.region, "s3", time.Now())
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("s3: %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// signV4 signs a request with AWS Signature Version 4, including the host and all the headers of the request
+func signV4(req *http.Request, body []byte, creds S3Credentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + "\n")
+	canonical.WriteString(uriEncode(req.URL.Path, false) + "\n")
+	canonical.WriteString(canonicalQuery(req) + "\n")
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonical.WriteString("\n" + signedHeaders + "\n" + payloadHash)
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hash := sha256.Sum256([]byte(canonical.String()))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery yields the query string of a request, sorted and encoded as AWS expects
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode escapes all the characters but the unreserved ones, and slashes unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package gqlusage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+type (
+	// Sink receives usage reports, e.g. to store them or forward them to an analytics backend
+	Sink interface {
+		Send(ctx context.Context, report *Report) error
+	}
+
+	// SinkFunc is a function used as a Sink
+	SinkFunc func(ctx context.Context, report *Report) error
+
+	multiSink []Sink
+
+	writerSink struct {
+		mu  sync.Mutex
+		enc *json.Encoder
+	}
+)
+
+// Send implements Sink
+func (f SinkFunc) Send(ctx context.Context, report *Report) error {
+	return f(ctx, report)
+}
+
+// WriterSink writes reports as JSON lines, e.g. to a rotatefile.Writer
+func WriterSink(w io.Writer) Sink {
+	return &writerSink{enc: json.NewEncoder(w)}
+}
+
+func (s *writerSink) Send(_ context.Context, report *Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(report)
+}
+
+// Sinks sends reports to all the sinks, e.g. to store usage and notify about deprecations. It returns the first error.
+func Sinks(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Send(ctx context.Context, report *Report) error {
+	var first error
+	for _, sink := range m {
+		if err := sink.Send(ctx, report); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,272 @@
+// Package gqlusage aggregates which types, fields and arguments of a schema are requested, and by which clients, to
+// decide safely when a field may be deprecated or removed:
+//
+//	usage := gqlusage.New(gqlusage.WriterSink(w))
+//	srv.Use(usage)
+//	defer usage.Shutdown(ctx)
+//
+// Usage is counted from the documents of operations, by schema coordinate ("Query", "Query.product",
+// "Query.product(id:)") and client: the count of a coordinate is the number of operations requesting it. Clients are
+// identified by their clientinfo.App: the apollographql-client-name and apollographql-client-version headers by default.
+//
+// Counts are kept in memory, within MaxEntries, and shipped on an interval to a pluggable Sink. The usage of
+// deprecated fields and arguments is flagged with the reason of their deprecation, and DeprecationWebhook notifies
+// their owners of the clients still requesting them. ObjectSink exports reports to S3 or Google Cloud Storage, for
+// data platforms. Coverage accumulates usage to report the fields never requested, to drive schema pruning.
+package gqlusage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const (
+	extensionName = "SchemaUsage"
+
+	// the number of operations whose coordinates are cached is bounded independently of the aggregated counts
+	maxCachedOperations = 1000
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Collector{}
+
+type (
+	// Collector is a gqlgen extension aggregating the usage of the schema, and shipping it to a Sink
+	Collector struct {
+		config
+		sink Sink
+
+		mu          sync.Mutex
+		counts      map[key]int64
+		operations  int64
+		dropped     int64
+		start       time.Time
+		coordinates map[string][]coordinate
+
+		flush    chan struct{}
+		done     chan struct{}
+		stopped  chan struct{}
+		stopOnce sync.Once
+	}
+
+	key struct {
+		coordinate
+		client Client
+	}
+
+	// Client requesting the schema
+	Client struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version,omitempty"`
+	}
+
+	// Report of the usage of the schema between Start and End
+	Report struct {
+		Start time.Time `json:"start"`
+		End   time.Time `json:"end"`
+		// Operations is the number of operations of the period
+		Operations int64 `json:"operations"`
+		// Dropped is the number of coordinates left out of the report, once MaxEntries was reached
+		Dropped int64   `json:"dropped,omitempty"`
+		Usage   []Usage `json:"usage"`
+	}
+
+	// Usage of a schema coordinate by a client: Count is the number of operations requesting it. Deprecated fields
+	// and arguments have the reason of their deprecation.
+	Usage struct {
+		Coordinate  string `json:"coordinate"`
+		Kind        string `json:"kind"`
+		Deprecation string `json:"deprecation,omitempty"`
+		Client      Client `json:"client"`
+		Count       int64  `json:"count"`
+	}
+)
+
+// String yields the name and version of the client, or "unknown"
+func (c Client) String() string {
+	switch {
+	case c.Name == "":
+		return "unknown"
+	case c.Version == "":
+		return c.Name
+	default:
+		return c.Name + " " + c.Version
+	}
+}
+
+// New usage collector, shipping reports to the sink.
+//
+// The collector ships reports in the background until Shutdown is called.
+func New(sink Sink, opts ...Option) *Collector {
+	c := &Collector{
+		config:      defaultConfig(),
+		sink:        sink,
+		counts:      map[key]int64{},
+		coordinates: map[string][]coordinate{},
+		flush:       make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	for _, apply := range opts {
+		apply(&c.config)
+	}
+	c.start = c.now()
+
+	go c.run()
+	return c
+}
+
+// ExtensionName yields the extension name: "SchemaUsage"
+func (*Collector) ExtensionName() string {
+	return extensionName
+}
+
+// Validate the extension. This is a noop
+func (*Collector) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation counts the coordinates requested by valid operations
+func (c *Collector) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+	if oc != nil && oc.Operation != nil {
+		c.record(c.operationCoordinates(oc), Client(clientinfo.AppOf(ctx, oc.Headers)))
+	}
+	return next(ctx)
+}
+
+// Flush sends the usage aggregated so far
+func (c *Collector) Flush(ctx context.Context) error {
+	report := c.swap()
+	if report == nil {
+		return nil
+	}
+	return c.sink.Send(ctx, report)
+}
+
+// Shutdown stops the background reporting, then sends the remaining usage
+func (c *Collector) Shutdown(ctx context.Context) error {
+	c.stopOnce.Do(func() { close(c.done) })
+	select {
+	case <-c.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return c.Flush(ctx)
+}
+
+func (c *Collector) run() {
+	defer close(c.stopped)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		case <-c.flush:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), c.interval)
+		if err := c.Flush(ctx); err != nil {
+			c.errorHandler(err)
+		}
+		cancel()
+	}
+}
+
+// operationCoordinates yields the coordinates of an operation, cached by fingerprint
+func (c *Collector) operationCoordinates(oc *graphql.OperationContext) []coordinate {
+	hash := fingerprint.Of(oc).Hash
+	c.mu.Lock()
+	coords, ok := c.coordinates[hash]
+	c.mu.Unlock()
+	if ok {
+		return coords
+	}
+
+	coords = coordinates(oc.Operation)
+
+	c.mu.Lock()
+	if len(c.coordinates) >= maxCachedOperations {
+		c.coordinates = map[string][]coordinate{}
+	}
+	c.coordinates[hash] = coords
+	c.mu.Unlock()
+	return coords
+}
+
+func (c *Collector) record(coords []coordinate, client Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.operations++
+	for _, coord := range coords {
+		k := key{coordinate: coord, client: client}
+		if _, ok := c.counts[k]; !ok && len(c.counts) >= c.maxEntries {
+			// memory bound reached: drop this coordinate and ship what we have early
+			c.dropped++
+			select {
+			case c.flush <- struct{}{}:
+			default:
+			}
+			continue
+		}
+		c.counts[k]++
+	}
+}
+
+// swap the aggregated counts for empty ones, and build a report from them
+func (c *Collector) swap() *Report {
+	end := c.now()
+	c.mu.Lock()
+	counts, operations, dropped, start := c.counts, c.operations, c.dropped, c.start
+	c.counts = map[key]int64{}
+	c.operations = 0
+	c.dropped = 0
+	c.start = end
+	c.mu.Unlock()
+
+	if operations == 0 {
+		return nil
+	}
+
+	report := &Report{
+		Start:      start,
+		End:        end,
+		Operations: operations,
+		Dropped:    dropped,
+		Usage:      make([]Usage, 0, len(counts)),
+	}
+	for k, count := range counts {
+		report.Usage = append(report.Usage, Usage{
+			Coordinate:  k.name,
+			Kind:        k.kind,
+			Deprecation: k.deprecation,
+			Client:      k.client,
+			Count:       count,
+		})
+	}
+	sort.Slice(report.Usage, func(i, j int) bool {
+		a, b := report.Usage[i], report.Usage[j]
+		if a.Coordinate != b.Coordinate {
+			return a.Coordinate < b.Coordinate
+		}
+		if a.Client.Name != b.Client.Name {
+			return a.Client.Name < b.Client.Name
+		}
+		return a.Client.Version < b.Client.Version
+	})
+	return report
+}
@@ -0,0 +1,112 @@
+package gqlusage
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+const schema = `
+type Query {
+	product(id: ID!, locale: String): Product
+	todo: String
+}
+
+type Product {
+	id: ID!
+	name: String
+}
+`
+
+func TestCollector(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	var reports []*Report
+	usage := New(SinkFunc(func(ctx context.Context, report *Report) error {
+		reports = append(reports, report)
+		return nil
+	}), Interval(time.Hour))
+	defer func() {
+		_ = usage.Shutdown(context.Background())
+	}()
+
+	srv := handler.New(testschema.New(schema, nil))
+	srv.AddTransport(transport.POST{})
+	srv.Use(usage)
+
+	query := func(client, body string) {
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("apollographql-client-name", client)
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	query("web", `{"query": "{ product(id: 1) { ...f name } __typename } fragment f on Product { id name }"}`)
+	query("web", `{"query": "{ product(id: 2) { name } }"}`)
+	query("ios", `{"query": "{ todo }"}`)
+	query("ios", `{"query": "{ unknown }"}`)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, usage.Flush(context.Background()))
+	require.Len(t, reports, 1)
+	web, ios := Client{Name: "web"}, Client{Name: "ios"}
+	assert.Equal(t, &Report{
+		Start:      time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC),
+		Operations: 3,
+		Usage: []Usage{
+			{Coordinate: "ID", Kind: KindType, Client: web, Count: 1},
+			{Coordinate: "Product", Kind: KindType, Client: web, Count: 2},
+			{Coordinate: "Product.id", Kind: KindField, Client: web, Count: 1},
+			{Coordinate: "Product.name", Kind: KindField, Client: web, Count: 2},
+			{Coordinate: "Query", Kind: KindType, Client: ios, Count: 1},
+			{Coordinate: "Query", Kind: KindType, Client: web, Count: 2},
+			{Coordinate: "Query.product", Kind: KindField, Client: web, Count: 2},
+			{Coordinate: "Query.product(id:)", Kind: KindArgument, Client: web, Count: 2},
+			{Coordinate: "Query.todo", Kind: KindField, Client: ios, Count: 1},
+			{Coordinate: "String", Kind: KindType, Client: ios, Count: 1},
+			{Coordinate: "String", Kind: KindType, Client: web, Count: 2},
+		},
+	}, reports[0])
+
+	require.NoError(t, usage.Flush(context.Background()))
+	assert.Len(t, reports, 1, "empty reports are not sent")
+}
+
+func TestMaxEntries(t *testing.T) {
+	usage := New(SinkFunc(func(context.Context, *Report) error { return nil }), Interval(time.Hour), MaxEntries(2))
+	defer func() {
+		_ = usage.Shutdown(context.Background())
+	}()
+
+	usage.record([]coordinate{{name: "Query", kind: KindType}, {name: "Query.todo", kind: KindField}}, Client{})
+	usage.record([]coordinate{{name: "Query", kind: KindType}, {name: "String", kind: KindType}}, Client{})
+
+	report := usage.swap()
+	assert.Equal(t, int64(1), report.Dropped)
+	assert.Len(t, report.Usage, 2)
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriterSink(&buf).Send(context.Background(), &Report{
+		Start:      time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC),
+		Operations: 1,
+		Usage:      []Usage{{Coordinate: "Query.todo", Kind: KindField, Client: Client{Name: "web"}, Count: 1}},
+	}))
+	assert.Equal(t, `{"start":"2020-01-01T00:00:00Z","end":"2020-01-01T00:01:00Z","operations":1,"usage":[{"coordinate":"Query.todo","kind":"field","client":{"name":"web"},"count":1}]}`+"\n", buf.String())
+}
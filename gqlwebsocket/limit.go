@@ -0,0 +1,176 @@
+// Package gqlwebsocket instruments and protects the websocket transport of gqlgen.
+package gqlwebsocket
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const limiterName = "SubscriptionLimit"
+
+// CloseReasonTooManySubscriptions is the message of the GQL_CONNECTION_ERROR terminating violators
+const CloseReasonTooManySubscriptions = "too many subscriptions"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = &Limiter{}
+
+type (
+	// Limiter is a gqlgen extension limiting the number of concurrent subscriptions per websocket connection,
+	// and per authenticated user. Connections exceeding a limit are terminated with a GQL_CONNECTION_ERROR.
+	//
+	// The limiter tracks connections with the init and close callbacks of the websocket transport:
+	//
+	//	limiter := gqlwebsocket.NewLimiter(gqlwebsocket.MaxPerConnection(10), gqlwebsocket.MaxPerUser(50))
+	//	srv.AddTransport(transport.Websocket{
+	//		InitFunc:  limiter.InitFunc(authenticate),
+	//		CloseFunc: limiter.CloseFunc(nil),
+	//	})
+	//	srv.Use(limiter)
+	Limiter struct {
+		limitConfig
+
+		mu          sync.Mutex
+		users       map[string]int
+		connections int64
+		active      int64
+	}
+
+	connection struct {
+		user   string
+		active int32
+		cancel context.CancelFunc
+		closed int32
+	}
+
+	connectionKey struct{}
+)
+
+// NewLimiter of subscriptions
+func NewLimiter(opts ...LimitOption) *Limiter {
+	l := &Limiter{
+		limitConfig: defaultLimitConfig(),
+		users:       make(map[string]int),
+	}
+	for _, apply := range opts {
+		apply(&l.limitConfig)
+	}
+	return l
+}
+
+// ExtensionName yields the extension name: "SubscriptionLimit"
+func (l *Limiter) ExtensionName() string {
+	return limiterName
+}
+
+// Validate the extension. This is a noop
+func (l *Limiter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InitFunc tracks connections, after initializing them with next (which may be nil)
+func (l *Limiter) InitFunc(next transport.WebsocketInitFunc) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, error) {
+		if next != nil {
+			var err error
+			if ctx, err = next(ctx, payload); err != nil {
+				return ctx, err
+			}
+		}
+
+		ctx = transport.AppendCloseReason(ctx, CloseReasonTooManySubscriptions)
+		ctx, cancel := context.WithCancel(ctx)
+		conn := &connection{user: l.user(ctx), cancel: cancel}
+		stats.Record(ctx, ActiveConnections.M(atomic.AddInt64(&l.connections, 1)))
+		return context.WithValue(ctx, connectionKey{}, conn), nil
+	}
+}
+
+// CloseFunc stops tracking connections, before calling next (which may be nil).
+//
+// The websocket transport may close a connection several times: next is called once per connection.
+func (l *Limiter) CloseFunc(next transport.WebsocketCloseFunc) transport.WebsocketCloseFunc {
+	return func(ctx context.Context, closeCode int) {
+		if conn, ok := ctx.Value(connectionKey{}).(*connection); ok {
+			if !atomic.CompareAndSwapInt32(&conn.closed, 0, 1) {
+				return
+			}
+			stats.Record(ctx, ActiveConnections.M(atomic.AddInt64(&l.connections, -1)))
+		}
+		if next != nil {
+			next(ctx, closeCode)
+		}
+	}
+}
+
+// InterceptOperation counts the subscriptions of connections and users, and terminates connections exceeding a limit
+func (l *Limiter) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	rc := graphql.GetOperationContext(ctx)
+	conn, ok := ctx.Value(connectionKey{}).(*connection)
+	if !ok || rc.Operation == nil || rc.Operation.Operation != ast.Subscription {
+		return next(ctx)
+	}
+
+	if reason := l.acquire(conn); reason != "" {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(TagLimit, reason)}, Terminations.M(1))
+		conn.cancel()
+		return graphql.OneShot(graphql.ErrorResponse(ctx, "%s", CloseReasonTooManySubscriptions))
+	}
+
+	go func() {
+		// the context of subscriptions is cancelled when they complete
+		<-ctx.Done()
+		l.release(conn)
+	}()
+	return next(ctx)
+}
+
+// acquire a subscription, or yields the exceeded limit: "connection" or "user"
+func (l *Limiter) acquire(conn *connection) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxPerConnection > 0 && int(atomic.LoadInt32(&conn.active)) >= l.maxPerConnection {
+		return "connection"
+	}
+	if l.maxPerUser > 0 && conn.user != "" && l.users[conn.user] >= l.maxPerUser {
+		return "user"
+	}
+
+	active := atomic.AddInt32(&conn.active, 1)
+	if conn.user != "" {
+		l.users[conn.user]++
+	}
+	ctx := context.Background()
+	stats.Record(ctx, ActiveSubscriptions.M(atomic.AddInt64(&l.active, 1)))
+	stats.Record(ctx, SubscriptionsPerConnection.M(int64(active)))
+	return ""
+}
+
+func (l *Limiter) release(conn *connection) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	atomic.AddInt32(&conn.active, -1)
+	if conn.user != "" {
+		if l.users[conn.user]--; l.users[conn.user] <= 0 {
+			delete(l.users, conn.user)
+		}
+	}
+	stats.Record(context.Background(), ActiveSubscriptions.M(atomic.AddInt64(&l.active, -1)))
+}
+
+// Subscriptions is the number of active subscriptions of a user
+func (l *Limiter) Subscriptions(user string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.users[user]
+}
@@ -0,0 +1,101 @@
+package gqlwebsocket
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats/view"
+)
+
+// subscriptionSchema has subscriptions yielding a number of ticks, then running until they are cancelled
+func subscriptionSchema(ticks int) graphql.ExecutableSchema {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query { hello: String }
+		type Subscription { ticks: Int }
+	`})
+	return &graphql.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema { return schema },
+		ComplexityFunc: func(string, string, int, map[string]interface{}) (int, bool) {
+			return 0, false
+		},
+		ExecFunc: func(ctx context.Context) graphql.ResponseHandler {
+			tick := 0
+			return func(ctx context.Context) *graphql.Response {
+				if tick < ticks {
+					tick++
+					return &graphql.Response{Data: []byte(`{"ticks":` + strconv.Itoa(tick) + `}`)}
+				}
+				<-ctx.Done()
+				return nil
+			}
+		},
+	}
+}
+
+type wsMessage struct {
+	ID      string                 `json:"id,omitempty"`
+	Type    string                 `json:"type"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+func TestLimiter(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	limiter := NewLimiter(MaxPerConnection(1))
+	closed := make(chan struct{})
+	srv := handler.New(subscriptionSchema(0))
+	srv.AddTransport(transport.Websocket{
+		InitFunc: limiter.InitFunc(nil),
+		CloseFunc: limiter.CloseFunc(func(context.Context, int) {
+			close(closed)
+		}),
+	})
+	srv.Use(limiter)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial(strings.Replace(ts.URL, "http", "ws", 1), nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.NoError(t, c.WriteJSON(wsMessage{Type: "connection_init"}))
+	var msg wsMessage
+	require.NoError(t, c.ReadJSON(&msg))
+	require.Equal(t, "connection_ack", msg.Type)
+
+	for _, id := range []string{"1", "2"} {
+		require.NoError(t, c.WriteJSON(wsMessage{ID: id, Type: "start", Payload: map[string]interface{}{
+			"query": "subscription { ticks }",
+		}}))
+	}
+
+	for msg.Type != "connection_error" {
+		msg = wsMessage{}
+		require.NoError(t, c.ReadJSON(&msg))
+	}
+	assert.Equal(t, "too many subscriptions", msg.Payload["message"])
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("connection not closed")
+	}
+
+	rows, err := view.RetrieveData(TerminationCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "connection", rows[0].Tags[0].Value)
+}
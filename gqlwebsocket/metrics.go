@@ -0,0 +1,216 @@
+package gqlwebsocket
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// RegisterViews registers the opencensus views of the websocket transport.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(WebsocketViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of the websocket transport
+func UnregisterViews() {
+	view.Unregister(WebsocketViews...)
+}
+
+var (
+	// WebsocketViews contains all opencensus stats views declared for the websocket transport
+	WebsocketViews = []*view.View{
+		ActiveConnectionsView,
+		ActiveSubscriptionsView,
+		SubscriptionsPerConnectionView,
+		TerminationCountView,
+		OpenConnectionsView,
+		ConnectionDurationView,
+		MessagesPerConnectionView,
+		InitFailureCountView,
+		SubscriptionDurationView,
+		EventsPerSubscriptionView,
+		SubscriptionTerminationCountView,
+	}
+
+	// measurements
+
+	// ActiveConnections tracks the number of open websocket connections
+	ActiveConnections = stats.Int64(
+		"gql/websocket/active_connections",
+		"Number of open websocket connections",
+		stats.UnitDimensionless)
+
+	// ActiveSubscriptions tracks the number of active subscriptions
+	ActiveSubscriptions = stats.Int64(
+		"gql/websocket/active_subscriptions",
+		"Number of active GraphQL subscriptions",
+		stats.UnitDimensionless)
+
+	// SubscriptionsPerConnection tracks the number of active subscriptions of a connection, when a subscription starts
+	SubscriptionsPerConnection = stats.Int64(
+		"gql/websocket/subscriptions_per_connection",
+		"Number of active GraphQL subscriptions per websocket connection",
+		stats.UnitDimensionless)
+
+	// Terminations tracks a count of connections terminated for exceeding a subscription limit
+	Terminations = stats.Int64(
+		"gql/websocket/termination_count",
+		"Number of websocket connections terminated for exceeding a subscription limit",
+		stats.UnitDimensionless)
+
+	// OpenConnections tracks the number of open websocket connections of a client, with an instrumented Transport
+	OpenConnections = stats.Int64(
+		"gql/websocket/open_connections",
+		"Number of open websocket connections, by client",
+		stats.UnitDimensionless)
+
+	// ConnectionDuration tracks the duration of websocket connections, from their initialization to their closing
+	ConnectionDuration = stats.Float64(
+		"gql/websocket/connection_duration",
+		"Duration of websocket connections",
+		stats.UnitMilliseconds)
+
+	// MessagesPerConnection tracks the number of messages sent on a websocket connection, when it closes
+	MessagesPerConnection = stats.Int64(
+		"gql/websocket/messages_per_connection",
+		"Number of messages sent per websocket connection",
+		stats.UnitDimensionless)
+
+	// InitFailures tracks a count of websocket connections failing to initialize, e.g. on authentication errors
+	InitFailures = stats.Int64(
+		"gql/websocket/init_failure_count",
+		"Number of websocket connections failing to initialize",
+		stats.UnitDimensionless)
+
+	// SubscriptionDuration tracks the lifetime of subscriptions
+	SubscriptionDuration = stats.Float64(
+		"gql/websocket/subscription_duration",
+		"Lifetime of GraphQL subscriptions",
+		stats.UnitMilliseconds)
+
+	// EventsPerSubscription tracks the number of events delivered by a subscription, when it completes
+	EventsPerSubscription = stats.Int64(
+		"gql/websocket/events_per_subscription",
+		"Number of events delivered per GraphQL subscription",
+		stats.UnitDimensionless)
+
+	// SubscriptionTerminations tracks a count of subscriptions ending, with a Tracer
+	SubscriptionTerminations = stats.Int64(
+		"gql/websocket/subscription_termination_count",
+		"Number of GraphQL subscriptions ending",
+		stats.UnitDimensionless)
+
+	// views
+
+	// ActiveConnectionsView reports the number of open websocket connections
+	ActiveConnectionsView = &view.View{
+		Name:        "gql/websocket/active_connections",
+		Description: "Number of open websocket connections",
+		Measure:     ActiveConnections,
+		Aggregation: view.LastValue(),
+	}
+
+	// ActiveSubscriptionsView reports the number of active subscriptions
+	ActiveSubscriptionsView = &view.View{
+		Name:        "gql/websocket/active_subscriptions",
+		Description: "Number of active GraphQL subscriptions",
+		Measure:     ActiveSubscriptions,
+		Aggregation: view.LastValue(),
+	}
+
+	// SubscriptionsPerConnectionView reports a distribution of the number of subscriptions per connection
+	SubscriptionsPerConnectionView = &view.View{
+		Name:        "gql/websocket/subscriptions_per_connection",
+		Description: "Distribution of the number of active GraphQL subscriptions per websocket connection",
+		Measure:     SubscriptionsPerConnection,
+		Aggregation: view.Distribution(1, 2, 3, 5, 10, 20, 50, 100),
+	}
+
+	// TerminationCountView reports a count of connections terminated for exceeding a subscription limit, by limit
+	TerminationCountView = &view.View{
+		Name:        "gql/websocket/termination_count",
+		Description: "Count of websocket connections terminated for exceeding a subscription limit, by limit",
+		Measure:     Terminations,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagLimit},
+	}
+
+	// OpenConnectionsView reports the number of open websocket connections, by client name
+	OpenConnectionsView = &view.View{
+		Name:        "gql/websocket/open_connections",
+		Description: "Number of open websocket connections, by client",
+		Measure:     OpenConnections,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TagClientName},
+	}
+
+	// ConnectionDurationView reports a distribution of the duration of websocket connections, by client name
+	ConnectionDurationView = &view.View{
+		Name:        "gql/websocket/connection_duration",
+		Description: "Distribution of the duration of websocket connections, by client",
+		Measure:     ConnectionDuration,
+		Aggregation: view.Distribution(1000, 10000, 60000, 300000, 900000, 3600000, 14400000, 86400000),
+		TagKeys:     []tag.Key{TagClientName},
+	}
+
+	// MessagesPerConnectionView reports a distribution of the number of messages sent per connection, by client name
+	MessagesPerConnectionView = &view.View{
+		Name:        "gql/websocket/messages_per_connection",
+		Description: "Distribution of the number of messages sent per websocket connection, by client",
+		Measure:     MessagesPerConnection,
+		Aggregation: view.Distribution(1, 10, 100, 1000, 10000, 100000),
+		TagKeys:     []tag.Key{TagClientName},
+	}
+
+	// InitFailureCountView reports a count of websocket connections failing to initialize, by client name
+	InitFailureCountView = &view.View{
+		Name:        "gql/websocket/init_failure_count",
+		Description: "Count of websocket connections failing to initialize, by client",
+		Measure:     InitFailures,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagClientName},
+	}
+
+	// SubscriptionDurationView reports a distribution of the lifetime of subscriptions, by subscription field
+	SubscriptionDurationView = &view.View{
+		Name:        "gql/websocket/subscription_duration",
+		Description: "Distribution of the lifetime of GraphQL subscriptions, by subscription field",
+		Measure:     SubscriptionDuration,
+		Aggregation: view.Distribution(1000, 10000, 60000, 300000, 900000, 3600000, 14400000, 86400000),
+		TagKeys:     []tag.Key{TagSubscription},
+	}
+
+	// EventsPerSubscriptionView reports a distribution of the number of events delivered per subscription,
+	// by subscription field
+	EventsPerSubscriptionView = &view.View{
+		Name:        "gql/websocket/events_per_subscription",
+		Description: "Distribution of the number of events delivered per GraphQL subscription, by subscription field",
+		Measure:     EventsPerSubscription,
+		Aggregation: view.Distribution(1, 10, 100, 1000, 10000, 100000),
+		TagKeys:     []tag.Key{TagSubscription},
+	}
+
+	// SubscriptionTerminationCountView reports a count of subscriptions ending, by reason
+	SubscriptionTerminationCountView = &view.View{
+		Name:        "gql/websocket/subscription_termination_count",
+		Description: "Count of GraphQL subscriptions ending, by reason",
+		Measure:     SubscriptionTerminations,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagReason},
+	}
+
+	// TagReason is the reason why a subscription ends: "complete", "error", "client_complete", "connection_close",
+	// "connection_drop" or "keepalive_timeout"
+	TagReason = tag.MustNewKey("gql.websocket.reason")
+
+	// TagSubscription is the root field of a subscription
+	TagSubscription = tag.MustNewKey("gql.websocket.subscription")
+
+	// TagClientName is the client name of a connection, from a header of the upgrade request, or "unknown"
+	TagClientName = tag.MustNewKey("gql.websocket.client_name")
+
+	// TagLimit is the limit exceeded by a connection: "connection" or "user"
+	TagLimit = tag.MustNewKey("gql.websocket.limit")
+)
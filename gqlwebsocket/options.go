@@ -0,0 +1,70 @@
+package gqlwebsocket
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen-contrib/gqlauthz"
+)
+
+// LimitOption for the subscription limiter
+type LimitOption func(*limitConfig)
+
+// UserFunc identifies the user of a connection, from the context returned by the init function.
+// It yields an empty string for anonymous users.
+type UserFunc func(context.Context) string
+
+type limitConfig struct {
+	maxPerConnection int
+	maxPerUser       int
+	user             UserFunc
+}
+
+func defaultLimitConfig() limitConfig {
+	return limitConfig{
+		user: func(ctx context.Context) string {
+			p, _ := gqlauthz.FromContext(ctx)
+			return p.ID
+		},
+	}
+}
+
+// MaxPerConnection limits the number of concurrent subscriptions per connection. The default is unlimited.
+func MaxPerConnection(max int) LimitOption {
+	return func(c *limitConfig) {
+		c.maxPerConnection = max
+	}
+}
+
+// MaxPerUser limits the number of concurrent subscriptions per authenticated user, across connections.
+// The default is unlimited.
+func MaxPerUser(max int) LimitOption {
+	return func(c *limitConfig) {
+		c.maxPerUser = max
+	}
+}
+
+// IdentifyUser identifies users with a custom function.
+// The default is the ID of the principal stored by the init function with gqlauthz.WithPrincipal.
+func IdentifyUser(user UserFunc) LimitOption {
+	return func(c *limitConfig) {
+		c.user = user
+	}
+}
+
+// TraceOption for the websocket tracer
+type TraceOption func(*traceConfig)
+
+type traceConfig struct {
+	skipMessages bool
+}
+
+func defaultTraceConfig() traceConfig {
+	return traceConfig{}
+}
+
+// SkipMessages disables the spans of messages, for chatty subscriptions
+func SkipMessages() TraceOption {
+	return func(c *traceConfig) {
+		c.skipMessages = true
+	}
+}
@@ -0,0 +1,77 @@
+package gqlwebsocket
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+const subscriptionMetricsName = "SubscriptionMetrics"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = SubscriptionMetrics{}
+
+// SubscriptionMetrics is a gqlgen extension recording the lifetime of subscriptions, and the number of events
+// they deliver, by subscription field. It tells long-lived idle subscriptions from chatty ones.
+type SubscriptionMetrics struct{}
+
+// NewSubscriptionMetrics records metrics of subscriptions
+func NewSubscriptionMetrics() SubscriptionMetrics {
+	return SubscriptionMetrics{}
+}
+
+// ExtensionName yields the extension name: "SubscriptionMetrics"
+func (SubscriptionMetrics) ExtensionName() string {
+	return subscriptionMetricsName
+}
+
+// Validate the extension. This is a noop
+func (SubscriptionMetrics) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptOperation counts the events of subscriptions, and records metrics when they complete
+func (SubscriptionMetrics) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	rc := graphql.GetOperationContext(ctx)
+	if rc.Operation == nil || rc.Operation.Operation != ast.Subscription {
+		return next(ctx)
+	}
+
+	var (
+		start  = time.Now()
+		events int64
+	)
+	go func() {
+		// the context of subscriptions is cancelled when they complete
+		<-ctx.Done()
+		_ = stats.RecordWithTags(context.Background(), []tag.Mutator{tag.Upsert(TagSubscription, subscriptionField(rc))},
+			SubscriptionDuration.M(float64(time.Since(start))/float64(time.Millisecond)),
+			EventsPerSubscription.M(atomic.LoadInt64(&events)),
+		)
+	}()
+
+	responses := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := responses(ctx)
+		if resp != nil {
+			atomic.AddInt64(&events, 1)
+		}
+		return resp
+	}
+}
+
+// subscriptionField yields the root field of a subscription
+func subscriptionField(rc *graphql.OperationContext) string {
+	fields := graphql.CollectFields(rc, rc.Operation.SelectionSet, []string{"Subscription"})
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return fields[0].Name
+}
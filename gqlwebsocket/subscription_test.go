@@ -0,0 +1,54 @@
+package gqlwebsocket
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+)
+
+func TestSubscriptionMetrics(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	srv := handler.New(subscriptionSchema(3))
+	srv.AddTransport(transport.Websocket{})
+	srv.Use(NewSubscriptionMetrics())
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial(strings.Replace(ts.URL, "http", "ws", 1), nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.NoError(t, c.WriteJSON(wsMessage{Type: "connection_init"}))
+	require.Equal(t, "connection_ack", readMessage(t, c).Type)
+	require.NoError(t, c.WriteJSON(wsMessage{ID: "1", Type: "start", Payload: map[string]interface{}{
+		"query": "subscription { ticks }",
+	}}))
+	for i := 0; i < 3; i++ {
+		require.Equal(t, "data", readMessage(t, c).Type)
+	}
+	require.NoError(t, c.WriteJSON(wsMessage{ID: "1", Type: "stop"}))
+	require.Equal(t, "complete", readMessage(t, c).Type)
+
+	var rows []*view.Row
+	require.Eventually(t, func() bool {
+		rows, err = view.RetrieveData(EventsPerSubscriptionView.Name)
+		return err == nil && len(rows) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "ticks", rows[0].Tags[0].Value)
+	assert.Equal(t, float64(3), rows[0].Data.(*view.DistributionData).Mean)
+
+	rows, err = view.RetrieveData(SubscriptionDurationView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(1), rows[0].Data.(*view.DistributionData).Count)
+}
@@ -0,0 +1,68 @@
+package gqlwebsocket
+
+import (
+	"errors"
+	"net"
+
+	"github.com/gorilla/websocket"
+	"go.opencensus.io/trace"
+)
+
+// Termination reasons of subscriptions
+const (
+	// ReasonComplete is the reason of subscriptions completed by the server
+	ReasonComplete = "complete"
+
+	// ReasonError is the reason of subscriptions completed by the server with an error
+	ReasonError = "error"
+
+	// ReasonClientComplete is the reason of subscriptions stopped by the client
+	ReasonClientComplete = "client_complete"
+
+	// ReasonConnectionClose is the reason of subscriptions of connections closed normally, by the client or the server
+	ReasonConnectionClose = "connection_close"
+
+	// ReasonConnectionDrop is the reason of subscriptions of connections lost on a read error
+	ReasonConnectionDrop = "connection_drop"
+
+	// ReasonKeepaliveTimeout is the reason of subscriptions of connections closed by the server,
+	// for missing the pings of the graphql-transport-ws protocol
+	ReasonKeepaliveTimeout = "keepalive_timeout"
+)
+
+// connectionReason classifies the read error of a connection
+func connectionReason(err error) string {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		if closeErr.Code == websocket.CloseAbnormalClosure {
+			// connection lost without a close frame
+			return ReasonConnectionDrop
+		}
+		return ReasonConnectionClose
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ReasonKeepaliveTimeout
+		}
+		return ReasonConnectionDrop
+	}
+
+	// including normal closures, reported by the transport as a generic error
+	return ReasonConnectionClose
+}
+
+// terminationStatus is the span status of a termination reason
+func terminationStatus(reason, message string) trace.Status {
+	switch reason {
+	case ReasonError:
+		return trace.Status{Code: trace.StatusCodeUnknown, Message: message}
+	case ReasonConnectionDrop:
+		return trace.Status{Code: trace.StatusCodeUnavailable, Message: reason}
+	case ReasonKeepaliveTimeout:
+		return trace.Status{Code: trace.StatusCodeDeadlineExceeded, Message: reason}
+	default:
+		return trace.Status{Code: trace.StatusCodeOK}
+	}
+}
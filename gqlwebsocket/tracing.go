@@ -0,0 +1,279 @@
+package gqlwebsocket
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+)
+
+const tracerName = "WebsocketTracing"
+
+// closeGrace is the time to wait for the closing of a connection, after the cancellation of its operations
+const closeGrace = 100 * time.Millisecond
+
+// Span names of the websocket lifecycle
+const (
+	// SpanConnection spans websocket connections, from their initialization to their closing
+	SpanConnection = "gql.websocket.connection"
+
+	// SpanConnectionInit spans the initialization of connections, including authentication by the init function
+	SpanConnectionInit = "gql.websocket.connection_init"
+
+	// SpanSubscribe spans operations, from their start to their completion
+	SpanSubscribe = "gql.websocket.subscribe"
+
+	// SpanMessage spans the production of a message of an operation, from the delivery of the previous message
+	SpanMessage = "gql.websocket.message"
+
+	// SpanComplete spans the end of the stream of messages of an operation, from the delivery of the last message
+	SpanComplete = "gql.websocket.complete"
+
+	// SpanClose spans the closing of connections, including the close function
+	SpanClose = "gql.websocket.close"
+)
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+} = &Tracer{}
+
+type (
+	// Tracer is a gqlgen extension tracing the lifecycle of websocket connections with opencensus.
+	//
+	// Operations of a connection are traced as children of its connection span, and messages of an operation
+	// as children of its subscribe span. The tracer tracks connections with the init, error and close callbacks
+	// of the websocket transport:
+	//
+	//	tracer := gqlwebsocket.NewTracer()
+	//	srv.AddTransport(transport.Websocket{
+	//		InitFunc:  tracer.InitFunc(authenticate),
+	//		ErrorFunc: tracer.ErrorFunc(nil),
+	//		CloseFunc: tracer.CloseFunc(nil),
+	//	})
+	//	srv.Use(tracer)
+	//
+	// The reason why operations end is set as the status of their subscribe span, and subscription terminations
+	// are counted by reason, even when spans are not sampled.
+	Tracer struct {
+		traceConfig
+	}
+
+	tracedConnection struct {
+		span       *trace.Span
+		reason     atomic.Value
+		reasonOnce sync.Once
+		done       chan struct{}
+		closed     int32
+	}
+
+	tracedOperation struct {
+		span     *trace.Span
+		messages int64
+		ended    int32
+		err      atomic.Value
+	}
+
+	tracedConnectionKey struct{}
+	tracedOperationKey  struct{}
+)
+
+// NewTracer of websocket connections
+func NewTracer(opts ...TraceOption) *Tracer {
+	tr := &Tracer{traceConfig: defaultTraceConfig()}
+	for _, apply := range opts {
+		apply(&tr.traceConfig)
+	}
+	return tr
+}
+
+// ExtensionName yields the extension name: "WebsocketTracing"
+func (tr *Tracer) ExtensionName() string {
+	return tracerName
+}
+
+// Validate the extension. This is a noop
+func (tr *Tracer) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InitFunc starts the span of connections, and spans their initialization with next (which may be nil)
+func (tr *Tracer) InitFunc(next transport.WebsocketInitFunc) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, error) {
+		ctx, span := trace.StartSpan(ctx, SpanConnection, trace.WithSpanKind(trace.SpanKindServer))
+		for _, attr := range clientinfo.Attributes(ctx) {
+			span.AddAttributes(trace.StringAttribute(attr.Key, attr.Value))
+		}
+
+		initCtx, initSpan := trace.StartSpan(ctx, SpanConnectionInit)
+		if next != nil {
+			var err error
+			if initCtx, err = next(initCtx, payload); err != nil {
+				// the close function is not called with the context of failed connections
+				status := trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()}
+				initSpan.SetStatus(status)
+				initSpan.End()
+				span.SetStatus(status)
+				span.End()
+				return initCtx, err
+			}
+		}
+		initSpan.End()
+
+		ctx = trace.NewContext(initCtx, span)
+		conn := &tracedConnection{span: span, done: make(chan struct{})}
+		return context.WithValue(ctx, tracedConnectionKey{}, conn), nil
+	}
+}
+
+// ErrorFunc records the reason why connections end on read errors, before calling next (which may be nil)
+func (tr *Tracer) ErrorFunc(next transport.WebsocketErrorFunc) transport.WebsocketErrorFunc {
+	return func(ctx context.Context, err error) {
+		if conn, ok := ctx.Value(tracedConnectionKey{}).(*tracedConnection); ok {
+			if wsErr, ok := err.(transport.WebsocketError); ok && wsErr.IsReadError {
+				conn.setReason(connectionReason(wsErr.Err))
+			}
+		}
+		if next != nil {
+			next(ctx, err)
+		}
+	}
+}
+
+// CloseFunc spans the closing of connections with next (which may be nil), then ends the span of connections.
+//
+// The websocket transport may close a connection several times: next is called once per connection.
+func (tr *Tracer) CloseFunc(next transport.WebsocketCloseFunc) transport.WebsocketCloseFunc {
+	return func(ctx context.Context, closeCode int) {
+		conn, ok := ctx.Value(tracedConnectionKey{}).(*tracedConnection)
+		if !ok {
+			if next != nil {
+				next(ctx, closeCode)
+			}
+			return
+		}
+		if !atomic.CompareAndSwapInt32(&conn.closed, 0, 1) {
+			return
+		}
+		conn.setReason(ReasonConnectionClose)
+		close(conn.done)
+
+		ctx, span := trace.StartSpan(ctx, SpanClose)
+		span.AddAttributes(trace.Int64Attribute("gql.websocket.close_code", int64(closeCode)))
+		if next != nil {
+			next(ctx, closeCode)
+		}
+		span.End()
+
+		conn.span.AddAttributes(trace.Int64Attribute("gql.websocket.close_code", int64(closeCode)))
+		conn.span.End()
+	}
+}
+
+// InterceptOperation spans operations of websocket connections, until they complete
+func (tr *Tracer) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	conn, ok := ctx.Value(tracedConnectionKey{}).(*tracedConnection)
+	if !ok {
+		return next(ctx)
+	}
+
+	rc := graphql.GetOperationContext(ctx)
+	ctx, span := trace.StartSpan(ctx, SpanSubscribe)
+	span.AddAttributes(trace.StringAttribute("gql.operation.name", fingerprint.OperationName(rc)))
+	op := &tracedOperation{span: span}
+
+	go func() {
+		// the context of operations is cancelled when they complete
+		<-ctx.Done()
+		reason, message := op.termination(conn)
+		if rc.Operation != nil && rc.Operation.Operation == ast.Subscription {
+			_ = stats.RecordWithTags(context.Background(), []tag.Mutator{tag.Upsert(TagReason, reason)},
+				SubscriptionTerminations.M(1))
+		}
+		span.AddAttributes(
+			trace.Int64Attribute("gql.websocket.messages", atomic.LoadInt64(&op.messages)),
+			trace.StringAttribute("gql.websocket.termination", reason),
+		)
+		span.SetStatus(terminationStatus(reason, message))
+		span.End()
+	}()
+
+	responses := next(context.WithValue(ctx, tracedOperationKey{}, op))
+	return func(ctx context.Context) *graphql.Response {
+		resp := responses(ctx)
+		switch {
+		case resp == nil && ctx.Err() == nil:
+			atomic.StoreInt32(&op.ended, 1)
+		case resp != nil && len(resp.Errors) > 0:
+			op.err.Store(resp.Errors.Error())
+		}
+		return resp
+	}
+}
+
+// InterceptResponse spans messages of operations of websocket connections
+func (tr *Tracer) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	op, ok := ctx.Value(tracedOperationKey{}).(*tracedOperation)
+	if !ok || tr.skipMessages {
+		return next(ctx)
+	}
+
+	ctx, span := trace.StartSpan(ctx, SpanMessage)
+	defer span.End()
+
+	resp := next(ctx)
+	if resp == nil {
+		span.SetName(SpanComplete)
+		return nil
+	}
+
+	seq := atomic.AddInt64(&op.messages, 1)
+	span.AddAttributes(trace.Int64Attribute("gql.websocket.message.seq", seq))
+	span.AddMessageSendEvent(seq, int64(len(resp.Data)), int64(len(resp.Data)))
+	if errs := resp.Errors; len(errs) > 0 {
+		span.SetStatus(trace.Status{
+			Code:    trace.StatusCodeUnknown,
+			Message: errs.Error(),
+		})
+	}
+	return resp
+}
+
+// termination yields the reason why an operation ended, and the message of errors
+func (op *tracedOperation) termination(conn *tracedConnection) (string, string) {
+	if atomic.LoadInt32(&op.ended) == 1 {
+		// the stream ended on the server
+		if message, ok := op.err.Load().(string); ok {
+			return ReasonError, message
+		}
+		return ReasonComplete, ""
+	}
+
+	// operations are cancelled when stopped by the client, or right before their connection closes
+	select {
+	case <-conn.done:
+	case <-time.After(closeGrace):
+	}
+	if reason, ok := conn.reason.Load().(string); ok {
+		return reason, ""
+	}
+	return ReasonClientComplete, ""
+}
+
+// setReason records the first reason why a connection ends
+func (c *tracedConnection) setReason(reason string) {
+	c.reasonOnce.Do(func() {
+		c.reason.Store(reason)
+	})
+}
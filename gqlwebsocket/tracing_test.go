@@ -0,0 +1,217 @@
+package gqlwebsocket
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+func (r *spanRecorder) byName() map[string][]*trace.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make(map[string][]*trace.SpanData)
+	for _, s := range r.spans {
+		spans[s.Name] = append(spans[s.Name], s)
+	}
+	return spans
+}
+
+// readMessage reads the next message, skipping keepalives
+func readMessage(t *testing.T, c *websocket.Conn) wsMessage {
+	for {
+		var msg wsMessage
+		require.NoError(t, c.ReadJSON(&msg))
+		if msg.Type != "ka" {
+			return msg
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(1e-4)})
+
+	tracer := NewTracer()
+	closed := make(chan struct{})
+	srv := handler.New(subscriptionSchema(2))
+	srv.AddTransport(transport.Websocket{
+		InitFunc: tracer.InitFunc(nil),
+		CloseFunc: tracer.CloseFunc(func(context.Context, int) {
+			close(closed)
+		}),
+	})
+	srv.Use(tracer)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial(strings.Replace(ts.URL, "http", "ws", 1), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c.WriteJSON(wsMessage{Type: "connection_init"}))
+	require.Equal(t, "connection_ack", readMessage(t, c).Type)
+
+	require.NoError(t, c.WriteJSON(wsMessage{ID: "1", Type: "start", Payload: map[string]interface{}{
+		"query": "subscription ticker { ticks }",
+	}}))
+	for i := 0; i < 2; i++ {
+		require.Equal(t, "data", readMessage(t, c).Type)
+	}
+	require.NoError(t, c.WriteJSON(wsMessage{ID: "1", Type: "stop"}))
+	require.Equal(t, "complete", readMessage(t, c).Type)
+	require.NoError(t, c.Close())
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("connection not closed")
+	}
+
+	var spans map[string][]*trace.SpanData
+	require.Eventually(t, func() bool {
+		spans = recorder.byName()
+		return len(spans[SpanConnection]) == 1 && len(spans[SpanSubscribe]) == 1 && len(spans[SpanComplete]) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	conn := spans[SpanConnection][0]
+	sub := spans[SpanSubscribe][0]
+	assert.Equal(t, conn.SpanID, spans[SpanConnectionInit][0].ParentSpanID)
+	assert.Equal(t, conn.SpanID, spans[SpanClose][0].ParentSpanID)
+	assert.Equal(t, conn.SpanID, sub.ParentSpanID)
+	assert.Equal(t, "ticker", sub.Attributes["gql.operation.name"])
+	assert.Equal(t, int64(2), sub.Attributes["gql.websocket.messages"])
+	require.Len(t, spans[SpanMessage], 2)
+	for _, s := range append(spans[SpanMessage], spans[SpanComplete]...) {
+		assert.Equal(t, sub.SpanID, s.ParentSpanID)
+	}
+}
+
+// finiteSchema has subscriptions named "done" completing after a tick, "failing" completing with an error,
+// and other subscriptions running until they are cancelled
+func finiteSchema() graphql.ExecutableSchema {
+	schema := subscriptionSchema(0)
+	return &graphql.ExecutableSchemaMock{
+		SchemaFunc:     schema.Schema,
+		ComplexityFunc: schema.Complexity,
+		ExecFunc: func(ctx context.Context) graphql.ResponseHandler {
+			var responses []*graphql.Response
+			switch graphql.GetOperationContext(ctx).Operation.Name {
+			case "done":
+				responses = append(responses, &graphql.Response{Data: []byte(`{"ticks":1}`)})
+			case "failing":
+				responses = append(responses, &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("boom")}})
+			default:
+				return schema.Exec(ctx)
+			}
+			return func(ctx context.Context) *graphql.Response {
+				if len(responses) == 0 {
+					return nil
+				}
+				resp := responses[0]
+				responses = responses[1:]
+				return resp
+			}
+		},
+	}
+}
+
+func TestTerminations(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(1e-4)})
+
+	tracer := NewTracer(SkipMessages())
+	srv := handler.New(finiteSchema())
+	srv.AddTransport(transport.Websocket{
+		InitFunc:  tracer.InitFunc(nil),
+		ErrorFunc: tracer.ErrorFunc(nil),
+		CloseFunc: tracer.CloseFunc(nil),
+	})
+	srv.Use(tracer)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c, _, err := websocket.DefaultDialer.Dial(strings.Replace(ts.URL, "http", "ws", 1), nil)
+	require.NoError(t, err)
+	require.NoError(t, c.WriteJSON(wsMessage{Type: "connection_init"}))
+	require.Equal(t, "connection_ack", readMessage(t, c).Type)
+
+	start := func(id, name string) {
+		require.NoError(t, c.WriteJSON(wsMessage{ID: id, Type: "start", Payload: map[string]interface{}{
+			"query": "subscription " + name + " { ticks }",
+		}}))
+	}
+
+	start("1", "done")
+	require.Equal(t, "data", readMessage(t, c).Type)
+	require.Equal(t, "complete", readMessage(t, c).Type)
+
+	start("2", "failing")
+	require.Equal(t, "data", readMessage(t, c).Type)
+	require.Equal(t, "complete", readMessage(t, c).Type)
+
+	start("3", "stopped")
+	require.NoError(t, c.WriteJSON(wsMessage{ID: "3", Type: "stop"}))
+	require.Equal(t, "complete", readMessage(t, c).Type)
+
+	var spans map[string][]*trace.SpanData
+	subscribed := func(n int) func() bool {
+		return func() bool {
+			spans = recorder.byName()
+			return len(spans[SpanSubscribe]) == n
+		}
+	}
+	require.Eventually(t, subscribed(3), time.Second, 10*time.Millisecond)
+
+	start("4", "dropped")
+	require.NoError(t, c.UnderlyingConn().Close())
+	require.Eventually(t, subscribed(4), time.Second, 10*time.Millisecond)
+
+	terminations := map[string]interface{}{}
+	for _, s := range spans[SpanSubscribe] {
+		terminations[s.Attributes["gql.operation.name"].(string)] = s.Attributes["gql.websocket.termination"]
+		if s.Attributes["gql.operation.name"] == "failing" {
+			assert.Equal(t, trace.Status{Code: trace.StatusCodeUnknown, Message: "input: boom\n"}, s.Status)
+		}
+	}
+	assert.Equal(t, map[string]interface{}{
+		"done":    ReasonComplete,
+		"failing": ReasonError,
+		"stopped": ReasonClientComplete,
+		"dropped": ReasonConnectionDrop,
+	}, terminations)
+
+	rows, err := view.RetrieveData(SubscriptionTerminationCountView.Name)
+	require.NoError(t, err)
+	assert.Len(t, rows, 4)
+}
@@ -0,0 +1,144 @@
+package gqlwebsocket
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/clientinfo"
+)
+
+var _ graphql.Transport = &Transport{}
+
+type (
+	// Transport instruments the websocket transport of gqlgen with opencensus metrics: open connections,
+	// connection durations, messages sent per connection and connection init failures, by client name.
+	//
+	//	srv.AddTransport(gqlwebsocket.Instrument(transport.Websocket{
+	//		InitFunc: authenticate,
+	//	}))
+	Transport struct {
+		ws transport.Websocket
+
+		mu   sync.Mutex
+		open map[string]int64
+	}
+
+	// meteredConnection tracks a connection, from its initialization to its closing
+	meteredConnection struct {
+		client   string
+		start    time.Time
+		messages int64
+		closed   int32
+	}
+
+	// meteredExecutor counts the messages sent on a connection
+	meteredExecutor struct {
+		graphql.GraphExecutor
+		conn *meteredConnection
+	}
+)
+
+// Instrument a websocket transport with metrics
+func Instrument(ws transport.Websocket) *Transport {
+	return &Transport{
+		ws:   ws,
+		open: make(map[string]int64),
+	}
+}
+
+// Supports implements graphql.Transport
+func (t *Transport) Supports(r *http.Request) bool {
+	return t.ws.Supports(r)
+}
+
+// Do implements graphql.Transport
+func (t *Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	client := clientinfo.AppOf(r.Context(), r.Header).Name
+	if client == "" {
+		client = "unknown"
+	}
+	conn := &meteredConnection{client: client}
+
+	ws := t.ws
+	ws.InitFunc = t.initFunc(conn, t.ws.InitFunc)
+	ws.CloseFunc = t.closeFunc(conn, t.ws.CloseFunc)
+	ws.Do(w, r, meteredExecutor{GraphExecutor: exec, conn: conn})
+}
+
+func (t *Transport) initFunc(conn *meteredConnection, next transport.WebsocketInitFunc) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, error) {
+		if next != nil {
+			var err error
+			if ctx, err = next(ctx, payload); err != nil {
+				_ = stats.RecordWithTags(ctx, conn.tags(), InitFailures.M(1))
+				return ctx, err
+			}
+		}
+
+		conn.start = time.Now()
+		_ = stats.RecordWithTags(ctx, conn.tags(), OpenConnections.M(t.add(conn.client, 1)))
+		return ctx, nil
+	}
+}
+
+// closeFunc records the metrics of initialized connections, then calls next (which may be nil) once
+func (t *Transport) closeFunc(conn *meteredConnection, next transport.WebsocketCloseFunc) transport.WebsocketCloseFunc {
+	return func(ctx context.Context, closeCode int) {
+		if !atomic.CompareAndSwapInt32(&conn.closed, 0, 1) {
+			return
+		}
+
+		if !conn.start.IsZero() {
+			_ = stats.RecordWithTags(ctx, conn.tags(),
+				OpenConnections.M(t.add(conn.client, -1)),
+				ConnectionDuration.M(float64(time.Since(conn.start))/float64(time.Millisecond)),
+				MessagesPerConnection.M(atomic.LoadInt64(&conn.messages)),
+			)
+		}
+		if next != nil {
+			next(ctx, closeCode)
+		}
+	}
+}
+
+// add to the number of open connections of a client
+func (t *Transport) add(client string, n int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.open[client] += n
+	open := t.open[client]
+	if open <= 0 {
+		delete(t.open, client)
+	}
+	return open
+}
+
+func (c *meteredConnection) tags() []tag.Mutator {
+	return []tag.Mutator{tag.Upsert(TagClientName, c.client)}
+}
+
+func (e meteredExecutor) DispatchOperation(ctx context.Context, rc *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	responses, ctx := e.GraphExecutor.DispatchOperation(ctx, rc)
+	return func(ctx context.Context) *graphql.Response {
+		resp := responses(ctx)
+		if resp != nil {
+			atomic.AddInt64(&e.conn.messages, 1)
+		}
+		return resp
+	}, ctx
+}
+
+func (e meteredExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	atomic.AddInt64(&e.conn.messages, 1)
+	return e.GraphExecutor.DispatchError(ctx, list)
+}
@@ -0,0 +1,93 @@
+package gqlwebsocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+)
+
+func TestTransport(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	closed := make(chan struct{}, 1)
+	srv := handler.New(subscriptionSchema(2))
+	srv.AddTransport(Instrument(transport.Websocket{
+		InitFunc: func(ctx context.Context, payload transport.InitPayload) (context.Context, error) {
+			if payload.Authorization() != "secret" {
+				return ctx, errors.New("unauthorized")
+			}
+			return ctx, nil
+		},
+		CloseFunc: func(context.Context, int) {
+			closed <- struct{}{}
+		},
+	}))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	dial := func(init wsMessage) *websocket.Conn {
+		c, _, err := websocket.DefaultDialer.Dial(strings.Replace(ts.URL, "http", "ws", 1), http.Header{
+			"Apollographql-Client-Name": []string{"ios"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, c.WriteJSON(init))
+		return c
+	}
+
+	waitClose := func() {
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("connection not closed")
+		}
+	}
+
+	c := dial(wsMessage{Type: "connection_init"})
+	assert.Equal(t, "connection_error", readMessage(t, c).Type)
+	_ = c.Close()
+	waitClose()
+
+	c = dial(wsMessage{Type: "connection_init", Payload: map[string]interface{}{"Authorization": "secret"}})
+	require.Equal(t, "connection_ack", readMessage(t, c).Type)
+	require.NoError(t, c.WriteJSON(wsMessage{ID: "1", Type: "start", Payload: map[string]interface{}{
+		"query": "subscription { ticks }",
+	}}))
+	for i := 0; i < 2; i++ {
+		require.Equal(t, "data", readMessage(t, c).Type)
+	}
+
+	rows, err := view.RetrieveData(OpenConnectionsView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "ios", rows[0].Tags[0].Value)
+	assert.Equal(t, float64(1), rows[0].Data.(*view.LastValueData).Value)
+
+	require.NoError(t, c.Close())
+	waitClose()
+
+	rows, err = view.RetrieveData(MessagesPerConnectionView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, float64(2), rows[0].Data.(*view.DistributionData).Mean)
+
+	rows, err = view.RetrieveData(InitFailureCountView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(1), rows[0].Data.(*view.CountData).Value)
+
+	rows, err = view.RetrieveData(OpenConnectionsView.Name)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), rows[0].Data.(*view.LastValueData).Value)
+}
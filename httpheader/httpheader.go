@@ -0,0 +1,79 @@
+// Package httpheader lets gqlgen extensions set HTTP response headers.
+//
+// Extensions only see the request context, not the http.ResponseWriter. Middleware exposes the response
+// headers in the request context, so that extensions may set headers before the response is written:
+//
+//	http.Handle("/query", httpheader.Middleware(srv))
+//
+// Headers set after the response is written (e.g. on subscriptions and incremental transports) are ignored.
+package httpheader
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type contextKey struct{}
+
+type holder struct {
+	mu     sync.Mutex
+	header http.Header
+}
+
+// Middleware exposes the response headers to extensions
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := &holder{header: w.Header()}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, h)))
+	})
+}
+
+// Installed tells if the Middleware is installed for the current request
+func Installed(ctx context.Context) bool {
+	_, ok := ctx.Value(contextKey{}).(*holder)
+	return ok
+}
+
+// Set a response header. It returns false when the Middleware is not installed.
+func Set(ctx context.Context, key, value string) bool {
+	return with(ctx, func(header http.Header) {
+		header.Set(key, value)
+	})
+}
+
+// Add a value to a response header. It returns false when the Middleware is not installed.
+func Add(ctx context.Context, key, value string) bool {
+	return with(ctx, func(header http.Header) {
+		header.Add(key, value)
+	})
+}
+
+// SetDefault sets a response header, unless it is already set. It returns false when the Middleware is not installed.
+func SetDefault(ctx context.Context, key, value string) bool {
+	return with(ctx, func(header http.Header) {
+		if header.Get(key) == "" {
+			header.Set(key, value)
+		}
+	})
+}
+
+// Get a response header
+func Get(ctx context.Context, key string) string {
+	var value string
+	with(ctx, func(header http.Header) {
+		value = header.Get(key)
+	})
+	return value
+}
+
+func with(ctx context.Context, fn func(http.Header)) bool {
+	h, ok := ctx.Value(contextKey{}).(*holder)
+	if !ok {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fn(h.header)
+	return true
+}
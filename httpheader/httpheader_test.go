@@ -0,0 +1,32 @@
+package httpheader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	assert.False(t, Set(context.Background(), "X-Test", "a"))
+	assert.False(t, Installed(context.Background()))
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		assert.True(t, Installed(ctx))
+		assert.True(t, Set(ctx, "X-Test", "a"))
+		assert.True(t, Add(ctx, "X-Test", "b"))
+		assert.True(t, SetDefault(ctx, "X-Test", "c"))
+		assert.True(t, SetDefault(ctx, "X-Other", "d"))
+		assert.Equal(t, "a", Get(ctx, "X-Test"))
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"a", "b"}, w.Header()["X-Test"])
+	assert.Equal(t, "d", w.Header().Get("X-Other"))
+}
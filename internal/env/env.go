@@ -0,0 +1,91 @@
+// Package env reads the configuration of extensions from environment variables, e.g. GQL_HOST=checkout.
+package env
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Reader reads typed environment variables. Unset and empty variables are skipped, and the first invalid value is
+// reported by Err.
+type Reader struct {
+	lookup func(string) (string, bool)
+	err    error
+}
+
+// New reader of the environment of the process
+func New() *Reader {
+	return &Reader{lookup: os.LookupEnv}
+}
+
+// NewWithLookup reads variables with a lookup function, e.g. a map in tests
+func NewWithLookup(lookup func(string) (string, bool)) *Reader {
+	return &Reader{lookup: lookup}
+}
+
+// String reads a string variable
+func (r *Reader) String(name string, apply func(string)) {
+	if value, ok := r.value(name); ok {
+		apply(value)
+	}
+}
+
+// Bool reads a boolean variable, e.g. "true", "false", "1" or "0"
+func (r *Reader) Bool(name string, apply func(bool)) {
+	value, ok := r.value(name)
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		r.fail(fmt.Errorf("%s: invalid boolean %q", name, value))
+		return
+	}
+	apply(b)
+}
+
+// Fraction reads a number between 0 and 1, e.g. a sampling rate
+func (r *Reader) Fraction(name string, apply func(float64)) {
+	value, ok := r.value(name)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 || f > 1 {
+		r.fail(fmt.Errorf("%s: invalid fraction %q, expecting a number between 0 and 1", name, value))
+		return
+	}
+	apply(f)
+}
+
+// Duration reads a duration variable, e.g. "5s"
+func (r *Reader) Duration(name string, apply func(time.Duration)) {
+	value, ok := r.value(name)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		r.fail(fmt.Errorf("%s: invalid duration %q, expecting e.g. 5s", name, value))
+		return
+	}
+	apply(d)
+}
+
+// Err yields the first invalid value
+func (r *Reader) Err() error {
+	return r.err
+}
+
+func (r *Reader) value(name string) (string, bool) {
+	value, ok := r.lookup(name)
+	return value, ok && value != ""
+}
+
+func (r *Reader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
@@ -0,0 +1,57 @@
+package env
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestReader(t *testing.T) {
+	r := NewWithLookup(lookup(map[string]string{
+		"HOST":     "checkout",
+		"FIELDS":   "true",
+		"SAMPLE":   "0.1",
+		"INTERVAL": "5s",
+		"EMPTY":    "",
+	}))
+
+	var (
+		host     string
+		fields   bool
+		sample   float64
+		interval time.Duration
+		empty    = "unchanged"
+	)
+	r.String("HOST", func(v string) { host = v })
+	r.Bool("FIELDS", func(v bool) { fields = v })
+	r.Fraction("SAMPLE", func(v float64) { sample = v })
+	r.Duration("INTERVAL", func(v time.Duration) { interval = v })
+	r.String("EMPTY", func(v string) { empty = v })
+	r.String("UNSET", func(string) { t.Fatal("unset variables are skipped") })
+
+	assert.NoError(t, r.Err())
+	assert.Equal(t, "checkout", host)
+	assert.True(t, fields)
+	assert.Equal(t, 0.1, sample)
+	assert.Equal(t, 5*time.Second, interval)
+	assert.Equal(t, "unchanged", empty)
+}
+
+func TestReaderErrors(t *testing.T) {
+	r := NewWithLookup(lookup(map[string]string{"FIELDS": "yes", "SAMPLE": "2"}))
+	r.Bool("FIELDS", func(bool) { t.Fatal("invalid values are skipped") })
+	r.Fraction("SAMPLE", func(float64) { t.Fatal("invalid values are skipped") })
+	assert.EqualError(t, r.Err(), `FIELDS: invalid boolean "yes"`)
+
+	r = NewWithLookup(lookup(map[string]string{"SAMPLE": "2"}))
+	r.Fraction("SAMPLE", func(float64) {})
+	assert.EqualError(t, r.Err(), `SAMPLE: invalid fraction "2", expecting a number between 0 and 1`)
+}
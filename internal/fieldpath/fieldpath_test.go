@@ -0,0 +1,63 @@
+package fieldpath
+
+import (
+	"context"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// fields yields the field contexts of "todos[i].text", for n todos
+func fields(n int) []*graphql.FieldContext {
+	todos := &graphql.FieldContext{Field: graphql.CollectedField{Field: &ast.Field{Name: "todos", Alias: "list"}}}
+	texts := make([]*graphql.FieldContext, 0, n)
+	for i := 0; i < n; i++ {
+		index := i
+		item := &graphql.FieldContext{Parent: todos, Index: &index}
+		texts = append(texts, &graphql.FieldContext{
+			Parent: item,
+			Field:  graphql.CollectedField{Field: &ast.Field{Name: "text", Alias: "text"}},
+		})
+	}
+	return texts
+}
+
+func TestString(t *testing.T) {
+	texts := fields(2)
+	for _, ctx := range []context.Context{context.Background(), WithCache(context.Background())} {
+		assert.Equal(t, "list", String(ctx, texts[0].Parent.Parent))
+		assert.Equal(t, "list[1]", String(ctx, texts[1].Parent))
+		for _, fc := range texts {
+			assert.Equal(t, fc.Path().String(), String(ctx, fc))
+		}
+	}
+
+	ctx := WithCache(context.Background())
+	assert.Equal(t, ctx, WithCache(ctx))
+}
+
+func BenchmarkString(b *testing.B) {
+	texts := fields(500)
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			for _, fc := range texts {
+				_ = String(ctx, fc)
+			}
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ctx := WithCache(context.Background())
+			for _, fc := range texts {
+				_ = String(ctx, fc)
+			}
+		}
+	})
+}
@@ -0,0 +1,63 @@
+// Package gcpauth yields the OAuth2 access tokens of Google Cloud servers, without the Google Cloud SDK
+package gcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MetadataURL is the token endpoint of the metadata server, for the default service account
+const MetadataURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+type metadataToken struct {
+	client  *http.Client
+	url     string
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// MetadataToken yields the access tokens of the default service account of a Google Cloud server, from the metadata
+// server at url (MetadataURL). Tokens are cached until shortly before they expire.
+func MetadataToken(client *http.Client, url string) func(ctx context.Context) (string, error) {
+	t := &metadataToken{client: client, url: url}
+	return t.get
+}
+
+func (t *metadataToken) get(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token != "" && time.Now().Before(t.expires) {
+		return t.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server: %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	t.token = token.AccessToken
+	// renew a minute early, so that tokens do not expire in flight
+	t.expires = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return t.token, nil
+}
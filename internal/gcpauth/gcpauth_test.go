@@ -0,0 +1,29 @@
+package gcpauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataToken(t *testing.T) {
+	calls := 0
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		_, _ = w.Write([]byte(`{"access_token": "token", "expires_in": 3600}`))
+	}))
+	defer metadata.Close()
+
+	token := MetadataToken(http.DefaultClient, metadata.URL)
+	for i := 0; i < 2; i++ {
+		got, err := token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "token", got)
+	}
+	assert.Equal(t, 1, calls, "tokens are cached")
+}
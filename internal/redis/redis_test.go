@@ -0,0 +1,61 @@
+package redis_test
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/99designs/gqlgen-contrib/internal/redis"
+	"github.com/99designs/gqlgen-contrib/internal/redis/redistest"
+)
+
+func TestClient(t *testing.T) {
+	srv := redistest.NewServer()
+	defer srv.Close()
+
+	c := redis.New(srv.Addr, redis.Options{Password: "secret", DB: 1, PoolSize: 2})
+	ctx := context.Background()
+
+	reply, err := c.Do(ctx, "GET", "k")
+	require.NoError(t, err)
+	assert.Nil(t, reply)
+
+	reply, err = c.Do(ctx, "SET", "k", []byte("v\r\n"), "PX", 1000)
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply)
+
+	reply, err = c.Do(ctx, "GET", "k")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v\r\n"), reply)
+
+	reply, err = c.Do(ctx, "INCRBY", "n", int64(3))
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), reply)
+
+	_, err = c.Do(ctx, "NOPE")
+	assert.IsType(t, redis.Error(""), err)
+
+	// the connection survives error replies
+	_, err = c.Do(ctx, "GET", "k")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Close())
+	_, err = c.Do(ctx, "GET", "k")
+	assert.Equal(t, redis.ErrClosed, err)
+}
+
+func TestReadReply(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("*3\r\n+OK\r\n-ERR wrong type\r\n*1\r\n-ERR nested\r\n:1\r\n"))
+
+	reply, err := redis.ReadReply(r)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"OK", redis.Error("ERR wrong type"), []interface{}{redis.Error("ERR nested")}}, reply)
+
+	reply, err = redis.ReadReply(r)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reply, "the array is read whole")
+}
@@ -0,0 +1,229 @@
+// Package redistest provides an in-memory Redis server for tests, implementing a handful of commands.
+package redistest
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen-contrib/internal/redis"
+)
+
+// Server is an in-memory Redis server supporting PING, AUTH, SELECT, GET, SET (with EX/PX), DEL,
+// INCRBY, PEXPIRE and PTTL.
+//
+// EVAL and EVALSHA are supported for scripts emulated in Go, registered with HandleScript.
+type Server struct {
+	// Addr to connect to
+	Addr string
+
+	ln       net.Listener
+	mu       sync.Mutex
+	values   map[string][]byte
+	expires  map[string]time.Time
+	scripts  map[string]ScriptFunc
+	loaded   map[string]bool
+	commands int
+}
+
+// ScriptFunc emulates a Lua script. Commands are run with call, which yields replies as decoded by the redis client.
+// The result is encoded like the replies of the client: int64, []byte, string, []interface{} or nil.
+type ScriptFunc func(call func(args ...string) (interface{}, error), keys, args []string) interface{}
+
+// NewServer starts a server on a random local port
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		Addr:    ln.Addr().String(),
+		ln:      ln,
+		values:  map[string][]byte{},
+		expires: map[string]time.Time{},
+		scripts: map[string]ScriptFunc{},
+		loaded:  map[string]bool{},
+	}
+	go s.serve()
+	return s
+}
+
+// Close the server
+func (s *Server) Close() {
+	_ = s.ln.Close()
+}
+
+// HandleScript emulates the Lua script src with fn
+func (s *Server) HandleScript(src string, fn ScriptFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[sha(src)] = fn
+}
+
+// Commands is the number of commands received
+func (s *Server) Commands() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.commands
+}
+
+func (s *Server) serve() {
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(c)
+	}
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	for {
+		cmd, err := redis.ReadReply(r)
+		if err != nil {
+			return
+		}
+		args, ok := cmd.([]interface{})
+		if !ok || len(args) == 0 {
+			return
+		}
+		strs := make([]string, len(args))
+		for i, arg := range args {
+			b, _ := arg.([]byte)
+			strs[i] = string(b)
+		}
+		_, _ = w.WriteString(s.exec(strs))
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands++
+	return s.run(args)
+}
+
+func (s *Server) run(args []string) string {
+	key := ""
+	if len(args) > 1 {
+		key = args[1]
+		if exp, ok := s.expires[key]; ok && !time.Now().Before(exp) {
+			delete(s.values, key)
+			delete(s.expires, key)
+		}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING", "AUTH", "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		v, ok := s.values[key]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(v)
+	case "SET":
+		s.values[key] = []byte(args[2])
+		delete(s.expires, key)
+		if len(args) == 5 {
+			n, _ := strconv.Atoi(args[4])
+			unit := time.Millisecond
+			if strings.ToUpper(args[3]) == "EX" {
+				unit = time.Second
+			}
+			s.expires[key] = time.Now().Add(time.Duration(n) * unit)
+		}
+		return "+OK\r\n"
+	case "DEL":
+		_, ok := s.values[key]
+		delete(s.values, key)
+		delete(s.expires, key)
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "INCRBY":
+		n, _ := strconv.ParseInt(string(s.values[key]), 10, 64)
+		by, _ := strconv.ParseInt(args[2], 10, 64)
+		n += by
+		s.values[key] = []byte(strconv.FormatInt(n, 10))
+		return ":" + strconv.FormatInt(n, 10) + "\r\n"
+	case "PEXPIRE":
+		if _, ok := s.values[key]; !ok {
+			return ":0\r\n"
+		}
+		ms, _ := strconv.Atoi(args[2])
+		s.expires[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return ":1\r\n"
+	case "PTTL":
+		if _, ok := s.values[key]; !ok {
+			return ":-2\r\n"
+		}
+		exp, ok := s.expires[key]
+		if !ok {
+			return ":-1\r\n"
+		}
+		return ":" + strconv.FormatInt(int64(time.Until(exp)/time.Millisecond), 10) + "\r\n"
+	case "EVAL", "EVALSHA":
+		id := args[1]
+		if strings.ToUpper(args[0]) == "EVAL" {
+			id = sha(args[1])
+			s.loaded[id] = true
+		}
+		fn, ok := s.scripts[id]
+		if !s.loaded[id] {
+			return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+		}
+		if !ok {
+			return "-ERR script not emulated\r\n"
+		}
+		n, _ := strconv.Atoi(args[2])
+		call := func(args ...string) (interface{}, error) {
+			return redis.ReadReply(bufio.NewReader(strings.NewReader(s.run(args))))
+		}
+		return encode(fn(call, args[3:3+n], args[3+n:]))
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func encode(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return ":" + strconv.FormatInt(v, 10) + "\r\n"
+	case []byte:
+		return bulk(v)
+	case string:
+		return "+" + v + "\r\n"
+	case []interface{}:
+		var b strings.Builder
+		b.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, item := range v {
+			b.WriteString(encode(item))
+		}
+		return b.String()
+	default:
+		return "$-1\r\n"
+	}
+}
+
+func sha(src string) string {
+	sum := sha1.Sum([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+func bulk(v []byte) string {
+	return "$" + strconv.Itoa(len(v)) + "\r\n" + string(v) + "\r\n"
+}
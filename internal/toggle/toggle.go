@@ -0,0 +1,23 @@
+// Package toggle switches extensions on and off at runtime, with a cheap check on the hot path of requests.
+package toggle
+
+import "sync/atomic"
+
+// Toggle is enabled unless it is switched off. The zero value and a nil Toggle are enabled.
+type Toggle struct {
+	disabled int32
+}
+
+// Enabled reports whether the toggle is on
+func (t *Toggle) Enabled() bool {
+	return t == nil || atomic.LoadInt32(&t.disabled) == 0
+}
+
+// Set the toggle on or off
+func (t *Toggle) Set(enabled bool) {
+	var disabled int32
+	if !enabled {
+		disabled = 1
+	}
+	atomic.StoreInt32(&t.disabled, disabled)
+}
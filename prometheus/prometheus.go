@@ -6,6 +6,8 @@ import (
 
 	"github.com/99designs/gqlgen/graphql"
 	prometheusclient "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
 )
 
 const (
@@ -99,17 +101,7 @@ func (Metrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandl
 			exitStatus = exitStatusSuccess
 		}
 
-		opName := ""
-		if opCtx.Operation != nil {
-			opName = opCtx.Operation.Name
-		}
-		if opName == "" && opCtx.Operation != nil {
-			//parent response case
-			opName = string(opCtx.Operation.Operation)
-		}
-		if opName == "" {
-			opName = opCtx.OperationName
-		}
+		opName := fingerprint.OperationName(opCtx)
 
 		timeToHandleRequest.WithLabelValues(exitStatus, opName).
 			Observe(float64(time.Since(start).Nanoseconds() / int64(time.Millisecond)))
@@ -11,8 +11,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/99designs/gqlgen-contrib/internal/graph"
 	"github.com/99designs/gqlgen-contrib/prometheus"
-	"github.com/99designs/gqlgen-contrib/prometheus/internal/graph"
 )
 
 func TestPrometheus_ResolverMiddleware_RequestMiddleware(t *testing.T) {
@@ -0,0 +1,84 @@
+package rotatefile
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Option for a rotated file
+type Option func(*config)
+
+type config struct {
+	maxSize    int64
+	interval   time.Duration
+	compress   bool
+	maxBackups int
+	maxAge     time.Duration
+	mode       os.FileMode
+
+	errorHandler func(error)
+}
+
+func defaultConfig() config {
+	return config{
+		maxSize: 100 << 20,
+		mode:    0644,
+		errorHandler: func(err error) {
+			log.Printf("%v", err)
+		},
+	}
+}
+
+// MaxSize rotates the file before it grows larger than the given size in bytes. The default is 100 MiB.
+//
+// Use zero to disable size-based rotation.
+func MaxSize(bytes int64) Option {
+	return func(c *config) {
+		c.maxSize = bytes
+	}
+}
+
+// Interval rotates the file at every multiple of the given interval, e.g. hourly or daily (UTC).
+// Time-based rotation is disabled by default.
+func Interval(interval time.Duration) Option {
+	return func(c *config) {
+		c.interval = interval
+	}
+}
+
+// Compress rotated files with gzip. This is disabled by default.
+func Compress(enabled bool) Option {
+	return func(c *config) {
+		c.compress = enabled
+	}
+}
+
+// MaxBackups is the maximum number of rotated files to retain. By default, all rotated files are kept.
+func MaxBackups(n int) Option {
+	return func(c *config) {
+		c.maxBackups = n
+	}
+}
+
+// MaxAge is the maximum age of rotated files to retain. By default, rotated files never expire.
+func MaxAge(age time.Duration) Option {
+	return func(c *config) {
+		c.maxAge = age
+	}
+}
+
+// Mode sets the permissions of new files. The default is 0644.
+func Mode(mode os.FileMode) Option {
+	return func(c *config) {
+		c.mode = mode
+	}
+}
+
+// ErrorHandler handles the errors of rotations triggered by writes. Writes go on to the current file when its
+// rotation fails. The default logs errors.
+func ErrorHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
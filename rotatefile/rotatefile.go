@@ -0,0 +1,316 @@
+// Package rotatefile provides an io.WriteCloser appending to a file which is rotated by size and/or
+// time, with optional gzip compression of rotated files and a retention policy.
+//
+// It is meant as a sink for the log-producing extensions of this repository (e.g. gqlsyslog.NewWriter),
+// when no external log shipper is available.
+package rotatefile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// currentTime is time.Now, except in tests
+var currentTime = time.Now
+
+var _ io.WriteCloser = &File{}
+
+// File is an io.WriteCloser writing to a rotated file. It is safe for concurrent use.
+type File struct {
+	config
+	filename string
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+
+	millMu sync.Mutex
+	millWG sync.WaitGroup
+}
+
+// New opens (or creates) the file at filename for appending, and rotates it according to the options.
+//
+// Rotated files are renamed in the same directory as name-<timestamp>.ext, e.g. audit-2020-05-12T10-11-12.000.log,
+// with a sequence number when the name is taken, e.g. audit-2020-05-12T10-11-12.000_1.log.
+func New(filename string, opts ...Option) (*File, error) {
+	f := &File{
+		config:   defaultConfig(),
+		filename: filename,
+	}
+	for _, apply := range opts {
+		apply(&f.config)
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements io.Writer. A single write is never split across two files.
+//
+// When a rotation fails, e.g. when the file cannot be renamed, p is still written to the current file and the
+// error goes to the ErrorHandler. The size trigger is then reset, to retry the rotation after another MaxSize bytes.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			f.errorHandler(err)
+			f.size = 0
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate forces the rotation of the current file, e.g. upon receiving SIGHUP
+func (f *File) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close the current file, waiting for any pending compression to complete
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.millWG.Wait()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *File) shouldRotate(n int64) bool {
+	if f.maxSize > 0 && f.size > 0 && f.size+n > f.maxSize {
+		return true
+	}
+	return f.interval > 0 && !currentTime().Before(f.nextRotation)
+}
+
+func (f *File) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.filename), 0755); err != nil {
+		return fmt.Errorf("rotatefile: could not create directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.mode)
+	if err != nil {
+		return fmt.Errorf("rotatefile: could not open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("rotatefile: could not stat file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	if f.interval > 0 {
+		f.nextRotation = currentTime().Truncate(f.interval).Add(f.interval)
+	}
+	return nil
+}
+
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("rotatefile: could not close file: %w", err)
+	}
+	f.file = nil
+
+	now := currentTime()
+	if f.size > 0 {
+		if err := os.Rename(f.filename, f.backupName(now)); err != nil {
+			// keep appending to the current file
+			if oerr := f.open(); oerr != nil {
+				return oerr
+			}
+			return fmt.Errorf("rotatefile: could not rename file: %w", err)
+		}
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.millWG.Add(1)
+	go func() {
+		defer f.millWG.Done()
+		f.mill(now)
+	}()
+	return nil
+}
+
+// backupName yields a name which is not taken by another backup, compressed or not, with a sequence number when
+// files are rotated more than once within a millisecond
+func (f *File) backupName(t time.Time) string {
+	dir, base := filepath.Split(f.filename)
+	ext := filepath.Ext(base)
+	stamp := strings.TrimSuffix(base, ext) + "-" + t.UTC().Format(backupTimeFormat)
+	name := filepath.Join(dir, stamp+ext)
+	for seq := 1; exists(name) || exists(name+compressSuffix); seq++ {
+		name = filepath.Join(dir, stamp+"_"+strconv.Itoa(seq)+ext)
+	}
+	return name
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+type backup struct {
+	path       string
+	timestamp  time.Time
+	seq        int
+	compressed bool
+}
+
+// mill compresses rotated files and enforces the retention policy
+func (f *File) mill(now time.Time) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		return
+	}
+
+	var remaining []backup
+	for i, b := range backups {
+		expired := f.maxAge > 0 && now.Sub(b.timestamp) > f.maxAge
+		tooMany := f.maxBackups > 0 && i >= f.maxBackups
+		if expired || tooMany {
+			_ = os.Remove(b.path)
+			continue
+		}
+		remaining = append(remaining, b)
+	}
+
+	if !f.compress {
+		return
+	}
+	for _, b := range remaining {
+		if !b.compressed {
+			_ = compressFile(b.path)
+		}
+	}
+}
+
+// backups lists rotated files, most recent first
+func (f *File) backups() ([]backup, error) {
+	dir, base := filepath.Split(f.filename)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		name := info.Name()
+		compressed := strings.HasSuffix(name, compressSuffix)
+		stamp := strings.TrimSuffix(name, compressSuffix)
+		if !strings.HasPrefix(stamp, prefix) || !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimPrefix(stamp, prefix), ext)
+		var seq int
+		if i := strings.LastIndexByte(stamp, '_'); i >= 0 {
+			if seq, err = strconv.Atoi(stamp[i+1:]); err != nil {
+				continue
+			}
+			stamp = stamp[:i]
+		}
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), timestamp: t, seq: seq, compressed: compressed})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].timestamp.Equal(backups[j].timestamp) {
+			return backups[i].timestamp.After(backups[j].timestamp)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + compressSuffix)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,172 @@
+package rotatefile
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotatefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = time.Now }()
+
+	f, err := New(filepath.Join(dir, "audit.log"), MaxSize(10), Compress(true), MaxBackups(2))
+	require.NoError(t, err)
+
+	for i, line := range []string{"1234\n", "5678\n", "abcd\n", "efgh\n", "ABCD\n", "EFGH\n", "IJKL\n"} {
+		now = now.Add(time.Duration(i) * time.Second)
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	current, err := ioutil.ReadFile(filepath.Join(dir, "audit.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "IJKL\n", string(current))
+
+	names := listDir(t, dir)
+	assert.Equal(t, []string{
+		"audit-2020-05-12T10-00-10.000.log.gz",
+		"audit-2020-05-12T10-00-21.000.log.gz",
+		"audit.log",
+	}, names)
+
+	gz, err := os.Open(filepath.Join(dir, names[1]))
+	require.NoError(t, err)
+	defer gz.Close()
+	r, err := gzip.NewReader(gz)
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD\nEFGH\n", string(content))
+}
+
+func TestRotateFileInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotatefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 5, 12, 10, 30, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = time.Now }()
+
+	f, err := New(filepath.Join(dir, "slow.log"), MaxSize(0), Interval(time.Hour), MaxAge(30*time.Minute))
+	require.NoError(t, err)
+
+	write := func(line string) {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	write("a\n")
+	write("b\n")
+	now = now.Add(time.Hour)
+	write("c\n")
+	now = now.Add(time.Hour)
+	write("d\n")
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{"slow-2020-05-12T12-30-00.000.log", "slow.log"}, listDir(t, dir))
+}
+
+func TestRotateFileUnique(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotatefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2020, 5, 12, 10, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = time.Now }()
+
+	f, err := New(filepath.Join(dir, "audit.log"), MaxBackups(2))
+	require.NoError(t, err)
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+		require.NoError(t, f.Rotate())
+	}
+	require.NoError(t, f.Close())
+
+	assert.Equal(t, []string{
+		"audit-2020-05-12T10-00-00.000_1.log",
+		"audit-2020-05-12T10-00-00.000_2.log",
+		"audit.log",
+	}, listDir(t, dir), "backups of the same millisecond are kept, the oldest are removed")
+	content, err := ioutil.ReadFile(filepath.Join(dir, "audit-2020-05-12T10-00-00.000_2.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "c\n", string(content))
+}
+
+func TestRotateFileRenameFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotatefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, "logs")
+	f, err := New(filepath.Join(sub, "audit.log"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("a\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(sub))
+	assert.Error(t, f.Rotate(), "the file to rename is gone")
+
+	_, err = f.Write([]byte("b\n"))
+	require.NoError(t, err, "the file is reopened")
+	require.NoError(t, f.Close())
+	content, err := ioutil.ReadFile(filepath.Join(sub, "audit.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "b\n", string(content))
+}
+
+func TestRotateFileSizeRenameFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotatefile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var errs []error
+	sub := filepath.Join(dir, "logs")
+	f, err := New(filepath.Join(sub, "audit.log"), MaxSize(3), ErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("a\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(sub))
+	n, err := f.Write([]byte("b\n"))
+	require.NoError(t, err, "the record is written despite the failed rotation")
+	assert.Equal(t, 2, n)
+	require.Len(t, errs, 1)
+
+	_, err = f.Write([]byte("c"))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1, "the rotation is not retried before another MaxSize bytes")
+
+	require.NoError(t, f.Close())
+	content, err := ioutil.ReadFile(filepath.Join(sub, "audit.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "b\nc", string(content))
+}
+
+func listDir(t testing.TB, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
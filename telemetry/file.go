@@ -0,0 +1,313 @@
+package telemetry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+	"gopkg.in/yaml.v3"
+
+	"github.com/99designs/gqlgen-contrib/gqlarmor"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/rotatefile"
+)
+
+type (
+	// document is the declarative configuration of the telemetry, see LoadConfig
+	document struct {
+		Host            string                  `yaml:"host"`
+		Fingerprint     bool                    `yaml:"fingerprint"`
+		Tracing         tracingDocument         `yaml:"tracing"`
+		Metrics         metricsDocument         `yaml:"metrics"`
+		Log             logDocument             `yaml:"log"`
+		Instrumentation instrumentationDocument `yaml:"instrumentation"`
+		Limits          *limitsDocument         `yaml:"limits"`
+		Redact          []string                `yaml:"redact"`
+	}
+
+	tracingDocument struct {
+		Enabled   bool     `yaml:"enabled"`
+		Sample    *float64 `yaml:"sample"`
+		RawQuery  bool     `yaml:"raw_query"`
+		Variables bool     `yaml:"variables"`
+		Args      bool     `yaml:"args"`
+		Output    string   `yaml:"output"`
+	}
+
+	metricsDocument struct {
+		Enabled       bool              `yaml:"enabled"`
+		Views         []string          `yaml:"views"`
+		ViewNames     map[string]string `yaml:"view_names"`
+		Fields        *bool             `yaml:"fields"`
+		FieldLatency  *bool             `yaml:"field_latency"`
+		LiteFields    bool              `yaml:"lite_fields"`
+		FlushInterval string            `yaml:"flush_interval"`
+	}
+
+	logDocument struct {
+		Output string `yaml:"output"`
+	}
+
+	instrumentationDocument struct {
+		OnlyMethods       *bool    `yaml:"only_methods"`
+		SkipIntrospection bool     `yaml:"skip_introspection"`
+		SchemaHash        bool     `yaml:"schema_hash"`
+		FieldSample       *float64 `yaml:"field_sample"`
+	}
+
+	limitsDocument struct {
+		MaxDepth      *int `yaml:"max_depth"`
+		MaxAliases    *int `yaml:"max_aliases"`
+		MaxDirectives *int `yaml:"max_directives"`
+		MaxBytes      *int `yaml:"max_bytes"`
+		MaxTokens     *int `yaml:"max_tokens"`
+	}
+)
+
+// LoadConfig loads the configuration of the telemetry from a YAML or JSON file, e.g. a standard file shipped to all
+// services:
+//
+//	host: checkout
+//	fingerprint: true     # identify operations by fingerprint in spans, metrics and log lines
+//	tracing:
+//	  enabled: true
+//	  sample: 0.1         # fraction of traced operations
+//	  raw_query: false    # see redact
+//	  variables: true     # see redact
+//	  args: false         # see redact
+//	  output: stdout      # stdout, stderr or a file, to print spans
+//	metrics:
+//	  enabled: true
+//	  views: [gql/server/operation_count, gql/server/latency] # all views by default
+//	  view_names:         # exported names of views, by default name
+//	    gql/server/latency: graphql_operation_latency_ms
+//	  fields: true
+//	  field_latency: false
+//	  lite_fields: true
+//	  flush_interval: 5s
+//	log:
+//	  output: stderr      # stdout, stderr or a file, for canonical log lines
+//	instrumentation:
+//	  only_methods: true
+//	  skip_introspection: true
+//	  schema_hash: true   # tag spans, metrics and log lines with a hash of the schema
+//	  field_sample: 0.5
+//	limits:               # armor limits, with gqlarmor defaults
+//	  max_depth: 10
+//	  max_aliases: 15
+//	  max_directives: 50
+//	  max_bytes: 102400
+//	  max_tokens: 1000
+//	redact: [password, token] # variables, args and query literals redacted from spans
+//
+// Unknown keys and invalid values are rejected, with the line or the key at fault. Files opened for outputs are
+// closed by the Shutdown of the telemetry.
+func LoadConfig(filename string) (Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %v", filename, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig parses the configuration of the telemetry from a YAML or JSON document, see LoadConfig
+func ParseConfig(data []byte) (Config, error) {
+	var doc document
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && err != io.EOF {
+		return Config{}, err
+	}
+	if err := doc.validate(); err != nil {
+		return Config{}, err
+	}
+	return doc.config()
+}
+
+func (d document) validate() error {
+	if err := validateFraction("tracing.sample", d.Tracing.Sample); err != nil {
+		return err
+	}
+	if err := validateFraction("instrumentation.field_sample", d.Instrumentation.FieldSample); err != nil {
+		return err
+	}
+	if d.Metrics.FlushInterval != "" {
+		if interval, err := time.ParseDuration(d.Metrics.FlushInterval); err != nil || interval < 0 {
+			return fmt.Errorf("metrics.flush_interval: invalid duration %q, expecting e.g. 5s", d.Metrics.FlushInterval)
+		}
+	}
+	for _, name := range d.Metrics.Views {
+		if findView(name) == nil {
+			return fmt.Errorf("metrics.views: unknown view %q, expecting one of %s", name, strings.Join(viewNames(), ", "))
+		}
+	}
+	for name, exported := range d.Metrics.ViewNames {
+		if findView(name) == nil {
+			return fmt.Errorf("metrics.view_names: unknown view %q, expecting one of %s", name, strings.Join(viewNames(), ", "))
+		}
+		if exported == "" {
+			return fmt.Errorf("metrics.view_names: empty name for view %q", name)
+		}
+	}
+	if l := d.Limits; l != nil {
+		limits := []struct {
+			key   string
+			value *int
+		}{
+			{"max_depth", l.MaxDepth},
+			{"max_aliases", l.MaxAliases},
+			{"max_directives", l.MaxDirectives},
+			{"max_bytes", l.MaxBytes},
+			{"max_tokens", l.MaxTokens},
+		}
+		for _, limit := range limits {
+			if limit.value != nil && *limit.value < 0 {
+				return fmt.Errorf("limits.%s: %d is negative, expecting 0 to disable the limit", limit.key, *limit.value)
+			}
+		}
+	}
+	return nil
+}
+
+func validateFraction(key string, fraction *float64) error {
+	if fraction != nil && (*fraction < 0 || *fraction > 1) {
+		return fmt.Errorf("%s: %v is not between 0 and 1", key, *fraction)
+	}
+	return nil
+}
+
+func (d document) config() (Config, error) {
+	cfg := Config{
+		Host:        d.Host,
+		Fingerprint: d.Fingerprint,
+		Tracing:     d.Tracing.Enabled,
+		Metrics:     d.Metrics.Enabled,
+	}
+
+	i := d.Instrumentation
+	if i.OnlyMethods != nil {
+		cfg.Common = append(cfg.Common, gqlinstrument.OnlyMethods(*i.OnlyMethods))
+	}
+	if i.SkipIntrospection {
+		cfg.Common = append(cfg.Common, gqlinstrument.SkipIntrospection())
+	}
+	if i.SchemaHash {
+		cfg.Common = append(cfg.Common, gqlinstrument.SchemaHash())
+	}
+	if i.FieldSample != nil {
+		cfg.Common = append(cfg.Common, gqlinstrument.WithSampler(gqlinstrument.ProbabilitySampler(*i.FieldSample)))
+	}
+
+	t := d.Tracing
+	if t.Sample != nil {
+		cfg.TracerOptions = append(cfg.TracerOptions, gqlopencensus.WithSampler(trace.ProbabilitySampler(*t.Sample)))
+	}
+	if t.RawQuery {
+		cfg.TracerOptions = append(cfg.TracerOptions, gqlopencensus.WithOperationAttributes(RedactedQuery(d.Redact...)))
+	}
+	if t.Variables {
+		cfg.TracerOptions = append(cfg.TracerOptions, gqlopencensus.WithOperationAttributes(RedactedVariables(d.Redact...)))
+	}
+	if t.Args {
+		cfg.TracerOptions = append(cfg.TracerOptions, gqlopencensus.WithFieldAttributes(RedactedArgs(d.Redact...)))
+	}
+
+	m := d.Metrics
+	for _, name := range m.Views {
+		cfg.Views = append(cfg.Views, findView(name))
+	}
+	cfg.ViewNames = m.ViewNames
+	if m.Fields != nil {
+		cfg.MetricsOptions = append(cfg.MetricsOptions, metrics.FieldsEnabled(*m.Fields))
+	}
+	if m.FieldLatency != nil {
+		cfg.MetricsOptions = append(cfg.MetricsOptions, metrics.FieldLatencyEnabled(*m.FieldLatency))
+	}
+	if m.LiteFields {
+		cfg.MetricsOptions = append(cfg.MetricsOptions, metrics.LiteFields())
+		if cfg.Views == nil {
+			cfg.Views = metrics.GQLViews
+		}
+		cfg.Views = metrics.LiteViews(cfg.Views)
+	}
+	if m.FlushInterval != "" {
+		interval, _ := time.ParseDuration(m.FlushInterval)
+		cfg.MetricsOptions = append(cfg.MetricsOptions, metrics.FlushInterval(interval))
+	}
+
+	if l := d.Limits; l != nil {
+		limits := gqlarmor.Default()
+		setLimit(&limits.MaxDepth, l.MaxDepth)
+		setLimit(&limits.MaxAliases, l.MaxAliases)
+		setLimit(&limits.MaxDirectives, l.MaxDirectives)
+		setLimit(&limits.MaxBytes, l.MaxBytes)
+		setLimit(&limits.MaxTokens, l.MaxTokens)
+		cfg.Limits = &limits
+	}
+
+	// open outputs last, so that nothing is left open on errors
+	var err error
+	if cfg.Traces, err = cfg.output("tracing.output", t.Output); err != nil {
+		return Config{}, err
+	}
+	if cfg.Log, err = cfg.output("log.output", d.Log.Output); err != nil {
+		for _, c := range cfg.closers {
+			_ = c.Close()
+		}
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func setLimit(limit *int, value *int) {
+	if value != nil {
+		*limit = *value
+	}
+}
+
+// output opens the standard outputs or a rotating file
+func (c *Config) output(key, output string) (io.Writer, error) {
+	switch output {
+	case "":
+		return nil, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	f, err := rotatefile.New(output)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+	c.closers = append(c.closers, f)
+	return f, nil
+}
+
+func findView(name string) *view.View {
+	for _, v := range metrics.GQLViews {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+func viewNames() []string {
+	names := make([]string, 0, len(metrics.GQLViews))
+	for _, v := range metrics.GQLViews {
+		names = append(names, v.Name)
+	}
+	return names
+}
@@ -0,0 +1,168 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`
+host: checkout
+fingerprint: true
+tracing:
+  enabled: true
+  sample: 0.1
+  variables: true
+  output: stdout
+metrics:
+  enabled: true
+  views: [gql/server/operation_count, gql/server/latency]
+  view_names:
+    gql/server/latency: graphql_operation_latency_ms
+  lite_fields: true
+  flush_interval: 5s
+instrumentation:
+  skip_introspection: true
+  schema_hash: true
+limits:
+  max_depth: 10
+redact: [password]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "checkout", cfg.Host)
+	assert.True(t, cfg.Fingerprint)
+	assert.True(t, cfg.Tracing)
+	assert.Len(t, cfg.TracerOptions, 2)
+	assert.Equal(t, os.Stdout, cfg.Traces)
+	assert.True(t, cfg.Metrics)
+	assert.Equal(t, []string{"gql/server/operation_count", "gql/server/latency"},
+		[]string{cfg.Views[0].Name, cfg.Views[1].Name})
+	assert.Equal(t, map[string]string{"gql/server/latency": "graphql_operation_latency_ms"}, cfg.ViewNames)
+	assert.Len(t, cfg.MetricsOptions, 2)
+	assert.Len(t, cfg.Common, 2)
+	require.NotNil(t, cfg.Limits)
+	assert.Equal(t, 10, cfg.Limits.MaxDepth)
+	assert.Equal(t, 15, cfg.Limits.MaxAliases)
+	assert.Nil(t, cfg.Log)
+
+	cfg, err = ParseConfig([]byte(`{"metrics": {"enabled": true, "lite_fields": true}}`))
+	require.NoError(t, err)
+	assert.Equal(t, metrics.LiteViews(metrics.GQLViews), cfg.Views)
+
+	cfg, err = ParseConfig([]byte(`{"host": "checkout", "metrics": {"enabled": true, "fields": false}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "checkout", cfg.Host)
+	assert.False(t, cfg.Tracing)
+	assert.True(t, cfg.Metrics)
+	assert.Nil(t, cfg.Limits)
+
+	cfg, err = ParseConfig(nil)
+	require.NoError(t, err)
+	assert.False(t, cfg.Tracing || cfg.Metrics)
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for doc, expected := range map[string]string{
+		"tracing:\n  sampel: 0.1":              "yaml: unmarshal errors:\n  line 2: field sampel not found in type telemetry.tracingDocument",
+		"tracing:\n  sample: 1.5":              "tracing.sample: 1.5 is not between 0 and 1",
+		"instrumentation:\n  field_sample: -1": "instrumentation.field_sample: -1 is not between 0 and 1",
+		"metrics:\n  flush_interval: often":    `metrics.flush_interval: invalid duration "often", expecting e.g. 5s`,
+		"limits:\n  max_depth: -1":             "limits.max_depth: -1 is negative, expecting 0 to disable the limit",
+	} {
+		_, err := ParseConfig([]byte(doc))
+		assert.EqualError(t, err, expected, doc)
+	}
+
+	_, err := ParseConfig([]byte("log:\n  output: /dev/null/graphql.log"))
+	assert.Contains(t, err.Error(), "log.output: ")
+
+	_, err = ParseConfig([]byte("metrics:\n  views: [gql/server/op_count]"))
+	assert.Contains(t, err.Error(), `metrics.views: unknown view "gql/server/op_count", expecting one of gql/server/operation_count, `)
+	_, err = ParseConfig([]byte("metrics:\n  view_names: {gql/server/op_count: ops}"))
+	assert.Contains(t, err.Error(), `metrics.view_names: unknown view "gql/server/op_count", expecting one of `)
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telemetry")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "telemetry.yaml")
+	logname := filepath.Join(dir, "graphql.log")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("host: checkout\nlog:\n  output: "+logname+"\n"), 0600))
+
+	cfg, err := LoadConfig(filename)
+	require.NoError(t, err)
+	tel, err := New(cfg)
+	require.NoError(t, err)
+
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).Context(context.Background())
+	tel.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response { return &graphql.Response{} })
+	require.NoError(t, tel.Shutdown())
+
+	logged, err := ioutil.ReadFile(logname)
+	require.NoError(t, err)
+	assert.Contains(t, string(logged), "host=checkout operation=todos ")
+
+	_, err = LoadConfig(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(filename, []byte("hots: checkout"), 0600))
+	_, err = LoadConfig(filename)
+	assert.EqualError(t, err, filename+": yaml: unmarshal errors:\n  line 1: field hots not found in type telemetry.document")
+}
+
+func TestRedactedVariables(t *testing.T) {
+	variables := map[string]interface{}{
+		"user":  map[string]interface{}{"name": "joe", "password": "secret"},
+		"users": []interface{}{map[string]interface{}{"password": "secret"}},
+		"token": "abc",
+	}
+	assert.Len(t, RedactedVariables("password")(&graphql.OperationContext{Variables: variables}), 1)
+
+	redacted, err := json.Marshal(redact(variables, map[string]bool{"password": true}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"user": {"name": "joe", "password": "[REDACTED]"},
+		"users": [{"password": "[REDACTED]"}],
+		"token": "abc"
+	}`, string(redacted))
+	assert.Equal(t, "secret", variables["user"].(map[string]interface{})["password"], "variables are left unchanged")
+}
+
+func TestRedactedArgs(t *testing.T) {
+	args := map[string]interface{}{
+		"input": map[string]interface{}{"name": "joe", "password": "secret"},
+	}
+	assert.Equal(t, []trace.Attribute{
+		trace.StringAttribute("args", `{"input":{"name":"joe","password":"[REDACTED]"}}`),
+	}, RedactedArgs("password")(&graphql.FieldContext{Args: args}))
+}
+
+func TestRedactedQuery(t *testing.T) {
+	query := `mutation login($password: String = "défaut") {
+  login(name: "joé", password: "secret", input: {password: """block""", hints: [1, 2]}, other: $password) {
+    token @include(if: true)
+  }
+}`
+	oc := graphql.GetOperationContext(gqltesting.Operation(query).Context(context.Background()))
+	assert.Equal(t, []trace.Attribute{trace.StringAttribute("query", `mutation login($password: String = "[REDACTED]") {
+  login(name: "joé", password: "[REDACTED]", input: {password: "[REDACTED]", hints: ["[REDACTED]", "[REDACTED]"]}, other: $password) {
+    token @include(if: true)
+  }
+}`)}, RedactedQuery("password", "hints")(oc))
+
+	assert.Empty(t, RedactedQuery("password")(&graphql.OperationContext{RawQuery: query}), "unparsed queries are left out")
+}
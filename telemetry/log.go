@@ -0,0 +1,108 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/errclass"
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlcomplexity"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+)
+
+// logger writes a canonical log line per operation, in logfmt, with a level set by the class of the worst error:
+//
+//	level=error host=pod-1 operation=todos type=query fingerprint=9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 duration_ms=12.5 slow=true errors=1 error_class=server cost=3 trace_id=4bf92f3577b34da6a3ce929d0e0e4736 gql.client=a1b2
+type logger struct {
+	mu       sync.Mutex
+	w        io.Writer
+	config   gqlinstrument.Config
+	settings *gqlinstrument.Settings
+	// fingerprint adds the hash of the fingerprint of operations
+	fingerprint bool
+	classifier  errclass.Classifier
+	now         func() time.Time
+}
+
+func newLogger(w io.Writer, config gqlinstrument.Config, settings *gqlinstrument.Settings) *logger {
+	return &logger{w: w, config: config, settings: settings, now: func() time.Time {
+		return graphql.Now()
+	}}
+}
+
+func (l *logger) intercept(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+
+	rc := graphql.GetOperationContext(ctx)
+	var errs gqlerror.List
+	if resp != nil {
+		errs = resp.Errors
+	}
+	classification, failed := errclass.Worst(l.classifier, errs)
+
+	var b strings.Builder
+	level := "info"
+	if failed {
+		level = classification.Class.Severity()
+	}
+	field(&b, "level", level)
+	field(&b, "host", l.config.Host)
+	field(&b, "operation", fingerprint.OperationName(rc))
+	if rc.Operation != nil {
+		field(&b, "type", string(rc.Operation.Operation))
+	}
+	if l.fingerprint {
+		if hash := fingerprint.Of(rc).Hash; hash != "" {
+			field(&b, "fingerprint", hash)
+		}
+	}
+	if start := rc.Stats.OperationStart; !start.IsZero() {
+		duration := l.now().Sub(start)
+		field(&b, "duration_ms", strconv.FormatFloat(float64(duration)/float64(time.Millisecond), 'f', -1, 64))
+		if l.settings != nil && l.settings.Slow(duration) {
+			field(&b, "slow", "true")
+		}
+	}
+	field(&b, "errors", strconv.Itoa(len(errs)))
+	if failed {
+		field(&b, "error_class", string(classification.Class))
+	}
+	if cs := gqlcomplexity.GetOperationStats(rc); cs != nil {
+		field(&b, "cost", strconv.Itoa(cs.Cost))
+	}
+	if span := trace.FromContext(ctx); span != nil {
+		field(&b, "trace_id", span.SpanContext().TraceID.String())
+	}
+	for _, t := range l.config.TagValues(ctx) {
+		field(&b, t.Key.Name(), t.Value)
+	}
+	b.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, b.String())
+	return resp
+}
+
+// field appends a key=value pair, quoting values with spaces or quotes
+func field(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if value == "" || strings.ContainsAny(value, " =\"") {
+		fmt.Fprintf(b, "%q", value)
+		return
+	}
+	b.WriteString(value)
+}
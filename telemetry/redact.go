@@ -0,0 +1,176 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+)
+
+// Redacted replaces the values of redacted variables
+const Redacted = "[REDACTED]"
+
+// RedactedVariables adds the variables of operations to their spans, like gqlopencensus.WithVariables, with the
+// values of some variables redacted, e.g. "password". Variables are redacted at any depth of input objects.
+func RedactedVariables(names ...string) gqlopencensus.OperationAttributer {
+	redacted := redactedNames(names)
+	return func(oc *graphql.OperationContext) []trace.Attribute {
+		variables, _ := json.Marshal(redact(oc.Variables, redacted))
+		return []trace.Attribute{
+			trace.StringAttribute("variables", string(variables)),
+		}
+	}
+}
+
+// RedactedArgs adds the args of fields to their spans, like gqlopencensus.WithArgs, with the values of some args
+// redacted, e.g. "password". Args are redacted at any depth of input objects.
+func RedactedArgs(names ...string) gqlopencensus.FieldAttributer {
+	redacted := redactedNames(names)
+	return func(fc *graphql.FieldContext) []trace.Attribute {
+		args, _ := json.Marshal(redact(fc.Args, redacted))
+		return []trace.Attribute{
+			trace.StringAttribute("args", string(args)),
+		}
+	}
+}
+
+// RedactedQuery adds the query of operations to their spans, like gqlopencensus.WithRawQuery, with the literal
+// values of some arguments redacted, e.g. "password". Literals are redacted at any depth of input objects, as well as
+// the default values of the variables with these names.
+func RedactedQuery(names ...string) gqlopencensus.OperationAttributer {
+	redacted := redactedNames(names)
+	return func(oc *graphql.OperationContext) []trace.Attribute {
+		if oc.Doc == nil {
+			// the query is not parsed: literals cannot be told apart
+			return nil
+		}
+		return []trace.Attribute{
+			trace.StringAttribute("query", redactQuery(oc.RawQuery, oc.Doc, redacted)),
+		}
+	}
+}
+
+func redactedNames(names []string) map[string]bool {
+	redacted := make(map[string]bool, len(names))
+	for _, name := range names {
+		redacted[name] = true
+	}
+	return redacted
+}
+
+func redact(value interface{}, redacted map[string]bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			if redacted[k] {
+				copied[k] = Redacted
+				continue
+			}
+			copied[k] = redact(v, redacted)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, v := range value {
+			copied[i] = redact(v, redacted)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+// redactQuery replaces the redacted literals of a query, located by their position in the parsed document
+func redactQuery(query string, doc *ast.QueryDocument, redacted map[string]bool) string {
+	r := literals{redacted: redacted}
+	for _, op := range doc.Operations {
+		for _, def := range op.VariableDefinitions {
+			r.value(def.Variable, def.DefaultValue, false)
+		}
+		r.directives(op.Directives)
+		r.selectionSet(op.SelectionSet)
+	}
+	for _, fragment := range doc.Fragments {
+		r.directives(fragment.Directives)
+		r.selectionSet(fragment.SelectionSet)
+	}
+	if len(r.positions) == 0 {
+		return query
+	}
+
+	// positions are counted in runes
+	sort.Slice(r.positions, func(i, j int) bool { return r.positions[i].Start < r.positions[j].Start })
+	runes := []rune(query)
+	var b strings.Builder
+	last := 0
+	for _, pos := range r.positions {
+		if pos.Start < last || pos.End > len(runes) {
+			continue
+		}
+		b.WriteString(string(runes[last:pos.Start]))
+		b.WriteString(`"` + Redacted + `"`)
+		last = pos.End
+	}
+	b.WriteString(string(runes[last:]))
+	return b.String()
+}
+
+// literals collects the positions of the redacted literals of a query
+type literals struct {
+	redacted  map[string]bool
+	positions []ast.Position
+}
+
+func (r *literals) selectionSet(set ast.SelectionSet) {
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			r.arguments(selection.Arguments)
+			r.directives(selection.Directives)
+			r.selectionSet(selection.SelectionSet)
+		case *ast.InlineFragment:
+			r.directives(selection.Directives)
+			r.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			r.directives(selection.Directives)
+		}
+	}
+}
+
+func (r *literals) directives(directives ast.DirectiveList) {
+	for _, directive := range directives {
+		r.arguments(directive.Arguments)
+	}
+}
+
+func (r *literals) arguments(args ast.ArgumentList) {
+	for _, arg := range args {
+		r.value(arg.Name, arg.Value, false)
+	}
+}
+
+// value collects the literals of a named value, redacted by name or within a redacted input object or list
+func (r *literals) value(name string, v *ast.Value, redacted bool) {
+	if v == nil {
+		return
+	}
+	redacted = redacted || r.redacted[name]
+	switch v.Kind {
+	case ast.Variable:
+	case ast.ListValue, ast.ObjectValue:
+		// the position of lists and objects is their opening token only
+		for _, child := range v.Children {
+			r.value(child.Name, child.Value, redacted)
+		}
+	default:
+		if redacted && v.Position != nil {
+			r.positions = append(r.positions, *v.Position)
+		}
+	}
+}
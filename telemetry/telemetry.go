@@ -0,0 +1,291 @@
+// Package telemetry wires opencensus tracing, opencensus metrics and canonical log lines in a single extension,
+// with the same host, operation names and tags for all of them:
+//
+//	tel, err := telemetry.New(telemetry.Config{
+//		Tracing: true,
+//		Metrics: true,
+//		Log:     os.Stderr,
+//		Tags:    []telemetry.Tag{{Key: clientinfo.TagClientID, Value: clientinfo.ClientID}},
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer tel.Shutdown()
+//	tel.Use(srv)
+package telemetry
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/99designs/gqlgen-contrib/errclass"
+	"github.com/99designs/gqlgen-contrib/gqlarmor"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+)
+
+const extensionName = "Telemetry"
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Telemetry{}
+
+type (
+	// Config of the telemetry. Tracing, metrics and logging are each disabled by default.
+	Config struct {
+		// Host tags spans, metrics and log lines. By default this is the OS hostname
+		Host string
+
+		// Fingerprint identifies operations by the hash of their fingerprint: in spans, which are named after the
+		// fingerprint for anonymous operations, in metrics tagged with metrics.TagFingerprint, and in log lines
+		Fingerprint bool
+
+		// Classifier classifies errors, for the status of spans, the error counts of metrics and the level of log
+		// lines. The default is errclass.Default().
+		Classifier errclass.Classifier
+
+		// Tracing enables an opencensus tracer, with some extra TracerOptions
+		Tracing       bool
+		TracerOptions []gqlopencensus.Option
+
+		// Metrics enables an opencensus metrics collector, with some extra MetricsOptions.
+		// Metrics views are registered with the keys of Tags.
+		Metrics        bool
+		MetricsOptions []metrics.Option
+
+		// Views of the metrics to register. By default, all the metrics.GQLViews are registered.
+		Views []*view.View
+
+		// ViewNames maps the default names of views to their exported names, as metrics.WithViewNames does
+		ViewNames map[string]string
+
+		// Traces receives a line per ended span, when set
+		Traces io.Writer
+
+		// Log receives a canonical log line per operation
+		Log io.Writer
+
+		// Tags are extracted from requests, and added to spans, metrics and log lines
+		Tags []Tag
+
+		// Common options of the tracer and metrics collector, e.g. gqlinstrument.SkipIntrospection()
+		Common []gqlinstrument.Option
+
+		// Settings tuned at runtime: the sampling of traces and fields, and the slow operations of log lines, when set
+		Settings *gqlinstrument.Settings
+
+		// Limits protect the server with an armor extension, used before tracing and metrics, when set
+		Limits *gqlarmor.Config
+
+		// closers of the files opened by LoadConfig
+		closers []io.Closer
+	}
+
+	// Tag extracts the value of a tag from the context of a request, e.g. the client name. Empty values are skipped.
+	Tag = gqlinstrument.Tag
+
+	// Telemetry is a gqlgen extension composing tracing, metrics and logging
+	Telemetry struct {
+		config    Config
+		armor     *gqlarmor.Armor
+		tracer    *gqlopencensus.Tracer
+		printer   *gqlopencensus.SpanPrinter
+		collector *metrics.Collector
+		logger    *logger
+
+		// interceptResponse chains the response interceptors of tracing, metrics and logging
+		interceptResponse interceptor
+	}
+
+	interceptor func(context.Context, graphql.ResponseHandler) *graphql.Response
+)
+
+// New telemetry extension. Metrics views are registered when metrics are enabled.
+func New(cfg Config) (*Telemetry, error) {
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+
+	common := append([]gqlinstrument.Option{gqlinstrument.Host(cfg.Host), gqlinstrument.Tags(cfg.Tags...)}, cfg.Common...)
+	var tracerOptions []gqlopencensus.Option
+	if cfg.Settings != nil {
+		common = append(common, gqlinstrument.WithSampler(cfg.Settings.FieldSampler()))
+		tracerOptions = append(tracerOptions, gqlopencensus.WithSampler(cfg.Settings.TraceSampler()))
+	}
+	instrument := gqlinstrument.Default()
+	for _, apply := range common {
+		apply(&instrument)
+	}
+
+	t := &Telemetry{config: cfg}
+	if cfg.Tracing {
+		if cfg.Fingerprint {
+			tracerOptions = append(tracerOptions, gqlopencensus.WithFingerprint())
+		}
+		if cfg.Classifier != nil {
+			tracerOptions = append(tracerOptions, gqlopencensus.WithErrorClassifier(cfg.Classifier))
+		}
+		opts := append(append([]gqlopencensus.Option{gqlopencensus.Common(common...)}, tracerOptions...), cfg.TracerOptions...)
+		t.tracer = gqlopencensus.New(opts...)
+	}
+
+	if cfg.Metrics {
+		keys := make([]tag.Key, 0, len(instrument.Tags))
+		for _, tg := range instrument.Tags {
+			keys = append(keys, tg.Key)
+		}
+		if cfg.Fingerprint {
+			keys = append(keys, metrics.TagFingerprint)
+		}
+		views := cfg.Views
+		if views == nil {
+			views = metrics.GQLViews
+		}
+		if cfg.ViewNames != nil {
+			views = metrics.WithViewNames(cfg.ViewNames, views...)
+		}
+		if err := metrics.RegisterViews(views, keys...); err != nil {
+			return nil, err
+		}
+		opts := []metrics.Option{metrics.Common(common...)}
+		if cfg.Fingerprint {
+			opts = append(opts, metrics.Fingerprint())
+		}
+		if cfg.Classifier != nil {
+			opts = append(opts, metrics.ErrorClassifier(cfg.Classifier))
+		}
+		opts = append(opts, cfg.MetricsOptions...)
+		t.collector = metrics.New(opts...)
+	}
+
+	if cfg.Traces != nil {
+		t.printer = gqlopencensus.NewSpanPrinter(cfg.Traces)
+		trace.RegisterExporter(t.printer)
+	}
+	if cfg.Limits != nil {
+		t.armor = gqlarmor.New(*cfg.Limits)
+	}
+	if cfg.Log != nil {
+		t.logger = newLogger(cfg.Log, instrument, cfg.Settings)
+		t.logger.fingerprint = cfg.Fingerprint
+		t.logger.classifier = cfg.Classifier
+	}
+
+	interceptors := make([]interceptor, 0, 3)
+	if t.tracer != nil {
+		interceptors = append(interceptors, t.tracer.InterceptResponse)
+	}
+	if t.collector != nil {
+		interceptors = append(interceptors, t.collector.InterceptResponse)
+	}
+	if t.logger != nil {
+		interceptors = append(interceptors, t.logger.intercept)
+	}
+	t.interceptResponse = chain(interceptors)
+	return t, nil
+}
+
+// Use the telemetry extension on a server, after the armor extension when limits are set
+func (t *Telemetry) Use(srv *handler.Server) {
+	for _, ext := range t.Extensions() {
+		srv.Use(ext)
+	}
+}
+
+// Extensions to use: the armor extension when limits are set, then the telemetry extension
+func (t *Telemetry) Extensions() []graphql.HandlerExtension {
+	if t.armor != nil {
+		return []graphql.HandlerExtension{t.armor, t}
+	}
+	return []graphql.HandlerExtension{t}
+}
+
+// Shutdown flushes the pending metrics, stops tracing and collecting metrics, and closes the files opened by
+// LoadConfig
+func (t *Telemetry) Shutdown() error {
+	var err error
+	if t.tracer != nil {
+		t.tracer.SetEnabled(false)
+	}
+	if t.printer != nil {
+		trace.UnregisterExporter(t.printer)
+	}
+	if t.collector != nil {
+		t.collector.SetEnabled(false)
+		err = t.collector.Close()
+	}
+	for _, c := range t.config.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// ExtensionName yields the extension name: "Telemetry"
+func (t *Telemetry) ExtensionName() string {
+	return extensionName
+}
+
+// Validate this extension. It computes the hash of the schema, with the gqlinstrument.SchemaHash option.
+func (t *Telemetry) Validate(schema graphql.ExecutableSchema) error {
+	if t.tracer != nil {
+		if err := t.tracer.Validate(schema); err != nil {
+			return err
+		}
+	}
+	if t.collector != nil {
+		if err := t.collector.Validate(schema); err != nil {
+			return err
+		}
+	}
+	if t.logger != nil {
+		return t.logger.config.Validate(schema)
+	}
+	return nil
+}
+
+// InterceptResponse traces, measures and logs the operation
+func (t *Telemetry) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	return t.interceptResponse(ctx, next)
+}
+
+// InterceptField traces and measures fields
+func (t *Telemetry) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	if t.collector != nil {
+		inner := next
+		next = func(ctx context.Context) (interface{}, error) {
+			return t.collector.InterceptField(ctx, inner)
+		}
+	}
+	if t.tracer != nil {
+		return t.tracer.InterceptField(ctx, next)
+	}
+	return next(ctx)
+}
+
+// chain response interceptors into one, the first one being the outermost
+func chain(interceptors []interceptor) interceptor {
+	chained := func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		return next(ctx)
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		outer, inner := interceptors[i], chained
+		chained = func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+			return outer(ctx, func(ctx context.Context) *graphql.Response {
+				return inner(ctx, next)
+			})
+		}
+	}
+	return chained
+}
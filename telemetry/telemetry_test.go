@@ -0,0 +1,208 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/99designs/gqlgen-contrib/fingerprint"
+	"github.com/99designs/gqlgen-contrib/gqlinstrument"
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus/tracetest"
+	"github.com/99designs/gqlgen-contrib/gqltesting"
+	"github.com/99designs/gqlgen-contrib/internal/testschema"
+)
+
+func TestTelemetry(t *testing.T) {
+	recorder := tracetest.Record()
+	defer recorder.Stop()
+
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	tenant := tag.MustNewKey("gql.tenant")
+	var log bytes.Buffer
+	tel, err := New(Config{
+		Host:           "telemetry",
+		Tracing:        true,
+		Metrics:        true,
+		MetricsOptions: []metrics.Option{metrics.Clock(clock.Now)},
+		Log:            &log,
+		Tags: []Tag{
+			{Key: tenant, Value: func(context.Context) string { return "acme" }},
+			{Key: tag.MustNewKey("gql.empty"), Value: func(context.Context) string { return "" }},
+		},
+	})
+	require.NoError(t, err)
+	defer metrics.Unregister()
+
+	ctx := gqltesting.Operation(`query todos { todos { text } }`).
+		Timings(clock.Now(), 0, 0, 0).
+		Context(context.Background())
+	tel.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fctx := gqltesting.Field("todos").Object("Query").Resolver().Context(ctx)
+		_, _ = tel.InterceptField(fctx, func(context.Context) (interface{}, error) {
+			clock.Advance(20 * time.Millisecond)
+			return nil, nil
+		})
+		return &graphql.Response{}
+	})
+
+	spans := recorder.WaitForSpans(t, 2)
+	tracetest.AssertTree(t, spans, `
+		todos
+		  todos
+	`)
+	operation := spans[len(spans)-1]
+	tracetest.AssertAttributes(t, operation, map[string]interface{}{
+		"host":       "telemetry",
+		"gql.tenant": "acme",
+	})
+	assert.NotContains(t, operation.Attributes, "gql.empty")
+
+	tags := map[tag.Key]string{metrics.TagHost: "telemetry", tenant: "acme"}
+	metricstest.AssertCount(t, metrics.OperationCountView, tags, 1)
+	metricstest.AssertDistributionSum(t, metrics.OperationLatencyView, tags, 20)
+
+	assert.Equal(t,
+		"level=info host=telemetry operation=todos type=query duration_ms=20 errors=0 trace_id="+
+			operation.TraceID.String()+" gql.tenant=acme\n",
+		log.String())
+
+	require.NoError(t, tel.Shutdown())
+}
+
+func TestDisabled(t *testing.T) {
+	tel, err := New(Config{})
+	require.NoError(t, err)
+
+	ctx := gqltesting.Operation(`{ todos { text } }`).Context(context.Background())
+	resp := tel.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		res, err := tel.InterceptField(gqltesting.Field("todos").Context(ctx), func(context.Context) (interface{}, error) {
+			return "ok", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", res)
+		return &graphql.Response{}
+	})
+	assert.NotNil(t, resp)
+	assert.NoError(t, tel.Shutdown())
+}
+
+func TestSettings(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	settings, err := gqlinstrument.NewSettings(gqlinstrument.DefaultValues())
+	require.NoError(t, err)
+	var log bytes.Buffer
+	tel, err := New(Config{Host: "settings", Log: &log, Settings: settings})
+	require.NoError(t, err)
+
+	query := func() {
+		ctx := gqltesting.Operation(`query todos { todos { text } }`).Timings(clock.Now(), 0, 0, 0).Context(context.Background())
+		tel.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+			clock.Advance(2 * time.Second)
+			return &graphql.Response{}
+		})
+	}
+
+	query()
+	_, err = settings.Update(func(v *gqlinstrument.Values) error {
+		v.SlowQuery = gqlinstrument.Duration(time.Second)
+		return nil
+	})
+	require.NoError(t, err)
+	query()
+
+	assert.Equal(t,
+		"level=info host=settings operation=todos type=query duration_ms=2000 errors=0\n"+
+			"level=info host=settings operation=todos type=query duration_ms=2000 slow=true errors=0\n",
+		log.String())
+}
+
+func TestFingerprint(t *testing.T) {
+	clock := gqltesting.NewClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	restore := clock.OverrideNow()
+	defer restore()
+
+	var log bytes.Buffer
+	tel, err := New(Config{Host: "fingerprint", Fingerprint: true, Log: &log})
+	require.NoError(t, err)
+
+	ctx := gqltesting.Operation(`{ todos(first: 10) { text } }`).
+		Timings(clock.Now(), 0, 0, 0).
+		Context(context.Background())
+	tel.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+
+	assert.Equal(t,
+		"level=info host=fingerprint operation=query type=query fingerprint="+fingerprint.Hash("query{todos(first:0){text}}")+" duration_ms=0 errors=0\n",
+		log.String())
+	require.NoError(t, tel.Shutdown())
+}
+
+func TestSchemaHash(t *testing.T) {
+	var log bytes.Buffer
+	tel, err := New(Config{Host: "schema", Log: &log, Common: []gqlinstrument.Option{gqlinstrument.SchemaHash()}})
+	require.NoError(t, err)
+
+	schema := testschema.New(`type Query { todos: [String] }`, nil)
+	require.NoError(t, tel.Validate(schema))
+	ctx := gqltesting.Operation(`{ todos }`).Context(context.Background())
+	tel.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{}
+	})
+
+	assert.Contains(t, log.String(), " gql.schema="+fingerprint.Schema(schema.Schema()).Short()+"\n")
+	require.NoError(t, tel.Shutdown())
+}
+
+func TestViewNames(t *testing.T) {
+	tel, err := New(Config{
+		Metrics:   true,
+		Views:     []*view.View{metrics.OperationCountView},
+		ViewNames: map[string]string{"gql/server/operation_count": "graphql_operations_total"},
+	})
+	require.NoError(t, err)
+	defer metrics.Unregister()
+
+	assert.NotNil(t, view.Find("graphql_operations_total"))
+	assert.Nil(t, view.Find(metrics.OperationCountView.Name))
+	require.NoError(t, tel.Shutdown())
+}
+
+func TestErrorLevel(t *testing.T) {
+	var log bytes.Buffer
+	tel, err := New(Config{Host: "errors", Log: &log})
+	require.NoError(t, err)
+
+	for _, err := range []error{context.Canceled, errors.New("boom")} {
+		err := err
+		tel.InterceptResponse(gqltesting.Operation(`{ todos }`).Context(context.Background()), func(context.Context) *graphql.Response {
+			return &graphql.Response{Errors: gqlerror.List{gqlerror.WrapPath(nil, err)}}
+		})
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "level=warn "))
+	assert.Contains(t, lines[0], " errors=1 error_class=canceled")
+	assert.True(t, strings.HasPrefix(lines[1], "level=error "))
+	assert.Contains(t, lines[1], " errors=1 error_class=server")
+	require.NoError(t, tel.Shutdown())
+}
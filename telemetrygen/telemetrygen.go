@@ -0,0 +1,196 @@
+// Package telemetrygen is a gqlgen plugin generating the coordinates of the fields of a schema as typed constants,
+// e.g. Query_User for "Query.user", and their typed telemetry helpers, e.g. Query.User.StartSpan(ctx) for spans named
+// "Query.user", see the coordinate package.
+//
+// Add the plugin to the code generation of gqlgen:
+//
+//	err := api.Generate(cfg, api.AddPlugin(telemetrygen.New("graph/telemetry/telemetry_gen.go")))
+//
+// or generate the file from schema files, e.g. with go:generate:
+//
+//	schema, err := gqlparser.LoadSchema(sources...)
+//	src, err := telemetrygen.Generate(schema, "telemetry")
+package telemetrygen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/99designs/gqlgen/plugin"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var _ interface {
+	// build time safeguards
+	plugin.Plugin
+	plugin.CodeGenerator
+} = &Plugin{}
+
+type (
+	// Plugin generating the coordinates of fields, once gqlgen has generated its code
+	Plugin struct {
+		config
+	}
+
+	// Option for the plugin
+	Option func(*config)
+
+	config struct {
+		filename string
+		pkg      string
+	}
+)
+
+// Package sets the name of the generated package. By default, it is the name of the directory of the file.
+func Package(name string) Option {
+	return func(c *config) {
+		c.pkg = name
+	}
+}
+
+// New plugin generating the coordinates of fields into a file
+func New(filename string, opts ...Option) *Plugin {
+	p := &Plugin{config: config{filename: filename, pkg: filepath.Base(filepath.Dir(filename))}}
+	for _, apply := range opts {
+		apply(&p.config)
+	}
+	return p
+}
+
+// Name of the plugin, implements plugin.Plugin
+func (p *Plugin) Name() string {
+	return "telemetrygen"
+}
+
+// GenerateCode generates the file from the schema of gqlgen, and implements plugin.CodeGenerator: errors fail the
+// code generation of gqlgen.
+func (p *Plugin) GenerateCode(data *codegen.Data) error {
+	src, err := Generate(data.Schema, p.pkg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p.filename), 0755); err != nil {
+		return fmt.Errorf("telemetrygen: %w", err)
+	}
+	if err := ioutil.WriteFile(p.filename, src, 0644); err != nil {
+		return fmt.Errorf("telemetrygen: %w", err)
+	}
+	return nil
+}
+
+type (
+	typeData struct {
+		Name   string
+		Fields []fieldData
+	}
+
+	fieldData struct {
+		Ident      string
+		Name       string
+		Coordinate string
+	}
+)
+
+// reserved identifiers of the generated package, which types cannot be named after
+var reserved = map[string]bool{"Fields": true, "coordinate": true}
+
+// Generate the source of a package declaring the coordinates of the fields of the object types of a schema, and a
+// variable of telemetry helpers per type, in alphabetical order of types, then in schema order of fields
+func Generate(schema *ast.Schema, pkg string) ([]byte, error) {
+	names := make([]string, 0, len(schema.Types))
+	for name, def := range schema.Types {
+		if def.Kind == ast.Object && !def.BuiltIn && !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	idents := map[string]string{}
+	types := make([]typeData, 0, len(names))
+	for _, name := range names {
+		if reserved[name] || token.IsKeyword(name) {
+			return nil, fmt.Errorf("telemetrygen: type %s clashes with the generated package", name)
+		}
+		idents[name] = name
+	}
+	for _, name := range names {
+		t := typeData{Name: name}
+		for _, field := range schema.Types[name].Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			coordinate := name + "." + field.Name
+			ident := name + "_" + exported(field.Name)
+			if other, ok := idents[ident]; ok {
+				return nil, fmt.Errorf("telemetrygen: %s and %s are both named %s", other, coordinate, ident)
+			}
+			idents[ident] = coordinate
+			t.Fields = append(t.Fields, fieldData{Ident: ident, Name: exported(field.Name), Coordinate: coordinate})
+		}
+		if len(t.Fields) > 0 {
+			types = append(types, t)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		Package string
+		Types   []typeData
+	}{Package: pkg, Types: types}); err != nil {
+		return nil, fmt.Errorf("telemetrygen: %w", err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("telemetrygen: could not format the generated source: %w", err)
+	}
+	return src, nil
+}
+
+// exported capitalizes the first letter of a name
+func exported(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+var tmpl = template.Must(template.New("telemetry").Parse(`// Code generated by github.com/99designs/gqlgen-contrib/telemetrygen, DO NOT EDIT.
+
+package {{ .Package }}
+
+import "github.com/99designs/gqlgen-contrib/coordinate"
+{{ range .Types }}
+// Fields of the {{ .Name }} type
+const (
+{{- range .Fields }}
+	{{ .Ident }} coordinate.Field = {{ printf "%q" .Coordinate }}
+{{- end }}
+)
+
+// {{ .Name }} holds the telemetry helpers of the fields of the {{ .Name }} type: tags and span names
+var {{ .Name }} = struct {
+{{- range .Fields }}
+	{{ .Name }} coordinate.Telemetry
+{{- end }}
+}{
+{{- range .Fields }}
+	{{ .Name }}: coordinate.NewTelemetry({{ .Ident }}),
+{{- end }}
+}
+{{ end }}
+// Fields of the schema
+var Fields = []coordinate.Field{
+{{- range .Types }}{{ range .Fields }}
+	{{ .Ident }},
+{{- end }}{{ end }}
+}
+`))
@@ -0,0 +1,120 @@
+package telemetrygen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/codegen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const sdl = `
+type Query { user(id: ID!): User, users: [User!]! }
+type User { id: ID!, orders: [Order!]! }
+type Order { id: ID!, _total: Float }
+input Filter { id: ID }
+interface Node { id: ID! }
+`
+
+const expected = `// Code generated by github.com/99designs/gqlgen-contrib/telemetrygen, DO NOT EDIT.
+
+package telemetry
+
+import "github.com/99designs/gqlgen-contrib/coordinate"
+
+// Fields of the Order type
+const (
+	Order_Id     coordinate.Field = "Order.id"
+	Order__total coordinate.Field = "Order._total"
+)
+
+// Order holds the telemetry helpers of the fields of the Order type: tags and span names
+var Order = struct {
+	Id     coordinate.Telemetry
+	_total coordinate.Telemetry
+}{
+	Id:     coordinate.NewTelemetry(Order_Id),
+	_total: coordinate.NewTelemetry(Order__total),
+}
+
+// Fields of the Query type
+const (
+	Query_User  coordinate.Field = "Query.user"
+	Query_Users coordinate.Field = "Query.users"
+)
+
+// Query holds the telemetry helpers of the fields of the Query type: tags and span names
+var Query = struct {
+	User  coordinate.Telemetry
+	Users coordinate.Telemetry
+}{
+	User:  coordinate.NewTelemetry(Query_User),
+	Users: coordinate.NewTelemetry(Query_Users),
+}
+
+// Fields of the User type
+const (
+	User_Id     coordinate.Field = "User.id"
+	User_Orders coordinate.Field = "User.orders"
+)
+
+// User holds the telemetry helpers of the fields of the User type: tags and span names
+var User = struct {
+	Id     coordinate.Telemetry
+	Orders coordinate.Telemetry
+}{
+	Id:     coordinate.NewTelemetry(User_Id),
+	Orders: coordinate.NewTelemetry(User_Orders),
+}
+
+// Fields of the schema
+var Fields = []coordinate.Field{
+	Order_Id,
+	Order__total,
+	Query_User,
+	Query_Users,
+	User_Id,
+	User_Orders,
+}
+`
+
+func TestGenerate(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: sdl})
+
+	src, err := Generate(schema, "telemetry")
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(src))
+
+	schema = gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { user: ID, User: ID }`})
+	_, err = Generate(schema, "telemetry")
+	assert.EqualError(t, err, "telemetrygen: Query.user and Query.User are both named Query_User")
+
+	schema = gqlparser.MustLoadSchema(&ast.Source{Input: `type Query { fields: Fields } type Fields { id: ID }`})
+	_, err = Generate(schema, "telemetry")
+	assert.EqualError(t, err, "telemetrygen: type Fields clashes with the generated package")
+}
+
+func TestPlugin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telemetrygen")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "telemetry", "telemetry_gen.go")
+	p := New(filename)
+	assert.Equal(t, "telemetrygen", p.Name())
+	data := &codegen.Data{Schema: gqlparser.MustLoadSchema(&ast.Source{Input: sdl})}
+	require.NoError(t, p.GenerateCode(data))
+
+	src, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(src))
+
+	// errors fail the code generation of gqlgen
+	err = New(filepath.Join(filename, "telemetry_gen.go")).GenerateCode(data)
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+// Package throttle standardizes the outcomes of the limiters of GraphQL operations, so that clients and dashboards
+// share one throttling picture across the gqlratelimit, gqlquota and gqlconcurrency extensions:
+//
+//   - the decision of each limiter on each operation is counted: allowed, limited, or queued before execution;
+//   - the time spent by queued operations is recorded in a distribution;
+//   - rejected operations carry the number of seconds to wait before retrying in the "retryAfter" extension of their
+//     error, and in the Retry-After header when the httpheader middleware is installed.
+//
+// Register the views to record the decisions of all limiters:
+//
+//	throttle.RegisterViews()
+package throttle
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+)
+
+// Decisions of limiters, as TagDecision values
+const (
+	// Allowed operations are executed without waiting
+	Allowed = "allowed"
+	// Limited operations are rejected
+	Limited = "limited"
+	// Queued operations are executed after waiting
+	Queued = "queued"
+)
+
+// RegisterViews registers the opencensus views of limiter decisions.
+//
+// Recording is a noop until views are registered.
+func RegisterViews() error {
+	return view.Register(ThrottleViews...)
+}
+
+// UnregisterViews unregisters the opencensus views of limiter decisions
+func UnregisterViews() {
+	view.Unregister(ThrottleViews...)
+}
+
+var (
+	// ThrottleViews contains all opencensus stats views declared for limiters
+	ThrottleViews = []*view.View{
+		DecisionCountView,
+		WaitTimeView,
+	}
+
+	// measurements
+
+	// Decisions tracks a count of the decisions of limiters on operations
+	Decisions = stats.Int64(
+		"gql/throttle/decision_count",
+		"Number of GraphQL operations allowed, limited or queued by limiters",
+		stats.UnitDimensionless)
+
+	// WaitTime tracks the time spent by queued operations before their execution, in milliseconds
+	WaitTime = stats.Float64(
+		"gql/throttle/wait_time",
+		"Time spent by GraphQL operations queued by limiters",
+		stats.UnitMilliseconds)
+
+	// views
+
+	// DecisionCountView reports a count of decisions, by limiter, decision and operation name
+	DecisionCountView = &view.View{
+		Name:        "gql/throttle/decision_count",
+		Description: "Count of GraphQL operations allowed, limited or queued, by limiter, decision and operation",
+		Measure:     Decisions,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{TagLimiter, TagDecision, metrics.TagOperation},
+	}
+
+	// WaitTimeView reports a distribution of the time spent by queued operations, by limiter and operation name (in
+	// milliseconds)
+	WaitTimeView = &view.View{
+		Name:        "gql/throttle/wait_time",
+		Description: "Distribution of the time spent by GraphQL operations queued by limiters, by limiter and operation",
+		Measure:     WaitTime,
+		Aggregation: metrics.DefaultLatencyDistribution,
+		TagKeys:     []tag.Key{TagLimiter, metrics.TagOperation},
+	}
+
+	// TagLimiter is the extension name of the limiter deciding on an operation, e.g. "RateLimit", "Quota" or
+	// "ConcurrencyLimit"
+	TagLimiter = tag.MustNewKey("gql.throttle.limiter")
+
+	// TagDecision is the decision of a limiter: Allowed, Limited or Queued
+	TagDecision = tag.MustNewKey("gql.throttle.decision")
+)
+
+// Record the decision of a limiter on an operation
+func Record(ctx context.Context, limiter, operation, decision string) {
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{
+			tag.Upsert(TagLimiter, limiter),
+			tag.Upsert(TagDecision, decision),
+			tag.Upsert(metrics.TagOperation, operation),
+		},
+		Decisions.M(1),
+	)
+}
+
+// RecordWait records the decision of a limiter on an operation which waited: Queued, or Limited when it is rejected
+// after waiting
+func RecordWait(ctx context.Context, limiter, operation, decision string, wait time.Duration) {
+	Record(ctx, limiter, operation, decision)
+	_ = stats.RecordWithTags(ctx,
+		[]tag.Mutator{
+			tag.Upsert(TagLimiter, limiter),
+			tag.Upsert(metrics.TagOperation, operation),
+		},
+		WaitTime.M(float64(wait)/float64(time.Millisecond)),
+	)
+}
+
+// RetryAfter hints clients to retry a rejected operation after a delay: the number of seconds, rounded up, is set in
+// the "retryAfter" extension of the error, and in the Retry-After header when the httpheader middleware is installed.
+// It yields the number of seconds.
+func RetryAfter(ctx context.Context, err *gqlerror.Error, after time.Duration) int {
+	seconds := int(math.Ceil(after.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	if err.Extensions == nil {
+		err.Extensions = make(map[string]interface{}, 1)
+	}
+	err.Extensions["retryAfter"] = seconds
+	httpheader.Set(ctx, "Retry-After", strconv.Itoa(seconds))
+	return seconds
+}
@@ -0,0 +1,58 @@
+package throttle
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.opencensus.io/tag"
+
+	metrics "github.com/99designs/gqlgen-contrib/gqlopencensus-metrics"
+	"github.com/99designs/gqlgen-contrib/gqlopencensus-metrics/metricstest"
+	"github.com/99designs/gqlgen-contrib/httpheader"
+)
+
+func TestRecord(t *testing.T) {
+	require.NoError(t, RegisterViews())
+	defer UnregisterViews()
+
+	ctx := context.Background()
+	Record(ctx, "RateLimit", "todos", Allowed)
+	Record(ctx, "RateLimit", "todos", Allowed)
+	Record(ctx, "RateLimit", "todos", Limited)
+	RecordWait(ctx, "ConcurrencyLimit", "report", Queued, 40*time.Millisecond)
+	RecordWait(ctx, "ConcurrencyLimit", "report", Limited, 60*time.Millisecond)
+
+	decision := func(limiter, opName, decision string) map[tag.Key]string {
+		return map[tag.Key]string{TagLimiter: limiter, TagDecision: decision, metrics.TagOperation: opName}
+	}
+	metricstest.AssertCount(t, DecisionCountView, decision("RateLimit", "todos", Allowed), 2)
+	metricstest.AssertCount(t, DecisionCountView, decision("RateLimit", "todos", Limited), 1)
+	metricstest.AssertCount(t, DecisionCountView, decision("ConcurrencyLimit", "report", Queued), 1)
+	metricstest.AssertCount(t, DecisionCountView, decision("ConcurrencyLimit", "report", Limited), 1)
+	metricstest.AssertDistributionSum(t, WaitTimeView, map[tag.Key]string{TagLimiter: "ConcurrencyLimit"}, 100)
+}
+
+func TestRetryAfter(t *testing.T) {
+	var seconds int
+	var err *gqlerror.Error
+	h := httpheader.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = gqlerror.Errorf("rate limit exceeded")
+		seconds = RetryAfter(r.Context(), err, 1500*time.Millisecond)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", nil))
+
+	assert.Equal(t, 2, seconds, "seconds are rounded up")
+	assert.Equal(t, 2, err.Extensions["retryAfter"])
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	err = gqlerror.Errorf("quota exceeded")
+	assert.Equal(t, 1, RetryAfter(context.Background(), err, 0), "clients wait at least a second")
+	assert.Equal(t, 1, err.Extensions["retryAfter"])
+}